@@ -2,13 +2,13 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"log"
-	"net"
 	"net/http"
 	"os"
 	"os/signal"
-	"strings"
+	"path/filepath"
 	"syscall"
 	"time"
 
@@ -16,11 +16,15 @@ import (
 	"podmanview/internal/config"
 	"podmanview/internal/events"
 	"podmanview/internal/mqtt"
+	"podmanview/internal/netutil"
 	"podmanview/internal/podman"
 	"podmanview/internal/plugins"
+	"podmanview/internal/plugins/backup"
 	"podmanview/internal/plugins/demo"
+	"podmanview/internal/plugins/imageupdates"
 	"podmanview/internal/plugins/temperature"
 	"podmanview/internal/storage"
+	"podmanview/internal/tlscert"
 )
 
 const (
@@ -28,6 +32,9 @@ const (
 	pluginStartTimeout = 10 * time.Second
 	shutdownTimeout    = 10 * time.Second
 	pluginsDBFile      = "podmanview.db"
+	podmanHealthPeriod = 30 * time.Second
+	selfSignedCertFile = "podmanview.crt"
+	selfSignedKeyFile  = "podmanview.key"
 )
 
 // Version is set at build time via -ldflags "-X main.Version=vX.Y.Z"
@@ -61,18 +68,33 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to connect to Podman: %v", err)
 	}
+	client.SetTimeout(cfg.PodmanTimeout())
+
+	retryPolicy := podman.DefaultRetryPolicy
+	retryPolicy.MaxRetries = cfg.PodmanRetries()
+	client.SetRetryPolicy(retryPolicy)
 
 	// Test connection
 	if err := client.Ping(ctx); err != nil {
 		log.Fatalf("Failed to ping Podman: %v", err)
 	}
 
+	// Re-ping periodically and re-dial if Podman restarts
+	client.StartHealthMonitor(ctx, podmanHealthPeriod)
+
 	// Create event store
 	eventStore := events.NewStore(100)
+	if cfg.ReverseDNS() {
+		eventStore.SetResolver(events.NewHostResolver(1000)) // Cache up to 1000 IPs
+	}
 
 	// Create or open BoltDB storage for application data
 	// This stores: plugin configs, plugin data, command history, etc.
-	pluginStorage, err := storage.NewBoltStorage(pluginsDBFile)
+	dbFile, err := dataFile(cfg.DataDir(), pluginsDBFile)
+	if err != nil {
+		log.Fatalf("Failed to prepare data directory: %v", err)
+	}
+	pluginStorage, err := storage.NewBoltStorage(dbFile)
 	if err != nil {
 		log.Fatalf("Failed to create application storage: %v", err)
 	}
@@ -105,6 +127,32 @@ func main() {
 		}
 	}
 
+	// Check if image updates plugin exists in storage
+	_, err = pluginStorage.GetPluginConfig("image-updates")
+	if err == storage.ErrPluginNotFound {
+		// Disabled by default, it contacts the registries of running images
+		log.Printf("Initializing default configuration for image-updates plugin")
+		if err := pluginStorage.SetPluginConfig("image-updates", &storage.PluginConfig{
+			Enabled: false,
+			Name:    "Image Update Checker",
+		}); err != nil {
+			log.Printf("Warning: Failed to set default image-updates plugin config: %v", err)
+		}
+	}
+
+	// Check if backup plugin exists in storage
+	_, err = pluginStorage.GetPluginConfig("backup")
+	if err == storage.ErrPluginNotFound {
+		// Disabled by default, it writes archives to the file manager directory
+		log.Printf("Initializing default configuration for backup plugin")
+		if err := pluginStorage.SetPluginConfig("backup", &storage.PluginConfig{
+			Enabled: false,
+			Name:    "Volume Backups",
+		}); err != nil {
+			log.Printf("Warning: Failed to set default backup plugin config: %v", err)
+		}
+	}
+
 	// Initialize MQTT services if configured
 	var mqttClient *mqtt.Client
 	var mqttPublisher *mqtt.Publisher
@@ -120,6 +168,13 @@ func main() {
 			Password: cfg.MQTTPassword(),
 			Prefix:   cfg.MQTTPrefix(),
 			UseTLS:   cfg.MQTTUseTLS(),
+
+			KeepAlive:            cfg.MQTTKeepAlive(),
+			MaxReconnectInterval: cfg.MQTTReconnectMax(),
+
+			Hostname:   api.PodmanHostname(context.Background(), client),
+			DeviceID:   cfg.MQTTDeviceID(),
+			DeviceName: cfg.MQTTDeviceName(),
 		}
 
 		mqttClient, err = mqtt.New(mqttCfg, log.Default())
@@ -129,6 +184,8 @@ func main() {
 		} else {
 			mqttPublisher = mqtt.NewPublisher(mqttClient, log.Default())
 			mqttDiscovery = mqtt.NewDiscoveryManager(mqttClient, log.Default(), pluginStorage, "global")
+			mqtt.PublishSecurityEvents(eventStore, mqttClient, log.Default())
+			mqtt.RecordConnectionEvents(eventStore, mqttClient)
 			log.Printf("MQTT services initialized successfully")
 		}
 	}
@@ -146,6 +203,14 @@ func main() {
 		log.Fatalf("Failed to register temperature plugin: %v", err)
 	}
 
+	if err := pluginRegistry.Register(imageupdates.New()); err != nil {
+		log.Fatalf("Failed to register image-updates plugin: %v", err)
+	}
+
+	if err := pluginRegistry.Register(backup.New()); err != nil {
+		log.Fatalf("Failed to register backup plugin: %v", err)
+	}
+
 	log.Printf("Registered %d plugins", pluginRegistry.Count())
 
 	// Get enabled plugin names from storage
@@ -159,17 +224,22 @@ func main() {
 	enabledPlugins := pluginRegistry.EnabledByConfig(enabledPluginNames)
 	log.Printf("Found %d/%d enabled plugins", len(enabledPlugins), pluginRegistry.Count())
 
+	// Outbound HTTP client shared by plugins
+	pluginHTTPClient, err := plugins.NewHTTPClient(cfg.PluginHTTPTimeout(), cfg.HTTPProxy())
+	if err != nil {
+		log.Fatalf("Failed to create plugin HTTP client: %v", err)
+	}
+
 	// Initialize enabled plugins with timeout
 	pluginDeps := &plugins.PluginDependencies{
-		PodmanClient:  client,
-		Config:        cfg,
-		EventStore:    eventStore,
-		Logger:        log.Default(),
-		Storage:       pluginStorage,
-		MQTTClient:    mqttClient,
-		MQTTPublisher: mqttPublisher,
-		MQTTDiscovery: mqttDiscovery,
+		PodmanClient: client,
+		Config:       cfg,
+		EventStore:   eventStore,
+		Logger:       log.Default(),
+		Storage:      pluginStorage,
+		HTTPClient:   pluginHTTPClient,
 	}
+	pluginDeps.SetMQTT(mqttClient, mqttPublisher, mqttDiscovery)
 
 	// Set dependencies in registry
 	pluginRegistry.SetDependencies(pluginDeps)
@@ -177,7 +247,7 @@ func main() {
 	// Initialize plugins
 	for _, p := range enabledPlugins {
 		initCtx, cancel := context.WithTimeout(ctx, pluginInitTimeout)
-		if err := p.Init(initCtx, pluginDeps); err != nil {
+		if err := p.Init(initCtx, pluginRegistry.PluginDeps(p.Name())); err != nil {
 			cancel()
 			log.Fatalf("Failed to initialize plugin %s: %v", p.Name(), err)
 		}
@@ -211,6 +281,15 @@ func main() {
 	allPlugins := pluginRegistry.All()
 	server := api.NewServerWithPlugins(client, cfg, Version, staticVersion, allPlugins, pluginRegistry, pluginStorage)
 
+	// Sample CPU usage in the background for host stats
+	api.StartHostSampler(ctx, cfg)
+
+	// Stream container stats into a cache shared by all clients
+	server.StartStatsCache(ctx)
+
+	// Keep recent container resource usage for graphs
+	server.StartStatsHistory(ctx)
+
 	// Start server
 	addr := cfg.Addr()
 	fmt.Printf("PodmanView starting on %s\n", addr)
@@ -219,36 +298,115 @@ func main() {
 		fmt.Println("WARNING: Authentication is DISABLED!")
 	}
 
+	// Resolve TLS certificate (configured, or self-signed next to .env)
+	certFile, keyFile := cfg.TLSCert(), cfg.TLSKey()
+	if certFile == "" && cfg.TLSSelfSigned() {
+		dir := filepath.Dir(cfg.FilePath())
+		certFile = filepath.Join(dir, selfSignedCertFile)
+		keyFile = filepath.Join(dir, selfSignedKeyFile)
+
+		hosts := []string{"localhost", "127.0.0.1", "::1"}
+		if hostname, err := os.Hostname(); err == nil {
+			hosts = append([]string{hostname}, hosts...)
+		}
+		hosts = append(hosts, netutil.LocalIPs()...)
+
+		created, err := tlscert.EnsureSelfSigned(certFile, keyFile, hosts)
+		if err != nil {
+			log.Fatalf("Failed to create self-signed certificate: %v", err)
+		}
+		if created {
+			log.Printf("Generated self-signed TLS certificate: %s", certFile)
+		}
+	}
+	useTLS := certFile != ""
+
 	// Print access URLs
-	port := addr
-	if strings.HasPrefix(port, ":") {
-		port = port[1:]
-	} else if idx := strings.LastIndex(port, ":"); idx != -1 {
-		port = port[idx+1:]
+	scheme := "http"
+	if useTLS {
+		scheme = "https"
+	}
+	if path, ok := netutil.UnixSocketPath(addr); ok {
+		fmt.Printf("\nListening on Unix socket %s (%s behind a reverse proxy)\n\n", path, scheme)
+	} else {
+		printAccessURLs(scheme, netutil.PortFromAddr(addr))
+	}
+
+	// Listen before starting so a busy address or stale socket fails startup
+	listener, err := netutil.Listen(addr, cfg.AddrMode())
+	if err != nil {
+		log.Fatalf("Failed to listen on %s: %v", addr, err)
 	}
-	printAccessURLs(port)
 
 	// Setup graceful shutdown
 	httpServer := &http.Server{
-		Addr:    addr,
-		Handler: server.Router(),
+		Addr:      addr,
+		Handler:   server.Router(),
+		TLSConfig: &tls.Config{MinVersion: tls.VersionTLS12},
 	}
 
 	// Channel to listen for interrupt signals
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
 
+	// Reload .env on SIGHUP (systemctl reload podmanview)
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			result, err := server.ReloadConfig("system", "")
+			if err != nil {
+				log.Printf("Configuration reload failed, keeping current settings: %v", err)
+				continue
+			}
+			log.Printf("Configuration reloaded, changed: %v", result.Changed)
+			if len(result.RestartRequired) > 0 {
+				log.Printf("Restart required to apply: %v", result.RestartRequired)
+			}
+			if result.Warning != "" {
+				log.Printf("Warning: %s", result.Warning)
+			}
+		}
+	}()
+
 	// Start HTTP server in goroutine
 	go func() {
-		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if useTLS {
+			err = httpServer.ServeTLS(listener, certFile, keyFile)
+		} else {
+			err = httpServer.Serve(listener)
+		}
+		if err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Server failed: %v", err)
 		}
 	}()
 
+	// Redirect plain HTTP to HTTPS
+	var redirectServer *http.Server
+	if redirectAddr := cfg.HTTPRedirectAddr(); useTLS && redirectAddr != "" {
+		redirectServer = &http.Server{
+			Addr:              redirectAddr,
+			Handler:           api.NewHTTPSRedirect(netutil.PortFromAddr(addr)),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		go func() {
+			if err := redirectServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Printf("HTTP redirect listener failed: %v", err)
+			}
+		}()
+		log.Printf("Redirecting HTTP on %s to HTTPS", redirectAddr)
+	}
+
 	log.Println("Server started. Press Ctrl+C to stop.")
 
-	// Wait for interrupt signal
-	<-stop
+	// Wait for interrupt signal or a restart from the API
+	restart := false
+	select {
+	case <-stop:
+	case <-server.RestartRequests():
+		restart = true
+	}
 
 	log.Println("Shutting down gracefully...")
 
@@ -260,11 +418,16 @@ func main() {
 	if err := httpServer.Shutdown(shutdownCtx); err != nil {
 		log.Printf("HTTP server shutdown error: %v", err)
 	}
+	if redirectServer != nil {
+		redirectServer.Shutdown(shutdownCtx)
+	}
 
-	// Stop all enabled plugins in reverse order
+	// Stop all enabled plugins in reverse order, each with its own
+	// timeout so a hanging plugin doesn't starve the rest
+	stopTimeout := cfg.PluginStopTimeout()
 	for i := len(enabledPlugins) - 1; i >= 0; i-- {
 		p := enabledPlugins[i]
-		if err := p.Stop(shutdownCtx); err != nil {
+		if err := plugins.StopWithTimeout(p, stopTimeout); err != nil {
 			log.Printf("Error stopping plugin %s: %v", p.Name(), err)
 		} else {
 			log.Printf("Stopped plugin: %s", p.Name())
@@ -272,60 +435,57 @@ func main() {
 	}
 
 	log.Println("Server stopped")
-}
-
-// getLocalIPs returns all local IP addresses
-func getLocalIPs() []string {
-	var ips []string
-
-	interfaces, err := net.Interfaces()
-	if err != nil {
-		return ips
-	}
 
-	for _, iface := range interfaces {
-		// Skip down or loopback interfaces
-		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
-			continue
-		}
-
-		addrs, err := iface.Addrs()
-		if err != nil {
-			continue
-		}
-
-		for _, addr := range addrs {
-			var ip net.IP
-			switch v := addr.(type) {
-			case *net.IPNet:
-				ip = v.IP
-			case *net.IPAddr:
-				ip = v.IP
-			}
-
-			// Skip loopback and IPv6
-			if ip == nil || ip.IsLoopback() || ip.To4() == nil {
-				continue
-			}
-
-			ips = append(ips, ip.String())
+	if restart {
+		// Release the database lock before the new process opens it
+		pluginStorage.Close()
+		if err := reexec(); err != nil {
+			log.Fatalf("Failed to restart: %v", err)
 		}
 	}
+}
 
-	return ips
+// reexec replaces the process with a fresh start of the same binary
+func reexec() error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	log.Printf("Restarting %s", exe)
+	return syscall.Exec(exe, os.Args, os.Environ())
 }
 
 // printAccessURLs prints all available access URLs
-func printAccessURLs(port string) {
-	ips := getLocalIPs()
+func printAccessURLs(scheme, port string) {
+	ips := netutil.LocalIPs()
 	if len(ips) == 0 {
-		fmt.Printf("\nOpen http://localhost:%s in your browser\n", port)
+		fmt.Printf("\nOpen %s://localhost:%s in your browser\n", scheme, port)
 		return
 	}
 
 	fmt.Println("\nAccess URLs:")
-	for _, ip := range ips {
-		fmt.Printf("  http://%s:%s\n", ip, port)
+	for _, url := range netutil.AccessURLs(scheme, ips, port) {
+		fmt.Printf("  %s\n", url)
 	}
 	fmt.Println()
 }
+
+// dataFile returns the path of name in the data directory, creating the
+// directory if needed. An empty dir means the working directory.
+func dataFile(dir, name string) (string, error) {
+	if dir == "" {
+		return name, nil
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, name)
+
+	// A database left in the working directory isn't picked up automatically
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if _, err := os.Stat(name); err == nil {
+			log.Printf("Warning: %s exists in the working directory but not in %s; move it there to keep plugin settings and history", name, dir)
+		}
+	}
+	return path, nil
+}
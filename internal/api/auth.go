@@ -1,31 +1,52 @@
 package api
 
 import (
-	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"podmanview/internal/auth"
+	"podmanview/internal/config"
 	"podmanview/internal/events"
 )
 
+// minPasswordLength is the shortest new password accepted; PAM modules
+// such as pam_pwquality may require more
+const minPasswordLength = 8
+
 // AuthHandler handles authentication endpoints
 type AuthHandler struct {
-	pamAuth      *auth.PAMAuth
-	jwtManager   *auth.JWTManager
-	wsTokenStore *auth.WSTokenStore
-	eventStore   *events.Store
-	rateLimiter  *auth.LoginRateLimiter
+	authenticator auth.Authenticator
+	oidc          *auth.OIDCProvider
+	jwtManager    *auth.JWTManager
+	wsTokenStore  *auth.WSTokenStore
+	eventStore    *events.Store
+	rateLimiter   *auth.LoginRateLimiter
+	config        *config.Config
 }
 
 // NewAuthHandler creates new auth handler
-func NewAuthHandler(pamAuth *auth.PAMAuth, jwtManager *auth.JWTManager, wsTokenStore *auth.WSTokenStore, eventStore *events.Store) *AuthHandler {
+func NewAuthHandler(authenticator auth.Authenticator, oidc *auth.OIDCProvider, jwtManager *auth.JWTManager, wsTokenStore *auth.WSTokenStore, eventStore *events.Store, cfg *config.Config) *AuthHandler {
+	rateLimiter := auth.NewLoginRateLimiter()
+	rateLimiter.SetLockoutHandler(func(ip string, attempts int) {
+		log.Printf("Login lockout: %s blocked after %d attempts", ip, attempts)
+		eventStore.AddWithMeta(events.EventLoginLockout, "", ip, false,
+			fmt.Sprintf("Blocked for %s after %d login attempts", rateLimiter.BlockTime(), attempts),
+			events.Meta{"attempts": strconv.Itoa(attempts)})
+	})
+
 	return &AuthHandler{
-		pamAuth:      pamAuth,
-		jwtManager:   jwtManager,
-		wsTokenStore: wsTokenStore,
-		eventStore:   eventStore,
-		rateLimiter:  auth.NewLoginRateLimiter(),
+		authenticator: authenticator,
+		oidc:          oidc,
+		jwtManager:    jwtManager,
+		wsTokenStore:  wsTokenStore,
+		eventStore:    eventStore,
+		rateLimiter:   rateLimiter,
+		config:        cfg,
 	}
 }
 
@@ -39,7 +60,6 @@ type LoginRequest struct {
 // LoginResponse represents login response
 type LoginResponse struct {
 	Success bool       `json:"success"`
-	Message string     `json:"message,omitempty"`
 	User    *auth.User `json:"user,omitempty"`
 }
 
@@ -49,37 +69,34 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 
 	// Check rate limit first - reject immediately without wasting resources
 	if allowed, _ := h.rateLimiter.Allow(clientIP); !allowed {
-		writeJSON(w, http.StatusTooManyRequests, LoginResponse{
-			Success: false,
-			Message: "Too many login attempts",
-		})
+		writeJSONError(w, http.StatusTooManyRequests, "too_many_attempts", "Too many login attempts")
 		return
 	}
 
 	var req LoginRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeJSON(w, http.StatusBadRequest, LoginResponse{
-			Success: false,
-			Message: "Invalid request body",
-		})
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
 	if req.Username == "" || req.Password == "" {
-		writeJSON(w, http.StatusBadRequest, LoginResponse{
-			Success: false,
-			Message: "Username and password are required",
-		})
+		writeJSONError(w, http.StatusBadRequest, "missing_field", "Username and password are required")
+		return
+	}
+
+	// The oidc: namespace belongs to single sign-on users, whose history
+	// and bookmarks a local account of that name would otherwise share
+	if strings.HasPrefix(req.Username, auth.OIDCUserPrefix) {
+		h.eventStore.Add(events.EventLoginFailed, req.Username, clientIP, false, "")
+		writeJSONError(w, http.StatusUnauthorized, "invalid_credentials", "Invalid username or password")
 		return
 	}
 
-	user, err := h.pamAuth.Authenticate(req.Username, req.Password)
+	user, err := h.authenticator.Authenticate(req.Username, req.Password)
 	if err != nil {
+		// Logged for the admin, e.g. an unreachable LDAP server; the client only learns it failed
+		logger(r.Context()).Printf("Login failed for %s: %v", req.Username, err)
 		h.eventStore.Add(events.EventLoginFailed, req.Username, clientIP, false, "")
-		writeJSON(w, http.StatusUnauthorized, LoginResponse{
-			Success: false,
-			Message: "Invalid username or password",
-		})
+		writeJSONError(w, http.StatusUnauthorized, "invalid_credentials", "Invalid username or password")
 		return
 	}
 
@@ -96,10 +113,7 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	// Generate JWT token
 	token, err := h.jwtManager.GenerateTokenWithDuration(user, tokenDuration)
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, LoginResponse{
-			Success: false,
-			Message: "Failed to generate token",
-		})
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to generate token")
 		return
 	}
 
@@ -115,6 +129,138 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// OIDCStatus handles GET /api/auth/oidc
+// Tells the login page whether to offer single sign-on
+func (h *AuthHandler) OIDCStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]bool{"enabled": h.oidc != nil})
+}
+
+// OIDCLogin handles GET /api/auth/oidc/login
+// Redirects the browser to the provider's login page
+func (h *AuthHandler) OIDCLogin(w http.ResponseWriter, r *http.Request) {
+	if h.oidc == nil {
+		writeJSONError(w, http.StatusNotFound, "oidc_disabled", "Single sign-on is not configured")
+		return
+	}
+
+	loginURL, err := h.oidc.LoginURL(w, r)
+	if err != nil {
+		logger(r.Context()).Printf("OIDC login failed: %v", err)
+		http.Redirect(w, r, "/?login_error=sso", http.StatusFound)
+		return
+	}
+	http.Redirect(w, r, loginURL, http.StatusFound)
+}
+
+// OIDCCallback handles GET /api/auth/oidc/callback
+// The provider redirects here after login; on success the user gets a
+// session cookie valid for PODMANVIEW_JWT_EXPIRATION and lands on the
+// dashboard.
+func (h *AuthHandler) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	if h.oidc == nil {
+		writeJSONError(w, http.StatusNotFound, "oidc_disabled", "Single sign-on is not configured")
+		return
+	}
+	clientIP := getClientIP(r)
+
+	user, err := h.oidc.Callback(w, r)
+	if err != nil {
+		logger(r.Context()).Printf("OIDC login failed: %v", err)
+		h.eventStore.Add(events.EventLoginFailed, "", clientIP, false, "oidc")
+		http.Redirect(w, r, "/?login_error=sso", http.StatusFound)
+		return
+	}
+
+	expiration := h.config.JWTExpiration()
+	token, err := h.jwtManager.GenerateTokenWithDuration(user, expiration)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to generate token")
+		return
+	}
+	auth.SetAuthCookie(w, r, token, int(expiration.Seconds()))
+
+	h.eventStore.Add(events.EventLogin, user.Username, clientIP, true, "oidc")
+	http.Redirect(w, r, "/", http.StatusFound)
+}
+
+// ChangePasswordRequest represents change password request body
+type ChangePasswordRequest struct {
+	Username        string `json:"username,omitempty"` // empty = the current user
+	CurrentPassword string `json:"currentPassword"`    // the current user's password, also when changing another user's
+	NewPassword     string `json:"newPassword"`
+}
+
+// ChangePassword handles POST /api/auth/change-password
+// Users change their own password; admins may change anyone's. The
+// caller's current password is verified first either way.
+func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	user := auth.GetUserFromContext(r.Context())
+	if user == nil {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized", "Not authenticated")
+		return
+	}
+	clientIP := getClientIP(r)
+
+	var req ChangePasswordRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	target := req.Username
+	if target == "" {
+		target = user.Username
+	}
+	if target != user.Username && !user.Can(auth.ActionManageUsers) {
+		writeJSONError(w, http.StatusForbidden, "forbidden", "Only admins can change other users' passwords")
+		return
+	}
+	if req.CurrentPassword == "" || req.NewPassword == "" {
+		writeJSONError(w, http.StatusBadRequest, "missing_field", "Current and new password are required")
+		return
+	}
+	if len(req.NewPassword) < minPasswordLength {
+		writeJSONError(w, http.StatusBadRequest, "password_too_short", fmt.Sprintf("New password must be at least %d characters", minPasswordLength))
+		return
+	}
+
+	changer, ok := h.authenticator.(auth.PasswordChanger)
+	if !ok {
+		writeJSONError(w, http.StatusNotImplemented, "not_supported", "Passwords can't be changed with this login backend")
+		return
+	}
+
+	// Guessing the current password here counts against the login limit
+	if allowed, _ := h.rateLimiter.Allow(clientIP); !allowed {
+		writeJSONError(w, http.StatusTooManyRequests, "too_many_attempts", "Too many attempts")
+		return
+	}
+	meta := events.Meta{"target": target}
+	if _, err := h.authenticator.Authenticate(user.Username, req.CurrentPassword); err != nil {
+		h.eventStore.AddWithMeta(events.EventPasswordChange, user.Username, clientIP, false, "Current password incorrect", meta)
+		writeJSONError(w, http.StatusForbidden, "invalid_credentials", "Current password is incorrect")
+		return
+	}
+	h.rateLimiter.Reset(clientIP)
+
+	// PAM asks for the target's current password only when not running as root
+	currentPassword := ""
+	if target == user.Username {
+		currentPassword = req.CurrentPassword
+	}
+	if err := changer.ChangePassword(target, currentPassword, req.NewPassword); err != nil {
+		h.eventStore.AddWithMeta(events.EventPasswordChange, user.Username, clientIP, false, "Failed to change password of "+target, meta)
+		if errors.Is(err, auth.ErrPasswordRejected) {
+			writeJSONError(w, http.StatusBadRequest, "password_rejected", err.Error())
+			return
+		}
+		logger(r.Context()).Printf("Password change for %s failed: %v", target, err)
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to change password")
+		return
+	}
+
+	h.eventStore.AddWithMeta(events.EventPasswordChange, user.Username, clientIP, true, "Changed password of "+target, meta)
+	writeJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
 // Logout handles POST /api/auth/logout
 func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 	user := auth.GetUserFromContext(r.Context())
@@ -124,6 +270,7 @@ func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 	}
 
 	auth.ClearAuthCookie(w)
+	h.wsTokenStore.RevokeSession(auth.GetSessionFromContext(r.Context()).ID)
 
 	// Log logout
 	h.eventStore.Add(events.EventLogout, username, getClientIP(r), true, "")
@@ -135,7 +282,7 @@ func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
 	user := auth.GetUserFromContext(r.Context())
 	if user == nil {
-		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "Not authenticated"})
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized", "Not authenticated")
 		return
 	}
 
@@ -144,26 +291,107 @@ func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// CapabilitiesResponse lists what the current user is allowed to do
+type CapabilitiesResponse struct {
+	Username     string          `json:"username"`
+	Role         auth.Role       `json:"role"`
+	Actions      []auth.Action   `json:"actions"`
+	Capabilities map[string]bool `json:"capabilities"` // can_<action> flags for every known action
+}
+
+// Capabilities handles GET /api/auth/capabilities
+// Derived from the same policy the handlers enforce
+func (h *AuthHandler) Capabilities(w http.ResponseWriter, r *http.Request) {
+	user := auth.GetUserFromContext(r.Context())
+	if user == nil {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized", "Not authenticated")
+		return
+	}
+
+	resp := CapabilitiesResponse{
+		Username:     user.Username,
+		Role:         user.Role,
+		Actions:      []auth.Action{},
+		Capabilities: make(map[string]bool),
+	}
+
+	for _, action := range auth.AllActions() {
+		allowed := user.Can(action)
+		resp.Capabilities["can_"+string(action)] = allowed
+		if allowed {
+			resp.Actions = append(resp.Actions, action)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
 // WSToken handles GET /api/auth/ws-token
 // Returns a one-time CSRF token for WebSocket connections
+// With ?renewable=true returns a renewable token for streaming endpoints
 func (h *AuthHandler) WSToken(w http.ResponseWriter, r *http.Request) {
 	user := auth.GetUserFromContext(r.Context())
 	if user == nil {
-		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "Not authenticated"})
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized", "Not authenticated")
 		return
 	}
 
-	// Only admins can get WebSocket tokens (terminals require admin)
-	if !user.IsAdmin() {
-		writeJSON(w, http.StatusForbidden, map[string]string{"error": "Admin access required"})
+	// Stream tokens are available to all users (streams are read-only)
+	if r.URL.Query().Get("renewable") == "true" {
+		token, err := h.wsTokenStore.GenerateRenewable(user.Username, auth.GetSessionFromContext(r.Context()))
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to generate token")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"token": token})
+		return
+	}
+
+	// One-time tokens are only used by terminals
+	if !user.Can(auth.ActionExec) && !user.Can(auth.ActionHostTerminal) {
+		writeJSONError(w, http.StatusForbidden, "forbidden", "Admin access required")
 		return
 	}
 
 	token, err := h.wsTokenStore.Generate(user.Username)
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to generate token"})
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to generate token")
 		return
 	}
 
 	writeJSON(w, http.StatusOK, map[string]string{"token": token})
 }
+
+// newAuthenticator returns the login backend selected by PODMANVIEW_AUTH_BACKEND
+func newAuthenticator(cfg *config.Config) auth.Authenticator {
+	if cfg.AuthBackend() != auth.BackendLDAP {
+		return auth.NewPAMAuth()
+	}
+	ldap := cfg.LDAPSettings()
+	return auth.NewLDAPAuth(auth.LDAPConfig{
+		URL:          ldap.URL,
+		StartTLS:     ldap.StartTLS,
+		BindDN:       ldap.BindDN,
+		BindPassword: ldap.BindPassword,
+		BaseDN:       ldap.BaseDN,
+		UserFilter:   ldap.UserFilter,
+		AdminGroups:  ldap.AdminGroups,
+	})
+}
+
+// newOIDCProvider returns the single sign-on provider, nil without an issuer
+func newOIDCProvider(cfg *config.Config) *auth.OIDCProvider {
+	oidc := cfg.OIDCSettings()
+	if oidc.Issuer == "" {
+		return nil
+	}
+	return auth.NewOIDCProvider(auth.OIDCConfig{
+		Issuer:       oidc.Issuer,
+		ClientID:     oidc.ClientID,
+		ClientSecret: oidc.ClientSecret,
+		RedirectURL:  oidc.RedirectURL,
+		Scopes:       oidc.Scopes,
+		RoleClaim:    oidc.RoleClaim,
+		AdminValues:  oidc.AdminValues,
+	})
+}
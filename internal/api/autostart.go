@@ -0,0 +1,198 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"podmanview/internal/auth"
+	"podmanview/internal/events"
+	"podmanview/internal/podman"
+)
+
+// enabledUnitStates are the systemctl is-enabled states of units that
+// start on boot. Static and indirect units have no [Install] section of
+// their own, so they only start when another unit pulls them in.
+var enabledUnitStates = []string{"enabled", "enabled-runtime", "alias"}
+
+// AutostartResponse is a container's start-on-boot state
+type AutostartResponse struct {
+	Enabled bool   `json:"enabled"`
+	Unit    string `json:"unit"`              // systemd unit starting the container
+	State   string `json:"state"`             // systemctl is-enabled output, "not-found" before the unit is installed
+	Warning string `json:"warning,omitempty"` // e.g. rootless units without lingering
+}
+
+// AutostartRequest sets a container's start-on-boot state
+type AutostartRequest struct {
+	Enabled bool `json:"enabled"`
+}
+
+// Autostart handles GET /api/containers/{id}/autostart.
+// The state is read from this host's systemd, so a remote engine is refused.
+func (h *ContainerHandler) Autostart(w http.ResponseWriter, r *http.Request) {
+	if !requireLocalEngine(w, h.client) {
+		return
+	}
+
+	info, err := h.client.InspectContainer(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writePodmanError(w, err, "container_not_found")
+		return
+	}
+
+	resp, err := autostartState(info)
+	if err != nil {
+		writeAutostartError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// SetAutostart handles POST /api/containers/{id}/autostart with {"enabled": true}.
+// Enabling installs the unit from `podman generate systemd` if the
+// container has none yet; disabling keeps the unit file. Refused for a
+// remote engine, whose containers don't run on this host.
+func (h *ContainerHandler) SetAutostart(w http.ResponseWriter, r *http.Request) {
+	user := auth.GetUserFromContext(r.Context())
+
+	var req AutostartRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if !requireLocalEngine(w, h.client) {
+		return
+	}
+
+	info, err := h.client.InspectContainer(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writePodmanError(w, err, "container_not_found")
+		return
+	}
+	if info.Pod != "" {
+		writeJSONError(w, http.StatusConflict, "container_in_pod", "Containers in a pod start with the pod's unit")
+		return
+	}
+	if info.HostConfig.AutoRemove {
+		writeJSONError(w, http.StatusConflict, "auto_remove", "Containers that are removed when they exit can't start on boot")
+		return
+	}
+
+	current, err := autostartState(info)
+	if err != nil {
+		writeAutostartError(w, err)
+		return
+	}
+	if current.State == "generated" {
+		writeJSONError(w, http.StatusConflict, "unit_generated",
+			fmt.Sprintf("%s is generated by Quadlet, set WantedBy= in its [Install] section instead", current.Unit))
+		return
+	}
+	if req.Enabled && current.State == "static" {
+		writeJSONError(w, http.StatusConflict, "unit_static",
+			fmt.Sprintf("%s has no [Install] section and can't be enabled", current.Unit))
+		return
+	}
+
+	_, scope, err := unitScope()
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	name := strings.TrimPrefix(info.Name, "/")
+	meta := events.Meta{"container": info.ID, "unit": current.Unit, "enabled": fmt.Sprint(req.Enabled)}
+
+	switch {
+	case req.Enabled && current.State == "not-found":
+		var units []SystemdUnit
+		if units, err = h.generateUnits(r, info.ID, podman.SystemdOptions{}); err == nil {
+			_, err = installUnits(units, true)
+		}
+	case req.Enabled:
+		err = runSystemctl(append(scope, "enable", current.Unit)...)
+	case current.State != "not-found":
+		err = runSystemctl(append(scope, "disable", current.Unit)...)
+	}
+	if err != nil {
+		h.eventStore.AddWithMeta(events.EventContainerSystemd, user.Username, getClientIP(r), false, name, meta)
+		writeAutostartError(w, err)
+		return
+	}
+
+	resp, err := autostartState(info)
+	if err != nil {
+		writeAutostartError(w, err)
+		return
+	}
+	h.eventStore.AddWithMeta(events.EventContainerSystemd, user.Username, getClientIP(r), true, name, meta)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// autostartState reports whether the container's unit is enabled. That is
+// the unit that started it, or the one `podman generate systemd` names.
+func autostartState(info *podman.ContainerInspect) (*AutostartResponse, error) {
+	unit := normalizeUnitName(info.Config.Labels[podman.LabelSystemdUnit])
+	if unit == "" {
+		unit = "container-" + strings.TrimPrefix(info.Name, "/") + ".service"
+	}
+	if !unitNamePattern.MatchString(unit) {
+		return nil, fmt.Errorf("invalid unit name %q", unit)
+	}
+
+	_, scope, err := unitScope()
+	if err != nil {
+		return nil, err
+	}
+
+	// is-enabled exits non-zero for disabled and unknown units, but still
+	// prints the state; older systemd reports unknown units on stderr only
+	out, err := exec.Command("systemctl", append(scope, "is-enabled", unit)...).Output()
+	state := strings.TrimSpace(string(out))
+	if state == "" {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("systemctl is-enabled %s: %w", unit, err)
+		}
+		if !strings.Contains(string(exitErr.Stderr), "No such file") {
+			return nil, fmt.Errorf("systemctl is-enabled %s: %s", unit, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		state = "not-found"
+	}
+
+	resp := &AutostartResponse{
+		Enabled: slices.Contains(enabledUnitStates, state),
+		Unit:    unit,
+		State:   state,
+	}
+	if resp.Enabled && scope != nil && !lingerEnabled() {
+		resp.Warning = "Lingering is off, so user units start at login rather than boot. Run: loginctl enable-linger"
+	}
+	return resp, nil
+}
+
+// lingerEnabled reports whether the current user's units start at boot
+func lingerEnabled() bool {
+	u, err := user.Current()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(filepath.Join("/var/lib/systemd/linger", u.Username))
+	return err == nil
+}
+
+// writeAutostartError reports a failed systemctl call
+func writeAutostartError(w http.ResponseWriter, err error) {
+	if errors.Is(err, exec.ErrNotFound) {
+		writeJSONError(w, http.StatusNotImplemented, "systemd_unavailable", "systemctl is not installed")
+		return
+	}
+	writeJSONError(w, http.StatusInternalServerError, "internal_error", err.Error())
+}
@@ -0,0 +1,176 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// maxSubRequests caps the number of sub-requests in one batch
+const maxSubRequests = 20
+
+// maxSubResponseSize caps the body kept for a single sub-request, so a
+// batched file download can't be buffered into memory
+const maxSubResponseSize = 4 * 1024 * 1024
+
+// subRequestTimeout ends sub-requests to streaming endpoints, which would
+// otherwise keep the batch open
+const subRequestTimeout = 30 * time.Second
+
+// errSubResponseTooLarge stops handlers writing past maxSubResponseSize
+var errSubResponseTooLarge = errors.New("batch response too large")
+
+// SubRequest is one call in the body of POST /api/batch
+type SubRequest struct {
+	Method string `json:"method"` // Only "GET"; empty means GET
+	Path   string `json:"path"`   // API path with query, e.g. "/api/containers?all=true"
+}
+
+// SubResponse is the result of one sub-request. Body is the handler's JSON
+// response as is; any other content is returned as a JSON string.
+type SubResponse struct {
+	Path   string          `json:"path"`
+	Status int             `json:"status"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+// BatchHandler runs several API calls in one request
+type BatchHandler struct {
+	router http.Handler
+}
+
+// NewBatchHandler creates a batch handler dispatching to router
+func NewBatchHandler(router http.Handler) *BatchHandler {
+	return &BatchHandler{router: router}
+}
+
+// Batch handles POST /api/batch with [{"method": "GET", "path": "/api/..."}].
+// The sub-requests run concurrently through the router in-process, with
+// the caller's credentials, so each one passes the same middleware and
+// authorization as a direct call. Responses are returned in request order.
+func (h *BatchHandler) Batch(w http.ResponseWriter, r *http.Request) {
+	var reqs []SubRequest
+	if !decodeJSON(w, r, &reqs) {
+		return
+	}
+
+	if len(reqs) == 0 {
+		writeJSONError(w, http.StatusBadRequest, "missing_field", "Requests are required")
+		return
+	}
+	if len(reqs) > maxSubRequests {
+		writeJSONError(w, http.StatusBadRequest, "too_many_requests",
+			fmt.Sprintf("At most %d requests per batch", maxSubRequests))
+		return
+	}
+	for _, req := range reqs {
+		if req.Method != "" && !strings.EqualFold(req.Method, http.MethodGet) {
+			writeJSONError(w, http.StatusBadRequest, "invalid_method", "Only GET requests can be batched")
+			return
+		}
+		if !strings.HasPrefix(req.Path, "/api/") || strings.HasPrefix(req.Path, "/api/batch") {
+			writeJSONError(w, http.StatusBadRequest, "invalid_path",
+				fmt.Sprintf("Invalid batch path: %q", req.Path))
+			return
+		}
+	}
+
+	responses := make([]SubResponse, len(reqs))
+	var wg sync.WaitGroup
+	for i, req := range reqs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			responses[i] = h.dispatch(r, req.Path)
+		}()
+	}
+	wg.Wait()
+
+	writeJSON(w, http.StatusOK, responses)
+}
+
+// dispatch runs a GET of target through the router as the caller of r
+func (h *BatchHandler) dispatch(r *http.Request, target string) SubResponse {
+	// The batch request's route context would be reused by the router
+	ctx := context.WithValue(r.Context(), chi.RouteCtxKey, nil)
+	ctx, cancel := context.WithTimeout(ctx, subRequestTimeout)
+	defer cancel()
+
+	sub, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return subErrorResponse(target, http.StatusBadRequest, "invalid_path", "Invalid batch path")
+	}
+	sub.Header = r.Header.Clone()
+	for _, name := range []string{"Content-Type", "Content-Length", "Content-Encoding", "Accept-Encoding", "Upgrade", "Connection"} {
+		sub.Header.Del(name)
+	}
+	sub.RemoteAddr = r.RemoteAddr
+	sub.Host = r.Host
+	sub.TLS = r.TLS
+
+	rec := &subResponseWriter{header: http.Header{}}
+	h.router.ServeHTTP(rec, sub)
+
+	if rec.overflow {
+		return subErrorResponse(target, http.StatusRequestEntityTooLarge, "response_too_large",
+			fmt.Sprintf("Response exceeds %d MB, request it directly", maxSubResponseSize/(1024*1024)))
+	}
+
+	resp := SubResponse{Path: target, Status: rec.status}
+	if resp.Status == 0 {
+		resp.Status = http.StatusOK
+	}
+	body := bytes.TrimSpace(rec.body.Bytes())
+	switch {
+	case len(body) == 0:
+	case strings.HasPrefix(rec.header.Get("Content-Type"), "application/json") && json.Valid(body):
+		resp.Body = body
+	default:
+		resp.Body, _ = json.Marshal(string(body))
+	}
+	return resp
+}
+
+// subErrorResponse is a SubResponse carrying an ErrorResponse body
+func subErrorResponse(target string, status int, code, message string) SubResponse {
+	body, _ := json.Marshal(ErrorResponse{Error: ErrorDetail{Code: code, Message: message}})
+	return SubResponse{Path: target, Status: status, Body: body}
+}
+
+// subResponseWriter records a sub-request's response in memory, like
+// httptest.ResponseRecorder but capped at maxSubResponseSize
+type subResponseWriter struct {
+	header   http.Header
+	status   int
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (w *subResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *subResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *subResponseWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if w.body.Len()+len(p) > maxSubResponseSize {
+		w.overflow = true
+		return 0, errSubResponseTooLarge
+	}
+	return w.body.Write(p)
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"podmanview/internal/config"
+)
+
+// gzipBody closes both the gzip reader and the underlying request body
+type gzipBody struct {
+	*gzip.Reader
+	body io.Closer
+}
+
+func (b *gzipBody) Close() error {
+	b.Reader.Close()
+	return b.body.Close()
+}
+
+// limitRequestBody decodes gzip request bodies and caps JSON bodies at
+// the configured size. Multipart uploads are left to the upload handler,
+// which has its own limit. The limit applies after decoding, so a small
+// compressed body can't expand past it.
+func limitRequestBody(cfg *config.Config) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Body == nil || r.Body == http.NoBody {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			switch encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding"))); encoding {
+			case "", "identity":
+			case "gzip", "x-gzip":
+				gz, err := gzip.NewReader(r.Body)
+				if err != nil {
+					writeJSONError(w, http.StatusBadRequest, "invalid_encoding", "Invalid gzip request body")
+					return
+				}
+				r.Body = &gzipBody{Reader: gz, body: r.Body}
+				r.Header.Del("Content-Encoding")
+				r.Header.Del("Content-Length")
+				r.ContentLength = -1
+			default:
+				writeJSONError(w, http.StatusUnsupportedMediaType, "unsupported_encoding", "Unsupported Content-Encoding: "+encoding)
+				return
+			}
+
+			if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "multipart/form-data" {
+				r.Body = http.MaxBytesReader(w, r.Body, cfg.JSONMaxBytes())
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// decodeJSON decodes the request body into v. On failure it writes the
+// error response, 413 when the body is over the size limit and 400
+// otherwise, and returns false.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err == nil {
+		return true
+	}
+
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeJSONError(w, http.StatusRequestEntityTooLarge, "body_too_large",
+			fmt.Sprintf("Request body exceeds %d bytes", tooLarge.Limit))
+		return false
+	}
+	writeJSONError(w, http.StatusBadRequest, "invalid_body", "Invalid request body")
+	return false
+}
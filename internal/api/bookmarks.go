@@ -0,0 +1,203 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"podmanview/internal/auth"
+	"podmanview/internal/storage"
+)
+
+// bookmarksNamespace is the storage namespace of file manager bookmarks,
+// keyed by username
+const bookmarksNamespace = "file_bookmarks"
+
+// maxBookmarks caps the bookmarks per user
+const maxBookmarks = 100
+
+// Bookmark is a saved file manager location
+type Bookmark struct {
+	Path      string    `json:"path"` // Relative to the file manager base directory
+	Name      string    `json:"name"`
+	IsDir     bool      `json:"is_dir"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BookmarksResponse lists the current user's bookmarks
+type BookmarksResponse struct {
+	Bookmarks []Bookmark `json:"bookmarks"`
+}
+
+// BookmarkHandler handles file manager bookmarks
+type BookmarkHandler struct {
+	storage storage.Storage
+	files   *FileManagerHandler // validates bookmarked paths
+	mu      sync.Mutex          // serializes read-modify-write of a user's list
+}
+
+// NewBookmarkHandler creates new bookmark handler
+func NewBookmarkHandler(store storage.Storage, files *FileManagerHandler) *BookmarkHandler {
+	return &BookmarkHandler{
+		storage: store,
+		files:   files,
+	}
+}
+
+// load returns username's bookmarks
+func (h *BookmarkHandler) load(username string) ([]Bookmark, error) {
+	bookmarks := []Bookmark{}
+	err := h.storage.GetJSON(bookmarksNamespace, username, &bookmarks)
+	if errors.Is(err, storage.ErrNotFound) {
+		return []Bookmark{}, nil
+	}
+	return bookmarks, err
+}
+
+// List handles GET /api/files/bookmarks
+func (h *BookmarkHandler) List(w http.ResponseWriter, r *http.Request) {
+	user := auth.GetUserFromContext(r.Context())
+
+	if h.storage == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, "storage_unavailable", "Bookmark storage not available")
+		return
+	}
+
+	h.mu.Lock()
+	bookmarks, err := h.load(user.Username)
+	h.mu.Unlock()
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, BookmarksResponse{Bookmarks: bookmarks})
+}
+
+// Add handles POST /api/files/bookmarks with {"path": "...", "name": "..."}.
+// The path must exist within the base directory; name defaults to its base name.
+func (h *BookmarkHandler) Add(w http.ResponseWriter, r *http.Request) {
+	user := auth.GetUserFromContext(r.Context())
+
+	var req struct {
+		Path string `json:"path"`
+		Name string `json:"name"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.Path == "" {
+		writeJSONError(w, http.StatusBadRequest, "missing_field", "Path is required")
+		return
+	}
+
+	if h.storage == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, "storage_unavailable", "Bookmark storage not available")
+		return
+	}
+
+	absPath, err := h.files.validatePath(req.Path)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid_path", err.Error())
+		return
+	}
+	stat, err := os.Stat(absPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			writeJSONError(w, http.StatusNotFound, "file_not_found", "File or directory not found")
+		} else {
+			writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to access path")
+		}
+		return
+	}
+
+	bookmark := Bookmark{
+		Path:      h.files.getRelativePath(absPath),
+		Name:      strings.TrimSpace(req.Name),
+		IsDir:     stat.IsDir(),
+		CreatedAt: time.Now(),
+	}
+	if bookmark.Name == "" {
+		bookmark.Name = stat.Name()
+		if bookmark.Path == "/" {
+			bookmark.Name = "Home"
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	bookmarks, err := h.load(user.Username)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	for _, b := range bookmarks {
+		if b.Path == bookmark.Path {
+			writeJSONError(w, http.StatusConflict, "bookmark_exists", "Path is already bookmarked")
+			return
+		}
+	}
+	if len(bookmarks) >= maxBookmarks {
+		writeJSONError(w, http.StatusBadRequest, "too_many_bookmarks",
+			fmt.Sprintf("At most %d bookmarks are allowed", maxBookmarks))
+		return
+	}
+
+	bookmarks = append(bookmarks, bookmark)
+	if err := h.storage.SetJSON(bookmarksNamespace, user.Username, bookmarks); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "storage_error", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, bookmark)
+}
+
+// Delete handles DELETE /api/files/bookmarks?path=...
+func (h *BookmarkHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	user := auth.GetUserFromContext(r.Context())
+
+	path := r.URL.Query().Get("path")
+	if path == "" {
+		writeJSONError(w, http.StatusBadRequest, "missing_field", "Path is required")
+		return
+	}
+
+	if h.storage == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, "storage_unavailable", "Bookmark storage not available")
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	bookmarks, err := h.load(user.Username)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+
+	// Bookmarks may point at paths that were since removed, so match the
+	// stored path instead of validating it again
+	kept := bookmarks[:0]
+	for _, b := range bookmarks {
+		if b.Path != path {
+			kept = append(kept, b)
+		}
+	}
+	if len(kept) == len(bookmarks) {
+		writeJSONError(w, http.StatusNotFound, "bookmark_not_found", "Bookmark not found")
+		return
+	}
+
+	if err := h.storage.SetJSON(bookmarksNamespace, user.Username, kept); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "storage_error", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": "Bookmark removed"})
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"podmanview/internal/config"
+)
+
+// compressExcluded reports whether a response must not be compressed.
+// File downloads and streams are mostly compressed media already, and
+// compressing them breaks their Content-Length and the byte ranges used
+// for seeking in videos.
+func compressExcluded(r *http.Request) bool {
+	path := r.URL.Path
+	return r.Header.Get("Range") != "" ||
+		path == "/api/files/download" || path == "/api/files/stream" ||
+		strings.HasSuffix(path, "/exec/download")
+}
+
+// compressResponses gzips responses whose content type is in the
+// configured list, at the configured level. A level of 0 or an empty list
+// disables compression. Changes to either apply to the next request.
+func compressResponses(cfg *config.Config) func(http.Handler) http.Handler {
+	var (
+		mu         sync.Mutex
+		key        string
+		compressor *middleware.Compressor
+	)
+	// current returns the compressor for the settings, reusing its
+	// encoder pools while they don't change
+	current := func(level int, types []string) *middleware.Compressor {
+		mu.Lock()
+		defer mu.Unlock()
+		if k := fmt.Sprintf("%d %s", level, strings.Join(types, ",")); k != key {
+			key = k
+			compressor = middleware.NewCompressor(level, types...)
+		}
+		return compressor
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			level, types := cfg.CompressLevel(), cfg.CompressTypes()
+			if level == 0 || len(types) == 0 || compressExcluded(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			current(level, types).Handler(next).ServeHTTP(w, r)
+		})
+	}
+}
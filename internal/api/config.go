@@ -0,0 +1,379 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"podmanview/internal/auth"
+	"podmanview/internal/config"
+	"podmanview/internal/events"
+	"podmanview/internal/updater"
+)
+
+// restartDelay gives the restart response time to reach the client
+const restartDelay = time.Second
+
+// restartKeys are settings only read at startup
+var restartKeys = map[string]bool{
+	config.EnvAddr:             true,
+	config.EnvAddrMode:         true,
+	config.EnvDevDir:           true,
+	config.EnvDataDir:          true,
+	config.EnvPprof:            true,
+	config.EnvJWTExpiration:    true,
+	config.EnvNoAuth:           true,
+	config.EnvAuthBackend:      true,
+	config.EnvLDAPURL:          true,
+	config.EnvLDAPStartTLS:     true,
+	config.EnvLDAPBindDN:       true,
+	config.EnvLDAPBindPassword: true,
+	config.EnvLDAPBaseDN:       true,
+	config.EnvLDAPUserFilter:   true,
+	config.EnvLDAPAdminGroups:  true,
+	config.EnvOIDCIssuer:       true,
+	config.EnvOIDCClientID:     true,
+	config.EnvOIDCClientSecret: true,
+	config.EnvOIDCRedirectURL:  true,
+	config.EnvOIDCScopes:       true,
+	config.EnvOIDCRoleClaim:    true,
+	config.EnvOIDCAdminValues:  true,
+	config.EnvTLSCert:          true,
+	config.EnvTLSKey:           true,
+	config.EnvTLSSelfSigned:    true,
+	config.EnvHTTPRedirect:     true,
+	config.EnvSocket:           true,
+}
+
+// ReloadResult describes a configuration reload
+type ReloadResult struct {
+	Changed         []string `json:"changed"`         // keys whose values changed
+	RestartRequired []string `json:"restartRequired"` // changed keys that apply after a restart
+	Warning         string   `json:"warning,omitempty"`
+}
+
+// ConfigHandler handles configuration endpoints
+type ConfigHandler struct {
+	server *Server
+}
+
+// NewConfigHandler creates a new config handler
+func NewConfigHandler(server *Server) *ConfigHandler {
+	return &ConfigHandler{server: server}
+}
+
+// GeneralConfigResponse represents the general settings.
+// Durations are in seconds; the JWT secret is never returned.
+type GeneralConfigResponse struct {
+	Addr           string   `json:"addr"`
+	JWTExpiration  int64    `json:"jwtExpiration"`
+	JWTSecretSet   bool     `json:"jwtSecretSet"`
+	NoAuth         bool     `json:"noAuth"`
+	Socket         string   `json:"socket"`
+	PodmanTimeout  int64    `json:"podmanTimeout"`
+	PodmanRetries  int      `json:"podmanRetries"`
+	LogMaxBytes    int64    `json:"logMaxBytes"`
+	LogTimezone    string   `json:"logTimezone"`
+	ImageScanner   string   `json:"imageScanner"`
+	TrustedProxies []string `json:"trustedProxies"`
+	ReverseDNS     bool     `json:"reverseDns"`
+}
+
+// GeneralConfigRequest represents a general settings update.
+// Omitted fields keep their current value.
+type GeneralConfigRequest struct {
+	Addr           *string   `json:"addr,omitempty"`
+	JWTExpiration  *int64    `json:"jwtExpiration,omitempty"`
+	NoAuth         *bool     `json:"noAuth,omitempty"`
+	Socket         *string   `json:"socket,omitempty"`
+	PodmanTimeout  *int64    `json:"podmanTimeout,omitempty"`
+	PodmanRetries  *int      `json:"podmanRetries,omitempty"`
+	LogMaxBytes    *int64    `json:"logMaxBytes,omitempty"`
+	LogTimezone    *string   `json:"logTimezone,omitempty"`
+	ImageScanner   *string   `json:"imageScanner,omitempty"`
+	TrustedProxies *[]string `json:"trustedProxies,omitempty"`
+	ReverseDNS     *bool     `json:"reverseDns,omitempty"`
+}
+
+// Get handles GET /api/system/config
+func (h *ConfigHandler) Get(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.buildResponse())
+}
+
+// Update handles PATCH /api/system/config
+func (h *ConfigHandler) Update(w http.ResponseWriter, r *http.Request) {
+	user := auth.GetUserFromContext(r.Context())
+	s := h.server
+
+	var req GeneralConfigRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	before := s.config.GeneralSettings()
+	settings := before
+	if req.Addr != nil {
+		settings.Addr = *req.Addr
+	}
+	if req.JWTExpiration != nil {
+		settings.JWTExpiration = time.Duration(*req.JWTExpiration) * time.Second
+	}
+	if req.NoAuth != nil {
+		settings.NoAuth = *req.NoAuth
+	}
+	if req.Socket != nil {
+		settings.Socket = *req.Socket
+	}
+	if req.PodmanTimeout != nil {
+		settings.PodmanTimeout = time.Duration(*req.PodmanTimeout) * time.Second
+	}
+	if req.PodmanRetries != nil {
+		settings.PodmanRetries = *req.PodmanRetries
+	}
+	if req.LogMaxBytes != nil {
+		settings.LogMaxBytes = *req.LogMaxBytes
+	}
+	if req.LogTimezone != nil {
+		settings.LogTimezone = *req.LogTimezone
+	}
+	if req.ImageScanner != nil {
+		settings.ImageScanner = *req.ImageScanner
+	}
+	if req.TrustedProxies != nil {
+		settings.TrustedProxies = *req.TrustedProxies
+	}
+	if req.ReverseDNS != nil {
+		settings.ReverseDNS = *req.ReverseDNS
+	}
+
+	if err := s.config.SetGeneralSettings(settings); err != nil {
+		s.eventStore.AddWithMeta(events.EventSettingsUpdate, user.Username, getClientIP(r), false, err.Error(), events.Meta{"section": "general"})
+		writeJSONError(w, http.StatusBadRequest, "validation_failed", err.Error())
+		return
+	}
+
+	changed := generalChanges(before, s.config.GeneralSettings())
+	restartRequired := []string{}
+	for _, key := range changed {
+		if restartKeys[key] {
+			restartRequired = append(restartRequired, key)
+		}
+	}
+	s.applyConfig(changed)
+
+	s.eventStore.AddWithMeta(events.EventSettingsUpdate, user.Username, getClientIP(r), true, strings.Join(changed, ","), events.Meta{"section": "general"})
+
+	response := map[string]interface{}{
+		"config":          h.buildResponse(),
+		"restartRequired": restartRequired,
+	}
+	if len(restartRequired) > 0 {
+		response["warning"] = fmt.Sprintf("Settings saved, restart PodmanView (POST /api/system/restart) to apply %s", strings.Join(restartRequired, ", "))
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// buildResponse returns the current general settings
+func (h *ConfigHandler) buildResponse() GeneralConfigResponse {
+	settings := h.server.config.GeneralSettings()
+	return GeneralConfigResponse{
+		Addr:           settings.Addr,
+		JWTExpiration:  int64(settings.JWTExpiration / time.Second),
+		JWTSecretSet:   h.server.config.JWTSecret() != "",
+		NoAuth:         settings.NoAuth,
+		Socket:         settings.Socket,
+		PodmanTimeout:  int64(settings.PodmanTimeout / time.Second),
+		PodmanRetries:  settings.PodmanRetries,
+		LogMaxBytes:    settings.LogMaxBytes,
+		LogTimezone:    settings.LogTimezone,
+		ImageScanner:   settings.ImageScanner,
+		TrustedProxies: settings.TrustedProxies,
+		ReverseDNS:     settings.ReverseDNS,
+	}
+}
+
+// generalChanges returns the keys of the settings that differ
+func generalChanges(before, after config.GeneralSettings) []string {
+	var changed []string
+	add := func(key string, differs bool) {
+		if differs {
+			changed = append(changed, key)
+		}
+	}
+	add(config.EnvAddr, before.Addr != after.Addr)
+	add(config.EnvJWTExpiration, before.JWTExpiration != after.JWTExpiration)
+	add(config.EnvNoAuth, before.NoAuth != after.NoAuth)
+	add(config.EnvSocket, before.Socket != after.Socket)
+	add(config.EnvPodmanTimeout, before.PodmanTimeout != after.PodmanTimeout)
+	add(config.EnvPodmanRetries, before.PodmanRetries != after.PodmanRetries)
+	add(config.EnvLogMaxBytes, before.LogMaxBytes != after.LogMaxBytes)
+	add(config.EnvLogTimezone, before.LogTimezone != after.LogTimezone)
+	add(config.EnvImageScanner, before.ImageScanner != after.ImageScanner)
+	add(config.EnvTrustedProxies, !slices.Equal(before.TrustedProxies, after.TrustedProxies))
+	add(config.EnvReverseDNS, before.ReverseDNS != after.ReverseDNS)
+	return changed
+}
+
+// RotateSecret handles POST /api/auth/rotate-secret
+// Generates a new JWT secret; tokens signed with the old one stay valid
+// for the grace period. The caller gets a token signed with the new secret.
+func (h *ConfigHandler) RotateSecret(w http.ResponseWriter, r *http.Request) {
+	user := auth.GetUserFromContext(r.Context())
+	s := h.server
+
+	if err := s.config.RotateJWTSecret(); err != nil {
+		s.eventStore.AddWithMeta(events.EventSettingsUpdate, user.Username, getClientIP(r), false, err.Error(), events.Meta{"section": "jwt"})
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to rotate secret")
+		return
+	}
+	setJWTSecrets(s.jwtManager, s.config)
+
+	// Re-issue the caller's session so it outlives the grace period
+	if !s.config.NoAuth() {
+		if token, err := s.jwtManager.GenerateToken(user); err == nil {
+			auth.SetAuthCookie(w, r, token, int(s.config.JWTExpiration().Seconds()))
+		}
+	}
+
+	_, until := s.config.JWTPreviousSecret()
+	s.eventStore.AddWithMeta(events.EventSettingsUpdate, user.Username, getClientIP(r), true, "jwt secret rotated", events.Meta{"section": "jwt"})
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success":       true,
+		"previousUntil": until,
+	})
+}
+
+// Reload handles POST /api/system/config/reload
+func (h *ConfigHandler) Reload(w http.ResponseWriter, r *http.Request) {
+	user := auth.GetUserFromContext(r.Context())
+
+	result, err := h.server.ReloadConfig(user.Username, getClientIP(r))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "validation_failed", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// Restart handles POST /api/system/restart
+// When PodmanView is the main process of a systemd service, that unit is
+// restarted with systemctl; otherwise the request is passed to main (see
+// RestartRequests), which shuts down gracefully and starts the binary
+// again with the same arguments.
+func (h *ConfigHandler) Restart(w http.ResponseWriter, r *http.Request) {
+	user := auth.GetUserFromContext(r.Context())
+	reqLog := logger(r.Context())
+
+	mode := "exec"
+	if updater.UnderSystemd() {
+		mode = "systemd"
+	}
+	h.server.eventStore.AddWithMeta(events.EventSystemRestart, user.Username, getClientIP(r), true, mode, events.Meta{"mode": mode})
+
+	// Send response before restarting
+	writeJSON(w, http.StatusAccepted, map[string]string{"status": "restarting", "mode": mode})
+
+	go func() {
+		time.Sleep(restartDelay)
+		reqLog.Printf("Restarting PodmanView (%s)", mode)
+		if mode == "systemd" {
+			if err := updater.RestartService(); err != nil {
+				reqLog.Printf("Failed to restart service: %v", err)
+			}
+			return
+		}
+		select {
+		case h.server.restart <- struct{}{}:
+		default: // already requested
+		}
+	}()
+}
+
+// RestartRequests delivers restart requests when PodmanView doesn't run
+// under systemd. The receiver is expected to shut down and re-exec.
+func (s *Server) RestartRequests() <-chan struct{} {
+	return s.restart
+}
+
+// ReloadConfig re-reads the .env file and applies the settings that can
+// change at runtime. Used by the reload endpoint and on SIGHUP.
+// An invalid file leaves the running configuration untouched.
+func (s *Server) ReloadConfig(username, ip string) (ReloadResult, error) {
+	changed, err := s.config.Reload()
+	if err != nil {
+		s.eventStore.AddWithMeta(events.EventSettingsUpdate, username, ip, false, err.Error(), events.Meta{"section": "reload"})
+		return ReloadResult{}, err
+	}
+
+	result := ReloadResult{Changed: []string{}, RestartRequired: []string{}}
+	for _, key := range changed {
+		result.Changed = append(result.Changed, key)
+		if restartKeys[key] {
+			result.RestartRequired = append(result.RestartRequired, key)
+		}
+	}
+	if err := s.applyConfig(changed); err != nil {
+		result.Warning = "Settings reloaded but MQTT failed to apply: " + err.Error()
+	}
+
+	s.eventStore.AddWithMeta(events.EventSettingsUpdate, username, ip, true, strings.Join(result.Changed, ","), events.Meta{"section": "reload"})
+	return result, nil
+}
+
+// applyConfig pushes changed runtime settings to their owners.
+// CSP, CORS origins, terminal filter, plugin sandbox, log limits and the
+// image scanner are read per request. Returns the MQTT apply error, if any.
+func (s *Server) applyConfig(changed []string) error {
+	mqttChanged := false
+	dnsChanged := false
+	jwtChanged := false
+	for _, key := range changed {
+		if key == config.EnvJWTSecret || key == config.EnvJWTPrevSecret || key == config.EnvJWTPrevUntil {
+			jwtChanged = true
+		}
+		if strings.HasPrefix(key, "PODMANVIEW_MQTT_") {
+			mqttChanged = true
+		}
+		if key == config.EnvReverseDNS {
+			dnsChanged = true
+		}
+	}
+
+	setTrustedProxies(s.config.TrustedProxies())
+	auth.SetCrossSiteCookies(len(s.config.CORSOrigins()) > 0)
+
+	if dnsChanged {
+		if s.config.ReverseDNS() {
+			s.eventStore.SetResolver(events.NewHostResolver(1000)) // Cache up to 1000 IPs
+		} else {
+			s.eventStore.SetResolver(nil)
+		}
+	}
+
+	if s.podmanClient != nil {
+		s.podmanClient.SetTimeout(s.config.PodmanTimeout())
+		retryPolicy := s.podmanClient.RetryPolicy()
+		retryPolicy.MaxRetries = s.config.PodmanRetries()
+		s.podmanClient.SetRetryPolicy(retryPolicy)
+	}
+
+	if jwtChanged {
+		setJWTSecrets(s.jwtManager, s.config)
+	}
+
+	if mqttChanged {
+		mqttHandler := NewMQTTHandler(s.config, s.pluginRegistry, s.eventStore)
+		return mqttHandler.applyConfig()
+	}
+	return nil
+}
+
+// setJWTSecrets loads the current and previous JWT secrets into m
+func setJWTSecrets(m *auth.JWTManager, cfg *config.Config) {
+	previous, until := cfg.JWTPreviousSecret()
+	m.SetSecrets(cfg.JWTSecret(), previous, until)
+}
@@ -1,27 +1,35 @@
 package api
 
 import (
-	"encoding/json"
+	"context"
+	"fmt"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 
 	"podmanview/internal/auth"
+	"podmanview/internal/config"
 	"podmanview/internal/events"
 	"podmanview/internal/podman"
 )
 
 // ContainerHandler handles container endpoints
 type ContainerHandler struct {
-	client     *podman.Client
-	eventStore *events.Store
+	client       *podman.Client
+	eventStore   *events.Store
+	config       *config.Config
+	statsCache   *StatsCache
+	statsHistory *StatsHistory
 }
 
 // NewContainerHandler creates new container handler
-func NewContainerHandler(client *podman.Client, eventStore *events.Store) *ContainerHandler {
-	return &ContainerHandler{client: client, eventStore: eventStore}
+func NewContainerHandler(client *podman.Client, eventStore *events.Store, cfg *config.Config, statsCache *StatsCache, statsHistory *StatsHistory) *ContainerHandler {
+	return &ContainerHandler{client: client, eventStore: eventStore, config: cfg, statsCache: statsCache, statsHistory: statsHistory}
 }
 
 // ContainerWithStats extends Container with resource stats
@@ -32,6 +40,16 @@ type ContainerWithStats struct {
 	State    string   `json:"State"`
 	CPU      float64  `json:"CPU"`
 	MemUsage uint64   `json:"MemUsage"`
+
+	RestartCount int    `json:"RestartCount"`
+	Uptime       string `json:"Uptime"` // empty when not running
+}
+
+// ContainerInspectResponse extends inspect output with computed uptime
+type ContainerInspectResponse struct {
+	*podman.ContainerInspect
+	Uptime       string               `json:"Uptime"`
+	PortBindings []podman.PortBinding `json:"PortBindings"` // published ports, flattened
 }
 
 // List handles GET /api/containers
@@ -40,12 +58,12 @@ func (h *ContainerHandler) List(w http.ResponseWriter, r *http.Request) {
 
 	containers, err := h.client.ListContainers(ctx)
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		writePodmanError(w, err, "")
 		return
 	}
 
 	// Get stats for running containers
-	stats, _ := h.client.GetContainersStats(ctx)
+	stats, _, _ := h.statsCache.Stats(ctx)
 	statsMap := make(map[string]*podman.ContainerStats)
 	for i := range stats {
 		statsMap[stats[i].ContainerID] = &stats[i]
@@ -59,6 +77,11 @@ func (h *ContainerHandler) List(w http.ResponseWriter, r *http.Request) {
 			Names: c.Names,
 			Image: c.Image,
 			State: c.State,
+
+			RestartCount: c.Restarts,
+		}
+		if c.State == "running" && c.StartedAt > 0 {
+			result[i].Uptime = formatUptime(time.Since(time.Unix(c.StartedAt, 0)))
 		}
 		if stat := statsMap[c.ID]; stat != nil {
 			result[i].CPU = stat.CPU
@@ -75,102 +98,168 @@ func (h *ContainerHandler) Inspect(w http.ResponseWriter, r *http.Request) {
 
 	info, err := h.client.InspectContainer(r.Context(), id)
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		writePodmanError(w, err, "container_not_found")
 		return
 	}
 
-	writeJSON(w, http.StatusOK, info)
+	resp := ContainerInspectResponse{
+		ContainerInspect: info,
+		PortBindings:     info.PortBindings(),
+	}
+	if info.State.Running {
+		if started, err := time.Parse(time.RFC3339Nano, info.State.StartedAt); err == nil {
+			resp.Uptime = formatUptime(time.Since(started))
+		}
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// formatUptime formats a duration as "3d 4h 5m" (same as the UI)
+func formatUptime(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	minutes := int(d.Minutes()) % 60
+
+	var parts []string
+	if days > 0 {
+		parts = append(parts, fmt.Sprintf("%dd", days))
+	}
+	if hours > 0 {
+		parts = append(parts, fmt.Sprintf("%dh", hours))
+	}
+	if minutes > 0 || len(parts) == 0 {
+		parts = append(parts, fmt.Sprintf("%dm", minutes))
+	}
+
+	return strings.Join(parts, " ")
 }
 
 // Start handles POST /api/containers/{id}/start
 func (h *ContainerHandler) Start(w http.ResponseWriter, r *http.Request) {
 	user := auth.GetUserFromContext(r.Context())
-	if !user.IsAdmin() {
-		writeJSON(w, http.StatusForbidden, map[string]string{"error": "Admin access required"})
-		return
-	}
 
 	id := chi.URLParam(r, "id")
 
 	if err := h.client.StartContainer(r.Context(), id); err != nil {
-		h.eventStore.Add(events.EventContainerStart, user.Username, getClientIP(r), false, shortID(id))
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		h.eventStore.AddWithMeta(events.EventContainerStart, user.Username, getClientIP(r), false, shortID(id), events.Meta{"container": id})
+		writePodmanError(w, err, "container_not_found")
 		return
 	}
 
-	h.eventStore.Add(events.EventContainerStart, user.Username, getClientIP(r), true, shortID(id))
+	h.eventStore.AddWithMeta(events.EventContainerStart, user.Username, getClientIP(r), true, shortID(id), events.Meta{"container": id})
 	writeJSON(w, http.StatusOK, map[string]string{"status": "started"})
 }
 
 // Stop handles POST /api/containers/{id}/stop
 func (h *ContainerHandler) Stop(w http.ResponseWriter, r *http.Request) {
 	user := auth.GetUserFromContext(r.Context())
-	if !user.IsAdmin() {
-		writeJSON(w, http.StatusForbidden, map[string]string{"error": "Admin access required"})
-		return
-	}
 
 	id := chi.URLParam(r, "id")
 
 	if err := h.client.StopContainer(r.Context(), id); err != nil {
-		h.eventStore.Add(events.EventContainerStop, user.Username, getClientIP(r), false, shortID(id))
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		h.eventStore.AddWithMeta(events.EventContainerStop, user.Username, getClientIP(r), false, shortID(id), events.Meta{"container": id})
+		writePodmanError(w, err, "container_not_found")
 		return
 	}
 
-	h.eventStore.Add(events.EventContainerStop, user.Username, getClientIP(r), true, shortID(id))
+	h.eventStore.AddWithMeta(events.EventContainerStop, user.Username, getClientIP(r), true, shortID(id), events.Meta{"container": id})
 	writeJSON(w, http.StatusOK, map[string]string{"status": "stopped"})
 }
 
 // Restart handles POST /api/containers/{id}/restart
 func (h *ContainerHandler) Restart(w http.ResponseWriter, r *http.Request) {
 	user := auth.GetUserFromContext(r.Context())
-	if !user.IsAdmin() {
-		writeJSON(w, http.StatusForbidden, map[string]string{"error": "Admin access required"})
-		return
-	}
 
 	id := chi.URLParam(r, "id")
 
 	if err := h.client.RestartContainer(r.Context(), id); err != nil {
-		h.eventStore.Add(events.EventContainerRestart, user.Username, getClientIP(r), false, shortID(id))
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		h.eventStore.AddWithMeta(events.EventContainerRestart, user.Username, getClientIP(r), false, shortID(id), events.Meta{"container": id})
+		writePodmanError(w, err, "container_not_found")
 		return
 	}
 
-	h.eventStore.Add(events.EventContainerRestart, user.Username, getClientIP(r), true, shortID(id))
+	h.eventStore.AddWithMeta(events.EventContainerRestart, user.Username, getClientIP(r), true, shortID(id), events.Meta{"container": id})
 	writeJSON(w, http.StatusOK, map[string]string{"status": "restarted"})
 }
 
 // Remove handles DELETE /api/containers/{id}
 func (h *ContainerHandler) Remove(w http.ResponseWriter, r *http.Request) {
 	user := auth.GetUserFromContext(r.Context())
-	if !user.IsAdmin() {
-		writeJSON(w, http.StatusForbidden, map[string]string{"error": "Admin access required"})
-		return
-	}
 
 	id := chi.URLParam(r, "id")
 	force := r.URL.Query().Get("force") == "true"
 
+	// Stats history is kept by full ID, id may be a name
+	fullID := id
+	if info, err := h.client.InspectContainer(r.Context(), id); err == nil {
+		fullID = info.ID
+	}
+
 	if err := h.client.RemoveContainer(r.Context(), id, force); err != nil {
-		h.eventStore.Add(events.EventContainerRemove, user.Username, getClientIP(r), false, shortID(id))
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		h.eventStore.AddWithMeta(events.EventContainerRemove, user.Username, getClientIP(r), false, shortID(id), events.Meta{"container": id})
+		writePodmanError(w, err, "container_not_found")
 		return
 	}
+	h.statsHistory.Remove(fullID)
 
-	h.eventStore.Add(events.EventContainerRemove, user.Username, getClientIP(r), true, shortID(id))
+	h.eventStore.AddWithMeta(events.EventContainerRemove, user.Username, getClientIP(r), true, shortID(id), events.Meta{"container": id})
 	writeJSON(w, http.StatusOK, map[string]string{"status": "removed"})
 }
 
+// maxJournaldTail caps tail for journald containers - reading the journal is slow
+const maxJournaldTail = 5000
+
 // LogsResponse represents the response for container logs
 type LogsResponse struct {
-	Lines []string `json:"lines"`
+	Lines     []string   `json:"lines"`
+	Entries   []LogEntry `json:"entries,omitempty"`   // format=structured only
+	Truncated bool       `json:"truncated,omitempty"` // older lines dropped to fit the size limit
+	Driver    string     `json:"driver,omitempty"`
+	Message   string     `json:"message,omitempty"`
+
+	// Time zone the timestamps were converted to, with timestamps only
+	Timezone       string `json:"timezone,omitempty"`
+	TimezoneOffset *int   `json:"timezoneOffset,omitempty"` // seconds east of UTC
 }
 
+// LogEntry is a log line split from its timestamp
+type LogEntry struct {
+	Time *time.Time `json:"time,omitempty"` // missing if the line had no timestamp
+	Line string     `json:"line"`
+}
+
+// logTimeLayout formats converted log timestamps (RFC 3339 with milliseconds)
+const logTimeLayout = "2006-01-02T15:04:05.000Z07:00"
+
 // Logs handles GET /api/containers/{id}/logs
+// With ?timestamps=true each line starts with its time, converted from UTC
+// to ?tz= (an IANA name such as Europe/Berlin) or the configured log time
+// zone. ?format=structured also returns entries with the parsed time.
 func (h *ContainerHandler) Logs(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
+	structured := r.URL.Query().Get("format") == "structured"
+	timestamps := structured || r.URL.Query().Get("timestamps") == "true"
+
+	var loc *time.Location
+	if timestamps {
+		name := r.URL.Query().Get("tz")
+		if name == "" {
+			name = h.config.LogTimezone()
+		}
+		loc = time.Local
+		if name != "" {
+			var err error
+			if loc, err = time.LoadLocation(name); err != nil {
+				writeJSONError(w, http.StatusBadRequest, "invalid_timezone", fmt.Sprintf("Unknown time zone: %q", name))
+				return
+			}
+		}
+	}
 
 	tail := 100
 	if t := r.URL.Query().Get("tail"); t != "" {
@@ -179,58 +268,132 @@ func (h *ContainerHandler) Logs(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	logs, err := h.client.GetContainerLogs(r.Context(), id, tail)
+	// Adjust to the container's log driver
+	var driver string
+	if info, err := h.client.InspectContainer(r.Context(), id); err == nil {
+		driver = info.HostConfig.LogConfig.Type
+	}
+
+	switch driver {
+	case podman.LogDriverNone, podman.LogDriverPassthrough:
+		writeJSON(w, http.StatusOK, LogsResponse{
+			Lines:   []string{},
+			Driver:  driver,
+			Message: "Container log driver '" + driver + "' does not store logs",
+		})
+		return
+	case podman.LogDriverJournald:
+		if tail < 0 || tail > maxJournaldTail {
+			tail = maxJournaldTail
+		}
+	}
+
+	maxBytes := h.config.LogMaxBytes()
+	logs, err := h.client.GetContainerLogsLimited(r.Context(), id, tail, maxBytes, timestamps)
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		writePodmanError(w, err, "container_not_found")
 		return
 	}
 
+	resp := LogsResponse{Truncated: logs.Truncated, Driver: driver}
+	if logs.Truncated {
+		resp.Message = fmt.Sprintf("Log truncated to the newest %d KiB", maxBytes/1024)
+	}
+
 	// Split logs into lines
 	var lines []string
-	if logs != "" {
-		lines = strings.Split(logs, "\n")
+	if logs.Text != "" {
+		lines = strings.Split(logs.Text, "\n")
 		// Remove empty trailing line if exists
 		if len(lines) > 0 && lines[len(lines)-1] == "" {
 			lines = lines[:len(lines)-1]
 		}
 	}
 
-	writeJSON(w, http.StatusOK, LogsResponse{Lines: lines})
+	if timestamps {
+		zone, offset := time.Now().In(loc).Zone()
+		resp.Timezone, resp.TimezoneOffset = loc.String(), &offset
+		if loc == time.Local {
+			resp.Timezone = zone
+		}
+		if structured {
+			resp.Entries = make([]LogEntry, len(lines))
+		}
+		for i, line := range lines {
+			t, text, ok := splitLogTimestamp(line)
+			if structured {
+				resp.Entries[i] = LogEntry{Line: text}
+				if ok {
+					t = t.In(loc)
+					resp.Entries[i].Time = &t
+				}
+			}
+			if ok {
+				lines[i] = t.In(loc).Format(logTimeLayout) + " " + text
+			}
+		}
+	}
+
+	resp.Lines = lines
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// splitLogTimestamp splits the RFC 3339 timestamp Podman puts in front of a
+// log line. ok is false if the line doesn't start with one.
+func splitLogTimestamp(line string) (t time.Time, text string, ok bool) {
+	stamp, text, found := strings.Cut(line, " ")
+	if !found {
+		stamp, text = line, ""
+	}
+	t, err := time.Parse(time.RFC3339Nano, stamp)
+	if err != nil {
+		return time.Time{}, line, false
+	}
+	return t, text, true
 }
 
 // CreateContainerRequest represents the request body for creating a container
 type CreateContainerRequest struct {
-	Image   string `json:"image"`
-	Name    string `json:"name"`
-	Ports   string `json:"ports"`
-	Volumes string `json:"volumes"`
-	Env     string `json:"env"`
-	Command string `json:"command"`
-	Start   bool   `json:"start"`
+	Image    string `json:"image"`
+	Name     string `json:"name"`
+	Ports    string `json:"ports"`
+	Volumes  string `json:"volumes"`
+	Env      string `json:"env"`
+	Command  string `json:"command"`
+	Start    bool   `json:"start"`
+	AutoPull bool   `json:"auto_pull"`
+	Remove   bool   `json:"remove"` // remove the container when it exits
+}
+
+// CreateDryRunResponse represents the result of a dry-run container creation
+type CreateDryRunResponse struct {
+	DryRun bool                          `json:"dryRun"`
+	Valid  bool                          `json:"valid"`
+	Errors []string                      `json:"errors"`
+	Config *podman.ContainerCreateConfig `json:"config"`
 }
 
 // Create handles POST /api/containers
+// With ?dry_run=true the request is parsed and validated, but nothing is created.
+// With ?stream=true the output of an auto-pull is streamed as
+// newline-delimited JSON ahead of the result.
 func (h *ContainerHandler) Create(w http.ResponseWriter, r *http.Request) {
 	user := auth.GetUserFromContext(r.Context())
-	if !user.IsAdmin() {
-		writeJSON(w, http.StatusForbidden, map[string]string{"error": "Admin access required"})
-		return
-	}
 
 	var req CreateContainerRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
 	if req.Image == "" {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Image is required"})
+		writeJSONError(w, http.StatusBadRequest, "missing_field", "Image is required")
 		return
 	}
 
 	config := &podman.ContainerCreateConfig{
-		Image: req.Image,
-		Name:  req.Name,
+		Image:  req.Image,
+		Name:   req.Name,
+		Remove: req.Remove,
 	}
 
 	// Parse command
@@ -253,18 +416,35 @@ func (h *ContainerHandler) Create(w http.ResponseWriter, r *http.Request) {
 		config.Mounts = parseVolumeMounts(req.Volumes)
 	}
 
+	// Dry run: validate and return the resolved config without creating anything
+	if r.URL.Query().Get("dry_run") == "true" {
+		errs := h.validateCreateConfig(r.Context(), config)
+		writeJSON(w, http.StatusOK, CreateDryRunResponse{
+			DryRun: true,
+			Valid:  len(errs) == 0,
+			Errors: errs,
+			Config: config,
+		})
+		return
+	}
+
+	sw := newStreamWriter(w, r)
+	if !h.ensureImage(sw, r, config.Image, req.AutoPull) {
+		return
+	}
+
 	result, err := h.client.CreateContainer(r.Context(), config)
 	if err != nil {
-		h.eventStore.Add(events.EventContainerCreate, user.Username, getClientIP(r), false, req.Image)
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		h.eventStore.AddWithMeta(events.EventContainerCreate, user.Username, getClientIP(r), false, req.Image, events.Meta{"image": req.Image})
+		sw.podmanError(err, "")
 		return
 	}
 
 	// Start container if requested
 	if req.Start {
 		if err := h.client.StartContainer(r.Context(), result.ID); err != nil {
-			h.eventStore.Add(events.EventContainerCreate, user.Username, getClientIP(r), true, shortID(result.ID))
-			writeJSON(w, http.StatusOK, map[string]string{
+			h.eventStore.AddWithMeta(events.EventContainerCreate, user.Username, getClientIP(r), true, shortID(result.ID), events.Meta{"container": result.ID, "image": req.Image})
+			sw.result(http.StatusOK, map[string]string{
 				"id":      result.ID,
 				"status":  "created",
 				"warning": "Container created but failed to start: " + err.Error(),
@@ -278,8 +458,97 @@ func (h *ContainerHandler) Create(w http.ResponseWriter, r *http.Request) {
 		status = "started"
 	}
 
-	h.eventStore.Add(events.EventContainerCreate, user.Username, getClientIP(r), true, shortID(result.ID))
-	writeJSON(w, http.StatusCreated, map[string]string{"id": result.ID, "status": status})
+	h.eventStore.AddWithMeta(events.EventContainerCreate, user.Username, getClientIP(r), true, shortID(result.ID), events.Meta{"container": result.ID, "image": req.Image})
+	sw.result(http.StatusCreated, map[string]string{"id": result.ID, "status": status})
+}
+
+// ensureImage makes sure an image is present locally, pulling it if
+// autoPull is set and sending the pull output as progress. On failure it
+// sends the error response and returns false.
+func (h *ContainerHandler) ensureImage(sw *streamWriter, r *http.Request, image string, autoPull bool) bool {
+	user := auth.GetUserFromContext(r.Context())
+
+	if _, err := h.client.InspectImage(r.Context(), image); err != nil {
+		if !podman.IsNotFound(err) {
+			sw.podmanError(err, "")
+			return false
+		}
+		if !autoPull {
+			sw.error(http.StatusNotFound, "image_not_found", "Image not found locally, pull it first")
+			return false
+		}
+		err := h.client.PullImageWithProgress(r.Context(), image, podman.Platform{}, func(p podman.PullProgress) {
+			if line := strings.TrimSpace(p.Stream); line != "" {
+				sw.progress(line)
+			}
+		})
+		h.eventStore.AddWithMeta(events.EventImagePull, user.Username, getClientIP(r), err == nil, image, events.Meta{"image": image})
+		if err != nil {
+			sw.error(http.StatusInternalServerError, "pull_failed", "Failed to pull image: "+err.Error())
+			return false
+		}
+	}
+	return true
+}
+
+// validateCreateConfig checks that the image exists locally, host ports are free
+// and bind mount sources resolve (with a local engine). Returns a list of
+// human-readable problems.
+func (h *ContainerHandler) validateCreateConfig(ctx context.Context, config *podman.ContainerCreateConfig) []string {
+	errs := []string{}
+
+	// Image must be present locally
+	if _, err := h.client.InspectImage(ctx, config.Image); err != nil {
+		errs = append(errs, fmt.Sprintf("image %s not found locally", config.Image))
+	}
+
+	// Host ports must not be published by running containers
+	if len(config.PortMappings) > 0 {
+		containers, err := h.client.ListContainers(ctx)
+		if err != nil {
+			errs = append(errs, "failed to check port conflicts: "+err.Error())
+		} else {
+			usedPorts := make(map[string]string)
+			for _, c := range containers {
+				if c.State != "running" {
+					continue
+				}
+				name := shortID(c.ID)
+				if len(c.Names) > 0 {
+					name = c.Names[0]
+				}
+				for _, p := range c.Ports {
+					for _, b := range p.Bindings() {
+						usedPorts[fmt.Sprintf("%d/%s", b.HostPort, b.Protocol)] = name
+					}
+				}
+			}
+			for _, pm := range config.PortMappings {
+				key := fmt.Sprintf("%d/%s", pm.HostPort, pm.Protocol)
+				if owner, ok := usedPorts[key]; ok {
+					errs = append(errs, fmt.Sprintf("host port %s is already used by container %s", key, owner))
+				}
+			}
+		}
+	}
+
+	// Bind mount sources must be absolute and exist on the host. A remote
+	// engine's host isn't this one, so there only the form is checked.
+	remote := h.client.IsRemote()
+	for _, m := range config.Mounts {
+		if !filepath.IsAbs(m.Source) {
+			errs = append(errs, fmt.Sprintf("volume source %s must be an absolute path", m.Source))
+			continue
+		}
+		if remote {
+			continue
+		}
+		if _, err := os.Stat(m.Source); err != nil {
+			errs = append(errs, fmt.Sprintf("volume source %s does not exist", m.Source))
+		}
+	}
+
+	return errs
 }
 
 // parsePortMappings parses port mappings from string like "80:80, 443:443"
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"podmanview/internal/config"
+)
+
+// corsAllowMethods and corsAllowHeaders are what the API routes use
+const (
+	corsAllowMethods = "GET, POST, PUT, PATCH, DELETE"
+	corsAllowHeaders = "Content-Type, Content-Encoding"
+)
+
+// corsMaxAge lets browsers cache a preflight for 10 minutes
+const corsMaxAge = "600"
+
+// corsHeaders lets the configured origins call /api routes from another
+// frontend. Auth is cookie based, so credentials are allowed and the
+// origin is echoed, never "*". Preflight requests are answered here,
+// before authentication. The origins are read from cfg on each request
+// so a config reload applies. Since the auth cookie then goes along on
+// cross-site requests (see auth.SetCrossSiteCookies), other sites can't
+// make changes.
+func corsHeaders(cfg *config.Config) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !strings.HasPrefix(r.URL.Path, "/api/") {
+				next.ServeHTTP(w, r)
+				return
+			}
+			origins := cfg.CORSOrigins()
+			if len(origins) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Add("Vary", "Origin")
+			origin := r.Header.Get("Origin")
+			if origin == "" || !corsAllowed(origins, origin) {
+				// The auth cookie is SameSite=None while CORS is enabled, so
+				// browsers send it from any site: only reads get through
+				if crossSite(r) && r.Method != http.MethodGet && r.Method != http.MethodHead && r.Method != http.MethodOptions {
+					writeJSONError(w, http.StatusForbidden, "cross_site_request",
+						"Cross-site requests are only allowed from the configured CORS origins")
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h.Set("Access-Control-Allow-Origin", origin)
+			h.Set("Access-Control-Allow-Credentials", "true")
+
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				h.Set("Access-Control-Allow-Methods", corsAllowMethods)
+				h.Set("Access-Control-Allow-Headers", corsAllowHeaders)
+				h.Set("Access-Control-Max-Age", corsMaxAge)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			h.Set("Access-Control-Expose-Headers", requestIDHeader+", Content-Disposition")
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// corsAllowed reports whether origin is one of origins. Scheme and host
+// are case-insensitive.
+func corsAllowed(origins []string, origin string) bool {
+	for _, allowed := range origins {
+		if strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// crossSite reports whether a request comes from a page on another site.
+// Browsers say so in Sec-Fetch-Site; older ones are checked by Origin,
+// which is sent on cross-origin requests and same-origin changes.
+func crossSite(r *http.Request) bool {
+	if site := r.Header.Get("Sec-Fetch-Site"); site != "" {
+		return site == "cross-site"
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return false
+	}
+	u, err := url.Parse(origin)
+	return err != nil || !strings.EqualFold(u.Host, r.Host)
+}
@@ -0,0 +1,280 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"podmanview/internal/mqtt"
+)
+
+// Diagnostic check results, from best to worst
+const (
+	DiagnosticPass = "pass"
+	DiagnosticWarn = "warn"
+	DiagnosticFail = "fail"
+)
+
+// diagnosticTimeout limits each diagnostic check
+const diagnosticTimeout = 10 * time.Second
+
+// Free space in the data directory below which the disk check warns or fails
+const (
+	diagnosticDiskWarn = 1 << 30   // 1 GiB
+	diagnosticDiskFail = 100 << 20 // 100 MiB
+)
+
+// DiagnosticCheck is the result of one diagnostic check
+type DiagnosticCheck struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"` // "pass", "warn" or "fail"
+	Detail     string `json:"detail"`
+	DurationMs int64  `json:"durationMs"`
+}
+
+// DiagnosticsResponse is the self-check report
+type DiagnosticsResponse struct {
+	Status  string            `json:"status"` // worst status of all checks
+	Version string            `json:"version"`
+	Time    time.Time         `json:"time"`
+	Checks  []DiagnosticCheck `json:"checks"`
+}
+
+// RuntimeStats is the memory and goroutine usage of the PodmanView process
+type RuntimeStats struct {
+	GoVersion     string     `json:"goVersion"`
+	Goroutines    int        `json:"goroutines"`
+	HeapAlloc     uint64     `json:"heapAlloc"`   // bytes of live and not yet collected objects
+	HeapInuse     uint64     `json:"heapInuse"`   // bytes in in-use heap spans
+	HeapObjects   uint64     `json:"heapObjects"` // allocated heap objects
+	HeapSys       uint64     `json:"heapSys"`     // heap memory obtained from the OS
+	Sys           uint64     `json:"sys"`         // total memory obtained from the OS
+	NumGC         uint32     `json:"numGC"`
+	LastGC        *time.Time `json:"lastGC,omitempty"`
+	GCPauseTotal  float64    `json:"gcPauseTotalMs"`
+	GCCPUFraction float64    `json:"gcCpuFraction"`
+}
+
+// DiagnosticsHandler runs the self-check
+type DiagnosticsHandler struct {
+	server *Server
+}
+
+// NewDiagnosticsHandler creates a new diagnostics handler
+func NewDiagnosticsHandler(server *Server) *DiagnosticsHandler {
+	return &DiagnosticsHandler{server: server}
+}
+
+// diagnostic is a named check returning its status and detail
+type diagnostic struct {
+	name string
+	run  func(ctx context.Context) (string, string)
+}
+
+// Run handles GET /api/system/diagnostics
+// Checks run in parallel; the report lists them in a fixed order.
+func (h *DiagnosticsHandler) Run(w http.ResponseWriter, r *http.Request) {
+	diagnostics := []diagnostic{
+		{"podman", h.checkPodman},
+		{"storage", h.checkStorage},
+		{"mqtt", h.checkMQTT},
+		{"host_stats", h.checkHostStats},
+		{"nvme", checkNVMe},
+		{"web_assets", h.checkWebAssets},
+		{"data_disk", h.checkDataDisk},
+	}
+
+	resp := DiagnosticsResponse{
+		Status:  DiagnosticPass,
+		Version: h.server.version,
+		Time:    time.Now(),
+		Checks:  make([]DiagnosticCheck, len(diagnostics)),
+	}
+
+	var wg sync.WaitGroup
+	for i, d := range diagnostics {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(r.Context(), diagnosticTimeout)
+			defer cancel()
+			start := time.Now()
+			status, detail := d.run(ctx)
+			resp.Checks[i] = DiagnosticCheck{
+				Name:       d.name,
+				Status:     status,
+				Detail:     detail,
+				DurationMs: time.Since(start).Milliseconds(),
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, check := range resp.Checks {
+		if check.Status == DiagnosticFail || (check.Status == DiagnosticWarn && resp.Status == DiagnosticPass) {
+			resp.Status = check.Status
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// Runtime handles GET /api/system/runtime
+// Taken over time, the numbers show whether goroutines or the heap keep
+// growing; PODMANVIEW_PPROF serves profiles that show where.
+func (h *DiagnosticsHandler) Runtime(w http.ResponseWriter, r *http.Request) {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	stats := RuntimeStats{
+		GoVersion:     runtime.Version(),
+		Goroutines:    runtime.NumGoroutine(),
+		HeapAlloc:     m.HeapAlloc,
+		HeapInuse:     m.HeapInuse,
+		HeapObjects:   m.HeapObjects,
+		HeapSys:       m.HeapSys,
+		Sys:           m.Sys,
+		NumGC:         m.NumGC,
+		GCPauseTotal:  float64(m.PauseTotalNs) / float64(time.Millisecond),
+		GCCPUFraction: m.GCCPUFraction,
+	}
+	if m.LastGC > 0 {
+		lastGC := time.Unix(0, int64(m.LastGC))
+		stats.LastGC = &lastGC
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+// checkPodman pings Podman and reports its version
+func (h *DiagnosticsHandler) checkPodman(ctx context.Context) (string, string) {
+	client := h.server.podmanClient
+	if client == nil {
+		return DiagnosticFail, "No Podman client"
+	}
+	info, err := client.GetSystemInfo(ctx)
+	if err != nil {
+		return DiagnosticFail, "Podman API unreachable: " + err.Error()
+	}
+	state := client.ConnectionState()
+	detail := fmt.Sprintf("Podman %s on %s", info.Version.Version, info.Host.Hostname)
+	if state.Reconnects > 0 {
+		detail += fmt.Sprintf(", reconnected %d times", state.Reconnects)
+	}
+	return DiagnosticPass, detail
+}
+
+// checkStorage writes, reads back and deletes a value in the database
+func (h *DiagnosticsHandler) checkStorage(ctx context.Context) (string, string) {
+	store := h.server.storage
+	if store == nil {
+		return DiagnosticFail, "No database, plugin settings and command history are not kept"
+	}
+	probe := []byte(time.Now().Format(time.RFC3339Nano))
+	if err := store.Set("diagnostics", "probe", probe); err != nil {
+		return DiagnosticFail, "Database not writable: " + err.Error()
+	}
+	defer store.Delete("diagnostics", "probe")
+	if value, err := store.Get("diagnostics", "probe"); err != nil || string(value) != string(probe) {
+		return DiagnosticFail, fmt.Sprintf("Database read back failed: %v", err)
+	}
+	return DiagnosticPass, "Database is writable"
+}
+
+// checkMQTT connects to the configured broker
+func (h *DiagnosticsHandler) checkMQTT(ctx context.Context) (string, string) {
+	cfg := h.server.config
+	if cfg.MQTTBroker() == "" {
+		return DiagnosticPass, "Not configured"
+	}
+	if err := mqtt.TestConnection(newMQTTConfig(cfg), mqttTestTimeout); err != nil {
+		return DiagnosticFail, fmt.Sprintf("Broker %s unreachable: %v", cfg.MQTTBroker(), err)
+	}
+	return DiagnosticPass, "Connected to " + cfg.MQTTBroker()
+}
+
+// checkHostStats reads the /proc and /sys files host stats come from
+func (h *DiagnosticsHandler) checkHostStats(ctx context.Context) (string, string) {
+	procPath := hostProcPath(h.server.config.HostProc())
+	for _, name := range []string{"stat", "meminfo", "uptime", "mounts"} {
+		path := filepath.Join(procPath, name)
+		if _, err := os.ReadFile(path); err != nil {
+			return DiagnosticFail, fmt.Sprintf("Cannot read %s: %v", path, err)
+		}
+	}
+	if procPath == "/proc" && inContainer() {
+		return DiagnosticWarn, "Running in a container, host stats show the container; mount the host's /proc at /host/proc or set PODMANVIEW_HOST_PROC"
+	}
+	var missing []string
+	for _, path := range []string{"/sys/class/hwmon", "/sys/block"} {
+		if _, err := os.ReadDir(path); err != nil {
+			missing = append(missing, path)
+		}
+	}
+	if len(missing) > 0 {
+		return DiagnosticWarn, "Cannot read " + strings.Join(missing, ", ") + ", temperatures and disk details are unavailable"
+	}
+	return DiagnosticPass, procPath + " and /sys are readable"
+}
+
+// checkNVMe looks for the nvme binary when there are NVMe drives
+func checkNVMe(ctx context.Context) (string, string) {
+	entries, _ := os.ReadDir("/sys/block")
+	drives := 0
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), "nvme") {
+			drives++
+		}
+	}
+	if drives == 0 {
+		return DiagnosticPass, "No NVMe drives"
+	}
+	path, err := exec.LookPath("nvme")
+	if err != nil {
+		return DiagnosticWarn, fmt.Sprintf("%d NVMe drives but no nvme binary (nvme-cli), their temperatures are unavailable", drives)
+	}
+	return DiagnosticPass, fmt.Sprintf("%d NVMe drives, using %s", drives, path)
+}
+
+// checkWebAssets looks for the page template and main script
+func (h *DiagnosticsHandler) checkWebAssets(ctx context.Context) (string, string) {
+	source := "embedded"
+	if dir := h.server.config.DevDir(); dir != "" {
+		source = filepath.Join(dir, "web")
+	}
+	for _, name := range []string{"templates/index.html", "static/js/app.js"} {
+		if _, err := fs.Stat(h.server.webFS, name); err != nil {
+			return DiagnosticFail, fmt.Sprintf("%s missing from %s", name, source)
+		}
+	}
+	return DiagnosticPass, "Web assets found (" + source + ")"
+}
+
+// checkDataDisk reports the free space where the database lives
+func (h *DiagnosticsHandler) checkDataDisk(ctx context.Context) (string, string) {
+	dir := h.server.config.DataDir()
+	if dir == "" {
+		dir = "."
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	total, free := getDiskUsage(dir)
+	if total == 0 {
+		return DiagnosticFail, "Cannot read disk usage of " + dir
+	}
+	detail := fmt.Sprintf("%d MiB free of %d MiB in %s", free>>20, total>>20, dir)
+	switch {
+	case free < diagnosticDiskFail:
+		return DiagnosticFail, detail
+	case free < diagnosticDiskWarn:
+		return DiagnosticWarn, detail
+	}
+	return DiagnosticPass, detail
+}
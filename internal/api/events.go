@@ -3,6 +3,7 @@ package api
 import (
 	"net/http"
 	"strconv"
+	"strings"
 
 	"podmanview/internal/events"
 )
@@ -19,7 +20,16 @@ func NewEventsHandler(store *events.Store) *EventsHandler {
 
 // List returns events from the store
 // GET /api/events?limit=50&since=123
+// Optional filters: type=, user=, success=true|false, meta.<key>=<value>
 func (h *EventsHandler) List(w http.ResponseWriter, r *http.Request) {
+	if filter, ok := parseEventFilter(r); ok {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"events": h.store.Filter(filter),
+			"lastId": h.store.LastID(),
+		})
+		return
+	}
+
 	// Check for since parameter (get events after ID)
 	if sinceStr := r.URL.Query().Get("since"); sinceStr != "" {
 		sinceID, err := strconv.ParseInt(sinceStr, 10, 64)
@@ -47,3 +57,52 @@ func (h *EventsHandler) List(w http.ResponseWriter, r *http.Request) {
 		"lastId": h.store.LastID(),
 	})
 }
+
+// parseEventFilter builds a filter from query params.
+// Returns false if no filter params are present.
+func parseEventFilter(r *http.Request) (events.Filter, bool) {
+	query := r.URL.Query()
+	filter := events.Filter{Limit: 50}
+	hasFilter := false
+
+	if v := query.Get("type"); v != "" {
+		filter.Type = events.EventType(v)
+		hasFilter = true
+	}
+	if v := query.Get("user"); v != "" {
+		filter.Username = v
+		hasFilter = true
+	}
+	if v := query.Get("success"); v != "" {
+		if success, err := strconv.ParseBool(v); err == nil {
+			filter.Success = &success
+			hasFilter = true
+		}
+	}
+	for key, values := range query {
+		if name, ok := strings.CutPrefix(key, "meta."); ok && name != "" && len(values) > 0 {
+			if filter.Meta == nil {
+				filter.Meta = events.Meta{}
+			}
+			filter.Meta[name] = values[0]
+			hasFilter = true
+		}
+	}
+
+	if !hasFilter {
+		return filter, false
+	}
+
+	if v := query.Get("since"); v != "" {
+		if sinceID, err := strconv.ParseInt(v, 10, 64); err == nil {
+			filter.SinceID = sinceID
+		}
+	}
+	if v := query.Get("limit"); v != "" {
+		if l, err := strconv.Atoi(v); err == nil && l > 0 && l <= 100 {
+			filter.Limit = l
+		}
+	}
+
+	return filter, true
+}
@@ -0,0 +1,205 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"podmanview/internal/auth"
+	"podmanview/internal/events"
+	"podmanview/internal/podman"
+)
+
+// maxExecFileSize caps files copied in or out of a container through exec
+const maxExecFileSize = 10 * 1024 * 1024
+
+// maxExecStderr is how much of a command's stderr is kept for errors
+const maxExecStderr = 4096
+
+// errExecFileTooLarge is returned when a file exceeds maxExecFileSize
+var errExecFileTooLarge = errors.New("file too large")
+
+// execBuffer collects exec output up to max bytes. Past that it fails
+// with errExecFileTooLarge, or with truncate set drops the excess.
+type execBuffer struct {
+	bytes.Buffer
+	max      int
+	truncate bool
+}
+
+func (b *execBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.Len(); len(p) > room {
+		if !b.truncate {
+			return 0, errExecFileTooLarge
+		}
+		b.Buffer.Write(p[:max(room, 0)])
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
+
+// validateContainerPath checks a path inside a container: it must be
+// absolute, and is returned cleaned
+func validateContainerPath(p string) (string, error) {
+	if p == "" {
+		return "", errors.New("path is required")
+	}
+	if !strings.HasPrefix(p, "/") {
+		return "", errors.New("path must be absolute")
+	}
+	if strings.ContainsRune(p, 0) {
+		return "", errors.New("path contains a NUL byte")
+	}
+	return path.Clean(p), nil
+}
+
+// writeExecError reports a failed ExecRun or a command that exited
+// non-zero, using its stderr to tell common failures apart
+func writeExecError(w http.ResponseWriter, r *http.Request, err error, exitCode int, stderr, tool string) {
+	if err != nil {
+		var apiErr *podman.APIError
+		switch {
+		case errors.Is(err, errExecFileTooLarge):
+			writeJSONError(w, http.StatusRequestEntityTooLarge, "file_too_large",
+				fmt.Sprintf("File exceeds %d MB", maxExecFileSize/(1024*1024)))
+		case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict:
+			writeJSONError(w, http.StatusConflict, "container_not_running", "Container is not running")
+		case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusInternalServerError &&
+			strings.Contains(apiErr.Message, "executable file"):
+			writeJSONError(w, http.StatusUnprocessableEntity, "exec_failed",
+				fmt.Sprintf("%s is not available in the container", tool))
+		case errors.Is(err, podman.ErrStdinNotSupported):
+			writeJSONError(w, http.StatusNotImplemented, "not_implemented", err.Error())
+		default:
+			logger(r.Context()).Printf("Exec file transfer failed: %v", err)
+			writePodmanError(w, err, "container_not_found")
+		}
+		return
+	}
+
+	stderr = strings.TrimSpace(stderr)
+	switch {
+	case strings.Contains(stderr, "No such file or directory"):
+		writeJSONError(w, http.StatusNotFound, "file_not_found", "File or directory not found")
+	case strings.Contains(stderr, "Is a directory"):
+		writeJSONError(w, http.StatusBadRequest, "is_directory", "Path is a directory")
+	case strings.Contains(stderr, "Permission denied"):
+		writeJSONError(w, http.StatusForbidden, "permission_denied", "Permission denied in the container")
+	case strings.Contains(stderr, "Read-only file system"):
+		writeJSONError(w, http.StatusConflict, "read_only", "Read-only file system")
+	case exitCode == 126 || exitCode == 127:
+		writeJSONError(w, http.StatusUnprocessableEntity, "exec_failed",
+			fmt.Sprintf("%s is not available in the container", tool))
+	default:
+		if stderr == "" {
+			stderr = "exit code " + strconv.Itoa(exitCode)
+		}
+		writeJSONError(w, http.StatusUnprocessableEntity, "exec_failed", stderr)
+	}
+}
+
+// ExecDownload handles POST /api/containers/{id}/exec/download with
+// {"path": "/etc/app.conf"}. It reads a single file by running cat in the
+// container, so no archive handling is needed on either side.
+func (h *TerminalHandler) ExecDownload(w http.ResponseWriter, r *http.Request) {
+	user := auth.GetUserFromContext(r.Context())
+	containerID := chi.URLParam(r, "id")
+
+	var req struct {
+		Path string `json:"path"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	filePath, err := validateContainerPath(req.Path)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid_path", err.Error())
+		return
+	}
+
+	stdout := &execBuffer{max: maxExecFileSize}
+	stderr := &execBuffer{max: maxExecStderr, truncate: true}
+	exitCode, err := h.client.ExecRun(r.Context(), containerID, []string{"cat", filePath}, nil, stdout, stderr)
+	if err != nil || exitCode != 0 {
+		writeExecError(w, r, err, exitCode, stderr.String(), "cat")
+		return
+	}
+
+	h.eventStore.AddWithMeta(events.EventFileDownload, user.Username, getClientIP(r), true,
+		fmt.Sprintf("container=%s file=%s size=%d", shortID(containerID), filePath, stdout.Len()),
+		events.Meta{"container": containerID, "path": filePath, "size": strconv.Itoa(stdout.Len())})
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", sanitizeFilename(path.Base(filePath))))
+	w.Header().Set("Content-Length", strconv.Itoa(stdout.Len()))
+	w.Write(stdout.Bytes())
+}
+
+// ExecUpload handles POST /api/containers/{id}/exec/upload (multipart form
+// with "path" and "file"). The file is written with cat through sh; a path
+// ending in "/" is a directory and keeps the uploaded file's name.
+func (h *TerminalHandler) ExecUpload(w http.ResponseWriter, r *http.Request) {
+	user := auth.GetUserFromContext(r.Context())
+	containerID := chi.URLParam(r, "id")
+
+	// Allow some room for the multipart encoding
+	r.Body = http.MaxBytesReader(w, r.Body, maxExecFileSize+1024*1024)
+	if err := r.ParseMultipartForm(maxExecFileSize); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeJSONError(w, http.StatusRequestEntityTooLarge, "file_too_large",
+				fmt.Sprintf("File exceeds %d MB", maxExecFileSize/(1024*1024)))
+		} else {
+			writeJSONError(w, http.StatusBadRequest, "invalid_body", "Invalid form data")
+		}
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "missing_field", "File is required")
+		return
+	}
+	defer file.Close()
+	if header.Size > maxExecFileSize {
+		writeJSONError(w, http.StatusRequestEntityTooLarge, "file_too_large",
+			fmt.Sprintf("File exceeds %d MB", maxExecFileSize/(1024*1024)))
+		return
+	}
+
+	target := r.FormValue("path")
+	if strings.HasSuffix(target, "/") {
+		target += sanitizeFilename(path.Base(header.Filename))
+	}
+	filePath, err := validateContainerPath(target)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid_path", err.Error())
+		return
+	}
+
+	// The path is passed as an argument, never interpolated into the script
+	cmd := []string{"sh", "-c", `cat > "$1"`, "sh", filePath}
+	stderr := &execBuffer{max: maxExecStderr, truncate: true}
+	exitCode, err := h.client.ExecRun(r.Context(), containerID, cmd, file, &execBuffer{max: maxExecStderr, truncate: true}, stderr)
+	if err != nil || exitCode != 0 {
+		writeExecError(w, r, err, exitCode, stderr.String(), "sh")
+		return
+	}
+
+	h.eventStore.AddWithMeta(events.EventFileUpload, user.Username, getClientIP(r), true,
+		fmt.Sprintf("container=%s file=%s size=%d", shortID(containerID), filePath, header.Size),
+		events.Meta{"container": containerID, "path": filePath, "size": strconv.FormatInt(header.Size, 10)})
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"path":    filePath,
+		"size":    header.Size,
+	})
+}
@@ -0,0 +1,192 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"podmanview/internal/auth"
+	"podmanview/internal/events"
+)
+
+// maxBatchPaths caps the number of paths in one batch request
+const maxBatchPaths = 1000
+
+// BatchRequest is the body of POST /api/files/batch
+type BatchRequest struct {
+	Action      string   `json:"action"`             // "delete" or "move"
+	Paths       []string `json:"paths"`              // Paths relative to baseDir
+	DestDir     string   `json:"dest_dir,omitempty"` // Target directory for "move"
+	StopOnError bool     `json:"stop_on_error"`      // Skip remaining paths after the first failure
+}
+
+// BatchResult is the outcome for one path of a batch request
+type BatchResult struct {
+	Path    string       `json:"path"`
+	Success bool         `json:"success"`
+	NewPath string       `json:"new_path,omitempty"` // Destination of a moved path
+	Error   *ErrorDetail `json:"error,omitempty"`
+}
+
+// BatchResponse lists a result per requested path, in request order
+type BatchResponse struct {
+	Results   []BatchResult `json:"results"`
+	Succeeded int           `json:"succeeded"`
+	Failed    int           `json:"failed"`
+}
+
+// batchError is a failed batch item, reported as an ErrorDetail
+type batchError struct {
+	code    string
+	message string
+}
+
+func (e *batchError) Error() string { return e.message }
+
+// Batch handles POST /api/files/batch, deleting or moving several paths in
+// one request. Each path is validated and processed in order; by default
+// all paths are attempted, with stop_on_error the remaining ones are
+// reported as skipped after the first failure.
+func (h *FileManagerHandler) Batch(w http.ResponseWriter, r *http.Request) {
+	user := auth.GetUserFromContext(r.Context())
+
+	var req BatchRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if len(req.Paths) == 0 {
+		writeJSONError(w, http.StatusBadRequest, "missing_field", "Paths are required")
+		return
+	}
+	if len(req.Paths) > maxBatchPaths {
+		writeJSONError(w, http.StatusBadRequest, "too_many_paths",
+			fmt.Sprintf("At most %d paths per batch", maxBatchPaths))
+		return
+	}
+
+	var apply func(absPath string) (string, error)
+	switch req.Action {
+	case "delete":
+		apply = h.batchDelete
+	case "move":
+		if req.DestDir == "" {
+			writeJSONError(w, http.StatusBadRequest, "missing_field", "dest_dir is required for move")
+			return
+		}
+		absDest, err := h.validatePath(req.DestDir)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid_path", err.Error())
+			return
+		}
+		stat, err := os.Stat(absDest)
+		if err != nil {
+			if os.IsNotExist(err) {
+				writeJSONError(w, http.StatusNotFound, "file_not_found", "Destination directory not found")
+			} else {
+				writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to access destination")
+			}
+			return
+		}
+		if !stat.IsDir() {
+			writeJSONError(w, http.StatusBadRequest, "not_a_directory", "Destination is not a directory")
+			return
+		}
+		apply = func(absPath string) (string, error) {
+			return h.batchMove(absPath, absDest)
+		}
+	default:
+		writeJSONError(w, http.StatusBadRequest, "invalid_action", "Action must be delete or move")
+		return
+	}
+
+	resp := BatchResponse{Results: make([]BatchResult, 0, len(req.Paths))}
+	stopped := false
+	for _, requestedPath := range req.Paths {
+		result := BatchResult{Path: requestedPath}
+		if stopped {
+			result.Error = &ErrorDetail{Code: "skipped", Message: "Skipped after an earlier error"}
+			resp.Results = append(resp.Results, result)
+			resp.Failed++
+			continue
+		}
+
+		newPath, err := h.batchApply(requestedPath, apply)
+		if err != nil {
+			var be *batchError
+			if !errors.As(err, &be) {
+				logger(r.Context()).Printf("Batch %s of %s failed: %v", req.Action, requestedPath, err)
+				be = &batchError{"internal_error", fmt.Sprintf("Failed to %s", req.Action)}
+			}
+			result.Error = &ErrorDetail{Code: be.code, Message: be.message}
+			resp.Failed++
+			stopped = req.StopOnError
+		} else {
+			result.Success = true
+			result.NewPath = newPath
+			resp.Succeeded++
+		}
+		resp.Results = append(resp.Results, result)
+	}
+
+	h.eventStore.AddWithMeta(events.EventFileBatch, user.Username, getClientIP(r), resp.Failed == 0,
+		fmt.Sprintf("action=%s paths=%d succeeded=%d failed=%d", req.Action, len(req.Paths), resp.Succeeded, resp.Failed),
+		events.Meta{"action": req.Action, "count": fmt.Sprint(len(req.Paths))})
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// batchApply validates one batch path and applies the action to it
+func (h *FileManagerHandler) batchApply(requestedPath string, apply func(string) (string, error)) (string, error) {
+	if requestedPath == "" || requestedPath == "/" {
+		return "", &batchError{"base_directory", "Cannot modify base directory"}
+	}
+	absPath, err := h.validatePath(requestedPath)
+	if err != nil {
+		return "", &batchError{"invalid_path", err.Error()}
+	}
+	if absPath == h.baseDir {
+		return "", &batchError{"base_directory", "Cannot modify base directory"}
+	}
+	if _, err := os.Lstat(absPath); err != nil {
+		if os.IsNotExist(err) {
+			return "", &batchError{"file_not_found", "File or directory not found"}
+		}
+		return "", err
+	}
+	return apply(absPath)
+}
+
+// batchDelete removes a file or directory tree
+func (h *FileManagerHandler) batchDelete(absPath string) (string, error) {
+	err := os.RemoveAll(absPath)
+	h.pathCache.invalidate(absPath) // even on failure, part of it may be gone
+	return "", err
+}
+
+// batchMove moves a file or directory into destDir, keeping its name
+func (h *FileManagerHandler) batchMove(absPath, destDir string) (string, error) {
+	target := filepath.Join(destDir, filepath.Base(absPath))
+	if target == absPath {
+		return "", &batchError{"invalid_path", "Already in the destination directory"}
+	}
+	if strings.HasPrefix(destDir+string(filepath.Separator), absPath+string(filepath.Separator)) {
+		return "", &batchError{"invalid_path", "Cannot move a directory into itself"}
+	}
+	if _, err := os.Lstat(target); err == nil {
+		return "", &batchError{"file_exists", "A file or directory with that name already exists in the destination"}
+	}
+
+	if err := os.Rename(absPath, target); err != nil {
+		if errors.Is(err, syscall.EXDEV) {
+			return "", &batchError{"cross_device", "Cannot move across filesystems"}
+		}
+		return "", err
+	}
+	h.pathCache.invalidate(absPath)
+	return h.getRelativePath(target), nil
+}
@@ -1,8 +1,8 @@
 package api
 
 import (
+	"container/list"
 	"encoding/base64"
-	"encoding/json"
 	"fmt"
 	"io"
 	"log"
@@ -64,6 +64,84 @@ var mimeTypesByExtension = map[string]string{
 	".txt":  "text/plain",
 }
 
+// Editor language identifiers by extension, parallel to mimeTypesByExtension
+var languagesByExtension = map[string]string{
+	// Go
+	".go":  "go",
+	".mod": "go.mod",
+
+	// Scripting
+	".py":   "python",
+	".pyw":  "python",
+	".rb":   "ruby",
+	".php":  "php",
+	".pl":   "perl",
+	".lua":  "lua",
+	".sh":   "shell",
+	".bash": "shell",
+	".zsh":  "shell",
+	".fish": "shell",
+	".ps1":  "powershell",
+
+	// Web
+	".js":   "javascript",
+	".mjs":  "javascript",
+	".cjs":  "javascript",
+	".jsx":  "javascript",
+	".ts":   "typescript",
+	".tsx":  "typescript",
+	".html": "html",
+	".htm":  "html",
+	".css":  "css",
+	".scss": "scss",
+	".sass": "sass",
+	".less": "less",
+	".vue":  "vue",
+
+	// Compiled
+	".c":     "c",
+	".h":     "c",
+	".cpp":   "cpp",
+	".cc":    "cpp",
+	".hpp":   "cpp",
+	".rs":    "rust",
+	".java":  "java",
+	".kt":    "kotlin",
+	".swift": "swift",
+	".cs":    "csharp",
+
+	// Data and config
+	".json": "json",
+	".yaml": "yaml",
+	".yml":  "yaml",
+	".toml": "toml",
+	".xml":  "xml",
+	".ini":  "ini",
+	".conf": "ini",
+	".cfg":  "ini",
+	".env":  "ini",
+	".sql":  "sql",
+	".md":   "markdown",
+
+	// systemd units and Podman Quadlet files
+	".service":   "ini",
+	".timer":     "ini",
+	".socket":    "ini",
+	".container": "ini",
+	".pod":       "ini",
+	".volume":    "ini",
+	".network":   "ini",
+	".kube":      "ini",
+}
+
+// Editor languages of files recognized by name rather than extension
+var languagesByFileName = map[string]string{
+	"dockerfile":    "dockerfile",
+	"containerfile": "dockerfile",
+	"makefile":      "makefile",
+	"go.sum":        "plaintext",
+}
+
 var binaryMimePrefixes = []string{
 	"image/",
 	"video/",
@@ -91,20 +169,30 @@ var binaryExtensions = []string{
 // FileManagerHandler handles file operations
 type FileManagerHandler struct {
 	eventStore    *events.Store
+	wsTokenStore  *auth.WSTokenStore
 	baseDir       string // Base directory for file operations (e.g., /home)
 	maxUploadSize int64  // Maximum upload size in bytes (default 100MB)
 	pathCache     *pathValidationCache
+	thumbnails    *thumbnailer
+	watchSlots    chan struct{} // limits concurrent directory watches
 }
 
-// pathValidationCache caches validated paths to avoid repeated validation
+// pathValidationCache is an LRU cache of validated paths, used to avoid
+// repeated validation
 type pathValidationCache struct {
-	sync.RWMutex
-	cache map[string]string // requestPath -> absPath
+	sync.Mutex
+	cache   map[string]*list.Element // requestPath -> element holding a pathCacheEntry
+	order   *list.List               // most recently used first
 	maxSize int
 }
 
+// pathCacheEntry is a cached requestPath -> absPath mapping
+type pathCacheEntry struct {
+	key, absPath string
+}
+
 // NewFileManagerHandler creates new file manager handler
-func NewFileManagerHandler(eventStore *events.Store, baseDir string) *FileManagerHandler {
+func NewFileManagerHandler(eventStore *events.Store, wsTokenStore *auth.WSTokenStore, baseDir string) *FileManagerHandler {
 	// If baseDir is empty, use user's home directory or root
 	if baseDir == "" {
 		if homeDir, err := os.UserHomeDir(); err == nil {
@@ -123,42 +211,70 @@ func NewFileManagerHandler(eventStore *events.Store, baseDir string) *FileManage
 
 	return &FileManagerHandler{
 		eventStore:    eventStore,
+		wsTokenStore:  wsTokenStore,
 		baseDir:       baseDir,
-		maxUploadSize: 100 * 1024 * 1024, // 100MB default
-		pathCache: &pathValidationCache{
-			cache:   make(map[string]string),
-			maxSize: 1000, // Cache up to 1000 paths
-		},
+		maxUploadSize: 100 * 1024 * 1024,            // 100MB default
+		pathCache:     newPathValidationCache(1000), // Cache up to 1000 paths
+		thumbnails:    newThumbnailer(),
+		watchSlots:    make(chan struct{}, maxFileWatches),
+	}
+}
+
+// newPathValidationCache creates a path validation cache holding up to maxSize paths
+func newPathValidationCache(maxSize int) *pathValidationCache {
+	return &pathValidationCache{
+		cache:   make(map[string]*list.Element),
+		order:   list.New(),
+		maxSize: maxSize,
 	}
 }
 
-// newPathValidationCache creates a new path validation cache
+// get returns the cached absolute path for a requested path
 func (c *pathValidationCache) get(key string) (string, bool) {
-	c.RLock()
-	defer c.RUnlock()
-	val, ok := c.cache[key]
-	return val, ok
+	c.Lock()
+	defer c.Unlock()
+	elem, ok := c.cache[key]
+	if !ok {
+		return "", false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*pathCacheEntry).absPath, true
 }
 
-// set stores a validated path in cache
+// set stores a validated path in cache, evicting the least recently used
+// path when full
 func (c *pathValidationCache) set(key, value string) {
 	c.Lock()
 	defer c.Unlock()
 
-	// Simple LRU: if cache is full, clear half of it
-	if len(c.cache) >= c.maxSize {
-		// Clear half the cache (simple eviction strategy)
-		count := 0
-		for k := range c.cache {
-			delete(c.cache, k)
-			count++
-			if count >= c.maxSize/2 {
-				break
-			}
-		}
+	if elem, ok := c.cache[key]; ok {
+		elem.Value.(*pathCacheEntry).absPath = value
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	if c.order.Len() >= c.maxSize {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.cache, oldest.Value.(*pathCacheEntry).key)
 	}
+	c.cache[key] = c.order.PushFront(&pathCacheEntry{key: key, absPath: value})
+}
 
-	c.cache[key] = value
+// invalidate removes cached paths at or below absPath, called after it
+// is renamed or deleted
+func (c *pathValidationCache) invalidate(absPath string) {
+	c.Lock()
+	defer c.Unlock()
+
+	prefix := strings.TrimSuffix(absPath, string(filepath.Separator)) + string(filepath.Separator)
+	for key, elem := range c.cache {
+		cached := elem.Value.(*pathCacheEntry).absPath
+		if cached == absPath || strings.HasPrefix(cached, prefix) {
+			c.order.Remove(elem)
+			delete(c.cache, key)
+		}
+	}
 }
 
 // FileInfo represents file or directory information
@@ -173,13 +289,15 @@ type FileInfo struct {
 
 // BrowseResponse represents directory browsing response
 type BrowseResponse struct {
-	Path       string     `json:"path"`        // Current path relative to baseDir
-	Parent     string     `json:"parent"`      // Parent directory path
-	Items      []FileInfo `json:"items"`       // Files and directories
-	TotalCount int        `json:"total_count"` // Total number of items in directory
-	Offset     int        `json:"offset"`      // Current offset
-	Limit      int        `json:"limit"`       // Items per page
-	HasMore    bool       `json:"has_more"`    // Whether there are more items
+	Path       string     `json:"path"`                 // Current path relative to baseDir
+	Parent     string     `json:"parent"`               // Parent directory path
+	Items      []FileInfo `json:"items"`                // Files and directories
+	TotalCount int        `json:"total_count"`          // Total number of items in directory
+	Offset     int        `json:"offset"`               // Current offset
+	Limit      int        `json:"limit"`                // Items per page
+	HasMore    bool       `json:"has_more"`             // Whether there are more items
+	DiskTotal  uint64     `json:"disk_total,omitempty"` // Size of the directory's filesystem in bytes
+	DiskFree   uint64     `json:"disk_free,omitempty"`  // Bytes available for writing on it
 }
 
 // validatePath checks if path is safe and within baseDir
@@ -239,13 +357,50 @@ func (h *FileManagerHandler) getRelativePath(absPath string) string {
 	return "/" + filepath.ToSlash(relPath)
 }
 
+// browseStatWorkers bounds the concurrent stat calls of a directory listing
+const browseStatWorkers = 16
+
+// statEntries calls Info() on entries using a bounded worker pool.
+// On network filesystems each stat is a round trip, so running them in
+// parallel makes large listings much faster. Results keep the order of
+// entries; entries that can't be stat'ed are nil.
+func statEntries(entries []os.DirEntry) []os.FileInfo {
+	infos := make([]os.FileInfo, len(entries))
+	workers := min(browseStatWorkers, len(entries))
+	if workers <= 1 {
+		for i, entry := range entries {
+			if info, err := entry.Info(); err == nil {
+				infos[i] = info
+			}
+		}
+		return infos
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				// Each worker writes only its own indexes
+				if info, err := entries[i].Info(); err == nil {
+					infos[i] = info
+				}
+			}
+		}()
+	}
+	for i := range entries {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return infos
+}
+
 // Browse lists files and directories with pagination
 func (h *FileManagerHandler) Browse(w http.ResponseWriter, r *http.Request) {
 	user := auth.GetUserFromContext(r.Context())
-	if !user.IsAdmin() {
-		http.Error(w, "Admin access required", http.StatusForbidden)
-		return
-	}
 
 	requestedPath := r.URL.Query().Get("path")
 	if requestedPath == "" {
@@ -269,7 +424,7 @@ func (h *FileManagerHandler) Browse(w http.ResponseWriter, r *http.Request) {
 	// Validate and get absolute path
 	absPath, err := h.validatePath(requestedPath)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "invalid_path", err.Error())
 		return
 	}
 
@@ -277,23 +432,23 @@ func (h *FileManagerHandler) Browse(w http.ResponseWriter, r *http.Request) {
 	stat, err := os.Stat(absPath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			http.Error(w, "Directory not found", http.StatusNotFound)
+			writeJSONError(w, http.StatusNotFound, "file_not_found", "Directory not found")
 		} else {
-			http.Error(w, "Failed to access directory", http.StatusInternalServerError)
+			writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to access directory")
 		}
 		return
 	}
 
 	if !stat.IsDir() {
-		http.Error(w, "Path is not a directory", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "not_a_directory", "Path is not a directory")
 		return
 	}
 
 	// Read directory contents
 	entries, err := os.ReadDir(absPath)
 	if err != nil {
-		http.Error(w, "Failed to read directory", http.StatusInternalServerError)
-		log.Printf("Failed to read directory %s: %v", absPath, err)
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to read directory")
+		logger(r.Context()).Printf("Failed to read directory %s: %v", absPath, err)
 		return
 	}
 
@@ -312,10 +467,11 @@ func (h *FileManagerHandler) Browse(w http.ResponseWriter, r *http.Request) {
 	paginatedEntries := entries[start:end]
 
 	// Build file list for current page
+	infos := statEntries(paginatedEntries)
 	items := make([]FileInfo, 0, len(paginatedEntries))
-	for _, entry := range paginatedEntries {
-		info, err := entry.Info()
-		if err != nil {
+	for i, entry := range paginatedEntries {
+		info := infos[i]
+		if info == nil {
 			continue // Skip files we can't stat
 		}
 
@@ -348,10 +504,12 @@ func (h *FileManagerHandler) Browse(w http.ResponseWriter, r *http.Request) {
 		Limit:      limit,
 		HasMore:    end < totalCount,
 	}
+	response.DiskTotal, response.DiskFree = getDiskUsage(absPath)
 
 	// Log browse event
-	h.eventStore.Add(events.EventFileBrowse, user.Username, getClientIP(r), true,
-		fmt.Sprintf("path=%s items=%d/%d", h.getRelativePath(absPath), len(items), totalCount))
+	h.eventStore.AddWithMeta(events.EventFileBrowse, user.Username, getClientIP(r), true,
+		fmt.Sprintf("path=%s items=%d/%d", h.getRelativePath(absPath), len(items), totalCount),
+		events.Meta{"path": h.getRelativePath(absPath)})
 
 	writeJSON(w, http.StatusOK, response)
 }
@@ -359,21 +517,17 @@ func (h *FileManagerHandler) Browse(w http.ResponseWriter, r *http.Request) {
 // Download serves a file for download
 func (h *FileManagerHandler) Download(w http.ResponseWriter, r *http.Request) {
 	user := auth.GetUserFromContext(r.Context())
-	if !user.IsAdmin() {
-		http.Error(w, "Admin access required", http.StatusForbidden)
-		return
-	}
 
 	requestedPath := r.URL.Query().Get("path")
 	if requestedPath == "" {
-		http.Error(w, "Path is required", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "missing_field", "Path is required")
 		return
 	}
 
 	// Validate and get absolute path
 	absPath, err := h.validatePath(requestedPath)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "invalid_path", err.Error())
 		return
 	}
 
@@ -381,32 +535,29 @@ func (h *FileManagerHandler) Download(w http.ResponseWriter, r *http.Request) {
 	stat, err := os.Stat(absPath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			http.Error(w, "File not found", http.StatusNotFound)
+			writeJSONError(w, http.StatusNotFound, "file_not_found", "File not found")
 		} else {
-			http.Error(w, "Failed to access file", http.StatusInternalServerError)
+			writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to access file")
 		}
 		return
 	}
 
 	if stat.IsDir() {
-		http.Error(w, "Cannot download directory", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "is_directory", "Cannot download directory")
 		return
 	}
 
 	// Open file
 	file, err := os.Open(absPath)
 	if err != nil {
-		http.Error(w, "Failed to open file", http.StatusInternalServerError)
-		log.Printf("Failed to open file %s: %v", absPath, err)
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to open file")
+		logger(r.Context()).Printf("Failed to open file %s: %v", absPath, err)
 		return
 	}
 	defer file.Close()
 
 	// Detect content type
-	contentType := mime.TypeByExtension(filepath.Ext(absPath))
-	if contentType == "" {
-		contentType = "application/octet-stream"
-	}
+	contentType := detectContentType(file, filepath.Ext(absPath))
 
 	// Set headers
 	w.Header().Set("Content-Type", contentType)
@@ -418,22 +569,19 @@ func (h *FileManagerHandler) Download(w http.ResponseWriter, r *http.Request) {
 	// Stream file to client
 	_, err = io.Copy(w, file)
 	if err != nil {
-		log.Printf("Failed to send file %s: %v", absPath, err)
+		logger(r.Context()).Printf("Failed to send file %s: %v", absPath, err)
 		return
 	}
 
 	// Log download event
-	h.eventStore.Add(events.EventFileDownload, user.Username, getClientIP(r), true,
-		fmt.Sprintf("file=%s size=%d", filepath.Base(absPath), stat.Size()))
+	h.eventStore.AddWithMeta(events.EventFileDownload, user.Username, getClientIP(r), true,
+		fmt.Sprintf("file=%s size=%d", filepath.Base(absPath), stat.Size()),
+		events.Meta{"path": h.getRelativePath(absPath), "size": strconv.FormatInt(stat.Size(), 10)})
 }
 
 // Upload handles file uploads (multipart form)
 func (h *FileManagerHandler) Upload(w http.ResponseWriter, r *http.Request) {
 	user := auth.GetUserFromContext(r.Context())
-	if !user.IsAdmin() {
-		http.Error(w, "Admin access required", http.StatusForbidden)
-		return
-	}
 
 	// Limit request body size
 	r.Body = http.MaxBytesReader(w, r.Body, h.maxUploadSize)
@@ -441,7 +589,7 @@ func (h *FileManagerHandler) Upload(w http.ResponseWriter, r *http.Request) {
 	// Parse multipart form
 	err := r.ParseMultipartForm(h.maxUploadSize)
 	if err != nil {
-		http.Error(w, "File too large or invalid form data", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "file_too_large", "File too large or invalid form data")
 		return
 	}
 
@@ -454,21 +602,21 @@ func (h *FileManagerHandler) Upload(w http.ResponseWriter, r *http.Request) {
 	// Validate target directory
 	absTargetDir, err := h.validatePath(targetPath)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "invalid_path", err.Error())
 		return
 	}
 
 	// Check if target is a directory
 	stat, err := os.Stat(absTargetDir)
 	if err != nil || !stat.IsDir() {
-		http.Error(w, "Target path is not a directory", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "not_a_directory", "Target path is not a directory")
 		return
 	}
 
 	// Get uploaded files
 	files := r.MultipartForm.File["files"]
 	if len(files) == 0 {
-		http.Error(w, "No files uploaded", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "bad_request", "No files uploaded")
 		return
 	}
 
@@ -518,14 +666,15 @@ func (h *FileManagerHandler) Upload(w http.ResponseWriter, r *http.Request) {
 		for _, filename := range uploadedFiles {
 			os.Remove(filepath.Join(absTargetDir, filename))
 		}
-		http.Error(w, uploadErr.Error(), http.StatusInternalServerError)
-		log.Printf("Upload failed: %v", uploadErr)
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", uploadErr.Error())
+		logger(r.Context()).Printf("Upload failed: %v", uploadErr)
 		return
 	}
 
 	// Log upload event
-	h.eventStore.Add(events.EventFileUpload, user.Username, getClientIP(r), true,
-		fmt.Sprintf("files=%d path=%s", len(uploadedFiles), h.getRelativePath(absTargetDir)))
+	h.eventStore.AddWithMeta(events.EventFileUpload, user.Username, getClientIP(r), true,
+		fmt.Sprintf("files=%d path=%s", len(uploadedFiles), h.getRelativePath(absTargetDir)),
+		events.Meta{"path": h.getRelativePath(absTargetDir), "files": strconv.Itoa(len(uploadedFiles))})
 
 	writeJSON(w, http.StatusOK, map[string]interface{}{
 		"success": true,
@@ -537,27 +686,23 @@ func (h *FileManagerHandler) Upload(w http.ResponseWriter, r *http.Request) {
 // Delete removes a file or directory
 func (h *FileManagerHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	user := auth.GetUserFromContext(r.Context())
-	if !user.IsAdmin() {
-		http.Error(w, "Admin access required", http.StatusForbidden)
-		return
-	}
 
 	requestedPath := r.URL.Query().Get("path")
 	if requestedPath == "" || requestedPath == "/" {
-		http.Error(w, "Cannot delete root directory", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "base_directory", "Cannot delete root directory")
 		return
 	}
 
 	// Validate and get absolute path
 	absPath, err := h.validatePath(requestedPath)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "invalid_path", err.Error())
 		return
 	}
 
 	// Prevent deleting baseDir
 	if absPath == h.baseDir {
-		http.Error(w, "Cannot delete base directory", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "base_directory", "Cannot delete base directory")
 		return
 	}
 
@@ -565,18 +710,19 @@ func (h *FileManagerHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	stat, err := os.Stat(absPath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			http.Error(w, "File or directory not found", http.StatusNotFound)
+			writeJSONError(w, http.StatusNotFound, "file_not_found", "File or directory not found")
 		} else {
-			http.Error(w, "Failed to access path", http.StatusInternalServerError)
+			writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to access path")
 		}
 		return
 	}
 
 	// Remove file or directory (recursively if directory)
 	err = os.RemoveAll(absPath)
+	h.pathCache.invalidate(absPath) // even on failure, part of it may be gone
 	if err != nil {
-		http.Error(w, "Failed to delete", http.StatusInternalServerError)
-		log.Printf("Failed to delete %s: %v", absPath, err)
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to delete")
+		logger(r.Context()).Printf("Failed to delete %s: %v", absPath, err)
 		return
 	}
 
@@ -585,8 +731,9 @@ func (h *FileManagerHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	if stat.IsDir() {
 		itemType = "directory"
 	}
-	h.eventStore.Add(events.EventFileDelete, user.Username, getClientIP(r), true,
-		fmt.Sprintf("type=%s path=%s", itemType, h.getRelativePath(absPath)))
+	h.eventStore.AddWithMeta(events.EventFileDelete, user.Username, getClientIP(r), true,
+		fmt.Sprintf("type=%s path=%s", itemType, h.getRelativePath(absPath)),
+		events.Meta{"path": h.getRelativePath(absPath), "type": itemType})
 
 	writeJSON(w, http.StatusOK, map[string]interface{}{
 		"success": true,
@@ -597,30 +744,25 @@ func (h *FileManagerHandler) Delete(w http.ResponseWriter, r *http.Request) {
 // MkDir creates a new directory
 func (h *FileManagerHandler) MkDir(w http.ResponseWriter, r *http.Request) {
 	user := auth.GetUserFromContext(r.Context())
-	if !user.IsAdmin() {
-		http.Error(w, "Admin access required", http.StatusForbidden)
-		return
-	}
 
 	var req struct {
 		Path string `json:"path"`
 		Name string `json:"name"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
 	if req.Name == "" {
-		http.Error(w, "Directory name is required", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "missing_field", "Directory name is required")
 		return
 	}
 
 	// Validate directory name (prevent path traversal)
 	dirName := filepath.Base(req.Name)
 	if dirName == "" || dirName == "." || dirName == ".." || strings.Contains(dirName, "/") || strings.Contains(dirName, "\\") {
-		http.Error(w, "Invalid directory name", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "invalid_name", "Invalid directory name")
 		return
 	}
 
@@ -632,14 +774,14 @@ func (h *FileManagerHandler) MkDir(w http.ResponseWriter, r *http.Request) {
 
 	absParentDir, err := h.validatePath(parentPath)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "invalid_path", err.Error())
 		return
 	}
 
 	// Check if parent is a directory
 	stat, err := os.Stat(absParentDir)
 	if err != nil || !stat.IsDir() {
-		http.Error(w, "Parent path is not a directory", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "not_a_directory", "Parent path is not a directory")
 		return
 	}
 
@@ -648,17 +790,18 @@ func (h *FileManagerHandler) MkDir(w http.ResponseWriter, r *http.Request) {
 	err = os.Mkdir(newDirPath, 0755)
 	if err != nil {
 		if os.IsExist(err) {
-			http.Error(w, "Directory already exists", http.StatusConflict)
+			writeJSONError(w, http.StatusConflict, "file_exists", "Directory already exists")
 		} else {
-			http.Error(w, "Failed to create directory", http.StatusInternalServerError)
-			log.Printf("Failed to create directory %s: %v", newDirPath, err)
+			writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to create directory")
+			logger(r.Context()).Printf("Failed to create directory %s: %v", newDirPath, err)
 		}
 		return
 	}
 
 	// Log mkdir event
-	h.eventStore.Add(events.EventFileMkdir, user.Username, getClientIP(r), true,
-		fmt.Sprintf("path=%s", h.getRelativePath(newDirPath)))
+	h.eventStore.AddWithMeta(events.EventFileMkdir, user.Username, getClientIP(r), true,
+		fmt.Sprintf("path=%s", h.getRelativePath(newDirPath)),
+		events.Meta{"path": h.getRelativePath(newDirPath)})
 
 	writeJSON(w, http.StatusOK, map[string]interface{}{
 		"success": true,
@@ -670,30 +813,25 @@ func (h *FileManagerHandler) MkDir(w http.ResponseWriter, r *http.Request) {
 // CreateFile creates a new empty file
 func (h *FileManagerHandler) CreateFile(w http.ResponseWriter, r *http.Request) {
 	user := auth.GetUserFromContext(r.Context())
-	if !user.IsAdmin() {
-		http.Error(w, "Admin access required", http.StatusForbidden)
-		return
-	}
 
 	var req struct {
 		Path string `json:"path"`
 		Name string `json:"name"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
 	if req.Name == "" {
-		http.Error(w, "File name is required", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "missing_field", "File name is required")
 		return
 	}
 
 	// Validate file name (prevent path traversal)
 	fileName := filepath.Base(req.Name)
 	if fileName == "" || fileName == "." || fileName == ".." || strings.Contains(fileName, "/") || strings.Contains(fileName, "\\") {
-		http.Error(w, "Invalid file name", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "invalid_name", "Invalid file name")
 		return
 	}
 
@@ -705,14 +843,14 @@ func (h *FileManagerHandler) CreateFile(w http.ResponseWriter, r *http.Request)
 
 	absParentDir, err := h.validatePath(parentPath)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "invalid_path", err.Error())
 		return
 	}
 
 	// Check if parent is a directory
 	stat, err := os.Stat(absParentDir)
 	if err != nil || !stat.IsDir() {
-		http.Error(w, "Parent path is not a directory", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "not_a_directory", "Parent path is not a directory")
 		return
 	}
 
@@ -721,22 +859,23 @@ func (h *FileManagerHandler) CreateFile(w http.ResponseWriter, r *http.Request)
 
 	// Check if file already exists
 	if _, err := os.Stat(newFilePath); err == nil {
-		http.Error(w, "File already exists", http.StatusConflict)
+		writeJSONError(w, http.StatusConflict, "file_exists", "File already exists")
 		return
 	}
 
 	// Create empty file
 	file, err := os.Create(newFilePath)
 	if err != nil {
-		http.Error(w, "Failed to create file", http.StatusInternalServerError)
-		log.Printf("Failed to create file %s: %v", newFilePath, err)
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to create file")
+		logger(r.Context()).Printf("Failed to create file %s: %v", newFilePath, err)
 		return
 	}
 	file.Close()
 
 	// Log file create event
-	h.eventStore.Add(events.EventFileWrite, user.Username, getClientIP(r), true,
-		fmt.Sprintf("path=%s action=create", h.getRelativePath(newFilePath)))
+	h.eventStore.AddWithMeta(events.EventFileWrite, user.Username, getClientIP(r), true,
+		fmt.Sprintf("path=%s action=create", h.getRelativePath(newFilePath)),
+		events.Meta{"path": h.getRelativePath(newFilePath), "action": "create"})
 
 	writeJSON(w, http.StatusOK, map[string]interface{}{
 		"success": true,
@@ -748,52 +887,47 @@ func (h *FileManagerHandler) CreateFile(w http.ResponseWriter, r *http.Request)
 // Rename renames a file or directory
 func (h *FileManagerHandler) Rename(w http.ResponseWriter, r *http.Request) {
 	user := auth.GetUserFromContext(r.Context())
-	if !user.IsAdmin() {
-		http.Error(w, "Admin access required", http.StatusForbidden)
-		return
-	}
 
 	var req struct {
 		OldPath string `json:"old_path"`
 		NewName string `json:"new_name"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
 	if req.OldPath == "" || req.NewName == "" {
-		http.Error(w, "Both old_path and new_name are required", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "missing_field", "Both old_path and new_name are required")
 		return
 	}
 
 	// Validate new name (prevent path traversal)
 	newName := filepath.Base(req.NewName)
 	if newName == "" || newName == "." || newName == ".." || strings.Contains(newName, "/") || strings.Contains(newName, "\\") {
-		http.Error(w, "Invalid new name", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "invalid_name", "Invalid new name")
 		return
 	}
 
 	// Validate old path
 	absOldPath, err := h.validatePath(req.OldPath)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "invalid_path", err.Error())
 		return
 	}
 
 	// Prevent renaming baseDir
 	if absOldPath == h.baseDir {
-		http.Error(w, "Cannot rename base directory", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "base_directory", "Cannot rename base directory")
 		return
 	}
 
 	// Check if old path exists
 	if _, err := os.Stat(absOldPath); err != nil {
 		if os.IsNotExist(err) {
-			http.Error(w, "File or directory not found", http.StatusNotFound)
+			writeJSONError(w, http.StatusNotFound, "file_not_found", "File or directory not found")
 		} else {
-			http.Error(w, "Failed to access path", http.StatusInternalServerError)
+			writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to access path")
 		}
 		return
 	}
@@ -804,21 +938,23 @@ func (h *FileManagerHandler) Rename(w http.ResponseWriter, r *http.Request) {
 
 	// Check if new path already exists
 	if _, err := os.Stat(absNewPath); err == nil {
-		http.Error(w, "A file or directory with that name already exists", http.StatusConflict)
+		writeJSONError(w, http.StatusConflict, "file_exists", "A file or directory with that name already exists")
 		return
 	}
 
 	// Rename
 	err = os.Rename(absOldPath, absNewPath)
 	if err != nil {
-		http.Error(w, "Failed to rename", http.StatusInternalServerError)
-		log.Printf("Failed to rename %s to %s: %v", absOldPath, absNewPath, err)
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to rename")
+		logger(r.Context()).Printf("Failed to rename %s to %s: %v", absOldPath, absNewPath, err)
 		return
 	}
+	h.pathCache.invalidate(absOldPath)
 
 	// Log rename event
-	h.eventStore.Add(events.EventFileRename, user.Username, getClientIP(r), true,
-		fmt.Sprintf("from=%s to=%s", filepath.Base(absOldPath), newName))
+	h.eventStore.AddWithMeta(events.EventFileRename, user.Username, getClientIP(r), true,
+		fmt.Sprintf("from=%s to=%s", filepath.Base(absOldPath), newName),
+		events.Meta{"path": h.getRelativePath(absOldPath), "to": newName})
 
 	writeJSON(w, http.StatusOK, map[string]interface{}{
 		"success": true,
@@ -830,21 +966,17 @@ func (h *FileManagerHandler) Rename(w http.ResponseWriter, r *http.Request) {
 // ReadFile reads file content for editing (optimized for memory)
 func (h *FileManagerHandler) ReadFile(w http.ResponseWriter, r *http.Request) {
 	user := auth.GetUserFromContext(r.Context())
-	if !user.IsAdmin() {
-		http.Error(w, "Admin access required", http.StatusForbidden)
-		return
-	}
 
 	requestedPath := r.URL.Query().Get("path")
 	if requestedPath == "" {
-		http.Error(w, "Path is required", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "missing_field", "Path is required")
 		return
 	}
 
 	// Validate and get absolute path
 	absPath, err := h.validatePath(requestedPath)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "invalid_path", err.Error())
 		return
 	}
 
@@ -852,22 +984,22 @@ func (h *FileManagerHandler) ReadFile(w http.ResponseWriter, r *http.Request) {
 	stat, err := os.Stat(absPath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			http.Error(w, "File not found", http.StatusNotFound)
+			writeJSONError(w, http.StatusNotFound, "file_not_found", "File not found")
 		} else {
-			http.Error(w, "Failed to access file", http.StatusInternalServerError)
+			writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to access file")
 		}
 		return
 	}
 
 	if stat.IsDir() {
-		http.Error(w, "Cannot read directory as file", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "is_directory", "Cannot read directory as file")
 		return
 	}
 
 	// Check file size (limit to 10MB for editing)
 	const maxEditSize = 10 * 1024 * 1024
 	if stat.Size() > maxEditSize {
-		http.Error(w, "File too large to edit (max 10MB)", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "file_too_large", "File too large to edit (max 10MB)")
 		return
 	}
 
@@ -884,8 +1016,8 @@ func (h *FileManagerHandler) ReadFile(w http.ResponseWriter, r *http.Request) {
 		// Read file content
 		content, err := os.ReadFile(absPath)
 		if err != nil {
-			http.Error(w, "Failed to read file", http.StatusInternalServerError)
-			log.Printf("Failed to read file %s: %v", absPath, err)
+			writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to read file")
+			logger(r.Context()).Printf("Failed to read file %s: %v", absPath, err)
 			return
 		}
 
@@ -905,14 +1037,16 @@ func (h *FileManagerHandler) ReadFile(w http.ResponseWriter, r *http.Request) {
 		}
 
 		// Log read event
-		h.eventStore.Add(events.EventFileRead, user.Username, getClientIP(r), true,
-			fmt.Sprintf("file=%s size=%d", filepath.Base(absPath), stat.Size()))
+		h.eventStore.AddWithMeta(events.EventFileRead, user.Username, getClientIP(r), true,
+			fmt.Sprintf("file=%s size=%d", filepath.Base(absPath), stat.Size()),
+			events.Meta{"path": h.getRelativePath(absPath), "size": strconv.FormatInt(stat.Size(), 10)})
 
 		writeJSON(w, http.StatusOK, map[string]interface{}{
 			"content":  contentStr,
 			"name":     filepath.Base(absPath),
 			"size":     stat.Size(),
 			"mimeType": mimeType,
+			"language": getLanguage(filepath.Base(absPath)),
 			"encoding": encoding,
 			"path":     h.getRelativePath(absPath),
 		})
@@ -926,8 +1060,9 @@ func (h *FileManagerHandler) ReadFile(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Log read event
-	h.eventStore.Add(events.EventFileRead, user.Username, getClientIP(r), true,
-		fmt.Sprintf("file=%s size=%d (streaming recommended)", filepath.Base(absPath), stat.Size()))
+	h.eventStore.AddWithMeta(events.EventFileRead, user.Username, getClientIP(r), true,
+		fmt.Sprintf("file=%s size=%d (streaming recommended)", filepath.Base(absPath), stat.Size()),
+		events.Meta{"path": h.getRelativePath(absPath), "size": strconv.FormatInt(stat.Size(), 10)})
 
 	writeJSON(w, http.StatusOK, map[string]interface{}{
 		"content":           "",
@@ -943,21 +1078,17 @@ func (h *FileManagerHandler) ReadFile(w http.ResponseWriter, r *http.Request) {
 // StreamFile streams file content (optimized for large binary files)
 func (h *FileManagerHandler) StreamFile(w http.ResponseWriter, r *http.Request) {
 	user := auth.GetUserFromContext(r.Context())
-	if !user.IsAdmin() {
-		http.Error(w, "Admin access required", http.StatusForbidden)
-		return
-	}
 
 	requestedPath := r.URL.Query().Get("path")
 	if requestedPath == "" {
-		http.Error(w, "Path is required", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "missing_field", "Path is required")
 		return
 	}
 
 	// Validate and get absolute path
 	absPath, err := h.validatePath(requestedPath)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "invalid_path", err.Error())
 		return
 	}
 
@@ -965,36 +1096,29 @@ func (h *FileManagerHandler) StreamFile(w http.ResponseWriter, r *http.Request)
 	stat, err := os.Stat(absPath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			http.Error(w, "File not found", http.StatusNotFound)
+			writeJSONError(w, http.StatusNotFound, "file_not_found", "File not found")
 		} else {
-			http.Error(w, "Failed to access file", http.StatusInternalServerError)
+			writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to access file")
 		}
 		return
 	}
 
 	if stat.IsDir() {
-		http.Error(w, "Cannot stream directory", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "is_directory", "Cannot stream directory")
 		return
 	}
 
 	// Open file
 	file, err := os.Open(absPath)
 	if err != nil {
-		http.Error(w, "Failed to open file", http.StatusInternalServerError)
-		log.Printf("Failed to open file %s: %v", absPath, err)
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to open file")
+		logger(r.Context()).Printf("Failed to open file %s: %v", absPath, err)
 		return
 	}
 	defer file.Close()
 
 	// Detect content type
-	ext := strings.ToLower(filepath.Ext(absPath))
-	contentType := getMimeTypeByExtension(ext)
-	if contentType == "" {
-		contentType = mime.TypeByExtension(ext)
-	}
-	if contentType == "" {
-		contentType = "application/octet-stream"
-	}
+	contentType := detectContentType(file, filepath.Ext(absPath))
 
 	// Set headers for inline viewing (not download)
 	w.Header().Set("Content-Type", contentType)
@@ -1013,13 +1137,14 @@ func (h *FileManagerHandler) StreamFile(w http.ResponseWriter, r *http.Request)
 	// Stream entire file
 	_, err = io.Copy(w, file)
 	if err != nil {
-		log.Printf("Failed to stream file %s: %v", absPath, err)
+		logger(r.Context()).Printf("Failed to stream file %s: %v", absPath, err)
 		return
 	}
 
 	// Log stream event
-	h.eventStore.Add(events.EventFileRead, user.Username, getClientIP(r), true,
-		fmt.Sprintf("stream file=%s size=%d", filepath.Base(absPath), stat.Size()))
+	h.eventStore.AddWithMeta(events.EventFileRead, user.Username, getClientIP(r), true,
+		fmt.Sprintf("stream file=%s size=%d", filepath.Base(absPath), stat.Size()),
+		events.Meta{"path": h.getRelativePath(absPath), "size": strconv.FormatInt(stat.Size(), 10), "action": "stream"})
 }
 
 // serveFileRange handles HTTP range requests for partial content
@@ -1031,7 +1156,7 @@ func (h *FileManagerHandler) serveFileRange(w http.ResponseWriter, r *http.Reque
 	if _, err := fmt.Sscanf(rangeHeader, "bytes=%d-%d", &start, &end); err != nil {
 		// Try parsing "bytes=start-" format
 		if _, err := fmt.Sscanf(rangeHeader, "bytes=%d-", &start); err != nil {
-			http.Error(w, "Invalid range", http.StatusRequestedRangeNotSatisfiable)
+			writeJSONError(w, http.StatusRequestedRangeNotSatisfiable, "invalid_range", "Invalid range")
 			return
 		}
 		end = fileSize - 1
@@ -1040,13 +1165,13 @@ func (h *FileManagerHandler) serveFileRange(w http.ResponseWriter, r *http.Reque
 	// Validate range
 	if start < 0 || start >= fileSize || end < start || end >= fileSize {
 		w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", fileSize))
-		http.Error(w, "Invalid range", http.StatusRequestedRangeNotSatisfiable)
+		writeJSONError(w, http.StatusRequestedRangeNotSatisfiable, "invalid_range", "Invalid range")
 		return
 	}
 
 	// Seek to start position
 	if _, err := file.Seek(start, 0); err != nil {
-		http.Error(w, "Failed to seek file", http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to seek file")
 		return
 	}
 
@@ -1061,37 +1186,32 @@ func (h *FileManagerHandler) serveFileRange(w http.ResponseWriter, r *http.Reque
 	// Stream the requested range
 	_, err := io.CopyN(w, file, contentLength)
 	if err != nil && err != io.EOF {
-		log.Printf("Failed to stream file range: %v", err)
+		logger(r.Context()).Printf("Failed to stream file range: %v", err)
 	}
 }
 
 // WriteFile saves file content after editing
 func (h *FileManagerHandler) WriteFile(w http.ResponseWriter, r *http.Request) {
 	user := auth.GetUserFromContext(r.Context())
-	if !user.IsAdmin() {
-		http.Error(w, "Admin access required", http.StatusForbidden)
-		return
-	}
 
 	var req struct {
 		Path    string `json:"path"`
 		Content string `json:"content"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
 	if req.Path == "" {
-		http.Error(w, "Path is required", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "missing_field", "Path is required")
 		return
 	}
 
 	// Validate and get absolute path
 	absPath, err := h.validatePath(req.Path)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "invalid_path", err.Error())
 		return
 	}
 
@@ -1099,29 +1219,30 @@ func (h *FileManagerHandler) WriteFile(w http.ResponseWriter, r *http.Request) {
 	stat, err := os.Stat(absPath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			http.Error(w, "File not found", http.StatusNotFound)
+			writeJSONError(w, http.StatusNotFound, "file_not_found", "File not found")
 		} else {
-			http.Error(w, "Failed to access file", http.StatusInternalServerError)
+			writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to access file")
 		}
 		return
 	}
 
 	if stat.IsDir() {
-		http.Error(w, "Cannot write to directory", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "is_directory", "Cannot write to directory")
 		return
 	}
 
 	// Write file content
 	err = os.WriteFile(absPath, []byte(req.Content), stat.Mode())
 	if err != nil {
-		http.Error(w, "Failed to write file", http.StatusInternalServerError)
-		log.Printf("Failed to write file %s: %v", absPath, err)
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to write file")
+		logger(r.Context()).Printf("Failed to write file %s: %v", absPath, err)
 		return
 	}
 
 	// Log write event
-	h.eventStore.Add(events.EventFileWrite, user.Username, getClientIP(r), true,
-		fmt.Sprintf("file=%s size=%d", filepath.Base(absPath), len(req.Content)))
+	h.eventStore.AddWithMeta(events.EventFileWrite, user.Username, getClientIP(r), true,
+		fmt.Sprintf("file=%s size=%d", filepath.Base(absPath), len(req.Content)),
+		events.Meta{"path": h.getRelativePath(absPath), "size": strconv.Itoa(len(req.Content))})
 
 	writeJSON(w, http.StatusOK, map[string]interface{}{
 		"success": true,
@@ -1135,6 +1256,41 @@ func getMimeTypeByExtension(ext string) string {
 	return mimeTypesByExtension[ext]
 }
 
+// detectContentType returns the MIME type of file, from its extension when
+// known and otherwise by sniffing its first 512 bytes, so media without an
+// extension can still be played inline. ReadAt leaves the file offset at
+// the start for streaming.
+func detectContentType(file *os.File, ext string) string {
+	ext = strings.ToLower(ext)
+	if contentType := getMimeTypeByExtension(ext); contentType != "" {
+		return contentType
+	}
+	// System tables map .bin and the like to octet-stream, sniff those too
+	if contentType := mime.TypeByExtension(ext); contentType != "" && contentType != "application/octet-stream" {
+		return contentType
+	}
+
+	buf := make([]byte, 512)
+	n, err := file.ReadAt(buf, 0)
+	if err != nil && err != io.EOF {
+		return "application/octet-stream"
+	}
+	return http.DetectContentType(buf[:n])
+}
+
+// getLanguage returns the editor language for a file name, or "" if unknown
+func getLanguage(name string) string {
+	name = strings.ToLower(name)
+	if lang, ok := languagesByFileName[name]; ok {
+		return lang
+	}
+	// Dockerfile.dev, Containerfile.arm64
+	if base, _, ok := strings.Cut(name, "."); ok && languagesByFileName[base] == "dockerfile" {
+		return "dockerfile"
+	}
+	return languagesByExtension[filepath.Ext(name)]
+}
+
 // isBinaryMimeType checks if MIME type represents binary content
 func isBinaryMimeType(mimeType string) bool {
 	for _, prefix := range binaryMimePrefixes {
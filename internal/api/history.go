@@ -1,32 +1,63 @@
 package api
 
 import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/go-chi/chi/v5"
+
+	"podmanview/internal/auth"
+	"podmanview/internal/events"
 	"podmanview/internal/storage"
 )
 
+// historyMaxCommands is how many commands are kept in history
+const historyMaxCommands = 500
+
 // HistoryHandler handles command history operations
 type HistoryHandler struct {
-	storage storage.Storage
-	mu      sync.RWMutex
+	storage    storage.Storage
+	eventStore *events.Store
+	mu         sync.RWMutex
 }
 
 // NewHistoryHandler creates new history handler
-func NewHistoryHandler(store storage.Storage) *HistoryHandler {
+func NewHistoryHandler(store storage.Storage, eventStore *events.Store) *HistoryHandler {
 	return &HistoryHandler{
-		storage: store,
+		storage:    store,
+		eventStore: eventStore,
 	}
 }
 
-// loadHistory returns command history array (last 50 commands)
-func (h *HistoryHandler) loadHistory() []string {
+// HistoryEntry represents a command in the history API.
+// Index is the position in the full history (0 = oldest).
+type HistoryEntry struct {
+	Index     int       `json:"index"`
+	Command   string    `json:"command"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// HistoryResponse represents command history response
+type HistoryResponse struct {
+	Commands []HistoryEntry `json:"commands"`
+}
+
+// loadHistory returns username's command history array (last 50 commands).
+// History is disabled when the server has no storage.
+func (h *HistoryHandler) loadHistory(username string) []string {
+	if h.storage == nil {
+		return []string{}
+	}
+
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 
-	entries, err := h.storage.GetCommandHistory(50)
+	entries, err := h.storage.GetCommandHistory(username, 50)
 	if err != nil {
 		return []string{}
 	}
@@ -39,10 +70,10 @@ func (h *HistoryHandler) loadHistory() []string {
 	return commands
 }
 
-// saveCommand saves a command to history (called from WebSocket)
-func (h *HistoryHandler) saveCommand(command string) error {
+// saveCommand saves a command to username's history (called from WebSocket)
+func (h *HistoryHandler) saveCommand(username, command string) error {
 	command = strings.TrimSpace(command)
-	if command == "" {
+	if command == "" || h.storage == nil {
 		return nil
 	}
 
@@ -50,13 +81,90 @@ func (h *HistoryHandler) saveCommand(command string) error {
 	defer h.mu.Unlock()
 
 	// Save to storage (duplicate check is handled inside)
-	if err := h.storage.SaveCommandHistory(command, time.Now()); err != nil {
+	if err := h.storage.SaveCommandHistory(username, command, time.Now()); err != nil {
 		return err
 	}
 
-	// Keep only last 500 commands (trim if needed)
-	go h.storage.TrimCommandHistory(500)
+	// Keep only last historyMaxCommands commands. Trimmed under the lock
+	// so indexes used by Delete don't shift underneath it
+	return h.storage.TrimCommandHistory(username, historyMaxCommands)
+}
+
+// List handles GET /api/terminal/history.
+// Returns the current user's commands.
+func (h *HistoryHandler) List(w http.ResponseWriter, r *http.Request) {
+	user := auth.GetUserFromContext(r.Context())
+
+	if h.storage == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, "storage_unavailable", "History storage not available")
+		return
+	}
+
+	h.mu.RLock()
+	entries, err := h.storage.GetCommandHistory(user.Username, math.MaxInt)
+	h.mu.RUnlock()
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+
+	commands := make([]HistoryEntry, len(entries))
+	for i, entry := range entries {
+		commands[i] = HistoryEntry{Index: i, Command: entry.Command, Timestamp: entry.Timestamp}
+	}
 
-	return nil
+	writeJSON(w, http.StatusOK, HistoryResponse{Commands: commands})
 }
 
+// Clear handles DELETE /api/terminal/history (current user's commands)
+func (h *HistoryHandler) Clear(w http.ResponseWriter, r *http.Request) {
+	user := auth.GetUserFromContext(r.Context())
+
+	if h.storage == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, "storage_unavailable", "History storage not available")
+		return
+	}
+
+	h.mu.Lock()
+	err := h.storage.ClearCommandHistory(user.Username)
+	h.mu.Unlock()
+	if err != nil {
+		h.eventStore.Add(events.EventTerminalHistory, user.Username, getClientIP(r), false, err.Error())
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+
+	h.eventStore.Add(events.EventTerminalHistory, user.Username, getClientIP(r), true, "all commands")
+	writeJSON(w, http.StatusOK, map[string]string{"message": "History cleared"})
+}
+
+// Delete handles DELETE /api/terminal/history/{index}
+func (h *HistoryHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	user := auth.GetUserFromContext(r.Context())
+
+	index, err := strconv.Atoi(chi.URLParam(r, "index"))
+	if err != nil || index < 0 {
+		writeJSONError(w, http.StatusBadRequest, "invalid_parameter", "Invalid history index")
+		return
+	}
+
+	if h.storage == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, "storage_unavailable", "History storage not available")
+		return
+	}
+
+	h.mu.Lock()
+	err = h.storage.DeleteCommandHistory(user.Username, index)
+	h.mu.Unlock()
+	if errors.Is(err, storage.ErrNotFound) {
+		writeJSONError(w, http.StatusNotFound, "history_entry_not_found", "History entry not found")
+		return
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+
+	h.eventStore.Add(events.EventTerminalHistory, user.Username, getClientIP(r), true, "entry "+strconv.Itoa(index))
+	writeJSON(w, http.StatusOK, map[string]string{"message": "History entry removed"})
+}
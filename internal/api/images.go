@@ -1,50 +1,114 @@
 package api
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 
 	"podmanview/internal/auth"
+	"podmanview/internal/config"
 	"podmanview/internal/events"
 	"podmanview/internal/podman"
+	"podmanview/internal/scanner"
 )
 
+// imageScanTimeout limits a single vulnerability scan (first runs
+// download the vulnerability database, so this is generous)
+const imageScanTimeout = 10 * time.Minute
+
 // ImageHandler handles image endpoints
 type ImageHandler struct {
 	client     *podman.Client
 	eventStore *events.Store
+	cfg        *config.Config
 }
 
 // NewImageHandler creates new image handler
-func NewImageHandler(client *podman.Client, eventStore *events.Store) *ImageHandler {
-	return &ImageHandler{client: client, eventStore: eventStore}
+func NewImageHandler(client *podman.Client, eventStore *events.Store, cfg *config.Config) *ImageHandler {
+	return &ImageHandler{client: client, eventStore: eventStore, cfg: cfg}
 }
 
 // ImageWithUsage extends Image with usage info
 type ImageWithUsage struct {
-	ID       string   `json:"Id"`
-	RepoTags []string `json:"RepoTags"`
-	Created  int64    `json:"Created"`
-	Size     int64    `json:"Size"`
-	InUse    bool     `json:"InUse"`
+	ID         string   `json:"Id"`
+	RepoTags   []string `json:"RepoTags"`
+	Created    int64    `json:"Created"`
+	Size       int64    `json:"Size"`
+	InUse      bool     `json:"InUse"`
+	Containers int      `json:"Containers"` // containers using the image, running or not
+}
+
+// ImageListResponse is a page of images, returned when offset or limit is set
+type ImageListResponse struct {
+	Images     []ImageWithUsage `json:"images"`
+	TotalCount int              `json:"total_count"` // Number of images matching the filters
+	Offset     int              `json:"offset"`
+	Limit      int              `json:"limit"`
+	HasMore    bool             `json:"has_more"`
 }
 
 // List handles GET /api/images
+// Filters: ?dangling=true|false and ?reference=nginx* (repeatable), passed
+// to Podman. Images are sorted newest first; with ?offset= or ?limit=
+// (default 100, max 1000) a page is returned as ImageListResponse,
+// otherwise a plain array of all matches.
 func (h *ImageHandler) List(w http.ResponseWriter, r *http.Request) {
-	images, err := h.client.ListImages(r.Context())
+	query := r.URL.Query()
+	filters := make(map[string][]string)
+	if v := query.Get("dangling"); v != "" {
+		if v != "true" && v != "false" {
+			writeJSONError(w, http.StatusBadRequest, "invalid_filter", "dangling must be true or false")
+			return
+		}
+		filters["dangling"] = []string{v}
+	}
+	if refs := query["reference"]; len(refs) > 0 {
+		filters["reference"] = refs
+	}
+
+	paginate := query.Has("offset") || query.Has("limit")
+	offset, limit := 0, 100
+	if v := query.Get("offset"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed >= 0 {
+			offset = parsed
+		}
+	}
+	if v := query.Get("limit"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 && parsed <= 1000 {
+			limit = parsed
+		}
+	}
+
+	images, err := h.client.ListImagesFiltered(r.Context(), filters)
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		writePodmanError(w, err, "")
 		return
 	}
+	sort.SliceStable(images, func(i, j int) bool {
+		if images[i].Created != images[j].Created {
+			return images[i].Created > images[j].Created
+		}
+		return images[i].ID < images[j].ID
+	})
+
+	totalCount := len(images)
+	if paginate {
+		images = images[min(offset, totalCount):min(offset+limit, totalCount)]
+	}
 
-	// Get containers to check which images are in use
+	// Get containers to count which images are in use
 	containers, _ := h.client.ListContainers(r.Context())
-	usedImageIDs := make(map[string]bool)
+	imageContainers := make(map[string]int)
 	for _, c := range containers {
 		if c.ImageID != "" {
-			usedImageIDs[c.ImageID] = true
+			imageContainers[c.ImageID]++
 		}
 	}
 
@@ -52,15 +116,26 @@ func (h *ImageHandler) List(w http.ResponseWriter, r *http.Request) {
 	result := make([]ImageWithUsage, len(images))
 	for i, img := range images {
 		result[i] = ImageWithUsage{
-			ID:       img.ID,
-			RepoTags: img.RepoTags,
-			Created:  img.Created,
-			Size:     img.Size,
-			InUse:    usedImageIDs[img.ID],
+			ID:         img.ID,
+			RepoTags:   img.RepoTags,
+			Created:    img.Created,
+			Size:       img.Size,
+			InUse:      imageContainers[img.ID] > 0,
+			Containers: imageContainers[img.ID],
 		}
 	}
 
-	writeJSON(w, http.StatusOK, result)
+	if !paginate {
+		writeJSON(w, http.StatusOK, result)
+		return
+	}
+	writeJSON(w, http.StatusOK, ImageListResponse{
+		Images:     result,
+		TotalCount: totalCount,
+		Offset:     offset,
+		Limit:      limit,
+		HasMore:    offset+len(result) < totalCount,
+	})
 }
 
 // Inspect handles GET /api/images/{id}
@@ -69,64 +144,333 @@ func (h *ImageHandler) Inspect(w http.ResponseWriter, r *http.Request) {
 
 	info, err := h.client.InspectImage(r.Context(), id)
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		writePodmanError(w, err, "image_not_found")
 		return
 	}
 
 	writeJSON(w, http.StatusOK, info)
 }
 
+// PinnedReference is an image tag with its digest reference
+type PinnedReference struct {
+	Tag       string `json:"tag,omitempty"` // repo tag, empty for untagged images
+	Reference string `json:"reference"`     // repo@sha256:..., or the image ID without a repo digest
+	Pinned    bool   `json:"pinned"`        // false when Reference is the image ID
+}
+
+// PinnedReferenceResponse lists the pinned references of an image
+type PinnedReferenceResponse struct {
+	ID         string            `json:"id"`
+	References []PinnedReference `json:"references"`
+}
+
+// PinnedReference handles GET /api/images/{id}/pinned-reference
+// Each repo tag is paired with the repo digest of the same repository.
+// Locally built images have no repo digests and get the image ID instead.
+func (h *ImageHandler) PinnedReference(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	info, err := h.client.InspectImage(r.Context(), id)
+	if err != nil {
+		writePodmanError(w, err, "image_not_found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, PinnedReferenceResponse{
+		ID:         info.ID,
+		References: pinnedReferences(info),
+	})
+}
+
+// pinnedReferences pairs an image's repo tags with its repo digests
+func pinnedReferences(info *podman.ImageInspect) []PinnedReference {
+	// Repository of each digest, first digest wins
+	digests := make(map[string]string)
+	for _, digest := range info.RepoDigests {
+		if i := strings.Index(digest, "@"); i > 0 {
+			if _, ok := digests[digest[:i]]; !ok {
+				digests[digest[:i]] = digest
+			}
+		}
+	}
+
+	refs := []PinnedReference{}
+	tagged := make(map[string]bool)
+	for _, tag := range info.RepoTags {
+		if tag == "" || tag == "<none>:<none>" {
+			continue
+		}
+		repo := tag
+		if i := strings.LastIndex(tag, ":"); i > strings.LastIndex(tag, "/") {
+			repo = tag[:i]
+		}
+		tagged[repo] = true
+		if digest, ok := digests[repo]; ok {
+			refs = append(refs, PinnedReference{Tag: tag, Reference: digest, Pinned: true})
+		} else {
+			refs = append(refs, PinnedReference{Tag: tag, Reference: info.ID})
+		}
+	}
+
+	// Untagged repositories, e.g. images pulled by digest
+	for _, digest := range info.RepoDigests {
+		if i := strings.Index(digest, "@"); i > 0 && !tagged[digest[:i]] && digests[digest[:i]] == digest {
+			refs = append(refs, PinnedReference{Reference: digest, Pinned: true})
+		}
+	}
+
+	if len(refs) == 0 {
+		refs = append(refs, PinnedReference{Reference: info.ID})
+	}
+	return refs
+}
+
+// ImageSearchResponse lists registry search results
+type ImageSearchResponse struct {
+	Results []podman.ImageSearchResult `json:"results"`
+}
+
+// Search handles GET /api/images/search?q=nginx
+// ?registry=quay.io searches only that registry; otherwise Podman searches
+// its unqualified-search registries. Registries without a search API
+// (e.g. ghcr.io) fail with 502 search_unavailable.
+func (h *ImageHandler) Search(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	term := strings.TrimSpace(query.Get("q"))
+	registry := strings.TrimSpace(query.Get("registry"))
+	if term == "" {
+		writeJSONError(w, http.StatusBadRequest, "missing_field", "Search term is required")
+		return
+	}
+	if len(term) > 255 || strings.ContainsAny(term, " \t@:") {
+		writeJSONError(w, http.StatusBadRequest, "invalid_term", "Search term must be a repository name without tag or digest")
+		return
+	}
+	if registry != "" && !isRegistryHost(registry) {
+		writeJSONError(w, http.StatusBadRequest, "invalid_registry", "Registry must be a host name with an optional port, e.g. quay.io")
+		return
+	}
+
+	results, err := h.client.SearchRegistry(r.Context(), term, registry)
+	if err != nil {
+		var apiErr *podman.APIError
+		if !errors.As(err, &apiErr) {
+			writePodmanError(w, err, "")
+			return
+		}
+		logger(r.Context()).Printf("Registry search for %q failed: %v", term, err)
+		msg := "Registry search failed, the registries may not support search"
+		if registry != "" {
+			msg = fmt.Sprintf("Registry %s does not support search or is unreachable", registry)
+		}
+		writeJSONError(w, http.StatusBadGateway, "search_unavailable", msg)
+		return
+	}
+	if results == nil {
+		results = []podman.ImageSearchResult{}
+	}
+
+	writeJSON(w, http.StatusOK, ImageSearchResponse{Results: results})
+}
+
+// isRegistryHost reports whether s is a registry host[:port]
+func isRegistryHost(s string) bool {
+	host, port, hasPort := strings.Cut(s, ":")
+	if host == "" || len(s) > 253 {
+		return false
+	}
+	if hasPort {
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return false
+		}
+	}
+	for _, c := range host {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '.' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
+
 // PullRequest represents image pull request
 type PullRequest struct {
 	Reference string `json:"reference"`
+	Platform  string `json:"platform,omitempty"` // os/arch[/variant], e.g. linux/arm64; empty for the host's
 }
 
 // Pull handles POST /api/images/pull
 func (h *ImageHandler) Pull(w http.ResponseWriter, r *http.Request) {
 	user := auth.GetUserFromContext(r.Context())
-	if !user.IsAdmin() {
-		writeJSON(w, http.StatusForbidden, map[string]string{"error": "Admin access required"})
-		return
-	}
 
 	var req PullRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
 	if req.Reference == "" {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Reference is required"})
+		writeJSONError(w, http.StatusBadRequest, "missing_field", "Reference is required")
+		return
+	}
+	platform, err := podman.ParsePlatform(req.Platform)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid_platform", err.Error())
 		return
 	}
 
-	if err := h.client.PullImage(r.Context(), req.Reference); err != nil {
-		h.eventStore.Add(events.EventImagePull, user.Username, getClientIP(r), false, req.Reference)
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	meta := events.Meta{"image": req.Reference}
+	if !platform.IsZero() {
+		meta["platform"] = platform.String()
+	}
+	if err := h.client.PullImage(r.Context(), req.Reference, platform); err != nil {
+		h.eventStore.AddWithMeta(events.EventImagePull, user.Username, getClientIP(r), false, req.Reference, meta)
+		writePodmanError(w, err, "")
 		return
 	}
 
-	h.eventStore.Add(events.EventImagePull, user.Username, getClientIP(r), true, req.Reference)
+	h.eventStore.AddWithMeta(events.EventImagePull, user.Username, getClientIP(r), true, req.Reference, meta)
 	writeJSON(w, http.StatusOK, map[string]string{"status": "pulled"})
 }
 
-// Remove handles DELETE /api/images/{id}
-func (h *ImageHandler) Remove(w http.ResponseWriter, r *http.Request) {
+// ImagePruneRequest represents an image prune request
+type ImagePruneRequest struct {
+	All    bool     `json:"all"`              // Remove every unused image, not just dangling ones
+	Until  string   `json:"until,omitempty"`  // Only images older than this duration, e.g. "168h"
+	Labels []string `json:"labels,omitempty"` // Only images with these labels ("key" or "key=value")
+}
+
+// ImagePruneResponse lists the images removed by a prune
+type ImagePruneResponse struct {
+	Removed        []string `json:"removed"`
+	ReclaimedBytes uint64   `json:"reclaimedBytes"`
+}
+
+// Prune handles POST /api/images/prune
+// Images used by a container, running or not, are never removed.
+func (h *ImageHandler) Prune(w http.ResponseWriter, r *http.Request) {
 	user := auth.GetUserFromContext(r.Context())
-	if !user.IsAdmin() {
-		writeJSON(w, http.StatusForbidden, map[string]string{"error": "Admin access required"})
+
+	var req ImagePruneRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	filters := make(map[string][]string)
+	if req.Until != "" {
+		age, err := time.ParseDuration(req.Until)
+		if err != nil || age <= 0 {
+			writeJSONError(w, http.StatusBadRequest, "invalid_duration", "until must be a positive duration such as 24h")
+			return
+		}
+		filters["until"] = []string{age.String()}
+	}
+	for _, label := range req.Labels {
+		if key, _, _ := strings.Cut(label, "="); key == "" {
+			writeJSONError(w, http.StatusBadRequest, "invalid_filter", fmt.Sprintf("Invalid label filter: %q", label))
+			return
+		}
+		filters["label"] = append(filters["label"], label)
+	}
+
+	mode := "dangling"
+	if req.All {
+		mode = "unused"
+	}
+	meta := events.Meta{"mode": mode}
+	if req.Until != "" {
+		meta["until"] = req.Until
+	}
+
+	reports, err := h.client.PruneImages(r.Context(), req.All, filters)
+	if err != nil {
+		h.eventStore.AddWithMeta(events.EventImagePrune, user.Username, getClientIP(r), false, mode, meta)
+		writePodmanError(w, err, "")
 		return
 	}
 
+	resp := ImagePruneResponse{Removed: []string{}}
+	for _, report := range reports {
+		resp.Removed = append(resp.Removed, report.ID)
+		resp.ReclaimedBytes += report.Size
+	}
+	meta["removed"] = strconv.Itoa(len(resp.Removed))
+	h.eventStore.AddWithMeta(events.EventImagePrune, user.Username, getClientIP(r), true,
+		fmt.Sprintf("%s: %d removed", mode, len(resp.Removed)), meta)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// Remove handles DELETE /api/images/{id}
+func (h *ImageHandler) Remove(w http.ResponseWriter, r *http.Request) {
+	user := auth.GetUserFromContext(r.Context())
+
 	id := chi.URLParam(r, "id")
 	force := r.URL.Query().Get("force") == "true"
 
 	if err := h.client.RemoveImage(r.Context(), id, force); err != nil {
-		h.eventStore.Add(events.EventImageRemove, user.Username, getClientIP(r), false, shortID(id))
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		h.eventStore.AddWithMeta(events.EventImageRemove, user.Username, getClientIP(r), false, shortID(id), events.Meta{"image": id})
+		writePodmanError(w, err, "image_not_found")
 		return
 	}
 
-	h.eventStore.Add(events.EventImageRemove, user.Username, getClientIP(r), true, shortID(id))
+	h.eventStore.AddWithMeta(events.EventImageRemove, user.Username, getClientIP(r), true, shortID(id), events.Meta{"image": id})
 	writeJSON(w, http.StatusOK, map[string]string{"status": "removed"})
 }
+
+// ScanResponse represents image scan result
+type ScanResponse struct {
+	*scanner.Result
+	ImageID string `json:"imageId"`
+	Size    int64  `json:"size"`
+}
+
+// Scan handles POST /api/images/{id}/scan.
+// The scanner reads this host's Podman storage, so a remote engine, whose
+// images are on another host, is refused.
+func (h *ImageHandler) Scan(w http.ResponseWriter, r *http.Request) {
+	user := auth.GetUserFromContext(r.Context())
+
+	id := chi.URLParam(r, "id")
+	if !requireLocalEngine(w, h.client) {
+		return
+	}
+
+	sc, err := scanner.New(h.cfg.ImageScanner())
+	if err != nil {
+		if errors.Is(err, scanner.ErrNotInstalled) {
+			writeJSONError(w, http.StatusNotImplemented, "scanner_not_installed", err.Error())
+			return
+		}
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+
+	info, err := h.client.InspectImage(r.Context(), id)
+	if err != nil {
+		writePodmanError(w, err, "image_not_found")
+		return
+	}
+
+	// Scan by tag when available so scanner output names the image
+	ref := info.ID
+	if len(info.RepoTags) > 0 {
+		ref = info.RepoTags[0]
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), imageScanTimeout)
+	defer cancel()
+
+	meta := events.Meta{"image": id, "scanner": string(sc.Kind())}
+
+	result, err := sc.Scan(ctx, ref)
+	if err != nil {
+		h.eventStore.AddWithMeta(events.EventImageScan, user.Username, getClientIP(r), false, ref, meta)
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+
+	h.eventStore.AddWithMeta(events.EventImageScan, user.Username, getClientIP(r), true, ref, meta)
+	writeJSON(w, http.StatusOK, ScanResponse{
+		Result:  result,
+		ImageID: info.ID,
+		Size:    info.Size,
+	})
+}
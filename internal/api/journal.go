@@ -0,0 +1,246 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"podmanview/internal/podman"
+	"podmanview/internal/updater"
+)
+
+// Journal reader settings
+const (
+	journalDefaultUnit  = "podmanview.service" // when PodmanView wasn't started by systemd
+	journalDefaultLines = 200
+	journalMaxLines     = 5000
+	journalTimeout      = 15 * time.Second
+)
+
+// unitNamePattern matches valid systemd unit names (no leading dash,
+// so a name can never be parsed as a journalctl flag)
+var unitNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9@._:-]{0,254}$`)
+
+// errRemoteJournal is returned for container units of a remote engine,
+// whose journal is on the other host
+var errRemoteJournal = errors.New("podman runs on another host, its container units aren't in this journal")
+
+// syslog priority names (index = PRIORITY field)
+var journalPriorities = []string{"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug"}
+
+// JournalEntry represents a single journal line
+type JournalEntry struct {
+	Timestamp  time.Time `json:"timestamp"`
+	Priority   int       `json:"priority"`
+	Level      string    `json:"level"`
+	Identifier string    `json:"identifier,omitempty"`
+	PID        int       `json:"pid,omitempty"`
+	Message    string    `json:"message"`
+}
+
+// JournalResponse represents system logs response
+type JournalResponse struct {
+	Unit    string         `json:"unit"`
+	Entries []JournalEntry `json:"entries"` // newest first
+}
+
+// Logs handles GET /api/system/logs?unit=podmanview&lines=200
+// or GET /api/system/logs?container={id} for a container's systemd unit.
+// Only the PodmanView unit and units owning a container can be read;
+// container units only with a local engine.
+func (h *SystemHandler) Logs(w http.ResponseWriter, r *http.Request) {
+	lines := journalDefaultLines
+	if v := r.URL.Query().Get("lines"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeJSONError(w, http.StatusBadRequest, "invalid_parameter", "Invalid lines value")
+			return
+		}
+		lines = min(n, journalMaxLines)
+	}
+
+	unit, userUnit, status, err := h.resolveJournalUnit(r)
+	if err != nil {
+		code := errorCode(status)
+		if errors.Is(err, errRemoteJournal) {
+			code = "remote_engine"
+		}
+		writeJSONError(w, status, code, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), journalTimeout)
+	defer cancel()
+
+	entries, err := readJournal(ctx, unit, userUnit, lines)
+	if err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			writeJSONError(w, http.StatusNotImplemented, "journalctl_not_installed", err.Error())
+			return
+		}
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, JournalResponse{Unit: unit, Entries: entries})
+}
+
+// resolveJournalUnit returns the requested unit after checking it against
+// the allowed units. userUnit is true for container units of rootless
+// Podman and for PodmanView running as a user service.
+func (h *SystemHandler) resolveJournalUnit(r *http.Request) (unit string, userUnit bool, status int, err error) {
+	rootless := os.Geteuid() != 0
+	remote := h.client != nil && h.client.IsRemote()
+
+	// Container's own unit
+	if id := r.URL.Query().Get("container"); id != "" {
+		if remote {
+			return "", false, http.StatusConflict, errRemoteJournal
+		}
+		info, err := h.client.InspectContainer(r.Context(), id)
+		if err != nil {
+			return "", false, http.StatusNotFound, err
+		}
+		unit := info.Config.Labels[podman.LabelSystemdUnit]
+		if unit == "" {
+			return "", false, http.StatusNotFound, errors.New("container is not managed by a systemd unit")
+		}
+		if !unitNamePattern.MatchString(unit) {
+			return "", false, http.StatusBadRequest, errors.New("invalid unit name")
+		}
+		return unit, rootless, 0, nil
+	}
+
+	// PodmanView's own unit, under whatever name it was installed
+	self, selfUser := updater.ServiceUnit()
+	if self == "" {
+		self = journalDefaultUnit
+	}
+	unit = normalizeUnitName(r.URL.Query().Get("unit"))
+	if unit == "" || unit == journalDefaultUnit {
+		unit = self
+	}
+	if !unitNamePattern.MatchString(unit) {
+		return "", false, http.StatusBadRequest, errors.New("invalid unit name")
+	}
+	if unit == self {
+		return unit, selfUser, 0, nil
+	}
+
+	// Any other unit must belong to a container
+	if remote {
+		return "", false, http.StatusConflict, errRemoteJournal
+	}
+	containers, err := h.client.ListContainers(r.Context())
+	if err != nil {
+		return "", false, http.StatusInternalServerError, err
+	}
+	for _, c := range containers {
+		if normalizeUnitName(c.Labels[podman.LabelSystemdUnit]) == unit {
+			return unit, rootless, 0, nil
+		}
+	}
+
+	return "", false, http.StatusForbidden, errors.New("unit is not PodmanView or a container unit")
+}
+
+// normalizeUnitName adds the .service suffix to bare unit names
+func normalizeUnitName(name string) string {
+	if name != "" && !strings.Contains(name, ".") {
+		return name + ".service"
+	}
+	return name
+}
+
+// readJournal runs journalctl for unit and parses its JSON output.
+// unit must already be validated against unitNamePattern.
+func readJournal(ctx context.Context, unit string, userUnit bool, lines int) ([]JournalEntry, error) {
+	args := []string{"--no-pager", "-o", "json", "-n", strconv.Itoa(lines), "-u", unit}
+	if userUnit {
+		args = append([]string{"--user"}, args...)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "journalctl", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return nil, err
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, errors.New("journalctl: " + msg)
+		}
+		return nil, err
+	}
+
+	entries := make([]JournalEntry, 0, lines)
+	scanner := bufio.NewScanner(&stdout)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if entry, ok := parseJournalLine(scanner.Bytes()); ok {
+			entries = append(entries, entry)
+		}
+	}
+
+	// Newest first, same as container logs
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+
+	return entries, nil
+}
+
+// parseJournalLine parses one `journalctl -o json` record.
+// All fields are strings, except MESSAGE which is a byte array
+// when it contains non-UTF-8 data.
+func parseJournalLine(line []byte) (JournalEntry, bool) {
+	var record struct {
+		Timestamp  string          `json:"__REALTIME_TIMESTAMP"`
+		Priority   string          `json:"PRIORITY"`
+		Identifier string          `json:"SYSLOG_IDENTIFIER"`
+		PID        string          `json:"_PID"`
+		Message    json.RawMessage `json:"MESSAGE"`
+	}
+	if err := json.Unmarshal(line, &record); err != nil {
+		return JournalEntry{}, false
+	}
+
+	entry := JournalEntry{
+		Priority:   6, // info
+		Identifier: record.Identifier,
+	}
+
+	if usec, err := strconv.ParseInt(record.Timestamp, 10, 64); err == nil {
+		entry.Timestamp = time.UnixMicro(usec)
+	}
+	if p, err := strconv.Atoi(record.Priority); err == nil && p >= 0 && p < len(journalPriorities) {
+		entry.Priority = p
+	}
+	entry.Level = journalPriorities[entry.Priority]
+	entry.PID, _ = strconv.Atoi(record.PID)
+
+	var text string
+	var raw []int
+	if err := json.Unmarshal(record.Message, &text); err == nil {
+		entry.Message = podman.StripAnsiCodes(text)
+	} else if err := json.Unmarshal(record.Message, &raw); err == nil {
+		b := make([]byte, len(raw))
+		for i, v := range raw {
+			b[i] = byte(v)
+		}
+		entry.Message = podman.StripAnsiCodes(strings.ToValidUTF8(string(b), "\uFFFD"))
+	}
+
+	return entry, true
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"podmanview/internal/netutil"
+	"podmanview/internal/podman"
+)
+
+// linkProbeTimeout limits each HTTP/HTTPS probe of a published port
+const linkProbeTimeout = 1500 * time.Millisecond
+
+// linkProbeClient probes ports without following redirects or
+// verifying certificates (self-signed certs are common in homelabs)
+var linkProbeClient = &http.Client{
+	Timeout: linkProbeTimeout,
+	Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+		DisableKeepAlives: true,
+	},
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// ContainerLink represents a guessed URL for a published port
+type ContainerLink struct {
+	URL           string `json:"url"`
+	Host          string `json:"host"`
+	HostPort      int    `json:"hostPort"`
+	ContainerPort int    `json:"containerPort"`
+	Scheme        string `json:"scheme"`   // http or https
+	Verified      bool   `json:"verified"` // port answered the HTTP(S) probe
+}
+
+// LinksResponse represents container links response
+type LinksResponse struct {
+	Links []ContainerLink `json:"links"`
+}
+
+// Links handles GET /api/containers/{id}/links.
+// Returns URLs for each published TCP port, using the host the client
+// reached PodmanView on and the server's local IPs.
+func (h *ContainerHandler) Links(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	info, err := h.client.InspectContainer(r.Context(), id)
+	if err != nil {
+		writePodmanError(w, err, "container_not_found")
+		return
+	}
+
+	// Only TCP ports can serve HTTP; probe each host port once
+	var bindings []podman.PortBinding
+	seen := make(map[int]bool)
+	for _, b := range info.PortBindings() {
+		if b.Protocol != "tcp" || seen[b.HostPort] {
+			continue
+		}
+		seen[b.HostPort] = true
+		bindings = append(bindings, b)
+	}
+
+	schemes := make([]string, len(bindings))
+	verified := make([]bool, len(bindings))
+	var wg sync.WaitGroup
+	for i, b := range bindings {
+		wg.Add(1)
+		go func(i int, b podman.PortBinding) {
+			defer wg.Done()
+			schemes[i], verified[i] = probeScheme(r.Context(), probeAddr(b))
+		}(i, b)
+	}
+	wg.Wait()
+
+	links := []ContainerLink{}
+	for i, b := range bindings {
+		for _, host := range linkHosts(r, b.HostIP) {
+			links = append(links, ContainerLink{
+				URL:           schemes[i] + "://" + netutil.URLHost(host, strconv.Itoa(b.HostPort)),
+				Host:          host,
+				HostPort:      b.HostPort,
+				ContainerPort: b.ContainerPort,
+				Scheme:        schemes[i],
+				Verified:      verified[i],
+			})
+		}
+	}
+
+	writeJSON(w, http.StatusOK, LinksResponse{Links: links})
+}
+
+// isWildcardIP reports whether a binding listens on all interfaces
+func isWildcardIP(ip string) bool {
+	return ip == "" || ip == "0.0.0.0" || ip == "::"
+}
+
+// linkHosts returns hosts a browser could use to reach a binding:
+// the host from the request first, then the server's local IPs
+func linkHosts(r *http.Request, hostIP string) []string {
+	if !isWildcardIP(hostIP) {
+		return []string{hostIP}
+	}
+
+	var hosts []string
+	seen := make(map[string]bool)
+	add := func(host string) {
+		if host != "" && !seen[host] {
+			seen[host] = true
+			hosts = append(hosts, host)
+		}
+	}
+
+	if host, _, err := net.SplitHostPort(r.Host); err == nil {
+		add(host)
+	} else {
+		add(r.Host)
+	}
+	for _, ip := range netutil.LocalIPs() {
+		add(ip)
+	}
+
+	return hosts
+}
+
+// probeAddr returns the address to probe a binding on from this host
+func probeAddr(b podman.PortBinding) string {
+	host := b.HostIP
+	if isWildcardIP(host) {
+		host = "127.0.0.1"
+	}
+	return net.JoinHostPort(host, strconv.Itoa(b.HostPort))
+}
+
+// probeScheme checks whether addr speaks HTTPS or HTTP.
+// Falls back to unverified http when neither answers.
+func probeScheme(ctx context.Context, addr string) (scheme string, ok bool) {
+	for _, scheme := range []string{"https", "http"} {
+		req, err := http.NewRequestWithContext(ctx, http.MethodHead, scheme+"://"+addr+"/", nil)
+		if err != nil {
+			continue
+		}
+		resp, err := linkProbeClient.Do(req)
+		if err != nil {
+			continue
+		}
+		resp.Body.Close()
+		return scheme, true
+	}
+	return "http", false
+}
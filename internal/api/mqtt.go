@@ -0,0 +1,375 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"podmanview/internal/auth"
+	"podmanview/internal/config"
+	"podmanview/internal/events"
+	"podmanview/internal/mqtt"
+	"podmanview/internal/plugins"
+	"podmanview/internal/podman"
+)
+
+// MQTTHandler handles runtime MQTT configuration endpoints
+type MQTTHandler struct {
+	config         *config.Config
+	pluginRegistry *plugins.Registry
+	eventStore     *events.Store
+}
+
+// mqttTestTimeout limits how long a connection test may take
+const mqttTestTimeout = 5 * time.Second
+
+// NewMQTTHandler creates new MQTT handler
+func NewMQTTHandler(cfg *config.Config, pluginRegistry *plugins.Registry, eventStore *events.Store) *MQTTHandler {
+	return &MQTTHandler{
+		config:         cfg,
+		pluginRegistry: pluginRegistry,
+		eventStore:     eventStore,
+	}
+}
+
+// MQTTConfigResponse represents MQTT settings (password is never returned)
+type MQTTConfigResponse struct {
+	Broker      string `json:"broker"`
+	ClientID    string `json:"clientId"`
+	Username    string `json:"username"`
+	PasswordSet bool   `json:"passwordSet"`
+	Prefix      string `json:"prefix"`
+	UseTLS      bool   `json:"useTls"`
+	DeviceID    string `json:"deviceId"`   // empty = host name
+	DeviceName  string `json:"deviceName"` // empty = "PodmanView <host name>"
+	Configured  bool   `json:"configured"`
+	Connected   bool   `json:"connected"`
+}
+
+// MQTTStatusResponse is the connection state of the shared MQTT client
+type MQTTStatusResponse struct {
+	Configured          bool       `json:"configured"`
+	Connected           bool       `json:"connected"`
+	Broker              string     `json:"broker,omitempty"`
+	Since               *time.Time `json:"since,omitempty"`     // when connected last changed
+	LastError           string     `json:"lastError,omitempty"` // why the connection was last lost
+	ReconnectAttempts   int        `json:"reconnectAttempts"`
+	KeepAliveSeconds    int        `json:"keepAliveSeconds"`
+	ReconnectMaxSeconds int        `json:"reconnectMaxSeconds"`
+}
+
+// MQTTConfigRequest represents MQTT settings update request
+// Password, device ID and device name are optional: omit them to keep
+// the current values
+type MQTTConfigRequest struct {
+	Broker     string  `json:"broker"`
+	ClientID   string  `json:"clientId"`
+	Username   string  `json:"username"`
+	Password   *string `json:"password,omitempty"`
+	Prefix     string  `json:"prefix"`
+	UseTLS     bool    `json:"useTls"`
+	DeviceID   *string `json:"deviceId,omitempty"`
+	DeviceName *string `json:"deviceName,omitempty"`
+}
+
+// GetConfig handles GET /api/system/mqtt/config
+func (h *MQTTHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.buildResponse())
+}
+
+// Status handles GET /api/system/mqtt/status
+func (h *MQTTHandler) Status(w http.ResponseWriter, r *http.Request) {
+	resp := MQTTStatusResponse{
+		KeepAliveSeconds:    int(h.config.MQTTKeepAlive().Seconds()),
+		ReconnectMaxSeconds: int(h.config.MQTTReconnectMax().Seconds()),
+	}
+
+	if h.pluginRegistry != nil {
+		if deps := h.pluginRegistry.Deps(); deps != nil && deps.MQTTClient() != nil {
+			state := deps.MQTTClient().State()
+			resp.Configured = true
+			resp.Connected = state.Connected
+			resp.Broker = state.Broker
+			resp.Since = &state.Since
+			resp.LastError = state.LastError
+			resp.ReconnectAttempts = state.ReconnectAttempts
+		}
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// UpdateConfig handles POST /api/system/mqtt/config
+func (h *MQTTHandler) UpdateConfig(w http.ResponseWriter, r *http.Request) {
+	user := auth.GetUserFromContext(r.Context())
+
+	var req MQTTConfigRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	settings := h.config.MQTTSettings()
+	settings.Broker = req.Broker
+	settings.ClientID = req.ClientID
+	settings.Username = req.Username
+	settings.Prefix = req.Prefix
+	settings.UseTLS = req.UseTLS
+	if req.Password != nil {
+		settings.Password = *req.Password
+	}
+	if req.DeviceID != nil {
+		settings.DeviceID = strings.TrimSpace(*req.DeviceID)
+	}
+	if req.DeviceName != nil {
+		settings.DeviceName = strings.TrimSpace(*req.DeviceName)
+	}
+
+	if err := h.config.SetMQTTSettings(settings); err != nil {
+		h.eventStore.AddWithMeta(events.EventSettingsUpdate, user.Username, getClientIP(r), false, "mqtt", events.Meta{"section": "mqtt"})
+		writeJSONError(w, http.StatusBadRequest, "validation_failed", err.Error())
+		return
+	}
+
+	h.eventStore.AddWithMeta(events.EventSettingsUpdate, user.Username, getClientIP(r), true, "mqtt", events.Meta{"section": "mqtt"})
+
+	if err := h.applyConfig(); err != nil {
+		logger(r.Context()).Printf("Failed to apply MQTT settings: %v", err)
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"config":  h.buildResponse(),
+			"warning": "Settings saved but failed to apply: " + err.Error(),
+		})
+		return
+	}
+
+	// Without plugin dependencies there is no live client to reconfigure
+	restartRequired := h.pluginRegistry == nil || h.pluginRegistry.Deps() == nil
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"config":           h.buildResponse(),
+		"restart_required": restartRequired,
+	})
+}
+
+// MQTTTestRequest represents MQTT connection test request
+// Password is optional: omit it to test with the saved password
+type MQTTTestRequest struct {
+	Broker   string  `json:"broker"`
+	Username string  `json:"username"`
+	Password *string `json:"password,omitempty"`
+	Prefix   string  `json:"prefix"`
+	UseTLS   bool    `json:"useTls"`
+}
+
+// Test handles POST /api/system/mqtt/test
+// Tries connect+publish+disconnect with a temporary client
+func (h *MQTTHandler) Test(w http.ResponseWriter, r *http.Request) {
+	var req MQTTTestRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	testCfg := mqtt.Config{
+		Broker:   req.Broker,
+		Username: req.Username,
+		Prefix:   req.Prefix,
+		UseTLS:   req.UseTLS,
+	}
+	if req.Password != nil {
+		testCfg.Password = *req.Password
+	} else {
+		testCfg.Password = h.config.MQTTPassword()
+	}
+
+	start := time.Now()
+	if err := mqtt.TestConnection(testCfg, mqttTestTimeout); err != nil {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success":   true,
+		"latencyMs": time.Since(start).Milliseconds(),
+	})
+}
+
+// Limits of messages published with POST /api/system/mqtt/publish
+const (
+	mqttPublishMaxPayload = 64 * 1024
+	mqttPublishMaxTopic   = 1024
+)
+
+// MQTTPublishRequest represents a debug publish request. The topic is used
+// as given, without the configured prefix.
+type MQTTPublishRequest struct {
+	Topic    string `json:"topic"`
+	Payload  string `json:"payload"`
+	QoS      int    `json:"qos"`
+	Retained bool   `json:"retained"`
+}
+
+// Publish handles POST /api/system/mqtt/publish
+// Publishes a raw message with the live client, to check what reaches the broker
+func (h *MQTTHandler) Publish(w http.ResponseWriter, r *http.Request) {
+	user := auth.GetUserFromContext(r.Context())
+
+	var req MQTTPublishRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	switch {
+	case req.Topic == "" || len(req.Topic) > mqttPublishMaxTopic:
+		writeJSONError(w, http.StatusBadRequest, "invalid_topic", fmt.Sprintf("Topic is required, at most %d bytes", mqttPublishMaxTopic))
+		return
+	case strings.ContainsAny(req.Topic, "#+\x00"):
+		writeJSONError(w, http.StatusBadRequest, "invalid_topic", "Topic cannot contain wildcards (#, +)")
+		return
+	case req.QoS < 0 || req.QoS > 2:
+		writeJSONError(w, http.StatusBadRequest, "invalid_qos", "QoS must be 0, 1 or 2")
+		return
+	case len(req.Payload) > mqttPublishMaxPayload:
+		writeJSONError(w, http.StatusRequestEntityTooLarge, "payload_too_large", fmt.Sprintf("Payload exceeds %d bytes", mqttPublishMaxPayload))
+		return
+	}
+
+	var client *mqtt.Client
+	if h.pluginRegistry != nil {
+		if deps := h.pluginRegistry.Deps(); deps != nil {
+			client = deps.MQTTClient()
+		}
+	}
+	if client == nil || !client.IsConnected() {
+		writeJSONError(w, http.StatusConflict, "mqtt_not_connected", "MQTT client is not connected")
+		return
+	}
+
+	meta := events.Meta{"topic": req.Topic, "qos": strconv.Itoa(req.QoS), "retained": strconv.FormatBool(req.Retained)}
+	if err := client.PublishRawWithQoS(req.Topic, byte(req.QoS), req.Retained, []byte(req.Payload), mqttTestTimeout); err != nil {
+		h.eventStore.AddWithMeta(events.EventMQTTPublish, user.Username, getClientIP(r), false, req.Topic+": "+err.Error(), meta)
+		writeJSONError(w, http.StatusBadGateway, "publish_failed", err.Error())
+		return
+	}
+
+	h.eventStore.AddWithMeta(events.EventMQTTPublish, user.Username, getClientIP(r), true, req.Topic, meta)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"topic":   req.Topic,
+	})
+}
+
+// applyConfig reconnects the shared MQTT client with the current settings
+// and resets discovery so it is republished with the new prefix/broker.
+// When MQTT was off at startup the services are created and connected, and
+// the plugins see them through their dependencies.
+func (h *MQTTHandler) applyConfig() error {
+	if h.pluginRegistry == nil {
+		return nil
+	}
+	deps := h.pluginRegistry.Deps()
+	if deps == nil {
+		return nil
+	}
+
+	mqttCfg := newMQTTConfig(h.config)
+
+	client := deps.MQTTClient()
+
+	// MQTT disabled: disconnect but keep the client so it can be re-enabled
+	if mqttCfg.Broker == "" {
+		if client != nil {
+			client.Disconnect()
+		}
+		return nil
+	}
+	mqttCfg.Hostname = PodmanHostname(context.Background(), deps.PodmanClient)
+
+	if client == nil {
+		// MQTT was not configured at startup - create services now
+		client, err := mqtt.New(mqttCfg, deps.Logger)
+		if err != nil {
+			return err
+		}
+		mqtt.PublishSecurityEvents(h.eventStore, client, deps.Logger)
+		mqtt.RecordConnectionEvents(h.eventStore, client)
+		deps.SetMQTT(client, mqtt.NewPublisher(client, deps.Logger),
+			mqtt.NewDiscoveryManager(client, deps.Logger, deps.Storage, "global"))
+		return client.Connect()
+	}
+
+	if err := client.Reconfigure(mqttCfg); err != nil {
+		return err
+	}
+
+	if discovery := deps.MQTTDiscovery(); discovery != nil {
+		discovery.Reset()
+	}
+
+	// Reconfigure reconnects an active client; one disconnected while MQTT
+	// was disabled is connected again
+	return client.Connect()
+}
+
+// buildResponse builds the redacted MQTT config response
+func (h *MQTTHandler) buildResponse() MQTTConfigResponse {
+	settings := h.config.MQTTSettings()
+	resp := MQTTConfigResponse{
+		Broker:      settings.Broker,
+		ClientID:    settings.ClientID,
+		Username:    settings.Username,
+		PasswordSet: settings.Password != "",
+		Prefix:      settings.Prefix,
+		UseTLS:      settings.UseTLS,
+		DeviceID:    settings.DeviceID,
+		DeviceName:  settings.DeviceName,
+	}
+
+	if h.pluginRegistry != nil {
+		if deps := h.pluginRegistry.Deps(); deps != nil && deps.MQTTClient() != nil {
+			resp.Configured = true
+			resp.Connected = deps.MQTTClient().IsConnected()
+		}
+	}
+
+	return resp
+}
+
+// newMQTTConfig builds MQTT client configuration from application config
+func newMQTTConfig(cfg *config.Config) mqtt.Config {
+	settings := cfg.MQTTSettings()
+	return mqtt.Config{
+		Broker:   settings.Broker,
+		ClientID: settings.ClientID,
+		Username: settings.Username,
+		Password: settings.Password,
+		Prefix:   settings.Prefix,
+		UseTLS:   settings.UseTLS,
+
+		KeepAlive:            settings.KeepAlive,
+		MaxReconnectInterval: settings.ReconnectMax,
+
+		DeviceID:   settings.DeviceID,
+		DeviceName: settings.DeviceName,
+	}
+}
+
+// PodmanHostname returns the host name Podman reports, which names the Home
+// Assistant device of this host. Without Podman it is the local host name
+// (of the container, when PodmanView runs in one).
+func PodmanHostname(ctx context.Context, client *podman.Client) string {
+	if client != nil {
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		if info, err := client.GetSystemInfo(ctx); err == nil && info.Host.Hostname != "" {
+			return info.Host.Hostname
+		}
+	}
+	hostname, _ := os.Hostname()
+	return hostname
+}
@@ -1,10 +1,20 @@
 package api
 
 import (
-	"encoding/json"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
+	"podmanview/internal/auth"
+	"podmanview/internal/events"
+	"podmanview/internal/plugins"
 	"podmanview/internal/storage"
 )
 
@@ -20,7 +30,7 @@ func NewPluginHandler(server *Server) *PluginHandler {
 	}
 }
 
-// List returns the list of all plugins
+// List returns the list of all plugins with their manifests
 func (h *PluginHandler) List(w http.ResponseWriter, r *http.Request) {
 	if h.server.plugins == nil {
 		writeJSON(w, http.StatusOK, []interface{}{})
@@ -30,11 +40,15 @@ func (h *PluginHandler) List(w http.ResponseWriter, r *http.Request) {
 	pluginsList := make([]map[string]interface{}, 0, len(h.server.plugins))
 
 	for _, plugin := range h.server.plugins {
+		manifest := plugins.ManifestOf(plugin)
 		pluginInfo := map[string]interface{}{
-			"name":        plugin.Name(),
-			"description": plugin.Description(),
-			"version":     plugin.Version(),
-			"enabled":     plugin.IsEnabled(),
+			"name":         plugin.Name(),
+			"description":  plugin.Description(),
+			"version":      plugin.Version(),
+			"icon":         manifest.Icon,
+			"category":     manifest.Category,
+			"capabilities": manifest.Capabilities,
+			"enabled":      plugin.IsEnabled(),
 		}
 		pluginsList = append(pluginsList, pluginInfo)
 	}
@@ -47,7 +61,7 @@ func (h *PluginHandler) Get(w http.ResponseWriter, r *http.Request) {
 	pluginName := chi.URLParam(r, "name")
 
 	if h.server.plugins == nil {
-		http.Error(w, "Plugin not found", http.StatusNotFound)
+		writeJSONError(w, http.StatusNotFound, "plugin_not_found", "Plugin not found")
 		return
 	}
 
@@ -59,10 +73,14 @@ func (h *PluginHandler) Get(w http.ResponseWriter, r *http.Request) {
 				routesCount = len(routes)
 			}
 
+			manifest := plugins.ManifestOf(plugin)
 			pluginInfo := map[string]interface{}{
 				"name":         plugin.Name(),
 				"description":  plugin.Description(),
 				"version":      plugin.Version(),
+				"icon":         manifest.Icon,
+				"category":     manifest.Category,
+				"capabilities": manifest.Capabilities,
 				"enabled":      plugin.IsEnabled(),
 				"routes_count": routesCount,
 			}
@@ -72,15 +90,28 @@ func (h *PluginHandler) Get(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	http.Error(w, "Plugin not found", http.StatusNotFound)
+	writeJSONError(w, http.StatusNotFound, "plugin_not_found", "Plugin not found")
 }
 
-// GetHTML returns the HTML interface for a specific plugin
+// pluginFrameCSP restricts sandboxed plugin documents: an opaque origin
+// (no cookies, storage or access to the parent page), inline scripts, the
+// frame bridge and styles only, and no network access besides static
+// assets. API calls go through the parent page (see plugin-frame.js).
+const pluginFrameCSP = "sandbox allow-scripts allow-forms; " +
+	"default-src 'none'; " +
+	"script-src 'self' 'unsafe-inline'; " +
+	"style-src 'self' 'unsafe-inline'; " +
+	"img-src 'self' data:; " +
+	"frame-ancestors 'self'"
+
+// GetHTML returns the HTML interface for a specific plugin.
+// With plugin sandboxing enabled it returns a page section holding a
+// sandboxed iframe, and ?frame=1 returns the plugin document for it.
 func (h *PluginHandler) GetHTML(w http.ResponseWriter, r *http.Request) {
 	pluginName := chi.URLParam(r, "name")
 
 	if h.server.plugins == nil {
-		http.Error(w, "Plugin not found", http.StatusNotFound)
+		writeJSONError(w, http.StatusNotFound, "plugin_not_found", "Plugin not found")
 		return
 	}
 
@@ -88,12 +119,38 @@ func (h *PluginHandler) GetHTML(w http.ResponseWriter, r *http.Request) {
 		if plugin.Name() == pluginName {
 			html, err := plugin.GetHTML()
 			if err != nil {
-				http.Error(w, "Failed to get plugin HTML: "+err.Error(), http.StatusInternalServerError)
+				writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to get plugin HTML: "+err.Error())
 				return
 			}
 
 			if html == "" {
-				http.Error(w, "Plugin has no HTML interface", http.StatusNotFound)
+				writeJSONError(w, http.StatusNotFound, "plugin_no_html", "Plugin has no HTML interface")
+				return
+			}
+
+			etag, err := pluginHTMLVersion(plugin, html)
+			if err != nil {
+				writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to get plugin HTML: "+err.Error())
+				return
+			}
+
+			if h.server.config.PluginSandbox() {
+				if r.URL.Query().Get("frame") == "1" {
+					html = h.pluginFrameDocument(pluginName, html)
+					etag += "-f" + h.server.staticVersion
+					w.Header().Set("Content-Security-Policy", pluginFrameCSP)
+				} else {
+					html = pluginFrameSection(pluginName)
+					etag += "-s"
+				}
+			}
+
+			// Let the browser cache the page but revalidate on every load
+			etag = `"` + etag + `"`
+			w.Header().Set("ETag", etag)
+			w.Header().Set("Cache-Control", "private, no-cache")
+			if etagMatches(r.Header.Get("If-None-Match"), etag) {
+				w.WriteHeader(http.StatusNotModified)
 				return
 			}
 
@@ -104,7 +161,49 @@ func (h *PluginHandler) GetHTML(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	http.Error(w, "Plugin not found", http.StatusNotFound)
+	writeJSONError(w, http.StatusNotFound, "plugin_not_found", "Plugin not found")
+}
+
+// pluginHTMLVersion returns the version of a plugin's HTML for its ETag.
+// Plugins that don't implement plugins.HTMLVersioner are versioned by content.
+func pluginHTMLVersion(plugin plugins.Plugin, content string) (string, error) {
+	if v, ok := plugin.(plugins.HTMLVersioner); ok {
+		return v.HTMLVersion()
+	}
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:8]), nil
+}
+
+// etagMatches reports whether an If-None-Match header matches etag
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// pluginFrameSection returns the page section that embeds a plugin's
+// sandboxed iframe in place of its inlined HTML
+func pluginFrameSection(name string) string {
+	src := "/api/plugins/" + url.PathEscape(name) + "/html?frame=1"
+	return fmt.Sprintf(`<section id="page-plugin-%s" class="content-page hidden">`+
+		`<iframe class="plugin-frame" sandbox="allow-scripts allow-forms" src="%s" title="%s" data-plugin="%s"></iframe>`+
+		`</section>`, html.EscapeString(name), html.EscapeString(src), html.EscapeString(name), html.EscapeString(name))
+}
+
+// pluginFrameDocument wraps plugin HTML in a standalone document using
+// the app stylesheet, with the bridge that relays its API calls
+func (h *PluginHandler) pluginFrameDocument(name, body string) string {
+	version := html.EscapeString(h.server.staticVersion)
+	return `<!DOCTYPE html><html><head><meta charset="utf-8">` +
+		`<link rel="stylesheet" href="/static/css/style.css?v=` + version + `">` +
+		`<script src="/static/js/plugin-frame.js?v=` + version + `" data-plugin="` + html.EscapeString(name) + `"></script>` +
+		// Plugin sections are hidden until the app shows them
+		`<style>.content-page.hidden { display: block !important; }</style>` +
+		`</head><body>` + body + `</body></html>`
 }
 
 // Toggle enables or disables a plugin
@@ -116,14 +215,13 @@ func (h *PluginHandler) Toggle(w http.ResponseWriter, r *http.Request) {
 		Enabled bool `json:"enabled"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
 	// Check if storage is available
 	if h.server.storage == nil {
-		http.Error(w, "Storage not available", http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, "storage_unavailable", "Storage not available")
 		return
 	}
 
@@ -135,7 +233,7 @@ func (h *PluginHandler) Toggle(w http.ResponseWriter, r *http.Request) {
 			Name:    pluginName,
 		}
 	} else if err != nil {
-		http.Error(w, "Failed to get plugin config: "+err.Error(), http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to get plugin config: "+err.Error())
 		return
 	} else {
 		pluginConfig.Enabled = req.Enabled
@@ -143,10 +241,16 @@ func (h *PluginHandler) Toggle(w http.ResponseWriter, r *http.Request) {
 
 	// Save to storage
 	if err := h.server.storage.SetPluginConfig(pluginName, pluginConfig); err != nil {
-		http.Error(w, "Failed to save plugin config: "+err.Error(), http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to save plugin config: "+err.Error())
 		return
 	}
 
+	user := auth.GetUserFromContext(r.Context())
+	eventType := events.EventPluginDisable
+	if req.Enabled {
+		eventType = events.EventPluginEnable
+	}
+
 	// Try to dynamically enable/disable the plugin
 	restartRequired := false
 	if h.server.pluginRegistry != nil {
@@ -158,13 +262,16 @@ func (h *PluginHandler) Toggle(w http.ResponseWriter, r *http.Request) {
 			err = h.server.pluginRegistry.DisablePlugin(ctx, pluginName)
 		}
 		if err != nil {
-			http.Error(w, "Failed to toggle plugin: "+err.Error(), http.StatusInternalServerError)
+			h.server.eventStore.AddWithMeta(eventType, user.Username, getClientIP(r), false, pluginName+": "+err.Error(), events.Meta{"plugin": pluginName})
+			writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to toggle plugin: "+err.Error())
 			return
 		}
 	} else {
 		restartRequired = true
 	}
 
+	h.server.eventStore.AddWithMeta(eventType, user.Username, getClientIP(r), true, pluginName, events.Meta{"plugin": pluginName})
+
 	response := map[string]interface{}{
 		"success":          true,
 		"plugin":           pluginName,
@@ -174,3 +281,95 @@ func (h *PluginHandler) Toggle(w http.ResponseWriter, r *http.Request) {
 
 	writeJSON(w, http.StatusOK, response)
 }
+
+// Rediscover handles POST /api/plugins/{name}/mqtt/rediscover
+// Republishes the plugin's Home Assistant discovery configs, for a Home
+// Assistant that was added or reset after they were published.
+func (h *PluginHandler) Rediscover(w http.ResponseWriter, r *http.Request) {
+	pluginName := chi.URLParam(r, "name")
+
+	var plugin plugins.Plugin
+	for _, p := range h.server.plugins {
+		if p.Name() == pluginName {
+			plugin = p
+			break
+		}
+	}
+	if plugin == nil {
+		writeJSONError(w, http.StatusNotFound, "plugin_not_found", "Plugin not found")
+		return
+	}
+	rediscoverer, ok := plugin.(plugins.MQTTRediscoverer)
+	if !ok {
+		writeJSONError(w, http.StatusBadRequest, "not_supported", "Plugin does not publish MQTT discovery")
+		return
+	}
+	if !plugin.IsEnabled() {
+		writeJSONError(w, http.StatusServiceUnavailable, "plugin_disabled", "Plugin not enabled")
+		return
+	}
+
+	user := auth.GetUserFromContext(r.Context())
+	if err := rediscoverer.RediscoverMQTT(); err != nil {
+		h.server.eventStore.AddWithMeta(events.EventMQTTRediscover, user.Username, getClientIP(r), false, pluginName+": "+err.Error(), events.Meta{"plugin": pluginName})
+		if errors.Is(err, plugins.ErrNoDiscovery) {
+			writeJSONError(w, http.StatusConflict, "discovery_unavailable", err.Error())
+			return
+		}
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to republish discovery: "+err.Error())
+		return
+	}
+
+	h.server.eventStore.AddWithMeta(events.EventMQTTRediscover, user.Username, getClientIP(r), true, pluginName, events.Meta{"plugin": pluginName})
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"plugin":  pluginName,
+	})
+}
+
+// pluginLogsDefault is how many lines GET /api/plugins/{name}/logs returns
+// without ?lines=
+const pluginLogsDefault = 200
+
+// PluginLogsResponse is a plugin's recent log lines
+type PluginLogsResponse struct {
+	Plugin    string            `json:"plugin"`
+	Lines     []plugins.LogLine `json:"lines"`     // oldest first
+	Truncated bool              `json:"truncated"` // older lines exist that are not returned
+}
+
+// Logs handles GET /api/plugins/{name}/logs?lines=200
+// Returns the plugin's newest log lines, kept in memory since startup
+// (at most 500 per plugin).
+func (h *PluginHandler) Logs(w http.ResponseWriter, r *http.Request) {
+	pluginName := chi.URLParam(r, "name")
+
+	lines := pluginLogsDefault
+	if v := r.URL.Query().Get("lines"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > plugins.LogBufferLines {
+			writeJSONError(w, http.StatusBadRequest, "invalid_parameter",
+				fmt.Sprintf("lines must be between 1 and %d", plugins.LogBufferLines))
+			return
+		}
+		lines = n
+	}
+
+	found := false
+	for _, plugin := range h.server.plugins {
+		if plugin.Name() == pluginName {
+			found = true
+			break
+		}
+	}
+	if !found {
+		writeJSONError(w, http.StatusNotFound, "plugin_not_found", "Plugin not found")
+		return
+	}
+
+	resp := PluginLogsResponse{Plugin: pluginName, Lines: []plugins.LogLine{}}
+	if h.server.pluginRegistry != nil {
+		resp.Lines, resp.Truncated = h.server.pluginRegistry.Logs().Lines(pluginName, lines)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
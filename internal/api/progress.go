@@ -0,0 +1,75 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"podmanview/internal/podman"
+)
+
+// StreamMessage is one line of a response streamed with ?stream=true
+type StreamMessage struct {
+	Type   string       `json:"type"`             // "progress", "result" or "error"
+	Stream string       `json:"stream,omitempty"` // progress: pull output
+	Result interface{}  `json:"result,omitempty"` // result: the body sent without streaming
+	Error  *ErrorDetail `json:"error,omitempty"`
+}
+
+// streamWriter sends the response of a handler that may pull an image
+// first, either as a single JSON object or, when streaming, as
+// newline-delimited StreamMessage lines with the pull output ahead
+type streamWriter struct {
+	w       http.ResponseWriter
+	stream  bool
+	started bool
+}
+
+// newStreamWriter streams when the request has ?stream=true
+func newStreamWriter(w http.ResponseWriter, r *http.Request) *streamWriter {
+	return &streamWriter{w: w, stream: r.URL.Query().Get("stream") == "true"}
+}
+
+// progress sends a line of pull output when streaming
+func (sw *streamWriter) progress(line string) {
+	if sw.stream {
+		sw.send(StreamMessage{Type: "progress", Stream: line})
+	}
+}
+
+// error sends a failure; once streaming has started the status is already 200
+func (sw *streamWriter) error(status int, code, message string) {
+	if !sw.started {
+		writeJSONError(sw.w, status, code, message)
+		return
+	}
+	sw.send(StreamMessage{Type: "error", Error: &ErrorDetail{Code: code, Message: message}})
+}
+
+// podmanError sends a Podman failure the way writePodmanError does
+func (sw *streamWriter) podmanError(err error, notFoundCode string) {
+	if notFoundCode != "" && podman.IsNotFound(err) {
+		sw.error(http.StatusNotFound, notFoundCode, err.Error())
+		return
+	}
+	sw.error(http.StatusInternalServerError, "podman_error", err.Error())
+}
+
+// result sends the outcome with status, or as the last line when streaming
+func (sw *streamWriter) result(status int, result interface{}) {
+	if !sw.stream {
+		writeJSON(sw.w, status, result)
+		return
+	}
+	sw.send(StreamMessage{Type: "result", Result: result})
+}
+
+func (sw *streamWriter) send(msg StreamMessage) {
+	if !sw.started {
+		sw.w.Header().Set("Content-Type", "application/x-ndjson")
+		sw.w.Header().Set("Cache-Control", "no-cache")
+		sw.w.WriteHeader(http.StatusOK)
+		sw.started = true
+	}
+	json.NewEncoder(sw.w).Encode(msg)
+	http.NewResponseController(sw.w).Flush()
+}
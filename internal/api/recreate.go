@@ -0,0 +1,414 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"podmanview/internal/auth"
+	"podmanview/internal/events"
+	"podmanview/internal/podman"
+)
+
+// replaceTimeout bounds replacing a container, including stopping it and
+// restoring the original if the new one fails
+const replaceTimeout = 5 * time.Minute
+
+// restartPolicies are the restart policies Podman accepts
+var restartPolicies = []string{"no", "always", "on-failure", "unless-stopped"}
+
+// RecreateContainerRequest lists the changes for POST /api/containers/{id}/recreate.
+// Omitted fields keep the container's current settings.
+type RecreateContainerRequest struct {
+	Env           map[string]*string    `json:"env,omitempty"`            // Variables to set; null removes one
+	Ports         *[]podman.PortMapping `json:"ports,omitempty"`          // Replaces the published ports
+	Image         string                `json:"image,omitempty"`          // New image reference, e.g. a new tag
+	RestartPolicy *string               `json:"restart_policy,omitempty"` // "no", "always", "on-failure" or "unless-stopped"
+	AutoPull      bool                  `json:"auto_pull"`                // Pull a new image that isn't present locally
+}
+
+// Recreate handles POST /api/containers/{id}/recreate.
+// Podman can't change a container in place, so the container is stopped,
+// removed and created again under the same name with the changes applied,
+// then started if it was running. If that fails, the original is created
+// again from its previous settings. With ?stream=true the output of an
+// auto-pull of a new image is streamed ahead of the result, as in Create.
+//
+// Carried over are the command, entrypoint, user, working directory,
+// environment, labels, healthcheck, ports, bind mounts, named volumes,
+// tmpfs mounts, networks with their IP and MAC addresses, hostname, DNS
+// settings, extra hosts, privileged mode, security options, capabilities,
+// devices, resource limits and restart policy. Containers in a pod,
+// sharing another's network namespace, or with other mounts or security
+// options can't be recreated.
+func (h *ContainerHandler) Recreate(w http.ResponseWriter, r *http.Request) {
+	user := auth.GetUserFromContext(r.Context())
+	id := chi.URLParam(r, "id")
+
+	var req RecreateContainerRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.RestartPolicy != nil && *req.RestartPolicy != "" && !slices.Contains(restartPolicies, *req.RestartPolicy) {
+		writeJSONError(w, http.StatusBadRequest, "invalid_restart_policy",
+			"Restart policy must be one of: "+strings.Join(restartPolicies, ", "))
+		return
+	}
+	for key := range req.Env {
+		if key == "" || strings.ContainsAny(key, "= \t\n") {
+			writeJSONError(w, http.StatusBadRequest, "invalid_env", fmt.Sprintf("Invalid environment variable name: %q", key))
+			return
+		}
+	}
+
+	info, err := h.client.InspectContainer(r.Context(), id)
+	if err != nil {
+		writePodmanError(w, err, "container_not_found")
+		return
+	}
+	if info.Pod != "" {
+		writeJSONError(w, http.StatusConflict, "container_in_pod", "Containers in a pod can't be recreated")
+		return
+	}
+
+	// The image's own command and environment aren't pinned, so a new
+	// image brings its defaults
+	oldImage, err := h.client.InspectImage(r.Context(), info.Image)
+	if err != nil && !podman.IsNotFound(err) {
+		writePodmanError(w, err, "")
+		return
+	}
+	original, err := recreateConfig(info, oldImage)
+	if err != nil {
+		writeJSONError(w, http.StatusConflict, "unsupported_config", "Container can't be recreated: "+err.Error())
+		return
+	}
+	updated := applyRecreateChanges(original, req)
+	meta := events.Meta{"container": info.ID, "name": original.Name, "image": updated.Image}
+
+	sw := newStreamWriter(w, r)
+	if updated.Image != original.Image && !h.ensureImage(sw, r, updated.Image, req.AutoPull) {
+		return
+	}
+
+	running := info.State.Running
+	newID, rerr := h.replaceContainer(r.Context(), info, original, updated)
+	if rerr != nil {
+		h.eventStore.AddWithMeta(events.EventContainerRecreate, user.Username, getClientIP(r), false, original.Name, meta)
+		sw.error(rerr.status, rerr.code, rerr.message)
+		return
+	}
+
+	h.eventStore.AddWithMeta(events.EventContainerRecreate, user.Username, getClientIP(r), true, original.Name, meta)
+	status := "created"
+	if running {
+		status = "started"
+	}
+	sw.result(http.StatusOK, map[string]string{"id": newID, "status": status})
+}
+
+// replaceError is a failed container replacement with its HTTP status
+type replaceError struct {
+	status  int
+	code    string
+	message string
+}
+
+// replaceContainer stops and removes the container described by info, then
+// creates updated and starts it if the container was running. If that
+// fails, original is created again in its place. Once started, the
+// replacement runs to the end even if the client goes away, so the
+// container isn't left removed.
+func (h *ContainerHandler) replaceContainer(ctx context.Context, info *podman.ContainerInspect, original, updated *podman.ContainerCreateConfig) (string, *replaceError) {
+	// Stopping would remove it before it can be replaced
+	if info.HostConfig.AutoRemove {
+		return "", &replaceError{http.StatusConflict, "auto_remove", "Containers that are removed when they exit can't be replaced"}
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), replaceTimeout)
+	defer cancel()
+	running := info.State.Running
+	if running {
+		if err := h.client.StopContainer(ctx, info.ID); err != nil {
+			return "", &replaceError{http.StatusInternalServerError, "podman_error", "Failed to stop container: " + err.Error()}
+		}
+	}
+	if err := h.client.RemoveContainer(ctx, info.ID, false); err != nil {
+		if running {
+			h.client.StartContainer(ctx, info.ID)
+		}
+		return "", &replaceError{http.StatusInternalServerError, "podman_error", "Failed to remove container: " + err.Error()}
+	}
+
+	newID, err := h.createAndStart(ctx, updated, running)
+	if err != nil {
+		logger(ctx).Printf("Recreating container %s failed, restoring it: %v", original.Name, err)
+		if _, rollbackErr := h.createAndStart(ctx, original, running); rollbackErr != nil {
+			logger(ctx).Printf("Restoring container %s failed: %v", original.Name, rollbackErr)
+			return "", &replaceError{http.StatusInternalServerError, "recreate_failed",
+				fmt.Sprintf("Failed to recreate container: %v; restoring the original also failed: %v", err, rollbackErr)}
+		}
+		return "", &replaceError{http.StatusUnprocessableEntity, "recreate_failed",
+			fmt.Sprintf("Failed to recreate container, the original was restored: %v", err)}
+	}
+	return newID, nil
+}
+
+// createAndStart creates a container and starts it if start is set. A
+// container that fails to start is removed again.
+func (h *ContainerHandler) createAndStart(ctx context.Context, config *podman.ContainerCreateConfig, start bool) (string, error) {
+	result, err := h.client.CreateContainer(ctx, config)
+	if err != nil {
+		return "", err
+	}
+	if start {
+		if err := h.client.StartContainer(ctx, result.ID); err != nil {
+			h.client.RemoveContainer(ctx, result.ID, true)
+			return "", err
+		}
+	}
+	return result.ID, nil
+}
+
+// recreateNetworkModes are the network modes a container can be recreated
+// with; bridge is carried over with its networks
+var recreateNetworkModes = []string{"", "bridge", "host", "none", "private", "slirp4netns", "pasta"}
+
+// recreateConfig returns the create config reproducing a container. The
+// command, entrypoint, user, working directory, variables, labels and
+// healthcheck that come from the image are left out. Fails for settings
+// that can't be reproduced (network modes, mount types and security
+// options it doesn't know), before anything is changed.
+func recreateConfig(info *podman.ContainerInspect, image *podman.ImageInspect) (*podman.ContainerCreateConfig, error) {
+	host := info.HostConfig
+	if !slices.Contains(recreateNetworkModes, host.NetworkMode) {
+		return nil, fmt.Errorf("network mode %q can't be reproduced", host.NetworkMode)
+	}
+
+	config := &podman.ContainerCreateConfig{
+		Name:          strings.TrimPrefix(info.Name, "/"),
+		Image:         info.ImageName,
+		RestartPolicy: info.HostConfig.RestartPolicy.Name,
+	}
+	if config.Image == "" {
+		config.Image = info.Image
+	}
+	if config.RestartPolicy == "on-failure" {
+		config.RestartTries = info.HostConfig.RestartPolicy.MaximumRetryCount
+	}
+
+	imageConfig := podman.ImageInspect{}.Config
+	if image != nil {
+		imageConfig = image.Config
+	}
+	imageEnv, imageLabels := imageConfig.Env, imageConfig.Labels
+	if !slices.Equal(info.Config.Cmd, imageConfig.Cmd) {
+		config.Command = info.Config.Cmd
+	}
+	if !slices.Equal(info.Config.Entrypoint, imageConfig.Entrypoint) {
+		config.Entrypoint = info.Config.Entrypoint
+	}
+	if info.Config.User != imageConfig.User {
+		config.User = info.Config.User
+	}
+	if info.Config.WorkingDir != imageConfig.WorkingDir {
+		config.WorkDir = info.Config.WorkingDir
+	}
+	if info.Config.Healthcheck != nil && !reflect.DeepEqual(info.Config.Healthcheck, imageConfig.Healthcheck) {
+		config.HealthConfig = info.Config.Healthcheck
+	}
+	for _, kv := range info.Config.Env {
+		if slices.Contains(imageEnv, kv) {
+			continue
+		}
+		if key, value, ok := strings.Cut(kv, "="); ok {
+			if config.Env == nil {
+				config.Env = make(map[string]string)
+			}
+			config.Env[key] = value
+		}
+	}
+
+	for key, value := range info.Config.Labels {
+		if imageValue, ok := imageLabels[key]; ok && imageValue == value {
+			continue
+		}
+		if config.Labels == nil {
+			config.Labels = make(map[string]string)
+		}
+		config.Labels[key] = value
+	}
+
+	for _, b := range info.PortBindings() {
+		config.PortMappings = append(config.PortMappings, podman.PortMapping{
+			HostIP:        b.HostIP,
+			ContainerPort: b.ContainerPort,
+			HostPort:      b.HostPort,
+			Protocol:      b.Protocol,
+		})
+	}
+
+	for _, m := range info.Mounts {
+		var options []string
+		if !m.RW {
+			options = []string{"ro"}
+		}
+		switch m.Type {
+		case "bind":
+			config.Mounts = append(config.Mounts, podman.Mount{Type: "bind", Source: m.Source, Destination: m.Destination, Options: options})
+		case "volume":
+			config.Volumes = append(config.Volumes, podman.NamedVolume{Name: m.Name, Dest: m.Destination, Options: options})
+		case "tmpfs":
+			// Listed with its options in HostConfig.Tmpfs
+			if _, ok := host.Tmpfs[m.Destination]; !ok {
+				config.Mounts = append(config.Mounts, podman.Mount{Type: "tmpfs", Source: "tmpfs", Destination: m.Destination, Options: options})
+			}
+		default:
+			return nil, fmt.Errorf("%s mount at %s can't be reproduced", m.Type, m.Destination)
+		}
+	}
+	for _, dest := range slices.Sorted(maps.Keys(host.Tmpfs)) {
+		var options []string
+		if opts := host.Tmpfs[dest]; opts != "" {
+			options = strings.Split(opts, ",")
+		}
+		config.Mounts = append(config.Mounts, podman.Mount{Type: "tmpfs", Source: "tmpfs", Destination: dest, Options: options})
+	}
+
+	config.Privileged = host.Privileged
+	if err := recreateSecurityOpts(config, host.SecurityOpt); err != nil {
+		return nil, err
+	}
+	config.CapAdd, config.CapDrop = host.CapAdd, host.CapDrop
+	for _, d := range host.Devices {
+		config.Devices = append(config.Devices, podman.Device{
+			Path: d.PathOnHost + ":" + d.PathInContainer + ":" + d.CgroupPermissions,
+		})
+	}
+	config.ResourceLimits = recreateLimits(info)
+
+	// Podman names the container after its short ID unless told otherwise,
+	// and a host-network container has the host's name
+	if hostname := info.Config.Hostname; hostname != "" && !strings.HasPrefix(info.ID, hostname) && host.NetworkMode != "host" {
+		config.Hostname = hostname
+	}
+	config.DNSServers, config.DNSOptions, config.DNSSearch = host.Dns, host.DnsOptions, host.DnsSearch
+	config.HostAdd = host.ExtraHosts
+
+	switch host.NetworkMode {
+	case "":
+	case "bridge":
+		config.NetNS = &podman.Namespace{NSMode: "bridge"}
+		for name, network := range info.NetworkSettings.Networks {
+			if config.Networks == nil {
+				config.Networks = make(map[string]podman.NetworkOptions)
+			}
+			// Podman adds the short container ID as an alias itself
+			var aliases []string
+			for _, alias := range network.Aliases {
+				if !strings.HasPrefix(info.ID, alias) {
+					aliases = append(aliases, alias)
+				}
+			}
+			// The addresses are pinned so the container keeps them
+			options := podman.NetworkOptions{Aliases: aliases, StaticMAC: network.MacAddress}
+			for _, ip := range []string{network.IPAddress, network.GlobalIPv6Address} {
+				if ip != "" {
+					options.StaticIPs = append(options.StaticIPs, ip)
+				}
+			}
+			config.Networks[name] = options
+		}
+	default:
+		config.NetNS = &podman.Namespace{NSMode: host.NetworkMode}
+	}
+
+	return config, nil
+}
+
+// recreateSecurityOpts sets the security options a container was created
+// with (HostConfig.SecurityOpt) on config
+func recreateSecurityOpts(config *podman.ContainerCreateConfig, opts []string) error {
+	for _, opt := range opts {
+		key, value, _ := strings.Cut(opt, "=")
+		switch key {
+		case "label":
+			config.SelinuxOpts = append(config.SelinuxOpts, value)
+		case "seccomp":
+			config.SeccompProfilePath = value
+		case "apparmor":
+			config.ApparmorProfile = value
+		case "no-new-privileges":
+			if value != "" && value != "true" {
+				continue
+			}
+			config.NoNewPrivileges = true
+		case "mask":
+			config.Mask = append(config.Mask, strings.Split(value, ":")...)
+		case "unmask":
+			config.Unmask = append(config.Unmask, strings.Split(value, ":")...)
+		default:
+			return fmt.Errorf("security option %q can't be reproduced", opt)
+		}
+	}
+	return nil
+}
+
+// recreateLimits returns a container's resource limits, nil if it has none
+func recreateLimits(info *podman.ContainerInspect) *podman.ResourceLimits {
+	host := info.HostConfig
+	limits := &podman.ResourceLimits{}
+	if host.Memory != 0 || host.MemorySwap != 0 {
+		limits.Memory = &podman.MemoryLimits{Limit: host.Memory, Swap: host.MemorySwap}
+	}
+	if host.CpuShares != 0 || host.CpuQuota != 0 || host.CpuPeriod != 0 || host.CpusetCpus != "" {
+		limits.CPU = &podman.CPULimits{Shares: host.CpuShares, Quota: host.CpuQuota, Period: host.CpuPeriod, Cpus: host.CpusetCpus}
+	}
+	if host.PidsLimit != 0 {
+		limits.Pids = &podman.PidsLimits{Limit: host.PidsLimit}
+	}
+	if *limits == (podman.ResourceLimits{}) {
+		return nil
+	}
+	return limits
+}
+
+// applyRecreateChanges returns a copy of config with the requested changes
+func applyRecreateChanges(config *podman.ContainerCreateConfig, req RecreateContainerRequest) *podman.ContainerCreateConfig {
+	updated := *config
+
+	if len(req.Env) > 0 {
+		updated.Env = make(map[string]string, len(config.Env)+len(req.Env))
+		for key, value := range config.Env {
+			updated.Env[key] = value
+		}
+		for key, value := range req.Env {
+			if value == nil {
+				delete(updated.Env, key)
+			} else {
+				updated.Env[key] = *value
+			}
+		}
+	}
+	if req.Ports != nil {
+		updated.PortMappings = *req.Ports
+	}
+	if req.Image != "" {
+		updated.Image = req.Image
+	}
+	if req.RestartPolicy != nil {
+		updated.RestartPolicy = *req.RestartPolicy
+		if updated.RestartPolicy != "on-failure" {
+			updated.RestartTries = 0
+		}
+	}
+
+	return &updated
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"podmanview/internal/auth"
+	"podmanview/internal/events"
+	"podmanview/internal/podman"
+)
+
+// RedeployResult is the outcome of POST /api/containers/{id}/redeploy
+type RedeployResult struct {
+	Updated  bool   `json:"updated"`          // Whether the container was recreated on a new image
+	ID       string `json:"id"`               // Container ID, new if updated
+	Image    string `json:"image"`            // Image reference that was pulled
+	OldImage string `json:"oldImage"`         // Image ID the container ran before
+	NewImage string `json:"newImage"`         // Image ID the reference points to now
+	Status   string `json:"status,omitempty"` // "created" or "started" if updated
+}
+
+// Redeploy handles POST /api/containers/{id}/redeploy?stream=true.
+// The container's image reference is pulled again; if it now points to a
+// different image, the container is recreated on it with its current
+// settings (see Recreate). With stream=true the pull output is streamed
+// as newline-delimited JSON ahead of the result.
+func (h *ContainerHandler) Redeploy(w http.ResponseWriter, r *http.Request) {
+	user := auth.GetUserFromContext(r.Context())
+	id := chi.URLParam(r, "id")
+	sw := newStreamWriter(w, r)
+
+	info, err := h.client.InspectContainer(r.Context(), id)
+	if err != nil {
+		writePodmanError(w, err, "container_not_found")
+		return
+	}
+	if info.Pod != "" {
+		writeJSONError(w, http.StatusConflict, "container_in_pod", "Containers in a pod can't be recreated")
+		return
+	}
+	reference := info.ImageName
+	if reference == "" || strings.HasPrefix(info.Image, reference) {
+		writeJSONError(w, http.StatusBadRequest, "no_image_reference",
+			"Container was created from an image ID, there is no tag to pull")
+		return
+	}
+
+	oldImage, err := h.client.InspectImage(r.Context(), info.Image)
+	if err != nil && !podman.IsNotFound(err) {
+		writePodmanError(w, err, "")
+		return
+	}
+	// The reference will point to the new image, so the original is
+	// restored from the old image ID if the new container fails
+	updated, err := recreateConfig(info, oldImage)
+	if err != nil {
+		writeJSONError(w, http.StatusConflict, "unsupported_config", "Container can't be recreated: "+err.Error())
+		return
+	}
+	original := *updated
+	original.Image = info.Image
+
+	err = h.client.PullImageWithProgress(r.Context(), reference, podman.Platform{}, func(p podman.PullProgress) {
+		if line := strings.TrimSpace(p.Stream); line != "" {
+			sw.progress(line)
+		}
+	})
+	h.eventStore.AddWithMeta(events.EventImagePull, user.Username, getClientIP(r), err == nil, reference, events.Meta{"image": reference})
+	if err != nil {
+		sw.error(http.StatusInternalServerError, "pull_failed", "Failed to pull image: "+err.Error())
+		return
+	}
+	newImage, err := h.client.InspectImage(r.Context(), reference)
+	if err != nil {
+		sw.error(http.StatusInternalServerError, "podman_error", "Failed to inspect pulled image: "+err.Error())
+		return
+	}
+
+	result := &RedeployResult{ID: info.ID, Image: reference, OldImage: info.Image, NewImage: newImage.ID}
+	if newImage.ID == info.Image {
+		sw.result(http.StatusOK, result)
+		return
+	}
+
+	meta := events.Meta{"container": info.ID, "name": updated.Name, "image": reference}
+
+	newID, rerr := h.replaceContainer(r.Context(), info, &original, updated)
+	h.eventStore.AddWithMeta(events.EventContainerRedeploy, user.Username, getClientIP(r), rerr == nil, updated.Name, meta)
+	if rerr != nil {
+		sw.error(rerr.status, rerr.code, rerr.message)
+		return
+	}
+
+	result.Updated = true
+	result.ID = newID
+	result.Status = "created"
+	if info.State.Running {
+		result.Status = "started"
+	}
+	sw.result(http.StatusOK, result)
+}
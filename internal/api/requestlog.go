@@ -0,0 +1,43 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// requestIDHeader returns the request ID to the client, so a user's
+// report can be matched with the server log
+const requestIDHeader = "X-Request-ID"
+
+// loggerKey is the context key of the request logger
+type loggerKey struct{}
+
+// requestLogger stores a logger prefixed with the request ID in the
+// request context. It must run after middleware.RequestID; the prefix
+// matches the ID middleware.Logger prints in the access log line.
+func requestLogger(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqID := middleware.GetReqID(r.Context())
+		if reqID == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set(requestIDHeader, reqID)
+		logger := log.New(log.Writer(), log.Prefix()+"["+reqID+"] ", log.Flags())
+		ctx := context.WithValue(r.Context(), loggerKey{}, logger)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// logger returns the request logger from ctx, or the standard logger
+// outside of a request
+func logger(ctx context.Context) *log.Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*log.Logger); ok {
+		return l
+	}
+	return log.Default()
+}
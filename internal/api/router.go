@@ -2,9 +2,11 @@ package api
 
 import (
 	"encoding/json"
+	"io/fs"
 	"log"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/go-chi/chi/v5"
@@ -17,13 +19,15 @@ import (
 	"podmanview/internal/plugins"
 	"podmanview/internal/storage"
 	"podmanview/internal/updater"
+	"podmanview/web"
 )
 
 // Server represents the API server
 type Server struct {
 	router         *chi.Mux
 	podmanClient   *podman.Client
-	pamAuth        *auth.PAMAuth
+	authenticator  auth.Authenticator
+	oidc           *auth.OIDCProvider // nil when single sign-on is off
 	jwtManager     *auth.JWTManager
 	authMw         *auth.Middleware
 	wsTokenStore   *auth.WSTokenStore
@@ -31,11 +35,15 @@ type Server struct {
 	config         *config.Config
 	updater        *updater.Updater
 	historyHandler *HistoryHandler
+	statsCache     *StatsCache   // latest container stats, streamed by StartStatsCache
+	statsHistory   *StatsHistory // container resource usage, sampled by StartStatsHistory
 	plugins        []plugins.Plugin
 	pluginRegistry *plugins.Registry
 	storage        storage.Storage
 	version        string
 	staticVersion  string
+	webFS          fs.FS // static/ and templates/, embedded or from PODMANVIEW_DEV_DIR
+	restart        chan struct{}
 }
 
 // NewServer creates new API server without plugins
@@ -45,11 +53,26 @@ func NewServer(podmanClient *podman.Client, cfg *config.Config, version, staticV
 
 // NewServerWithPlugins creates new API server with plugins
 func NewServerWithPlugins(podmanClient *podman.Client, cfg *config.Config, version, staticVersion string, pluginList []plugins.Plugin, registry *plugins.Registry, pluginStorage storage.Storage) *Server {
-	pamAuth := auth.NewPAMAuth()
+	authenticator := newAuthenticator(cfg)
 	jwtManager := auth.NewJWTManager(cfg.JWTSecret(), cfg.JWTExpiration())
+	// Keep sessions signed before the last secret rotation valid
+	setJWTSecrets(jwtManager, cfg)
 	authMw := auth.NewMiddleware(jwtManager)
 	wsTokenStore := auth.NewWSTokenStore()
-	eventStore := events.NewStore(100) // Keep last 100 events in memory
+	// Honor forwarded client IPs only from configured proxies
+	setTrustedProxies(cfg.TrustedProxies())
+	// Never send the auth cookie over plaintext when serving HTTPS
+	auth.SetSecureCookies(cfg.TLSEnabled())
+	// Let CORS frontends on other sites send the auth cookie
+	auth.SetCrossSiteCookies(len(cfg.CORSOrigins()) > 0)
+
+	// Share the plugins' event store so plugin events show up in the audit log
+	var eventStore *events.Store
+	if registry != nil && registry.Deps() != nil && registry.Deps().EventStore != nil {
+		eventStore = registry.Deps().EventStore
+	} else {
+		eventStore = events.NewStore(100) // Keep last 100 events in memory
+	}
 
 	// Get working directory for updater
 	workDir, err := os.Getwd()
@@ -65,12 +88,13 @@ func NewServerWithPlugins(podmanClient *podman.Client, cfg *config.Config, versi
 	}
 
 	// Create history handler (store history in database)
-	historyHandler := NewHistoryHandler(pluginStorage)
+	historyHandler := NewHistoryHandler(pluginStorage, eventStore)
 
 	s := &Server{
 		router:         chi.NewRouter(),
 		podmanClient:   podmanClient,
-		pamAuth:        pamAuth,
+		authenticator:  authenticator,
+		oidc:           newOIDCProvider(cfg),
 		jwtManager:     jwtManager,
 		authMw:         authMw,
 		wsTokenStore:   wsTokenStore,
@@ -78,11 +102,21 @@ func NewServerWithPlugins(podmanClient *podman.Client, cfg *config.Config, versi
 		config:         cfg,
 		updater:        upd,
 		historyHandler: historyHandler,
+		statsCache:     NewStatsCache(podmanClient),
+		statsHistory:   NewStatsHistory(statsHistoryPoints, statsHistoryContainers),
 		plugins:        pluginList,
 		pluginRegistry: registry,
 		storage:        pluginStorage,
 		version:        version,
 		staticVersion:  staticVersion,
+		webFS:          web.FS,
+		restart:        make(chan struct{}, 1),
+	}
+
+	// Development: serve assets from the checkout so edits apply without rebuilding
+	if devDir := cfg.DevDir(); devDir != "" {
+		s.webFS = os.DirFS(filepath.Join(devDir, "web"))
+		log.Printf("Serving web assets from %s", filepath.Join(devDir, "web"))
 	}
 
 	s.setupRoutes()
@@ -94,23 +128,48 @@ func (s *Server) setupRoutes() {
 	r := s.router
 
 	// Middleware
+	r.Use(middleware.RequestID)
+	r.Use(requestLogger)
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
-	r.Use(middleware.Compress(5))
+	r.Use(compressResponses(s.config))
+	r.Use(limitRequestBody(s.config))
+	r.Use(securityHeaders(s.config))
+	r.Use(corsHeaders(s.config))
+	if s.config.TLSCert() != "" {
+		// HSTS only with a configured certificate (see strictTransport)
+		r.Use(strictTransport)
+	}
 
 	// Create handlers
-	authHandler := NewAuthHandler(s.pamAuth, s.jwtManager, s.wsTokenStore, s.eventStore)
-	containerHandler := NewContainerHandler(s.podmanClient, s.eventStore)
-	imageHandler := NewImageHandler(s.podmanClient, s.eventStore)
-	systemHandler := NewSystemHandler(s.podmanClient, s.eventStore, s.pluginRegistry)
-	terminalHandler := NewTerminalHandler(s.podmanClient, s.wsTokenStore, s.eventStore, s.historyHandler)
+	authHandler := NewAuthHandler(s.authenticator, s.oidc, s.jwtManager, s.wsTokenStore, s.eventStore, s.config)
+	containerHandler := NewContainerHandler(s.podmanClient, s.eventStore, s.config, s.statsCache, s.statsHistory)
+	imageHandler := NewImageHandler(s.podmanClient, s.eventStore, s.config)
+	volumeHandler := NewVolumeHandler(s.podmanClient, s.eventStore)
+	systemHandler := NewSystemHandler(s.podmanClient, s.eventStore, s.pluginRegistry, s.config)
+	terminalHandler := NewTerminalHandler(s.podmanClient, s.wsTokenStore, s.eventStore, s.historyHandler, s.config)
 	eventsHandler := NewEventsHandler(s.eventStore)
 	updateHandler := NewUpdateHandler(s.updater, s.eventStore)
-	fileManagerHandler := NewFileManagerHandler(s.eventStore, "")  // Empty baseDir means use home dir
+	fileManagerHandler := NewFileManagerHandler(s.eventStore, s.wsTokenStore, "")  // Empty baseDir means use home dir
+	bookmarkHandler := NewBookmarkHandler(s.storage, fileManagerHandler)
+	templateHandler := NewTemplateHandler(s.storage)
 	pluginHandler := NewPluginHandler(s)
+	mqttHandler := NewMQTTHandler(s.config, s.pluginRegistry, s.eventStore)
+	configHandler := NewConfigHandler(s)
+	diagnosticsHandler := NewDiagnosticsHandler(s)
+	batchHandler := NewBatchHandler(s.router)
+	streamMuxHandler := NewStreamMuxHandler(s.podmanClient, s.eventStore, s.wsTokenStore, s.statsCache)
+
+	// Authorization: mutating routes declare the action they require,
+	// the policy in auth decides which roles may perform it
+	allow := auth.RequireAction
 
 	// Public routes
 	r.Post("/api/auth/login", authHandler.Login)
+	r.Get("/api/auth/oidc", authHandler.OIDCStatus)
+	r.Get("/api/auth/oidc/login", authHandler.OIDCLogin)
+	r.Get("/api/auth/oidc/callback", authHandler.OIDCCallback)
+	r.Get("/healthz", systemHandler.Healthz)
 
 	// Protected API routes
 	r.Group(func(r chi.Router) {
@@ -125,68 +184,142 @@ func (s *Server) setupRoutes() {
 		// Auth
 		r.Post("/api/auth/logout", authHandler.Logout)
 		r.Get("/api/auth/me", authHandler.Me)
+		r.Get("/api/auth/capabilities", authHandler.Capabilities)
 		r.Get("/api/auth/ws-token", authHandler.WSToken)
+		r.Post("/api/auth/change-password", authHandler.ChangePassword)
+		r.With(allow(auth.ActionChangeSettings)).Post("/api/auth/rotate-secret", configHandler.RotateSecret)
+
+		// Batch of GET requests, dispatched through this router
+		r.Post("/api/batch", batchHandler.Batch)
 
 		// Events
 		r.Get("/api/events", eventsHandler.List)
 
+		// Events, container stats and logs over one WebSocket
+		r.Get("/api/ws", streamMuxHandler.Connect)
+
 		// Containers
 		r.Get("/api/containers", containerHandler.List)
-		r.Post("/api/containers", containerHandler.Create)
+		r.With(allow(auth.ActionManageContainers)).Post("/api/containers", containerHandler.Create)
+		r.With(allow(auth.ActionManageContainers)).Post("/api/containers/run", containerHandler.Run)
+		r.Get("/api/containers/stats", containerHandler.Stats)
 		r.Get("/api/containers/{id}", containerHandler.Inspect)
 		r.Get("/api/containers/{id}/logs", containerHandler.Logs)
-		r.Post("/api/containers/{id}/start", containerHandler.Start)
-		r.Post("/api/containers/{id}/stop", containerHandler.Stop)
-		r.Post("/api/containers/{id}/restart", containerHandler.Restart)
-		r.Delete("/api/containers/{id}", containerHandler.Remove)
+		r.Get("/api/containers/{id}/stats/history", containerHandler.StatsHistory)
+		r.Get("/api/containers/{id}/links", containerHandler.Links)
+		r.With(allow(auth.ActionManageContainers)).Post("/api/containers/{id}/start", containerHandler.Start)
+		r.With(allow(auth.ActionManageContainers)).Post("/api/containers/{id}/stop", containerHandler.Stop)
+		r.With(allow(auth.ActionManageContainers)).Post("/api/containers/{id}/restart", containerHandler.Restart)
+		r.With(allow(auth.ActionManageContainers)).Post("/api/containers/{id}/recreate", containerHandler.Recreate)
+		r.With(allow(auth.ActionManageContainers)).Post("/api/containers/{id}/redeploy", containerHandler.Redeploy)
+		r.With(allow(auth.ActionDeleteContainers)).Delete("/api/containers/{id}", containerHandler.Remove)
+		r.Get("/api/containers/{id}/systemd", containerHandler.Systemd)
+		r.With(allow(auth.ActionInstallUnits)).Post("/api/containers/{id}/systemd", containerHandler.InstallSystemd)
+		r.Get("/api/containers/{id}/autostart", containerHandler.Autostart)
+		r.With(allow(auth.ActionInstallUnits)).Post("/api/containers/{id}/autostart", containerHandler.SetAutostart)
+
+		// Container templates (presets for the create form)
+		r.With(allow(auth.ActionManageContainers)).Get("/api/templates", templateHandler.List)
+		r.With(allow(auth.ActionManageContainers)).Post("/api/templates", templateHandler.Save)
+		r.With(allow(auth.ActionManageContainers)).Delete("/api/templates/{name}", templateHandler.Delete)
 
 		// Terminal (WebSocket) - history is sent via WebSocket
-		r.Get("/api/containers/{id}/terminal", terminalHandler.Connect)
-		r.Get("/api/terminal", terminalHandler.HostTerminal)
+		r.With(allow(auth.ActionExec)).Get("/api/containers/{id}/terminal", terminalHandler.Connect)
+		r.With(allow(auth.ActionExec)).Get("/api/pods/{id}/terminal", terminalHandler.PodConnect)
+		r.With(allow(auth.ActionExec)).Post("/api/containers/{id}/exec/download", terminalHandler.ExecDownload)
+		r.With(allow(auth.ActionExec)).Post("/api/containers/{id}/exec/upload", terminalHandler.ExecUpload)
+		r.With(allow(auth.ActionHostTerminal)).Get("/api/terminal", terminalHandler.HostTerminal)
+		r.With(allow(auth.ActionHostTerminal)).Get("/api/terminal/history", s.historyHandler.List)
+		r.With(allow(auth.ActionHostTerminal)).Delete("/api/terminal/history", s.historyHandler.Clear)
+		r.With(allow(auth.ActionHostTerminal)).Delete("/api/terminal/history/{index}", s.historyHandler.Delete)
 
 		// Images
 		r.Get("/api/images", imageHandler.List)
+		r.With(allow(auth.ActionPullImages)).Get("/api/images/search", imageHandler.Search)
 		r.Get("/api/images/{id}", imageHandler.Inspect)
-		r.Post("/api/images/pull", imageHandler.Pull)
-		r.Delete("/api/images/{id}", imageHandler.Remove)
+		r.Get("/api/images/{id}/pinned-reference", imageHandler.PinnedReference)
+		r.With(allow(auth.ActionPullImages)).Post("/api/images/pull", imageHandler.Pull)
+		r.With(allow(auth.ActionDeleteImages)).Post("/api/images/prune", imageHandler.Prune)
+		r.With(allow(auth.ActionDeleteImages)).Delete("/api/images/{id}", imageHandler.Remove)
+		r.With(allow(auth.ActionScanImages)).Post("/api/images/{id}/scan", imageHandler.Scan)
+
+		// Volumes
+		r.Get("/api/volumes/dangling", volumeHandler.Dangling)
+		r.With(allow(auth.ActionDeleteVolumes)).Post("/api/volumes/prune", volumeHandler.Prune)
 
 		// System
 		r.Get("/api/system/dashboard", systemHandler.Dashboard)
 		r.Get("/api/system/info", systemHandler.Info)
 		r.Get("/api/system/df", systemHandler.DiskUsage)
-		r.Post("/api/system/reboot", systemHandler.Reboot)
-		r.Post("/api/system/shutdown", systemHandler.Shutdown)
+		r.With(allow(auth.ActionChangeSettings)).Get("/api/system/diagnostics", diagnosticsHandler.Run)
+		r.With(allow(auth.ActionChangeSettings)).Get("/api/system/runtime", diagnosticsHandler.Runtime)
+		r.With(allow(auth.ActionPowerSystem)).Post("/api/system/reboot", systemHandler.Reboot)
+		r.With(allow(auth.ActionPowerSystem)).Post("/api/system/shutdown", systemHandler.Shutdown)
+		r.With(allow(auth.ActionSystemLogs)).Get("/api/system/logs", systemHandler.Logs)
 
 		// Updates
 		r.Get("/api/system/version", updateHandler.Version)
 		r.Get("/api/system/update/check", updateHandler.Check)
 		r.Get("/api/system/update/status", updateHandler.Status)
-		r.Post("/api/system/update", updateHandler.Perform)
-
-		// File Manager
-		r.Get("/api/files/browse", fileManagerHandler.Browse)
-		r.Get("/api/files/download", fileManagerHandler.Download)
-		r.Get("/api/files/stream", fileManagerHandler.StreamFile) // New: streaming endpoint for large files
-		r.Post("/api/files/upload", fileManagerHandler.Upload)
-		r.Delete("/api/files", fileManagerHandler.Delete)
-		r.Post("/api/files/mkdir", fileManagerHandler.MkDir)
-		r.Post("/api/files/create", fileManagerHandler.CreateFile)
-		r.Post("/api/files/rename", fileManagerHandler.Rename)
-		r.Get("/api/files/read", fileManagerHandler.ReadFile)
-		r.Post("/api/files/write", fileManagerHandler.WriteFile)
+		r.With(allow(auth.ActionUpdateSystem)).Post("/api/system/update", updateHandler.Perform)
+
+		// Configuration
+		r.Get("/api/system/config", configHandler.Get)
+		r.With(allow(auth.ActionChangeSettings)).Patch("/api/system/config", configHandler.Update)
+		r.With(allow(auth.ActionChangeSettings)).Post("/api/system/config/reload", configHandler.Reload)
+		r.With(allow(auth.ActionChangeSettings)).Get("/api/system/export-settings", configHandler.ExportSettings)
+		r.With(allow(auth.ActionChangeSettings)).Post("/api/system/import-settings", configHandler.ImportSettings)
+		r.With(allow(auth.ActionChangeSettings)).Post("/api/system/restart", configHandler.Restart)
+
+		// MQTT
+		r.Get("/api/system/mqtt/config", mqttHandler.GetConfig)
+		r.Get("/api/system/mqtt/status", mqttHandler.Status)
+		r.With(allow(auth.ActionChangeSettings)).Post("/api/system/mqtt/config", mqttHandler.UpdateConfig)
+		r.With(allow(auth.ActionChangeSettings)).Post("/api/system/mqtt/test", mqttHandler.Test)
+		r.With(allow(auth.ActionChangeSettings)).Post("/api/system/mqtt/publish", mqttHandler.Publish)
+
+		// File Manager (host filesystem access)
+		r.Group(func(r chi.Router) {
+			r.Use(allow(auth.ActionManageFiles))
+			r.Get("/api/files/browse", fileManagerHandler.Browse)
+			r.Get("/api/files/download", fileManagerHandler.Download)
+			r.Get("/api/files/stream", fileManagerHandler.StreamFile) // New: streaming endpoint for large files
+			r.Get("/api/files/thumbnail", fileManagerHandler.Thumbnail)
+			r.Get("/api/files/tail", fileManagerHandler.Tail) // WebSocket
+			r.Get("/api/files/watch", fileManagerHandler.Watch) // WebSocket
+			r.Post("/api/files/upload", fileManagerHandler.Upload)
+			r.Delete("/api/files", fileManagerHandler.Delete)
+			r.Post("/api/files/mkdir", fileManagerHandler.MkDir)
+			r.Post("/api/files/create", fileManagerHandler.CreateFile)
+			r.Post("/api/files/rename", fileManagerHandler.Rename)
+			r.Post("/api/files/batch", fileManagerHandler.Batch)
+			r.Get("/api/files/read", fileManagerHandler.ReadFile)
+			r.Post("/api/files/write", fileManagerHandler.WriteFile)
+			r.Get("/api/files/bookmarks", bookmarkHandler.List)
+			r.Post("/api/files/bookmarks", bookmarkHandler.Add)
+			r.Delete("/api/files/bookmarks", bookmarkHandler.Delete)
+		})
 
 		// Plugins Management
 		r.Get("/api/plugins", pluginHandler.List)
 		r.Get("/api/plugins/{name}", pluginHandler.Get)
 		r.Get("/api/plugins/{name}/html", pluginHandler.GetHTML)
-		r.Post("/api/plugins/{name}/toggle", pluginHandler.Toggle)
+		r.With(allow(auth.ActionSystemLogs)).Get("/api/plugins/{name}/logs", pluginHandler.Logs)
+		r.With(allow(auth.ActionManagePlugins)).Post("/api/plugins/{name}/toggle", pluginHandler.Toggle)
+		r.With(allow(auth.ActionManagePlugins)).Post("/api/plugins/{name}/mqtt/rediscover", pluginHandler.Rediscover)
+
+		// Profiling (heap, goroutines, CPU) for leak reports, off by default
+		if s.config.Pprof() {
+			r.With(allow(auth.ActionChangeSettings)).Mount("/debug", middleware.Profiler())
+		}
 	})
 
 	// Register plugin routes
 	s.registerPluginRoutes(r)
 
 	// Static files and SPA
-	r.Handle("/static/*", http.StripPrefix("/static/", http.FileServer(http.Dir("web/static"))))
+	staticFS, _ := fs.Sub(s.webFS, "static")
+	r.Handle("/static/*", http.StripPrefix("/static/", http.FileServer(http.FS(staticFS))))
 
 	// Serve index.html for all other routes (SPA)
 	r.Get("/*", s.serveIndex)
@@ -201,7 +334,7 @@ func (s *Server) pluginEnabledMiddleware(pluginName string, next http.HandlerFun
 				return
 			}
 		}
-		http.Error(w, "Plugin not enabled", http.StatusServiceUnavailable)
+		writeJSONError(w, http.StatusServiceUnavailable, "plugin_disabled", "Plugin not enabled")
 	}
 }
 
@@ -221,10 +354,14 @@ func (s *Server) registerPluginRoutes(r chi.Router) {
 			handler := s.pluginEnabledMiddleware(plugin.Name(), route.Handler)
 
 			if route.RequireAuth && !s.config.NoAuth() {
-				authHandler := handler
-				handler = func(w http.ResponseWriter, req *http.Request) {
-					s.authMw.RequireAuth(http.HandlerFunc(authHandler)).ServeHTTP(w, req)
+				// Mutating plugin routes go through the same policy as the
+				// built-in ones
+				var authHandler http.Handler = handler
+				if route.Method != "GET" && route.Method != "HEAD" {
+					authHandler = auth.RequireAction(auth.ActionManagePlugins)(authHandler)
 				}
+				authHandler = s.authMw.RequireAuth(authHandler)
+				handler = authHandler.ServeHTTP
 			}
 
 			switch route.Method {
@@ -246,10 +383,10 @@ func (s *Server) registerPluginRoutes(r chi.Router) {
 // serveIndex serves the main HTML page with version placeholders replaced
 func (s *Server) serveIndex(w http.ResponseWriter, r *http.Request) {
 	// Read the template file
-	content, err := os.ReadFile("web/templates/index.html")
+	content, err := fs.ReadFile(s.webFS, "templates/index.html")
 	if err != nil {
 		http.Error(w, "Failed to load page", http.StatusInternalServerError)
-		log.Printf("Error reading index.html: %v", err)
+		logger(r.Context()).Printf("Error reading index.html: %v", err)
 		return
 	}
 
@@ -276,6 +413,55 @@ func writeJSON(w http.ResponseWriter, status int, data interface{}) {
 	json.NewEncoder(w).Encode(data)
 }
 
+// ErrorResponse is the body of every API error response
+type ErrorResponse struct {
+	Error ErrorDetail `json:"error"`
+}
+
+// ErrorDetail describes an API error. Code is stable and machine-readable
+// (e.g. "image_not_found"), Message is for display.
+type ErrorDetail struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// writeJSONError writes an error response in the {"error": {code, message}} envelope
+func writeJSONError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, ErrorResponse{Error: ErrorDetail{Code: code, Message: message}})
+}
+
+// writePodmanError writes a Podman API error, as 404 with notFoundCode when
+// the object doesn't exist and as 500 "podman_error" otherwise
+func writePodmanError(w http.ResponseWriter, err error, notFoundCode string) {
+	if notFoundCode != "" && podman.IsNotFound(err) {
+		writeJSONError(w, http.StatusNotFound, notFoundCode, err.Error())
+		return
+	}
+	writeJSONError(w, http.StatusInternalServerError, "podman_error", err.Error())
+}
+
+// errorCode returns the generic error code for an HTTP status
+func errorCode(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "bad_request"
+	case http.StatusUnauthorized:
+		return "unauthorized"
+	case http.StatusForbidden:
+		return "forbidden"
+	case http.StatusNotFound:
+		return "not_found"
+	case http.StatusConflict:
+		return "conflict"
+	case http.StatusNotImplemented:
+		return "not_implemented"
+	case http.StatusServiceUnavailable:
+		return "unavailable"
+	default:
+		return "internal_error"
+	}
+}
+
 // fakeAuthMiddleware injects a fake admin user for no-auth mode
 func (s *Server) fakeAuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
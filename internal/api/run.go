@@ -0,0 +1,150 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"podmanview/internal/auth"
+	"podmanview/internal/events"
+	"podmanview/internal/podman"
+)
+
+// Limits on how long POST /api/containers/run waits for the container
+const (
+	defaultRunTimeout = 60 * time.Second
+	maxRunTimeout     = 10 * time.Minute
+)
+
+// RunContainerRequest is a one-shot container for POST /api/containers/run
+type RunContainerRequest struct {
+	Image    string   `json:"image"`
+	Command  []string `json:"command,omitempty"` // arguments as given, e.g. ["wget", "-qO-", "http://nas.lan"]
+	Env      string   `json:"env,omitempty"`     // "KEY=value, DEBUG=true" as in the create form
+	Volumes  string   `json:"volumes,omitempty"` // "/host:/container" as in the create form
+	AutoPull bool     `json:"auto_pull"`
+	Detach   bool     `json:"detach"`  // return once started; Podman removes the container when it exits
+	Timeout  int      `json:"timeout"` // seconds to wait for the container to exit, default 60, max 600
+}
+
+// RunContainerResponse is the outcome of a one-shot container
+type RunContainerResponse struct {
+	ID        string   `json:"id"`
+	Status    string   `json:"status"`             // "exited", "timeout" or with detach "started"
+	ExitCode  *int     `json:"exitCode,omitempty"` // unset when detached or timed out
+	Output    []string `json:"output"`             // stdout and stderr lines, oldest first
+	Truncated bool     `json:"truncated"`          // older output dropped at the log size limit
+}
+
+// Run handles POST /api/containers/run
+// It creates and starts a container, waits for it to exit and returns its
+// output and exit code, then removes it. A container still running at the
+// timeout is killed. With detach the response comes right after the start
+// and the container is created with auto-remove instead. With ?stream=true
+// the output of an auto-pull is streamed ahead of the result, as in Create.
+func (h *ContainerHandler) Run(w http.ResponseWriter, r *http.Request) {
+	user := auth.GetUserFromContext(r.Context())
+
+	var req RunContainerRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.Image == "" {
+		writeJSONError(w, http.StatusBadRequest, "missing_field", "Image is required")
+		return
+	}
+	timeout := defaultRunTimeout
+	if req.Timeout != 0 {
+		timeout = time.Duration(req.Timeout) * time.Second
+		if timeout < time.Second || timeout > maxRunTimeout {
+			writeJSONError(w, http.StatusBadRequest, "invalid_timeout",
+				fmt.Sprintf("Timeout must be between 1 and %d seconds", int(maxRunTimeout.Seconds())))
+			return
+		}
+	}
+
+	config := &podman.ContainerCreateConfig{
+		Image:   req.Image,
+		Command: slices.DeleteFunc(slices.Clone(req.Command), func(arg string) bool { return arg == "" }),
+		Remove:  req.Detach,
+	}
+	if req.Env != "" {
+		config.Env = parseEnvVars(req.Env)
+	}
+	if req.Volumes != "" {
+		config.Mounts = parseVolumeMounts(req.Volumes)
+	}
+
+	sw := newStreamWriter(w, r)
+	if !h.ensureImage(sw, r, config.Image, req.AutoPull) {
+		return
+	}
+
+	meta := events.Meta{"image": req.Image}
+	result, err := h.client.CreateContainer(r.Context(), config)
+	if err != nil {
+		h.eventStore.AddWithMeta(events.EventContainerRun, user.Username, getClientIP(r), false, req.Image, meta)
+		sw.podmanError(err, "")
+		return
+	}
+	meta["container"] = result.ID
+
+	// Clean up even if the client goes away while waiting
+	cleanupCtx := context.WithoutCancel(r.Context())
+	if !req.Detach {
+		defer func() {
+			if err := h.client.RemoveContainer(cleanupCtx, result.ID, true); err != nil && !podman.IsNotFound(err) {
+				logger(r.Context()).Printf("Removing one-shot container %s failed: %v", shortID(result.ID), err)
+			}
+		}()
+	}
+
+	if err := h.client.StartContainer(r.Context(), result.ID); err != nil {
+		if req.Detach {
+			h.client.RemoveContainer(cleanupCtx, result.ID, true)
+		}
+		h.eventStore.AddWithMeta(events.EventContainerRun, user.Username, getClientIP(r), false, req.Image, meta)
+		sw.podmanError(err, "")
+		return
+	}
+
+	if req.Detach {
+		h.eventStore.AddWithMeta(events.EventContainerRun, user.Username, getClientIP(r), true, req.Image, meta)
+		sw.result(http.StatusAccepted, RunContainerResponse{ID: result.ID, Status: "started", Output: []string{}})
+		return
+	}
+
+	waitCtx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+	resp := RunContainerResponse{ID: result.ID, Status: "exited", Output: []string{}}
+	exitCode, err := h.client.WaitContainer(waitCtx, result.ID)
+	switch {
+	case err == nil:
+		resp.ExitCode = &exitCode
+		meta["exit_code"] = fmt.Sprint(exitCode)
+	case errors.Is(waitCtx.Err(), context.DeadlineExceeded):
+		resp.Status = "timeout"
+		meta["exit_code"] = "timeout"
+	default:
+		h.eventStore.AddWithMeta(events.EventContainerRun, user.Username, getClientIP(r), false, req.Image, meta)
+		sw.podmanError(err, "")
+		return
+	}
+
+	logs, err := h.client.GetContainerLogsLimited(cleanupCtx, result.ID, -1, h.config.LogMaxBytes(), false)
+	if err != nil {
+		logger(r.Context()).Printf("Reading output of one-shot container %s failed: %v", shortID(result.ID), err)
+	} else if logs.Text != "" {
+		// Logs come newest first
+		resp.Output = strings.Split(strings.TrimSuffix(logs.Text, "\n"), "\n")
+		slices.Reverse(resp.Output)
+		resp.Truncated = logs.Truncated
+	}
+
+	h.eventStore.AddWithMeta(events.EventContainerRun, user.Username, getClientIP(r), resp.ExitCode != nil && *resp.ExitCode == 0, req.Image, meta)
+	sw.result(http.StatusOK, resp)
+}
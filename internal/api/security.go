@@ -0,0 +1,102 @@
+package api
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"podmanview/internal/config"
+)
+
+// hstsHeader asks browsers to use HTTPS only for one year
+const hstsHeader = "max-age=31536000"
+
+// defaultCSP is the built-in policy for the SPA. Inline scripts are
+// allowed because the UI uses inline handlers and plugin pages ship
+// inline <script> blocks; everything is restricted to this origin.
+const defaultCSP = "default-src 'self'; " +
+	"script-src 'self' 'unsafe-inline'; " +
+	"style-src 'self' 'unsafe-inline'; " +
+	"img-src 'self' data: blob:; " +
+	"media-src 'self' blob:; " +
+	"font-src 'self' data:; " +
+	"connect-src 'self' ws: wss:; " +
+	"object-src 'self' blob: data:; " +
+	"frame-src 'self' blob: data:; " +
+	"base-uri 'self'; " +
+	"form-action 'self'"
+
+// securityHeaders sets nosniff, referrer, framing and CSP headers.
+// The policy is read from cfg on each request so a config reload applies.
+func securityHeaders(cfg *config.Config) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			csp, frameOptions := securityPolicy(cfg.CSP(), cfg.FrameAncestors())
+
+			h := w.Header()
+			h.Set("X-Content-Type-Options", "nosniff")
+			h.Set("Referrer-Policy", "same-origin")
+			h.Set("Content-Security-Policy", csp)
+			if frameOptions != "" {
+				h.Set("X-Frame-Options", frameOptions)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// securityPolicy returns the CSP and X-Frame-Options values.
+// csp overrides the built-in policy; frameAncestors is appended unless
+// the policy already sets frame-ancestors.
+func securityPolicy(csp, frameAncestors string) (string, string) {
+	if csp == "" {
+		csp = defaultCSP
+	}
+	if frameAncestors != "" && !strings.Contains(csp, "frame-ancestors") {
+		csp = strings.TrimRight(strings.TrimSpace(csp), ";") + "; frame-ancestors " + frameAncestors
+	}
+
+	// X-Frame-Options for older browsers; it can't express origin lists
+	var frameOptions string
+	switch strings.TrimSpace(frameAncestors) {
+	case "'self'":
+		frameOptions = "SAMEORIGIN"
+	case "'none'":
+		frameOptions = "DENY"
+	}
+
+	return csp, frameOptions
+}
+
+// strictTransport sets HSTS on responses served over TLS.
+// Not used with self-signed certificates: under HSTS browsers
+// no longer let users click through certificate warnings.
+func strictTransport(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil {
+			w.Header().Set("Strict-Transport-Security", hstsHeader)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// NewHTTPSRedirect returns a handler that 301-redirects plain HTTP
+// requests to the same host and path on httpsPort
+func NewHTTPSRedirect(httpsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := strings.Trim(r.Host, "[]")
+		if h, _, err := net.SplitHostPort(r.Host); err == nil {
+			host = h
+		}
+		if host == "" {
+			writeJSONError(w, http.StatusBadRequest, "host_required", "Host header required")
+			return
+		}
+
+		target := net.JoinHostPort(host, httpsPort)
+		if httpsPort == "443" {
+			target = strings.TrimSuffix(target, ":443")
+		}
+		http.Redirect(w, r, "https://"+target+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+}
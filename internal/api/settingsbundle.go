@@ -0,0 +1,346 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"podmanview/internal/auth"
+	"podmanview/internal/config"
+	"podmanview/internal/events"
+	"podmanview/internal/plugins"
+	"podmanview/internal/storage"
+)
+
+// settingsBundleVersion is the schema version of settings bundles
+const settingsBundleVersion = 1
+
+// bundleSecretKeys are exported only with ?secrets=true
+var bundleSecretKeys = []string{
+	config.EnvMQTTPassword,
+	config.EnvLDAPBindPassword,
+	config.EnvOIDCClientSecret,
+}
+
+// bundleInstanceKeys belong to one instance and are neither exported
+// nor imported: sessions stay signed with this instance's secret
+var bundleInstanceKeys = map[string]bool{
+	config.EnvJWTSecret:     true,
+	config.EnvJWTPrevSecret: true,
+	config.EnvJWTPrevUntil:  true,
+}
+
+// SettingsBundle is the application configuration as one JSON document:
+// .env settings, plugin configs and settings, container templates and file
+// manager bookmarks. Plugin data, command history and events are not
+// included.
+type SettingsBundle struct {
+	Version        int                              `json:"version"`
+	AppVersion     string                           `json:"app_version,omitempty"`
+	ExportedAt     time.Time                        `json:"exported_at"`
+	Settings       map[string]string                `json:"settings"`
+	Redacted       []string                         `json:"redacted,omitempty"` // secret settings left out of Settings
+	Plugins        map[string]*storage.PluginConfig `json:"plugins"`
+	PluginSettings map[string]map[string]string     `json:"plugin_settings,omitempty"` // plugin -> storage key -> value
+	Templates      []ContainerTemplate              `json:"templates"`
+	Bookmarks      map[string][]Bookmark            `json:"bookmarks"` // by username
+}
+
+// ImportResult describes what a settings import changed
+type ImportResult struct {
+	Changed         []string `json:"changed"`         // settings whose values changed
+	RestartRequired []string `json:"restartRequired"` // changed settings that apply after a restart
+	Plugins         int      `json:"plugins"`         // plugin configs written, enabled state applies after a restart
+	PluginSettings  int      `json:"pluginSettings"`  // plugins whose settings were written, they apply after a restart
+	Templates       int      `json:"templates"`
+	Bookmarks       int      `json:"bookmarks"` // users whose bookmarks were replaced
+	Warning         string   `json:"warning,omitempty"`
+}
+
+// ExportSettings handles GET /api/system/export-settings
+// Secrets (MQTT and LDAP passwords, OIDC client secret) are left out
+// unless ?secrets=true; the JWT secret is never exported.
+func (h *ConfigHandler) ExportSettings(w http.ResponseWriter, r *http.Request) {
+	user := auth.GetUserFromContext(r.Context())
+	s := h.server
+
+	bundle := SettingsBundle{
+		Version:    settingsBundleVersion,
+		AppVersion: s.version,
+		ExportedAt: time.Now(),
+		Settings:   s.config.Values(),
+		Plugins:    map[string]*storage.PluginConfig{},
+		Templates:  []ContainerTemplate{},
+		Bookmarks:  map[string][]Bookmark{},
+	}
+	for key := range bundleInstanceKeys {
+		delete(bundle.Settings, key)
+	}
+	if r.URL.Query().Get("secrets") != "true" {
+		for _, key := range bundleSecretKeys {
+			if bundle.Settings[key] != "" {
+				bundle.Redacted = append(bundle.Redacted, key)
+			}
+			delete(bundle.Settings, key)
+		}
+	}
+
+	if s.storage != nil {
+		plugins, err := s.storage.ListAllPlugins()
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, "internal_error", err.Error())
+			return
+		}
+		bundle.Plugins = plugins
+
+		if bundle.PluginSettings, err = s.exportPluginSettings(); err != nil {
+			writeJSONError(w, http.StatusInternalServerError, "internal_error", err.Error())
+			return
+		}
+
+		if bundle.Templates, err = NewTemplateHandler(s.storage).load(); err != nil {
+			writeJSONError(w, http.StatusInternalServerError, "internal_error", err.Error())
+			return
+		}
+
+		values, err := s.storage.List(bookmarksNamespace)
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, "internal_error", err.Error())
+			return
+		}
+		for username, value := range values {
+			var bookmarks []Bookmark
+			if err := json.Unmarshal(value, &bookmarks); err == nil {
+				bundle.Bookmarks[username] = bookmarks
+			}
+		}
+	}
+
+	s.eventStore.AddWithMeta(events.EventSettingsUpdate, user.Username, getClientIP(r), true, "settings exported", events.Meta{"section": "export"})
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="podmanview-settings-%s.json"`, time.Now().Format("20060102-150405")))
+	writeJSON(w, http.StatusOK, bundle)
+}
+
+// ImportSettings handles POST /api/system/import-settings
+// The bundle is validated as a whole before anything is written. Settings
+// are merged into the current ones (redacted secrets keep their values);
+// plugin configs and settings, templates and the bookmarks of the users in
+// the bundle are replaced.
+func (h *ConfigHandler) ImportSettings(w http.ResponseWriter, r *http.Request) {
+	user := auth.GetUserFromContext(r.Context())
+	s := h.server
+
+	var bundle SettingsBundle
+	if !decodeJSON(w, r, &bundle) {
+		return
+	}
+	if problems := h.validateBundle(&bundle); len(problems) > 0 {
+		writeJSONError(w, http.StatusBadRequest, "invalid_bundle", "Invalid settings bundle: "+strings.Join(problems, "; "))
+		return
+	}
+	hasData := len(bundle.Plugins) > 0 || len(bundle.PluginSettings) > 0 || len(bundle.Templates) > 0 || len(bundle.Bookmarks) > 0
+	if hasData && s.storage == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, "storage_unavailable", "Storage not available, cannot import plugins, templates or bookmarks")
+		return
+	}
+
+	changed, err := s.config.Import(bundle.Settings)
+	if err != nil {
+		s.eventStore.AddWithMeta(events.EventSettingsUpdate, user.Username, getClientIP(r), false, err.Error(), events.Meta{"section": "import"})
+		writeJSONError(w, http.StatusBadRequest, "validation_failed", err.Error())
+		return
+	}
+
+	result := ImportResult{Changed: []string{}, RestartRequired: []string{}}
+	for _, key := range changed {
+		result.Changed = append(result.Changed, key)
+		if restartKeys[key] {
+			result.RestartRequired = append(result.RestartRequired, key)
+		}
+	}
+	if err := s.applyConfig(changed); err != nil {
+		result.Warning = "Settings imported but MQTT failed to apply: " + err.Error()
+	}
+
+	if hasData {
+		if err := h.importData(&bundle, &result); err != nil {
+			s.eventStore.AddWithMeta(events.EventSettingsUpdate, user.Username, getClientIP(r), false, err.Error(), events.Meta{"section": "import"})
+			writeJSONError(w, http.StatusInternalServerError, "storage_error", err.Error())
+			return
+		}
+	}
+
+	detail := fmt.Sprintf("%d settings, %d plugins, %d plugins' settings, %d templates, %d users' bookmarks",
+		len(result.Changed), result.Plugins, result.PluginSettings, result.Templates, result.Bookmarks)
+	s.eventStore.AddWithMeta(events.EventSettingsUpdate, user.Username, getClientIP(r), true, "settings imported: "+detail, events.Meta{"section": "import"})
+	writeJSON(w, http.StatusOK, result)
+}
+
+// validateBundle checks the bundle's schema and returns its problems
+func (h *ConfigHandler) validateBundle(bundle *SettingsBundle) []string {
+	var problems []string
+	if bundle.Version != settingsBundleVersion {
+		problems = append(problems, fmt.Sprintf("unsupported version %d (expected %d)", bundle.Version, settingsBundleVersion))
+	}
+
+	known := h.server.config.Values()
+	keys := make([]string, 0, len(bundle.Settings))
+	for key := range bundle.Settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if _, ok := known[key]; !ok {
+			problems = append(problems, "unknown setting "+key)
+		} else if bundleInstanceKeys[key] {
+			problems = append(problems, key+" cannot be imported")
+		}
+	}
+
+	for name, plugin := range bundle.Plugins {
+		if plugin == nil {
+			problems = append(problems, fmt.Sprintf("plugin %q has no config", name))
+		} else if h.server.pluginRegistry != nil {
+			if _, ok := h.server.pluginRegistry.Get(name); !ok {
+				problems = append(problems, fmt.Sprintf("unknown plugin %q", name))
+			}
+		}
+	}
+
+	for name, values := range bundle.PluginSettings {
+		exporter, ok := h.server.settingsExporter(name)
+		if !ok {
+			problems = append(problems, fmt.Sprintf("plugin %q has no settings", name))
+			continue
+		}
+		keys := exporter.SettingsKeys()
+		for key := range values {
+			if !slices.Contains(keys, key) {
+				problems = append(problems, fmt.Sprintf("unknown setting %q of plugin %q", key, name))
+			}
+		}
+	}
+
+	names := make(map[string]bool)
+	for i, t := range bundle.Templates {
+		switch {
+		case strings.TrimSpace(t.Name) == "" || strings.Contains(t.Name, "/") || len(t.Name) > maxTemplateNameLength:
+			problems = append(problems, fmt.Sprintf("template %d has an invalid name", i))
+		case t.Config.Image == "":
+			problems = append(problems, fmt.Sprintf("template %q has no image", t.Name))
+		case names[t.Name]:
+			problems = append(problems, fmt.Sprintf("template %q appears twice", t.Name))
+		}
+		names[t.Name] = true
+	}
+	if len(bundle.Templates) > maxTemplates {
+		problems = append(problems, fmt.Sprintf("at most %d templates are allowed", maxTemplates))
+	}
+
+	for username, bookmarks := range bundle.Bookmarks {
+		if username == "" {
+			problems = append(problems, "bookmarks without a username")
+			continue
+		}
+		if len(bookmarks) > maxBookmarks {
+			problems = append(problems, fmt.Sprintf("%s has more than %d bookmarks", username, maxBookmarks))
+		}
+		for _, b := range bookmarks {
+			if b.Path == "" || b.Name == "" {
+				problems = append(problems, fmt.Sprintf("%s has a bookmark without path or name", username))
+				break
+			}
+		}
+	}
+	return problems
+}
+
+// importData writes the bundle's plugin configs, templates and bookmarks
+func (h *ConfigHandler) importData(bundle *SettingsBundle, result *ImportResult) error {
+	store := h.server.storage
+	for name, plugin := range bundle.Plugins {
+		if err := store.SetPluginConfig(name, plugin); err != nil {
+			return fmt.Errorf("failed to import plugin %s: %w", name, err)
+		}
+		result.Plugins++
+	}
+
+	for name, values := range bundle.PluginSettings {
+		for key, value := range values {
+			if err := store.Set(name, key, []byte(value)); err != nil {
+				return fmt.Errorf("failed to import settings of plugin %s: %w", name, err)
+			}
+		}
+		result.PluginSettings++
+	}
+
+	if len(bundle.Templates) > 0 {
+		existing, err := store.List(templatesNamespace)
+		if err != nil {
+			return fmt.Errorf("failed to replace templates: %w", err)
+		}
+		for name := range existing {
+			if err := store.Delete(templatesNamespace, name); err != nil {
+				return fmt.Errorf("failed to replace templates: %w", err)
+			}
+		}
+		for _, t := range bundle.Templates {
+			if err := store.SetJSON(templatesNamespace, t.Name, t); err != nil {
+				return fmt.Errorf("failed to import template %s: %w", t.Name, err)
+			}
+			result.Templates++
+		}
+	}
+
+	for username, bookmarks := range bundle.Bookmarks {
+		if err := store.SetJSON(bookmarksNamespace, username, bookmarks); err != nil {
+			return fmt.Errorf("failed to import bookmarks of %s: %w", username, err)
+		}
+		result.Bookmarks++
+	}
+	return nil
+}
+
+// settingsExporter returns the plugin named name if it exports settings
+func (s *Server) settingsExporter(name string) (plugins.SettingsExporter, bool) {
+	for _, p := range s.plugins {
+		if p.Name() == name {
+			exporter, ok := p.(plugins.SettingsExporter)
+			return exporter, ok
+		}
+	}
+	return nil, false
+}
+
+// exportPluginSettings returns the stored settings of plugins that export
+// them; keys that were never set are left out
+func (s *Server) exportPluginSettings() (map[string]map[string]string, error) {
+	result := map[string]map[string]string{}
+	for _, p := range s.plugins {
+		exporter, ok := p.(plugins.SettingsExporter)
+		if !ok {
+			continue
+		}
+		values := map[string]string{}
+		for _, key := range exporter.SettingsKeys() {
+			value, err := s.storage.Get(p.Name(), key)
+			if errors.Is(err, storage.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to read settings of plugin %s: %w", p.Name(), err)
+			}
+			values[key] = string(value)
+		}
+		if len(values) > 0 {
+			result[p.Name()] = values
+		}
+	}
+	return result, nil
+}
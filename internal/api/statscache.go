@@ -0,0 +1,196 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"podmanview/internal/podman"
+)
+
+// Container stats cache: Podman streams the stats of all running
+// containers every statsCacheInterval. Snapshots older than
+// statsCacheMaxAge (the stream is down) are not served.
+const (
+	statsCacheInterval = 5 * time.Second
+	statsCacheMaxAge   = 3 * statsCacheInterval
+)
+
+// StatsCache keeps the latest stats of all running containers, fed by one
+// Podman stats stream, so handlers need not query Podman on every request
+type StatsCache struct {
+	client  *podman.Client
+	restart chan struct{} // reopens the stream to pick up started containers
+
+	mu      sync.RWMutex
+	stats   map[string]podman.ContainerStats // by container ID
+	updated time.Time
+}
+
+// NewStatsCache creates an empty stats cache, filled once Run is started
+func NewStatsCache(client *podman.Client) *StatsCache {
+	return &StatsCache{
+		client:  client,
+		restart: make(chan struct{}, 1),
+		stats:   make(map[string]podman.ContainerStats),
+	}
+}
+
+// set replaces the snapshot with a report from the stream
+func (c *StatsCache) set(now time.Time, stats []podman.ContainerStats) {
+	snapshot := make(map[string]podman.ContainerStats, len(stats))
+	for _, s := range stats {
+		snapshot[s.ContainerID] = s
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stats = snapshot
+	c.updated = now
+}
+
+// remove drops a stopped or removed container from the snapshot
+func (c *StatsCache) remove(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.stats, id)
+}
+
+// Stats returns the stats of running containers sorted by name and the
+// time they were taken. Without a recent snapshot Podman is asked directly.
+func (c *StatsCache) Stats(ctx context.Context) ([]podman.ContainerStats, time.Time, error) {
+	c.mu.RLock()
+	fresh := time.Since(c.updated) <= statsCacheMaxAge
+	stats := make([]podman.ContainerStats, 0, len(c.stats))
+	for _, s := range c.stats {
+		stats = append(stats, s)
+	}
+	updated := c.updated
+	c.mu.RUnlock()
+
+	if !fresh {
+		var err error
+		updated = time.Now()
+		if stats, err = c.client.GetContainersStats(ctx); err != nil {
+			return nil, time.Time{}, err
+		}
+		if stats == nil {
+			stats = []podman.ContainerStats{}
+		}
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Name < stats[j].Name })
+	return stats, updated, nil
+}
+
+// Run keeps the snapshot current until ctx is done. The stream is reopened
+// when a container starts and after failures; containers that stop are
+// dropped as soon as Podman reports it.
+func (c *StatsCache) Run(ctx context.Context) {
+	go c.watchEvents(ctx)
+
+	failing := false
+	for {
+		streamCtx, cancel := context.WithCancel(ctx)
+		done := make(chan error, 1)
+		go func() {
+			done <- c.client.StreamContainersStats(streamCtx, statsCacheInterval, func(stats []podman.ContainerStats) {
+				c.set(time.Now(), stats)
+			})
+		}()
+
+		var err error
+		restarted := false
+		select {
+		case err = <-done:
+		case <-c.restart:
+			restarted = true
+			cancel()
+			<-done
+		}
+		cancel()
+
+		switch {
+		case ctx.Err() != nil:
+			return
+		case restarted:
+			continue
+		case err != nil:
+			// Report a failure once, not on every reconnect
+			if !failing {
+				logger(ctx).Printf("Container stats stream failed: %v", err)
+			}
+			failing = true
+		default:
+			// Podman ends the stream when no container runs
+			failing = false
+			c.set(time.Now(), nil)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.restart:
+		case <-time.After(statsCacheInterval):
+		}
+	}
+}
+
+// watchEvents follows container events to keep the watched set current
+func (c *StatsCache) watchEvents(ctx context.Context) {
+	failing := false
+	for {
+		err := c.client.StreamContainerEvents(ctx, func(event podman.ContainerEvent) {
+			failing = false
+			switch event.Action {
+			case "start", "restart", "unpause":
+				select {
+				case c.restart <- struct{}{}:
+				default:
+				}
+			case "died", "stop", "pause", "remove":
+				c.remove(event.Actor.ID)
+			}
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil && !failing {
+			logger(ctx).Printf("Container events stream failed: %v", err)
+			failing = true
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(statsCacheInterval):
+		}
+	}
+}
+
+// StartStatsCache streams container stats into the cache until ctx is done
+func (s *Server) StartStatsCache(ctx context.Context) {
+	if s.podmanClient == nil {
+		return
+	}
+	go s.statsCache.Run(ctx)
+}
+
+// ContainersStatsResponse is the latest stats of all running containers
+type ContainersStatsResponse struct {
+	Stats   []podman.ContainerStats `json:"stats"` // sorted by name
+	Updated time.Time               `json:"updated"`
+}
+
+// Stats handles GET /api/containers/stats
+// Served from the cache, which Podman updates every 5 seconds.
+func (h *ContainerHandler) Stats(w http.ResponseWriter, r *http.Request) {
+	stats, updated, err := h.statsCache.Stats(r.Context())
+	if err != nil {
+		writePodmanError(w, err, "")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ContainersStatsResponse{Stats: stats, Updated: updated})
+}
@@ -0,0 +1,216 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"podmanview/internal/podman"
+)
+
+// Container stats history: sampled every statsHistoryInterval, keeping the
+// last statsHistoryPoints samples (one hour) of at most
+// statsHistoryContainers containers
+const (
+	statsHistoryInterval   = 10 * time.Second
+	statsHistoryPoints     = 360
+	statsHistoryContainers = 200
+	statsHistoryDefault    = 120 // points returned when ?points= is not set
+)
+
+// StatsPoint is one container stats sample
+type StatsPoint struct {
+	Time     int64   `json:"t"` // unix seconds
+	CPU      float64 `json:"cpu"`
+	MemUsage uint64  `json:"mem"`
+	MemPerc  float64 `json:"memPerc"`
+}
+
+// statsSeries is a ring buffer of samples
+type statsSeries struct {
+	points []StatsPoint
+	next   int // index the next sample is written to once full
+	last   time.Time
+}
+
+// StatsHistory keeps recent stats samples per container in memory. Both
+// the samples per container and the number of containers are bounded;
+// the container sampled longest ago makes room for a new one.
+type StatsHistory struct {
+	mu            sync.Mutex
+	series        map[string]*statsSeries
+	points        int
+	maxContainers int
+}
+
+// NewStatsHistory creates a history of up to points samples for each of
+// up to maxContainers containers
+func NewStatsHistory(points, maxContainers int) *StatsHistory {
+	return &StatsHistory{
+		series:        make(map[string]*statsSeries),
+		points:        points,
+		maxContainers: maxContainers,
+	}
+}
+
+// Add records a sample of each container at now
+func (h *StatsHistory) Add(now time.Time, stats []podman.ContainerStats) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, st := range stats {
+		s, ok := h.series[st.ContainerID]
+		if !ok {
+			if len(h.series) >= h.maxContainers {
+				h.evictOldest()
+			}
+			s = &statsSeries{points: make([]StatsPoint, 0, h.points)}
+			h.series[st.ContainerID] = s
+		}
+
+		p := StatsPoint{Time: now.Unix(), CPU: st.CPU, MemUsage: st.MemUsage, MemPerc: st.MemPerc}
+		if len(s.points) < h.points {
+			s.points = append(s.points, p)
+		} else {
+			s.points[s.next] = p
+			s.next = (s.next + 1) % h.points
+		}
+		s.last = now
+	}
+}
+
+// evictOldest removes the container sampled longest ago
+func (h *StatsHistory) evictOldest() {
+	var oldest string
+	for id, s := range h.series {
+		if oldest == "" || s.last.Before(h.series[oldest].last) {
+			oldest = id
+		}
+	}
+	delete(h.series, oldest)
+}
+
+// Get returns up to n of a container's newest samples, oldest first
+func (h *StatsHistory) Get(id string, n int) []StatsPoint {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.series[id]
+	if !ok {
+		return []StatsPoint{}
+	}
+	ordered := make([]StatsPoint, 0, len(s.points))
+	ordered = append(ordered, s.points[s.next:]...)
+	ordered = append(ordered, s.points[:s.next]...)
+	if n > 0 && len(ordered) > n {
+		ordered = ordered[len(ordered)-n:]
+	}
+	return ordered
+}
+
+// Remove drops a container's history
+func (h *StatsHistory) Remove(id string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.series, id)
+}
+
+// Prune drops the history of containers not sampled since before
+func (h *StatsHistory) Prune(before time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for id, s := range h.series {
+		if s.last.Before(before) {
+			delete(h.series, id)
+		}
+	}
+}
+
+// Containers returns the IDs of containers with history, sorted
+func (h *StatsHistory) Containers() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ids := make([]string, 0, len(h.series))
+	for id := range h.series {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// StartStatsHistory samples the stats of running containers into the
+// history until ctx is done: once right away, then every 10 seconds.
+// Containers without a sample for the whole window (stopped or removed)
+// are dropped.
+func (s *Server) StartStatsHistory(ctx context.Context) {
+	if s.podmanClient == nil {
+		return
+	}
+	sample := func() {
+		now := time.Now()
+		stats, _, err := s.statsCache.Stats(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				logger(ctx).Printf("Failed to sample container stats: %v", err)
+			}
+			return
+		}
+		s.statsHistory.Add(now, stats)
+		s.statsHistory.Prune(now.Add(-statsHistoryPoints * statsHistoryInterval))
+	}
+
+	go func() {
+		sample()
+		ticker := time.NewTicker(statsHistoryInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				sample()
+			}
+		}
+	}()
+}
+
+// StatsHistoryResponse is a container's recent resource usage
+type StatsHistoryResponse struct {
+	ID       string       `json:"id"`
+	Interval int          `json:"interval"` // seconds between samples
+	Points   []StatsPoint `json:"points"`   // oldest first
+}
+
+// StatsHistory handles GET /api/containers/{id}/stats/history?points=120
+// Returns up to ?points= (default 120, max 360) samples taken every 10
+// seconds while the container was running.
+func (h *ContainerHandler) StatsHistory(w http.ResponseWriter, r *http.Request) {
+	points := statsHistoryDefault
+	if v := r.URL.Query().Get("points"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > statsHistoryPoints {
+			writeJSONError(w, http.StatusBadRequest, "invalid_parameter",
+				"points must be between 1 and "+strconv.Itoa(statsHistoryPoints))
+			return
+		}
+		points = n
+	}
+
+	// Accept names and short IDs; history is kept by full ID
+	info, err := h.client.InspectContainer(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writePodmanError(w, err, "container_not_found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, StatsHistoryResponse{
+		ID:       info.ID,
+		Interval: int(statsHistoryInterval.Seconds()),
+		Points:   h.statsHistory.Get(info.ID, points),
+	})
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/websocket"
+
+	"podmanview/internal/auth"
+)
+
+// errInvalidStreamToken is returned when a stream connection has no valid ws_token
+var errInvalidStreamToken = errors.New("invalid or expired ws_token")
+
+// StreamTokenMessage is sent as the first message on every stream connection.
+// It carries a fresh renewable token the client should use for its next reconnect.
+type StreamTokenMessage struct {
+	Type  string `json:"type"` // always "ws_token"
+	Token string `json:"token"`
+}
+
+// upgradeStream validates a renewable ws_token, upgrades the connection to
+// WebSocket and sends the client a fresh token for reconnecting.
+// Used by long-lived read-only streams (logs, stats, events), not terminals.
+func upgradeStream(w http.ResponseWriter, r *http.Request, store *auth.WSTokenStore) (*websocket.Conn, error) {
+	username, nextToken, ok := store.ValidateAndRenew(r.URL.Query().Get("ws_token"))
+	if !ok {
+		writeJSONError(w, http.StatusForbidden, "invalid_ws_token", "Invalid or expired ws_token")
+		return nil, errInvalidStreamToken
+	}
+
+	upgrader := websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		// Token was validated above (CSWSH protection)
+		CheckOrigin: func(r *http.Request) bool { return true },
+	}
+
+	ws, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		logger(r.Context()).Printf("Stream WebSocket upgrade failed: %v", err)
+		return nil, err
+	}
+
+	if err := ws.WriteJSON(StreamTokenMessage{Type: "ws_token", Token: nextToken}); err != nil {
+		ws.Close()
+		return nil, err
+	}
+
+	logger(r.Context()).Printf("Stream connection authorized for user: %s", username)
+	return ws, nil
+}
@@ -1,12 +1,16 @@
 package api
 
 import (
+	"context"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
 	"syscall"
 	"time"
+
+	"podmanview/internal/config"
 )
 
 // HostStats represents CPU, memory, temperature, uptime and disk info
@@ -14,12 +18,51 @@ type HostStats struct {
 	CPUUsage     float64       `json:"cpuUsage"`
 	MemTotal     uint64        `json:"memTotal"`               // bytes
 	MemFree      uint64        `json:"memFree"`                // bytes (MemAvailable from /proc/meminfo)
+	SwapTotal    uint64        `json:"swapTotal"`              // bytes (0 without swap)
+	SwapFree     uint64        `json:"swapFree"`               // bytes
 	Temperatures []Temperature `json:"temperatures"`           // CPU/SoC temperatures
 	StorageTemps []StorageTemp `json:"storageTemps,omitempty"` // NVMe/Storage temperatures grouped by device
+	TempUnit     string        `json:"tempUnit,omitempty"`     // unit to show temperatures in, C or F (they are always sent in °C)
 	Uptime       int64         `json:"uptime"`                 // seconds
 	DiskTotal    uint64        `json:"diskTotal"`              // bytes (deprecated, kept for compatibility)
 	DiskFree     uint64        `json:"diskFree"`               // bytes (deprecated, kept for compatibility)
 	Disks        []DiskInfo    `json:"disks,omitempty"`        // All disks info
+	Unavailable  []string      `json:"unavailable,omitempty"`  // "cpu", "memory", "uptime" or "disks" that could not be read
+}
+
+// defaultHostProc is where a containerized PodmanView expects the host's /proc
+const defaultHostProc = "/host/proc"
+
+// hostProcPath returns the /proc to read host stats from: the configured
+// path, the host's /proc mounted at /host/proc, or the local /proc
+func hostProcPath(configured string) string {
+	if configured != "" {
+		return configured
+	}
+	if _, err := os.Stat(filepath.Join(defaultHostProc, "stat")); err == nil {
+		return defaultHostProc
+	}
+	return "/proc"
+}
+
+// hostRoot returns the path at which the host's root filesystem is reachable.
+// Through a mounted host /proc that is the root of the host's init process,
+// readable only with --pid=host or enough privileges.
+func hostRoot(procPath string) string {
+	if procPath == "/proc" {
+		return "/"
+	}
+	return filepath.Join(procPath, "1", "root")
+}
+
+// inContainer reports whether PodmanView itself runs in a container
+func inContainer() bool {
+	for _, path := range []string{"/run/.containerenv", "/.dockerenv"} {
+		if _, err := os.Stat(path); err == nil {
+			return true
+		}
+	}
+	return false
 }
 
 // DiskInfo represents disk usage information
@@ -43,9 +86,10 @@ type Temperature struct {
 	Temp  float64 `json:"temp"`
 }
 
-// GetHostStats reads CPU usage, memory, uptime and disk info from /sys and /proc
+// GetHostStats reads CPU usage, memory, uptime and disk info from procPath
+// (see hostProcPath). Values that can't be read are zero and listed in Unavailable.
 // Note: Temperature monitoring has been moved to the temperature plugin
-func GetHostStats() *HostStats {
+func GetHostStats(procPath string) *HostStats {
 	stats := &HostStats{
 		Temperatures: []Temperature{},
 		StorageTemps: []StorageTemp{},
@@ -53,36 +97,56 @@ func GetHostStats() *HostStats {
 	}
 
 	// Get CPU usage
-	stats.CPUUsage = getCPUUsage()
+	var ok bool
+	if stats.CPUUsage, ok = getCPUUsage(procPath); !ok {
+		stats.Unavailable = append(stats.Unavailable, "cpu")
+	}
 
 	// Get memory info
-	stats.MemTotal, stats.MemFree = getMemoryInfo()
+	mem := getMemoryInfo(procPath)
+	stats.MemTotal, stats.MemFree = mem.total, mem.available
+	stats.SwapTotal, stats.SwapFree = mem.swapTotal, mem.swapFree
+	if stats.MemTotal == 0 {
+		stats.Unavailable = append(stats.Unavailable, "memory")
+	}
 
 	// Note: Temperature monitoring has been moved to the temperature plugin
 	// Temperatures and StorageTemps will be populated by the plugin if enabled
 	// If the plugin is disabled, these fields will remain empty arrays
 
 	// Get uptime
-	stats.Uptime = getUptime()
+	if stats.Uptime = getUptime(procPath); stats.Uptime == 0 {
+		stats.Unavailable = append(stats.Unavailable, "uptime")
+	}
 
 	// Get all disks usage
-	stats.Disks = getAllDisksUsage()
+	if stats.Disks = getAllDisksUsage(procPath); len(stats.Disks) == 0 {
+		stats.Unavailable = append(stats.Unavailable, "disks")
+	}
 
 	// Keep backward compatibility - use root disk for DiskTotal/DiskFree
-	stats.DiskTotal, stats.DiskFree = getDiskUsage("/")
+	stats.DiskTotal, stats.DiskFree = getDiskUsage(hostRoot(procPath))
 
 	return stats
 }
 
-// getMemoryInfo reads memory info from /proc/meminfo
-// Returns MemTotal and MemAvailable (as "free" - more useful than actual MemFree)
-func getMemoryInfo() (uint64, uint64) {
-	data, err := os.ReadFile("/proc/meminfo")
+// memoryInfo holds the /proc/meminfo values host stats report, in bytes
+type memoryInfo struct {
+	total     uint64
+	available uint64 // MemAvailable (as "free" - more useful than actual MemFree)
+	swapTotal uint64
+	swapFree  uint64
+}
+
+// getMemoryInfo reads memory and swap info from /proc/meminfo
+// Without swap both swap values are zero.
+func getMemoryInfo(procPath string) memoryInfo {
+	var mem memoryInfo
+	data, err := os.ReadFile(filepath.Join(procPath, "meminfo"))
 	if err != nil {
-		return 0, 0
+		return mem
 	}
 
-	var memTotal, memAvailable uint64
 	lines := strings.Split(string(data), "\n")
 	for _, line := range lines {
 		fields := strings.Fields(line)
@@ -98,29 +162,22 @@ func getMemoryInfo() (uint64, uint64) {
 
 		switch fields[0] {
 		case "MemTotal:":
-			memTotal = value
+			mem.total = value
 		case "MemAvailable:":
-			memAvailable = value
+			mem.available = value
+		case "SwapTotal:":
+			mem.swapTotal = value
+		case "SwapFree:":
+			mem.swapFree = value
 		}
 	}
 
-	return memTotal, memAvailable
-}
-
-// getDiskUsage returns total and free disk space for a path
-func getDiskUsage(path string) (uint64, uint64) {
-	var stat syscall.Statfs_t
-	if err := syscall.Statfs(path, &stat); err != nil {
-		return 0, 0
-	}
-	total := stat.Blocks * uint64(stat.Bsize)
-	free := stat.Bavail * uint64(stat.Bsize)
-	return total, free
+	return mem
 }
 
 // getUptime reads system uptime from /proc/uptime
-func getUptime() int64 {
-	data, err := os.ReadFile("/proc/uptime")
+func getUptime(procPath string) int64 {
+	data, err := os.ReadFile(filepath.Join(procPath, "uptime"))
 	if err != nil {
 		return 0
 	}
@@ -145,19 +202,75 @@ var (
 	prevIdle     int64
 	prevTime     time.Time
 	lastCPUUsage float64
+	cpuReadable  bool
+	cpuSampling  bool // StartHostSampler keeps lastCPUUsage current
 )
 
-// getCPUUsage calculates real CPU usage from /proc/stat
-// Returns percentage (0-100)
-func getCPUUsage() float64 {
-	total, idle := readCPUStat()
-	if total == 0 {
-		return lastCPUUsage
-	}
+// cpuFirstDelta is the period of the first sample after the sampler starts
+const cpuFirstDelta = 250 * time.Millisecond
+
+// StartHostSampler samples CPU times in the background until ctx is done,
+// every PODMANVIEW_STATS_INTERVAL. Host stats then report usage over the
+// last interval, independent of when and how often they are requested.
+// The first usage is measured over a short period right after starting.
+func StartHostSampler(ctx context.Context, cfg *config.Config) {
+	procPath := func() string { return hostProcPath(cfg.HostProc()) }
+
+	cpuMu.Lock()
+	cpuSampling = true
+	sampleCPU(procPath())
+	cpuMu.Unlock()
+
+	go func() {
+		defer func() {
+			cpuMu.Lock()
+			cpuSampling = false
+			cpuMu.Unlock()
+		}()
+
+		timer := time.NewTimer(cpuFirstDelta)
+		defer timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+				cpuMu.Lock()
+				sampleCPU(procPath())
+				cpuMu.Unlock()
+
+				// Read each time to pick up a reloaded interval
+				timer.Reset(cfg.StatsInterval())
+			}
+		}
+	}()
+}
 
+// getCPUUsage returns CPU usage as percentage (0-100) and false if
+// /proc/stat can't be read. Without the background sampler each call
+// measures the time since the previous one, and the first returns 0.
+func getCPUUsage(procPath string) (float64, bool) {
 	cpuMu.Lock()
 	defer cpuMu.Unlock()
 
+	if !cpuSampling {
+		sampleCPU(procPath)
+	}
+	if !cpuReadable {
+		return 0, false
+	}
+	return lastCPUUsage, true
+}
+
+// sampleCPU calculates real CPU usage from /proc/stat since the previous
+// sample (cpuMu must be held)
+func sampleCPU(procPath string) {
+	total, idle := readCPUStat(procPath)
+	cpuReadable = total != 0
+	if !cpuReadable {
+		return
+	}
+
 	now := time.Now()
 
 	// Need previous reading to calculate delta
@@ -165,7 +278,7 @@ func getCPUUsage() float64 {
 		prevTotal = total
 		prevIdle = idle
 		prevTime = now
-		return 0
+		return
 	}
 
 	// Calculate delta since last reading
@@ -178,7 +291,7 @@ func getCPUUsage() float64 {
 	prevTime = now
 
 	if totalDelta <= 0 {
-		return lastCPUUsage
+		return
 	}
 
 	// CPU usage = (total - idle) / total * 100
@@ -188,13 +301,11 @@ func getCPUUsage() float64 {
 	} else if lastCPUUsage > 100 {
 		lastCPUUsage = 100
 	}
-
-	return lastCPUUsage
 }
 
 // readCPUStat reads CPU times from /proc/stat
-func readCPUStat() (total, idle int64) {
-	data, err := os.ReadFile("/proc/stat")
+func readCPUStat(procPath string) (total, idle int64) {
+	data, err := os.ReadFile(filepath.Join(procPath, "stat"))
 	if err != nil {
 		return 0, 0
 	}
@@ -227,12 +338,19 @@ func readCPUStat() (total, idle int64) {
 // have been moved to the temperature plugin (internal/plugins/temperature)
 
 // getAllDisksUsage returns usage info for all mounted block devices
-func getAllDisksUsage() []DiskInfo {
+// With the host's /proc mounted, the mounts are those of the host's init
+// process and are measured through its root (see hostRoot).
+func getAllDisksUsage(procPath string) []DiskInfo {
 	disks := []DiskInfo{}
 	seen := make(map[string]bool)
+	root := hostRoot(procPath)
 
 	// Read /proc/mounts to find all mounted filesystems
-	data, err := os.ReadFile("/proc/mounts")
+	mounts := filepath.Join(procPath, "mounts")
+	if root != "/" {
+		mounts = filepath.Join(procPath, "1", "mounts")
+	}
+	data, err := os.ReadFile(mounts)
 	if err != nil {
 		return disks
 	}
@@ -288,7 +406,7 @@ func getAllDisksUsage() []DiskInfo {
 
 		// Get disk usage for this mount point
 		var stat syscall.Statfs_t
-		if err := syscall.Statfs(mountPoint, &stat); err != nil {
+		if err := syscall.Statfs(filepath.Join(root, mountPoint), &stat); err != nil {
 			continue
 		}
 
@@ -8,7 +8,9 @@ import (
 	"time"
 
 	"podmanview/internal/auth"
+	"podmanview/internal/config"
 	"podmanview/internal/events"
+	"podmanview/internal/netutil"
 	"podmanview/internal/podman"
 	"podmanview/internal/plugins"
 	"podmanview/internal/plugins/temperature"
@@ -34,14 +36,16 @@ type SystemHandler struct {
 	client         *podman.Client
 	eventStore     *events.Store
 	pluginRegistry *plugins.Registry
+	config         *config.Config
 }
 
 // NewSystemHandler creates new system handler
-func NewSystemHandler(client *podman.Client, eventStore *events.Store, pluginRegistry *plugins.Registry) *SystemHandler {
+func NewSystemHandler(client *podman.Client, eventStore *events.Store, pluginRegistry *plugins.Registry, cfg *config.Config) *SystemHandler {
 	return &SystemHandler{
 		client:         client,
 		eventStore:     eventStore,
 		pluginRegistry: pluginRegistry,
+		config:         cfg,
 	}
 }
 
@@ -63,9 +67,10 @@ type DashboardSystemInfo struct {
 
 // DashboardHostInfo contains only used host fields
 type DashboardHostInfo struct {
-	Arch     string `json:"arch"`
-	Hostname string `json:"hostname"`
-	Kernel   string `json:"kernel"`
+	Arch     string   `json:"arch"`
+	Hostname string   `json:"hostname"`
+	Kernel   string   `json:"kernel"`
+	IPv6     []string `json:"ipv6,omitempty"` // detected IPv6 addresses
 }
 
 // DashboardVersionInfo contains only used version fields
@@ -87,7 +92,7 @@ func (h *SystemHandler) Dashboard(w http.ResponseWriter, r *http.Request) {
 	// Get cached or fresh system info (static data, cache for 5 minutes)
 	sysInfo := h.getCachedSystemInfo(ctx)
 	if sysInfo == nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get system info"})
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to get system info")
 		return
 	}
 
@@ -97,12 +102,12 @@ func (h *SystemHandler) Dashboard(w http.ResponseWriter, r *http.Request) {
 	// Only containers need fresh data (state changes frequently)
 	containers, err := h.client.ListContainers(ctx)
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		writePodmanError(w, err, "")
 		return
 	}
 
 	// Get host stats (reads /proc, /sys)
-	hostStats := GetHostStats()
+	hostStats := GetHostStats(hostProcPath(h.config.HostProc()))
 
 	// Check if temperature plugin is enabled and get temperature data from it
 	if h.pluginRegistry != nil {
@@ -113,6 +118,7 @@ func (h *SystemHandler) Dashboard(w http.ResponseWriter, r *http.Request) {
 				// Convert plugin temperature data to API temperature data
 				hostStats.Temperatures = convertTemperatures(tempData.Temperatures)
 				hostStats.StorageTemps = convertStorageTemps(tempData.StorageTemps)
+				hostStats.TempUnit = plugin.Unit()
 			}
 		}
 	}
@@ -132,6 +138,7 @@ func (h *SystemHandler) Dashboard(w http.ResponseWriter, r *http.Request) {
 			Arch:     sysInfo.Host.Arch,
 			Hostname: sysInfo.Host.Hostname,
 			Kernel:   sysInfo.Host.Kernel,
+			IPv6:     netutil.IPv6Only(netutil.LocalIPs()),
 		},
 		Version: DashboardVersionInfo{
 			Version: sysInfo.Version.Version,
@@ -227,18 +234,47 @@ func (h *SystemHandler) getCachedResourceCounts(ctx context.Context) (int, int,
 func (h *SystemHandler) Info(w http.ResponseWriter, r *http.Request) {
 	info, err := h.client.GetSystemInfo(r.Context())
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		writePodmanError(w, err, "")
 		return
 	}
 
 	writeJSON(w, http.StatusOK, info)
 }
 
+// HealthResponse represents health check response
+type HealthResponse struct {
+	Status     string    `json:"status"`
+	Podman     bool      `json:"podman"`
+	LastCheck  time.Time `json:"lastCheck"`
+	Reconnects int       `json:"reconnects"`
+}
+
+// Healthz handles GET /healthz
+// Public endpoint: reports Podman connection state without socket details
+func (h *SystemHandler) Healthz(w http.ResponseWriter, r *http.Request) {
+	state := h.client.ConnectionState()
+
+	resp := HealthResponse{
+		Status:     "ok",
+		Podman:     state.Connected,
+		LastCheck:  state.LastCheck,
+		Reconnects: state.Reconnects,
+	}
+
+	if !state.Connected {
+		resp.Status = "degraded"
+		writeJSON(w, http.StatusServiceUnavailable, resp)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
 // DiskUsage handles GET /api/system/df
 func (h *SystemHandler) DiskUsage(w http.ResponseWriter, r *http.Request) {
 	df, err := h.client.GetSystemDF(r.Context())
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		writePodmanError(w, err, "")
 		return
 	}
 
@@ -248,10 +284,6 @@ func (h *SystemHandler) DiskUsage(w http.ResponseWriter, r *http.Request) {
 // Reboot handles POST /api/system/reboot
 func (h *SystemHandler) Reboot(w http.ResponseWriter, r *http.Request) {
 	user := auth.GetUserFromContext(r.Context())
-	if !user.IsAdmin() {
-		writeJSON(w, http.StatusForbidden, map[string]string{"error": "Admin access required"})
-		return
-	}
 
 	// Log reboot event
 	h.eventStore.Add(events.EventSystemReboot, user.Username, getClientIP(r), true, "")
@@ -268,10 +300,6 @@ func (h *SystemHandler) Reboot(w http.ResponseWriter, r *http.Request) {
 // Shutdown handles POST /api/system/shutdown
 func (h *SystemHandler) Shutdown(w http.ResponseWriter, r *http.Request) {
 	user := auth.GetUserFromContext(r.Context())
-	if !user.IsAdmin() {
-		writeJSON(w, http.StatusForbidden, map[string]string{"error": "Admin access required"})
-		return
-	}
 
 	// Log shutdown event
 	h.eventStore.Add(events.EventSystemShutdown, user.Username, getClientIP(r), true, "")
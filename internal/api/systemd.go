@@ -0,0 +1,238 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"podmanview/internal/auth"
+	"podmanview/internal/events"
+	"podmanview/internal/podman"
+)
+
+// systemUnitDir is where units are installed when running as root
+const systemUnitDir = "/etc/systemd/system"
+
+// SystemdUnit represents a generated unit file
+type SystemdUnit struct {
+	Name    string `json:"name"`
+	Content string `json:"content"`
+}
+
+// SystemdResponse represents generated units response
+type SystemdResponse struct {
+	Units []SystemdUnit `json:"units"`
+}
+
+// SystemdInstallRequest represents unit install request
+type SystemdInstallRequest struct {
+	podman.SystemdOptions
+	Enable bool `json:"enable"` // enable the units so they start on boot
+}
+
+// SystemdInstallResponse represents unit install result
+type SystemdInstallResponse struct {
+	Status  string   `json:"status"`
+	Files   []string `json:"files"`
+	Enabled bool     `json:"enabled"`
+}
+
+// Systemd handles GET /api/containers/{id}/systemd?new=true&restartPolicy=always&stopTimeout=10
+func (h *ContainerHandler) Systemd(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	query := r.URL.Query()
+
+	opts := podman.SystemdOptions{
+		New:           query.Get("new") == "true",
+		RestartPolicy: query.Get("restartPolicy"),
+	}
+	if v := query.Get("stopTimeout"); v != "" {
+		timeout, err := strconv.Atoi(v)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid_parameter", "Invalid stopTimeout")
+			return
+		}
+		opts.StopTimeout = timeout
+	}
+
+	units, err := h.generateUnits(r, id, opts)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "validation_failed", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, SystemdResponse{Units: units})
+}
+
+// InstallSystemd handles POST /api/containers/{id}/systemd.
+// Writes the generated units to the systemd unit directory and reloads systemd.
+// Refused for a remote engine, whose containers don't run on this host.
+func (h *ContainerHandler) InstallSystemd(w http.ResponseWriter, r *http.Request) {
+	user := auth.GetUserFromContext(r.Context())
+
+	id := chi.URLParam(r, "id")
+
+	var req SystemdInstallRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if !requireLocalEngine(w, h.client) {
+		return
+	}
+
+	units, err := h.generateUnits(r, id, req.SystemdOptions)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "validation_failed", err.Error())
+		return
+	}
+
+	meta := events.Meta{"container": id}
+
+	files, err := installUnits(units, req.Enable)
+	if err != nil {
+		h.eventStore.AddWithMeta(events.EventContainerSystemd, user.Username, getClientIP(r), false, shortID(id), meta)
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+
+	meta["units"] = strings.Join(files, ",")
+	h.eventStore.AddWithMeta(events.EventContainerSystemd, user.Username, getClientIP(r), true, shortID(id), meta)
+	writeJSON(w, http.StatusOK, SystemdInstallResponse{
+		Status:  "installed",
+		Files:   files,
+		Enabled: req.Enable,
+	})
+}
+
+// generateUnits validates options and returns generated units sorted by name
+func (h *ContainerHandler) generateUnits(r *http.Request, id string, opts podman.SystemdOptions) ([]SystemdUnit, error) {
+	if opts.RestartPolicy != "" && !slices.Contains(podman.SystemdRestartPolicies, opts.RestartPolicy) {
+		return nil, fmt.Errorf("invalid restart policy %q", opts.RestartPolicy)
+	}
+	if opts.StopTimeout < 0 {
+		return nil, errors.New("stop timeout cannot be negative")
+	}
+
+	generated, err := h.client.GenerateSystemd(r.Context(), id, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	units := make([]SystemdUnit, 0, len(generated))
+	for name, content := range generated {
+		name = normalizeUnitName(name)
+		if !unitNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid unit name %q", name)
+		}
+		units = append(units, SystemdUnit{Name: name, Content: content})
+	}
+	sort.Slice(units, func(i, j int) bool { return units[i].Name < units[j].Name })
+
+	return units, nil
+}
+
+// requireLocalEngine rejects requests that act on this host (its systemd
+// or Podman storage) for containers or images of a remote engine, which
+// aren't here. Returns false if the request was rejected.
+func requireLocalEngine(w http.ResponseWriter, client *podman.Client) bool {
+	if client != nil && client.IsRemote() {
+		writeJSONError(w, http.StatusConflict, "remote_engine", "Podman runs on another host, this only works with a local engine")
+		return false
+	}
+	return true
+}
+
+// unitScope returns the unit directory and the systemctl scope arguments:
+// the system manager as root, the user's manager for rootless Podman
+func unitScope() (dir string, scope []string, err error) {
+	if os.Geteuid() == 0 {
+		return systemUnitDir, nil, nil
+	}
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", nil, err
+	}
+	return filepath.Join(configDir, "systemd", "user"), []string{"--user"}, nil
+}
+
+// installUnits writes units atomically, reloads systemd and optionally
+// enables them. Rootless installs go to the user's systemd directory.
+func installUnits(units []SystemdUnit, enable bool) ([]string, error) {
+	dir, scope, err := unitScope()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	files := make([]string, 0, len(units))
+	for _, unit := range units {
+		path := filepath.Join(dir, filepath.Base(unit.Name))
+		if err := writeFileAtomic(path, []byte(unit.Content), 0644); err != nil {
+			return files, err
+		}
+		files = append(files, path)
+	}
+
+	if err := runSystemctl(append(scope, "daemon-reload")...); err != nil {
+		return files, err
+	}
+
+	if enable {
+		args := append(scope, "enable")
+		for _, unit := range units {
+			args = append(args, unit.Name)
+		}
+		if err := runSystemctl(args...); err != nil {
+			return files, err
+		}
+	}
+
+	return files, nil
+}
+
+// writeFileAtomic writes data to a temp file and renames it over path
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// runSystemctl runs systemctl, including its output in the error
+func runSystemctl(args ...string) error {
+	out, err := exec.Command("systemctl", args...).CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("systemctl %s: %s", strings.Join(args, " "), msg)
+		}
+		return fmt.Errorf("systemctl %s: %w", strings.Join(args, " "), err)
+	}
+	return nil
+}
@@ -0,0 +1,268 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"podmanview/internal/auth"
+	"podmanview/internal/events"
+)
+
+// Tail-follow limits
+const (
+	defaultTailLines = 100
+	maxTailLines     = 5000
+	// maxTailInitialBytes caps how far back the initial lines are searched
+	maxTailInitialBytes = 1 << 20
+	// maxTailChunk caps the appended data sent per message
+	maxTailChunk = 256 * 1024
+)
+
+// tailPollInterval is how often a followed file is checked for new data
+const tailPollInterval = time.Second
+
+// TailMessage is sent on a tail-follow stream after the ws_token message
+type TailMessage struct {
+	Type string `json:"type"`           // "data", "rotated" or "error"
+	Data string `json:"data,omitempty"` // file content for "data", reason for "error"
+}
+
+// tailFollower follows a file across truncation and rotation
+type tailFollower struct {
+	path   string
+	file   *os.File
+	info   os.FileInfo
+	offset int64
+}
+
+// open opens path and remembers its identity for rotation checks
+func (t *tailFollower) open() error {
+	file, err := os.Open(t.path)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	if t.file != nil {
+		t.file.Close()
+	}
+	t.file, t.info, t.offset = file, info, 0
+	return nil
+}
+
+func (t *tailFollower) close() {
+	if t.file != nil {
+		t.file.Close()
+	}
+}
+
+// lastLines returns up to n complete trailing lines of the open file and
+// positions the follower at its end
+func (t *tailFollower) lastLines(n int) (string, error) {
+	size := t.info.Size()
+	start := max(0, size-maxTailInitialBytes)
+	buf := make([]byte, size-start)
+	if _, err := t.file.ReadAt(buf, start); err != nil && err != io.EOF {
+		return "", err
+	}
+	t.offset = size
+
+	// Count newlines from the end, ignoring a trailing one
+	end := len(buf)
+	if end > 0 && buf[end-1] == '\n' {
+		end--
+	}
+	cut := 0
+	for i, found := end-1, 0; i >= 0; i-- {
+		if buf[i] == '\n' {
+			found++
+			if found == n {
+				cut = i + 1
+				break
+			}
+		}
+	}
+	if cut == 0 && start > 0 {
+		// Searched the whole window: drop the first, partial line
+		if i := bytes.IndexByte(buf, '\n'); i >= 0 {
+			cut = i + 1
+		}
+	}
+	return string(buf[cut:]), nil
+}
+
+// poll returns data appended since the last call. rotated reports that
+// the file was truncated or replaced and is being read from the start.
+func (t *tailFollower) poll() (data string, rotated bool, err error) {
+	info, err := os.Stat(t.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// Moved away by rotation, the new file isn't there yet
+			return "", false, nil
+		}
+		return "", false, err
+	}
+
+	switch {
+	case !os.SameFile(info, t.info):
+		if err := t.open(); err != nil {
+			return "", false, err
+		}
+		rotated = true
+	case info.Size() < t.offset:
+		t.offset = 0
+		rotated = true
+	}
+
+	if info.Size() <= t.offset {
+		return "", rotated, nil
+	}
+	buf := make([]byte, min(info.Size()-t.offset, maxTailChunk))
+	n, err := t.file.ReadAt(buf, t.offset)
+	if err != nil && err != io.EOF {
+		return "", rotated, err
+	}
+	t.offset += int64(n)
+	return strings.ToValidUTF8(string(buf[:n]), "�"), rotated, nil
+}
+
+// Tail handles GET /api/files/tail?path=...&lines=100&ws_token=...
+// It streams the last lines of a file over WebSocket and then, like
+// tail -F, the data appended to it, reopening the file when it is
+// truncated or replaced by log rotation.
+func (h *FileManagerHandler) Tail(w http.ResponseWriter, r *http.Request) {
+	user := auth.GetUserFromContext(r.Context())
+
+	requestedPath := r.URL.Query().Get("path")
+	if requestedPath == "" {
+		writeJSONError(w, http.StatusBadRequest, "missing_field", "Path is required")
+		return
+	}
+
+	lines := defaultTailLines
+	if v := r.URL.Query().Get("lines"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || n > maxTailLines {
+			writeJSONError(w, http.StatusBadRequest, "invalid_parameter",
+				fmt.Sprintf("Lines must be between 0 and %d", maxTailLines))
+			return
+		}
+		lines = n
+	}
+
+	absPath, err := h.validatePath(requestedPath)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid_path", err.Error())
+		return
+	}
+
+	// Check the type before opening, opening a FIFO would block
+	stat, err := os.Stat(absPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			writeJSONError(w, http.StatusNotFound, "file_not_found", "File not found")
+		} else {
+			writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to access file")
+		}
+		return
+	}
+	if stat.IsDir() {
+		writeJSONError(w, http.StatusBadRequest, "is_directory", "Cannot follow directory")
+		return
+	}
+	if !stat.Mode().IsRegular() {
+		writeJSONError(w, http.StatusBadRequest, "invalid_path", "Can only follow regular files")
+		return
+	}
+
+	follower := &tailFollower{path: absPath}
+	if err := follower.open(); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to open file")
+		return
+	}
+	defer follower.close()
+
+	ws, err := upgradeStream(w, r, h.wsTokenStore)
+	if err != nil {
+		return
+	}
+	defer ws.Close()
+
+	h.eventStore.AddWithMeta(events.EventFileRead, user.Username, getClientIP(r), true,
+		fmt.Sprintf("tail file=%s", filepath.Base(absPath)),
+		events.Meta{"path": h.getRelativePath(absPath), "action": "tail"})
+
+	// The client only closes the stream; reading detects that
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := ws.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	send := func(msg TailMessage) bool {
+		ws.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		return ws.WriteJSON(msg) == nil
+	}
+
+	initial := ""
+	if lines > 0 {
+		if initial, err = follower.lastLines(lines); err != nil {
+			send(TailMessage{Type: "error", Data: "Failed to read file"})
+			return
+		}
+	} else {
+		follower.offset = follower.info.Size()
+	}
+	if initial != "" && !send(TailMessage{Type: "data", Data: strings.ToValidUTF8(initial, "�")}) {
+		return
+	}
+
+	ticker := time.NewTicker(tailPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			ws.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+			return
+		case <-ticker.C:
+		}
+
+		// Drain in chunks so a burst of writes doesn't wait a tick per chunk
+		for {
+			data, rotated, err := follower.poll()
+			if err != nil {
+				logger(r.Context()).Printf("Failed to follow file %s: %v", absPath, err)
+				send(TailMessage{Type: "error", Data: "Failed to read file"})
+				return
+			}
+			if rotated && !send(TailMessage{Type: "rotated"}) {
+				return
+			}
+			if data == "" {
+				break
+			}
+			if !send(TailMessage{Type: "data", Data: data}) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"podmanview/internal/auth"
+	"podmanview/internal/podman"
+	"podmanview/internal/storage"
+)
+
+// templatesNamespace is the storage namespace of container templates,
+// keyed by template name
+const templatesNamespace = "container_templates"
+
+// Limits on container templates
+const (
+	maxTemplates          = 100
+	maxTemplateNameLength = 64
+)
+
+// ContainerTemplate is a named preset for the create container form
+type ContainerTemplate struct {
+	Name      string                       `json:"name"`
+	Config    podman.ContainerCreateConfig `json:"config"`
+	CreatedBy string                       `json:"created_by"`
+	UpdatedAt time.Time                    `json:"updated_at"`
+}
+
+// TemplatesResponse lists the saved container templates
+type TemplatesResponse struct {
+	Templates []ContainerTemplate `json:"templates"`
+}
+
+// TemplateHandler handles container templates, shared by all admins
+type TemplateHandler struct {
+	storage storage.Storage
+	mu      sync.Mutex // serializes the count check and write of a new template
+}
+
+// NewTemplateHandler creates new container template handler
+func NewTemplateHandler(store storage.Storage) *TemplateHandler {
+	return &TemplateHandler{storage: store}
+}
+
+// load returns all templates sorted by name
+func (h *TemplateHandler) load() ([]ContainerTemplate, error) {
+	values, err := h.storage.List(templatesNamespace)
+	if err != nil {
+		return nil, err
+	}
+	templates := make([]ContainerTemplate, 0, len(values))
+	for _, value := range values {
+		var t ContainerTemplate
+		if err := json.Unmarshal(value, &t); err != nil {
+			continue
+		}
+		templates = append(templates, t)
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		return strings.ToLower(templates[i].Name) < strings.ToLower(templates[j].Name)
+	})
+	return templates, nil
+}
+
+// List handles GET /api/templates
+func (h *TemplateHandler) List(w http.ResponseWriter, r *http.Request) {
+	if h.storage == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, "storage_unavailable", "Template storage not available")
+		return
+	}
+
+	templates, err := h.load()
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, TemplatesResponse{Templates: templates})
+}
+
+// Save handles POST /api/templates with {"name": "...", "config": {...}}.
+// Saving under an existing name replaces that template. The config has
+// the shape returned by POST /api/containers?dry_run=true.
+func (h *TemplateHandler) Save(w http.ResponseWriter, r *http.Request) {
+	user := auth.GetUserFromContext(r.Context())
+
+	var req struct {
+		Name   string                       `json:"name"`
+		Config podman.ContainerCreateConfig `json:"config"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		writeJSONError(w, http.StatusBadRequest, "missing_field", "Name is required")
+		return
+	}
+	if len(req.Name) > maxTemplateNameLength || strings.Contains(req.Name, "/") {
+		writeJSONError(w, http.StatusBadRequest, "invalid_name",
+			fmt.Sprintf("Name must be at most %d characters and contain no /", maxTemplateNameLength))
+		return
+	}
+	if req.Config.Image == "" {
+		writeJSONError(w, http.StatusBadRequest, "missing_field", "Image is required")
+		return
+	}
+
+	if h.storage == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, "storage_unavailable", "Template storage not available")
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	status := http.StatusOK
+	var existing ContainerTemplate
+	err := h.storage.GetJSON(templatesNamespace, req.Name, &existing)
+	if errors.Is(err, storage.ErrNotFound) {
+		status = http.StatusCreated
+		values, err := h.storage.List(templatesNamespace)
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, "internal_error", err.Error())
+			return
+		}
+		if len(values) >= maxTemplates {
+			writeJSONError(w, http.StatusBadRequest, "too_many_templates",
+				fmt.Sprintf("At most %d templates are allowed", maxTemplates))
+			return
+		}
+	} else if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+
+	template := ContainerTemplate{
+		Name:      req.Name,
+		Config:    req.Config,
+		CreatedBy: user.Username,
+		UpdatedAt: time.Now(),
+	}
+	if err := h.storage.SetJSON(templatesNamespace, template.Name, template); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "storage_error", err.Error())
+		return
+	}
+
+	writeJSON(w, status, template)
+}
+
+// Delete handles DELETE /api/templates/{name}
+func (h *TemplateHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+
+	if h.storage == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, "storage_unavailable", "Template storage not available")
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, err := h.storage.Get(templatesNamespace, name); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			writeJSONError(w, http.StatusNotFound, "template_not_found", "Template not found")
+		} else {
+			writeJSONError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		}
+		return
+	}
+	if err := h.storage.Delete(templatesNamespace, name); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "storage_error", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": "Template removed"})
+}
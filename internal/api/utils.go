@@ -1,8 +1,11 @@
 package api
 
 import (
+	"net"
 	"net/http"
 	"strings"
+	"sync"
+	"syscall"
 )
 
 // shortID returns first 12 characters of an ID (safe for short IDs)
@@ -13,27 +16,103 @@ func shortID(id string) string {
 	return id
 }
 
+// getDiskUsage returns total and free disk space for a path.
+// Free is the space available to unprivileged users (0, 0 on error).
+func getDiskUsage(path string) (uint64, uint64) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, 0
+	}
+	total := stat.Blocks * uint64(stat.Bsize)
+	free := stat.Bavail * uint64(stat.Bsize)
+	return total, free
+}
+
+// trustedProxies holds networks whose X-Forwarded-For / X-Real-IP headers are honored
+var (
+	trustedProxies   []*net.IPNet
+	trustedProxiesMu sync.RWMutex
+)
+
+// setTrustedProxies parses IPs/CIDRs of trusted reverse proxies
+func setTrustedProxies(proxies []string) {
+	nets := make([]*net.IPNet, 0, len(proxies))
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			if ip := net.ParseIP(proxy); ip != nil && ip.To4() != nil {
+				proxy += "/32"
+			} else {
+				proxy += "/128"
+			}
+		}
+		if _, ipNet, err := net.ParseCIDR(proxy); err == nil {
+			nets = append(nets, ipNet)
+		}
+	}
+
+	trustedProxiesMu.Lock()
+	trustedProxies = nets
+	trustedProxiesMu.Unlock()
+}
+
+// isTrustedProxy reports whether ip belongs to a trusted proxy
+func isTrustedProxy(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+
+	trustedProxiesMu.RLock()
+	defer trustedProxiesMu.RUnlock()
+
+	for _, ipNet := range trustedProxies {
+		if ipNet.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
 // getClientIP extracts client IP from request, considering reverse proxy headers
+// Forwarded headers are only honored when the direct peer is a trusted proxy
 func getClientIP(r *http.Request) string {
-	// Check X-Real-IP first (set by nginx)
-	if ip := r.Header.Get("X-Real-IP"); ip != "" {
-		return ip
+	remoteIP := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(remoteIP); err == nil {
+		remoteIP = host
 	}
 
-	// Check X-Forwarded-For (can contain multiple IPs)
+	// Peers on a Unix listen socket are a local reverse proxy
+	if isUnixSocket(r) {
+		remoteIP = "unix"
+	} else if !isTrustedProxy(remoteIP) {
+		return remoteIP
+	}
+
+	// X-Forwarded-For: client, proxy1, proxy2 - walk from the right and
+	// skip our own proxies; the first untrusted address is the client
 	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
-		// Take the first IP (original client)
-		if idx := strings.Index(xff, ","); idx != -1 {
-			return strings.TrimSpace(xff[:idx])
+		hops := strings.Split(xff, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if net.ParseIP(hop) == nil {
+				break
+			}
+			if !isTrustedProxy(hop) || i == 0 {
+				return hop
+			}
 		}
-		return strings.TrimSpace(xff)
 	}
 
-	// Fall back to RemoteAddr
-	// Remove port if present
-	addr := r.RemoteAddr
-	if idx := strings.LastIndex(addr, ":"); idx != -1 {
-		return addr[:idx]
+	// X-Real-IP (set by nginx)
+	if ip := strings.TrimSpace(r.Header.Get("X-Real-IP")); net.ParseIP(ip) != nil {
+		return ip
 	}
-	return addr
+
+	return remoteIP
+}
+
+// isUnixSocket reports whether r arrived on a Unix socket listener
+func isUnixSocket(r *http.Request) bool {
+	addr, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr)
+	return ok && addr.Network() == "unix"
 }
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"podmanview/internal/auth"
+	"podmanview/internal/events"
+	"podmanview/internal/podman"
+)
+
+// VolumeHandler handles volume endpoints
+type VolumeHandler struct {
+	client     *podman.Client
+	eventStore *events.Store
+}
+
+// NewVolumeHandler creates new volume handler
+func NewVolumeHandler(client *podman.Client, eventStore *events.Store) *VolumeHandler {
+	return &VolumeHandler{client: client, eventStore: eventStore}
+}
+
+// DanglingVolume is a volume no container references
+type DanglingVolume struct {
+	podman.Volume
+	Size int64 `json:"Size"` // bytes reclaimed by removing it (-1 if unknown)
+}
+
+// DanglingVolumesResponse lists the dangling volumes
+type DanglingVolumesResponse struct {
+	Volumes          []DanglingVolume `json:"volumes"`
+	ReclaimableBytes int64            `json:"reclaimableBytes"`
+}
+
+// VolumePruneRequest limits a prune to some of the dangling volumes
+type VolumePruneRequest struct {
+	Names []string `json:"names,omitempty"` // Empty removes every dangling volume
+}
+
+// VolumePruneFailure is a volume that could not be removed
+type VolumePruneFailure struct {
+	Name  string `json:"name"`
+	Error string `json:"error"`
+}
+
+// VolumePruneResponse lists the volumes removed by a prune
+type VolumePruneResponse struct {
+	Removed        []string             `json:"removed"`
+	ReclaimedBytes int64                `json:"reclaimedBytes"`
+	Skipped        []string             `json:"skipped,omitempty"` // requested names that are in use or don't exist
+	Failed         []VolumePruneFailure `json:"failed,omitempty"`
+}
+
+// Dangling handles GET /api/volumes/dangling
+func (h *VolumeHandler) Dangling(w http.ResponseWriter, r *http.Request) {
+	volumes, err := h.danglingVolumes(r.Context())
+	if err != nil {
+		writePodmanError(w, err, "")
+		return
+	}
+
+	resp := DanglingVolumesResponse{Volumes: volumes}
+	for _, v := range volumes {
+		resp.ReclaimableBytes += max(v.Size, 0)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// Prune handles POST /api/volumes/prune
+// Removes the volumes GET /api/volumes/dangling lists. Podman refuses to
+// remove a volume a container started using in the meantime.
+func (h *VolumeHandler) Prune(w http.ResponseWriter, r *http.Request) {
+	user := auth.GetUserFromContext(r.Context())
+
+	var req VolumePruneRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	volumes, err := h.danglingVolumes(r.Context())
+	if err != nil {
+		writePodmanError(w, err, "")
+		return
+	}
+
+	resp := VolumePruneResponse{Removed: []string{}}
+	if len(req.Names) > 0 {
+		var selected []DanglingVolume
+		for _, name := range req.Names {
+			i := slices.IndexFunc(volumes, func(v DanglingVolume) bool { return v.Name == name })
+			if i < 0 {
+				resp.Skipped = append(resp.Skipped, name)
+				continue
+			}
+			selected = append(selected, volumes[i])
+		}
+		volumes = selected
+	}
+
+	for _, v := range volumes {
+		if err := h.client.RemoveVolume(r.Context(), v.Name, false); err != nil {
+			resp.Failed = append(resp.Failed, VolumePruneFailure{Name: v.Name, Error: err.Error()})
+			continue
+		}
+		resp.Removed = append(resp.Removed, v.Name)
+		resp.ReclaimedBytes += max(v.Size, 0)
+	}
+
+	h.eventStore.AddWithMeta(events.EventVolumePrune, user.Username, getClientIP(r), len(resp.Failed) == 0,
+		fmt.Sprintf("%d removed, %d failed", len(resp.Removed), len(resp.Failed)),
+		events.Meta{"removed": fmt.Sprint(len(resp.Removed))})
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// danglingVolumes returns the volumes not mounted by any container,
+// running or not, with their size from Podman's disk usage report
+func (h *VolumeHandler) danglingVolumes(ctx context.Context) ([]DanglingVolume, error) {
+	volumes, err := h.client.ListVolumes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	containers, err := h.client.ListContainers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	used := make(map[string]bool)
+	for _, c := range containers {
+		info, err := h.client.InspectContainer(ctx, c.ID)
+		if err != nil {
+			if podman.IsNotFound(err) {
+				continue // removed since listing
+			}
+			return nil, err
+		}
+		for _, m := range info.Mounts {
+			if m.Type == "volume" {
+				used[m.Name] = true
+			}
+		}
+	}
+
+	// Sizes are informational, a failed report leaves them unknown
+	sizes := make(map[string]int64)
+	if df, err := h.client.GetSystemDF(ctx); err == nil {
+		for _, v := range df.Volumes {
+			sizes[v.VolumeName] = v.Size
+		}
+	}
+
+	result := []DanglingVolume{}
+	for _, v := range volumes {
+		if used[v.Name] {
+			continue
+		}
+		size, ok := sizes[v.Name]
+		if !ok {
+			size = -1
+		}
+		result = append(result, DanglingVolume{Volume: v, Size: size})
+	}
+	return result, nil
+}
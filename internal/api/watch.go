@@ -0,0 +1,184 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"podmanview/internal/auth"
+	"podmanview/internal/events"
+)
+
+// maxFileWatches caps concurrent directory watches across all clients
+const maxFileWatches = 16
+
+// watchBatchInterval collects changes before sending them, so a process
+// writing a file doesn't produce a message per write
+const watchBatchInterval = 250 * time.Millisecond
+
+// Directory watch errors, see newDirWatcher
+var (
+	errWatchUnsupported = errors.New("directory watching is not supported on this platform")
+	errWatchLimit       = errors.New("inotify watch limit reached, raise fs.inotify.max_user_watches or max_user_instances")
+)
+
+// dirEvent is a change to an entry of a watched directory
+type dirEvent struct {
+	Op    string `json:"op"`   // "create", "delete", "modify" or "overflow"
+	Name  string `json:"name"` // Entry name, empty for "overflow"
+	IsDir bool   `json:"is_dir,omitempty"`
+}
+
+// dirWatcher delivers changes to one directory until closed or the
+// directory itself is removed or moved, which closes events
+type dirWatcher struct {
+	file   *os.File
+	events chan dirEvent
+	done   chan struct{} // closed by close, stops the reader
+}
+
+func (d *dirWatcher) close() {
+	close(d.done)
+	d.file.Close()
+}
+
+// WatchMessage is sent on a directory watch stream after the ws_token message
+type WatchMessage struct {
+	Type    string     `json:"type"`              // "changes" or "gone"
+	Changes []dirEvent `json:"changes,omitempty"` // Coalesced changes for "changes"
+}
+
+// Watch handles GET /api/files/watch?path=...&ws_token=...
+// It streams changes to a directory's entries over WebSocket so the file
+// browser can refresh live. "gone" is sent when the directory is removed.
+func (h *FileManagerHandler) Watch(w http.ResponseWriter, r *http.Request) {
+	user := auth.GetUserFromContext(r.Context())
+
+	requestedPath := r.URL.Query().Get("path")
+	if requestedPath == "" {
+		requestedPath = "/"
+	}
+
+	absPath, err := h.validatePath(requestedPath)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid_path", err.Error())
+		return
+	}
+
+	stat, err := os.Stat(absPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			writeJSONError(w, http.StatusNotFound, "file_not_found", "Directory not found")
+		} else {
+			writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to access directory")
+		}
+		return
+	}
+	if !stat.IsDir() {
+		writeJSONError(w, http.StatusBadRequest, "not_a_directory", "Path is not a directory")
+		return
+	}
+
+	select {
+	case h.watchSlots <- struct{}{}:
+		defer func() { <-h.watchSlots }()
+	default:
+		writeJSONError(w, http.StatusTooManyRequests, "too_many_watches",
+			fmt.Sprintf("At most %d directories can be watched at once", maxFileWatches))
+		return
+	}
+
+	watcher, err := newDirWatcher(absPath)
+	if err != nil {
+		switch {
+		case errors.Is(err, errWatchUnsupported):
+			writeJSONError(w, http.StatusNotImplemented, "watch_unsupported", err.Error())
+		case errors.Is(err, errWatchLimit):
+			logger(r.Context()).Printf("Failed to watch %s: %v", absPath, err)
+			writeJSONError(w, http.StatusServiceUnavailable, "watch_limit", err.Error())
+		default:
+			logger(r.Context()).Printf("Failed to watch %s: %v", absPath, err)
+			writeJSONError(w, http.StatusInternalServerError, "internal_error", "Failed to watch directory")
+		}
+		return
+	}
+	defer watcher.close()
+
+	ws, err := upgradeStream(w, r, h.wsTokenStore)
+	if err != nil {
+		return
+	}
+	defer ws.Close()
+
+	h.eventStore.AddWithMeta(events.EventFileBrowse, user.Username, getClientIP(r), true,
+		fmt.Sprintf("watch path=%s", h.getRelativePath(absPath)),
+		events.Meta{"path": h.getRelativePath(absPath), "action": "watch"})
+
+	// The client only closes the stream; reading detects that
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := ws.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	send := func(msg WatchMessage) bool {
+		ws.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		return ws.WriteJSON(msg) == nil
+	}
+
+	var pending []dirEvent
+	var flush <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			ws.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+			return
+		case ev, ok := <-watcher.events:
+			if !ok {
+				if len(pending) > 0 {
+					send(WatchMessage{Type: "changes", Changes: pending})
+				}
+				send(WatchMessage{Type: "gone"})
+				return
+			}
+			pending = coalesceDirEvent(pending, ev)
+			if flush == nil {
+				flush = time.After(watchBatchInterval)
+			}
+		case <-flush:
+			flush = nil
+			if !send(WatchMessage{Type: "changes", Changes: pending}) {
+				return
+			}
+			pending = nil
+		}
+	}
+}
+
+// coalesceDirEvent adds ev to pending, replacing an earlier change to the
+// same entry: repeated writes become one "modify", a create followed by
+// writes stays a "create", and overflows are reported once
+func coalesceDirEvent(pending []dirEvent, ev dirEvent) []dirEvent {
+	for i, p := range pending {
+		if p.Name != ev.Name {
+			continue
+		}
+		if p.Op == ev.Op || (p.Op == "create" && ev.Op == "modify") {
+			return pending
+		}
+		pending[i] = ev
+		return pending
+	}
+	return append(pending, ev)
+}
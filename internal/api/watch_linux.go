@@ -0,0 +1,91 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// dirWatchMask selects the inotify events reported for a watched directory
+const dirWatchMask = unix.IN_CREATE | unix.IN_DELETE | unix.IN_MODIFY | unix.IN_ATTRIB |
+	unix.IN_MOVED_FROM | unix.IN_MOVED_TO | unix.IN_DELETE_SELF | unix.IN_MOVE_SELF | unix.IN_ONLYDIR
+
+// newDirWatcher starts watching the entries of dir with inotify. Each
+// watcher uses its own inotify instance, so closing it drops the watch.
+func newDirWatcher(dir string) (*dirWatcher, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		if errors.Is(err, unix.EMFILE) {
+			return nil, errWatchLimit
+		}
+		return nil, err
+	}
+	if _, err := unix.InotifyAddWatch(fd, dir, dirWatchMask); err != nil {
+		unix.Close(fd)
+		if errors.Is(err, unix.ENOSPC) {
+			return nil, errWatchLimit
+		}
+		return nil, err
+	}
+
+	// A non-blocking fd goes through the runtime poller, so Close
+	// interrupts a pending Read
+	d := &dirWatcher{
+		file:   os.NewFile(uintptr(fd), "inotify"),
+		events: make(chan dirEvent, 64),
+		done:   make(chan struct{}),
+	}
+	go d.read()
+	return d, nil
+}
+
+// read decodes inotify events until the watcher is closed or the
+// directory goes away
+func (d *dirWatcher) read() {
+	defer close(d.events)
+
+	buf := make([]byte, 64*(unix.SizeofInotifyEvent+unix.NAME_MAX+1))
+	for {
+		n, err := d.file.Read(buf)
+		if err != nil {
+			return
+		}
+
+		for offset := 0; offset+unix.SizeofInotifyEvent <= n; {
+			raw := (*unix.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameBytes := buf[offset+unix.SizeofInotifyEvent : offset+unix.SizeofInotifyEvent+int(raw.Len)]
+			offset += unix.SizeofInotifyEvent + int(raw.Len)
+
+			mask := raw.Mask
+			if mask&(unix.IN_DELETE_SELF|unix.IN_MOVE_SELF|unix.IN_IGNORED) != 0 {
+				return
+			}
+
+			ev := dirEvent{
+				Name:  string(bytes.TrimRight(nameBytes, "\x00")),
+				IsDir: mask&unix.IN_ISDIR != 0,
+			}
+			switch {
+			case mask&unix.IN_Q_OVERFLOW != 0:
+				ev = dirEvent{Op: "overflow"}
+			case mask&(unix.IN_CREATE|unix.IN_MOVED_TO) != 0:
+				ev.Op = "create"
+			case mask&(unix.IN_DELETE|unix.IN_MOVED_FROM) != 0:
+				ev.Op = "delete"
+			case mask&(unix.IN_MODIFY|unix.IN_ATTRIB) != 0:
+				ev.Op = "modify"
+			default:
+				continue
+			}
+
+			select {
+			case d.events <- ev:
+			case <-d.done:
+				return
+			}
+		}
+	}
+}
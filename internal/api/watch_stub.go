@@ -0,0 +1,9 @@
+//go:build !linux
+// +build !linux
+
+package api
+
+// newDirWatcher is only implemented with inotify on Linux
+func newDirWatcher(dir string) (*dirWatcher, error) {
+	return nil, errWatchUnsupported
+}
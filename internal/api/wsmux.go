@@ -0,0 +1,349 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"podmanview/internal/auth"
+	"podmanview/internal/events"
+	"podmanview/internal/podman"
+)
+
+// Channels of the multiplexed stream
+const (
+	ChannelEvents         = "events"
+	ChannelContainerStats = "container-stats"
+	ChannelContainerLogs  = "container-logs"
+)
+
+// maxMuxSubscriptions caps subscriptions on one multiplexed connection
+const maxMuxSubscriptions = 16
+
+// Polling intervals of the events and container-stats channels
+const (
+	muxEventsInterval       = time.Second
+	muxStatsDefaultInterval = 5 * time.Second
+	muxStatsMinInterval     = 2 * time.Second
+	muxStatsMaxInterval     = time.Minute
+)
+
+// Limits of the container-logs channel
+const (
+	muxLogsDefaultTail  = 100
+	muxLogsMaxTail      = 1000
+	muxLogsMaxLineBytes = 64 * 1024
+	muxLogsBatch        = 200 * time.Millisecond // lines are sent in batches
+)
+
+// MuxRequest is a message from the client on /api/ws
+type MuxRequest struct {
+	Type      string `json:"type"`                // "subscribe" or "unsubscribe"
+	ID        string `json:"id"`                  // Subscription ID chosen by the client
+	Channel   string `json:"channel,omitempty"`   // Channel to subscribe to
+	Container string `json:"container,omitempty"` // container-logs: container ID or name
+	Tail      int    `json:"tail,omitempty"`      // container-logs: lines of history (default 100)
+	Since     int64  `json:"since,omitempty"`     // events: last event ID seen (default: only new events)
+	Interval  int    `json:"interval,omitempty"`  // container-stats: seconds between updates (default 5)
+}
+
+// MuxMessage is a message from the server on /api/ws, after the ws_token message.
+//
+// Data depends on the channel: events sends {"events", "lastId"} like
+// GET /api/events, container-stats the stats of running containers and
+// container-logs {"lines"} with new lines, oldest first.
+type MuxMessage struct {
+	Type    string       `json:"type"` // "subscribed", "data", "error" or "end"
+	ID      string       `json:"id,omitempty"`
+	Channel string       `json:"channel,omitempty"`
+	Data    interface{}  `json:"data,omitempty"`
+	Error   *ErrorDetail `json:"error,omitempty"`
+}
+
+// StreamMuxHandler serves the multiplexed stream, which carries several
+// channels over one WebSocket so clients need a single ws_token
+type StreamMuxHandler struct {
+	client       *podman.Client
+	eventStore   *events.Store
+	wsTokenStore *auth.WSTokenStore
+	statsCache   *StatsCache
+}
+
+// NewStreamMuxHandler creates new multiplexed stream handler
+func NewStreamMuxHandler(client *podman.Client, eventStore *events.Store, wsTokenStore *auth.WSTokenStore, statsCache *StatsCache) *StreamMuxHandler {
+	return &StreamMuxHandler{
+		client:       client,
+		eventStore:   eventStore,
+		wsTokenStore: wsTokenStore,
+		statsCache:   statsCache,
+	}
+}
+
+// muxConn is one multiplexed connection. Subscriptions send through out,
+// which a single goroutine writes to the socket.
+type muxConn struct {
+	ctx  context.Context
+	out  chan MuxMessage
+	mu   sync.Mutex
+	subs map[string]context.CancelFunc
+	wg   sync.WaitGroup
+}
+
+// send queues msg, giving up when the connection is closing
+func (c *muxConn) send(ctx context.Context, msg MuxMessage) bool {
+	select {
+	case c.out <- msg:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// sendError queues an error for subscription id
+func (c *muxConn) sendError(id, code, message string) {
+	c.send(c.ctx, MuxMessage{Type: "error", ID: id, Error: &ErrorDetail{Code: code, Message: message}})
+}
+
+// Connect handles GET /api/ws?ws_token=...
+// Clients send MuxRequest messages to subscribe to and unsubscribe from
+// channels; each channel is fed by the same source as its REST endpoint.
+func (h *StreamMuxHandler) Connect(w http.ResponseWriter, r *http.Request) {
+	ws, err := upgradeStream(w, r, h.wsTokenStore)
+	if err != nil {
+		return
+	}
+	defer ws.Close()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	conn := &muxConn{
+		ctx:  ctx,
+		out:  make(chan MuxMessage, 64),
+		subs: make(map[string]context.CancelFunc),
+	}
+
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		for {
+			select {
+			case <-ctx.Done():
+				ws.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+				return
+			case msg := <-conn.out:
+				ws.SetWriteDeadline(time.Now().Add(10 * time.Second))
+				if err := ws.WriteJSON(msg); err != nil {
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	for {
+		var req MuxRequest
+		if err := ws.ReadJSON(&req); err != nil {
+			break
+		}
+		switch req.Type {
+		case "subscribe":
+			h.subscribe(conn, req)
+		case "unsubscribe":
+			conn.mu.Lock()
+			if stop, ok := conn.subs[req.ID]; ok {
+				stop()
+				delete(conn.subs, req.ID)
+			}
+			conn.mu.Unlock()
+		default:
+			conn.sendError(req.ID, "invalid_request", fmt.Sprintf("Unknown message type: %q", req.Type))
+		}
+	}
+
+	cancel()
+	conn.wg.Wait()
+	<-writerDone
+}
+
+// subscribe validates req and starts its channel
+func (h *StreamMuxHandler) subscribe(conn *muxConn, req MuxRequest) {
+	if req.ID == "" {
+		conn.sendError("", "missing_field", "Subscription ID is required")
+		return
+	}
+
+	var run func(ctx context.Context, conn *muxConn, req MuxRequest)
+	switch req.Channel {
+	case ChannelEvents:
+		run = h.runEvents
+	case ChannelContainerStats:
+		run = h.runStats
+	case ChannelContainerLogs:
+		if req.Container == "" {
+			conn.sendError(req.ID, "missing_field", "Container is required")
+			return
+		}
+		run = h.runLogs
+	default:
+		conn.sendError(req.ID, "invalid_channel", fmt.Sprintf("Unknown channel: %q", req.Channel))
+		return
+	}
+
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	if _, ok := conn.subs[req.ID]; ok {
+		conn.sendError(req.ID, "subscription_exists", "Subscription ID is already in use")
+		return
+	}
+	if len(conn.subs) >= maxMuxSubscriptions {
+		conn.sendError(req.ID, "too_many_subscriptions",
+			fmt.Sprintf("At most %d subscriptions per connection", maxMuxSubscriptions))
+		return
+	}
+
+	ctx, stop := context.WithCancel(conn.ctx)
+	conn.subs[req.ID] = stop
+	conn.send(ctx, MuxMessage{Type: "subscribed", ID: req.ID, Channel: req.Channel})
+
+	conn.wg.Add(1)
+	go func() {
+		defer conn.wg.Done()
+		run(ctx, conn, req)
+
+		// A channel that ended on its own tells the client
+		conn.mu.Lock()
+		ended := ctx.Err() == nil
+		if ended {
+			delete(conn.subs, req.ID)
+		}
+		conn.mu.Unlock()
+		stop()
+		if ended {
+			conn.send(conn.ctx, MuxMessage{Type: "end", ID: req.ID, Channel: req.Channel})
+		}
+	}()
+}
+
+// runEvents sends new audit events as they are added to the store
+func (h *StreamMuxHandler) runEvents(ctx context.Context, conn *muxConn, req MuxRequest) {
+	lastID := req.Since
+	if lastID <= 0 {
+		lastID = h.eventStore.LastID()
+	}
+
+	ticker := time.NewTicker(muxEventsInterval)
+	defer ticker.Stop()
+	for {
+		if eventList := h.eventStore.GetSince(lastID); len(eventList) > 0 {
+			lastID = eventList[0].ID
+			data := map[string]interface{}{"events": eventList, "lastId": lastID}
+			if !conn.send(ctx, MuxMessage{Type: "data", ID: req.ID, Channel: req.Channel, Data: data}) {
+				return
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runStats sends the stats of running containers every interval. They
+// come from the stats cache, which Podman updates every 5 seconds.
+func (h *StreamMuxHandler) runStats(ctx context.Context, conn *muxConn, req MuxRequest) {
+	interval := muxStatsDefaultInterval
+	if req.Interval > 0 {
+		interval = min(max(time.Duration(req.Interval)*time.Second, muxStatsMinInterval), muxStatsMaxInterval)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	failing := false
+	for {
+		stats, _, err := h.statsCache.Stats(ctx)
+		switch {
+		case ctx.Err() != nil:
+			return
+		case err != nil:
+			// Report a failure once, not on every poll
+			if !failing {
+				logger(ctx).Printf("Failed to get container stats: %v", err)
+				conn.sendError(req.ID, "podman_error", err.Error())
+			}
+			failing = true
+		default:
+			failing = false
+			if stats == nil {
+				stats = []podman.ContainerStats{}
+			}
+			if !conn.send(ctx, MuxMessage{Type: "data", ID: req.ID, Channel: req.Channel, Data: stats}) {
+				return
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runLogs follows a container's logs, sending new lines in batches. It
+// ends when the container stops.
+func (h *StreamMuxHandler) runLogs(ctx context.Context, conn *muxConn, req MuxRequest) {
+	tail := req.Tail
+	if tail <= 0 {
+		tail = muxLogsDefaultTail
+	}
+	tail = min(tail, muxLogsMaxTail)
+
+	lines := make(chan string, 256)
+	followErr := make(chan error, 1)
+	go func() {
+		defer close(lines)
+		followErr <- h.client.FollowContainerLogs(ctx, req.Container, tail, muxLogsMaxLineBytes, func(line string) {
+			select {
+			case lines <- line:
+			case <-ctx.Done():
+			}
+		})
+	}()
+
+	var pending []string
+	var flush <-chan time.Time
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				if len(pending) > 0 {
+					conn.send(ctx, MuxMessage{Type: "data", ID: req.ID, Channel: req.Channel,
+						Data: map[string]interface{}{"lines": pending}})
+				}
+				if err := <-followErr; err != nil && ctx.Err() == nil {
+					if podman.IsNotFound(err) {
+						conn.sendError(req.ID, "container_not_found", err.Error())
+					} else {
+						conn.sendError(req.ID, "podman_error", err.Error())
+					}
+				}
+				return
+			}
+			pending = append(pending, line)
+			if flush == nil {
+				flush = time.After(muxLogsBatch)
+			}
+		case <-flush:
+			flush = nil
+			if !conn.send(ctx, MuxMessage{Type: "data", ID: req.ID, Channel: req.Channel,
+				Data: map[string]interface{}{"lines": pending}}) {
+				return
+			}
+			pending = nil
+		}
+	}
+}
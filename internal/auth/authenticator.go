@@ -0,0 +1,33 @@
+package auth
+
+import "errors"
+
+// Authenticator verifies a username and password and returns the user
+// with the role derived from their groups. PAMAuth checks local accounts,
+// LDAPAuth a directory server.
+type Authenticator interface {
+	Authenticate(username, password string) (*User, error)
+}
+
+// PasswordChanger is implemented by backends that can change a user's
+// password. Callers verify the current password with Authenticate first.
+type PasswordChanger interface {
+	ChangePassword(username, currentPassword, newPassword string) error
+}
+
+// ErrPasswordRejected wraps the reason the backend refused a new password
+// (too short, too simple, ...), which can be shown to the user
+var ErrPasswordRejected = errors.New("password rejected")
+
+// Authentication backends (PODMANVIEW_AUTH_BACKEND)
+const (
+	BackendPAM  = "pam"
+	BackendLDAP = "ldap"
+)
+
+var (
+	_ Authenticator = (*PAMAuth)(nil)
+	_ Authenticator = (*LDAPAuth)(nil)
+
+	_ PasswordChanger = (*PAMAuth)(nil)
+)
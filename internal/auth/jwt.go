@@ -1,7 +1,10 @@
 package auth
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -20,9 +23,14 @@ type Claims struct {
 	jwt.RegisteredClaims
 }
 
-// JWTManager handles JWT token operations
+// JWTManager handles JWT token operations.
+// After a secret rotation tokens signed with the previous key are
+// still accepted until previousUntil.
 type JWTManager struct {
+	mu            sync.RWMutex
 	secretKey     []byte
+	previousKey   []byte
+	previousUntil time.Time
 	tokenDuration time.Duration
 }
 
@@ -35,6 +43,33 @@ func NewJWTManager(secretKey string, tokenDuration time.Duration) *JWTManager {
 	}
 }
 
+// SetSecrets replaces the signing key. Tokens signed with previous are
+// accepted until previousUntil; pass an empty previous to accept only the new key.
+func (m *JWTManager) SetSecrets(secretKey, previous string, previousUntil time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.secretKey = []byte(secretKey)
+	m.previousKey = nil
+	m.previousUntil = time.Time{}
+	if previous != "" {
+		m.previousKey = []byte(previous)
+		m.previousUntil = previousUntil
+	}
+}
+
+// keys returns the keys a token may be signed with, current first
+func (m *JWTManager) keys() [][]byte {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	keys := [][]byte{m.secretKey}
+	if m.previousKey != nil && time.Now().Before(m.previousUntil) {
+		keys = append(keys, m.previousKey)
+	}
+	return keys
+}
+
 // GenerateToken creates new JWT token for user with default duration
 func (m *JWTManager) GenerateToken(user *User) (string, error) {
 	return m.GenerateTokenWithDuration(user, m.tokenDuration)
@@ -42,28 +77,54 @@ func (m *JWTManager) GenerateToken(user *User) (string, error) {
 
 // GenerateTokenWithDuration creates new JWT token for user with custom duration
 func (m *JWTManager) GenerateTokenWithDuration(user *User, duration time.Duration) (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+
 	claims := &Claims{
 		Username: user.Username,
 		UID:      user.UID,
 		Role:     user.Role,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        hex.EncodeToString(id),
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(duration)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Issuer:    "podmanview",
 		},
 	}
 
+	m.mu.RLock()
+	secretKey := m.secretKey
+	m.mu.RUnlock()
+
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(m.secretKey)
+	return token.SignedString(secretKey)
 }
 
-// ValidateToken validates JWT token and returns claims
+// ValidateToken validates JWT token and returns claims.
+// The current key is tried first, then the previous one during rotation.
 func (m *JWTManager) ValidateToken(tokenString string) (*Claims, error) {
+	var err error
+	for _, key := range m.keys() {
+		var claims *Claims
+		if claims, err = parseToken(tokenString, key); err == nil {
+			return claims, nil
+		}
+		if errors.Is(err, ErrExpiredToken) {
+			return nil, err
+		}
+	}
+	return nil, err
+}
+
+// parseToken verifies tokenString with key and returns its claims
+func parseToken(tokenString string, key []byte) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, ErrInvalidToken
 		}
-		return m.secretKey, nil
+		return key, nil
 	})
 
 	if err != nil {
@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// ldapTimeout limits connecting to and each request against the directory
+const ldapTimeout = 10 * time.Second
+
+// LDAPConfig describes the directory users are looked up in
+type LDAPConfig struct {
+	URL          string // ldap://host:389 or ldaps://host:636
+	StartTLS     bool   // upgrade ldap:// connections with StartTLS
+	BindDN       string // service account for the user search (empty = anonymous)
+	BindPassword string
+	BaseDN       string   // subtree searched for users
+	UserFilter   string   // %s is replaced with the escaped username
+	AdminGroups  []string // group DNs or CNs whose members get the admin role
+}
+
+// LDAPAuth authenticates against an LDAP directory: it finds the user's
+// entry with the service account, then binds as that entry with the password
+type LDAPAuth struct {
+	config LDAPConfig
+}
+
+// NewLDAPAuth creates new LDAP authenticator
+func NewLDAPAuth(config LDAPConfig) *LDAPAuth {
+	return &LDAPAuth{config: config}
+}
+
+// Authenticate verifies username and password with a bind as the user's entry
+func (a *LDAPAuth) Authenticate(username, password string) (*User, error) {
+	// An empty password would be an unauthenticated bind, which succeeds
+	if username == "" || password == "" {
+		return nil, errors.New("username and password are required")
+	}
+
+	conn, err := a.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if a.config.BindDN != "" {
+		if err := conn.Bind(a.config.BindDN, a.config.BindPassword); err != nil {
+			return nil, fmt.Errorf("LDAP service bind failed: %w", err)
+		}
+	} else if err := conn.UnauthenticatedBind(""); err != nil {
+		return nil, fmt.Errorf("LDAP anonymous bind failed: %w", err)
+	}
+
+	filter := strings.ReplaceAll(a.config.UserFilter, "%s", ldap.EscapeFilter(username))
+	result, err := conn.Search(ldap.NewSearchRequest(
+		a.config.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, int(ldapTimeout.Seconds()), false,
+		filter, []string{"memberOf", "uidNumber", "gidNumber"}, nil,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("LDAP user search failed: %w", err)
+	}
+	if len(result.Entries) != 1 {
+		return nil, fmt.Errorf("LDAP user search returned %d entries", len(result.Entries))
+	}
+	entry := result.Entries[0]
+
+	if err := conn.Bind(entry.DN, password); err != nil {
+		return nil, fmt.Errorf("authentication failed: %w", err)
+	}
+
+	return &User{
+		Username: username,
+		UID:      entry.GetAttributeValue("uidNumber"),
+		GID:      entry.GetAttributeValue("gidNumber"),
+		Role:     a.determineRole(entry.GetAttributeValues("memberOf")),
+	}, nil
+}
+
+// dial connects to the directory, upgrading to TLS if configured
+func (a *LDAPAuth) dial() (*ldap.Conn, error) {
+	conn, err := ldap.DialURL(a.config.URL, ldap.DialWithDialer(&net.Dialer{Timeout: ldapTimeout}))
+	if err != nil {
+		return nil, fmt.Errorf("LDAP connect failed: %w", err)
+	}
+	conn.SetTimeout(ldapTimeout)
+
+	if a.config.StartTLS {
+		var host string
+		if u, err := url.Parse(a.config.URL); err == nil {
+			host = u.Hostname()
+		}
+		if err := conn.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("LDAP StartTLS failed: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+// determineRole checks the user's groups (memberOf DNs) against the admin
+// groups, given as full DNs or as the group's CN
+func (a *LDAPAuth) determineRole(groups []string) Role {
+	for _, group := range groups {
+		dn, err := ldap.ParseDN(group)
+		if err != nil {
+			continue
+		}
+		var cn string
+		if len(dn.RDNs) > 0 && len(dn.RDNs[0].Attributes) > 0 && strings.EqualFold(dn.RDNs[0].Attributes[0].Type, "cn") {
+			cn = dn.RDNs[0].Attributes[0].Value
+		}
+
+		for _, adminGroup := range a.config.AdminGroups {
+			if strings.EqualFold(adminGroup, cn) {
+				return RoleAdmin
+			}
+			if adminDN, err := ldap.ParseDN(adminGroup); err == nil && adminDN.EqualFold(dn) {
+				return RoleAdmin
+			}
+		}
+	}
+	return RoleReadOnly
+}
@@ -2,16 +2,26 @@ package auth
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
+	"sync/atomic"
+	"time"
 )
 
 type contextKey string
 
 const (
-	UserContextKey contextKey = "user"
-	CookieName     string     = "podmanview_token"
+	UserContextKey    contextKey = "user"
+	SessionContextKey contextKey = "session"
+	CookieName        string     = "podmanview_token"
 )
 
+// Session identifies the login (JWT) a request was authenticated with
+type Session struct {
+	ID        string    // JWT ID; empty for tokens issued without one
+	ExpiresAt time.Time // zero when unknown (no-auth mode)
+}
+
 // Middleware handles authentication for protected routes
 type Middleware struct {
 	jwtManager *JWTManager
@@ -28,7 +38,7 @@ func (m *Middleware) RequireAuth(next http.Handler) http.Handler {
 		// Try to get token from cookie
 		cookie, err := r.Cookie(CookieName)
 		if err != nil {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			writeError(w, http.StatusUnauthorized, "unauthorized", "Unauthorized")
 			return
 		}
 
@@ -43,7 +53,7 @@ func (m *Middleware) RequireAuth(next http.Handler) http.Handler {
 				MaxAge:   -1,
 				HttpOnly: true,
 			})
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			writeError(w, http.StatusUnauthorized, "unauthorized", "Unauthorized")
 			return
 		}
 
@@ -53,7 +63,12 @@ func (m *Middleware) RequireAuth(next http.Handler) http.Handler {
 			UID:      claims.UID,
 			Role:     claims.Role,
 		}
+		session := Session{ID: claims.ID}
+		if claims.ExpiresAt != nil {
+			session.ExpiresAt = claims.ExpiresAt.Time
+		}
 		ctx := context.WithValue(r.Context(), UserContextKey, user)
+		ctx = context.WithValue(ctx, SessionContextKey, session)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -63,12 +78,12 @@ func (m *Middleware) RequireAdmin(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		user := GetUserFromContext(r.Context())
 		if user == nil {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			writeError(w, http.StatusUnauthorized, "unauthorized", "Unauthorized")
 			return
 		}
 
 		if !user.IsAdmin() {
-			http.Error(w, "Forbidden: admin access required", http.StatusForbidden)
+			writeError(w, http.StatusForbidden, "forbidden", "Forbidden: admin access required")
 			return
 		}
 
@@ -76,6 +91,37 @@ func (m *Middleware) RequireAdmin(next http.Handler) http.Handler {
 	})
 }
 
+// RequireAction returns middleware that allows only users permitted
+// to perform action by the policy (see Allowed)
+func RequireAction(action Action) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user := GetUserFromContext(r.Context())
+			if user == nil {
+				writeError(w, http.StatusUnauthorized, "unauthorized", "Not authenticated")
+				return
+			}
+
+			if !user.Can(action) {
+				writeError(w, http.StatusForbidden, "forbidden", "Permission denied: "+string(action)+" requires admin access")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// writeError writes a JSON error response in the same
+// {"error": {code, message}} envelope as the API handlers
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]map[string]string{
+		"error": {"code": code, "message": message},
+	})
+}
+
 // GetUserFromContext extracts user from request context
 func GetUserFromContext(ctx context.Context) *User {
 	user, ok := ctx.Value(UserContextKey).(*User)
@@ -85,16 +131,49 @@ func GetUserFromContext(ctx context.Context) *User {
 	return user
 }
 
+// GetSessionFromContext returns the login session of the request
+// (empty when unauthenticated or in no-auth mode)
+func GetSessionFromContext(ctx context.Context) Session {
+	session, _ := ctx.Value(SessionContextKey).(Session)
+	return session
+}
+
 // SetUserContext adds user to context
 func SetUserContext(ctx context.Context, user *User) context.Context {
 	return context.WithValue(ctx, UserContextKey, user)
 }
 
+// secureCookies forces the Secure flag on auth cookies
+var secureCookies atomic.Bool
+
+// SetSecureCookies forces the Secure flag on auth cookies regardless of
+// how a request arrived. Enabled when the server listens with TLS.
+func SetSecureCookies(enabled bool) {
+	secureCookies.Store(enabled)
+}
+
+// crossSiteCookies sends auth cookies on cross-site requests
+var crossSiteCookies atomic.Bool
+
+// SetCrossSiteCookies issues auth cookies as SameSite=None, so frontends
+// on another site allowed by CORS can make credentialed requests.
+// Enabled when CORS origins are configured.
+func SetCrossSiteCookies(enabled bool) {
+	crossSiteCookies.Store(enabled)
+}
+
 // SetAuthCookie sets JWT token in HttpOnly cookie
-// Automatically sets Secure flag when request is over HTTPS
+// Sets Secure flag when TLS is active or request is over HTTPS. The cookie
+// is SameSite=Strict, or SameSite=None with cross-site cookies enabled,
+// which browsers only accept on a Secure cookie.
 func SetAuthCookie(w http.ResponseWriter, r *http.Request, token string, maxAge int) {
 	// Determine if request came over HTTPS (direct TLS or via reverse proxy)
-	secure := r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https"
+	secure := secureCookies.Load() || r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https"
+
+	sameSite := http.SameSiteStrictMode
+	if crossSiteCookies.Load() && secure {
+		sameSite = http.SameSiteNoneMode
+	}
 
 	http.SetCookie(w, &http.Cookie{
 		Name:     CookieName,
@@ -102,7 +181,7 @@ func SetAuthCookie(w http.ResponseWriter, r *http.Request, token string, maxAge
 		Path:     "/",
 		MaxAge:   maxAge,
 		HttpOnly: true,
-		SameSite: http.SameSiteStrictMode,
+		SameSite: sameSite,
 		Secure:   secure,
 	})
 }
@@ -0,0 +1,227 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// OIDCStateCookie keeps the state, nonce and PKCE verifier of a login
+// between the redirect to the provider and the callback
+const OIDCStateCookie = "podmanview_oidc"
+
+// OIDCUserPrefix namespaces single sign-on users, so they never share the
+// history and bookmarks of a local PAM or LDAP user of the same name
+const OIDCUserPrefix = "oidc:"
+
+const (
+	oidcStateMaxAge = 10 * 60 // seconds to complete the login at the provider
+	oidcTimeout     = 15 * time.Second
+)
+
+// OIDCConfig describes the OpenID Connect provider and client
+type OIDCConfig struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string   // .../api/auth/oidc/callback as registered at the provider
+	Scopes       []string // openid is always requested
+	RoleClaim    string   // claim holding groups or roles, dots for nested claims (realm_access.roles)
+	AdminValues  []string // values of RoleClaim that grant the admin role
+}
+
+// OIDCProvider logs users in through an external OpenID Connect provider
+// (Authentik, Keycloak, ...). The provider is discovered on first use, so
+// PodmanView starts even when it is unreachable.
+type OIDCProvider struct {
+	config OIDCConfig
+
+	mu       sync.Mutex
+	oauth    *oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+// NewOIDCProvider creates new OIDC login provider
+func NewOIDCProvider(config OIDCConfig) *OIDCProvider {
+	return &OIDCProvider{config: config}
+}
+
+// discover fetches the provider's endpoints and keys once
+func (p *OIDCProvider) discover(ctx context.Context) (*oauth2.Config, *oidc.IDTokenVerifier, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.oauth != nil {
+		return p.oauth, p.verifier, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, oidcTimeout)
+	defer cancel()
+	provider, err := oidc.NewProvider(ctx, p.config.Issuer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("OIDC discovery failed: %w", err)
+	}
+
+	scopes := []string{oidc.ScopeOpenID}
+	for _, scope := range p.config.Scopes {
+		if scope != oidc.ScopeOpenID {
+			scopes = append(scopes, scope)
+		}
+	}
+	p.oauth = &oauth2.Config{
+		ClientID:     p.config.ClientID,
+		ClientSecret: p.config.ClientSecret,
+		Endpoint:     provider.Endpoint(),
+		RedirectURL:  p.config.RedirectURL,
+		Scopes:       scopes,
+	}
+	p.verifier = provider.Verifier(&oidc.Config{ClientID: p.config.ClientID})
+	return p.oauth, p.verifier, nil
+}
+
+// LoginURL starts a login: it stores a fresh state, nonce and PKCE
+// verifier in a short-lived cookie and returns the provider's login URL
+func (p *OIDCProvider) LoginURL(w http.ResponseWriter, r *http.Request) (string, error) {
+	oauth, _, err := p.discover(r.Context())
+	if err != nil {
+		return "", err
+	}
+
+	state, nonce := randomToken(), randomToken()
+	verifier := oauth2.GenerateVerifier()
+	p.setStateCookie(w, r, strings.Join([]string{state, nonce, verifier}, "."), oidcStateMaxAge)
+
+	return oauth.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier)), nil
+}
+
+// Callback completes a login: it checks the state, exchanges the code,
+// verifies the ID token and maps its claims to a user
+func (p *OIDCProvider) Callback(w http.ResponseWriter, r *http.Request) (*User, error) {
+	cookie, err := r.Cookie(OIDCStateCookie)
+	p.setStateCookie(w, r, "", -1) // single use
+	if err != nil {
+		return nil, errors.New("login state missing or expired")
+	}
+	parts := strings.Split(cookie.Value, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("invalid login state")
+	}
+	state, nonce, verifier := parts[0], parts[1], parts[2]
+
+	query := r.URL.Query()
+	if subtle.ConstantTimeCompare([]byte(query.Get("state")), []byte(state)) != 1 {
+		return nil, errors.New("login state mismatch")
+	}
+	if e := query.Get("error"); e != "" {
+		return nil, fmt.Errorf("provider returned %s: %s", e, query.Get("error_description"))
+	}
+
+	oauth, idVerifier, err := p.discover(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), oidcTimeout)
+	defer cancel()
+	token, err := oauth.Exchange(ctx, query.Get("code"), oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("code exchange failed: %w", err)
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, errors.New("no ID token in token response")
+	}
+	idToken, err := idVerifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID token: %w", err)
+	}
+	if subtle.ConstantTimeCompare([]byte(idToken.Nonce), []byte(nonce)) != 1 {
+		return nil, errors.New("ID token nonce mismatch")
+	}
+
+	var claims map[string]any
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("invalid ID token claims: %w", err)
+	}
+	return p.userFromClaims(idToken.Subject, claims), nil
+}
+
+// userFromClaims names the user after preferred_username, email or the
+// subject behind OIDCUserPrefix and makes them admin if the role claim
+// holds an admin value
+func (p *OIDCProvider) userFromClaims(subject string, claims map[string]any) *User {
+	name := subject
+	for _, claim := range []string{"preferred_username", "email"} {
+		if v, ok := claims[claim].(string); ok && v != "" {
+			name = v
+			break
+		}
+	}
+	username := OIDCUserPrefix + name
+
+	role := RoleReadOnly
+	for _, value := range claimValues(claims, p.config.RoleClaim) {
+		if slices.Contains(p.config.AdminValues, value) {
+			role = RoleAdmin
+			break
+		}
+	}
+	return &User{Username: username, Role: role}
+}
+
+// claimValues returns the string or strings at a dotted claim path
+func claimValues(claims map[string]any, path string) []string {
+	var value any = claims
+	for _, key := range strings.Split(path, ".") {
+		m, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = m[key]
+	}
+
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		var values []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// setStateCookie sets or clears the login state cookie. SameSite=Lax
+// so it comes along when the provider redirects back.
+func (p *OIDCProvider) setStateCookie(w http.ResponseWriter, r *http.Request, value string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     OIDCStateCookie,
+		Value:    value,
+		Path:     "/api/auth/oidc",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+		Secure:   secureCookies.Load() || r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+	})
+}
+
+// randomToken returns 32 random bytes, base64url encoded
+func randomToken() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
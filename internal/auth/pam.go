@@ -2,7 +2,9 @@ package auth
 
 import (
 	"fmt"
+	"os"
 	"os/user"
+	"strings"
 
 	"github.com/msteinert/pam"
 )
@@ -25,15 +27,17 @@ type User struct {
 
 // PAMAuth handles PAM authentication
 type PAMAuth struct {
-	serviceName string
-	adminGroups []string
+	serviceName       string
+	passwdServiceName string // PAM service for password changes
+	adminGroups       []string
 }
 
 // NewPAMAuth creates new PAM authenticator
 func NewPAMAuth() *PAMAuth {
 	return &PAMAuth{
-		serviceName: "login",
-		adminGroups: []string{"wheel", "sudo", "root", "admin"},
+		serviceName:       "login",
+		passwdServiceName: "passwd",
+		adminGroups:       []string{"wheel", "sudo", "root", "admin"},
 	}
 }
 
@@ -84,6 +88,43 @@ func (p *PAMAuth) Authenticate(username, password string) (*User, error) {
 	}, nil
 }
 
+// ChangePassword changes username's password with the PAM chauthtok flow
+// of the passwd service. Running as root, PAM only asks for the new
+// password; otherwise it asks for the current one first. The prompts are
+// answered by their order, as their text depends on the locale.
+func (p *PAMAuth) ChangePassword(username, currentPassword, newPassword string) error {
+	// Messages from PAM modules, e.g. pam_pwquality's reason for a weak password
+	var messages []string
+	askCurrent := os.Geteuid() != 0
+	t, err := pam.StartFunc(p.passwdServiceName, username, func(s pam.Style, msg string) (string, error) {
+		switch s {
+		case pam.PromptEchoOff:
+			if askCurrent {
+				askCurrent = false
+				return currentPassword, nil
+			}
+			return newPassword, nil
+		case pam.PromptEchoOn:
+			return username, nil
+		case pam.ErrorMsg, pam.TextInfo:
+			messages = append(messages, strings.TrimSpace(msg))
+			return "", nil
+		}
+		return "", fmt.Errorf("unrecognized PAM message style: %v", s)
+	})
+	if err != nil {
+		return fmt.Errorf("PAM start failed: %w", err)
+	}
+
+	if err := t.ChangeAuthTok(0); err != nil {
+		if len(messages) > 0 {
+			return fmt.Errorf("%w: %s", ErrPasswordRejected, strings.Join(messages, "; "))
+		}
+		return fmt.Errorf("password change failed: %w", err)
+	}
+	return nil
+}
+
 // determineRole checks if user is admin based on group membership
 func (p *PAMAuth) determineRole(username string) Role {
 	u, err := user.Lookup(username)
@@ -26,15 +26,17 @@ type User struct {
 
 // PAMAuth handles PAM authentication (stub for non-Linux platforms)
 type PAMAuth struct {
-	serviceName string
-	adminGroups []string
+	serviceName       string
+	passwdServiceName string
+	adminGroups       []string
 }
 
 // NewPAMAuth creates new PAM authenticator (stub for non-Linux platforms)
 func NewPAMAuth() *PAMAuth {
 	return &PAMAuth{
-		serviceName: "login",
-		adminGroups: []string{"wheel", "sudo", "root", "admin"},
+		serviceName:       "login",
+		passwdServiceName: "passwd",
+		adminGroups:       []string{"wheel", "sudo", "root", "admin"},
 	}
 }
 
@@ -43,6 +45,11 @@ func (p *PAMAuth) Authenticate(username, password string) (*User, error) {
 	return nil, fmt.Errorf("PAM authentication is not supported on this platform (Linux only)")
 }
 
+// ChangePassword returns error on non-Linux platforms
+func (p *PAMAuth) ChangePassword(username, currentPassword, newPassword string) error {
+	return fmt.Errorf("PAM password changes are not supported on this platform (Linux only)")
+}
+
 // determineRole checks if user is admin based on group membership
 func (p *PAMAuth) determineRole(username string) Role {
 	u, err := user.Lookup(username)
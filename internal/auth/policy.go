@@ -0,0 +1,105 @@
+package auth
+
+// Action represents an operation subject to authorization
+type Action string
+
+const (
+	// Read-only access (lists, inspect, logs, dashboards, streams)
+	ActionView Action = "view"
+
+	// Containers
+	ActionManageContainers Action = "manage_containers" // create/start/stop/restart
+	ActionDeleteContainers Action = "delete_containers"
+	ActionExec             Action = "exec"          // container terminal
+	ActionInstallUnits     Action = "install_units" // write systemd units to the host
+
+	// Images
+	ActionPullImages   Action = "pull_images"
+	ActionDeleteImages Action = "delete_images"
+	ActionScanImages   Action = "scan_images" // runs an external scanner
+
+	// Volumes
+	ActionDeleteVolumes Action = "delete_volumes"
+
+	// Host
+	ActionHostTerminal Action = "host_terminal"
+	ActionSystemLogs   Action = "system_logs" // systemd journal
+	ActionManageFiles  Action = "manage_files"
+	ActionPowerSystem  Action = "power_system" // reboot/shutdown
+	ActionUpdateSystem Action = "update_system"
+
+	// Settings and plugins
+	ActionChangeSettings Action = "change_settings"
+	ActionManagePlugins  Action = "manage_plugins"
+	ActionManageUsers    Action = "manage_users" // change other users' passwords
+)
+
+// policy maps each action to the roles allowed to perform it.
+// This is the single source of truth for handler checks and
+// the capabilities endpoint.
+var policy = map[Action][]Role{
+	ActionView:             {RoleAdmin, RoleReadOnly},
+	ActionManageContainers: {RoleAdmin},
+	ActionDeleteContainers: {RoleAdmin},
+	ActionExec:             {RoleAdmin},
+	ActionInstallUnits:     {RoleAdmin},
+	ActionPullImages:       {RoleAdmin},
+	ActionDeleteImages:     {RoleAdmin},
+	ActionScanImages:       {RoleAdmin},
+	ActionDeleteVolumes:    {RoleAdmin},
+	ActionHostTerminal:     {RoleAdmin},
+	ActionSystemLogs:       {RoleAdmin},
+	ActionManageFiles:      {RoleAdmin},
+	ActionPowerSystem:      {RoleAdmin},
+	ActionUpdateSystem:     {RoleAdmin},
+	ActionChangeSettings:   {RoleAdmin},
+	ActionManagePlugins:    {RoleAdmin},
+	ActionManageUsers:      {RoleAdmin},
+}
+
+// actionOrder keeps a stable order for API responses
+var actionOrder = []Action{
+	ActionView,
+	ActionManageContainers,
+	ActionDeleteContainers,
+	ActionExec,
+	ActionInstallUnits,
+	ActionPullImages,
+	ActionDeleteImages,
+	ActionScanImages,
+	ActionDeleteVolumes,
+	ActionHostTerminal,
+	ActionSystemLogs,
+	ActionManageFiles,
+	ActionPowerSystem,
+	ActionUpdateSystem,
+	ActionChangeSettings,
+	ActionManagePlugins,
+	ActionManageUsers,
+}
+
+// AllActions returns all known actions in a stable order
+func AllActions() []Action {
+	result := make([]Action, len(actionOrder))
+	copy(result, actionOrder)
+	return result
+}
+
+// Allowed reports whether role may perform action.
+// Unknown actions are denied.
+func Allowed(role Role, action Action) bool {
+	for _, r := range policy[action] {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Can reports whether the user may perform action (nil user can't)
+func (u *User) Can(action Action) bool {
+	if u == nil {
+		return false
+	}
+	return Allowed(u.Role, action)
+}
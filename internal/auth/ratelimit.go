@@ -13,6 +13,8 @@ type LoginRateLimiter struct {
 	maxAttempts int           // Max attempts before blocking
 	window      time.Duration // Time window for counting attempts
 	blockTime   time.Duration // How long to block after max attempts
+
+	onLockout func(ip string, attempts int) // called when an IP gets blocked
 }
 
 type ipAttempts struct {
@@ -38,9 +40,31 @@ func NewLoginRateLimiter() *LoginRateLimiter {
 	return rl
 }
 
+// SetLockoutHandler sets fn to be called once each time an IP crosses
+// the attempt limit and gets blocked, with the number of attempts
+func (rl *LoginRateLimiter) SetLockoutHandler(fn func(ip string, attempts int)) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.onLockout = fn
+}
+
+// BlockTime returns how long an IP stays blocked
+func (rl *LoginRateLimiter) BlockTime() time.Duration {
+	return rl.blockTime
+}
+
 // Allow checks if IP is allowed to attempt login
 // Returns (allowed, remainingSeconds until unblock)
 func (rl *LoginRateLimiter) Allow(ip string) (bool, int) {
+	// The lockout handler runs after unlocking, so it may take its time
+	var lockedOut func(ip string, attempts int)
+	var attempts int
+	defer func() {
+		if lockedOut != nil {
+			lockedOut(ip, attempts)
+		}
+	}()
+
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 
@@ -83,6 +107,7 @@ func (rl *LoginRateLimiter) Allow(ip string) (bool, int) {
 	if att.count > rl.maxAttempts {
 		att.blocked = true
 		att.blockEnd = now.Add(rl.blockTime)
+		lockedOut, attempts = rl.onLockout, att.count
 		remaining := int(rl.blockTime.Seconds())
 		return false, remaining
 	}
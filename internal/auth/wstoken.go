@@ -8,7 +8,10 @@ import (
 )
 
 // WSTokenStore manages WebSocket CSRF tokens
-// Tokens are one-time use and expire after a short TTL
+// Tokens are one-time use and expire after a short TTL.
+// Renewable tokens (for long-lived streams) are also one-time use, but each
+// successful validation issues a fresh token for the next reconnect, until
+// the login session they were issued for expires or logs out.
 type WSTokenStore struct {
 	mu     sync.RWMutex
 	tokens map[string]*wsTokenEntry
@@ -17,11 +20,15 @@ type WSTokenStore struct {
 type wsTokenEntry struct {
 	username  string
 	createdAt time.Time
+	renewable bool
+	session   Session // login the token was issued for (renewable only)
 }
 
 const (
 	// WSTokenTTL is how long a token is valid
 	WSTokenTTL = 30 * time.Second
+	// WSRenewableTokenTTL is how long a renewable (stream) token is valid
+	WSRenewableTokenTTL = 1 * time.Hour
 	// WSTokenLength is the byte length of the token (will be hex encoded to 2x)
 	WSTokenLength = 32
 )
@@ -38,6 +45,17 @@ func NewWSTokenStore() *WSTokenStore {
 
 // Generate creates a new one-time token for a user
 func (s *WSTokenStore) Generate(username string) (string, error) {
+	return s.generate(username, false, Session{})
+}
+
+// GenerateRenewable creates a new renewable token for a user (streams only).
+// It and the tokens it is renewed into are valid only until the session expires.
+func (s *WSTokenStore) GenerateRenewable(username string, session Session) (string, error) {
+	return s.generate(username, true, session)
+}
+
+// generate creates and stores a new token
+func (s *WSTokenStore) generate(username string, renewable bool, session Session) (string, error) {
 	bytes := make([]byte, WSTokenLength)
 	if _, err := rand.Read(bytes); err != nil {
 		return "", err
@@ -48,6 +66,8 @@ func (s *WSTokenStore) Generate(username string) (string, error) {
 	s.tokens[token] = &wsTokenEntry{
 		username:  username,
 		createdAt: time.Now(),
+		renewable: renewable,
+		session:   session,
 	}
 	s.mu.Unlock()
 
@@ -56,24 +76,85 @@ func (s *WSTokenStore) Generate(username string) (string, error) {
 
 // Validate checks if a token is valid and consumes it (one-time use)
 // Returns the username associated with the token, or empty string if invalid
+// Renewable tokens are rejected - terminals always require a one-time token
 func (s *WSTokenStore) Validate(token string) (string, bool) {
+	entry, ok := s.consume(token)
+	if !ok || entry.renewable {
+		return "", false
+	}
+	return entry.username, true
+}
+
+// ValidateAndRenew checks a token for a stream connection and consumes it.
+// On success it returns the username and a fresh renewable token that the
+// client can use for its next reconnect. One-time (terminal) tokens are
+// rejected, and the fresh token belongs to the same session.
+func (s *WSTokenStore) ValidateAndRenew(token string) (string, string, bool) {
+	entry, ok := s.consume(token)
+	if !ok || !entry.renewable {
+		return "", "", false
+	}
+
+	next, err := s.GenerateRenewable(entry.username, entry.session)
+	if err != nil {
+		return "", "", false
+	}
+
+	return entry.username, next, true
+}
+
+// consume removes a token from the store and returns it if not expired
+func (s *WSTokenStore) consume(token string) (*wsTokenEntry, bool) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	entry, exists := s.tokens[token]
 	if !exists {
-		return "", false
+		return nil, false
 	}
 
 	// Delete token immediately (one-time use)
 	delete(s.tokens, token)
 
 	// Check if expired
-	if time.Since(entry.createdAt) > WSTokenTTL {
-		return "", false
+	if entry.expired(time.Now()) {
+		return nil, false
 	}
 
-	return entry.username, true
+	return entry, true
+}
+
+// RevokeSession removes the renewable tokens of a session, so streams
+// opened with it can't reconnect after logout
+func (s *WSTokenStore) RevokeSession(id string) {
+	if id == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for token, entry := range s.tokens {
+		if entry.session.ID == id {
+			delete(s.tokens, token)
+		}
+	}
+}
+
+// expired reports whether the token's TTL or its session has run out
+func (e *wsTokenEntry) expired(now time.Time) bool {
+	if now.Sub(e.createdAt) > e.ttl() {
+		return true
+	}
+	return !e.session.ExpiresAt.IsZero() && now.After(e.session.ExpiresAt)
+}
+
+// ttl returns how long the token is valid
+func (e *wsTokenEntry) ttl() time.Duration {
+	if e.renewable {
+		return WSRenewableTokenTTL
+	}
+	return WSTokenTTL
 }
 
 // cleanupLoop periodically removes expired tokens
@@ -93,7 +174,7 @@ func (s *WSTokenStore) cleanup() {
 
 	now := time.Now()
 	for token, entry := range s.tokens {
-		if now.Sub(entry.createdAt) > WSTokenTTL {
+		if entry.expired(now) {
 			delete(s.tokens, token)
 		}
 	}
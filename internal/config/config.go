@@ -6,42 +6,140 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"net/url"
 	"os"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"podmanview/internal/netutil"
 )
 
 // Environment variable names
 const (
-	EnvAddr          = "PODMANVIEW_ADDR"
-	EnvJWTSecret     = "PODMANVIEW_JWT_SECRET"
-	EnvJWTExpiration = "PODMANVIEW_JWT_EXPIRATION"
-	EnvNoAuth        = "PODMANVIEW_NO_AUTH"
-	EnvSocket        = "PODMANVIEW_SOCKET"
+	EnvAddr           = "PODMANVIEW_ADDR"
+	EnvAddrMode       = "PODMANVIEW_ADDR_MODE"
+	EnvDevDir         = "PODMANVIEW_DEV_DIR"
+	EnvDataDir        = "PODMANVIEW_DATA_DIR"
+	EnvPprof          = "PODMANVIEW_PPROF"
+	EnvHostProc       = "PODMANVIEW_HOST_PROC"
+	EnvStatsInterval  = "PODMANVIEW_STATS_INTERVAL"
+	EnvPluginStop     = "PODMANVIEW_PLUGIN_STOP_TIMEOUT"
+	EnvPluginHTTP     = "PODMANVIEW_PLUGIN_HTTP_TIMEOUT"
+	EnvHTTPProxy      = "PODMANVIEW_HTTP_PROXY"
+	EnvJSONMaxBytes   = "PODMANVIEW_JSON_MAX_BYTES"
+	EnvCompressLevel  = "PODMANVIEW_COMPRESS_LEVEL"
+	EnvCompressTypes  = "PODMANVIEW_COMPRESS_TYPES"
+	EnvJWTSecret      = "PODMANVIEW_JWT_SECRET"
+	EnvJWTExpiration  = "PODMANVIEW_JWT_EXPIRATION"
+	EnvJWTGracePeriod = "PODMANVIEW_JWT_GRACE_PERIOD"
+	EnvJWTPrevSecret  = "PODMANVIEW_JWT_PREVIOUS_SECRET"
+	EnvJWTPrevUntil   = "PODMANVIEW_JWT_PREVIOUS_UNTIL"
+	EnvNoAuth         = "PODMANVIEW_NO_AUTH"
+	EnvAuthBackend    = "PODMANVIEW_AUTH_BACKEND"
+	EnvTLSCert        = "PODMANVIEW_TLS_CERT"
+	EnvTLSKey         = "PODMANVIEW_TLS_KEY"
+	EnvTLSSelfSigned  = "PODMANVIEW_TLS_SELF_SIGNED"
+	EnvHTTPRedirect   = "PODMANVIEW_HTTP_REDIRECT_ADDR"
+	EnvCSP            = "PODMANVIEW_CSP"
+	EnvFrameAncestors = "PODMANVIEW_FRAME_ANCESTORS"
+	EnvCORSOrigins    = "PODMANVIEW_CORS_ORIGINS"
+	EnvPluginSandbox  = "PODMANVIEW_PLUGIN_SANDBOX"
+	EnvTrustedProxies = "PODMANVIEW_TRUSTED_PROXIES"
+	EnvReverseDNS     = "PODMANVIEW_REVERSE_DNS"
+	EnvTerminalAllow  = "PODMANVIEW_TERMINAL_ALLOW"
+	EnvTerminalDeny   = "PODMANVIEW_TERMINAL_DENY"
+	EnvSocket         = "PODMANVIEW_SOCKET"
+	EnvPodmanTimeout  = "PODMANVIEW_PODMAN_TIMEOUT"
+	EnvPodmanRetries  = "PODMANVIEW_PODMAN_RETRIES"
+	EnvLogMaxBytes    = "PODMANVIEW_LOG_MAX_BYTES"
+	EnvLogTimezone    = "PODMANVIEW_LOG_TIMEZONE"
+	EnvImageScanner   = "PODMANVIEW_IMAGE_SCANNER"
 	// MQTT settings
-	EnvMQTTBroker   = "PODMANVIEW_MQTT_BROKER"
-	EnvMQTTClientID = "PODMANVIEW_MQTT_CLIENT_ID"
-	EnvMQTTUsername = "PODMANVIEW_MQTT_USERNAME"
-	EnvMQTTPassword = "PODMANVIEW_MQTT_PASSWORD"
-	EnvMQTTPrefix   = "PODMANVIEW_MQTT_PREFIX"
-	EnvMQTTUseTLS   = "PODMANVIEW_MQTT_USE_TLS"
+	EnvMQTTBroker       = "PODMANVIEW_MQTT_BROKER"
+	EnvMQTTClientID     = "PODMANVIEW_MQTT_CLIENT_ID"
+	EnvMQTTUsername     = "PODMANVIEW_MQTT_USERNAME"
+	EnvMQTTPassword     = "PODMANVIEW_MQTT_PASSWORD"
+	EnvMQTTPrefix       = "PODMANVIEW_MQTT_PREFIX"
+	EnvMQTTUseTLS       = "PODMANVIEW_MQTT_USE_TLS"
+	EnvMQTTKeepAlive    = "PODMANVIEW_MQTT_KEEPALIVE"
+	EnvMQTTReconnectMax = "PODMANVIEW_MQTT_RECONNECT_MAX"
+	EnvMQTTDeviceID     = "PODMANVIEW_MQTT_DEVICE_ID"
+	EnvMQTTDeviceName   = "PODMANVIEW_MQTT_DEVICE_NAME"
+	// LDAP settings
+	EnvLDAPURL          = "PODMANVIEW_LDAP_URL"
+	EnvLDAPStartTLS     = "PODMANVIEW_LDAP_STARTTLS"
+	EnvLDAPBindDN       = "PODMANVIEW_LDAP_BIND_DN"
+	EnvLDAPBindPassword = "PODMANVIEW_LDAP_BIND_PASSWORD"
+	EnvLDAPBaseDN       = "PODMANVIEW_LDAP_BASE_DN"
+	EnvLDAPUserFilter   = "PODMANVIEW_LDAP_USER_FILTER"
+	EnvLDAPAdminGroups  = "PODMANVIEW_LDAP_ADMIN_GROUPS"
+	// OIDC settings
+	EnvOIDCIssuer       = "PODMANVIEW_OIDC_ISSUER"
+	EnvOIDCClientID     = "PODMANVIEW_OIDC_CLIENT_ID"
+	EnvOIDCClientSecret = "PODMANVIEW_OIDC_CLIENT_SECRET"
+	EnvOIDCRedirectURL  = "PODMANVIEW_OIDC_REDIRECT_URL"
+	EnvOIDCScopes       = "PODMANVIEW_OIDC_SCOPES"
+	EnvOIDCRoleClaim    = "PODMANVIEW_OIDC_ROLE_CLAIM"
+	EnvOIDCAdminValues  = "PODMANVIEW_OIDC_ADMIN_VALUES"
 )
 
 // Default values
 const (
-	DefaultAddr          = ":80"
-	DefaultJWTExpiration = 24 * time.Hour
-	DefaultNoAuth        = false
-	DefaultSocket        = "" // auto-detect
+	DefaultAddr           = ":80"
+	DefaultAddrMode       = 0660 // Unix socket permissions
+	DefaultDevDir         = ""   // embedded assets
+	DefaultDataDir        = ""   // working directory
+	DefaultPprof          = false
+	DefaultHostProc       = "" // /host/proc if mounted, otherwise /proc
+	DefaultStatsInterval  = 2 * time.Second
+	DefaultPluginStop     = 5 * time.Second
+	DefaultPluginHTTP     = 15 * time.Second
+	DefaultHTTPProxy      = ""               // HTTP_PROXY/HTTPS_PROXY from the environment
+	DefaultJSONMaxBytes   = 16 * 1024 * 1024 // fits a file at the editor limit after JSON escaping
+	DefaultCompressLevel  = 5                // gzip level, 0 disables compression
+	DefaultCompressTypes  = "text/html,text/css,text/plain,text/javascript,application/javascript,application/json,image/svg+xml"
+	DefaultJWTExpiration  = 24 * time.Hour
+	DefaultJWTGracePeriod = 24 * time.Hour // previous secret stays valid after rotation
+	DefaultNoAuth         = false
+	DefaultAuthBackend    = "pam"
+	DefaultTLSSelfSigned  = false
+	DefaultHTTPRedirect   = "" // disabled
+	DefaultCSP            = "" // built-in policy
+	DefaultFrameAncestors = "'self'"
+	DefaultCORSOrigins    = "" // disabled, the API is only used by its own UI
+	DefaultPluginSandbox  = false
+	DefaultTrustedProxies = "127.0.0.1,::1" // reverse proxy on the same host
+	DefaultReverseDNS     = false
+	DefaultTerminalAllow  = "" // no restriction
+	DefaultTerminalDeny   = ""
+	DefaultSocket         = "" // auto-detect
+	DefaultPodmanTimeout  = 30 * time.Second
+	DefaultPodmanRetries  = 3
+	DefaultLogMaxBytes    = 5 * 1024 * 1024 // 5 MiB per logs response
+	DefaultLogTimezone    = ""              // server's local time zone
+	DefaultImageScanner   = ""              // auto-detect trivy/grype
 	// MQTT defaults
-	DefaultMQTTBroker   = ""
-	DefaultMQTTClientID = ""
-	DefaultMQTTUsername = ""
-	DefaultMQTTPassword = ""
-	DefaultMQTTPrefix   = "podmanview"
-	DefaultMQTTUseTLS   = false
+	DefaultMQTTBroker       = ""
+	DefaultMQTTClientID     = ""
+	DefaultMQTTUsername     = ""
+	DefaultMQTTPassword     = ""
+	DefaultMQTTPrefix       = "podmanview"
+	DefaultMQTTUseTLS       = false
+	DefaultMQTTKeepAlive    = 30 * time.Second
+	DefaultMQTTReconnectMax = 10 * time.Second
+	DefaultMQTTDeviceID     = "" // host name
+	DefaultMQTTDeviceName   = "" // PodmanView <host name>
+	// LDAP defaults
+	DefaultLDAPUserFilter  = "(uid=%s)"
+	DefaultLDAPAdminGroups = ""
+	// OIDC defaults
+	DefaultOIDCScopes      = "openid,profile,email"
+	DefaultOIDCRoleClaim   = "groups"
+	DefaultOIDCAdminValues = ""
 )
 
 // Config holds all application configuration.
@@ -52,23 +150,95 @@ type Config struct {
 	dirty    bool // tracks if config was modified
 
 	// Server settings
-	addr string
+	addr     string
+	addrMode os.FileMode // permissions of a unix: listen socket
+	devDir   string      // source checkout to serve web assets and plugin HTML from
+	dataDir  string      // application database location
+	pprof    bool        // serve /debug/pprof/ to admins
+	hostProc string      // host's /proc when running in a container
+
+	// Host stats settings
+	statsInterval time.Duration // CPU usage sampling period
+
+	// Plugin settings
+	pluginStopTimeout time.Duration // per plugin, on shutdown
+	pluginHTTPTimeout time.Duration // outbound requests by plugins
+	httpProxy         string
+
+	// Request settings
+	jsonMaxBytes  int64    // JSON request bodies, after gzip decoding
+	compressLevel int      // gzip level of responses, 0 = off
+	compressTypes []string // content types that are compressed
 
 	// Security settings
 	jwtSecret     string
 	jwtExpiration time.Duration
 	noAuth        bool
+	authBackend   string // "pam" or "ldap"
+
+	// JWT secret rotation: tokens signed with the previous secret
+	// are accepted until prevUntil
+	jwtGracePeriod time.Duration
+	jwtPrevSecret  string
+	jwtPrevUntil   time.Time
+
+	// TLS settings (empty cert/key = plain HTTP unless self-signed)
+	tlsCert       string
+	tlsKey        string
+	tlsSelfSigned bool
+	httpRedirect  string // plain HTTP listener that redirects to HTTPS
+
+	// Response header settings
+	csp            string
+	frameAncestors string
+	corsOrigins    []string // origins of other frontends allowed to call the API
+	pluginSandbox  bool     // render plugin pages in sandboxed iframes
+
+	// Audit settings
+	trustedProxies []string
+	reverseDNS     bool
+
+	// Host terminal command filter (command prefixes)
+	terminalAllow []string
+	terminalDeny  []string
 
 	// Podman settings
-	socketPath string
+	socketPath    string
+	podmanTimeout time.Duration
+	podmanRetries int
+	logMaxBytes   int64
+	logTimezone   string
+	imageScanner  string
 
 	// MQTT settings
-	mqttBroker   string
-	mqttClientID string
-	mqttUsername string
-	mqttPassword string
-	mqttPrefix   string
-	mqttUseTLS   bool
+	mqttBroker       string
+	mqttClientID     string
+	mqttUsername     string
+	mqttPassword     string
+	mqttPrefix       string
+	mqttUseTLS       bool
+	mqttKeepAlive    time.Duration // interval between pings to the broker
+	mqttReconnectMax time.Duration // longest wait between reconnect attempts
+	mqttDeviceID     string        // Home Assistant device of this host
+	mqttDeviceName   string
+
+	// LDAP settings (PODMANVIEW_AUTH_BACKEND=ldap)
+	ldapURL          string
+	ldapStartTLS     bool
+	ldapBindDN       string
+	ldapBindPassword string
+	ldapBaseDN       string
+	ldapUserFilter   string
+	ldapAdminGroups  []string // group DNs or CNs
+
+	// OIDC settings (single sign-on, enabled by an issuer)
+	oidcIssuer       string
+	oidcClientID     string
+	oidcClientSecret string
+	oidcRedirectURL  string
+	oidcScopes       []string
+	oidcRoleClaim    string
+	oidcAdminValues  []string
 }
 
 // Load loads configuration from .env file or creates it with defaults.
@@ -118,10 +288,43 @@ func Load(filePath string) (*Config, error) {
 // setDefaults initializes all fields with default values.
 func (c *Config) setDefaults() {
 	c.addr = DefaultAddr
+	c.addrMode = DefaultAddrMode
+	c.devDir = DefaultDevDir
+	c.dataDir = DefaultDataDir
+	c.pprof = DefaultPprof
+	c.hostProc = DefaultHostProc
+	c.statsInterval = DefaultStatsInterval
+	c.pluginStopTimeout = DefaultPluginStop
+	c.pluginHTTPTimeout = DefaultPluginHTTP
+	c.httpProxy = DefaultHTTPProxy
+	c.jsonMaxBytes = DefaultJSONMaxBytes
+	c.compressLevel = DefaultCompressLevel
+	c.compressTypes = splitList(DefaultCompressTypes)
 	c.jwtSecret = ""
 	c.jwtExpiration = DefaultJWTExpiration
 	c.noAuth = DefaultNoAuth
+	c.authBackend = DefaultAuthBackend
+	c.jwtGracePeriod = DefaultJWTGracePeriod
+	c.jwtPrevSecret = ""
+	c.jwtPrevUntil = time.Time{}
+	c.tlsCert = ""
+	c.tlsKey = ""
+	c.tlsSelfSigned = DefaultTLSSelfSigned
+	c.httpRedirect = DefaultHTTPRedirect
+	c.csp = DefaultCSP
+	c.frameAncestors = DefaultFrameAncestors
+	c.corsOrigins = splitList(DefaultCORSOrigins)
+	c.pluginSandbox = DefaultPluginSandbox
+	c.trustedProxies = splitList(DefaultTrustedProxies)
+	c.reverseDNS = DefaultReverseDNS
+	c.terminalAllow = splitList(DefaultTerminalAllow)
+	c.terminalDeny = splitList(DefaultTerminalDeny)
 	c.socketPath = DefaultSocket
+	c.podmanTimeout = DefaultPodmanTimeout
+	c.podmanRetries = DefaultPodmanRetries
+	c.logMaxBytes = DefaultLogMaxBytes
+	c.logTimezone = DefaultLogTimezone
+	c.imageScanner = DefaultImageScanner
 	// MQTT defaults
 	c.mqttBroker = DefaultMQTTBroker
 	c.mqttClientID = DefaultMQTTClientID
@@ -129,6 +332,15 @@ func (c *Config) setDefaults() {
 	c.mqttPassword = DefaultMQTTPassword
 	c.mqttPrefix = DefaultMQTTPrefix
 	c.mqttUseTLS = DefaultMQTTUseTLS
+	c.mqttKeepAlive = DefaultMQTTKeepAlive
+	c.mqttReconnectMax = DefaultMQTTReconnectMax
+	c.mqttDeviceID = DefaultMQTTDeviceID
+	c.mqttDeviceName = DefaultMQTTDeviceName
+	c.ldapUserFilter = DefaultLDAPUserFilter
+	c.ldapAdminGroups = splitDNList(DefaultLDAPAdminGroups)
+	c.oidcScopes = splitList(DefaultOIDCScopes)
+	c.oidcRoleClaim = DefaultOIDCRoleClaim
+	c.oidcAdminValues = splitList(DefaultOIDCAdminValues)
 }
 
 // loadFromFile reads configuration from .env file.
@@ -154,6 +366,12 @@ func (c *Config) applyValues(values map[string]string) {
 		c.addr = v
 	}
 
+	if v, ok := values[EnvAddrMode]; ok && v != "" {
+		if mode, err := strconv.ParseUint(v, 8, 32); err == nil {
+			c.addrMode = os.FileMode(mode)
+		}
+	}
+
 	if v, ok := values[EnvJWTSecret]; ok && v != "" {
 		c.jwtSecret = v
 	}
@@ -164,14 +382,162 @@ func (c *Config) applyValues(values map[string]string) {
 		}
 	}
 
+	if v, ok := values[EnvJWTGracePeriod]; ok && v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil && seconds >= 0 {
+			c.jwtGracePeriod = time.Duration(seconds) * time.Second
+		}
+	}
+
+	if v, ok := values[EnvJWTPrevSecret]; ok {
+		c.jwtPrevSecret = v
+	}
+
+	if v, ok := values[EnvJWTPrevUntil]; ok && v != "" {
+		if unix, err := strconv.ParseInt(v, 10, 64); err == nil && unix > 0 {
+			c.jwtPrevUntil = time.Unix(unix, 0)
+		}
+	}
+
 	if v, ok := values[EnvNoAuth]; ok {
 		c.noAuth = parseBool(v)
 	}
 
+	if v, ok := values[EnvAuthBackend]; ok && v != "" {
+		c.authBackend = strings.ToLower(strings.TrimSpace(v))
+	}
+
+	if v, ok := values[EnvTLSCert]; ok {
+		c.tlsCert = v
+	}
+
+	if v, ok := values[EnvTLSKey]; ok {
+		c.tlsKey = v
+	}
+
+	if v, ok := values[EnvTLSSelfSigned]; ok {
+		c.tlsSelfSigned = parseBool(v)
+	}
+
+	if v, ok := values[EnvHTTPRedirect]; ok {
+		c.httpRedirect = v
+	}
+
+	if v, ok := values[EnvCSP]; ok {
+		c.csp = v
+	}
+
+	if v, ok := values[EnvFrameAncestors]; ok && v != "" {
+		c.frameAncestors = v
+	}
+
+	if v, ok := values[EnvCORSOrigins]; ok {
+		c.corsOrigins = splitList(v)
+	}
+
+	if v, ok := values[EnvPluginSandbox]; ok {
+		c.pluginSandbox = parseBool(v)
+	}
+
+	if v, ok := values[EnvTrustedProxies]; ok {
+		c.trustedProxies = splitList(v)
+	}
+
+	if v, ok := values[EnvTerminalAllow]; ok {
+		c.terminalAllow = splitList(v)
+	}
+
+	if v, ok := values[EnvTerminalDeny]; ok {
+		c.terminalDeny = splitList(v)
+	}
+
+	if v, ok := values[EnvReverseDNS]; ok {
+		c.reverseDNS = parseBool(v)
+	}
+
 	if v, ok := values[EnvSocket]; ok {
 		c.socketPath = v
 	}
 
+	if v, ok := values[EnvPodmanTimeout]; ok && v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil && seconds > 0 {
+			c.podmanTimeout = time.Duration(seconds) * time.Second
+		}
+	}
+
+	if v, ok := values[EnvLogMaxBytes]; ok && v != "" {
+		if size, err := strconv.ParseInt(v, 10, 64); err == nil && size > 0 {
+			c.logMaxBytes = size
+		}
+	}
+
+	if v, ok := values[EnvLogTimezone]; ok {
+		c.logTimezone = strings.TrimSpace(v)
+	}
+
+	if v, ok := values[EnvImageScanner]; ok {
+		c.imageScanner = v
+	}
+
+	if v, ok := values[EnvDevDir]; ok {
+		c.devDir = v
+	}
+
+	if v, ok := values[EnvDataDir]; ok {
+		c.dataDir = strings.TrimSpace(v)
+	}
+
+	if v, ok := values[EnvPprof]; ok {
+		c.pprof = parseBool(v)
+	}
+
+	if v, ok := values[EnvHostProc]; ok {
+		c.hostProc = strings.TrimRight(strings.TrimSpace(v), "/")
+	}
+
+	if v, ok := values[EnvStatsInterval]; ok && v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil && seconds > 0 {
+			c.statsInterval = time.Duration(seconds) * time.Second
+		}
+	}
+
+	if v, ok := values[EnvPluginStop]; ok && v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil && seconds > 0 {
+			c.pluginStopTimeout = time.Duration(seconds) * time.Second
+		}
+	}
+
+	if v, ok := values[EnvPluginHTTP]; ok && v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil && seconds > 0 {
+			c.pluginHTTPTimeout = time.Duration(seconds) * time.Second
+		}
+	}
+
+	if v, ok := values[EnvJSONMaxBytes]; ok && v != "" {
+		if size, err := strconv.ParseInt(v, 10, 64); err == nil && size > 0 {
+			c.jsonMaxBytes = size
+		}
+	}
+
+	if v, ok := values[EnvCompressLevel]; ok && v != "" {
+		if level, err := strconv.Atoi(v); err == nil {
+			c.compressLevel = level
+		}
+	}
+
+	if v, ok := values[EnvCompressTypes]; ok {
+		c.compressTypes = splitList(strings.ToLower(v))
+	}
+
+	if v, ok := values[EnvHTTPProxy]; ok {
+		c.httpProxy = v
+	}
+
+	if v, ok := values[EnvPodmanRetries]; ok && v != "" {
+		if retries, err := strconv.Atoi(v); err == nil && retries >= 0 {
+			c.podmanRetries = retries
+		}
+	}
+
 	// MQTT settings
 	if v, ok := values[EnvMQTTBroker]; ok {
 		c.mqttBroker = v
@@ -191,6 +557,68 @@ func (c *Config) applyValues(values map[string]string) {
 	if v, ok := values[EnvMQTTUseTLS]; ok {
 		c.mqttUseTLS = parseBool(v)
 	}
+	if v, ok := values[EnvMQTTKeepAlive]; ok && v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil && seconds > 0 {
+			c.mqttKeepAlive = time.Duration(seconds) * time.Second
+		}
+	}
+	if v, ok := values[EnvMQTTReconnectMax]; ok && v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil && seconds > 0 {
+			c.mqttReconnectMax = time.Duration(seconds) * time.Second
+		}
+	}
+	if v, ok := values[EnvMQTTDeviceID]; ok {
+		c.mqttDeviceID = strings.TrimSpace(v)
+	}
+	if v, ok := values[EnvMQTTDeviceName]; ok {
+		c.mqttDeviceName = strings.TrimSpace(v)
+	}
+
+	// LDAP settings
+	if v, ok := values[EnvLDAPURL]; ok {
+		c.ldapURL = strings.TrimSpace(v)
+	}
+	if v, ok := values[EnvLDAPStartTLS]; ok {
+		c.ldapStartTLS = parseBool(v)
+	}
+	if v, ok := values[EnvLDAPBindDN]; ok {
+		c.ldapBindDN = v
+	}
+	if v, ok := values[EnvLDAPBindPassword]; ok {
+		c.ldapBindPassword = v
+	}
+	if v, ok := values[EnvLDAPBaseDN]; ok {
+		c.ldapBaseDN = v
+	}
+	if v, ok := values[EnvLDAPUserFilter]; ok && v != "" {
+		c.ldapUserFilter = v
+	}
+	if v, ok := values[EnvLDAPAdminGroups]; ok {
+		c.ldapAdminGroups = splitDNList(v)
+	}
+
+	// OIDC settings
+	if v, ok := values[EnvOIDCIssuer]; ok {
+		c.oidcIssuer = strings.TrimSpace(v)
+	}
+	if v, ok := values[EnvOIDCClientID]; ok {
+		c.oidcClientID = v
+	}
+	if v, ok := values[EnvOIDCClientSecret]; ok {
+		c.oidcClientSecret = v
+	}
+	if v, ok := values[EnvOIDCRedirectURL]; ok {
+		c.oidcRedirectURL = strings.TrimSpace(v)
+	}
+	if v, ok := values[EnvOIDCScopes]; ok && v != "" {
+		c.oidcScopes = splitList(v)
+	}
+	if v, ok := values[EnvOIDCRoleClaim]; ok && v != "" {
+		c.oidcRoleClaim = v
+	}
+	if v, ok := values[EnvOIDCAdminValues]; ok {
+		c.oidcAdminValues = splitList(v)
+	}
 }
 
 // validate checks if configuration is valid.
@@ -201,8 +629,11 @@ func (c *Config) validate() error {
 	}
 
 	// Check if address format is valid
-	host, port, err := net.SplitHostPort(c.addr)
-	if err != nil {
+	if path, ok := netutil.UnixSocketPath(c.addr); ok {
+		if !filepath.IsAbs(path) {
+			return fmt.Errorf("Unix socket path must be absolute: %s", path)
+		}
+	} else if host, port, err := net.SplitHostPort(c.addr); err != nil {
 		// Try with default host
 		if _, err := strconv.Atoi(strings.TrimPrefix(c.addr, ":")); err != nil {
 			return fmt.Errorf("invalid server address format: %s", c.addr)
@@ -218,6 +649,55 @@ func (c *Config) validate() error {
 		_ = host // host can be empty (bind to all interfaces)
 	}
 
+	if c.addrMode&^0777 != 0 {
+		return fmt.Errorf("invalid Unix socket mode: %04o", uint32(c.addrMode))
+	}
+
+	// A relative data directory would again depend on the working directory
+	if c.dataDir != "" && !filepath.IsAbs(c.dataDir) {
+		return fmt.Errorf("data directory must be an absolute path: %s", c.dataDir)
+	}
+	if c.hostProc != "" && !filepath.IsAbs(c.hostProc) {
+		return fmt.Errorf("host proc path must be absolute: %s", c.hostProc)
+	}
+
+	// TLS certificate and key must be set together
+	if (c.tlsCert == "") != (c.tlsKey == "") {
+		return errors.New("TLS certificate and key must both be set")
+	}
+
+	// Validate HTTP redirect listener (optional)
+	if c.httpRedirect != "" {
+		if _, port, err := net.SplitHostPort(c.httpRedirect); err != nil {
+			return fmt.Errorf("invalid HTTP redirect address format: %s", c.httpRedirect)
+		} else if portNum, err := strconv.Atoi(port); err != nil || portNum < 1 || portNum > 65535 {
+			return fmt.Errorf("invalid HTTP redirect port number: %s", port)
+		}
+		if c.httpRedirect == c.addr {
+			return errors.New("HTTP redirect address must differ from server address")
+		}
+		if _, ok := netutil.UnixSocketPath(c.addr); ok {
+			return errors.New("HTTP redirect requires a TCP server address")
+		}
+	}
+
+	// Header values must not contain line breaks
+	if strings.ContainsAny(c.csp, "\r\n") || strings.ContainsAny(c.frameAncestors, "\r\n") {
+		return errors.New("Content-Security-Policy settings cannot contain line breaks")
+	}
+
+	// Validate CORS origins: credentials are sent, so no wildcard
+	for _, origin := range c.corsOrigins {
+		if origin == "*" {
+			return errors.New("CORS origins must be listed explicitly, * is not allowed")
+		}
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+			u.Path != "" || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+			return fmt.Errorf("invalid CORS origin (expected scheme://host[:port]): %s", origin)
+		}
+	}
+
 	// Validate JWT expiration
 	if c.jwtExpiration < time.Minute {
 		return errors.New("JWT expiration must be at least 1 minute")
@@ -226,14 +706,250 @@ func (c *Config) validate() error {
 		return errors.New("JWT expiration cannot exceed 1 year")
 	}
 
+	// Validate JWT rotation grace period
+	if c.jwtGracePeriod < 0 || c.jwtGracePeriod > 30*24*time.Hour {
+		return errors.New("JWT grace period must be between 0 and 30 days")
+	}
+
+	// Validate trusted proxies (IP or CIDR)
+	for _, proxy := range c.trustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err == nil {
+			continue
+		}
+		if net.ParseIP(proxy) == nil {
+			return fmt.Errorf("invalid trusted proxy (expected IP or CIDR): %s", proxy)
+		}
+	}
+
 	// Validate socket path if specified
 	if c.socketPath != "" {
 		// Just check it's not obviously invalid
 		if strings.ContainsAny(c.socketPath, "\x00") {
 			return errors.New("socket path contains invalid characters")
 		}
+		// URI form selects the transport: unix://, tcp:// or ssh://
+		if scheme, _, ok := strings.Cut(c.socketPath, "://"); ok {
+			switch scheme {
+			case "unix", "tcp", "ssh":
+			default:
+				return fmt.Errorf("unsupported socket scheme: %s", scheme)
+			}
+		}
+	}
+
+	// Validate Podman request timeout
+	if c.podmanTimeout < time.Second {
+		return errors.New("Podman timeout must be at least 1 second")
+	}
+	if c.podmanTimeout > time.Hour {
+		return errors.New("Podman timeout cannot exceed 1 hour")
+	}
+
+	// Validate host stats sampling period
+	if c.statsInterval < time.Second || c.statsInterval > time.Minute {
+		return errors.New("stats interval must be between 1 and 60 seconds")
+	}
+
+	// Validate plugin stop timeout
+	if c.pluginStopTimeout < time.Second || c.pluginStopTimeout > time.Minute {
+		return errors.New("plugin stop timeout must be between 1 and 60 seconds")
+	}
+
+	// Validate plugin HTTP client settings
+	if c.pluginHTTPTimeout < time.Second || c.pluginHTTPTimeout > 10*time.Minute {
+		return errors.New("plugin HTTP timeout must be between 1 second and 10 minutes")
+	}
+	if err := validateHTTPProxy(c.httpProxy); err != nil {
+		return err
+	}
+
+	// Validate JSON request body limit
+	if c.jsonMaxBytes < 64*1024 {
+		return errors.New("JSON body size limit must be at least 64 KiB")
+	}
+
+	// Validate response compression
+	if c.compressLevel < 0 || c.compressLevel > 9 {
+		return errors.New("compression level must be between 0 (off) and 9")
+	}
+	for _, t := range c.compressTypes {
+		if !isContentTypePattern(t) {
+			return fmt.Errorf("invalid compression content type (expected type/subtype or type/*): %s", t)
+		}
+	}
+
+	// Validate Podman retry count
+	if c.podmanRetries < 0 || c.podmanRetries > 10 {
+		return errors.New("Podman retries must be between 0 and 10")
+	}
+
+	// Validate container log size limit
+	if c.logMaxBytes < 64*1024 {
+		return errors.New("log size limit must be at least 64 KiB")
+	}
+
+	// Validate log timestamp time zone (empty = local)
+	if c.logTimezone != "" {
+		if _, err := time.LoadLocation(c.logTimezone); err != nil {
+			return fmt.Errorf("invalid log time zone: %s", c.logTimezone)
+		}
+	}
+
+	// Validate MQTT settings (broker is optional - empty disables MQTT)
+	if err := validateMQTTBroker(c.mqttBroker); err != nil {
+		return err
+	}
+	if err := validateMQTTPrefix(c.mqttPrefix); err != nil {
+		return err
+	}
+	if err := validateMQTTTimings(c.mqttKeepAlive, c.mqttReconnectMax); err != nil {
+		return err
+	}
+	if err := validateMQTTDeviceID(c.mqttDeviceID); err != nil {
+		return err
+	}
+
+	// Validate authentication backend
+	switch c.authBackend {
+	case "pam":
+	case "ldap":
+		if err := c.validateLDAP(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid auth backend %q (expected pam or ldap)", c.authBackend)
+	}
+
+	// Validate single sign-on (empty issuer disables it)
+	if c.oidcIssuer != "" {
+		if err := c.validateOIDC(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateOIDC checks the settings OIDC login needs.
+func (c *Config) validateOIDC() error {
+	if u, err := url.Parse(c.oidcIssuer); err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		return fmt.Errorf("OIDC issuer must be an http(s) URL: %s", c.oidcIssuer)
+	}
+	if c.oidcClientID == "" {
+		return errors.New("OIDC client ID is required")
+	}
+	u, err := url.Parse(c.oidcRedirectURL)
+	if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		return fmt.Errorf("OIDC redirect URL must be the absolute URL of /api/auth/oidc/callback: %q", c.oidcRedirectURL)
+	}
+	return nil
+}
+
+// validateLDAP checks the settings the LDAP backend needs.
+func (c *Config) validateLDAP() error {
+	u, err := url.Parse(c.ldapURL)
+	if err != nil || u.Hostname() == "" {
+		return fmt.Errorf("LDAP URL must be in form ldap://host:port or ldaps://host:port: %q", c.ldapURL)
+	}
+	switch u.Scheme {
+	case "ldap":
+	case "ldaps":
+		if c.ldapStartTLS {
+			return errors.New("LDAP StartTLS cannot be used with ldaps://")
+		}
+	default:
+		return fmt.Errorf("invalid LDAP URL scheme %q (expected ldap or ldaps)", u.Scheme)
+	}
+	if c.ldapBaseDN == "" {
+		return errors.New("LDAP base DN is required")
+	}
+	if !strings.Contains(c.ldapUserFilter, "%s") || !strings.HasPrefix(c.ldapUserFilter, "(") {
+		return fmt.Errorf("LDAP user filter must be a filter containing %%s for the username: %s", c.ldapUserFilter)
+	}
+	return nil
+}
+
+// validateMQTTBroker checks broker address format (scheme://host:port).
+// Empty broker is valid and means MQTT is disabled.
+func validateMQTTBroker(broker string) error {
+	if broker == "" {
+		return nil
+	}
+
+	u, err := url.Parse(broker)
+	if err != nil {
+		return fmt.Errorf("invalid MQTT broker address: %s", broker)
+	}
+
+	switch u.Scheme {
+	case "tcp", "ssl", "tls", "mqtt", "mqtts":
+	default:
+		return fmt.Errorf("invalid MQTT broker scheme %q (expected tcp:// or ssl://)", u.Scheme)
+	}
+
+	host, port, err := net.SplitHostPort(u.Host)
+	if err != nil || host == "" {
+		return fmt.Errorf("MQTT broker must be in form scheme://host:port: %s", broker)
+	}
+	portNum, err := strconv.Atoi(port)
+	if err != nil || portNum < 1 || portNum > 65535 {
+		return fmt.Errorf("invalid MQTT broker port: %s", port)
+	}
+
+	return nil
+}
+
+// validateHTTPProxy checks the outbound proxy URL (empty is allowed).
+func validateHTTPProxy(proxy string) error {
+	if proxy == "" {
+		return nil
+	}
+
+	u, err := url.Parse(proxy)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("HTTP proxy must be in form scheme://host:port: %s", proxy)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return fmt.Errorf("invalid HTTP proxy scheme %q (expected http, https or socks5)", u.Scheme)
+	}
+	return nil
+}
+
+// validateMQTTPrefix checks MQTT topic prefix.
+func validateMQTTPrefix(prefix string) error {
+	if strings.HasPrefix(prefix, "/") {
+		return errors.New("MQTT prefix cannot start with '/'")
+	}
+	if strings.ContainsAny(prefix, "#+\x00") {
+		return errors.New("MQTT prefix cannot contain wildcards (#, +)")
+	}
+	return nil
+}
+
+// validateMQTTTimings checks the keepalive and reconnect backoff limits.
+func validateMQTTTimings(keepAlive, reconnectMax time.Duration) error {
+	if keepAlive < 5*time.Second || keepAlive > time.Hour {
+		return errors.New("MQTT keepalive must be between 5 seconds and 1 hour")
 	}
+	if reconnectMax < time.Second || reconnectMax > time.Hour {
+		return errors.New("MQTT reconnect interval must be between 1 second and 1 hour")
+	}
+	return nil
+}
 
+// validateMQTTDeviceID checks the Home Assistant device ID, which is part
+// of discovery topics. Empty means the host name.
+func validateMQTTDeviceID(id string) error {
+	if len(id) > 64 {
+		return errors.New("MQTT device ID cannot be longer than 64 characters")
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-') {
+			return fmt.Errorf("invalid MQTT device ID %q (letters, digits, _ and - only)", id)
+		}
+	}
 	return nil
 }
 
@@ -258,18 +974,71 @@ func (c *Config) Save() error {
 // toMap converts config to key-value map for saving.
 func (c *Config) toMap() map[string]string {
 	return map[string]string{
-		EnvAddr:          c.addr,
-		EnvJWTSecret:     c.jwtSecret,
-		EnvJWTExpiration: strconv.Itoa(int(c.jwtExpiration.Seconds())),
-		EnvNoAuth:        strconv.FormatBool(c.noAuth),
-		EnvSocket:        c.socketPath,
+		EnvAddr:           c.addr,
+		EnvAddrMode:       fmt.Sprintf("%04o", uint32(c.addrMode)),
+		EnvDevDir:         c.devDir,
+		EnvDataDir:        c.dataDir,
+		EnvPprof:          strconv.FormatBool(c.pprof),
+		EnvHostProc:       c.hostProc,
+		EnvStatsInterval:  strconv.Itoa(int(c.statsInterval.Seconds())),
+		EnvPluginStop:     strconv.Itoa(int(c.pluginStopTimeout.Seconds())),
+		EnvPluginHTTP:     strconv.Itoa(int(c.pluginHTTPTimeout.Seconds())),
+		EnvHTTPProxy:      c.httpProxy,
+		EnvJSONMaxBytes:   strconv.FormatInt(c.jsonMaxBytes, 10),
+		EnvCompressLevel:  strconv.Itoa(c.compressLevel),
+		EnvCompressTypes:  strings.Join(c.compressTypes, ","),
+		EnvJWTSecret:      c.jwtSecret,
+		EnvJWTExpiration:  strconv.Itoa(int(c.jwtExpiration.Seconds())),
+		EnvJWTGracePeriod: strconv.Itoa(int(c.jwtGracePeriod.Seconds())),
+		EnvJWTPrevSecret:  c.jwtPrevSecret,
+		EnvJWTPrevUntil:   formatUnix(c.jwtPrevUntil),
+		EnvNoAuth:         strconv.FormatBool(c.noAuth),
+		EnvAuthBackend:    c.authBackend,
+		EnvTLSCert:        c.tlsCert,
+		EnvTLSKey:         c.tlsKey,
+		EnvTLSSelfSigned:  strconv.FormatBool(c.tlsSelfSigned),
+		EnvHTTPRedirect:   c.httpRedirect,
+		EnvCSP:            c.csp,
+		EnvFrameAncestors: c.frameAncestors,
+		EnvCORSOrigins:    strings.Join(c.corsOrigins, ","),
+		EnvPluginSandbox:  strconv.FormatBool(c.pluginSandbox),
+		EnvTrustedProxies: strings.Join(c.trustedProxies, ","),
+		EnvReverseDNS:     strconv.FormatBool(c.reverseDNS),
+		EnvTerminalAllow:  strings.Join(c.terminalAllow, ","),
+		EnvTerminalDeny:   strings.Join(c.terminalDeny, ","),
+		EnvSocket:         c.socketPath,
+		EnvPodmanTimeout:  strconv.Itoa(int(c.podmanTimeout.Seconds())),
+		EnvPodmanRetries:  strconv.Itoa(c.podmanRetries),
+		EnvLogMaxBytes:    strconv.FormatInt(c.logMaxBytes, 10),
+		EnvLogTimezone:    c.logTimezone,
+		EnvImageScanner:   c.imageScanner,
 		// MQTT settings
-		EnvMQTTBroker:   c.mqttBroker,
-		EnvMQTTClientID: c.mqttClientID,
-		EnvMQTTUsername: c.mqttUsername,
-		EnvMQTTPassword: c.mqttPassword,
-		EnvMQTTPrefix:   c.mqttPrefix,
-		EnvMQTTUseTLS:   strconv.FormatBool(c.mqttUseTLS),
+		EnvMQTTBroker:       c.mqttBroker,
+		EnvMQTTClientID:     c.mqttClientID,
+		EnvMQTTUsername:     c.mqttUsername,
+		EnvMQTTPassword:     c.mqttPassword,
+		EnvMQTTPrefix:       c.mqttPrefix,
+		EnvMQTTUseTLS:       strconv.FormatBool(c.mqttUseTLS),
+		EnvMQTTKeepAlive:    strconv.Itoa(int(c.mqttKeepAlive.Seconds())),
+		EnvMQTTReconnectMax: strconv.Itoa(int(c.mqttReconnectMax.Seconds())),
+		EnvMQTTDeviceID:     c.mqttDeviceID,
+		EnvMQTTDeviceName:   c.mqttDeviceName,
+		// LDAP settings
+		EnvLDAPURL:          c.ldapURL,
+		EnvLDAPStartTLS:     strconv.FormatBool(c.ldapStartTLS),
+		EnvLDAPBindDN:       c.ldapBindDN,
+		EnvLDAPBindPassword: c.ldapBindPassword,
+		EnvLDAPBaseDN:       c.ldapBaseDN,
+		EnvLDAPUserFilter:   c.ldapUserFilter,
+		EnvLDAPAdminGroups:  strings.Join(c.ldapAdminGroups, ";"),
+		// OIDC settings
+		EnvOIDCIssuer:       c.oidcIssuer,
+		EnvOIDCClientID:     c.oidcClientID,
+		EnvOIDCClientSecret: c.oidcClientSecret,
+		EnvOIDCRedirectURL:  c.oidcRedirectURL,
+		EnvOIDCScopes:       strings.Join(c.oidcScopes, ","),
+		EnvOIDCRoleClaim:    c.oidcRoleClaim,
+		EnvOIDCAdminValues:  strings.Join(c.oidcAdminValues, ","),
 	}
 }
 
@@ -282,6 +1051,13 @@ func (c *Config) Addr() string {
 	return c.addr
 }
 
+// AddrMode returns the permissions of the listen socket for unix: addresses.
+func (c *Config) AddrMode() os.FileMode {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.addrMode
+}
+
 // JWTSecret returns the JWT secret key.
 func (c *Config) JWTSecret() string {
 	c.mu.RLock()
@@ -289,6 +1065,25 @@ func (c *Config) JWTSecret() string {
 	return c.jwtSecret
 }
 
+// JWTGracePeriod returns how long the previous JWT secret stays valid after rotation.
+func (c *Config) JWTGracePeriod() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.jwtGracePeriod
+}
+
+// JWTPreviousSecret returns the secret replaced by the last rotation and
+// until when tokens signed with it are accepted. Empty once the grace
+// period is over.
+func (c *Config) JWTPreviousSecret() (string, time.Time) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.jwtPrevSecret == "" || !time.Now().Before(c.jwtPrevUntil) {
+		return "", time.Time{}
+	}
+	return c.jwtPrevSecret, c.jwtPrevUntil
+}
+
 // JWTExpiration returns the JWT token expiration duration.
 func (c *Config) JWTExpiration() time.Duration {
 	c.mu.RLock()
@@ -303,37 +1098,266 @@ func (c *Config) NoAuth() bool {
 	return c.noAuth
 }
 
-// SocketPath returns the Podman socket path.
-func (c *Config) SocketPath() string {
+// AuthBackend returns how logins are checked: "pam" or "ldap".
+func (c *Config) AuthBackend() string {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	return c.socketPath
+	return c.authBackend
 }
 
-// FilePath returns the path to the .env file.
-func (c *Config) FilePath() string {
+// TLSCert returns the TLS certificate file path.
+func (c *Config) TLSCert() string {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	return c.filePath
+	return c.tlsCert
 }
 
-// MQTT Getters
-
-// MQTTBroker returns the MQTT broker address.
-func (c *Config) MQTTBroker() string {
+// TLSKey returns the TLS private key file path.
+func (c *Config) TLSKey() string {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	return c.mqttBroker
+	return c.tlsKey
 }
 
-// MQTTClientID returns the MQTT client ID.
-func (c *Config) MQTTClientID() string {
+// TLSSelfSigned returns whether a self-signed certificate is generated
+// when no certificate is configured.
+func (c *Config) TLSSelfSigned() bool {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	return c.mqttClientID
+	return c.tlsSelfSigned
 }
 
-// MQTTUsername returns the MQTT username.
+// TLSEnabled returns whether the server listens with TLS.
+func (c *Config) TLSEnabled() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tlsCert != "" || c.tlsSelfSigned
+}
+
+// HTTPRedirectAddr returns the plain HTTP listener address that redirects
+// to HTTPS (empty = disabled, only used when TLS is enabled).
+func (c *Config) HTTPRedirectAddr() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.httpRedirect
+}
+
+// CSP returns the Content-Security-Policy override (empty = built-in policy).
+func (c *Config) CSP() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.csp
+}
+
+// FrameAncestors returns the CSP frame-ancestors sources allowed to embed the UI.
+func (c *Config) FrameAncestors() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.frameAncestors
+}
+
+// CORSOrigins returns the origins allowed to call the API cross-origin
+// (empty = CORS disabled).
+func (c *Config) CORSOrigins() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	result := make([]string, len(c.corsOrigins))
+	copy(result, c.corsOrigins)
+	return result
+}
+
+// PluginSandbox returns whether plugin pages are isolated in sandboxed iframes.
+func (c *Config) PluginSandbox() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.pluginSandbox
+}
+
+// TrustedProxies returns IPs/CIDRs whose forwarded headers are honored.
+func (c *Config) TrustedProxies() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	result := make([]string, len(c.trustedProxies))
+	copy(result, c.trustedProxies)
+	return result
+}
+
+// TerminalAllow returns the command prefixes allowed in the host terminal
+// (empty = any command not denied).
+func (c *Config) TerminalAllow() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	result := make([]string, len(c.terminalAllow))
+	copy(result, c.terminalAllow)
+	return result
+}
+
+// TerminalDeny returns the command prefixes denied in the host terminal.
+func (c *Config) TerminalDeny() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	result := make([]string, len(c.terminalDeny))
+	copy(result, c.terminalDeny)
+	return result
+}
+
+// ReverseDNS returns whether client IPs in events are resolved to hostnames.
+func (c *Config) ReverseDNS() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.reverseDNS
+}
+
+// SocketPath returns the Podman socket path.
+func (c *Config) SocketPath() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.socketPath
+}
+
+// PodmanTimeout returns the default timeout for Podman API calls.
+func (c *Config) PodmanTimeout() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.podmanTimeout
+}
+
+// PodmanRetries returns the retry count for idempotent Podman API calls.
+func (c *Config) PodmanRetries() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.podmanRetries
+}
+
+// LogMaxBytes returns the maximum size of a container logs response.
+func (c *Config) LogMaxBytes() int64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.logMaxBytes
+}
+
+// LogTimezone returns the time zone of container log timestamps
+// (empty = the server's local time zone).
+func (c *Config) LogTimezone() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.logTimezone
+}
+
+// ImageScanner returns the image scanner command (empty = auto-detect).
+func (c *Config) ImageScanner() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.imageScanner
+}
+
+// DevDir returns the source checkout that web assets and plugin HTML
+// are served from instead of the embedded copies (empty = embedded).
+func (c *Config) DevDir() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.devDir
+}
+
+// DataDir returns the directory of the application database
+// (empty = the working directory).
+func (c *Config) DataDir() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.dataDir
+}
+
+// Pprof returns whether the Go profiling endpoints are served.
+func (c *Config) Pprof() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.pprof
+}
+
+// HostProc returns the configured mount of the host's /proc
+// (empty = detect /host/proc, otherwise /proc).
+func (c *Config) HostProc() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.hostProc
+}
+
+// StatsInterval returns how often CPU usage is sampled for host stats.
+func (c *Config) StatsInterval() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.statsInterval
+}
+
+// PluginStopTimeout returns how long each plugin may take to stop on shutdown.
+func (c *Config) PluginStopTimeout() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.pluginStopTimeout
+}
+
+// PluginHTTPTimeout returns the timeout of the HTTP client given to plugins.
+func (c *Config) PluginHTTPTimeout() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.pluginHTTPTimeout
+}
+
+// JSONMaxBytes returns the maximum size of a JSON request body.
+func (c *Config) JSONMaxBytes() int64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.jsonMaxBytes
+}
+
+// CompressLevel returns the gzip level of responses, 0 when disabled.
+func (c *Config) CompressLevel() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.compressLevel
+}
+
+// CompressTypes returns the content types of responses that are compressed.
+func (c *Config) CompressTypes() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	result := make([]string, len(c.compressTypes))
+	copy(result, c.compressTypes)
+	return result
+}
+
+// HTTPProxy returns the proxy URL for outbound plugin requests
+// (empty = HTTP_PROXY/HTTPS_PROXY from the environment).
+func (c *Config) HTTPProxy() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.httpProxy
+}
+
+// FilePath returns the path to the .env file.
+func (c *Config) FilePath() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.filePath
+}
+
+// MQTT Getters
+
+// MQTTBroker returns the MQTT broker address.
+func (c *Config) MQTTBroker() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.mqttBroker
+}
+
+// MQTTClientID returns the MQTT client ID.
+func (c *Config) MQTTClientID() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.mqttClientID
+}
+
+// MQTTUsername returns the MQTT username.
 func (c *Config) MQTTUsername() string {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -361,6 +1385,165 @@ func (c *Config) MQTTUseTLS() bool {
 	return c.mqttUseTLS
 }
 
+// MQTTKeepAlive returns the interval between pings to the MQTT broker.
+func (c *Config) MQTTKeepAlive() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.mqttKeepAlive
+}
+
+// MQTTDeviceID returns the Home Assistant device ID of this host (empty = host name).
+func (c *Config) MQTTDeviceID() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.mqttDeviceID
+}
+
+// MQTTDeviceName returns the Home Assistant device name of this host (empty = default).
+func (c *Config) MQTTDeviceName() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.mqttDeviceName
+}
+
+// MQTTReconnectMax returns the longest wait between MQTT reconnect attempts.
+func (c *Config) MQTTReconnectMax() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.mqttReconnectMax
+}
+
+// MQTTSettings groups all MQTT settings for atomic get/update.
+type MQTTSettings struct {
+	Broker   string
+	ClientID string
+	Username string
+	Password string
+	Prefix   string
+	UseTLS   bool
+
+	KeepAlive    time.Duration
+	ReconnectMax time.Duration
+
+	DeviceID   string // empty = host name
+	DeviceName string
+}
+
+// MQTTSettings returns a snapshot of all MQTT settings.
+func (c *Config) MQTTSettings() MQTTSettings {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return MQTTSettings{
+		Broker:   c.mqttBroker,
+		ClientID: c.mqttClientID,
+		Username: c.mqttUsername,
+		Password: c.mqttPassword,
+		Prefix:   c.mqttPrefix,
+		UseTLS:   c.mqttUseTLS,
+
+		KeepAlive:    c.mqttKeepAlive,
+		ReconnectMax: c.mqttReconnectMax,
+
+		DeviceID:   c.mqttDeviceID,
+		DeviceName: c.mqttDeviceName,
+	}
+}
+
+// LDAPSettings groups the LDAP authentication settings.
+type LDAPSettings struct {
+	URL          string
+	StartTLS     bool
+	BindDN       string
+	BindPassword string
+	BaseDN       string
+	UserFilter   string
+	AdminGroups  []string
+}
+
+// LDAPSettings returns a snapshot of the LDAP settings.
+func (c *Config) LDAPSettings() LDAPSettings {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	groups := make([]string, len(c.ldapAdminGroups))
+	copy(groups, c.ldapAdminGroups)
+	return LDAPSettings{
+		URL:          c.ldapURL,
+		StartTLS:     c.ldapStartTLS,
+		BindDN:       c.ldapBindDN,
+		BindPassword: c.ldapBindPassword,
+		BaseDN:       c.ldapBaseDN,
+		UserFilter:   c.ldapUserFilter,
+		AdminGroups:  groups,
+	}
+}
+
+// OIDCSettings groups the OpenID Connect login settings.
+type OIDCSettings struct {
+	Issuer       string // empty = OIDC login disabled
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	RoleClaim    string
+	AdminValues  []string
+}
+
+// OIDCSettings returns a snapshot of the OIDC settings.
+func (c *Config) OIDCSettings() OIDCSettings {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	scopes := make([]string, len(c.oidcScopes))
+	copy(scopes, c.oidcScopes)
+	values := make([]string, len(c.oidcAdminValues))
+	copy(values, c.oidcAdminValues)
+	return OIDCSettings{
+		Issuer:       c.oidcIssuer,
+		ClientID:     c.oidcClientID,
+		ClientSecret: c.oidcClientSecret,
+		RedirectURL:  c.oidcRedirectURL,
+		Scopes:       scopes,
+		RoleClaim:    c.oidcRoleClaim,
+		AdminValues:  values,
+	}
+}
+
+// GeneralSettings groups the server, auth, audit and Podman settings
+// for atomic get/update. The JWT secret and TLS paths are not included.
+type GeneralSettings struct {
+	Addr           string
+	JWTExpiration  time.Duration
+	NoAuth         bool
+	Socket         string
+	PodmanTimeout  time.Duration
+	PodmanRetries  int
+	LogMaxBytes    int64
+	LogTimezone    string
+	ImageScanner   string
+	TrustedProxies []string
+	ReverseDNS     bool
+}
+
+// GeneralSettings returns a snapshot of the general settings.
+func (c *Config) GeneralSettings() GeneralSettings {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	proxies := make([]string, len(c.trustedProxies))
+	copy(proxies, c.trustedProxies)
+	return GeneralSettings{
+		Addr:           c.addr,
+		JWTExpiration:  c.jwtExpiration,
+		NoAuth:         c.noAuth,
+		Socket:         c.socketPath,
+		PodmanTimeout:  c.podmanTimeout,
+		PodmanRetries:  c.podmanRetries,
+		LogMaxBytes:    c.logMaxBytes,
+		LogTimezone:    c.logTimezone,
+		ImageScanner:   c.imageScanner,
+		TrustedProxies: proxies,
+		ReverseDNS:     c.reverseDNS,
+	}
+}
+
 // Setters (thread-safe, auto-save)
 
 // SetAddr sets the server address and saves to file.
@@ -390,6 +1573,37 @@ func (c *Config) SetJWTSecret(secret string) error {
 	return c.Save()
 }
 
+// RotateJWTSecret replaces the JWT secret with a newly generated one and
+// keeps the old secret valid for the grace period, then saves to file.
+func (c *Config) RotateJWTSecret() error {
+	secret, err := generateSecureSecret(32)
+	if err != nil {
+		return fmt.Errorf("failed to generate JWT secret: %w", err)
+	}
+
+	c.mu.Lock()
+	c.jwtPrevSecret = c.jwtSecret
+	c.jwtPrevUntil = time.Now().Add(c.jwtGracePeriod).Truncate(time.Second)
+	c.jwtSecret = secret
+	c.dirty = true
+	c.mu.Unlock()
+
+	return c.Save()
+}
+
+// SetJWTGracePeriod sets the rotation grace period and saves to file.
+func (c *Config) SetJWTGracePeriod(d time.Duration) error {
+	c.mu.Lock()
+	c.jwtGracePeriod = d
+	c.dirty = true
+	c.mu.Unlock()
+
+	if err := c.validate(); err != nil {
+		return err
+	}
+	return c.Save()
+}
+
 // SetJWTExpiration sets the JWT expiration and saves to file.
 func (c *Config) SetJWTExpiration(d time.Duration) error {
 	c.mu.Lock()
@@ -413,6 +1627,141 @@ func (c *Config) SetNoAuth(noAuth bool) error {
 	return c.Save()
 }
 
+// SetTerminalFilter sets the host terminal's allowed and denied
+// command prefixes and saves to file. Empty lists disable filtering.
+func (c *Config) SetTerminalFilter(allow, deny []string) error {
+	c.mu.Lock()
+	c.terminalAllow = allow
+	c.terminalDeny = deny
+	c.dirty = true
+	c.mu.Unlock()
+
+	return c.Save()
+}
+
+// SetTrustedProxies sets the trusted reverse proxies and saves to file.
+func (c *Config) SetTrustedProxies(proxies []string) error {
+	c.mu.Lock()
+	c.trustedProxies = proxies
+	c.dirty = true
+	c.mu.Unlock()
+
+	if err := c.validate(); err != nil {
+		return err
+	}
+	return c.Save()
+}
+
+// SetTLS sets the TLS certificate and key paths and saves to file.
+// Empty paths disable TLS.
+func (c *Config) SetTLS(certFile, keyFile string) error {
+	c.mu.Lock()
+	c.tlsCert = certFile
+	c.tlsKey = keyFile
+	c.dirty = true
+	c.mu.Unlock()
+
+	if err := c.validate(); err != nil {
+		return err
+	}
+	return c.Save()
+}
+
+// SetTLSSelfSigned sets the self-signed certificate flag and saves to file.
+func (c *Config) SetTLSSelfSigned(enabled bool) error {
+	c.mu.Lock()
+	c.tlsSelfSigned = enabled
+	c.dirty = true
+	c.mu.Unlock()
+
+	return c.Save()
+}
+
+// SetAddrMode sets the Unix listen socket permissions and saves to file.
+func (c *Config) SetAddrMode(mode os.FileMode) error {
+	c.mu.Lock()
+	c.addrMode = mode
+	c.dirty = true
+	c.mu.Unlock()
+
+	if err := c.validate(); err != nil {
+		return err
+	}
+	return c.Save()
+}
+
+// SetHTTPRedirectAddr sets the HTTP redirect listener address and saves to file.
+func (c *Config) SetHTTPRedirectAddr(addr string) error {
+	c.mu.Lock()
+	c.httpRedirect = addr
+	c.dirty = true
+	c.mu.Unlock()
+
+	if err := c.validate(); err != nil {
+		return err
+	}
+	return c.Save()
+}
+
+// SetCSP sets the Content-Security-Policy override and saves to file.
+func (c *Config) SetCSP(policy string) error {
+	c.mu.Lock()
+	c.csp = policy
+	c.dirty = true
+	c.mu.Unlock()
+
+	if err := c.validate(); err != nil {
+		return err
+	}
+	return c.Save()
+}
+
+// SetFrameAncestors sets the sources allowed to embed the UI and saves to file.
+func (c *Config) SetFrameAncestors(sources string) error {
+	c.mu.Lock()
+	c.frameAncestors = sources
+	c.dirty = true
+	c.mu.Unlock()
+
+	if err := c.validate(); err != nil {
+		return err
+	}
+	return c.Save()
+}
+
+// SetCORSOrigins sets the origins allowed to call the API and saves to file.
+func (c *Config) SetCORSOrigins(origins []string) error {
+	c.mu.Lock()
+	c.corsOrigins = origins
+	c.dirty = true
+	c.mu.Unlock()
+
+	if err := c.validate(); err != nil {
+		return err
+	}
+	return c.Save()
+}
+
+// SetPluginSandbox sets the plugin sandbox flag and saves to file.
+func (c *Config) SetPluginSandbox(enabled bool) error {
+	c.mu.Lock()
+	c.pluginSandbox = enabled
+	c.dirty = true
+	c.mu.Unlock()
+
+	return c.Save()
+}
+
+// SetReverseDNS sets the reverse DNS flag and saves to file.
+func (c *Config) SetReverseDNS(enabled bool) error {
+	c.mu.Lock()
+	c.reverseDNS = enabled
+	c.dirty = true
+	c.mu.Unlock()
+
+	return c.Save()
+}
+
 // SetSocketPath sets the Podman socket path and saves to file.
 func (c *Config) SetSocketPath(path string) error {
 	c.mu.Lock()
@@ -426,6 +1775,287 @@ func (c *Config) SetSocketPath(path string) error {
 	return c.Save()
 }
 
+// SetPodmanTimeout sets the default Podman API timeout and saves to file.
+func (c *Config) SetPodmanTimeout(d time.Duration) error {
+	c.mu.Lock()
+	c.podmanTimeout = d
+	c.dirty = true
+	c.mu.Unlock()
+
+	if err := c.validate(); err != nil {
+		return err
+	}
+	return c.Save()
+}
+
+// SetPodmanRetries sets the Podman API retry count and saves to file.
+func (c *Config) SetPodmanRetries(retries int) error {
+	c.mu.Lock()
+	c.podmanRetries = retries
+	c.dirty = true
+	c.mu.Unlock()
+
+	if err := c.validate(); err != nil {
+		return err
+	}
+	return c.Save()
+}
+
+// SetJSONMaxBytes sets the JSON request body size limit and saves to file.
+func (c *Config) SetJSONMaxBytes(size int64) error {
+	c.mu.Lock()
+	c.jsonMaxBytes = size
+	c.dirty = true
+	c.mu.Unlock()
+
+	if err := c.validate(); err != nil {
+		return err
+	}
+	return c.Save()
+}
+
+// SetLogMaxBytes sets the container logs size limit and saves to file.
+func (c *Config) SetLogMaxBytes(size int64) error {
+	c.mu.Lock()
+	c.logMaxBytes = size
+	c.dirty = true
+	c.mu.Unlock()
+
+	if err := c.validate(); err != nil {
+		return err
+	}
+	return c.Save()
+}
+
+// SetImageScanner sets the image scanner command and saves to file.
+func (c *Config) SetImageScanner(command string) error {
+	c.mu.Lock()
+	c.imageScanner = command
+	c.dirty = true
+	c.mu.Unlock()
+
+	return c.Save()
+}
+
+// SetDevDir sets the development source directory and saves to file.
+func (c *Config) SetDevDir(dir string) error {
+	c.mu.Lock()
+	c.devDir = dir
+	c.dirty = true
+	c.mu.Unlock()
+
+	return c.Save()
+}
+
+// SetPluginStopTimeout sets the per-plugin shutdown timeout and saves to file.
+func (c *Config) SetPluginStopTimeout(d time.Duration) error {
+	c.mu.Lock()
+	c.pluginStopTimeout = d
+	c.dirty = true
+	c.mu.Unlock()
+
+	if err := c.validate(); err != nil {
+		return err
+	}
+	return c.Save()
+}
+
+// SetPluginHTTPTimeout sets the plugin HTTP client timeout and saves to file.
+func (c *Config) SetPluginHTTPTimeout(d time.Duration) error {
+	c.mu.Lock()
+	c.pluginHTTPTimeout = d
+	c.dirty = true
+	c.mu.Unlock()
+
+	if err := c.validate(); err != nil {
+		return err
+	}
+	return c.Save()
+}
+
+// SetHTTPProxy sets the outbound proxy URL and saves to file.
+func (c *Config) SetHTTPProxy(proxy string) error {
+	c.mu.Lock()
+	c.httpProxy = proxy
+	c.dirty = true
+	c.mu.Unlock()
+
+	if err := c.validate(); err != nil {
+		return err
+	}
+	return c.Save()
+}
+
+// SetMQTTBroker sets the MQTT broker address and saves to file.
+func (c *Config) SetMQTTBroker(broker string) error {
+	if err := validateMQTTBroker(broker); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.mqttBroker = broker
+	c.dirty = true
+	c.mu.Unlock()
+
+	return c.Save()
+}
+
+// SetMQTTClientID sets the MQTT client ID and saves to file.
+func (c *Config) SetMQTTClientID(clientID string) error {
+	c.mu.Lock()
+	c.mqttClientID = clientID
+	c.dirty = true
+	c.mu.Unlock()
+
+	return c.Save()
+}
+
+// SetMQTTUsername sets the MQTT username and saves to file.
+func (c *Config) SetMQTTUsername(username string) error {
+	c.mu.Lock()
+	c.mqttUsername = username
+	c.dirty = true
+	c.mu.Unlock()
+
+	return c.Save()
+}
+
+// SetMQTTPassword sets the MQTT password and saves to file.
+func (c *Config) SetMQTTPassword(password string) error {
+	c.mu.Lock()
+	c.mqttPassword = password
+	c.dirty = true
+	c.mu.Unlock()
+
+	return c.Save()
+}
+
+// SetMQTTPrefix sets the MQTT topic prefix and saves to file.
+func (c *Config) SetMQTTPrefix(prefix string) error {
+	if err := validateMQTTPrefix(prefix); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.mqttPrefix = prefix
+	c.dirty = true
+	c.mu.Unlock()
+
+	return c.Save()
+}
+
+// SetMQTTUseTLS sets the MQTT TLS flag and saves to file.
+func (c *Config) SetMQTTUseTLS(useTLS bool) error {
+	c.mu.Lock()
+	c.mqttUseTLS = useTLS
+	c.dirty = true
+	c.mu.Unlock()
+
+	return c.Save()
+}
+
+// SetMQTTSettings replaces all MQTT settings at once and saves to file.
+func (c *Config) SetMQTTSettings(m MQTTSettings) error {
+	if err := validateMQTTBroker(m.Broker); err != nil {
+		return err
+	}
+	if err := validateMQTTPrefix(m.Prefix); err != nil {
+		return err
+	}
+	if err := validateMQTTTimings(m.KeepAlive, m.ReconnectMax); err != nil {
+		return err
+	}
+	if err := validateMQTTDeviceID(m.DeviceID); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.mqttBroker = m.Broker
+	c.mqttClientID = m.ClientID
+	c.mqttUsername = m.Username
+	c.mqttPassword = m.Password
+	c.mqttPrefix = m.Prefix
+	c.mqttUseTLS = m.UseTLS
+	c.mqttKeepAlive = m.KeepAlive
+	c.mqttReconnectMax = m.ReconnectMax
+	c.mqttDeviceID = m.DeviceID
+	c.mqttDeviceName = m.DeviceName
+	c.dirty = true
+	c.mu.Unlock()
+
+	return c.Save()
+}
+
+// SetGeneralSettings replaces the general settings at once and saves to file.
+// Nothing is changed if any value is invalid.
+func (c *Config) SetGeneralSettings(g GeneralSettings) error {
+	c.mu.Lock()
+	previous := c.toMap()
+	c.addr = g.Addr
+	c.jwtExpiration = g.JWTExpiration
+	c.noAuth = g.NoAuth
+	c.socketPath = g.Socket
+	c.podmanTimeout = g.PodmanTimeout
+	c.podmanRetries = g.PodmanRetries
+	c.logMaxBytes = g.LogMaxBytes
+	c.logTimezone = g.LogTimezone
+	c.imageScanner = g.ImageScanner
+	c.trustedProxies = g.TrustedProxies
+	c.reverseDNS = g.ReverseDNS
+
+	if err := c.validate(); err != nil {
+		c.setDefaults()
+		c.applyValues(previous)
+		c.mu.Unlock()
+		return err
+	}
+	c.dirty = true
+	c.mu.Unlock()
+
+	return c.Save()
+}
+
+// Values returns all settings as they are written to .env.
+func (c *Config) Values() map[string]string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.toMap()
+}
+
+// Import applies values on top of the current settings, validates and
+// saves them, and returns the keys whose values changed, sorted. Unknown
+// keys are rejected; if the result is invalid nothing changes.
+func (c *Config) Import(values map[string]string) ([]string, error) {
+	c.mu.Lock()
+	previous := c.toMap()
+	for key := range values {
+		if _, ok := previous[key]; !ok {
+			c.mu.Unlock()
+			return nil, fmt.Errorf("unknown setting %s", key)
+		}
+	}
+
+	c.applyValues(values)
+	if err := c.validate(); err != nil {
+		c.setDefaults()
+		c.applyValues(previous)
+		c.mu.Unlock()
+		return nil, err
+	}
+
+	var changed []string
+	for key, value := range c.toMap() {
+		if previous[key] != value {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+	c.dirty = true
+	c.mu.Unlock()
+
+	return changed, c.Save()
+}
+
 // Helper functions
 
 // generateSecureSecret generates a cryptographically secure random hex string.
@@ -449,31 +2079,87 @@ func parseBool(s string) bool {
 	}
 }
 
-// Reload reloads configuration from file.
-// Useful for hot-reloading configuration.
-func (c *Config) Reload() error {
+// formatUnix formats t as Unix seconds, empty for the zero time.
+func formatUnix(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return strconv.FormatInt(t.Unix(), 10)
+}
+
+// splitList splits a comma-separated value, dropping empty items.
+func splitList(s string) []string {
+	var result []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// isContentTypePattern reports whether s is a media type such as
+// text/plain, or a whole type such as text/*
+func isContentTypePattern(s string) bool {
+	major, minor, ok := strings.Cut(s, "/")
+	if !ok || major == "" || minor == "" || strings.ContainsAny(s, " ;,") || strings.Contains(major, "*") {
+		return false
+	}
+	return minor == "*" || !strings.Contains(minor, "*")
+}
+
+// splitDNList splits a semicolon-separated list, since DNs contain commas.
+func splitDNList(s string) []string {
+	var result []string
+	for _, item := range strings.Split(s, ";") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// Reload reloads configuration from file and returns the keys whose
+// values changed, sorted. If the file is invalid the running
+// configuration is kept and the validation error returned.
+func (c *Config) Reload() ([]string, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	// Save current JWT secret in case file doesn't have one
-	currentSecret := c.jwtSecret
+	previous := c.toMap()
+	restore := func() {
+		c.setDefaults()
+		c.applyValues(previous)
+	}
 
 	// Reset to defaults
 	c.setDefaults()
 
 	// Load from file
-	if err := c.loadFromFile(); err != nil {
-		if !os.IsNotExist(err) {
-			return err
-		}
+	if err := c.loadFromFile(); err != nil && !os.IsNotExist(err) {
+		restore()
+		return nil, err
 	}
 
 	// Restore JWT secret if not in file
 	if c.jwtSecret == "" {
-		c.jwtSecret = currentSecret
+		c.jwtSecret = previous[EnvJWTSecret]
+	}
+
+	if err := c.validate(); err != nil {
+		restore()
+		return nil, err
+	}
+
+	var changed []string
+	for key, value := range c.toMap() {
+		if previous[key] != value {
+			changed = append(changed, key)
+		}
 	}
+	sort.Strings(changed)
 
-	return c.validate()
+	return changed, nil
 }
 
 // String returns a string representation of the config (without secrets).
@@ -487,7 +2173,7 @@ func (c *Config) String() string {
 	}
 
 	return fmt.Sprintf(
-		"Config{Addr: %q, JWTSecret: %s, JWTExpiration: %v, NoAuth: %v, SocketPath: %q}",
-		c.addr, secretDisplay, c.jwtExpiration, c.noAuth, c.socketPath,
+		"Config{Addr: %q, JWTSecret: %s, JWTExpiration: %v, NoAuth: %v, TLS: %v, SocketPath: %q, PodmanTimeout: %v}",
+		c.addr, secretDisplay, c.jwtExpiration, c.noAuth, c.tlsCert != "" || c.tlsSelfSigned, c.socketPath, c.podmanTimeout,
 	)
 }
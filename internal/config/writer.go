@@ -24,7 +24,19 @@ var envTemplate = []envEntry{
 	{"", "# Server Settings"},
 	{"", "# ==================="},
 	{"", ""},
-	{"PODMANVIEW_ADDR", "# Server address (host:port)"},
+	{"PODMANVIEW_ADDR", "# Server address (host:port, or unix:/path to listen on a Unix socket)"},
+	{"PODMANVIEW_ADDR_MODE", "# Permissions of the Unix listen socket (octal, default: 0660)"},
+	{"PODMANVIEW_DEV_DIR", "# Serve web assets and plugin HTML from this source checkout instead of the embedded copies (development only)"},
+	{"PODMANVIEW_DATA_DIR", "# Absolute directory for podmanview.db with plugin settings and command history, created if missing (leave empty for the working directory)"},
+	{"PODMANVIEW_PPROF", "# Serve Go profiling endpoints at /debug/pprof/ to admins, for diagnosing memory and goroutine leaks (default: false)"},
+	{"PODMANVIEW_HOST_PROC", "# Host's /proc bind-mounted into the PodmanView container for host stats, e.g. /host/proc (empty: /host/proc if mounted, otherwise /proc)"},
+	{"PODMANVIEW_STATS_INTERVAL", "# Seconds between CPU usage samples shown in host stats (1-60, default: 2)"},
+	{"PODMANVIEW_PLUGIN_STOP_TIMEOUT", "# Seconds each plugin may take to stop on shutdown before it is skipped"},
+	{"PODMANVIEW_PLUGIN_HTTP_TIMEOUT", "# Timeout in seconds for outbound HTTP requests made by plugins"},
+	{"PODMANVIEW_HTTP_PROXY", "# Proxy for outbound plugin requests, e.g. http://proxy.lan:3128 (empty: HTTP_PROXY/HTTPS_PROXY environment)"},
+	{"PODMANVIEW_JSON_MAX_BYTES", "# Maximum size of a JSON request body in bytes, after gzip decoding (file uploads have their own limit)"},
+	{"PODMANVIEW_COMPRESS_LEVEL", "# Gzip level of responses (1-9, 0 disables compression; file downloads and streams are never compressed)"},
+	{"PODMANVIEW_COMPRESS_TYPES", "# Comma-separated content types of responses that are compressed, e.g. text/*,application/json"},
 	{"", ""},
 	{"", "# ==================="},
 	{"", "# Security Settings"},
@@ -32,13 +44,48 @@ var envTemplate = []envEntry{
 	{"", ""},
 	{"PODMANVIEW_JWT_SECRET", "# JWT secret key (auto-generated, do not share!)"},
 	{"PODMANVIEW_JWT_EXPIRATION", "# JWT token expiration in seconds (default: 24 hours)"},
+	{"PODMANVIEW_JWT_GRACE_PERIOD", "# Seconds tokens signed with the previous secret stay valid after a rotation (default: 24 hours)"},
+	{"PODMANVIEW_JWT_PREVIOUS_SECRET", "# Secret replaced by the last rotation (managed by PodmanView)"},
+	{"PODMANVIEW_JWT_PREVIOUS_UNTIL", "# Unix time the previous secret expires (managed by PodmanView)"},
 	{"PODMANVIEW_NO_AUTH", "# Disable authentication (true/false, for development only!)"},
+	{"PODMANVIEW_AUTH_BACKEND", "# How logins are checked: pam (local Linux accounts) or ldap (PODMANVIEW_LDAP_* settings)"},
+	{"PODMANVIEW_LDAP_URL", "# LDAP server, ldap://host:389 or ldaps://host:636"},
+	{"PODMANVIEW_LDAP_STARTTLS", "# Upgrade ldap:// connections with StartTLS (true/false)"},
+	{"PODMANVIEW_LDAP_BIND_DN", "# Service account DN used to search for users (leave empty for an anonymous search)"},
+	{"PODMANVIEW_LDAP_BIND_PASSWORD", "# Service account password"},
+	{"PODMANVIEW_LDAP_BASE_DN", "# Subtree users are searched in, e.g. ou=people,dc=example,dc=com"},
+	{"PODMANVIEW_LDAP_USER_FILTER", "# Filter finding a user, %s is the username (default: (uid=%s), Active Directory: (sAMAccountName=%s))"},
+	{"PODMANVIEW_LDAP_ADMIN_GROUPS", "# Semicolon-separated group DNs or CNs (from memberOf) whose members are admins; everyone else is read-only"},
+	{"PODMANVIEW_OIDC_ISSUER", "# OpenID Connect provider for single sign-on, e.g. https://auth.example.com/application/o/podmanview/ (empty = disabled)"},
+	{"PODMANVIEW_OIDC_CLIENT_ID", "# Client ID registered at the provider"},
+	{"PODMANVIEW_OIDC_CLIENT_SECRET", "# Client secret (empty for a public client)"},
+	{"PODMANVIEW_OIDC_REDIRECT_URL", "# Absolute callback URL registered at the provider, e.g. https://pi.lan/api/auth/oidc/callback"},
+	{"PODMANVIEW_OIDC_SCOPES", "# Comma-separated scopes to request (default: openid,profile,email)"},
+	{"PODMANVIEW_OIDC_ROLE_CLAIM", "# ID token claim with the user's groups or roles, dots for nested claims (default: groups)"},
+	{"PODMANVIEW_OIDC_ADMIN_VALUES", "# Comma-separated values of the role claim that grant admin; everyone else is read-only"},
+	{"PODMANVIEW_TLS_CERT", "# TLS certificate file (PEM). Set together with PODMANVIEW_TLS_KEY to serve HTTPS"},
+	{"PODMANVIEW_TLS_KEY", "# TLS private key file (PEM)"},
+	{"PODMANVIEW_TLS_SELF_SIGNED", "# Serve HTTPS with a generated self-signed certificate when no certificate is set (true/false)"},
+	{"PODMANVIEW_HTTP_REDIRECT_ADDR", "# Plain HTTP listener redirecting to HTTPS when TLS is enabled, e.g. :80 (leave empty to disable)"},
+	{"PODMANVIEW_CSP", "# Content-Security-Policy header (leave empty for the built-in policy)"},
+	{"PODMANVIEW_FRAME_ANCESTORS", "# Sources allowed to embed the UI in a frame, e.g. 'self' https://ha.local:8123 ('none' forbids embedding)"},
+	{"PODMANVIEW_CORS_ORIGINS", "# Comma-separated origins of other frontends allowed to call the API, e.g. https://dash.example.com (leave empty to disable CORS)"},
+	{"PODMANVIEW_PLUGIN_SANDBOX", "# Render plugin pages in sandboxed iframes isolated from the app's cookies and DOM (true/false)"},
+	{"PODMANVIEW_TRUSTED_PROXIES", "# Comma-separated proxy IPs/CIDRs allowed to set X-Forwarded-For / X-Real-IP"},
+	{"PODMANVIEW_REVERSE_DNS", "# Resolve client IPs to hostnames in the event log (true/false)"},
+	{"PODMANVIEW_TERMINAL_ALLOW", "# Comma-separated command prefixes the host terminal may run, e.g. podman,systemctl status (leave empty to allow all)"},
+	{"PODMANVIEW_TERMINAL_DENY", "# Comma-separated command prefixes the host terminal may not run, e.g. rm -rf,reboot"},
 	{"", ""},
 	{"", "# ==================="},
 	{"", "# Podman Settings"},
 	{"", "# ==================="},
 	{"", ""},
-	{"PODMANVIEW_SOCKET", "# Podman socket path (leave empty for auto-detection)"},
+	{"PODMANVIEW_SOCKET", "# Podman socket path or URI: unix:///path, tcp://host:port, ssh://user@host/run/podman/podman.sock (leave empty for auto-detection)"},
+	{"PODMANVIEW_PODMAN_TIMEOUT", "# Default timeout for Podman API calls in seconds (image pulls use a longer limit)"},
+	{"PODMANVIEW_PODMAN_RETRIES", "# Retries for read-only Podman API calls on transient errors (0 disables)"},
+	{"PODMANVIEW_LOG_MAX_BYTES", "# Maximum size of a container logs response in bytes (older lines are dropped)"},
+	{"PODMANVIEW_LOG_TIMEZONE", "# Time zone of container log timestamps, e.g. Europe/Berlin (leave empty for the server's local time zone)"},
+	{"PODMANVIEW_IMAGE_SCANNER", "# Image vulnerability scanner command, trivy or grype (leave empty for auto-detection)"},
 }
 
 // WriteEnvFile writes configuration to .env file with comments.
@@ -0,0 +1,113 @@
+package events
+
+import (
+	"container/list"
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Reverse DNS settings
+const (
+	resolverTimeout  = 2 * time.Second
+	resolverCacheTTL = 1 * time.Hour
+)
+
+// hostEntry is a cached reverse DNS result (empty name = no PTR record)
+type hostEntry struct {
+	ip      string
+	name    string
+	expires time.Time
+}
+
+// HostResolver resolves IPs to hostnames with a lookup timeout, caching
+// up to maxSize results and evicting the least recently used
+type HostResolver struct {
+	mu      sync.Mutex
+	cache   map[string]*list.Element // ip -> element holding a hostEntry
+	order   *list.List               // most recently used first
+	maxSize int
+}
+
+// NewHostResolver creates a reverse DNS resolver caching up to maxSize IPs
+func NewHostResolver(maxSize int) *HostResolver {
+	return &HostResolver{
+		cache:   make(map[string]*list.Element),
+		order:   list.New(),
+		maxSize: maxSize,
+	}
+}
+
+// Cached returns a cached hostname for ip, if present and not expired
+func (r *HostResolver) Cached(ip string) (string, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	elem, ok := r.cache[ip]
+	if !ok {
+		return "", false
+	}
+	entry := elem.Value.(*hostEntry)
+	if time.Now().After(entry.expires) {
+		return "", false
+	}
+	r.order.MoveToFront(elem)
+	return entry.name, true
+}
+
+// Len returns the number of cached IPs, expired ones included
+func (r *HostResolver) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.order.Len()
+}
+
+// store caches the hostname for ip. Expired entries are dropped first,
+// then the least recently used while the cache is full.
+func (r *HostResolver) store(ip, name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if elem, ok := r.cache[ip]; ok {
+		*elem.Value.(*hostEntry) = hostEntry{ip: ip, name: name, expires: now.Add(resolverCacheTTL)}
+		r.order.MoveToFront(elem)
+		return
+	}
+
+	for elem := r.order.Back(); elem != nil; {
+		prev := elem.Prev()
+		if entry := elem.Value.(*hostEntry); now.After(entry.expires) {
+			r.order.Remove(elem)
+			delete(r.cache, entry.ip)
+		}
+		elem = prev
+	}
+	for r.order.Len() >= r.maxSize && r.order.Len() > 0 {
+		oldest := r.order.Back()
+		r.order.Remove(oldest)
+		delete(r.cache, oldest.Value.(*hostEntry).ip)
+	}
+	r.cache[ip] = r.order.PushFront(&hostEntry{ip: ip, name: name, expires: now.Add(resolverCacheTTL)})
+}
+
+// Lookup returns the hostname for ip (blocking, bounded by resolverTimeout).
+// Failed lookups are cached too, so unresolvable IPs aren't retried constantly.
+func (r *HostResolver) Lookup(ip string) string {
+	if name, ok := r.Cached(ip); ok {
+		return name
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), resolverTimeout)
+	defer cancel()
+
+	name := ""
+	if names, err := net.DefaultResolver.LookupAddr(ctx, ip); err == nil && len(names) > 0 {
+		name = strings.TrimSuffix(names[0], ".")
+	}
+
+	r.store(ip, name)
+	return name
+}
@@ -10,29 +10,59 @@ type EventType string
 
 const (
 	// Auth events
-	EventLogin       EventType = "login"
-	EventLoginFailed EventType = "login_failed"
-	EventLogout      EventType = "logout"
+	EventLogin          EventType = "login"
+	EventLoginFailed    EventType = "login_failed"
+	EventLoginLockout   EventType = "login_lockout" // IP blocked after too many failed logins
+	EventLogout         EventType = "logout"
+	EventPasswordChange EventType = "password_change"
 
 	// Terminal events
 	EventTerminalHost      EventType = "terminal_host"
 	EventTerminalContainer EventType = "terminal_container"
+	EventTerminalBlocked   EventType = "terminal_blocked" // command rejected by the host terminal filter
+	EventTerminalHistory   EventType = "terminal_history" // command history cleared or entry removed
 
 	// Container events
-	EventContainerStart   EventType = "container_start"
-	EventContainerStop    EventType = "container_stop"
-	EventContainerRestart EventType = "container_restart"
-	EventContainerRemove  EventType = "container_remove"
-	EventContainerCreate  EventType = "container_create"
+	EventContainerStart    EventType = "container_start"
+	EventContainerStop     EventType = "container_stop"
+	EventContainerRestart  EventType = "container_restart"
+	EventContainerRemove   EventType = "container_remove"
+	EventContainerCreate   EventType = "container_create"
+	EventContainerRun      EventType = "container_run"      // one-shot container, removed after it exits
+	EventContainerRecreate EventType = "container_recreate" // removed and created again with changed settings
+	EventContainerRedeploy EventType = "container_redeploy" // recreated on a newly pulled image
+	EventContainerSystemd  EventType = "container_systemd"
 
 	// Image events
 	EventImagePull   EventType = "image_pull"
 	EventImageRemove EventType = "image_remove"
+	EventImageScan   EventType = "image_scan"
+	EventImagePrune  EventType = "image_prune"
+	EventImageUpdate EventType = "image_update" // newer image available for a running container
+
+	// Volume events
+	EventVolumeBackup EventType = "volume_backup"
+	EventVolumePrune  EventType = "volume_prune"
 
 	// System events
 	EventSystemReboot   EventType = "system_reboot"
 	EventSystemShutdown EventType = "system_shutdown"
 	EventSystemUpdate   EventType = "system_update"
+	EventSystemRestart  EventType = "system_restart" // PodmanView itself
+
+	// Plugin events
+	EventPluginEnable  EventType = "plugin_enable"
+	EventPluginDisable EventType = "plugin_disable"
+	EventPluginError   EventType = "plugin_error"
+
+	// Settings events
+	EventSettingsUpdate EventType = "settings_update"
+
+	// MQTT events (connection state of the shared client, including reconnects)
+	EventMQTTConnected    EventType = "mqtt_connected"
+	EventMQTTDisconnected EventType = "mqtt_disconnected" // failed when the connection was lost
+	EventMQTTPublish      EventType = "mqtt_publish"      // message published by an admin for debugging
+	EventMQTTRediscover   EventType = "mqtt_rediscover"   // Home Assistant discovery of a plugin republished
 
 	// File manager events
 	EventFileBrowse   EventType = "file_browse"
@@ -43,6 +73,7 @@ const (
 	EventFileRename   EventType = "file_rename"
 	EventFileRead     EventType = "file_read"
 	EventFileWrite    EventType = "file_write"
+	EventFileBatch    EventType = "file_batch"
 )
 
 // Event represents a security/audit event
@@ -52,8 +83,45 @@ type Event struct {
 	Timestamp time.Time `json:"timestamp"`
 	Username  string    `json:"username"`
 	IP        string    `json:"ip"`
+	Hostname  string    `json:"hostname,omitempty"` // Reverse DNS of IP (if enabled)
 	Success   bool      `json:"success"`
-	Details   string    `json:"details,omitempty"`
+	Details   string    `json:"details,omitempty"` // Human-readable summary for display
+	Meta      Meta      `json:"meta,omitempty"`    // Structured fields for filtering
+}
+
+// Meta holds structured event fields (container, image, path, plugin, etc.)
+type Meta map[string]string
+
+// Filter selects events by field values. Zero-value fields match everything.
+type Filter struct {
+	Type     EventType
+	Username string
+	Success  *bool
+	SinceID  int64
+	Meta     Meta // all keys must match exactly
+	Limit    int  // 0 means no limit
+}
+
+// Matches reports whether the event passes the filter
+func (f Filter) Matches(e Event) bool {
+	if f.Type != "" && e.Type != f.Type {
+		return false
+	}
+	if f.Username != "" && e.Username != f.Username {
+		return false
+	}
+	if f.Success != nil && e.Success != *f.Success {
+		return false
+	}
+	if e.ID <= f.SinceID {
+		return false
+	}
+	for k, v := range f.Meta {
+		if e.Meta[k] != v {
+			return false
+		}
+	}
+	return true
 }
 
 // Store holds events in memory with a fixed capacity (ring buffer)
@@ -62,6 +130,9 @@ type Store struct {
 	events  []Event
 	maxSize int
 	nextID  int64
+
+	resolver    *HostResolver // optional reverse DNS for event IPs
+	subscribers []func(Event)
 }
 
 // NewStore creates a new event store with specified max capacity
@@ -72,8 +143,28 @@ func NewStore(maxSize int) *Store {
 	}
 }
 
+// SetResolver enables hostname enrichment of new events
+func (s *Store) SetResolver(r *HostResolver) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.resolver = r
+}
+
+// Subscribe calls fn with every new event, for notifications (MQTT, ...).
+// fn runs in its own goroutine so slow notifiers never block callers.
+func (s *Store) Subscribe(fn func(Event)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subscribers = append(s.subscribers, fn)
+}
+
 // Add adds a new event to the store
 func (s *Store) Add(eventType EventType, username, ip string, success bool, details string) {
+	s.AddWithMeta(eventType, username, ip, success, details, nil)
+}
+
+// AddWithMeta adds a new event with structured metadata to the store
+func (s *Store) AddWithMeta(eventType EventType, username, ip string, success bool, details string, meta Meta) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -86,6 +177,7 @@ func (s *Store) Add(eventType EventType, username, ip string, success bool, deta
 		IP:        ip,
 		Success:   success,
 		Details:   details,
+		Meta:      meta,
 	}
 
 	// Ring buffer: remove oldest if at max capacity
@@ -93,6 +185,41 @@ func (s *Store) Add(eventType EventType, username, ip string, success bool, deta
 		s.events = s.events[1:]
 	}
 	s.events = append(s.events, event)
+
+	for _, fn := range s.subscribers {
+		go fn(event)
+	}
+
+	// Enrich with hostname: use cache if possible, otherwise resolve
+	// in background so callers are never blocked by DNS
+	if s.resolver != nil && ip != "" {
+		if name, ok := s.resolver.Cached(ip); ok {
+			s.events[len(s.events)-1].Hostname = name
+		} else {
+			go s.resolveHostname(event.ID, ip, s.resolver)
+		}
+	}
+}
+
+// resolveHostname looks up ip and stores the result on event id
+func (s *Store) resolveHostname(id int64, ip string, resolver *HostResolver) {
+	name := resolver.Lookup(ip)
+	if name == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := len(s.events) - 1; i >= 0; i-- {
+		if s.events[i].ID == id {
+			s.events[i].Hostname = name
+			return
+		}
+		if s.events[i].ID < id {
+			return
+		}
+	}
 }
 
 // GetAll returns all events (newest first)
@@ -140,6 +267,27 @@ func (s *Store) GetSince(lastID int64) []Event {
 	return result
 }
 
+// Filter returns events matching f (newest first)
+func (s *Store) Filter(f Filter) []Event {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]Event, 0)
+	for i := len(s.events) - 1; i >= 0; i-- {
+		if s.events[i].ID <= f.SinceID {
+			break
+		}
+		if !f.Matches(s.events[i]) {
+			continue
+		}
+		result = append(result, s.events[i])
+		if f.Limit > 0 && len(result) >= f.Limit {
+			break
+		}
+	}
+	return result
+}
+
 // Count returns the total number of events
 func (s *Store) Count() int {
 	s.mu.RLock()
@@ -5,6 +5,7 @@ import (
 	"crypto/tls"
 	"fmt"
 	"log"
+	"os"
 	"sync"
 	"time"
 
@@ -19,6 +20,30 @@ type Config struct {
 	Password string // MQTT password (optional)
 	Prefix   string // Topic prefix for all messages
 	UseTLS   bool   // Enable TLS connection
+
+	KeepAlive            time.Duration // Interval between pings (default 30s)
+	MaxReconnectInterval time.Duration // Longest wait between reconnect attempts (default 10s)
+
+	// Home Assistant device the discovered sensors of this host belong to
+	Hostname   string // Host name (default: os.Hostname())
+	DeviceID   string // Device ID (default: Hostname)
+	DeviceName string // Device name (default: "PodmanView <Hostname>")
+}
+
+// Defaults for the keepalive and reconnect backoff when Config leaves them zero
+const (
+	defaultKeepAlive            = 30 * time.Second
+	defaultMaxReconnectInterval = 10 * time.Second
+)
+
+// State is the connection state of the client. Unlike IsConnected it is
+// kept across reconnects, so it tells since when and why it is down.
+type State struct {
+	Connected         bool
+	Broker            string
+	Since             time.Time // when Connected last changed
+	LastError         string    // why the connection was last lost or failed
+	ReconnectAttempts int       // since the connection was lost
 }
 
 // Client wraps the MQTT client with additional functionality
@@ -28,6 +53,12 @@ type Client struct {
 	mu       sync.RWMutex
 	logger   *log.Logger
 	isActive bool
+
+	// Connection state, guarded separately from mu because paho calls
+	// the connection handlers while Connect holds mu
+	stateMu       sync.Mutex
+	state         State
+	onStateChange func(State)
 }
 
 // New creates a new MQTT client
@@ -40,11 +71,22 @@ func New(cfg Config, logger *log.Logger) (*Client, error) {
 		cfg.ClientID = fmt.Sprintf("podmanview-%d", time.Now().Unix())
 	}
 
+	if cfg.Hostname == "" {
+		cfg.Hostname, _ = os.Hostname()
+	}
+
 	c := &Client{
 		config: cfg,
 		logger: logger,
+		state:  State{Broker: cfg.Broker, Since: time.Now()},
 	}
 
+	c.client = c.newPahoClient(cfg)
+	return c, nil
+}
+
+// newPahoClient builds the underlying paho client from configuration
+func (c *Client) newPahoClient(cfg Config) mqtt.Client {
 	opts := mqtt.NewClientOptions()
 	opts.AddBroker(cfg.Broker)
 	opts.SetClientID(cfg.ClientID)
@@ -69,33 +111,137 @@ func New(cfg Config, logger *log.Logger) (*Client, error) {
 		if c.logger != nil {
 			c.logger.Printf("[MQTT] Connection lost: %v", err)
 		}
+		c.setState(false, cfg.Broker, err)
 	})
 
 	opts.SetOnConnectHandler(func(client mqtt.Client) {
 		if c.logger != nil {
 			c.logger.Printf("[MQTT] Connected to broker: %s", cfg.Broker)
 		}
+		c.setState(true, cfg.Broker, nil)
 	})
 
 	opts.SetReconnectingHandler(func(client mqtt.Client, options *mqtt.ClientOptions) {
+		c.stateMu.Lock()
+		c.state.ReconnectAttempts++
+		attempt := c.state.ReconnectAttempts
+		c.stateMu.Unlock()
 		if c.logger != nil {
-			c.logger.Printf("[MQTT] Attempting to reconnect...")
+			c.logger.Printf("[MQTT] Attempting to reconnect (attempt %d)...", attempt)
 		}
 	})
 
 	// Auto-reconnect settings
+	maxReconnect := cfg.MaxReconnectInterval
+	if maxReconnect <= 0 {
+		maxReconnect = defaultMaxReconnectInterval
+	}
 	opts.SetAutoReconnect(true)
-	opts.SetMaxReconnectInterval(10 * time.Second)
+	opts.SetMaxReconnectInterval(maxReconnect)
 
 	// Keep alive settings
-	opts.SetKeepAlive(30 * time.Second)
+	keepAlive := cfg.KeepAlive
+	if keepAlive <= 0 {
+		keepAlive = defaultKeepAlive
+	}
+	opts.SetKeepAlive(keepAlive)
 	opts.SetPingTimeout(10 * time.Second)
 
 	// Clean session
 	opts.SetCleanSession(true)
 
-	c.client = mqtt.NewClient(opts)
-	return c, nil
+	return mqtt.NewClient(opts)
+}
+
+// Reconfigure applies new configuration at runtime.
+// The current connection is closed and, if the client was connected,
+// a new connection is established with the new settings.
+func (c *Client) Reconfigure(cfg Config) error {
+	if cfg.Broker == "" {
+		return fmt.Errorf("MQTT broker address is required")
+	}
+
+	if cfg.ClientID == "" {
+		cfg.ClientID = fmt.Sprintf("podmanview-%d", time.Now().Unix())
+	}
+
+	if cfg.Hostname == "" {
+		cfg.Hostname, _ = os.Hostname()
+	}
+
+	c.mu.Lock()
+	wasActive := c.isActive
+	if wasActive {
+		c.client.Disconnect(250)
+		c.isActive = false
+	}
+	previous := c.config.Broker
+	c.config = cfg
+	c.client = c.newPahoClient(cfg)
+	c.mu.Unlock()
+
+	if c.logger != nil {
+		c.logger.Printf("[MQTT] Configuration updated (broker: %s)", cfg.Broker)
+	}
+
+	if wasActive {
+		c.setState(false, previous, nil)
+		return c.Connect()
+	}
+	return nil
+}
+
+// TestConnection verifies MQTT settings with a temporary client:
+// connect, publish a test message and disconnect, all within timeout.
+// It never touches the live client.
+func TestConnection(cfg Config, timeout time.Duration) error {
+	if cfg.Broker == "" {
+		return fmt.Errorf("MQTT broker address is required")
+	}
+
+	if cfg.ClientID == "" {
+		cfg.ClientID = fmt.Sprintf("podmanview-test-%d", time.Now().UnixNano())
+	}
+
+	opts := mqtt.NewClientOptions()
+	opts.AddBroker(cfg.Broker)
+	opts.SetClientID(cfg.ClientID)
+	opts.SetUsername(cfg.Username)
+	opts.SetPassword(cfg.Password)
+	if cfg.UseTLS {
+		opts.SetTLSConfig(&tls.Config{InsecureSkipVerify: false})
+	}
+
+	// Fail fast: no retries, short timeout
+	opts.SetAutoReconnect(false)
+	opts.SetConnectRetry(false)
+	opts.SetConnectTimeout(timeout)
+
+	client := mqtt.NewClient(opts)
+
+	token := client.Connect()
+	if !token.WaitTimeout(timeout) {
+		return fmt.Errorf("connection timed out after %v (broker unreachable?)", timeout)
+	}
+	if err := token.Error(); err != nil {
+		return fmt.Errorf("connection failed: %w", err)
+	}
+	defer client.Disconnect(250)
+
+	topic := "test"
+	if cfg.Prefix != "" {
+		topic = cfg.Prefix + "/" + topic
+	}
+
+	pub := client.Publish(topic, 0, false, []byte("podmanview connection test"))
+	if !pub.WaitTimeout(timeout) {
+		return fmt.Errorf("publish timed out after %v", timeout)
+	}
+	if err := pub.Error(); err != nil {
+		return fmt.Errorf("publish failed: %w", err)
+	}
+
+	return nil
 }
 
 // Connect establishes connection to MQTT broker
@@ -113,6 +259,7 @@ func (c *Client) Connect() error {
 
 	token := c.client.Connect()
 	if token.Wait() && token.Error() != nil {
+		c.setState(false, c.config.Broker, token.Error())
 		return fmt.Errorf("failed to connect to MQTT broker: %w", token.Error())
 	}
 
@@ -127,18 +274,61 @@ func (c *Client) Connect() error {
 // Disconnect closes connection to MQTT broker
 func (c *Client) Disconnect() {
 	c.mu.Lock()
-	defer c.mu.Unlock()
-
 	if !c.isActive {
+		c.mu.Unlock()
 		return
 	}
 
 	c.client.Disconnect(250) // Wait up to 250ms for graceful disconnect
 	c.isActive = false
+	broker := c.config.Broker
+	c.mu.Unlock()
 
 	if c.logger != nil {
 		c.logger.Printf("[MQTT] Disconnected from broker")
 	}
+	c.setState(false, broker, nil)
+}
+
+// State returns the current connection state
+func (c *Client) State() State {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	return c.state
+}
+
+// OnStateChange sets a function called each time the client connects or
+// disconnects, including automatic reconnects. It replaces the previous one.
+func (c *Client) OnStateChange(fn func(State)) {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	c.onStateChange = fn
+}
+
+// setState records a connection state; err is the reason a connection was
+// lost or failed, nil for an intentional disconnect. The callback runs only
+// when Connected changes.
+func (c *Client) setState(connected bool, broker string, err error) {
+	c.stateMu.Lock()
+	changed := c.state.Connected != connected
+	c.state.Connected = connected
+	c.state.Broker = broker
+	if changed {
+		c.state.Since = time.Now()
+	}
+	if connected {
+		c.state.LastError = ""
+		c.state.ReconnectAttempts = 0
+	} else if err != nil {
+		c.state.LastError = err.Error()
+	}
+	state := c.state
+	fn := c.onStateChange
+	c.stateMu.Unlock()
+
+	if changed && fn != nil {
+		fn(state)
+	}
 }
 
 // Publish publishes a message to the specified topic with QoS 0 (default for telemetry)
@@ -193,6 +383,31 @@ func (c *Client) PublishRaw(topic string, payload interface{}, retained bool) er
 	return nil
 }
 
+// PublishRawWithQoS publishes a message to topic as given, without the
+// prefix, waiting at most timeout for the broker to acknowledge it
+func (c *Client) PublishRawWithQoS(topic string, qos byte, retained bool, payload interface{}, timeout time.Duration) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if !c.isActive {
+		return fmt.Errorf("MQTT client is not connected")
+	}
+
+	token := c.client.Publish(topic, qos, retained, payload)
+	if !token.WaitTimeout(timeout) {
+		return fmt.Errorf("publish timed out after %v", timeout)
+	}
+	if err := token.Error(); err != nil {
+		return fmt.Errorf("failed to publish message: %w", err)
+	}
+
+	if c.logger != nil {
+		c.logger.Printf("[MQTT] Published (raw) to %s (QoS %d, retained %v)", topic, qos, retained)
+	}
+
+	return nil
+}
+
 // buildTopic constructs full topic path with prefix
 func (c *Client) buildTopic(topic string) string {
 	if c.config.Prefix == "" {
@@ -0,0 +1,63 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"log"
+	"strconv"
+	"time"
+
+	"podmanview/internal/events"
+)
+
+// LockoutTopic receives a message each time an IP is blocked for too
+// many failed logins (below the configured prefix)
+const LockoutTopic = "security/login_lockout"
+
+// LockoutMessage is the payload published on LockoutTopic
+type LockoutMessage struct {
+	IP        string    `json:"ip"`
+	Hostname  string    `json:"hostname,omitempty"`
+	Attempts  int       `json:"attempts"`
+	Details   string    `json:"details"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// PublishSecurityEvents publishes login lockouts from store to the broker,
+// so brute-force attempts can raise an alert as they happen
+func PublishSecurityEvents(store *events.Store, client *Client, logger *log.Logger) {
+	store.Subscribe(func(e events.Event) {
+		if e.Type != events.EventLoginLockout {
+			return
+		}
+		attempts, _ := strconv.Atoi(e.Meta["attempts"])
+		payload, err := json.Marshal(LockoutMessage{
+			IP:        e.IP,
+			Hostname:  e.Hostname,
+			Attempts:  attempts,
+			Details:   e.Details,
+			Timestamp: e.Timestamp,
+		})
+		if err != nil {
+			return
+		}
+		if err := client.PublishWithQoS(LockoutTopic, 1, false, payload); err != nil && logger != nil {
+			logger.Printf("[MQTT] Failed to publish login lockout: %v", err)
+		}
+	})
+}
+
+// RecordConnectionEvents logs each connect and disconnect of client in
+// store, so broker outages show up in the event log
+func RecordConnectionEvents(store *events.Store, client *Client) {
+	client.OnStateChange(func(s State) {
+		if s.Connected {
+			store.AddWithMeta(events.EventMQTTConnected, "system", "", true, s.Broker, events.Meta{"broker": s.Broker})
+			return
+		}
+		if s.LastError == "" {
+			store.AddWithMeta(events.EventMQTTDisconnected, "system", "", true, s.Broker, events.Meta{"broker": s.Broker})
+			return
+		}
+		store.AddWithMeta(events.EventMQTTDisconnected, "system", "", false, s.Broker+": "+s.LastError, events.Meta{"broker": s.Broker, "error": s.LastError})
+	})
+}
@@ -2,7 +2,9 @@ package mqtt
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
+	"sort"
 	"sync"
 
 	"podmanview/internal/storage"
@@ -21,19 +23,66 @@ type DiscoveryManager struct {
 
 	// State tracking
 	lastSensorCount int
+	published       map[string]bool // discovery topics with a retained config
 	mu              sync.RWMutex
 }
 
+// discoveryTopicsKey is the storage key of the published discovery topics,
+// kept so their retained configs can be removed after a restart too
+const discoveryTopicsKey = "discoveryTopics"
+
 // NewDiscoveryManager creates a new DiscoveryManager instance
 func NewDiscoveryManager(client *Client, logger *log.Logger, storage storage.Storage, pluginName string) *DiscoveryManager {
-	return &DiscoveryManager{
+	d := &DiscoveryManager{
 		mqttClient:       client,
 		logger:           logger,
 		storage:          storage,
 		pluginName:       pluginName,
 		discoveryConfigs: make(map[string][]byte),
 		lastSensorCount:  0,
+		published:        make(map[string]bool),
+	}
+
+	if storage != nil {
+		var topics []string
+		if err := storage.GetJSON(pluginName, discoveryTopicsKey, &topics); err == nil {
+			for _, topic := range topics {
+				d.published[topic] = true
+			}
+		}
 	}
+
+	return d
+}
+
+// Device returns the Home Assistant device of this host, so sensors of
+// several PodmanView hosts on one broker show up as separate devices.
+// Sensors without DeviceInfo belong to it.
+func (d *DiscoveryManager) Device(model string) *DeviceInfo {
+	mqttCfg := d.mqttClient.GetConfig()
+	name := mqttCfg.DeviceName
+	if name == "" {
+		name = "PodmanView " + mqttCfg.Hostname
+	}
+	return &DeviceInfo{
+		Identifiers:  []string{"podmanview_" + d.deviceID()},
+		Name:         name,
+		Model:        model,
+		Manufacturer: "PodmanView",
+	}
+}
+
+// deviceID returns the configured device ID or the sanitized host name,
+// used in discovery topics and unique IDs
+func (d *DiscoveryManager) deviceID() string {
+	mqttCfg := d.mqttClient.GetConfig()
+	if mqttCfg.DeviceID != "" {
+		return mqttCfg.DeviceID
+	}
+	if mqttCfg.Hostname == "" {
+		return "podmanview"
+	}
+	return sanitizeSensorIDFast(mqttCfg.Hostname)
 }
 
 // ShouldRepublishDiscovery checks if discovery configs should be republished
@@ -67,21 +116,41 @@ func (d *DiscoveryManager) PublishDiscoveryConfig(cfg *SensorConfig) error {
 	if cfg == nil {
 		return nil
 	}
+	if err := cfg.validate(); err != nil {
+		return err
+	}
 
 	configJSON := d.generateDiscoveryConfig(cfg)
 	if configJSON == nil {
 		return nil
 	}
 
-	// Topic: homeassistant/sensor/{domain}/{sensor_id}/config
-	discoveryTopic := "homeassistant/sensor/podmanview/" + cfg.SensorID + "/config"
+	discoveryTopic := cfg.discoveryTopic(d.deviceID())
+	if err := d.mqttClient.PublishRaw(discoveryTopic, configJSON, true); err != nil {
+		return err
+	}
 
-	return d.mqttClient.PublishRaw(discoveryTopic, configJSON, true)
+	d.mu.Lock()
+	added := !d.published[discoveryTopic]
+	d.published[discoveryTopic] = true
+	d.mu.Unlock()
+	if added {
+		d.savePublished()
+	}
+	return nil
 }
 
-// PublishMultipleDiscoveryConfigs publishes discovery configs for multiple sensors
+// PublishMultipleDiscoveryConfigs publishes discovery configs for multiple
+// sensors. configs is the complete set: configs published before for
+// sensors that are gone are removed.
 func (d *DiscoveryManager) PublishMultipleDiscoveryConfigs(configs []*SensorConfig) error {
+	current := make(map[string]bool, len(configs))
+	deviceID := d.deviceID()
 	for _, cfg := range configs {
+		if cfg == nil {
+			continue
+		}
+		current[cfg.discoveryTopic(deviceID)] = true
 		if err := d.PublishDiscoveryConfig(cfg); err != nil {
 			if d.logger != nil {
 				d.logger.Printf("[%s] Failed to publish discovery for %s: %v",
@@ -90,6 +159,20 @@ func (d *DiscoveryManager) PublishMultipleDiscoveryConfigs(configs []*SensorConf
 		}
 	}
 
+	d.mu.RLock()
+	var stale []string
+	for topic := range d.published {
+		if !current[topic] {
+			stale = append(stale, topic)
+		}
+	}
+	d.mu.RUnlock()
+	if len(stale) > 0 {
+		if err := d.removeTopics(stale); err != nil && d.logger != nil {
+			d.logger.Printf("[%s] Failed to remove discovery of gone sensors: %v", d.pluginName, err)
+		}
+	}
+
 	// Mark as published
 	d.markDiscoveryPublished()
 
@@ -115,10 +198,31 @@ func (d *DiscoveryManager) generateDiscoveryConfig(cfg *SensorConfig) []byte {
 	mqttCfg := d.mqttClient.GetConfig()
 
 	discoveryConfig := map[string]interface{}{
-		"name":                cfg.Name,
-		"unique_id":           "podmanview_" + cfg.SensorID,
-		"state_topic":         mqttCfg.Prefix + "/" + cfg.StateTopic,
-		"unit_of_measurement": cfg.Unit,
+		"name":        cfg.Name,
+		"unique_id":   "podmanview_" + d.deviceID() + "_" + cfg.SensorID,
+		"state_topic": mqttCfg.Prefix + "/" + cfg.StateTopic,
+	}
+
+	switch cfg.component() {
+	case ComponentBinarySensor:
+		// Binary sensors have no unit, their state is published as JSON true/false
+		discoveryConfig["payload_on"] = "true"
+		discoveryConfig["payload_off"] = "false"
+	case ComponentSwitch:
+		discoveryConfig["command_topic"] = mqttCfg.Prefix + "/" + cfg.CommandTopic
+		discoveryConfig["payload_on"] = "true"
+		discoveryConfig["payload_off"] = "false"
+	default:
+		switch cfg.SensorType {
+		case SensorTypeText:
+		case SensorTypeEnum:
+			discoveryConfig["device_class"] = "enum"
+			discoveryConfig["options"] = cfg.Options
+		default:
+			if cfg.Unit != "" {
+				discoveryConfig["unit_of_measurement"] = cfg.Unit
+			}
+		}
 	}
 
 	// Add optional fields
@@ -141,13 +245,15 @@ func (d *DiscoveryManager) generateDiscoveryConfig(cfg *SensorConfig) []byte {
 	}
 
 	// Device information for grouping in Home Assistant
-	if cfg.DeviceInfo != nil {
-		discoveryConfig["device"] = map[string]interface{}{
-			"identifiers":  cfg.DeviceInfo.Identifiers,
-			"name":         cfg.DeviceInfo.Name,
-			"model":        cfg.DeviceInfo.Model,
-			"manufacturer": cfg.DeviceInfo.Manufacturer,
-		}
+	device := cfg.DeviceInfo
+	if device == nil {
+		device = d.Device("")
+	}
+	discoveryConfig["device"] = map[string]interface{}{
+		"identifiers":  device.Identifiers,
+		"name":         device.Name,
+		"model":        device.Model,
+		"manufacturer": device.Manufacturer,
 	}
 
 	configJSON, err := json.Marshal(discoveryConfig)
@@ -178,11 +284,81 @@ func (d *DiscoveryManager) markDiscoveryPublished() {
 	}
 }
 
-// ClearDiscoveryState clears discovery state (for shutdown)
-func (d *DiscoveryManager) ClearDiscoveryState() {
-	// Could clear retained messages if needed
-	// For now just log
-	if d.logger != nil {
-		d.logger.Printf("[%s] Discovery state cleared", d.pluginName)
+// Reset drops cached discovery configs and the published flag so that
+// discovery is republished on the next update (e.g. after MQTT settings change)
+func (d *DiscoveryManager) Reset() {
+	d.discoveryMu.Lock()
+	d.discoveryConfigs = make(map[string][]byte)
+	d.discoveryMu.Unlock()
+
+	d.mu.Lock()
+	d.lastSensorCount = 0
+	d.mu.Unlock()
+
+	if d.storage != nil {
+		if err := d.storage.SetBool(d.pluginName, "discoveryPublished", false); err != nil {
+			if d.logger != nil {
+				d.logger.Printf("[%s] Failed to reset discovery state: %v", d.pluginName, err)
+			}
+		}
+	}
+}
+
+// RemoveDiscoveryConfigs removes every published discovery config from the
+// broker, so Home Assistant drops the entities instead of keeping them as
+// unavailable. Call it when MQTT or the plugin is disabled, not on shutdown.
+// Discovery is republished on the next update after it is enabled again.
+func (d *DiscoveryManager) RemoveDiscoveryConfigs() error {
+	d.mu.RLock()
+	topics := make([]string, 0, len(d.published))
+	for topic := range d.published {
+		topics = append(topics, topic)
+	}
+	d.mu.RUnlock()
+
+	err := d.removeTopics(topics)
+	d.Reset()
+	if err == nil && len(topics) > 0 && d.logger != nil {
+		d.logger.Printf("[%s] Removed MQTT discovery config for %d sensors", d.pluginName, len(topics))
+	}
+	return err
+}
+
+// removeTopics publishes an empty retained payload to each discovery topic,
+// which is how Home Assistant is told to remove an entity. Topics that
+// could not be cleared stay tracked and are retried next time.
+func (d *DiscoveryManager) removeTopics(topics []string) error {
+	var firstErr error
+	for _, topic := range topics {
+		if err := d.mqttClient.PublishRaw(topic, []byte{}, true); err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to remove discovery config %s: %w", topic, err)
+			}
+			continue
+		}
+		d.mu.Lock()
+		delete(d.published, topic)
+		d.mu.Unlock()
+	}
+	d.savePublished()
+	return firstErr
+}
+
+// savePublished stores the published discovery topics
+func (d *DiscoveryManager) savePublished() {
+	if d.storage == nil {
+		return
+	}
+
+	d.mu.RLock()
+	topics := make([]string, 0, len(d.published))
+	for topic := range d.published {
+		topics = append(topics, topic)
+	}
+	d.mu.RUnlock()
+	sort.Strings(topics)
+
+	if err := d.storage.SetJSON(d.pluginName, discoveryTopicsKey, topics); err != nil && d.logger != nil {
+		d.logger.Printf("[%s] Failed to save discovery topics: %v", d.pluginName, err)
 	}
 }
@@ -34,7 +34,7 @@ func (p *Publisher) PublishSensorState(data *SensorData) error {
 	sensorID := p.getSanitizedID(data.ID)
 
 	// Publish state
-	stateJSON, err := json.Marshal(data.Value)
+	stateJSON, err := encodeState(data.Value)
 	if err != nil {
 		if p.logger != nil {
 			p.logger.Printf("[MQTT Publisher] Failed to marshal sensor state: %v", err)
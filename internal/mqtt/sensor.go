@@ -1,5 +1,10 @@
 package mqtt
 
+import (
+	"encoding/json"
+	"fmt"
+)
+
 // SensorType defines the type of sensor for Home Assistant
 type SensorType string
 
@@ -12,16 +17,42 @@ const (
 	SensorTypeFrequency   SensorType = "frequency"
 	SensorTypePercentage  SensorType = "percentage"
 	SensorTypeBinary      SensorType = "binary_sensor"
+	SensorTypeText        SensorType = "text" // free-form string state
+	SensorTypeEnum        SensorType = "enum" // one of SensorConfig.Options, e.g. "running"/"stopped"
+)
+
+// Component is the Home Assistant entity platform a sensor is discovered as
+type Component string
+
+const (
+	ComponentSensor       Component = "sensor"        // numeric, text or enum state
+	ComponentBinarySensor Component = "binary_sensor" // on/off state
+	ComponentSwitch       Component = "switch"        // on/off state, changed through CommandTopic
 )
 
 // SensorData represents sensor data for MQTT publishing
 type SensorData struct {
 	ID         string                 // Unique sensor ID (will be sanitized)
 	Label      string                 // Human-readable label
-	Value      interface{}            // Current value
+	Value      interface{}            // Current value: number, bool or string
 	Attributes map[string]interface{} // Additional attributes
 }
 
+// encodeState returns the state payload of a sensor value. Strings are
+// published as they are, so Home Assistant shows running rather than
+// "running"; numbers and bools as JSON (true/false match the payload_on
+// and payload_off of binary sensors and switches).
+func encodeState(value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case string:
+		return []byte(v), nil
+	case fmt.Stringer:
+		return []byte(v.String()), nil
+	default:
+		return json.Marshal(v)
+	}
+}
+
 // SensorConfig contains sensor configuration for Home Assistant Discovery
 type SensorConfig struct {
 	// Basic parameters
@@ -43,8 +74,20 @@ type SensorConfig struct {
 	// Availability
 	AvailabilityTopic string // Availability topic
 
-	// Device grouping
+	// Device grouping (default: the device of this host, see
+	// DiscoveryManager.Device)
 	DeviceInfo *DeviceInfo
+
+	// Component overrides the Home Assistant platform; empty means
+	// binary_sensor for SensorTypeBinary and sensor otherwise
+	Component Component
+
+	// Options are the possible states of a SensorTypeEnum sensor
+	Options []string
+
+	// CommandTopic receives "true"/"false" when a switch is toggled in
+	// Home Assistant (required for ComponentSwitch)
+	CommandTopic string
 }
 
 // DeviceInfo contains device information for grouping in Home Assistant
@@ -54,3 +97,38 @@ type DeviceInfo struct {
 	Model        string   // Model
 	Manufacturer string   // Manufacturer
 }
+
+// component returns the Home Assistant component the sensor is discovered as
+func (c *SensorConfig) component() Component {
+	switch {
+	case c.Component != "":
+		return c.Component
+	case c.SensorType == SensorTypeBinary:
+		return ComponentBinarySensor
+	default:
+		return ComponentSensor
+	}
+}
+
+// validate checks that the config has what its component needs
+func (c *SensorConfig) validate() error {
+	switch c.component() {
+	case ComponentSensor, ComponentBinarySensor:
+	case ComponentSwitch:
+		if c.CommandTopic == "" {
+			return fmt.Errorf("switch %s has no command topic", c.SensorID)
+		}
+	default:
+		return fmt.Errorf("sensor %s has unknown component %q", c.SensorID, c.Component)
+	}
+	if c.SensorType == SensorTypeEnum && len(c.Options) == 0 {
+		return fmt.Errorf("enum sensor %s has no options", c.SensorID)
+	}
+	return nil
+}
+
+// discoveryTopic returns the topic of the sensor's retained discovery config:
+// homeassistant/{component}/{device_id}/{sensor_id}/config
+func (c *SensorConfig) discoveryTopic(deviceID string) string {
+	return "homeassistant/" + string(c.component()) + "/" + deviceID + "/" + c.SensorID + "/config"
+}
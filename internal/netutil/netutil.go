@@ -0,0 +1,174 @@
+// Package netutil provides host network helpers shared by the server and API.
+package netutil
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// UnixPrefix marks a listen address as a Unix socket path (unix:/run/podmanview.sock)
+const UnixPrefix = "unix:"
+
+// LocalIPs returns the non-loopback addresses of interfaces that are up,
+// IPv4 first (see FilterAddrs)
+func LocalIPs() []string {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return nil
+	}
+
+	var all []net.Addr
+	for _, iface := range interfaces {
+		// Skip down or loopback interfaces
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+
+		for _, addr := range addrs {
+			// Link-local IPv6 is only reachable with the interface zone
+			if v, ok := addr.(*net.IPNet); ok && v.IP.To4() == nil && v.IP.IsLinkLocalUnicast() {
+				addr = &net.IPAddr{IP: v.IP, Zone: iface.Name}
+			}
+			all = append(all, addr)
+		}
+	}
+
+	return FilterAddrs(all)
+}
+
+// FilterAddrs returns the usable addresses from interface addresses:
+// IPv4 first, then global IPv6. Loopback is skipped, and IPv6 link-local
+// (fe80::) addresses are only returned when there is no other address.
+// IPv4 link-local (169.254.0.0/16) is skipped.
+func FilterAddrs(addrs []net.Addr) []string {
+	var v4, v6, linkLocal []string
+
+	for _, addr := range addrs {
+		var ip net.IP
+		var zone string
+		switch v := addr.(type) {
+		case *net.IPNet:
+			ip = v.IP
+		case *net.IPAddr:
+			ip, zone = v.IP, v.Zone
+		}
+
+		if ip == nil || ip.IsLoopback() || ip.IsUnspecified() {
+			continue
+		}
+
+		switch {
+		case ip.To4() != nil:
+			if !ip.IsLinkLocalUnicast() {
+				v4 = append(v4, ip.String())
+			}
+		case ip.IsLinkLocalUnicast():
+			if zone != "" {
+				linkLocal = append(linkLocal, ip.String()+"%"+zone)
+			} else {
+				linkLocal = append(linkLocal, ip.String())
+			}
+		default:
+			v6 = append(v6, ip.String())
+		}
+	}
+
+	ips := append(v4, v6...)
+	if len(ips) == 0 {
+		return linkLocal
+	}
+	return ips
+}
+
+// AccessURLs returns scheme URLs for port on each of ips
+func AccessURLs(scheme string, ips []string, port string) []string {
+	urls := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		urls = append(urls, scheme+"://"+URLHost(ip, port))
+	}
+	return urls
+}
+
+// URLHost joins host and port for use in a URL. IPv6 hosts are
+// bracketed and zones escaped: [fe80::1%25eth0]:80
+func URLHost(host, port string) string {
+	return strings.Replace(net.JoinHostPort(host, port), "%", "%25", 1)
+}
+
+// IPv6Only returns the IPv6 addresses from ips
+func IPv6Only(ips []string) []string {
+	var result []string
+	for _, ip := range ips {
+		host, _, _ := strings.Cut(ip, "%")
+		if parsed := net.ParseIP(host); parsed != nil && parsed.To4() == nil {
+			result = append(result, ip)
+		}
+	}
+	return result
+}
+
+// PortFromAddr extracts the port from a listen address (":80", "0.0.0.0:80")
+func PortFromAddr(addr string) string {
+	if _, port, err := net.SplitHostPort(addr); err == nil {
+		return port
+	}
+	return addr
+}
+
+// UnixSocketPath returns the socket path of a unix: listen address
+func UnixSocketPath(addr string) (string, bool) {
+	return strings.CutPrefix(addr, UnixPrefix)
+}
+
+// Listen opens a TCP listener, or a Unix socket listener for unix: addresses.
+// The socket file gets mode and is removed when the listener is closed.
+// A stale socket left by an unclean shutdown is replaced.
+func Listen(addr string, mode os.FileMode) (net.Listener, error) {
+	path, ok := UnixSocketPath(addr)
+	if !ok {
+		return net.Listen("tcp", addr)
+	}
+
+	if err := removeStaleSocket(path); err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+// removeStaleSocket removes a socket file nothing is listening on.
+// Fails if path is another kind of file or a live socket.
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s exists and is not a socket", path)
+	}
+
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		conn.Close()
+		return fmt.Errorf("%s is in use by another process", path)
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,230 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// timestampLayout is the time format in backup file names
+const timestampLayout = "20060102-150405"
+
+// archiveExt is the extension of backup files
+const archiveExt = ".tar.gz"
+
+// volumeNamePattern matches valid Podman volume names
+var volumeNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// BackupFile is a backup archive in the destination directory
+type BackupFile struct {
+	Volume  string    `json:"volume"`
+	Name    string    `json:"name"`
+	Size    int64     `json:"size"`
+	Created time.Time `json:"created"`
+}
+
+// FileName returns the backup file name for volume at t
+func FileName(volume string, t time.Time) string {
+	return volume + "-" + t.Format(timestampLayout) + archiveExt
+}
+
+// parseFileName returns the volume and time of a backup file name
+func parseFileName(name string) (string, time.Time, bool) {
+	base, ok := strings.CutSuffix(name, archiveExt)
+	if !ok || len(base) < len(timestampLayout)+2 {
+		return "", time.Time{}, false
+	}
+	split := len(base) - len(timestampLayout) - 1
+	if base[split] != '-' {
+		return "", time.Time{}, false
+	}
+	created, err := time.ParseInLocation(timestampLayout, base[split+1:], time.Local)
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	volume := base[:split]
+	if !volumeNamePattern.MatchString(volume) {
+		return "", time.Time{}, false
+	}
+	return volume, created, true
+}
+
+// ListBackups returns the backup archives in dir, newest first
+func ListBackups(dir string) ([]BackupFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []BackupFile{}, nil
+		}
+		return nil, err
+	}
+
+	backups := []BackupFile{}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		volume, created, ok := parseFileName(entry.Name())
+		if !ok {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, BackupFile{
+			Volume:  volume,
+			Name:    entry.Name(),
+			Size:    info.Size(),
+			Created: created,
+		})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Created.After(backups[j].Created)
+	})
+	return backups, nil
+}
+
+// PruneBackups deletes all but the newest keep backups of volume in dir
+// and returns the names of the deleted files
+func PruneBackups(dir, volume string, keep int) ([]string, error) {
+	backups, err := ListBackups(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	kept := 0
+	for _, b := range backups {
+		if b.Volume != volume {
+			continue
+		}
+		if kept < keep {
+			kept++
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, b.Name)); err != nil {
+			return removed, err
+		}
+		removed = append(removed, b.Name)
+	}
+	return removed, nil
+}
+
+// CreateBackup archives src into a new backup of volume in dir.
+// The archive is written under a temporary name and renamed when complete,
+// so an interrupted backup never looks like a valid one.
+func CreateBackup(ctx context.Context, src, dir, volume string, now time.Time) (BackupFile, error) {
+	name := FileName(volume, now)
+	tmp, err := os.CreateTemp(dir, "."+name+".*.partial")
+	if err != nil {
+		return BackupFile{}, err
+	}
+	defer os.Remove(tmp.Name()) // no-op after the rename
+
+	gz := gzip.NewWriter(tmp)
+	err = WriteArchive(ctx, gz, src, dir)
+	if closeErr := gz.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return BackupFile{}, err
+	}
+
+	path := filepath.Join(dir, name)
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return BackupFile{}, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return BackupFile{}, err
+	}
+
+	return BackupFile{Volume: volume, Name: name, Size: info.Size(), Created: now}, nil
+}
+
+// WriteArchive writes the tree under src to w as a tar stream with paths
+// relative to src. The skip directory is left out, so a destination inside
+// the volume doesn't end up in its own backup. Files that can't be read fail
+// the archive rather than producing an incomplete backup.
+func WriteArchive(ctx context.Context, w io.Writer, src, skip string) error {
+	tw := tar.NewWriter(w)
+
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if skip != "" && path == skip && d.IsDir() {
+			return filepath.SkipDir
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil || rel == "." {
+			return err
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		link := ""
+		switch {
+		case info.Mode()&fs.ModeSymlink != 0:
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		case info.IsDir(), info.Mode().IsRegular():
+		default:
+			// Sockets, pipes and devices can't be backed up as content
+			return nil
+		}
+
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		// Copy only the size in the header, files may grow while being read
+		if _, err := io.CopyN(tw, f, header.Size); err != nil {
+			return fmt.Errorf("%s: %w", rel, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return tw.Close()
+}
@@ -0,0 +1,446 @@
+// Package backup provides a plugin that archives Podman volumes to
+// timestamped tar.gz files on a schedule and prunes old archives
+package backup
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"podmanview/internal/events"
+	"podmanview/internal/plugins"
+)
+
+// htmlFS holds the plugin page, embedded so it works from any working directory
+//
+//go:embed index.html
+var htmlFS embed.FS
+
+// Defaults for new installations
+const (
+	DefaultSchedule    = "0 3 * * *"
+	DefaultDestination = "/backups"
+	DefaultRetention   = 7
+	MaxRetention       = 365
+)
+
+// Settings is the backup configuration
+type Settings struct {
+	Volumes     []string `json:"volumes"`     // Volume names to back up
+	Destination string   `json:"destination"` // Directory relative to the file manager base directory
+	Schedule    string   `json:"schedule"`    // Cron schedule
+	Retention   int      `json:"retention"`   // Backups kept per volume
+}
+
+// Result is the outcome of the last backup of one volume
+type Result struct {
+	Volume   string `json:"volume"`
+	File     string `json:"file,omitempty"`
+	Size     int64  `json:"size,omitempty"`
+	Pruned   int    `json:"pruned,omitempty"`
+	Duration int64  `json:"durationMs"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Status is the state of the backup plugin
+type Status struct {
+	Running  bool         `json:"running"`
+	LastRun  *time.Time   `json:"lastRun,omitempty"`
+	NextRun  *time.Time   `json:"nextRun,omitempty"`
+	Results  []Result     `json:"results"`
+	Backups  []BackupFile `json:"backups"`
+	Settings Settings     `json:"settings"`
+	BaseDir  string       `json:"baseDir"`
+}
+
+// BackupPlugin backs up volumes on a schedule
+type BackupPlugin struct {
+	*plugins.BasePlugin
+	mu       sync.RWMutex
+	settings Settings
+	baseDir  string
+	running  bool
+	lastRun  time.Time
+	results  []Result
+
+	runMu            sync.Mutex // held while a backup runs
+	backgroundCtx    context.Context
+	backgroundCancel context.CancelFunc
+	bgMutex          sync.Mutex
+}
+
+// New creates a new BackupPlugin instance
+func New() *BackupPlugin {
+	return &BackupPlugin{
+		BasePlugin: plugins.NewBasePluginFS(
+			"backup",
+			"Scheduled volume backups",
+			"1.0.0",
+			htmlFS,
+			"index.html",
+		),
+		settings: Settings{
+			Volumes:     []string{},
+			Destination: DefaultDestination,
+			Schedule:    DefaultSchedule,
+			Retention:   DefaultRetention,
+		},
+		baseDir: defaultBaseDir(),
+		results: []Result{},
+	}
+}
+
+// defaultBaseDir returns the file manager's base directory (the home directory)
+func defaultBaseDir() string {
+	if home, err := os.UserHomeDir(); err == nil {
+		return home
+	}
+	return "/"
+}
+
+// Manifest describes the plugin for the plugin catalog
+func (p *BackupPlugin) Manifest() plugins.Manifest {
+	m := p.BasePlugin.Manifest()
+	m.Icon = "archive"
+	m.Category = plugins.CategoryBackup
+	m.Capabilities = []string{plugins.CapabilityPodman, plugins.CapabilityStorage, plugins.CapabilityHost}
+	return m
+}
+
+// Init initializes the plugin
+func (p *BackupPlugin) Init(ctx context.Context, deps *plugins.PluginDependencies) error {
+	p.SetDependencies(deps)
+
+	if deps.Storage != nil {
+		var settings Settings
+		if err := deps.Storage.GetJSON(p.Name(), "settings", &settings); err == nil {
+			if err := p.validate(&settings); err == nil {
+				p.settings = settings
+			} else {
+				p.LogError("Ignoring invalid stored settings: %v", err)
+			}
+		} else {
+			// Save default settings if not set
+			deps.Storage.SetJSON(p.Name(), "settings", p.settings)
+		}
+	}
+
+	if p.Logger() != nil {
+		p.Logger().Printf("[%s] Plugin initialized", p.Name())
+	}
+	return nil
+}
+
+// Start starts the plugin
+func (p *BackupPlugin) Start(ctx context.Context) error {
+	return nil
+}
+
+// Stop stops the plugin, cancelling a running backup
+func (p *BackupPlugin) Stop(ctx context.Context) error {
+	p.bgMutex.Lock()
+	if p.backgroundCancel != nil {
+		p.backgroundCancel()
+		p.backgroundCancel = nil
+	}
+	p.bgMutex.Unlock()
+
+	if p.Logger() != nil {
+		p.Logger().Printf("[%s] Plugin stopped gracefully", p.Name())
+	}
+	return nil
+}
+
+// Routes returns the plugin's HTTP routes
+func (p *BackupPlugin) Routes() []plugins.Route {
+	return []plugins.Route{
+		{
+			Method:      "GET",
+			Path:        "/api/plugins/backup/status",
+			Handler:     p.handleGetStatus,
+			RequireAuth: true,
+		},
+		{
+			Method:      "POST",
+			Path:        "/api/plugins/backup/run",
+			Handler:     p.handleRun,
+			RequireAuth: true,
+		},
+		{
+			Method:      "GET",
+			Path:        "/api/plugins/backup/settings",
+			Handler:     p.handleGetSettings,
+			RequireAuth: true,
+		},
+		{
+			Method:      "POST",
+			Path:        "/api/plugins/backup/settings",
+			Handler:     p.handleUpdateSettings,
+			RequireAuth: true,
+		},
+	}
+}
+
+// SettingsKeys returns the storage keys exported with the settings bundle
+func (p *BackupPlugin) SettingsKeys() []string {
+	return []string{"settings"}
+}
+
+// IsEnabled checks if the plugin is enabled
+func (p *BackupPlugin) IsEnabled() bool {
+	if p.Deps() == nil || p.Deps().Storage == nil {
+		return false
+	}
+	enabled, err := p.Deps().Storage.IsPluginEnabled(p.Name())
+	if err != nil {
+		return false
+	}
+	return enabled
+}
+
+// StartBackgroundTasks starts the scheduled backup
+func (p *BackupPlugin) StartBackgroundTasks(ctx context.Context) error {
+	p.bgMutex.Lock()
+	p.backgroundCtx, p.backgroundCancel = context.WithCancel(ctx)
+	bgCtx := p.backgroundCtx
+	p.bgMutex.Unlock()
+
+	p.runSchedule(bgCtx)
+	return nil
+}
+
+// RestartBackgroundTasks restarts the scheduled backup with the new schedule
+func (p *BackupPlugin) RestartBackgroundTasks() error {
+	p.bgMutex.Lock()
+	if p.backgroundCancel != nil {
+		p.backgroundCancel()
+	}
+	// Use context.Background() as parent since the original parent context is long-lived
+	p.backgroundCtx, p.backgroundCancel = context.WithCancel(context.Background())
+	bgCtx := p.backgroundCtx
+	p.bgMutex.Unlock()
+
+	p.runSchedule(bgCtx)
+	return nil
+}
+
+// runSchedule starts the scheduled backup in the background
+func (p *BackupPlugin) runSchedule(ctx context.Context) {
+	schedule := p.GetSettings().Schedule
+	if p.Logger() != nil {
+		p.Logger().Printf("[%s] Backing up volumes on schedule %q", p.Name(), schedule)
+	}
+	go func() {
+		if err := plugins.RunCron(ctx, schedule, p.Logger(), p.EventStore(), p.Name(), p.run); err != nil {
+			p.LogError("Invalid schedule %q: %v", schedule, err)
+		}
+	}()
+}
+
+// runContext returns the context backups run in, cancelled when the plugin stops
+func (p *BackupPlugin) runContext() context.Context {
+	p.bgMutex.Lock()
+	defer p.bgMutex.Unlock()
+	if p.backgroundCtx != nil {
+		return p.backgroundCtx
+	}
+	return context.Background()
+}
+
+// GetSettings returns a copy of the current settings
+func (p *BackupPlugin) GetSettings() Settings {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	settings := p.settings
+	settings.Volumes = append([]string{}, p.settings.Volumes...)
+	return settings
+}
+
+// GetStatus returns the state of the plugin and the existing backups
+func (p *BackupPlugin) GetStatus() Status {
+	p.mu.RLock()
+	status := Status{
+		Running: p.running,
+		Results: append([]Result{}, p.results...),
+		BaseDir: p.baseDir,
+	}
+	if !p.lastRun.IsZero() {
+		lastRun := p.lastRun
+		status.LastRun = &lastRun
+	}
+	p.mu.RUnlock()
+
+	status.Settings = p.GetSettings()
+	if schedule, err := plugins.ParseCron(status.Settings.Schedule); err == nil {
+		next := schedule.Next(time.Now())
+		status.NextRun = &next
+	}
+
+	status.Backups = []BackupFile{}
+	if dir, err := p.destinationDir(status.Settings.Destination); err == nil {
+		if backups, err := ListBackups(dir); err == nil {
+			status.Backups = backups
+		}
+	}
+	return status
+}
+
+// validate checks and normalizes settings
+func (p *BackupPlugin) validate(s *Settings) error {
+	seen := make(map[string]bool)
+	volumes := []string{}
+	for _, v := range s.Volumes {
+		v = strings.TrimSpace(v)
+		if v == "" || seen[v] {
+			continue
+		}
+		if !volumeNamePattern.MatchString(v) {
+			return fmt.Errorf("invalid volume name %q", v)
+		}
+		seen[v] = true
+		volumes = append(volumes, v)
+	}
+	s.Volumes = volumes
+
+	if _, err := p.destinationDir(s.Destination); err != nil {
+		return err
+	}
+	s.Destination = filepath.ToSlash(filepath.Clean("/" + s.Destination))
+
+	s.Schedule = strings.TrimSpace(s.Schedule)
+	if _, err := plugins.ParseCron(s.Schedule); err != nil {
+		return fmt.Errorf("invalid schedule: %w", err)
+	}
+
+	if s.Retention < 1 || s.Retention > MaxRetention {
+		return fmt.Errorf("retention must be between 1 and %d backups", MaxRetention)
+	}
+	return nil
+}
+
+// destinationDir resolves a destination relative to the base directory,
+// refusing paths that escape it
+func (p *BackupPlugin) destinationDir(destination string) (string, error) {
+	p.mu.RLock()
+	base := p.baseDir
+	p.mu.RUnlock()
+
+	dir := filepath.Join(base, filepath.Clean("/"+destination))
+	rel, err := filepath.Rel(base, dir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", errors.New("destination must be inside the file manager directory")
+	}
+	return dir, nil
+}
+
+// run backs up every configured volume, then prunes old backups.
+// Does nothing if a backup is already running.
+func (p *BackupPlugin) run(ctx context.Context) error {
+	if !p.runMu.TryLock() {
+		return nil
+	}
+	defer p.runMu.Unlock()
+
+	deps := p.Deps()
+	if deps == nil || deps.PodmanClient == nil {
+		return errors.New("podman client not available")
+	}
+
+	settings := p.GetSettings()
+	if len(settings.Volumes) == 0 {
+		return nil
+	}
+
+	dir, err := p.destinationDir(settings.Destination)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	p.mu.Lock()
+	p.running = true
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		p.running = false
+		p.mu.Unlock()
+	}()
+
+	results := make([]Result, 0, len(settings.Volumes))
+	failed := 0
+	for _, volume := range settings.Volumes {
+		result := p.backupVolume(ctx, volume, dir, settings.Retention)
+		if result.Error != "" {
+			failed++
+		}
+		results = append(results, result)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	p.mu.Lock()
+	p.results = results
+	p.lastRun = time.Now()
+	p.mu.Unlock()
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d volume backups failed", failed, len(settings.Volumes))
+	}
+	return nil
+}
+
+// backupVolume backs up one volume into dir and prunes its old backups
+func (p *BackupPlugin) backupVolume(ctx context.Context, volume, dir string, retention int) Result {
+	start := time.Now()
+	result := Result{Volume: volume}
+
+	fail := func(err error) Result {
+		result.Error = err.Error()
+		result.Duration = time.Since(start).Milliseconds()
+		p.LogError("Backup of volume %s failed: %v", volume, err)
+		p.Events().System(events.EventVolumeBackup, false,
+			fmt.Sprintf("backup of volume %s failed: %v", volume, err),
+			events.Meta{"volume": volume})
+		return result
+	}
+
+	info, err := p.Deps().PodmanClient.InspectVolume(ctx, volume)
+	if err != nil {
+		return fail(fmt.Errorf("inspect volume: %w", err))
+	}
+	if info.Mountpoint == "" {
+		return fail(errors.New("volume has no mountpoint"))
+	}
+
+	backup, err := CreateBackup(ctx, info.Mountpoint, dir, volume, start)
+	if err != nil {
+		return fail(err)
+	}
+	result.File = backup.Name
+	result.Size = backup.Size
+
+	removed, err := PruneBackups(dir, volume, retention)
+	result.Pruned = len(removed)
+	if err != nil {
+		p.LogError("Failed to prune backups of volume %s: %v", volume, err)
+	}
+
+	result.Duration = time.Since(start).Milliseconds()
+	if p.Logger() != nil {
+		p.Logger().Printf("[%s] Backed up volume %s to %s (%d bytes, %d old backups removed)",
+			p.Name(), volume, backup.Name, backup.Size, len(removed))
+	}
+	p.Events().System(events.EventVolumeBackup, true,
+		fmt.Sprintf("volume %s backed up to %s", volume, backup.Name),
+		events.Meta{"volume": volume, "file": backup.Name})
+	return result
+}
@@ -0,0 +1,93 @@
+package backup
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"podmanview/internal/auth"
+	"podmanview/internal/plugins"
+)
+
+// requireManage rejects users that may not manage plugins.
+// There is no user in the context when authentication is disabled.
+func requireManage(w http.ResponseWriter, r *http.Request) bool {
+	if user := auth.GetUserFromContext(r.Context()); user != nil && !user.Can(auth.ActionManagePlugins) {
+		plugins.WriteError(w, http.StatusForbidden, "forbidden", "Permission denied")
+		return false
+	}
+	return true
+}
+
+// handleGetStatus returns the last results and the existing backups
+func (p *BackupPlugin) handleGetStatus(w http.ResponseWriter, r *http.Request) {
+	plugins.WriteJSON(w, http.StatusOK, p.GetStatus())
+}
+
+// handleRun starts a backup of all configured volumes in the background
+func (p *BackupPlugin) handleRun(w http.ResponseWriter, r *http.Request) {
+	if !requireManage(w, r) {
+		return
+	}
+	if p.GetStatus().Running {
+		plugins.WriteError(w, http.StatusConflict, "backup_running", "A backup is already running")
+		return
+	}
+	if len(p.GetSettings().Volumes) == 0 {
+		plugins.WriteError(w, http.StatusBadRequest, "no_volumes", "No volumes selected for backup")
+		return
+	}
+
+	ctx := p.runContext()
+	go func() {
+		if err := p.run(ctx); err != nil {
+			p.LogError("Manual backup failed: %v", err)
+		}
+	}()
+
+	plugins.WriteJSON(w, http.StatusAccepted, map[string]string{"status": "Backup started"})
+}
+
+// handleGetSettings returns current plugin settings
+func (p *BackupPlugin) handleGetSettings(w http.ResponseWriter, r *http.Request) {
+	plugins.WriteJSON(w, http.StatusOK, p.GetSettings())
+}
+
+// handleUpdateSettings validates, saves and applies new settings
+func (p *BackupPlugin) handleUpdateSettings(w http.ResponseWriter, r *http.Request) {
+	if !requireManage(w, r) {
+		return
+	}
+
+	var settings Settings
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+		plugins.WriteError(w, http.StatusBadRequest, "invalid_body", "Invalid request body")
+		return
+	}
+	if err := p.validate(&settings); err != nil {
+		plugins.WriteError(w, http.StatusBadRequest, "invalid_settings", err.Error())
+		return
+	}
+
+	if deps := p.Deps(); deps != nil && deps.Storage != nil {
+		if err := deps.Storage.SetJSON(p.Name(), "settings", settings); err != nil {
+			p.LogError("Failed to save settings to storage: %v", err)
+			plugins.WriteError(w, http.StatusInternalServerError, "storage_error", "Failed to save settings")
+			return
+		}
+	}
+
+	p.mu.Lock()
+	scheduleChanged := p.settings.Schedule != settings.Schedule
+	p.settings = settings
+	p.mu.Unlock()
+
+	if scheduleChanged {
+		if err := p.RestartBackgroundTasks(); err != nil {
+			p.LogError("Failed to restart background tasks: %v", err)
+			plugins.WriteError(w, http.StatusInternalServerError, "internal_error", "Failed to restart background tasks")
+			return
+		}
+	}
+
+	plugins.WriteJSON(w, http.StatusOK, settings)
+}
@@ -0,0 +1,87 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"podmanview/internal/events"
+)
+
+// cronMaxSleep caps a single wait in RunCron. Timers don't advance while
+// the host is suspended and ignore wall clock changes, so long waits are
+// split up and the due time is checked against the wall clock.
+const cronMaxSleep = time.Minute
+
+// CronSchedule is a parsed cron expression
+type CronSchedule struct {
+	spec     string
+	schedule cron.Schedule
+}
+
+// ParseCron parses a standard 5-field cron expression ("0 3 * * *") or a
+// descriptor (@daily, @hourly, @every 90m). Times are in the local time
+// zone unless the spec starts with CRON_TZ=<zone>.
+func ParseCron(spec string) (*CronSchedule, error) {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron spec %q: %w", spec, err)
+	}
+	return &CronSchedule{spec: spec, schedule: schedule}, nil
+}
+
+// Next returns the first activation time after t.
+// Times skipped by a DST change are not run, repeated ones run once.
+func (s *CronSchedule) Next(t time.Time) time.Time {
+	return s.schedule.Next(t)
+}
+
+// String returns the cron expression
+func (s *CronSchedule) String() string {
+	return s.spec
+}
+
+// RunCron runs a function on a cron schedule until the context is cancelled
+// Task errors are handled like in RunPeriodic. Runs missed while the task
+// was still running or the host was asleep are skipped, not caught up.
+// Returns an error without running anything if spec is invalid, so
+// validate it with ParseCron first when starting RunCron in a goroutine.
+// Usage example:
+//
+//	go RunCron(ctx, "0 3 * * *", p.Logger(), p.EventStore(), p.Name(), func(ctx context.Context) error {
+//	    return p.pruneImages(ctx)
+//	})
+func RunCron(ctx context.Context, spec string, logger *log.Logger, eventStore *events.Store, pluginName string, task func(context.Context) error) error {
+	schedule, err := ParseCron(spec)
+	if err != nil {
+		return err
+	}
+
+	runTask := taskRunner(ctx, logger, eventStore, pluginName, task)
+
+	// Recompute from the wall clock every iteration so DST changes,
+	// clock adjustments and long tasks don't shift the schedule
+	next := schedule.Next(time.Now())
+	for {
+		wait := time.Until(next)
+		if wait <= 0 {
+			runTask()
+			next = schedule.Next(time.Now())
+			continue
+		}
+
+		timer := time.NewTimer(min(wait, cronMaxSleep))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			if logger != nil {
+				logger.Printf("[%s] Scheduled task stopped", pluginName)
+			}
+			return nil
+		case <-timer.C:
+		}
+	}
+}
@@ -3,14 +3,19 @@ package demo
 
 import (
 	"context"
+	"embed"
 	"net/http"
-	"path/filepath"
 	"sync"
 	"time"
 
 	"podmanview/internal/plugins"
 )
 
+// htmlFS holds the plugin page, embedded so it works from any working directory
+//
+//go:embed index.html
+var htmlFS embed.FS
+
 // DemoPlugin is a simple demonstration plugin
 type DemoPlugin struct {
 	*plugins.BasePlugin
@@ -21,19 +26,26 @@ type DemoPlugin struct {
 
 // New creates a new DemoPlugin instance
 func New() *DemoPlugin {
-	// Get the path to the HTML file relative to this plugin's directory
-	htmlPath := filepath.Join("internal", "plugins", "demo", "index.html")
-
 	return &DemoPlugin{
-		BasePlugin: plugins.NewBasePlugin(
+		BasePlugin: plugins.NewBasePluginFS(
 			"demo",
 			"Simple demonstration plugin",
 			"1.0.0",
-			htmlPath,
+			htmlFS,
+			"index.html",
 		),
 	}
 }
 
+// Manifest describes the plugin for the plugin catalog
+func (p *DemoPlugin) Manifest() plugins.Manifest {
+	m := p.BasePlugin.Manifest()
+	m.Icon = "code"
+	m.Category = plugins.CategoryDevelopment
+	m.Capabilities = []string{plugins.CapabilityStorage}
+	return m
+}
+
 // Init initializes the plugin
 func (p *DemoPlugin) Init(ctx context.Context, deps *plugins.PluginDependencies) error {
 	p.SetDependencies(deps)
@@ -67,7 +79,7 @@ func (p *DemoPlugin) Stop(ctx context.Context) error {
 // This is an example of how to implement periodic background work
 func (p *DemoPlugin) StartBackgroundTasks(ctx context.Context) error {
 	// Example: Log uptime every 30 seconds
-	go plugins.RunPeriodic(ctx, 30*time.Second, p.Logger(), p.Name(), func(ctx context.Context) error {
+	go plugins.RunPeriodic(ctx, 30*time.Second, p.Logger(), p.EventStore(), p.Name(), func(ctx context.Context) error {
 		uptime := time.Since(p.startTime)
 		p.LogError("Uptime: %s, Counter: %d", uptime.Round(time.Second), p.counter)
 		return nil
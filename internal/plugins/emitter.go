@@ -0,0 +1,40 @@
+package plugins
+
+import (
+	"podmanview/internal/events"
+)
+
+// EventEmitter records audit events on behalf of one plugin. Details are
+// prefixed with the plugin name and every event carries a "plugin" meta
+// field, so plugin events can be told apart and filtered in the event log.
+type EventEmitter struct {
+	store  *events.Store
+	plugin string
+}
+
+// NewEventEmitter creates an emitter for plugin. A nil store discards events.
+func NewEventEmitter(store *events.Store, plugin string) *EventEmitter {
+	return &EventEmitter{store: store, plugin: plugin}
+}
+
+// Emit records an event caused by username from ip.
+// Background work should use "system" and an empty ip.
+func (e *EventEmitter) Emit(eventType events.EventType, username, ip string, success bool, details string, meta events.Meta) {
+	if e == nil || e.store == nil {
+		return
+	}
+
+	m := events.Meta{"plugin": e.plugin}
+	for k, v := range meta {
+		if k != "plugin" {
+			m[k] = v
+		}
+	}
+
+	e.store.AddWithMeta(eventType, username, ip, success, e.plugin+": "+details, m)
+}
+
+// System records an event from a plugin's background work
+func (e *EventEmitter) System(eventType events.EventType, success bool, details string, meta events.Meta) {
+	e.Emit(eventType, "system", "", success, details, meta)
+}
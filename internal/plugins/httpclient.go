@@ -0,0 +1,27 @@
+package plugins
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// NewHTTPClient creates the HTTP client shared by plugins for outbound
+// requests (webhooks, uptime checks, ...). An empty proxy uses
+// HTTP_PROXY/HTTPS_PROXY/NO_PROXY from the environment.
+func NewHTTPClient(timeout time.Duration, proxy string) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if proxy != "" {
+		proxyURL, err := url.Parse(proxy)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}, nil
+}
@@ -0,0 +1,128 @@
+package imageupdates
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"podmanview/internal/auth"
+	"podmanview/internal/plugins"
+)
+
+// manualCheckTimeout bounds a check started from the UI
+const manualCheckTimeout = 5 * time.Minute
+
+// PluginSettings represents plugin configuration
+type PluginSettings struct {
+	Schedule    string `json:"schedule"`    // Cron schedule of the check
+	MQTTEnabled bool   `json:"mqttEnabled"` // Publish update sensors to MQTT
+}
+
+// handleGetStatus returns the result of the last check
+func (p *ImageUpdatesPlugin) handleGetStatus(w http.ResponseWriter, r *http.Request) {
+	plugins.WriteJSON(w, http.StatusOK, p.GetStatus())
+}
+
+// handleCheck starts a check in the background
+func (p *ImageUpdatesPlugin) handleCheck(w http.ResponseWriter, r *http.Request) {
+	if user := auth.GetUserFromContext(r.Context()); user != nil && !user.Can(auth.ActionManagePlugins) {
+		plugins.WriteError(w, http.StatusForbidden, "forbidden", "Permission denied")
+		return
+	}
+
+	if p.GetStatus().Checking {
+		plugins.WriteError(w, http.StatusConflict, "check_running", "A check is already running")
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), manualCheckTimeout)
+		defer cancel()
+		if err := p.check(ctx); err != nil {
+			p.LogError("Manual check failed: %v", err)
+		}
+	}()
+
+	plugins.WriteJSON(w, http.StatusAccepted, map[string]string{"status": "Check started"})
+}
+
+// handleGetSettings returns current plugin settings
+func (p *ImageUpdatesPlugin) handleGetSettings(w http.ResponseWriter, r *http.Request) {
+	p.mu.RLock()
+	settings := PluginSettings{
+		Schedule:    p.schedule,
+		MQTTEnabled: p.mqttEnabled,
+	}
+	p.mu.RUnlock()
+
+	plugins.WriteJSON(w, http.StatusOK, settings)
+}
+
+// handleUpdateSettings updates plugin settings
+func (p *ImageUpdatesPlugin) handleUpdateSettings(w http.ResponseWriter, r *http.Request) {
+	if user := auth.GetUserFromContext(r.Context()); user != nil && !user.Can(auth.ActionManagePlugins) {
+		plugins.WriteError(w, http.StatusForbidden, "forbidden", "Permission denied")
+		return
+	}
+
+	var settings PluginSettings
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+		plugins.WriteError(w, http.StatusBadRequest, "invalid_body", "Invalid request body")
+		return
+	}
+
+	settings.Schedule = strings.TrimSpace(settings.Schedule)
+	if _, err := plugins.ParseCron(settings.Schedule); err != nil {
+		plugins.WriteError(w, http.StatusBadRequest, "invalid_settings", "Invalid schedule: "+err.Error())
+		return
+	}
+
+	if deps := p.Deps(); deps != nil && deps.Storage != nil {
+		if err := deps.Storage.SetString(p.Name(), "schedule", settings.Schedule); err != nil {
+			p.LogError("Failed to save schedule to storage: %v", err)
+			plugins.WriteError(w, http.StatusInternalServerError, "storage_error", "Failed to save settings")
+			return
+		}
+		if err := deps.Storage.SetBool(p.Name(), "mqttEnabled", settings.MQTTEnabled); err != nil {
+			p.LogError("Failed to save MQTT state to storage: %v", err)
+			plugins.WriteError(w, http.StatusInternalServerError, "storage_error", "Failed to save settings")
+			return
+		}
+	}
+
+	p.mu.Lock()
+	scheduleChanged := p.schedule != settings.Schedule
+	mqttEnabled := p.mqttEnabled
+	p.schedule = settings.Schedule
+	p.mqttEnabled = settings.MQTTEnabled
+	p.mu.Unlock()
+
+	// Announce or withdraw the update sensors
+	deps := p.Deps()
+	if client := deps.MQTTClient(); mqttEnabled != settings.MQTTEnabled && client != nil {
+		discovery := p.discoveryManager()
+		if settings.MQTTEnabled {
+			discovery.Reset()
+			if err := client.Connect(); err == nil {
+				p.publishMQTT(p.GetStatus().Containers)
+			}
+		} else if client.IsConnected() {
+			if err := discovery.RemoveDiscoveryConfigs(); err != nil {
+				p.LogError("Failed to remove MQTT discovery: %v", err)
+			}
+			client.Publish(availabilityTopic, []byte("offline"))
+		}
+	}
+
+	if scheduleChanged {
+		if err := p.RestartBackgroundTasks(); err != nil {
+			p.LogError("Failed to restart background tasks: %v", err)
+			plugins.WriteError(w, http.StatusInternalServerError, "internal_error", "Failed to restart background tasks")
+			return
+		}
+	}
+
+	plugins.WriteJSON(w, http.StatusOK, map[string]string{"status": "Settings updated successfully"})
+}
@@ -0,0 +1,568 @@
+// Package imageupdates provides a plugin that detects containers running
+// an outdated image, by comparing the local image digest with the digest
+// the registry currently serves for the same tag
+package imageupdates
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"podmanview/internal/events"
+	"podmanview/internal/mqtt"
+	"podmanview/internal/plugins"
+	"podmanview/internal/storage"
+)
+
+// htmlFS holds the plugin page, embedded so it works from any working directory
+//
+//go:embed index.html
+var htmlFS embed.FS
+
+// DefaultSchedule checks for updates every 6 hours
+const DefaultSchedule = "0 */6 * * *"
+
+// availabilityTopic is the MQTT availability topic of the update sensors
+const availabilityTopic = "sensor/image_updates/availability"
+
+// ImageUpdatesPlugin flags containers whose image tag points to a newer image
+type ImageUpdatesPlugin struct {
+	*plugins.BasePlugin
+	mu          sync.RWMutex
+	containers  []ContainerStatus
+	lastCheck   time.Time
+	checking    bool
+	schedule    string
+	mqttEnabled bool
+
+	checkMu          sync.Mutex             // held while a check runs
+	discovery        *mqtt.DiscoveryManager // created with the MQTT client, see discoveryManager
+	backgroundCtx    context.Context
+	backgroundCancel context.CancelFunc
+	bgMutex          sync.Mutex
+}
+
+// ContainerStatus is the update state of one running container
+type ContainerStatus struct {
+	ID              string `json:"id"`
+	Name            string `json:"name"`
+	Image           string `json:"image"`
+	LocalDigest     string `json:"localDigest,omitempty"`
+	RemoteDigest    string `json:"remoteDigest,omitempty"`
+	UpdateAvailable bool   `json:"updateAvailable"`
+	Error           string `json:"error,omitempty"` // why the image couldn't be checked
+}
+
+// Status is the result of the last check
+type Status struct {
+	Containers       []ContainerStatus `json:"containers"`
+	UpdatesAvailable int               `json:"updatesAvailable"`
+	LastCheck        *time.Time        `json:"lastCheck,omitempty"`
+	NextCheck        *time.Time        `json:"nextCheck,omitempty"`
+	Checking         bool              `json:"checking"`
+	Schedule         string            `json:"schedule"`
+}
+
+// New creates a new ImageUpdatesPlugin instance
+func New() *ImageUpdatesPlugin {
+	p := &ImageUpdatesPlugin{
+		BasePlugin: plugins.NewBasePluginFS(
+			"image-updates",
+			"Detects containers running outdated images",
+			"1.0.0",
+			htmlFS,
+			"index.html",
+		),
+		schedule:   DefaultSchedule,
+		containers: []ContainerStatus{},
+	}
+	p.SetSourceDir("imageupdates")
+	return p
+}
+
+// Manifest describes the plugin for the plugin catalog
+func (p *ImageUpdatesPlugin) Manifest() plugins.Manifest {
+	m := p.BasePlugin.Manifest()
+	m.Icon = "refresh"
+	m.Category = plugins.CategoryMaintenance
+	m.Capabilities = []string{plugins.CapabilityPodman, plugins.CapabilityStorage, plugins.CapabilityMQTT, plugins.CapabilityNetwork}
+	return m
+}
+
+// Init initializes the plugin
+func (p *ImageUpdatesPlugin) Init(ctx context.Context, deps *plugins.PluginDependencies) error {
+	p.SetDependencies(deps)
+
+	// Load settings from storage (schedule, MQTT enabled state)
+	p.loadSettings(deps.Storage)
+
+	if client := deps.MQTTClient(); client != nil && p.isMQTTEnabled() {
+		if err := client.Connect(); err != nil {
+			p.LogError("Failed to connect to MQTT: %v", err)
+		}
+	}
+
+	if p.Logger() != nil {
+		p.Logger().Printf("[%s] Plugin initialized", p.Name())
+	}
+	return nil
+}
+
+// Start starts the plugin
+func (p *ImageUpdatesPlugin) Start(ctx context.Context) error {
+	return nil
+}
+
+// Stop stops the plugin
+func (p *ImageUpdatesPlugin) Stop(ctx context.Context) error {
+	p.bgMutex.Lock()
+	if p.backgroundCancel != nil {
+		p.backgroundCancel()
+		p.backgroundCancel = nil
+	}
+	p.bgMutex.Unlock()
+
+	deps := p.Deps()
+	if client := deps.MQTTClient(); p.isMQTTEnabled() && client != nil && client.IsConnected() {
+		// Disabled by an admin: remove the sensors from Home Assistant,
+		// on shutdown they only become unavailable
+		if discovery := p.discoveryManager(); discovery != nil && p.Disabling() {
+			if err := discovery.RemoveDiscoveryConfigs(); err != nil {
+				p.LogError("Failed to remove MQTT discovery: %v", err)
+			}
+		}
+		client.Publish(availabilityTopic, []byte("offline"))
+		// Give the message time to go out, within the stop deadline
+		select {
+		case <-time.After(100 * time.Millisecond):
+		case <-ctx.Done():
+		}
+	}
+
+	if p.Logger() != nil {
+		p.Logger().Printf("[%s] Plugin stopped gracefully", p.Name())
+	}
+	return nil
+}
+
+// Routes returns the plugin's HTTP routes
+func (p *ImageUpdatesPlugin) Routes() []plugins.Route {
+	return []plugins.Route{
+		{
+			Method:      "GET",
+			Path:        "/api/plugins/image-updates/status",
+			Handler:     p.handleGetStatus,
+			RequireAuth: true,
+		},
+		{
+			Method:      "POST",
+			Path:        "/api/plugins/image-updates/check",
+			Handler:     p.handleCheck,
+			RequireAuth: true,
+		},
+		{
+			Method:      "GET",
+			Path:        "/api/plugins/image-updates/settings",
+			Handler:     p.handleGetSettings,
+			RequireAuth: true,
+		},
+		{
+			Method:      "POST",
+			Path:        "/api/plugins/image-updates/settings",
+			Handler:     p.handleUpdateSettings,
+			RequireAuth: true,
+		},
+	}
+}
+
+// SettingsKeys returns the storage keys exported with the settings bundle
+func (p *ImageUpdatesPlugin) SettingsKeys() []string {
+	return []string{"schedule", "mqttEnabled"}
+}
+
+// IsEnabled checks if the plugin is enabled
+func (p *ImageUpdatesPlugin) IsEnabled() bool {
+	if p.Deps() == nil || p.Deps().Storage == nil {
+		return false
+	}
+	enabled, err := p.Deps().Storage.IsPluginEnabled(p.Name())
+	if err != nil {
+		return false
+	}
+	return enabled
+}
+
+// StartBackgroundTasks runs a first check shortly after startup,
+// then checks on the configured schedule
+func (p *ImageUpdatesPlugin) StartBackgroundTasks(ctx context.Context) error {
+	p.bgMutex.Lock()
+	p.backgroundCtx, p.backgroundCancel = context.WithCancel(ctx)
+	bgCtx := p.backgroundCtx
+	p.bgMutex.Unlock()
+
+	go plugins.RunOnce(bgCtx, time.Minute, p.Logger(), p.Name(), p.check)
+	p.runSchedule(bgCtx)
+	return nil
+}
+
+// RestartBackgroundTasks restarts the scheduled check with the new schedule
+func (p *ImageUpdatesPlugin) RestartBackgroundTasks() error {
+	p.bgMutex.Lock()
+	if p.backgroundCancel != nil {
+		p.backgroundCancel()
+	}
+	// Use context.Background() as parent since the original parent context is long-lived
+	p.backgroundCtx, p.backgroundCancel = context.WithCancel(context.Background())
+	bgCtx := p.backgroundCtx
+	p.bgMutex.Unlock()
+
+	p.runSchedule(bgCtx)
+	return nil
+}
+
+// runSchedule starts the scheduled check in the background
+func (p *ImageUpdatesPlugin) runSchedule(ctx context.Context) {
+	schedule := p.getSchedule()
+	if p.Logger() != nil {
+		p.Logger().Printf("[%s] Checking for image updates on schedule %q", p.Name(), schedule)
+	}
+	go func() {
+		if err := plugins.RunCron(ctx, schedule, p.Logger(), p.EventStore(), p.Name(), p.check); err != nil {
+			p.LogError("Invalid schedule %q: %v", schedule, err)
+		}
+	}()
+}
+
+// GetStatus returns the result of the last check
+func (p *ImageUpdatesPlugin) GetStatus() Status {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	status := Status{
+		Containers: append([]ContainerStatus(nil), p.containers...),
+		Checking:   p.checking,
+		Schedule:   p.schedule,
+	}
+	for _, c := range p.containers {
+		if c.UpdateAvailable {
+			status.UpdatesAvailable++
+		}
+	}
+	if !p.lastCheck.IsZero() {
+		lastCheck := p.lastCheck
+		status.LastCheck = &lastCheck
+	}
+	if schedule, err := plugins.ParseCron(p.schedule); err == nil {
+		next := schedule.Next(time.Now())
+		status.NextCheck = &next
+	}
+	return status
+}
+
+// check compares the images of all running containers with the registry.
+// Does nothing if a check is already running.
+func (p *ImageUpdatesPlugin) check(ctx context.Context) error {
+	if !p.checkMu.TryLock() {
+		return nil
+	}
+	defer p.checkMu.Unlock()
+
+	deps := p.Deps()
+	if deps == nil || deps.PodmanClient == nil {
+		return errors.New("podman client not available")
+	}
+
+	p.setChecking(true)
+	defer p.setChecking(false)
+
+	containers, err := deps.PodmanClient.ListContainers(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	// Read logins on every check so a new `podman login` is picked up
+	registry := NewRegistryClient(p.HTTPClient(), LoadCredentials(DefaultAuthFiles()...))
+
+	// Containers often share images, look each up only once
+	type lookup struct {
+		digest string
+		err    error
+	}
+	remote := make(map[string]lookup)
+	local := make(map[string][]string)
+
+	results := make([]ContainerStatus, 0, len(containers))
+	for _, c := range containers {
+		if c.State != "running" {
+			continue
+		}
+
+		status := ContainerStatus{
+			ID:    c.ID,
+			Name:  containerName(c.Names, c.ID),
+			Image: c.Image,
+		}
+
+		ref, err := ParseReference(c.Image)
+		if err != nil {
+			status.Error = err.Error()
+			results = append(results, status)
+			continue
+		}
+
+		digests, ok := local[c.ImageID]
+		if !ok {
+			if image, err := deps.PodmanClient.InspectImage(ctx, c.ImageID); err == nil {
+				digests = repoDigests(image.RepoDigests)
+			}
+			local[c.ImageID] = digests
+		}
+		if len(digests) == 0 {
+			status.Error = "image has no registry digest (built or loaded locally)"
+			results = append(results, status)
+			continue
+		}
+		status.LocalDigest = digests[0]
+
+		found, ok := remote[ref.String()]
+		if !ok {
+			found.digest, found.err = registry.Digest(ctx, ref)
+			remote[ref.String()] = found
+		}
+		if found.err != nil {
+			status.Error = found.err.Error()
+			results = append(results, status)
+			continue
+		}
+
+		status.RemoteDigest = found.digest
+		status.UpdateAvailable = !contains(digests, found.digest)
+		if !status.UpdateAvailable {
+			status.LocalDigest = found.digest
+		}
+		results = append(results, status)
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	p.mu.Lock()
+	previous := p.containers
+	p.containers = results
+	p.lastCheck = time.Now()
+	p.mu.Unlock()
+
+	p.reportNewUpdates(previous, results)
+	p.publishMQTT(results)
+
+	if p.Logger() != nil {
+		updates := 0
+		for _, r := range results {
+			if r.UpdateAvailable {
+				updates++
+			}
+		}
+		p.Logger().Printf("[%s] Checked %d running containers, %d with updates available", p.Name(), len(results), updates)
+	}
+	return nil
+}
+
+// reportNewUpdates records an event for each container that became outdated
+func (p *ImageUpdatesPlugin) reportNewUpdates(previous, current []ContainerStatus) {
+	known := make(map[string]string, len(previous))
+	for _, c := range previous {
+		if c.UpdateAvailable {
+			known[c.ID] = c.RemoteDigest
+		}
+	}
+	for _, c := range current {
+		if c.UpdateAvailable && known[c.ID] != c.RemoteDigest {
+			p.Events().System(events.EventImageUpdate, true,
+				fmt.Sprintf("update available for %s (%s)", c.Name, c.Image),
+				events.Meta{"container": c.Name, "image": c.Image})
+		}
+	}
+}
+
+// publishMQTT publishes an "update available" binary sensor per container
+func (p *ImageUpdatesPlugin) publishMQTT(results []ContainerStatus) {
+	deps := p.Deps()
+	if !p.isMQTTEnabled() || deps == nil {
+		return
+	}
+	client, publisher := deps.MQTTClient(), deps.MQTTPublisher()
+	if client == nil || publisher == nil || !client.IsConnected() {
+		return
+	}
+
+	client.Publish(availabilityTopic, []byte("online"))
+
+	if discovery := p.discoveryManager(); discovery != nil && discovery.ShouldRepublishDiscovery(len(results)) {
+		deviceInfo := discovery.Device("Image Update Checker")
+		configs := make([]*mqtt.SensorConfig, 0, len(results))
+		for _, c := range results {
+			sensorID := sensorID(c.Name)
+			configs = append(configs, &mqtt.SensorConfig{
+				SensorID:          sensorID,
+				Name:              c.Name + " Update Available",
+				SensorType:        mqtt.SensorTypeBinary,
+				StateTopic:        "sensor/" + sensorID + "/state",
+				AttributesTopic:   "sensor/" + sensorID + "/attributes",
+				DeviceClass:       "update",
+				AvailabilityTopic: availabilityTopic,
+				DeviceInfo:        deviceInfo,
+			})
+		}
+		discovery.PublishMultipleDiscoveryConfigs(configs)
+	}
+
+	for _, c := range results {
+		attributes := map[string]interface{}{
+			"container": c.Name,
+			"image":     c.Image,
+		}
+		if c.LocalDigest != "" {
+			attributes["local_digest"] = c.LocalDigest
+		}
+		if c.RemoteDigest != "" {
+			attributes["remote_digest"] = c.RemoteDigest
+		}
+		if c.Error != "" {
+			attributes["error"] = c.Error
+		}
+		publisher.PublishSensorState(&mqtt.SensorData{
+			ID:         sensorID(c.Name),
+			Label:      c.Name,
+			Value:      c.UpdateAvailable,
+			Attributes: attributes,
+		})
+	}
+}
+
+// loadSettings loads plugin settings from storage
+func (p *ImageUpdatesPlugin) loadSettings(storage storage.Storage) {
+	if storage == nil {
+		return
+	}
+
+	schedule, err := storage.GetString(p.Name(), "schedule")
+	if err == nil {
+		if _, err := plugins.ParseCron(schedule); err == nil {
+			p.mu.Lock()
+			p.schedule = schedule
+			p.mu.Unlock()
+		} else if p.Logger() != nil {
+			p.Logger().Printf("[%s] Ignoring invalid stored schedule %q: %v", p.Name(), schedule, err)
+		}
+	} else {
+		// Save default schedule if not set
+		storage.SetString(p.Name(), "schedule", DefaultSchedule)
+	}
+
+	mqttEnabled, err := storage.GetBool(p.Name(), "mqttEnabled")
+	if err == nil {
+		p.mu.Lock()
+		p.mqttEnabled = mqttEnabled
+		p.mu.Unlock()
+	} else {
+		// Save default state if not set
+		storage.SetBool(p.Name(), "mqttEnabled", false)
+	}
+}
+
+// getSchedule returns the cron schedule of the check
+func (p *ImageUpdatesPlugin) getSchedule() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.schedule
+}
+
+// RediscoverMQTT republishes the Home Assistant discovery configs with the
+// results of the last check, instead of waiting for the next scheduled one
+func (p *ImageUpdatesPlugin) RediscoverMQTT() error {
+	discovery := p.discoveryManager()
+	if !p.isMQTTEnabled() || discovery == nil {
+		return fmt.Errorf("%w: MQTT publishing is disabled", plugins.ErrNoDiscovery)
+	}
+	discovery.Reset()
+	if status := p.GetStatus(); status.LastCheck != nil {
+		p.publishMQTT(status.Containers)
+	}
+	return nil
+}
+
+// isMQTTEnabled reports whether update sensors are published
+func (p *ImageUpdatesPlugin) isMQTTEnabled() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.mqttEnabled
+}
+
+// setChecking marks a check as running or finished
+func (p *ImageUpdatesPlugin) setChecking(checking bool) {
+	p.mu.Lock()
+	p.checking = checking
+	p.mu.Unlock()
+}
+
+// containerName returns the container's first name, or its short ID
+func containerName(names []string, id string) string {
+	if len(names) > 0 {
+		return strings.TrimPrefix(names[0], "/")
+	}
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
+
+// repoDigests extracts the digests from name@digest entries
+func repoDigests(entries []string) []string {
+	var digests []string
+	for _, entry := range entries {
+		if _, digest, ok := strings.Cut(entry, "@"); ok && !contains(digests, digest) {
+			digests = append(digests, digest)
+		}
+	}
+	return digests
+}
+
+// contains reports whether list holds s
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// sensorID returns the MQTT sensor ID for a container
+func sensorID(name string) string {
+	id := strings.ToLower(name)
+	id = strings.NewReplacer(" ", "_", "/", "_", ".", "_", "-", "_").Replace(id)
+	return "image_update_" + id
+}
+
+// discoveryManager returns the plugin's own discovery manager (the shared
+// one tracks the temperature sensor count), created once an MQTT client
+// exists, which may be after Init when MQTT is enabled at runtime
+func (p *ImageUpdatesPlugin) discoveryManager() *mqtt.DiscoveryManager {
+	deps := p.Deps()
+	client := deps.MQTTClient()
+	if client == nil {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovery == nil {
+		p.discovery = mqtt.NewDiscoveryManager(client, deps.Logger, deps.Storage, p.Name())
+	}
+	return p.discovery
+}
@@ -0,0 +1,404 @@
+package imageupdates
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// dockerHub is the canonical name of Docker Hub in image references
+const dockerHub = "docker.io"
+
+// manifestTypes are the manifest media types accepted from the registry.
+// Lists come first so the digest matches what Podman records for multi-arch images.
+var manifestTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// maxManifestSize limits manifests downloaded when the registry doesn't send a digest
+const maxManifestSize = 4 << 20
+
+// ErrNotTagged is returned for images that can't be looked up by tag
+// (pinned by digest, referenced by ID or built locally)
+var ErrNotTagged = errors.New("image is not referenced by a registry tag")
+
+// Reference is a parsed image reference
+type Reference struct {
+	Registry   string // registry host, e.g. docker.io, ghcr.io, registry.lan:5000
+	Repository string // repository path, e.g. library/nginx
+	Tag        string
+}
+
+// ParseReference parses an image name as shown by Podman
+// (docker.io/library/nginx:latest, ghcr.io/owner/app:1.2, nginx).
+// Returns ErrNotTagged for digest-pinned, ID-only and localhost images.
+func ParseReference(image string) (Reference, error) {
+	name := strings.TrimSpace(image)
+	if name == "" || strings.Contains(name, "@") || isImageID(name) {
+		return Reference{}, ErrNotTagged
+	}
+
+	var ref Reference
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		ref.Tag = name[i+1:]
+		name = name[:i]
+	}
+	if ref.Tag == "" {
+		ref.Tag = "latest"
+	}
+
+	ref.Registry = dockerHub
+	if i := strings.Index(name, "/"); i >= 0 && (strings.ContainsAny(name[:i], ".:") || name[:i] == "localhost") {
+		ref.Registry = name[:i]
+		name = name[i+1:]
+	}
+	if ref.Registry == "index.docker.io" {
+		ref.Registry = dockerHub
+	}
+	if ref.Registry == "localhost" {
+		return Reference{}, ErrNotTagged
+	}
+
+	if ref.Registry == dockerHub && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+	if name == "" {
+		return Reference{}, fmt.Errorf("invalid image reference %q", image)
+	}
+	ref.Repository = name
+
+	return ref, nil
+}
+
+// String returns the fully qualified reference
+func (r Reference) String() string {
+	return r.Registry + "/" + r.Repository + ":" + r.Tag
+}
+
+// host returns the host serving the registry API
+func (r Reference) host() string {
+	if r.Registry == dockerHub {
+		return "registry-1.docker.io"
+	}
+	return r.Registry
+}
+
+// isImageID reports whether s is a bare image ID (full or sha256: prefixed)
+func isImageID(s string) bool {
+	s = strings.TrimPrefix(s, "sha256:")
+	if len(s) != 64 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// Credential is a registry login
+type Credential struct {
+	Username string
+	Password string
+}
+
+// Credentials holds registry logins keyed by registry host,
+// optionally followed by a repository namespace (quay.io/org)
+type Credentials map[string]Credential
+
+// authFile is the format of containers auth.json and Docker config.json
+type authFile struct {
+	Auths map[string]struct {
+		Auth string `json:"auth"`
+	} `json:"auths"`
+}
+
+// DefaultAuthFiles returns the files `podman login` and `docker login` store
+// credentials in, in the order Podman reads them
+func DefaultAuthFiles() []string {
+	var files []string
+	if f := os.Getenv("REGISTRY_AUTH_FILE"); f != "" {
+		files = append(files, f)
+	}
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		files = append(files, filepath.Join(dir, "containers", "auth.json"))
+	}
+	files = append(files, fmt.Sprintf("/run/containers/%d/auth.json", os.Getuid()))
+	if home, err := os.UserHomeDir(); err == nil {
+		files = append(files,
+			filepath.Join(home, ".config", "containers", "auth.json"),
+			filepath.Join(home, ".docker", "config.json"),
+		)
+	}
+	return files
+}
+
+// LoadCredentials reads registry logins from auth files.
+// Missing or unreadable files are skipped; earlier files take precedence.
+func LoadCredentials(files ...string) Credentials {
+	creds := make(Credentials)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		var af authFile
+		if err := json.Unmarshal(data, &af); err != nil {
+			continue
+		}
+		for key, entry := range af.Auths {
+			decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+			if err != nil {
+				continue
+			}
+			user, pass, ok := strings.Cut(string(decoded), ":")
+			if !ok {
+				continue
+			}
+			key = normalizeAuthKey(key)
+			if _, exists := creds[key]; !exists {
+				creds[key] = Credential{Username: user, Password: pass}
+			}
+		}
+	}
+	return creds
+}
+
+// normalizeAuthKey strips the scheme and API path Docker stores in keys
+// (https://index.docker.io/v1/) and maps Docker Hub hosts to docker.io
+func normalizeAuthKey(key string) string {
+	key = strings.TrimPrefix(key, "https://")
+	key = strings.TrimPrefix(key, "http://")
+	key = strings.TrimSuffix(key, "/")
+	key = strings.TrimSuffix(key, "/v1")
+	key = strings.TrimSuffix(key, "/v2")
+	host, rest, _ := strings.Cut(key, "/")
+	if host == "index.docker.io" || host == "registry-1.docker.io" {
+		host = dockerHub
+	}
+	if rest != "" {
+		return host + "/" + rest
+	}
+	return host
+}
+
+// lookup returns the most specific login for ref
+func (c Credentials) lookup(ref Reference) (Credential, bool) {
+	key := ref.Registry + "/" + ref.Repository
+	for {
+		if cred, ok := c[key]; ok {
+			return cred, true
+		}
+		i := strings.LastIndex(key, "/")
+		if i < 0 {
+			return Credential{}, false
+		}
+		key = key[:i]
+	}
+}
+
+// RegistryClient looks up tag digests with the registry HTTP API v2
+type RegistryClient struct {
+	httpClient *http.Client
+	creds      Credentials
+}
+
+// NewRegistryClient creates a RegistryClient. A nil httpClient uses http.DefaultClient.
+func NewRegistryClient(httpClient *http.Client, creds Credentials) *RegistryClient {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &RegistryClient{httpClient: httpClient, creds: creds}
+}
+
+// Digest returns the manifest digest ref's tag currently points to
+func (c *RegistryClient) Digest(ctx context.Context, ref Reference) (string, error) {
+	manifestURL := fmt.Sprintf("https://%s/v2/%s/manifests/%s", ref.host(), ref.Repository, ref.Tag)
+
+	resp, err := c.do(ctx, http.MethodHead, manifestURL, "")
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	authorization := ""
+	if resp.StatusCode == http.StatusUnauthorized {
+		authorization, err = c.authorize(ctx, ref, resp.Header.Get("WWW-Authenticate"))
+		if err != nil {
+			return "", err
+		}
+		resp, err = c.do(ctx, http.MethodHead, manifestURL, authorization)
+		if err != nil {
+			return "", err
+		}
+		resp.Body.Close()
+	}
+	if err := checkStatus(resp); err != nil {
+		return "", err
+	}
+
+	if digest := resp.Header.Get("Docker-Content-Digest"); digest != "" {
+		return digest, nil
+	}
+
+	// Some registries only send the digest with GET, hash the manifest instead
+	resp, err = c.do(ctx, http.MethodGet, manifestURL, authorization)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return "", err
+	}
+	if digest := resp.Header.Get("Docker-Content-Digest"); digest != "" {
+		return digest, nil
+	}
+	hash := sha256.New()
+	if _, err := io.Copy(hash, io.LimitReader(resp.Body, maxManifestSize)); err != nil {
+		return "", fmt.Errorf("failed to read manifest: %w", err)
+	}
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// do sends a manifest request
+func (c *RegistryClient) do(ctx context.Context, method, url, authorization string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", strings.Join(manifestTypes, ", "))
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	return c.httpClient.Do(req)
+}
+
+// authorize answers a WWW-Authenticate challenge and returns the
+// Authorization header to retry with
+func (c *RegistryClient) authorize(ctx context.Context, ref Reference, challenge string) (string, error) {
+	cred, hasCred := c.creds.lookup(ref)
+	scheme, params := parseChallenge(challenge)
+
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if !hasCred {
+			return "", fmt.Errorf("registry %s requires a login", ref.Registry)
+		}
+		return "Basic " + basicAuth(cred), nil
+
+	case "bearer":
+		realm := params["realm"]
+		if realm == "" {
+			return "", fmt.Errorf("registry %s sent a token challenge without realm", ref.Registry)
+		}
+		tokenURL, err := url.Parse(realm)
+		if err != nil {
+			return "", fmt.Errorf("invalid token realm %q: %w", realm, err)
+		}
+		query := tokenURL.Query()
+		if service := params["service"]; service != "" {
+			query.Set("service", service)
+		}
+		scope := params["scope"]
+		if scope == "" {
+			scope = "repository:" + ref.Repository + ":pull"
+		}
+		query.Set("scope", scope)
+		tokenURL.RawQuery = query.Encode()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenURL.String(), nil)
+		if err != nil {
+			return "", err
+		}
+		if hasCred {
+			req.Header.Set("Authorization", "Basic "+basicAuth(cred))
+		}
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return "", fmt.Errorf("token request failed: %w", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("token request failed: %s", resp.Status)
+		}
+
+		var token struct {
+			Token       string `json:"token"`
+			AccessToken string `json:"access_token"`
+		}
+		if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&token); err != nil {
+			return "", fmt.Errorf("invalid token response: %w", err)
+		}
+		if token.Token == "" {
+			token.Token = token.AccessToken
+		}
+		if token.Token == "" {
+			return "", errors.New("token response has no token")
+		}
+		return "Bearer " + token.Token, nil
+
+	default:
+		return "", fmt.Errorf("registry %s requires unsupported authentication %q", ref.Registry, scheme)
+	}
+}
+
+// checkStatus turns a non-200 manifest response into an error
+func checkStatus(resp *http.Response) error {
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("access denied by registry: %s", resp.Status)
+	case http.StatusNotFound:
+		return errors.New("tag not found in registry")
+	default:
+		return fmt.Errorf("registry returned %s", resp.Status)
+	}
+}
+
+// basicAuth encodes a login for the Basic scheme
+func basicAuth(cred Credential) string {
+	return base64.StdEncoding.EncodeToString([]byte(cred.Username + ":" + cred.Password))
+}
+
+// parseChallenge splits a WWW-Authenticate header
+// (Bearer realm="https://auth.docker.io/token",service="registry.docker.io")
+// into its scheme and parameters
+func parseChallenge(header string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(header), " ")
+	params := make(map[string]string)
+
+	for rest != "" {
+		rest = strings.TrimLeft(rest, " ,")
+		key, value, ok := strings.Cut(rest, "=")
+		if !ok {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+
+		if strings.HasPrefix(value, `"`) {
+			end := strings.Index(value[1:], `"`)
+			if end < 0 {
+				params[key] = value[1:]
+				break
+			}
+			params[key] = value[1 : end+1]
+			rest = value[end+2:]
+		} else {
+			value, rest, _ = strings.Cut(value, ",")
+			params[key] = strings.TrimSpace(value)
+		}
+	}
+
+	return scheme, params
+}
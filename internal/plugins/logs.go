@@ -0,0 +1,114 @@
+package plugins
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limits of the per-plugin log buffers
+const (
+	LogBufferLines   = 500
+	maxLogLineLength = 4096
+)
+
+// LogLine is one line a plugin logged
+type LogLine struct {
+	Time    time.Time `json:"time"`
+	Message string    `json:"message"`
+}
+
+// logBuffer is a ring buffer of a plugin's newest log lines
+type logBuffer struct {
+	lines   []LogLine
+	next    int // index the next line is written to
+	full    bool
+	dropped int // lines overwritten since startup
+}
+
+// LogStore keeps the recent log lines of each plugin, so one plugin's
+// output can be read without the rest of the service log
+type LogStore struct {
+	mu      sync.Mutex
+	buffers map[string]*logBuffer
+	size    int
+}
+
+// NewLogStore creates a store keeping the newest size lines per plugin
+func NewLogStore(size int) *LogStore {
+	return &LogStore{buffers: make(map[string]*logBuffer), size: size}
+}
+
+// Add records a line logged by plugin
+func (s *LogStore) Add(plugin string, t time.Time, message string) {
+	message = strings.TrimRight(message, "\n")
+	if len(message) > maxLogLineLength {
+		message = message[:maxLogLineLength] + "…"
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b := s.buffers[plugin]
+	if b == nil {
+		b = &logBuffer{lines: make([]LogLine, s.size)}
+		s.buffers[plugin] = b
+	}
+	if b.full {
+		b.dropped++
+	}
+	b.lines[b.next] = LogLine{Time: t, Message: message}
+	b.next = (b.next + 1) % len(b.lines)
+	if b.next == 0 {
+		b.full = true
+	}
+}
+
+// Lines returns up to n of the newest lines of plugin (all when n <= 0),
+// oldest first, and whether older lines exist that are not returned
+func (s *LogStore) Lines(plugin string, n int) ([]LogLine, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b := s.buffers[plugin]
+	if b == nil {
+		return []LogLine{}, false
+	}
+
+	count := b.next
+	if b.full {
+		count = len(b.lines)
+	}
+	if n <= 0 || n > count {
+		n = count
+	}
+	result := make([]LogLine, n)
+	start := b.next - n
+	for i := range result {
+		result[i] = b.lines[(start+i+len(b.lines))%len(b.lines)]
+	}
+	return result, b.dropped > 0 || n < count
+}
+
+// Logger returns a logger for plugin that writes through base (with its
+// prefix and timestamp) and keeps each line in the store
+func (s *LogStore) Logger(plugin string, base *log.Logger) *log.Logger {
+	if base == nil {
+		base = log.Default()
+	}
+	return log.New(&pluginLogWriter{store: s, plugin: plugin, base: base}, "", 0)
+}
+
+// pluginLogWriter tees a plugin's log lines into the store
+type pluginLogWriter struct {
+	store  *LogStore
+	plugin string
+	base   *log.Logger
+}
+
+func (w *pluginLogWriter) Write(p []byte) (int, error) {
+	w.store.Add(w.plugin, time.Now(), string(p))
+	if err := w.base.Output(2, string(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
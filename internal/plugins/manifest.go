@@ -0,0 +1,114 @@
+package plugins
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Plugin categories, used to group plugins in the catalog
+const (
+	CategoryMonitoring  = "monitoring"
+	CategoryMaintenance = "maintenance"
+	CategoryBackup      = "backup"
+	CategoryDevelopment = "development"
+	CategoryOther       = "other"
+)
+
+// Capabilities a plugin requires, shown to admins before enabling it
+const (
+	CapabilityPodman  = "podman"  // uses the Podman API
+	CapabilityStorage = "storage" // keeps settings or data in the PodmanView database
+	CapabilityMQTT    = "mqtt"    // publishes over the configured MQTT broker
+	CapabilityNetwork = "network" // makes outbound HTTP requests
+	CapabilityHost    = "host"    // reads or writes host files outside the database
+)
+
+var (
+	manifestCategories = map[string]bool{
+		CategoryMonitoring:  true,
+		CategoryMaintenance: true,
+		CategoryBackup:      true,
+		CategoryDevelopment: true,
+		CategoryOther:       true,
+	}
+	manifestCapabilities = map[string]bool{
+		CapabilityPodman:  true,
+		CapabilityStorage: true,
+		CapabilityMQTT:    true,
+		CapabilityNetwork: true,
+		CapabilityHost:    true,
+	}
+
+	manifestNamePattern    = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+	manifestVersionPattern = regexp.MustCompile(`^\d+\.\d+\.\d+(-[0-9A-Za-z.-]+)?$`)
+	manifestIconPattern    = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+)
+
+// Limits on manifest fields
+const (
+	maxManifestName        = 32
+	maxManifestDescription = 200
+)
+
+// Manifest describes a plugin for the plugin catalog
+type Manifest struct {
+	Name         string   `json:"name"`        // lowercase letters, digits and dashes
+	Description  string   `json:"description"` // one line
+	Version      string   `json:"version"`     // semver
+	Icon         string   `json:"icon,omitempty"`
+	Category     string   `json:"category"`
+	Capabilities []string `json:"capabilities"`
+}
+
+// ManifestProvider is an optional interface for plugins that describe
+// themselves with a manifest. BasePlugin implements it; plugins override
+// Manifest to add their icon, category and capabilities.
+type ManifestProvider interface {
+	Manifest() Manifest
+}
+
+// ManifestOf returns the manifest of a plugin. Plugins without one get a
+// manifest built from their name, description and version.
+func ManifestOf(p Plugin) Manifest {
+	var m Manifest
+	if provider, ok := p.(ManifestProvider); ok {
+		m = provider.Manifest()
+	} else {
+		m = Manifest{Name: p.Name(), Description: p.Description(), Version: p.Version()}
+	}
+	if m.Category == "" {
+		m.Category = CategoryOther
+	}
+	if m.Capabilities == nil {
+		m.Capabilities = []string{}
+	}
+	return m
+}
+
+// Validate checks the manifest's required fields and their formats
+func (m Manifest) Validate() error {
+	switch {
+	case !manifestNamePattern.MatchString(m.Name) || len(m.Name) > maxManifestName:
+		return fmt.Errorf("invalid plugin name %q (lowercase letters, digits and dashes, at most %d characters)", m.Name, maxManifestName)
+	case m.Description == "" || len(m.Description) > maxManifestDescription:
+		return fmt.Errorf("plugin %s: description is required, at most %d characters", m.Name, maxManifestDescription)
+	case !manifestVersionPattern.MatchString(m.Version):
+		return fmt.Errorf("plugin %s: version %q is not semver (1.2.3)", m.Name, m.Version)
+	case m.Icon != "" && !manifestIconPattern.MatchString(m.Icon):
+		return fmt.Errorf("plugin %s: invalid icon name %q", m.Name, m.Icon)
+	case m.Category != "" && !manifestCategories[m.Category]:
+		return fmt.Errorf("plugin %s: unknown category %q", m.Name, m.Category)
+	}
+
+	seen := make(map[string]bool, len(m.Capabilities))
+	for _, c := range m.Capabilities {
+		if !manifestCapabilities[c] {
+			return fmt.Errorf("plugin %s: unknown capability %q", m.Name, c)
+		}
+		if seen[c] {
+			return fmt.Errorf("plugin %s: capability %q listed twice", m.Name, c)
+		}
+		seen[c] = true
+	}
+	return nil
+}
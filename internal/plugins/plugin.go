@@ -2,10 +2,17 @@ package plugins
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
 	"log"
 	"net/http"
 	"os"
+	"path/filepath"
+	"sync"
 	"time"
 
 	"podmanview/internal/config"
@@ -50,6 +57,34 @@ type Plugin interface {
 	GetHTML() (string, error)
 }
 
+// HTMLVersioner is an optional interface for plugins that can identify
+// the current version of their HTML, used as the HTTP ETag so the UI can
+// cache plugin pages. BasePlugin implements it.
+type HTMLVersioner interface {
+	// HTMLVersion returns a value that changes whenever GetHTML's output changes
+	HTMLVersion() (string, error)
+}
+
+// MQTTRediscoverer is an optional interface for plugins that publish Home
+// Assistant discovery configs. RediscoverMQTT republishes them, for a Home
+// Assistant that missed the retained messages, and returns an error
+// wrapping ErrNoDiscovery when the plugin currently publishes none.
+type MQTTRediscoverer interface {
+	RediscoverMQTT() error
+}
+
+// ErrNoDiscovery is returned by RediscoverMQTT when there is nothing to republish
+var ErrNoDiscovery = errors.New("no Home Assistant discovery published")
+
+// SettingsExporter is an optional interface for plugins whose settings are
+// part of the settings bundle. SettingsKeys returns the keys in the
+// plugin's storage namespace that hold settings, leaving out data such as
+// counters or results. Imported values are written to storage as they are
+// and validated when the plugin loads them on the next start.
+type SettingsExporter interface {
+	SettingsKeys() []string
+}
+
 // BackgroundTaskRunner is an optional interface for plugins that need to run background tasks
 // Plugins can implement this interface to run periodic tasks (monitoring, checks, updates, etc.)
 type BackgroundTaskRunner interface {
@@ -77,10 +112,96 @@ type PluginDependencies struct {
 	// Storage is the storage for plugin configurations and data
 	Storage storage.Storage
 
-	// MQTT services (can be nil if MQTT is not configured)
-	MQTTClient    *mqtt.Client           // MQTT client for direct publishing
-	MQTTPublisher *mqtt.Publisher        // Publisher for sensor data
-	MQTTDiscovery *mqtt.DiscoveryManager // Home Assistant discovery manager
+	// HTTPClient is for outbound requests, with the configured timeout and proxy
+	HTTPClient *http.Client
+
+	// mqtt holds the MQTT services, shared by all copies (see SetMQTT)
+	mqtt *mqttServices
+}
+
+// mqttServices are the MQTT services of all plugins. MQTT can be enabled
+// at runtime, after the plugins got their dependencies, so they are
+// replaced under a lock instead of in each copy.
+type mqttServices struct {
+	mu        sync.RWMutex
+	client    *mqtt.Client
+	publisher *mqtt.Publisher
+	discovery *mqtt.DiscoveryManager
+}
+
+// mqttServicesInit guards the lazy creation of PluginDependencies.mqtt
+var mqttServicesInit sync.Mutex
+
+// services returns the shared MQTT services, creating them on first use
+func (d *PluginDependencies) services() *mqttServices {
+	mqttServicesInit.Lock()
+	defer mqttServicesInit.Unlock()
+	if d.mqtt == nil {
+		d.mqtt = &mqttServices{}
+	}
+	return d.mqtt
+}
+
+// SetMQTT sets the MQTT services for the dependencies and all their copies
+func (d *PluginDependencies) SetMQTT(client *mqtt.Client, publisher *mqtt.Publisher, discovery *mqtt.DiscoveryManager) {
+	s := d.services()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.client, s.publisher, s.discovery = client, publisher, discovery
+}
+
+// MQTTClient returns the MQTT client for direct publishing (nil if MQTT is
+// not configured). The MQTT getters are safe to call on nil dependencies.
+func (d *PluginDependencies) MQTTClient() *mqtt.Client {
+	if d == nil {
+		return nil
+	}
+	s := d.services()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.client
+}
+
+// MQTTPublisher returns the publisher for sensor data (nil if MQTT is not configured)
+func (d *PluginDependencies) MQTTPublisher() *mqtt.Publisher {
+	if d == nil {
+		return nil
+	}
+	s := d.services()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.publisher
+}
+
+// MQTTDiscovery returns the Home Assistant discovery manager (nil if MQTT is not configured)
+func (d *PluginDependencies) MQTTDiscovery() *mqtt.DiscoveryManager {
+	if d == nil {
+		return nil
+	}
+	s := d.services()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.discovery
+}
+
+// ForPlugin returns a copy of the dependencies for one plugin whose Logger
+// also keeps the plugin's lines in logs
+func (d *PluginDependencies) ForPlugin(plugin string, logs *LogStore) *PluginDependencies {
+	if d == nil || logs == nil {
+		return d
+	}
+	d.services() // the copy shares the MQTT services
+	deps := *d
+	deps.Logger = logs.Logger(plugin, d.Logger)
+	return &deps
+}
+
+// Events returns an emitter that records audit events for plugin
+func (d *PluginDependencies) Events(plugin string) *EventEmitter {
+	if d == nil {
+		return NewEventEmitter(nil, plugin)
+	}
+	return NewEventEmitter(d.EventStore, plugin)
 }
 
 // Route represents a plugin's HTTP route
@@ -95,7 +216,9 @@ type Route struct {
 	// Handler is the request handler
 	Handler http.HandlerFunc
 
-	// RequireAuth indicates whether authentication is required for this route
+	// RequireAuth indicates whether authentication is required for this route.
+	// Authenticated routes other than GET and HEAD also require the
+	// manage_plugins action.
 	RequireAuth bool
 }
 
@@ -126,6 +249,15 @@ type BasePlugin struct {
 	deps        *PluginDependencies
 	logger      *log.Logger
 	htmlPath    string // Path to the plugin's HTML file
+	htmlFS      fs.FS  // Filesystem htmlPath is read from (nil = disk)
+	sourceDir   string // Directory under internal/plugins in dev mode (default: name)
+
+	// Cached HTML, re-read from disk only when the file changes
+	htmlMu      sync.Mutex
+	htmlContent string
+	htmlVersion string
+	htmlModTime time.Time
+	htmlSize    int64
 }
 
 // NewBasePlugin creates a new BasePlugin
@@ -138,6 +270,21 @@ func NewBasePlugin(name, description, version, htmlPath string) *BasePlugin {
 	}
 }
 
+// NewBasePluginFS creates a new BasePlugin whose HTML is read from fsys
+// (typically an embed.FS), so it doesn't depend on the working directory
+func NewBasePluginFS(name, description, version string, fsys fs.FS, htmlPath string) *BasePlugin {
+	p := NewBasePlugin(name, description, version, htmlPath)
+	p.htmlFS = fsys
+	return p
+}
+
+// SetSourceDir sets the plugin's package directory under internal/plugins,
+// where embedded HTML is read from in dev mode, for plugins whose name
+// isn't a valid package name ("image-updates" lives in imageupdates)
+func (p *BasePlugin) SetSourceDir(dir string) {
+	p.sourceDir = dir
+}
+
 // Name implements Plugin.Name
 func (p *BasePlugin) Name() string {
 	return p.name
@@ -153,6 +300,17 @@ func (p *BasePlugin) Version() string {
 	return p.version
 }
 
+// Manifest implements ManifestProvider with the name, description and
+// version; plugins embedding BasePlugin override it to add the rest
+func (p *BasePlugin) Manifest() Manifest {
+	return Manifest{
+		Name:        p.name,
+		Description: p.description,
+		Version:     p.version,
+		Category:    CategoryOther,
+	}
+}
+
 // SetDependencies sets the plugin's dependencies
 func (p *BasePlugin) SetDependencies(deps *PluginDependencies) {
 	p.deps = deps
@@ -169,6 +327,38 @@ func (p *BasePlugin) Logger() *log.Logger {
 	return p.logger
 }
 
+// EventStore returns the event store for audit logging (nil before Init)
+func (p *BasePlugin) EventStore() *events.Store {
+	if p.deps == nil {
+		return nil
+	}
+	return p.deps.EventStore
+}
+
+// Events returns the plugin's event emitter (discards events before Init)
+func (p *BasePlugin) Events() *EventEmitter {
+	return p.deps.Events(p.name)
+}
+
+// HTTPClient returns the shared outbound HTTP client (default client before Init)
+func (p *BasePlugin) HTTPClient() *http.Client {
+	if p.deps == nil || p.deps.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return p.deps.HTTPClient
+}
+
+// Disabling reports whether the plugin is disabled in storage. Within Stop
+// it tells an admin disabling the plugin (the state is saved before Stop is
+// called) from a shutdown, where the plugin stays enabled.
+func (p *BasePlugin) Disabling() bool {
+	if p.deps == nil || p.deps.Storage == nil {
+		return false
+	}
+	enabled, err := p.deps.Storage.IsPluginEnabled(p.name)
+	return err == nil && !enabled
+}
+
 // LogError logs an error message
 func (p *BasePlugin) LogError(format string, v ...interface{}) {
 	if p.logger != nil {
@@ -179,15 +369,65 @@ func (p *BasePlugin) LogError(format string, v ...interface{}) {
 
 // GetHTML returns the plugin's HTML interface
 func (p *BasePlugin) GetHTML() (string, error) {
+	content, _, err := p.loadHTML()
+	return content, err
+}
+
+// HTMLVersion implements HTMLVersioner
+func (p *BasePlugin) HTMLVersion() (string, error) {
+	_, version, err := p.loadHTML()
+	return version, err
+}
+
+// loadHTML returns the cached HTML and its version. Embedded HTML is read
+// once; files on disk (including embedded pages in dev mode) are re-read
+// when their mtime or size changes.
+func (p *BasePlugin) loadHTML() (content, version string, err error) {
 	if p.htmlPath == "" {
-		return "", nil
+		return "", "", nil
+	}
+
+	p.htmlMu.Lock()
+	defer p.htmlMu.Unlock()
+
+	fsys, path := p.htmlFS, p.htmlPath
+	// Development: read embedded pages from the source checkout (PODMANVIEW_DEV_DIR)
+	if fsys != nil && p.deps != nil && p.deps.Config != nil && p.deps.Config.DevDir() != "" {
+		dir := p.sourceDir
+		if dir == "" {
+			dir = p.name
+		}
+		fsys, path = nil, filepath.Join(p.deps.Config.DevDir(), "internal", "plugins", dir, p.htmlPath)
+	}
+
+	if fsys != nil {
+		if p.htmlVersion == "" {
+			data, err := fs.ReadFile(fsys, path)
+			if err != nil {
+				return "", "", err
+			}
+			sum := sha256.Sum256(data)
+			p.htmlContent = string(data)
+			p.htmlVersion = hex.EncodeToString(sum[:8])
+		}
+		return p.htmlContent, p.htmlVersion, nil
 	}
-	// Read HTML file from the plugin's directory
-	content, err := os.ReadFile(p.htmlPath)
+
+	info, err := os.Stat(path)
 	if err != nil {
-		return "", err
+		return "", "", err
+	}
+	if p.htmlVersion == "" || !info.ModTime().Equal(p.htmlModTime) || info.Size() != p.htmlSize {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", "", err
+		}
+		p.htmlContent = string(data)
+		p.htmlModTime = info.ModTime()
+		p.htmlSize = info.Size()
+		p.htmlVersion = fmt.Sprintf("%x-%x", info.ModTime().UnixNano(), info.Size())
 	}
-	return string(content), nil
+	return p.htmlContent, p.htmlVersion, nil
 }
 
 // WriteJSON is a shared helper function for writing JSON responses
@@ -200,24 +440,33 @@ func WriteJSON(w http.ResponseWriter, status int, data interface{}) {
 	}
 }
 
+// WriteError writes an error response in the API's
+// {"error": {"code", "message"}} envelope
+func WriteError(w http.ResponseWriter, status int, code, message string) {
+	WriteJSON(w, status, map[string]map[string]string{
+		"error": {"code": code, "message": message},
+	})
+}
+
 // RunPeriodic runs a function periodically until the context is cancelled
 // This is a helper for plugins that need to run background tasks
+// Task errors are logged and recorded in eventStore (can be nil) as
+// EventPluginError - only when the error first appears or changes,
+// so a persistently failing task doesn't flood the audit log
 // Usage example:
 //
-//	go RunPeriodic(ctx, 30*time.Second, p.logger, p.name, func(ctx context.Context) error {
+//	go RunPeriodic(ctx, 30*time.Second, p.Logger(), p.EventStore(), p.Name(), func(ctx context.Context) error {
 //	    // Your periodic task here
 //	    return p.checkStatus()
 //	})
-func RunPeriodic(ctx context.Context, interval time.Duration, logger *log.Logger, pluginName string, task func(context.Context) error) {
+func RunPeriodic(ctx context.Context, interval time.Duration, logger *log.Logger, eventStore *events.Store, pluginName string, task func(context.Context) error) {
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
+	runTask := taskRunner(ctx, logger, eventStore, pluginName, task)
+
 	// Run task immediately on start
-	if err := task(ctx); err != nil {
-		if logger != nil {
-			logger.Printf("[%s] Background task error: %v", pluginName, err)
-		}
-	}
+	runTask()
 
 	// Then run periodically
 	for {
@@ -228,12 +477,29 @@ func RunPeriodic(ctx context.Context, interval time.Duration, logger *log.Logger
 			}
 			return
 		case <-ticker.C:
-			if err := task(ctx); err != nil {
-				if logger != nil {
-					logger.Printf("[%s] Background task error: %v", pluginName, err)
-				}
-			}
+			runTask()
+		}
+	}
+}
+
+// taskRunner wraps a background task so its errors are logged and
+// recorded as EventPluginError when they first appear or change
+func taskRunner(ctx context.Context, logger *log.Logger, eventStore *events.Store, pluginName string, task func(context.Context) error) func() {
+	emitter := NewEventEmitter(eventStore, pluginName)
+	lastErr := ""
+	return func() {
+		err := task(ctx)
+		if err == nil {
+			lastErr = ""
+			return
+		}
+		if logger != nil {
+			logger.Printf("[%s] Background task error: %v", pluginName, err)
+		}
+		if err.Error() != lastErr {
+			emitter.System(events.EventPluginError, false, err.Error(), nil)
 		}
+		lastErr = err.Error()
 	}
 }
 
@@ -2,16 +2,22 @@ package plugins
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
+	"time"
 )
 
+// ErrStopTimeout is returned when a plugin doesn't stop within its timeout
+var ErrStopTimeout = errors.New("plugin did not stop in time")
+
 // Registry is the registry of all plugins
 type Registry struct {
 	mu      sync.RWMutex
 	plugins map[string]Plugin
 	order   []string // registration order
 	deps    *PluginDependencies
+	logs    *LogStore // recent log lines of each plugin
 }
 
 // NewRegistry creates a new plugin registry
@@ -19,6 +25,7 @@ func NewRegistry() *Registry {
 	return &Registry{
 		plugins: make(map[string]Plugin),
 		order:   make([]string, 0),
+		logs:    NewLogStore(LogBufferLines),
 	}
 }
 
@@ -36,6 +43,19 @@ func (r *Registry) Deps() *PluginDependencies {
 	return r.deps
 }
 
+// Logs returns the store of the plugins' recent log lines
+func (r *Registry) Logs() *LogStore {
+	return r.logs
+}
+
+// PluginDeps returns the dependencies to initialize a plugin with: the
+// shared ones, with a logger that keeps the plugin's lines in Logs
+func (r *Registry) PluginDeps(name string) *PluginDependencies {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.deps.ForPlugin(name, r.logs)
+}
+
 // Register registers a plugin in the registry
 func (r *Registry) Register(p Plugin) error {
 	if p == nil {
@@ -46,6 +66,13 @@ func (r *Registry) Register(p Plugin) error {
 	if name == "" {
 		return fmt.Errorf("plugin name cannot be empty")
 	}
+	manifest := ManifestOf(p)
+	if manifest.Name != name {
+		return fmt.Errorf("plugin %s: manifest name %q differs from the plugin name", name, manifest.Name)
+	}
+	if err := manifest.Validate(); err != nil {
+		return err
+	}
 
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -158,7 +185,7 @@ func (r *Registry) InitAll(ctx context.Context, deps *PluginDependencies) error
 	initialized := make([]Plugin, 0, len(enabled))
 
 	for _, p := range enabled {
-		if err := p.Init(ctx, deps); err != nil {
+		if err := p.Init(ctx, deps.ForPlugin(p.Name(), r.logs)); err != nil {
 			// Rollback: stop all already initialized plugins
 			for i := len(initialized) - 1; i >= 0; i-- {
 				if stopErr := initialized[i].Stop(ctx); stopErr != nil {
@@ -235,6 +262,26 @@ func (r *Registry) StopAll(ctx context.Context) error {
 	return lastErr
 }
 
+// StopWithTimeout stops p with its own deadline. Stop runs in a goroutine
+// so a plugin that ignores its context can't block the caller; it is
+// abandoned when the timeout expires.
+func StopWithTimeout(p Plugin, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- p.Stop(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("%w after %v", ErrStopTimeout, timeout)
+	}
+}
+
 // GetInfo returns information about a plugin
 func (r *Registry) GetInfo(name string) (*PluginInfo, error) {
 	p, ok := r.Get(name)
@@ -289,7 +336,7 @@ func (r *Registry) EnablePlugin(ctx context.Context, name string) error {
 	}
 
 	if r.deps != nil {
-		if err := plugin.Init(ctx, r.deps); err != nil {
+		if err := plugin.Init(ctx, r.deps.ForPlugin(name, r.logs)); err != nil {
 			return fmt.Errorf("failed to init plugin %s: %w", name, err)
 		}
 	}
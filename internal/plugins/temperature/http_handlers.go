@@ -2,6 +2,7 @@ package temperature
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
@@ -11,16 +12,21 @@ import (
 
 // PluginSettings represents plugin configuration
 type PluginSettings struct {
-	UpdateInterval int `json:"updateInterval"` // Update interval in seconds
+	UpdateInterval  int    `json:"updateInterval"`            // Update interval in seconds
+	StorageInterval int    `json:"storageInterval,omitempty"` // NVMe temperature interval in seconds (0 keeps the current one)
+	PublishMode     string `json:"publishMode,omitempty"`     // MQTT messages: both, aggregated or individual (empty keeps the current one)
+	Unit            string `json:"unit,omitempty"`            // C or F, for MQTT values and the UI (empty keeps the current one)
+	Precision       *int   `json:"precision,omitempty"`       // decimals MQTT values are rounded to, 0-3 (omit to keep the current one)
 }
 
 // MQTTStatus represents MQTT status
 type MQTTStatus struct {
-	Enabled     bool   `json:"enabled"`     // MQTT publishing enabled
-	Connected   bool   `json:"connected"`   // MQTT client connected
-	Configured  bool   `json:"configured"`  // MQTT broker configured
-	BrokerURL   string `json:"brokerUrl"`   // MQTT broker URL (for display)
-	TopicPrefix string `json:"topicPrefix"` // MQTT topic prefix
+	Enabled     bool   `json:"enabled"`             // MQTT publishing enabled
+	Connected   bool   `json:"connected"`           // MQTT client connected
+	Configured  bool   `json:"configured"`          // MQTT broker configured
+	BrokerURL   string `json:"brokerUrl"`           // MQTT broker URL (for display)
+	TopicPrefix string `json:"topicPrefix"`         // MQTT topic prefix
+	LastError   string `json:"lastError,omitempty"` // Why the broker connection was last lost
 }
 
 // MQTTToggleRequest represents request to toggle MQTT
@@ -38,10 +44,17 @@ func (p *TemperaturePlugin) handleGetTemperatures(w http.ResponseWriter, r *http
 func (p *TemperaturePlugin) handleGetSettings(w http.ResponseWriter, r *http.Request) {
 	p.mu.RLock()
 	interval := int(p.updatePeriod.Seconds())
+	storageInterval := int(p.storageInterval.Seconds())
+	publishMode := p.publishMode
+	unit, precision := p.unit, p.precision
 	p.mu.RUnlock()
 
 	settings := PluginSettings{
-		UpdateInterval: interval,
+		UpdateInterval:  interval,
+		StorageInterval: storageInterval,
+		PublishMode:     publishMode,
+		Unit:            unit,
+		Precision:       &precision,
 	}
 
 	plugins.WriteJSON(w, http.StatusOK, settings)
@@ -51,19 +64,55 @@ func (p *TemperaturePlugin) handleGetSettings(w http.ResponseWriter, r *http.Req
 func (p *TemperaturePlugin) handleUpdateSettings(w http.ResponseWriter, r *http.Request) {
 	var settings PluginSettings
 	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
-		plugins.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		plugins.WriteError(w, http.StatusBadRequest, "invalid_body", "Invalid request body")
 		return
 	}
 
 	// Validate interval (5-60 seconds)
 	if settings.UpdateInterval < 5 || settings.UpdateInterval > 60 {
-		plugins.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Update interval must be between 5 and 60 seconds"})
+		plugins.WriteError(w, http.StatusBadRequest, "invalid_settings", "Update interval must be between 5 and 60 seconds")
 		return
 	}
 
-	// Update in-memory interval
+	if settings.StorageInterval != 0 && !validStorageInterval(settings.StorageInterval) {
+		plugins.WriteError(w, http.StatusBadRequest, "invalid_settings", fmt.Sprintf("Storage interval must be between %d and %d seconds",
+			int(MinStorageInterval.Seconds()), int(MaxStorageInterval.Seconds())))
+		return
+	}
+
+	if settings.PublishMode != "" && !validPublishMode(settings.PublishMode) {
+		plugins.WriteError(w, http.StatusBadRequest, "invalid_settings", "Publish mode must be both, aggregated or individual")
+		return
+	}
+
+	if settings.Unit != "" && !validUnit(settings.Unit) {
+		plugins.WriteError(w, http.StatusBadRequest, "invalid_settings", "Unit must be C or F")
+		return
+	}
+
+	if settings.Precision != nil && (*settings.Precision < 0 || *settings.Precision > MaxPrecision) {
+		plugins.WriteError(w, http.StatusBadRequest, "invalid_settings", fmt.Sprintf("Precision must be between 0 and %d decimals", MaxPrecision))
+		return
+	}
+
+	// Update in-memory intervals
 	p.mu.Lock()
 	p.updatePeriod = time.Duration(settings.UpdateInterval) * time.Second
+	if settings.StorageInterval != 0 {
+		p.storageInterval = time.Duration(settings.StorageInterval) * time.Second
+	}
+	previousMode := p.publishMode
+	if settings.PublishMode != "" {
+		p.publishMode = settings.PublishMode
+	}
+	previousUnit := p.unit
+	if settings.Unit != "" {
+		p.unit = settings.Unit
+	}
+	if settings.Precision != nil {
+		p.precision = *settings.Precision
+	}
+	mqttEnabled := p.mqttEnabled
 	p.mu.Unlock()
 
 	// Save to storage
@@ -72,9 +121,58 @@ func (p *TemperaturePlugin) handleUpdateSettings(w http.ResponseWriter, r *http.
 			if p.Logger() != nil {
 				p.Logger().Printf("[%s] Failed to save update interval to storage: %v", p.Name(), err)
 			}
-			plugins.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to save settings"})
+			plugins.WriteError(w, http.StatusInternalServerError, "storage_error", "Failed to save settings")
 			return
 		}
+		if settings.StorageInterval != 0 {
+			if err := p.Deps().Storage.SetInt(p.Name(), "storageInterval", settings.StorageInterval); err != nil {
+				if p.Logger() != nil {
+					p.Logger().Printf("[%s] Failed to save storage interval to storage: %v", p.Name(), err)
+				}
+				plugins.WriteError(w, http.StatusInternalServerError, "storage_error", "Failed to save settings")
+				return
+			}
+		}
+		if settings.PublishMode != "" {
+			if err := p.Deps().Storage.SetString(p.Name(), "publishMode", settings.PublishMode); err != nil {
+				p.LogError("Failed to save publish mode to storage: %v", err)
+				plugins.WriteError(w, http.StatusInternalServerError, "storage_error", "Failed to save settings")
+				return
+			}
+		}
+		if settings.Unit != "" {
+			if err := p.Deps().Storage.SetString(p.Name(), "unit", settings.Unit); err != nil {
+				p.LogError("Failed to save unit to storage: %v", err)
+				plugins.WriteError(w, http.StatusInternalServerError, "storage_error", "Failed to save settings")
+				return
+			}
+		}
+		if settings.Precision != nil {
+			if err := p.Deps().Storage.SetInt(p.Name(), "precision", *settings.Precision); err != nil {
+				p.LogError("Failed to save precision to storage: %v", err)
+				plugins.WriteError(w, http.StatusInternalServerError, "storage_error", "Failed to save settings")
+				return
+			}
+		}
+	}
+
+	// Withdraw the Home Assistant sensors when their topics stop, or
+	// announce them again on the next update when they resume
+	if deps := p.Deps(); settings.PublishMode != "" && settings.PublishMode != previousMode && mqttEnabled && deps != nil && deps.MQTTDiscovery() != nil {
+		if settings.PublishMode == PublishAggregated {
+			if deps.MQTTClient() != nil && deps.MQTTClient().IsConnected() {
+				if err := deps.MQTTDiscovery().RemoveDiscoveryConfigs(); err != nil {
+					p.LogError("Failed to remove MQTT discovery: %v", err)
+				}
+			}
+		} else if previousMode == PublishAggregated {
+			deps.MQTTDiscovery().Reset()
+		}
+	}
+
+	// Home Assistant takes the unit from the discovery config
+	if deps := p.Deps(); settings.Unit != "" && settings.Unit != previousUnit && mqttEnabled && deps != nil && deps.MQTTDiscovery() != nil {
+		deps.MQTTDiscovery().Reset()
 	}
 
 	// Restart background task with new interval
@@ -82,7 +180,7 @@ func (p *TemperaturePlugin) handleUpdateSettings(w http.ResponseWriter, r *http.
 		if p.Logger() != nil {
 			p.Logger().Printf("[%s] Failed to restart background tasks: %v", p.Name(), err)
 		}
-		plugins.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to restart background tasks"})
+		plugins.WriteError(w, http.StatusInternalServerError, "internal_error", "Failed to restart background tasks")
 		return
 	}
 
@@ -100,7 +198,7 @@ func (p *TemperaturePlugin) handleGetMQTTStatus(w http.ResponseWriter, r *http.R
 	p.mu.RUnlock()
 
 	deps := p.Deps()
-	mqttClient := deps.MQTTClient
+	mqttClient := deps.MQTTClient()
 
 	status := MQTTStatus{
 		Enabled:    enabled,
@@ -113,6 +211,7 @@ func (p *TemperaturePlugin) handleGetMQTTStatus(w http.ResponseWriter, r *http.R
 		cfg := mqttClient.GetConfig()
 		status.BrokerURL = cfg.Broker
 		status.TopicPrefix = cfg.Prefix
+		status.LastError = mqttClient.State().LastError
 	}
 
 	plugins.WriteJSON(w, http.StatusOK, status)
@@ -122,16 +221,16 @@ func (p *TemperaturePlugin) handleGetMQTTStatus(w http.ResponseWriter, r *http.R
 func (p *TemperaturePlugin) handleToggleMQTT(w http.ResponseWriter, r *http.Request) {
 	var req MQTTToggleRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		plugins.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		plugins.WriteError(w, http.StatusBadRequest, "invalid_body", "Invalid request body")
 		return
 	}
 
 	deps := p.Deps()
-	mqttClient := deps.MQTTClient
+	mqttClient := deps.MQTTClient()
 
 	// Check if MQTT client is configured
 	if mqttClient == nil {
-		plugins.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "MQTT is not configured. Please set MQTT broker in .env file"})
+		plugins.WriteError(w, http.StatusBadRequest, "mqtt_not_configured", "MQTT is not configured. Please set MQTT broker in .env file")
 		return
 	}
 
@@ -146,7 +245,7 @@ func (p *TemperaturePlugin) handleToggleMQTT(w http.ResponseWriter, r *http.Requ
 			if p.Logger() != nil {
 				p.Logger().Printf("[%s] Failed to save MQTT enabled state: %v", p.Name(), err)
 			}
-			plugins.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to save settings"})
+			plugins.WriteError(w, http.StatusInternalServerError, "storage_error", "Failed to save settings")
 			return
 		}
 	}
@@ -158,7 +257,7 @@ func (p *TemperaturePlugin) handleToggleMQTT(w http.ResponseWriter, r *http.Requ
 				if p.Logger() != nil {
 					p.Logger().Printf("[%s] Failed to connect to MQTT broker: %v", p.Name(), err)
 				}
-				plugins.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to connect to MQTT broker"})
+				plugins.WriteError(w, http.StatusInternalServerError, "mqtt_error", "Failed to connect to MQTT broker")
 				return
 			}
 		}
@@ -172,8 +271,13 @@ func (p *TemperaturePlugin) handleToggleMQTT(w http.ResponseWriter, r *http.Requ
 			p.Logger().Printf("[%s] MQTT publishing enabled", p.Name())
 		}
 	} else {
-		// Publish offline status before disconnecting
+		// Remove the sensors from Home Assistant and publish offline status before disconnecting
 		if mqttClient.IsConnected() {
+			if deps.MQTTDiscovery() != nil {
+				if err := deps.MQTTDiscovery().RemoveDiscoveryConfigs(); err != nil {
+					p.LogError("Failed to remove MQTT discovery: %v", err)
+				}
+			}
 			mqttClient.Publish("sensor/temperature/availability", []byte("offline"))
 			time.Sleep(100 * time.Millisecond) // Wait for publish
 		}
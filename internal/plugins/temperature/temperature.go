@@ -3,6 +3,9 @@ package temperature
 
 import (
 	"context"
+	"embed"
+	"fmt"
+	"math"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -17,17 +20,118 @@ import (
 	"podmanview/internal/storage"
 )
 
+// htmlFS holds the plugin page, embedded so it works from any working directory
+//
+//go:embed index.html
+var htmlFS embed.FS
+
 // TemperaturePlugin monitors system temperatures
 type TemperaturePlugin struct {
 	*plugins.BasePlugin
-	mu                sync.RWMutex
-	cachedData        *TemperatureData
-	lastUpdate        time.Time
-	updatePeriod      time.Duration
-	backgroundCtx     context.Context
-	backgroundCancel  context.CancelFunc
-	bgMutex           sync.Mutex
-	mqttEnabled       bool // MQTT publishing enabled flag
+	mu               sync.RWMutex
+	cachedData       *TemperatureData
+	lastUpdate       time.Time
+	updatePeriod     time.Duration
+	backgroundCtx    context.Context
+	backgroundCancel context.CancelFunc
+	bgMutex          sync.Mutex
+	mqttEnabled      bool   // MQTT publishing enabled flag
+	publishMode      string // which MQTT messages are published, see PublishBoth
+	unit             string // unit of MQTT values and the UI, UnitCelsius or UnitFahrenheit
+	precision        int    // decimals MQTT values are rounded to
+
+	// NVMe temperatures come from `nvme smart-log`, a process per device that
+	// can wake idle drives, so they are read less often than CPU temperatures
+	storageInterval   time.Duration
+	lastStorageUpdate time.Time
+	nvmeBackoff       map[string]*deviceBackoff // devices whose smart-log failed recently
+}
+
+// deviceBackoff delays probing a device after smart-log failures
+type deviceBackoff struct {
+	failures int
+	until    time.Time
+}
+
+// Storage temperature interval bounds and default
+const (
+	DefaultStorageInterval = 60 * time.Second
+	MinStorageInterval     = 15 * time.Second
+	MaxStorageInterval     = time.Hour
+
+	// maxNVMeBackoff caps the wait before retrying a failing device
+	maxNVMeBackoff = time.Hour
+)
+
+// MQTT publish modes: one JSON message with all readings, a state topic per
+// sensor, or both. Home Assistant discovery uses the per-sensor topics, so
+// it is withdrawn in aggregated mode.
+const (
+	PublishBoth        = "both"
+	PublishAggregated  = "aggregated"
+	PublishIndividual  = "individual"
+	DefaultPublishMode = PublishBoth
+
+	// aggregatedTopic receives the aggregated JSON (below the MQTT prefix)
+	aggregatedTopic = "sensor/temperature/state"
+)
+
+// validPublishMode reports whether mode is one of the publish modes
+func validPublishMode(mode string) bool {
+	return mode == PublishBoth || mode == PublishAggregated || mode == PublishIndividual
+}
+
+// Temperature units and rounding of published values. Sensors are read in
+// Celsius; raw readings like 53.0000001 clutter Home Assistant graphs.
+const (
+	UnitCelsius      = "C"
+	UnitFahrenheit   = "F"
+	DefaultUnit      = UnitCelsius
+	DefaultPrecision = 1
+	MaxPrecision     = 3
+)
+
+// validUnit reports whether unit is one of the temperature units
+func validUnit(unit string) bool {
+	return unit == UnitCelsius || unit == UnitFahrenheit
+}
+
+// unitSymbol returns the unit of measurement shown in Home Assistant
+func unitSymbol(unit string) string {
+	if unit == UnitFahrenheit {
+		return "°F"
+	}
+	return "°C"
+}
+
+// ConvertTemp converts a Celsius reading to unit, rounded to precision decimals
+func ConvertTemp(celsius float64, unit string, precision int) float64 {
+	value := celsius
+	if unit == UnitFahrenheit {
+		value = celsius*9/5 + 32
+	}
+	scale := math.Pow(10, float64(precision))
+	return math.Round(value*scale) / scale
+}
+
+// convertData returns a copy of data with every reading converted for publishing
+func convertData(data *TemperatureData, unit string, precision int) *TemperatureData {
+	convert := func(temps []Temperature) []Temperature {
+		result := make([]Temperature, len(temps))
+		for i, t := range temps {
+			result[i] = Temperature{Label: t.Label, Temp: ConvertTemp(t.Temp, unit, precision)}
+		}
+		return result
+	}
+	converted := &TemperatureData{
+		Temperatures: convert(data.Temperatures),
+		StorageTemps: make([]StorageTemp, len(data.StorageTemps)),
+		Unit:         unitSymbol(unit),
+	}
+	for i, st := range data.StorageTemps {
+		converted.StorageTemps[i] = StorageTemp{Device: st.Device, Sensors: convert(st.Sensors)}
+	}
+	return converted
 }
 
 // Temperature represents a temperature sensor reading
@@ -46,21 +150,25 @@ type StorageTemp struct {
 type TemperatureData struct {
 	Temperatures []Temperature `json:"temperatures"`           // CPU/SoC temperatures
 	StorageTemps []StorageTemp `json:"storageTemps,omitempty"` // NVMe/Storage temperatures grouped by device
+	Unit         string        `json:"unit,omitempty"`         // unit of published values (readings are °C otherwise)
 }
 
 // New creates a new TemperaturePlugin instance
 func New() *TemperaturePlugin {
-	// Get the path to the HTML file relative to this plugin's directory
-	htmlPath := filepath.Join("internal", "plugins", "temperature", "index.html")
-
 	return &TemperaturePlugin{
-		BasePlugin: plugins.NewBasePlugin(
+		BasePlugin: plugins.NewBasePluginFS(
 			"temperature",
 			"System temperature monitoring",
 			"1.0.0",
-			htmlPath,
+			htmlFS,
+			"index.html",
 		),
-		updatePeriod: 15 * time.Second, // Update every 15 seconds
+		updatePeriod:    15 * time.Second, // Update every 15 seconds
+		storageInterval: DefaultStorageInterval,
+		publishMode:     DefaultPublishMode,
+		unit:            DefaultUnit,
+		precision:       DefaultPrecision,
+		nvmeBackoff:     make(map[string]*deviceBackoff),
 		cachedData: &TemperatureData{
 			Temperatures: []Temperature{},
 			StorageTemps: []StorageTemp{},
@@ -68,6 +176,15 @@ func New() *TemperaturePlugin {
 	}
 }
 
+// Manifest describes the plugin for the plugin catalog
+func (p *TemperaturePlugin) Manifest() plugins.Manifest {
+	m := p.BasePlugin.Manifest()
+	m.Icon = "thermometer"
+	m.Category = plugins.CategoryMonitoring
+	m.Capabilities = []string{plugins.CapabilityHost, plugins.CapabilityStorage, plugins.CapabilityMQTT}
+	return m
+}
+
 // Init initializes the plugin
 func (p *TemperaturePlugin) Init(ctx context.Context, deps *plugins.PluginDependencies) error {
 	p.SetDependencies(deps)
@@ -75,14 +192,14 @@ func (p *TemperaturePlugin) Init(ctx context.Context, deps *plugins.PluginDepend
 	// Load settings from storage (update interval, MQTT enabled state)
 	p.loadSettings(deps.Storage)
 
-	// MQTT инициализация НЕ нужна - используем deps.MQTTClient
-	if deps.MQTTClient != nil && p.mqttEnabled {
-		if err := deps.MQTTClient.Connect(); err != nil {
+	// MQTT инициализация НЕ нужна - используем deps.MQTTClient()
+	if deps.MQTTClient() != nil && p.mqttEnabled {
+		if err := deps.MQTTClient().Connect(); err != nil {
 			if p.Logger() != nil {
 				p.Logger().Printf("[%s] Failed to connect to MQTT: %v", p.Name(), err)
 			}
 		} else {
-			deps.MQTTClient.Publish("sensor/temperature/availability", []byte("online"))
+			deps.MQTTClient().Publish("sensor/temperature/availability", []byte("online"))
 		}
 	}
 
@@ -116,9 +233,20 @@ func (p *TemperaturePlugin) Stop(ctx context.Context) error {
 
 	// Graceful MQTT shutdown
 	deps := p.Deps()
-	if p.mqttEnabled && deps != nil && deps.MQTTClient != nil && deps.MQTTClient.IsConnected() {
-		deps.MQTTClient.Publish("sensor/temperature/availability", []byte("offline"))
-		time.Sleep(100 * time.Millisecond)
+	if p.mqttEnabled && deps != nil && deps.MQTTClient() != nil && deps.MQTTClient().IsConnected() {
+		// Disabled by an admin: remove the sensors from Home Assistant,
+		// on shutdown they only become unavailable
+		if deps.MQTTDiscovery() != nil && p.Disabling() {
+			if err := deps.MQTTDiscovery().RemoveDiscoveryConfigs(); err != nil {
+				p.LogError("Failed to remove MQTT discovery: %v", err)
+			}
+		}
+		deps.MQTTClient().Publish("sensor/temperature/availability", []byte("offline"))
+		// Give the message time to go out, within the stop deadline
+		select {
+		case <-time.After(100 * time.Millisecond):
+		case <-ctx.Done():
+		}
 	}
 
 	if p.Logger() != nil {
@@ -163,6 +291,11 @@ func (p *TemperaturePlugin) Routes() []plugins.Route {
 	}
 }
 
+// SettingsKeys returns the storage keys exported with the settings bundle
+func (p *TemperaturePlugin) SettingsKeys() []string {
+	return []string{"updateInterval", "storageInterval", "publishMode", "unit", "precision", "mqttEnabled"}
+}
+
 // IsEnabled checks if the plugin is enabled
 func (p *TemperaturePlugin) IsEnabled() bool {
 	if p.Deps() == nil || p.Deps().Storage == nil {
@@ -189,7 +322,7 @@ func (p *TemperaturePlugin) StartBackgroundTasks(ctx context.Context) error {
 	}
 
 	// Run periodic temperature updates
-	go plugins.RunPeriodic(p.backgroundCtx, p.updatePeriod, p.Logger(), p.Name(), func(ctx context.Context) error {
+	go plugins.RunPeriodic(p.backgroundCtx, p.updatePeriod, p.Logger(), p.EventStore(), p.Name(), func(ctx context.Context) error {
 		p.updateTemperatureData()
 		return nil
 	})
@@ -221,7 +354,7 @@ func (p *TemperaturePlugin) RestartBackgroundTasks() error {
 	}
 
 	// Run periodic temperature updates with new interval
-	go plugins.RunPeriodic(p.backgroundCtx, p.updatePeriod, p.Logger(), p.Name(), func(ctx context.Context) error {
+	go plugins.RunPeriodic(p.backgroundCtx, p.updatePeriod, p.Logger(), p.EventStore(), p.Name(), func(ctx context.Context) error {
 		p.updateTemperatureData()
 		return nil
 	})
@@ -231,17 +364,33 @@ func (p *TemperaturePlugin) RestartBackgroundTasks() error {
 
 // updateTemperatureData updates the cached temperature data
 func (p *TemperaturePlugin) updateTemperatureData() {
+	now := time.Now()
+
+	// Storage temperatures are refreshed on their own, longer interval
+	p.mu.RLock()
+	storageTemps := p.cachedData.StorageTemps
+	storageDue := now.Sub(p.lastStorageUpdate) >= p.storageInterval
+	p.mu.RUnlock()
+	if storageDue {
+		storageTemps = p.getNVMeTemperaturesGrouped(now)
+	}
+
 	// Collect fresh temperature data
 	newData := &TemperatureData{
 		Temperatures: getCPUTemperatures(),
-		StorageTemps: getNVMeTemperaturesGrouped(),
+		StorageTemps: storageTemps,
 	}
 
 	// Update cache with lock
 	p.mu.Lock()
 	p.cachedData = newData
-	p.lastUpdate = time.Now()
+	p.lastUpdate = now
+	if storageDue {
+		p.lastStorageUpdate = now
+	}
 	mqttEnabled := p.mqttEnabled
+	publishMode := p.publishMode
+	unit, precision := p.unit, p.precision
 	p.mu.Unlock()
 
 	// Log update
@@ -252,27 +401,54 @@ func (p *TemperaturePlugin) updateTemperatureData() {
 
 	// НОВОЕ: Публикация через общий Publisher
 	deps := p.Deps()
-	if mqttEnabled && deps != nil && deps.MQTTPublisher != nil && deps.MQTTClient != nil && deps.MQTTClient.IsConnected() {
+	if mqttEnabled && deps != nil && deps.MQTTPublisher() != nil && deps.MQTTClient() != nil && deps.MQTTClient().IsConnected() {
+		mqttData := convertData(newData, unit, precision)
+
 		// 1. Агрегированный JSON (1 сообщение вместо 21)
-		deps.MQTTPublisher.PublishAggregated("sensor/temperature/state", newData)
+		if publishMode != PublishIndividual {
+			deps.MQTTPublisher().PublishAggregated(aggregatedTopic, mqttData)
+		}
+
+		// Home Assistant reads the per-sensor topics, which aggregated mode skips
+		if publishMode == PublishAggregated {
+			return
+		}
 
 		// 2. Discovery если нужно
-		if deps.MQTTDiscovery != nil {
+		if deps.MQTTDiscovery() != nil {
 			currentCount := len(newData.Temperatures)
 			for _, storage := range newData.StorageTemps {
 				currentCount += len(storage.Sensors)
 			}
 
-			if deps.MQTTDiscovery.ShouldRepublishDiscovery(currentCount) {
-				p.publishDiscoveryConfigs(newData, deps)
+			if deps.MQTTDiscovery().ShouldRepublishDiscovery(currentCount) {
+				p.publishDiscoveryConfigs(mqttData, deps)
 			}
 		}
 
 		// 3. Индивидуальные сенсоры
-		p.publishIndividualSensors(newData, deps)
+		p.publishIndividualSensors(mqttData, deps)
 	}
 }
 
+// RediscoverMQTT makes the next update republish the Home Assistant
+// discovery configs
+func (p *TemperaturePlugin) RediscoverMQTT() error {
+	p.mu.RLock()
+	enabled, publishMode := p.mqttEnabled, p.publishMode
+	p.mu.RUnlock()
+
+	deps := p.Deps()
+	switch {
+	case !enabled || deps == nil || deps.MQTTDiscovery() == nil:
+		return fmt.Errorf("%w: MQTT publishing is disabled", plugins.ErrNoDiscovery)
+	case publishMode == PublishAggregated:
+		return fmt.Errorf("%w: publish mode is aggregated", plugins.ErrNoDiscovery)
+	}
+	deps.MQTTDiscovery().Reset()
+	return nil
+}
+
 // GetTemperatureData returns cached temperature data
 func (p *TemperaturePlugin) GetTemperatureData() *TemperatureData {
 	p.mu.RLock()
@@ -285,6 +461,14 @@ func (p *TemperaturePlugin) GetTemperatureData() *TemperatureData {
 	}
 }
 
+// Unit returns the temperature unit set for MQTT and the UI (UnitCelsius
+// or UnitFahrenheit); GetTemperatureData is always in Celsius
+func (p *TemperaturePlugin) Unit() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.unit
+}
+
 // GetLastUpdateTime returns the time of the last temperature data update
 func (p *TemperaturePlugin) GetLastUpdateTime() time.Time {
 	p.mu.RLock()
@@ -312,6 +496,39 @@ func (p *TemperaturePlugin) loadSettings(storage storage.Storage) {
 		storage.SetInt(p.Name(), "updateInterval", 15)
 	}
 
+	// Load storage temperature interval
+	storageInterval, err := storage.GetInt(p.Name(), "storageInterval")
+	if err == nil && validStorageInterval(storageInterval) {
+		p.mu.Lock()
+		p.storageInterval = time.Duration(storageInterval) * time.Second
+		p.mu.Unlock()
+	} else if err != nil {
+		// Save default interval if not set
+		storage.SetInt(p.Name(), "storageInterval", int(DefaultStorageInterval.Seconds()))
+	}
+
+	// Load MQTT publish mode
+	publishMode, err := storage.GetString(p.Name(), "publishMode")
+	if err == nil && validPublishMode(publishMode) {
+		p.mu.Lock()
+		p.publishMode = publishMode
+		p.mu.Unlock()
+	}
+
+	// Load unit and rounding of published values
+	unit, err := storage.GetString(p.Name(), "unit")
+	if err == nil && validUnit(unit) {
+		p.mu.Lock()
+		p.unit = unit
+		p.mu.Unlock()
+	}
+	precision, err := storage.GetInt(p.Name(), "precision")
+	if err == nil && precision >= 0 && precision <= MaxPrecision {
+		p.mu.Lock()
+		p.precision = precision
+		p.mu.Unlock()
+	}
+
 	// Load MQTT enabled state
 	mqttEnabled, err := storage.GetBool(p.Name(), "mqttEnabled")
 	if err == nil {
@@ -476,8 +693,49 @@ func getCPUTemperatures() []Temperature {
 	return temps
 }
 
-// getNVMeTemperaturesGrouped reads temperatures from NVMe devices and groups by device
-func getNVMeTemperaturesGrouped() []StorageTemp {
+// validStorageInterval reports whether seconds is an allowed storage temperature interval
+func validStorageInterval(seconds int) bool {
+	interval := time.Duration(seconds) * time.Second
+	return interval >= MinStorageInterval && interval <= MaxStorageInterval
+}
+
+// nvmeReady reports whether device may be probed at now
+func (p *TemperaturePlugin) nvmeReady(device string, now time.Time) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	b, ok := p.nvmeBackoff[device]
+	return !ok || !now.Before(b.until)
+}
+
+// nvmeResult records the outcome of probing device. After a failure the
+// device is skipped for the storage interval, doubling on each further
+// failure up to maxNVMeBackoff.
+func (p *TemperaturePlugin) nvmeResult(device string, now time.Time, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err == nil {
+		delete(p.nvmeBackoff, device)
+		return
+	}
+
+	b, ok := p.nvmeBackoff[device]
+	if !ok {
+		b = &deviceBackoff{}
+		p.nvmeBackoff[device] = b
+	}
+	b.failures++
+	wait := min(p.storageInterval<<min(b.failures-1, 10), maxNVMeBackoff)
+	b.until = now.Add(wait)
+
+	if p.Logger() != nil && b.failures == 1 {
+		p.Logger().Printf("[%s] nvme smart-log failed for %s, retrying after %v: %v", p.Name(), device, wait, err)
+	}
+}
+
+// getNVMeTemperaturesGrouped reads temperatures from NVMe devices and groups by device.
+// Devices that failed recently are skipped until their backoff expires.
+func (p *TemperaturePlugin) getNVMeTemperaturesGrouped(now time.Time) []StorageTemp {
 	result := []StorageTemp{}
 
 	// Scan /sys/block for nvme devices
@@ -502,8 +760,13 @@ func getNVMeTemperaturesGrouped() []StorageTemp {
 			continue
 		}
 
+		if !p.nvmeReady(deviceName, now) {
+			continue
+		}
+
 		cmd := exec.Command("nvme", "smart-log", devicePath)
 		output, err := cmd.Output()
+		p.nvmeResult(deviceName, now, err)
 		if err != nil {
 			continue
 		}
@@ -550,7 +813,7 @@ func getNVMeTemperaturesGrouped() []StorageTemp {
 
 // publishIndividualSensors публикует отдельные сенсоры через общий Publisher
 func (p *TemperaturePlugin) publishIndividualSensors(data *TemperatureData, deps *plugins.PluginDependencies) {
-	if data == nil || deps.MQTTPublisher == nil {
+	if data == nil || deps.MQTTPublisher() == nil {
 		return
 	}
 
@@ -563,10 +826,10 @@ func (p *TemperaturePlugin) publishIndividualSensors(data *TemperatureData, deps
 			Attributes: map[string]interface{}{
 				"temperature": temp.Temp,
 				"label":       temp.Label,
-				"unit":        "°C",
+				"unit":        data.Unit,
 			},
 		}
-		deps.MQTTPublisher.PublishSensorState(sensorData)
+		deps.MQTTPublisher().PublishSensorState(sensorData)
 	}
 
 	// Storage температуры
@@ -581,29 +844,24 @@ func (p *TemperaturePlugin) publishIndividualSensors(data *TemperatureData, deps
 					"temperature": temp.Temp,
 					"device":      storage.Device,
 					"sensor":      temp.Label,
-					"unit":        "°C",
+					"unit":        data.Unit,
 				},
 			}
-			deps.MQTTPublisher.PublishSensorState(sensorData)
+			deps.MQTTPublisher().PublishSensorState(sensorData)
 		}
 	}
 }
 
 // publishDiscoveryConfigs публикует discovery конфигурации через общий DiscoveryManager
 func (p *TemperaturePlugin) publishDiscoveryConfigs(data *TemperatureData, deps *plugins.PluginDependencies) {
-	if data == nil || deps.MQTTDiscovery == nil {
+	if data == nil || deps.MQTTDiscovery() == nil {
 		return
 	}
 
 	configs := make([]*mqtt.SensorConfig, 0)
 
-	// Device info для группировки
-	deviceInfo := &mqtt.DeviceInfo{
-		Identifiers:  []string{"podmanview"},
-		Name:         "PodmanView",
-		Model:        "Temperature Monitor",
-		Manufacturer: "PodmanView",
-	}
+	// Device info для группировки: отдельное устройство на каждый хост
+	deviceInfo := deps.MQTTDiscovery().Device("Temperature Monitor")
 
 	// CPU/SoC сенсоры
 	for _, temp := range data.Temperatures {
@@ -612,7 +870,7 @@ func (p *TemperaturePlugin) publishDiscoveryConfigs(data *TemperatureData, deps
 			SensorID:          sensorID,
 			Name:              temp.Label + " Temperature",
 			SensorType:        mqtt.SensorTypeTemperature,
-			Unit:              "°C",
+			Unit:              data.Unit,
 			StateTopic:        "sensor/" + sensorID + "/state",
 			AttributesTopic:   "sensor/" + sensorID + "/attributes",
 			DeviceClass:       "temperature",
@@ -631,7 +889,7 @@ func (p *TemperaturePlugin) publishDiscoveryConfigs(data *TemperatureData, deps
 				SensorID:          sensorID,
 				Name:              storage.Device + " " + temp.Label + " Temperature",
 				SensorType:        mqtt.SensorTypeTemperature,
-				Unit:              "°C",
+				Unit:              data.Unit,
 				StateTopic:        "sensor/" + sensorID + "/state",
 				AttributesTopic:   "sensor/" + sensorID + "/attributes",
 				DeviceClass:       "temperature",
@@ -643,7 +901,7 @@ func (p *TemperaturePlugin) publishDiscoveryConfigs(data *TemperatureData, deps
 		}
 	}
 
-	deps.MQTTDiscovery.PublishMultipleDiscoveryConfigs(configs)
+	deps.MQTTDiscovery().PublishMultipleDiscoveryConfigs(configs)
 }
 
 // sanitizeSensorID создает безопасный ID для MQTT топиков
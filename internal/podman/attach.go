@@ -0,0 +1,46 @@
+package podman
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+)
+
+// AttachContainer attaches to the main process of a running container.
+// It returns the hijacked connection, which takes the process's stdin when
+// stdin is true, and a reader of its output: raw when the container has a
+// TTY, multiplexed (see DemuxStream) when it has none. Closing the
+// connection detaches without stopping the container.
+func (c *Client) AttachContainer(ctx context.Context, id string, stdin bool) (net.Conn, io.Reader, error) {
+	conn, err := c.Dial(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	httpReq := fmt.Sprintf("POST /v4.0.0/libpod/containers/%s/attach?stream=true&stdout=true&stderr=true&stdin=%t HTTP/1.1\r\n"+
+		"Host: localhost\r\n"+
+		"Content-Length: 0\r\n"+
+		"Connection: Upgrade\r\n"+
+		"Upgrade: tcp\r\n"+
+		"\r\n", id, stdin)
+	if _, err := io.WriteString(conn, httpReq); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols && resp.StatusCode != http.StatusOK {
+		apiErr := newAPIError(resp)
+		conn.Close()
+		return nil, nil, apiErr
+	}
+	return conn, reader, nil
+}
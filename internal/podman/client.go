@@ -3,6 +3,7 @@ package podman
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -10,6 +11,7 @@ import (
 	"net/url"
 	"os"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -17,11 +19,39 @@ import (
 type Client struct {
 	httpClient *http.Client
 	socketPath string
+	autoDetect bool // re-run socket detection on reconnect
+
+	// Default deadline for regular API calls (see withTimeout)
+	timeout time.Duration
+	// Retry policy for idempotent GETs (see withRetry)
+	retry RetryPolicy
+
+	mu     sync.RWMutex
+	dialer dialer
+
+	stateMu  sync.RWMutex
+	state    ConnectionState
+	redialed bool // reconnect ran since the last successful ping
 }
 
 // NewClient creates a new Podman client
 // It tries rootless socket first, then falls back to rootful
 func NewClient() (*Client, error) {
+	path, err := detectSocket()
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := NewClientWithSocket(path)
+	if err != nil {
+		return nil, err
+	}
+	client.autoDetect = true
+	return client, nil
+}
+
+// detectSocket returns the first existing local Podman socket
+func detectSocket() (string, error) {
 	socketPaths := []string{
 		fmt.Sprintf("/run/user/%d/podman/podman.sock", os.Getuid()),
 		"/run/podman/podman.sock",
@@ -29,45 +59,116 @@ func NewClient() (*Client, error) {
 
 	for _, path := range socketPaths {
 		if _, err := os.Stat(path); err == nil {
-			client := &Client{
-				socketPath: path,
-				httpClient: &http.Client{
-					Transport: &http.Transport{
-						DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
-							return net.Dial("unix", path)
-						},
-					},
-					Timeout: 30 * time.Second,
-				},
-			}
-			return client, nil
+			return path, nil
 		}
 	}
 
-	return nil, fmt.Errorf("podman socket not found")
+	return "", fmt.Errorf("podman socket not found")
 }
 
-// NewClientWithSocket creates a client with specific socket path
+// NewClientWithSocket creates a client with specific socket path or URI
+// (unix:///path, tcp://host:port, ssh://user@host/path)
 func NewClientWithSocket(socketPath string) (*Client, error) {
-	if _, err := os.Stat(socketPath); err != nil {
-		return nil, fmt.Errorf("socket not found: %s", socketPath)
+	d, err := newDialer(socketPath)
+	if err != nil {
+		return nil, err
 	}
 
-	return &Client{
+	c := &Client{
 		socketPath: socketPath,
-		httpClient: &http.Client{
-			Transport: &http.Transport{
-				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
-					return net.Dial("unix", socketPath)
-				},
+		timeout:    DefaultTimeout,
+		retry:      DefaultRetryPolicy,
+		dialer:     d,
+		state: ConnectionState{
+			Connected: true,
+			Socket:    socketPath,
+		},
+	}
+	// No client-level timeout: deadlines are set per operation via context
+	// so long pulls and streams are not cut off
+	c.httpClient = &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return c.Dial(ctx)
 			},
-			Timeout: 30 * time.Second,
 		},
-	}, nil
+	}
+	return c, nil
+}
+
+// Operation timeouts
+const (
+	DefaultTimeout = 30 * time.Second // list/inspect/start/stop/etc.
+	PullTimeout    = 30 * time.Minute // image pulls and prunes
+)
+
+// SetTimeout sets the default deadline for regular API calls
+func (c *Client) SetTimeout(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.timeout = d
+}
+
+// Timeout returns the default deadline for regular API calls
+func (c *Client) Timeout() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.timeout
+}
+
+// withTimeout applies d to ctx unless d is zero (no timeout).
+// An earlier deadline already set on ctx is kept.
+func withTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+// Dial opens a raw connection to the Podman API (for hijacked streams)
+func (c *Client) Dial(ctx context.Context) (net.Conn, error) {
+	c.mu.RLock()
+	d := c.dialer
+	c.mu.RUnlock()
+	return d.dial(ctx)
 }
 
 // request makes HTTP request to Podman API
+// On a dead connection it re-dials the socket and retries once. A reset
+// connection may have delivered the request already, so only GET and HEAD
+// are replayed then; other methods only when the dial itself failed.
 func (c *Client) request(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
+	resp, err := c.doRequest(ctx, method, path, body)
+	if err == nil || !isConnectionError(err) || ctx.Err() != nil {
+		return resp, err
+	}
+
+	c.markDisconnected(err)
+
+	if method != http.MethodGet && method != http.MethodHead && !isDialError(err) {
+		// Reconnect for the next request, but don't run a mutation twice
+		c.reconnect()
+		return nil, err
+	}
+
+	// Body can only be replayed if it is seekable
+	if seeker, ok := body.(io.Seeker); ok {
+		if _, serr := seeker.Seek(0, io.SeekStart); serr != nil {
+			return nil, err
+		}
+	} else if body != nil {
+		return nil, err
+	}
+
+	if rerr := c.reconnect(); rerr != nil {
+		return nil, err
+	}
+
+	return c.doRequest(ctx, method, path, body)
+}
+
+// doRequest performs a single HTTP request to Podman API
+func (c *Client) doRequest(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
 	url := "http://localhost" + path
 	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
@@ -80,7 +181,18 @@ func (c *Client) request(ctx context.Context, method, path string, body io.Reade
 }
 
 // get performs GET request and decodes JSON response
+// GETs are idempotent, so transient failures are retried (see withRetry)
 func (c *Client) get(ctx context.Context, path string, result interface{}) error {
+	ctx, cancel := withTimeout(ctx, c.Timeout())
+	defer cancel()
+
+	return c.withRetry(ctx, func() error {
+		return c.getOnce(ctx, path, result)
+	})
+}
+
+// getOnce performs a single GET request and decodes JSON response
+func (c *Client) getOnce(ctx context.Context, path string, result interface{}) error {
 	resp, err := c.request(ctx, http.MethodGet, path, nil)
 	if err != nil {
 		return err
@@ -88,8 +200,7 @@ func (c *Client) get(ctx context.Context, path string, result interface{}) error
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error %d: %s", resp.StatusCode, string(body))
+		return newAPIError(resp)
 	}
 
 	return json.NewDecoder(resp.Body).Decode(result)
@@ -97,6 +208,9 @@ func (c *Client) get(ctx context.Context, path string, result interface{}) error
 
 // post performs POST request
 func (c *Client) post(ctx context.Context, path string, body interface{}) error {
+	ctx, cancel := withTimeout(ctx, c.Timeout())
+	defer cancel()
+
 	var reader io.Reader
 	if body != nil {
 		data, err := json.Marshal(body)
@@ -113,8 +227,7 @@ func (c *Client) post(ctx context.Context, path string, body interface{}) error
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
-		respBody, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error %d: %s", resp.StatusCode, string(respBody))
+		return newAPIError(resp)
 	}
 
 	return nil
@@ -122,6 +235,9 @@ func (c *Client) post(ctx context.Context, path string, body interface{}) error
 
 // delete performs DELETE request
 func (c *Client) delete(ctx context.Context, path string) error {
+	ctx, cancel := withTimeout(ctx, c.Timeout())
+	defer cancel()
+
 	resp, err := c.request(ctx, http.MethodDelete, path, nil)
 	if err != nil {
 		return err
@@ -129,13 +245,35 @@ func (c *Client) delete(ctx context.Context, path string) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error %d: %s", resp.StatusCode, string(body))
+		return newAPIError(resp)
 	}
 
 	return nil
 }
 
+// APIError represents an error response from the Podman API
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API error %d: %s", e.StatusCode, e.Message)
+}
+
+// newAPIError builds an APIError from a failed response
+func newAPIError(resp *http.Response) *APIError {
+	body, _ := io.ReadAll(resp.Body)
+	return &APIError{StatusCode: resp.StatusCode, Message: string(body)}
+}
+
+// IsNotFound reports whether err is a Podman 404 response
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
 // Container types
 type Container struct {
 	ID      string   `json:"Id"`
@@ -146,38 +284,101 @@ type Container struct {
 	State   string   `json:"State"`
 	Status  string   `json:"Status"`
 	Ports   []Port   `json:"Ports"`
+
+	Restarts  int   `json:"Restarts"`  // restarts by restart policy
+	StartedAt int64 `json:"StartedAt"` // unix seconds, 0 if never started
+
+	Labels map[string]string `json:"Labels"`
 }
 
+// LabelSystemdUnit is set by Podman on containers started from a
+// unit generated by `podman generate systemd` (or Quadlet)
+const LabelSystemdUnit = "PODMAN_SYSTEMD_UNIT"
+
+// Port is a port mapping in libpod list format
 type Port struct {
-	IP          string `json:"IP"`
-	PrivatePort int    `json:"PrivatePort"`
-	PublicPort  int    `json:"PublicPort"`
-	Type        string `json:"Type"`
+	HostIP        string `json:"host_ip"`
+	ContainerPort int    `json:"container_port"`
+	HostPort      int    `json:"host_port"`
+	Range         int    `json:"range"` // consecutive ports covered (0 or 1 = single port)
+	Protocol      string `json:"protocol"`
 }
 
 type ContainerInspect struct {
-	ID      string `json:"Id"`
-	Name    string `json:"Name"`
-	Created string `json:"Created"`
-	State   struct {
+	ID           string `json:"Id"`
+	Name         string `json:"Name"`
+	Created      string `json:"Created"`
+	RestartCount int    `json:"RestartCount"`
+	State        struct {
 		Status     string `json:"Status"`
 		Running    bool   `json:"Running"`
 		Paused     bool   `json:"Paused"`
 		StartedAt  string `json:"StartedAt"`
 		FinishedAt string `json:"FinishedAt"`
 	} `json:"State"`
-	Image  string `json:"Image"`
-	Config struct {
-		Hostname string            `json:"Hostname"`
-		Env      []string          `json:"Env"`
-		Cmd      []string          `json:"Cmd"`
-		Labels   map[string]string `json:"Labels"`
+	Image     string `json:"Image"`     // image ID
+	ImageName string `json:"ImageName"` // reference the container was created from
+	Pod       string `json:"Pod"`       // pod ID, empty when not in a pod
+	Config    struct {
+		Hostname    string            `json:"Hostname"`
+		Env         []string          `json:"Env"`
+		Cmd         []string          `json:"Cmd"`
+		Entrypoint  StringList        `json:"Entrypoint"`
+		User        string            `json:"User"`
+		WorkingDir  string            `json:"WorkingDir"`
+		Healthcheck *HealthConfig     `json:"Healthcheck"`
+		Labels      map[string]string `json:"Labels"`
+		Tty         bool              `json:"Tty"`       // main process has a TTY (-t)
+		OpenStdin   bool              `json:"OpenStdin"` // main process stdin is kept open (-i)
 	} `json:"Config"`
 	Mounts []struct {
 		Type        string `json:"Type"`
+		Name        string `json:"Name"` // volume name for "volume" mounts
 		Source      string `json:"Source"`
 		Destination string `json:"Destination"`
+		RW          bool   `json:"RW"`
 	} `json:"Mounts"`
+	NetworkSettings struct {
+		Ports    map[string][]InspectHostPort `json:"Ports"` // "80/tcp" -> bindings (nil = exposed only)
+		Networks map[string]struct {
+			Aliases           []string `json:"Aliases"`
+			IPAddress         string   `json:"IPAddress"`
+			GlobalIPv6Address string   `json:"GlobalIPv6Address"`
+			MacAddress        string   `json:"MacAddress"`
+		} `json:"Networks"` // network name -> settings, for bridge networking
+	} `json:"NetworkSettings"`
+	HostConfig struct {
+		LogConfig struct {
+			Type string `json:"Type"` // k8s-file, journald, json-file, none, passthrough
+		} `json:"LogConfig"`
+		RestartPolicy struct {
+			Name              string `json:"Name"` // empty or "no", always, on-failure, unless-stopped
+			MaximumRetryCount uint   `json:"MaximumRetryCount"`
+		} `json:"RestartPolicy"`
+		AutoRemove  bool              `json:"AutoRemove"`  // removed when it exits (--rm)
+		NetworkMode string            `json:"NetworkMode"` // bridge, host, none, slirp4netns, pasta, container:<id>, ns:<path>
+		Privileged  bool              `json:"Privileged"`
+		SecurityOpt []string          `json:"SecurityOpt"` // label=disable, seccomp=unconfined, no-new-privileges, ...
+		Tmpfs       map[string]string `json:"Tmpfs"`       // destination -> options ("rw,size=64m")
+		Dns         []string          `json:"Dns"`
+		DnsOptions  []string          `json:"DnsOptions"`
+		DnsSearch   []string          `json:"DnsSearch"`
+		ExtraHosts  []string          `json:"ExtraHosts"` // "name:ip"
+		CapAdd      []string          `json:"CapAdd"`
+		CapDrop     []string          `json:"CapDrop"`
+		Devices     []struct {
+			PathOnHost        string `json:"PathOnHost"`
+			PathInContainer   string `json:"PathInContainer"`
+			CgroupPermissions string `json:"CgroupPermissions"`
+		} `json:"Devices"`
+		Memory     int64  `json:"Memory"`     // bytes, 0 = unlimited
+		MemorySwap int64  `json:"MemorySwap"` // bytes, -1 = unlimited
+		CpuShares  uint64 `json:"CpuShares"`
+		CpuQuota   int64  `json:"CpuQuota"`  // microseconds per period
+		CpuPeriod  uint64 `json:"CpuPeriod"` // microseconds
+		CpusetCpus string `json:"CpusetCpus"`
+		PidsLimit  int64  `json:"PidsLimit"`
+	} `json:"HostConfig"`
 }
 
 // ListContainers returns list of all containers (running and stopped)
@@ -206,6 +407,9 @@ type ContainerStats struct {
 
 // GetContainersStats returns stats for all running containers
 func (c *Client) GetContainersStats(ctx context.Context) ([]ContainerStats, error) {
+	ctx, cancel := withTimeout(ctx, c.Timeout())
+	defer cancel()
+
 	resp, err := c.request(ctx, http.MethodGet, "/v4.0.0/libpod/containers/stats?stream=false", nil)
 	if err != nil {
 		return nil, err
@@ -226,11 +430,61 @@ func (c *Client) GetContainersStats(ctx context.Context) ([]ContainerStats, erro
 	return result.Stats, nil
 }
 
+// StreamContainersStats calls onStats with the stats of all running
+// containers every interval until ctx ends or Podman closes the stream
+func (c *Client) StreamContainersStats(ctx context.Context, interval time.Duration, onStats func([]ContainerStats)) error {
+	// No timeout: the stream stays open for as long as the caller needs it
+	path := fmt.Sprintf("/v4.0.0/libpod/containers/stats?stream=true&interval=%d", max(int(interval.Seconds()), 1))
+	resp, err := c.request(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return newAPIError(resp)
+	}
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var report struct {
+			Stats []ContainerStats `json:"Stats"`
+		}
+		if err := decoder.Decode(&report); err != nil {
+			if err == io.EOF || ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		onStats(report.Stats)
+	}
+}
+
 // StartContainer starts a container
 func (c *Client) StartContainer(ctx context.Context, id string) error {
 	return c.post(ctx, fmt.Sprintf("/v4.0.0/libpod/containers/%s/start", id), nil)
 }
 
+// WaitContainer blocks until a container exits and returns its exit code.
+// It has no timeout of its own; ctx bounds the wait.
+func (c *Client) WaitContainer(ctx context.Context, id string) (int, error) {
+	resp, err := c.request(ctx, http.MethodPost, fmt.Sprintf("/v4.0.0/libpod/containers/%s/wait?condition=exited", id), nil)
+	if err != nil {
+		return -1, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return -1, newAPIError(resp)
+	}
+
+	var exitCode int
+	if err := json.NewDecoder(resp.Body).Decode(&exitCode); err != nil {
+		return -1, err
+	}
+	return exitCode, nil
+}
+
 // StopContainer stops a container
 func (c *Client) StopContainer(ctx context.Context, id string) error {
 	return c.post(ctx, fmt.Sprintf("/v4.0.0/libpod/containers/%s/stop", id), nil)
@@ -252,16 +506,46 @@ func (c *Client) RemoveContainer(ctx context.Context, id string, force bool) err
 
 // ContainerCreateConfig represents container creation options
 type ContainerCreateConfig struct {
-	Name         string            `json:"name,omitempty"`
-	Image        string            `json:"image"`
-	Command      []string          `json:"command,omitempty"`
-	Env          map[string]string `json:"env,omitempty"`
-	PortMappings []PortMapping     `json:"portmappings,omitempty"`
-	Mounts       []Mount           `json:"mounts,omitempty"`
+	Name          string            `json:"name,omitempty"`
+	Image         string            `json:"image"`
+	Command       []string          `json:"command,omitempty"`
+	Env           map[string]string `json:"env,omitempty"`
+	Labels        map[string]string `json:"labels,omitempty"`
+	PortMappings  []PortMapping     `json:"portmappings,omitempty"`
+	Mounts        []Mount           `json:"mounts,omitempty"`
+	Volumes       []NamedVolume     `json:"volumes,omitempty"`
+	RestartPolicy string            `json:"restart_policy,omitempty"`
+	RestartTries  uint              `json:"restart_tries,omitempty"` // for "on-failure"
+	Remove        bool              `json:"remove,omitempty"`        // remove the container when it exits (--rm)
+
+	Entrypoint     []string                  `json:"entrypoint,omitempty"`
+	User           string                    `json:"user,omitempty"`
+	WorkDir        string                    `json:"work_dir,omitempty"`
+	HealthConfig   *HealthConfig             `json:"healthconfig,omitempty"`
+	CapAdd         []string                  `json:"cap_add,omitempty"`
+	CapDrop        []string                  `json:"cap_drop,omitempty"`
+	Devices        []Device                  `json:"devices,omitempty"`
+	ResourceLimits *ResourceLimits           `json:"resource_limits,omitempty"`
+	NetNS          *Namespace                `json:"netns,omitempty"`
+	Networks       map[string]NetworkOptions `json:"networks,omitempty"` // for nsmode bridge
+
+	Privileged         bool     `json:"privileged,omitempty"`
+	SelinuxOpts        []string `json:"selinux_opts,omitempty"` // label options ("disable", "type:spc_t")
+	ApparmorProfile    string   `json:"apparmor_profile,omitempty"`
+	SeccompProfilePath string   `json:"seccomp_profile_path,omitempty"` // or "unconfined"
+	NoNewPrivileges    bool     `json:"no_new_privileges,omitempty"`
+	Mask               []string `json:"mask,omitempty"`
+	Unmask             []string `json:"unmask,omitempty"`
+	Hostname           string   `json:"hostname,omitempty"`
+	DNSServers         []string `json:"dns_server,omitempty"`
+	DNSOptions         []string `json:"dns_option,omitempty"`
+	DNSSearch          []string `json:"dns_search,omitempty"`
+	HostAdd            []string `json:"hostadd,omitempty"` // "name:ip"
 }
 
 // PortMapping represents a port mapping
 type PortMapping struct {
+	HostIP        string `json:"host_ip,omitempty"`
 	ContainerPort int    `json:"container_port"`
 	HostPort      int    `json:"host_port"`
 	Protocol      string `json:"protocol,omitempty"`
@@ -269,9 +553,17 @@ type PortMapping struct {
 
 // Mount represents a volume mount
 type Mount struct {
-	Type        string `json:"Type"`
-	Source      string `json:"Source"`
-	Destination string `json:"Destination"`
+	Type        string   `json:"Type"`
+	Source      string   `json:"Source"`
+	Destination string   `json:"Destination"`
+	Options     []string `json:"Options,omitempty"`
+}
+
+// NamedVolume mounts a named volume into a container
+type NamedVolume struct {
+	Name    string   `json:"Name"`
+	Dest    string   `json:"Dest"`
+	Options []string `json:"Options,omitempty"`
 }
 
 // CreateContainerResponse represents the response from container creation
@@ -282,6 +574,9 @@ type CreateContainerResponse struct {
 
 // CreateContainer creates a new container
 func (c *Client) CreateContainer(ctx context.Context, config *ContainerCreateConfig) (*CreateContainerResponse, error) {
+	ctx, cancel := withTimeout(ctx, c.Timeout())
+	defer cancel()
+
 	data, err := json.Marshal(config)
 	if err != nil {
 		return nil, err
@@ -306,37 +601,17 @@ func (c *Client) CreateContainer(ctx context.Context, config *ContainerCreateCon
 	return &result, nil
 }
 
-// GetContainerLogs returns container logs
+// GetContainerLogs returns container logs (newest first, no size limit)
 func (c *Client) GetContainerLogs(ctx context.Context, id string, tail int) (string, error) {
-	path := fmt.Sprintf("/v4.0.0/libpod/containers/%s/logs?stdout=true&stderr=true&tail=%d", id, tail)
-	resp, err := c.request(ctx, http.MethodGet, path, nil)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
+	logs, err := c.GetContainerLogsLimited(ctx, id, tail, 0, false)
 	if err != nil {
 		return "", err
 	}
-
-	// Parse multiplexed stream and reverse order (newest first)
-	lines := parseContainerLogs(body)
-
-	// Reverse lines order (newest first)
-	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
-		lines[i], lines[j] = lines[j], lines[i]
-	}
-
-	// Strip ANSI escape codes
-	result := strings.Join(lines, "\n")
-	result = stripAnsiCodes(result)
-
-	return result, nil
+	return logs.Text, nil
 }
 
-// stripAnsiCodes removes ANSI escape sequences from string
-func stripAnsiCodes(s string) string {
+// StripAnsiCodes removes ANSI escape sequences from string
+func StripAnsiCodes(s string) string {
 	// Match ANSI escape sequences: ESC[ ... m (colors, styles)
 	// and ESC[ ... other control codes
 	result := make([]byte, 0, len(s))
@@ -362,59 +637,6 @@ func stripAnsiCodes(s string) string {
 	return string(result)
 }
 
-// parseContainerLogs parses multiplexed log stream
-// Each frame: [1 byte type][3 bytes padding][4 bytes size BE][payload]
-func parseContainerLogs(data []byte) []string {
-	var lines []string
-	pos := 0
-
-	for pos < len(data) {
-		// Need at least 8 bytes for header
-		if pos+8 > len(data) {
-			// Remaining data without header - treat as plain text
-			remaining := strings.TrimSpace(string(data[pos:]))
-			if remaining != "" {
-				lines = append(lines, strings.Split(remaining, "\n")...)
-			}
-			break
-		}
-
-		// Check if this looks like a multiplexed header (type byte 0-2)
-		streamType := data[pos]
-		if streamType > 2 {
-			// Not a valid header - treat rest as plain text
-			remaining := strings.TrimSpace(string(data[pos:]))
-			if remaining != "" {
-				lines = append(lines, strings.Split(remaining, "\n")...)
-			}
-			break
-		}
-
-		// Read payload size (big endian uint32 at offset 4)
-		size := int(data[pos+4])<<24 | int(data[pos+5])<<16 | int(data[pos+6])<<8 | int(data[pos+7])
-
-		// Sanity check size
-		if size < 0 || pos+8+size > len(data) {
-			remaining := strings.TrimSpace(string(data[pos:]))
-			if remaining != "" {
-				lines = append(lines, strings.Split(remaining, "\n")...)
-			}
-			break
-		}
-
-		// Extract payload
-		payload := string(data[pos+8 : pos+8+size])
-		payload = strings.TrimRight(payload, "\n\r")
-		if payload != "" {
-			lines = append(lines, payload)
-		}
-
-		pos += 8 + size
-	}
-
-	return lines
-}
-
 // Image types
 type Image struct {
 	ID          string   `json:"Id"`
@@ -434,17 +656,35 @@ type ImageInspect struct {
 	Architecture  string   `json:"Architecture"`
 	Os            string   `json:"Os"`
 	Config        struct {
-		Env        []string          `json:"Env"`
-		Cmd        []string          `json:"Cmd"`
-		Entrypoint []string          `json:"Entrypoint"`
-		Labels     map[string]string `json:"Labels"`
+		Env         []string          `json:"Env"`
+		Cmd         []string          `json:"Cmd"`
+		Entrypoint  []string          `json:"Entrypoint"`
+		User        string            `json:"User"`
+		WorkingDir  string            `json:"WorkingDir"`
+		Healthcheck *HealthConfig     `json:"Healthcheck"`
+		Labels      map[string]string `json:"Labels"`
 	} `json:"Config"`
 }
 
 // ListImages returns list of all images
 func (c *Client) ListImages(ctx context.Context) ([]Image, error) {
+	return c.ListImagesFiltered(ctx, nil)
+}
+
+// ListImagesFiltered returns the images matching Podman list filters,
+// e.g. {"dangling": {"true"}, "reference": {"nginx*"}}
+func (c *Client) ListImagesFiltered(ctx context.Context, filters map[string][]string) ([]Image, error) {
+	path := "/v4.0.0/libpod/images/json"
+	if len(filters) > 0 {
+		encoded, err := json.Marshal(filters)
+		if err != nil {
+			return nil, err
+		}
+		path += "?filters=" + url.QueryEscape(string(encoded))
+	}
+
 	var images []Image
-	err := c.get(ctx, "/v4.0.0/libpod/images/json", &images)
+	err := c.get(ctx, path, &images)
 	return images, err
 }
 
@@ -455,21 +695,55 @@ func (c *Client) InspectImage(ctx context.Context, id string) (*ImageInspect, er
 	return &info, err
 }
 
-// PullImage pulls an image from registry
-func (c *Client) PullImage(ctx context.Context, reference string) error {
-	path := fmt.Sprintf("/v4.0.0/libpod/images/pull?reference=%s", url.QueryEscape(reference))
-	resp, err := c.request(ctx, http.MethodPost, path, nil)
+// PullProgress represents a single line of the image pull progress stream
+type PullProgress struct {
+	Stream string   `json:"stream,omitempty"`
+	Error  string   `json:"error,omitempty"`
+	ID     string   `json:"id,omitempty"`
+	Images []string `json:"images,omitempty"`
+}
+
+// PullImage pulls an image from registry, for platform or with the zero
+// Platform for the host's
+func (c *Client) PullImage(ctx context.Context, reference string, platform Platform) error {
+	return c.PullImageWithProgress(ctx, reference, platform, nil)
+}
+
+// PullImageWithProgress pulls an image and calls onProgress for every stream line
+func (c *Client) PullImageWithProgress(ctx context.Context, reference string, platform Platform, onProgress func(PullProgress)) error {
+	ctx, cancel := withTimeout(ctx, PullTimeout)
+	defer cancel()
+
+	query := url.Values{}
+	query.Set("reference", reference)
+	platform.setQuery(query)
+	resp, err := c.request(ctx, http.MethodPost, "/v4.0.0/libpod/images/pull?"+query.Encode(), nil)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
-	// Read the streaming response
-	_, err = io.ReadAll(resp.Body)
 	if resp.StatusCode >= 400 {
 		return fmt.Errorf("pull failed with status %d", resp.StatusCode)
 	}
-	return err
+
+	// Read the streaming response (one JSON object per line)
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var progress PullProgress
+		if err := decoder.Decode(&progress); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if onProgress != nil {
+			onProgress(progress)
+		}
+		if progress.Error != "" {
+			return fmt.Errorf("pull failed: %s", progress.Error)
+		}
+	}
 }
 
 // RemoveImage removes an image
@@ -481,6 +755,100 @@ func (c *Client) RemoveImage(ctx context.Context, id string, force bool) error {
 	return c.delete(ctx, path)
 }
 
+// ImagePruneReport is an image removed by PruneImages
+type ImagePruneReport struct {
+	ID   string `json:"Id"`
+	Size uint64 `json:"Size"`
+}
+
+// PruneImages removes images no container uses: dangling ones only, or with
+// all every unused image. Filters are Podman prune filters such as
+// {"until": {"24h"}, "label": {"env=dev"}}.
+func (c *Client) PruneImages(ctx context.Context, all bool, filters map[string][]string) ([]ImagePruneReport, error) {
+	ctx, cancel := withTimeout(ctx, PullTimeout)
+	defer cancel()
+
+	query := url.Values{}
+	query.Set("all", fmt.Sprint(all))
+	if len(filters) > 0 {
+		encoded, err := json.Marshal(filters)
+		if err != nil {
+			return nil, err
+		}
+		query.Set("filters", string(encoded))
+	}
+
+	resp, err := c.request(ctx, http.MethodPost, "/v4.0.0/libpod/images/prune?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return nil, newAPIError(resp)
+	}
+
+	var reports []ImagePruneReport
+	if err := json.NewDecoder(resp.Body).Decode(&reports); err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+// ImageSearchResult is a registry search hit
+type ImageSearchResult struct {
+	Index       string `json:"index"` // registry searched, e.g. docker.io
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Stars       int    `json:"stars"`
+	Official    bool   `json:"official"`
+	Automated   bool   `json:"automated"`
+}
+
+// SearchLimit is the maximum number of results per registry searched
+const SearchLimit = 25
+
+// SearchRegistry searches registries for images matching term. With an
+// empty registry Podman searches its unqualified-search registries
+// (registries.conf), otherwise only the given one.
+func (c *Client) SearchRegistry(ctx context.Context, term, registry string) ([]ImageSearchResult, error) {
+	if registry != "" {
+		term = strings.TrimSuffix(registry, "/") + "/" + term
+	}
+	query := url.Values{}
+	query.Set("term", term)
+	query.Set("limit", fmt.Sprint(SearchLimit))
+
+	// Podman reports flags as "[OK]" or ""
+	var raw []struct {
+		Index       string `json:"Index"`
+		Name        string `json:"Name"`
+		Description string `json:"Description"`
+		Stars       int    `json:"Stars"`
+		Official    string `json:"Official"`
+		Automated   string `json:"Automated"`
+	}
+	// Not retried: Podman reports registry failures as 500
+	ctx, cancel := withTimeout(ctx, c.Timeout())
+	defer cancel()
+	if err := c.getOnce(ctx, "/v4.0.0/libpod/images/search?"+query.Encode(), &raw); err != nil {
+		return nil, err
+	}
+
+	results := make([]ImageSearchResult, len(raw))
+	for i, r := range raw {
+		results[i] = ImageSearchResult{
+			Index:       r.Index,
+			Name:        r.Name,
+			Description: r.Description,
+			Stars:       r.Stars,
+			Official:    r.Official != "",
+			Automated:   r.Automated != "",
+		}
+	}
+	return results, nil
+}
+
 // Volume types
 type Volume struct {
 	Name       string            `json:"Name"`
@@ -507,6 +875,9 @@ func (c *Client) ListVolumes(ctx context.Context) ([]Volume, error) {
 
 // CreateVolume creates a new volume
 func (c *Client) CreateVolume(ctx context.Context, name string) (*Volume, error) {
+	ctx, cancel := withTimeout(ctx, c.Timeout())
+	defer cancel()
+
 	body := map[string]string{"Name": name}
 	data, _ := json.Marshal(body)
 
@@ -575,6 +946,9 @@ func (c *Client) InspectNetwork(ctx context.Context, name string) (*Network, err
 
 // CreateNetwork creates a new network
 func (c *Client) CreateNetwork(ctx context.Context, name string) (*Network, error) {
+	ctx, cancel := withTimeout(ctx, c.Timeout())
+	defer cancel()
+
 	body := map[string]string{"name": name}
 	data, _ := json.Marshal(body)
 
@@ -609,12 +983,13 @@ type Pod struct {
 }
 
 type PodInspect struct {
-	ID         string `json:"Id"`
-	Name       string `json:"Name"`
-	State      string `json:"State"`
-	Created    string `json:"Created"`
-	Hostname   string `json:"Hostname"`
-	Containers []struct {
+	ID               string `json:"Id"`
+	Name             string `json:"Name"`
+	State            string `json:"State"`
+	Created          string `json:"Created"`
+	Hostname         string `json:"Hostname"`
+	InfraContainerID string `json:"InfraContainerID"`
+	Containers       []struct {
 		ID    string `json:"Id"`
 		Name  string `json:"Name"`
 		State string `json:"State"`
@@ -641,6 +1016,9 @@ type PodCreateResponse struct {
 
 // CreatePod creates a new pod
 func (c *Client) CreatePod(ctx context.Context, config *PodCreateConfig) (*PodCreateResponse, error) {
+	ctx, cancel := withTimeout(ctx, c.Timeout())
+	defer cancel()
+
 	data, err := json.Marshal(config)
 	if err != nil {
 		return nil, err
@@ -735,6 +1113,9 @@ func (c *Client) GetSystemDF(ctx context.Context) (*SystemDF, error) {
 
 // Ping checks if Podman API is available
 func (c *Client) Ping(ctx context.Context) error {
+	ctx, cancel := withTimeout(ctx, c.Timeout())
+	defer cancel()
+
 	resp, err := c.request(ctx, http.MethodGet, "/_ping", nil)
 	if err != nil {
 		return err
@@ -765,6 +1146,9 @@ func (c *Client) CreateExec(ctx context.Context, containerID string, cmd []strin
 
 // CreateExecWithEnv creates an exec instance in a container with environment variables
 func (c *Client) CreateExecWithEnv(ctx context.Context, containerID string, cmd []string, env []string) (*ExecCreateResponse, error) {
+	ctx, cancel := withTimeout(ctx, c.Timeout())
+	defer cancel()
+
 	config := ExecConfig{
 		AttachStdin:  true,
 		AttachStdout: true,
@@ -798,7 +1182,21 @@ func (c *Client) CreateExecWithEnv(ctx context.Context, containerID string, cmd
 	return &result, nil
 }
 
-// GetSocketPath returns the socket path
+// GetSocketPath returns the socket path or URI
 func (c *Client) GetSocketPath() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	return c.socketPath
 }
+
+// IsRemote reports whether the engine runs on another host (tcp:// or
+// ssh:// socket), so its containers aren't on this machine
+func (c *Client) IsRemote() bool {
+	return IsRemoteSocket(c.GetSocketPath())
+}
+
+// IsRemoteSocket reports whether a socket path or URI points to another host
+func IsRemoteSocket(uri string) bool {
+	scheme, _, ok := strings.Cut(uri, "://")
+	return ok && scheme != "unix"
+}
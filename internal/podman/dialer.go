@@ -0,0 +1,321 @@
+package podman
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// dialer opens raw connections to the Podman API
+type dialer interface {
+	dial(ctx context.Context) (net.Conn, error)
+	// reset drops state kept between dials, such as a shared SSH connection
+	reset()
+	// Close releases the dialer's connections; it isn't used afterwards
+	Close() error
+}
+
+// Default SSH settings for remote connections
+const (
+	defaultSSHPort      = "22"
+	defaultRemoteSocket = "/run/podman/podman.sock"
+	sshHandshakeTimeout = 10 * time.Second
+)
+
+// newDialer creates a dialer for the given socket path or URI.
+// Supported forms:
+//   - /path/to/podman.sock or unix:///path/to/podman.sock
+//   - tcp://host:port
+//   - ssh://user@host[:port]/path/to/podman.sock[?identity=/path/to/key]
+func newDialer(uri string) (dialer, error) {
+	if !strings.Contains(uri, "://") {
+		return unixDialer(uri)
+	}
+
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid socket URI: %w", err)
+	}
+
+	switch u.Scheme {
+	case "unix":
+		return unixDialer(u.Path)
+	case "tcp":
+		if u.Host == "" {
+			return nil, fmt.Errorf("tcp socket URI requires host:port: %s", uri)
+		}
+		return &netDialer{network: "tcp", addr: u.Host}, nil
+	case "ssh":
+		return newSSHDialer(u)
+	default:
+		return nil, fmt.Errorf("unsupported socket scheme: %s", u.Scheme)
+	}
+}
+
+// unixDialer creates a dialer for a local unix socket
+func unixDialer(path string) (dialer, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("socket not found: %s", path)
+	}
+	return &netDialer{network: "unix", addr: path}, nil
+}
+
+// netDialer connects to a unix socket or TCP address directly
+type netDialer struct {
+	network string
+	addr    string
+}
+
+func (d *netDialer) dial(ctx context.Context) (net.Conn, error) {
+	var nd net.Dialer
+	return nd.DialContext(ctx, d.network, d.addr)
+}
+
+func (d *netDialer) reset() {}
+
+func (d *netDialer) Close() error { return nil }
+
+// sshDialer forwards connections to a remote unix socket over SSH.
+// A single SSH connection is shared and re-established when it drops.
+type sshDialer struct {
+	addr       string
+	remotePath string
+	config     *ssh.ClientConfig
+	agentConn  net.Conn // ssh-agent connection behind config.Auth, if any
+
+	mu     sync.Mutex
+	client *ssh.Client
+}
+
+// newSSHDialer builds SSH client configuration from the URI
+func newSSHDialer(u *url.URL) (*sshDialer, error) {
+	if u.User == nil || u.User.Username() == "" {
+		return nil, fmt.Errorf("ssh socket URI requires a user: ssh://user@host/path")
+	}
+
+	host := u.Hostname()
+	if host == "" {
+		return nil, fmt.Errorf("ssh socket URI requires a host")
+	}
+	port := u.Port()
+	if port == "" {
+		port = defaultSSHPort
+	}
+
+	remotePath := u.Path
+	if remotePath == "" || remotePath == "/" {
+		remotePath = defaultRemoteSocket
+	}
+
+	hostKeyCallback, err := sshHostKeyCallback()
+	if err != nil {
+		return nil, err
+	}
+
+	auth, agentConn, err := sshAuthMethods(u.Query().Get("identity"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &sshDialer{
+		addr:       net.JoinHostPort(host, port),
+		remotePath: remotePath,
+		agentConn:  agentConn,
+		config: &ssh.ClientConfig{
+			User:            u.User.Username(),
+			Auth:            auth,
+			HostKeyCallback: hostKeyCallback,
+		},
+	}, nil
+}
+
+// dial opens a forwarded connection to the remote Podman socket
+func (d *sshDialer) dial(ctx context.Context) (net.Conn, error) {
+	client, err := d.getClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := d.dialRemote(ctx, client)
+	if err == nil || ctx.Err() != nil {
+		return conn, err
+	}
+
+	// SSH connection may be stale - reconnect once and retry
+	d.resetClient(client)
+	client, err = d.getClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.dialRemote(ctx, client)
+}
+
+// dialRemote opens a channel to the remote socket, giving up when ctx is
+// done. ssh.Client.Dial can't be cancelled, so a channel that opens after
+// that is closed.
+func (d *sshDialer) dialRemote(ctx context.Context, client *ssh.Client) (net.Conn, error) {
+	type result struct {
+		conn net.Conn
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		conn, err := client.Dial("unix", d.remotePath)
+		done <- result{conn, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.conn, r.err
+	case <-ctx.Done():
+		go func() {
+			if r := <-done; r.conn != nil {
+				r.conn.Close()
+			}
+		}()
+		return nil, ctx.Err()
+	}
+}
+
+// getClient returns the shared SSH client, connecting if needed
+func (d *sshDialer) getClient(ctx context.Context) (*ssh.Client, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.client != nil {
+		return d.client, nil
+	}
+
+	var nd net.Dialer
+	conn, err := nd.DialContext(ctx, "tcp", d.addr)
+	if err != nil {
+		return nil, fmt.Errorf("ssh connect to %s: %w", d.addr, err)
+	}
+
+	// Bound the handshake so an unresponsive host can't hang requests
+	conn.SetDeadline(time.Now().Add(sshHandshakeTimeout))
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, d.addr, d.config)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("ssh handshake with %s: %w", d.addr, err)
+	}
+	conn.SetDeadline(time.Time{})
+
+	d.client = ssh.NewClient(sshConn, chans, reqs)
+	return d.client, nil
+}
+
+// resetClient closes the given SSH client if it is still the shared one
+func (d *sshDialer) resetClient(client *ssh.Client) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.client == client {
+		d.client.Close()
+		d.client = nil
+	}
+}
+
+// reset drops the shared SSH connection, the next dial opens a new one
+func (d *sshDialer) reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.client != nil {
+		d.client.Close()
+		d.client = nil
+	}
+}
+
+// Close closes the shared SSH connection and the ssh-agent connection
+func (d *sshDialer) Close() error {
+	d.reset()
+	if d.agentConn != nil {
+		return d.agentConn.Close()
+	}
+	return nil
+}
+
+// sshAuthMethods collects auth from ssh-agent and private key files. The
+// returned ssh-agent connection, if any, stays open for the methods to use.
+func sshAuthMethods(identity string) ([]ssh.AuthMethod, net.Conn, error) {
+	var methods []ssh.AuthMethod
+	var agentConn net.Conn
+
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		if conn, err := net.Dial("unix", sock); err == nil {
+			agentConn = conn
+			methods = append(methods, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+		}
+	}
+
+	fail := func(err error) ([]ssh.AuthMethod, net.Conn, error) {
+		if agentConn != nil {
+			agentConn.Close()
+		}
+		return nil, nil, err
+	}
+
+	var keyFiles []string
+	if identity != "" {
+		keyFiles = []string{identity}
+	} else if home, err := os.UserHomeDir(); err == nil {
+		for _, name := range []string{"id_ed25519", "id_ecdsa", "id_rsa"} {
+			keyFiles = append(keyFiles, filepath.Join(home, ".ssh", name))
+		}
+	}
+
+	var signers []ssh.Signer
+	for _, path := range keyFiles {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if identity != "" {
+				return fail(fmt.Errorf("failed to read ssh identity: %w", err))
+			}
+			continue
+		}
+		signer, err := ssh.ParsePrivateKey(data)
+		if err != nil {
+			if identity != "" {
+				return fail(fmt.Errorf("failed to parse ssh identity: %w", err))
+			}
+			continue
+		}
+		signers = append(signers, signer)
+	}
+	if len(signers) > 0 {
+		methods = append(methods, ssh.PublicKeys(signers...))
+	}
+
+	if len(methods) == 0 {
+		return nil, nil, fmt.Errorf("no ssh credentials found (start ssh-agent or add ~/.ssh/id_ed25519)")
+	}
+
+	return methods, agentConn, nil
+}
+
+// sshHostKeyCallback verifies remote hosts against ~/.ssh/known_hosts
+func sshHostKeyCallback() (ssh.HostKeyCallback, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate home directory: %w", err)
+	}
+
+	callback, err := knownhosts.New(filepath.Join(home, ".ssh", "known_hosts"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load known_hosts (connect once with ssh to add the host): %w", err)
+	}
+
+	return callback, nil
+}
@@ -0,0 +1,46 @@
+package podman
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// ContainerEvent is a container event from the Podman events stream
+type ContainerEvent struct {
+	Action string `json:"Action"` // create, start, died, stop, remove, ...
+	Actor  struct {
+		ID         string            `json:"ID"`
+		Attributes map[string]string `json:"Attributes"` // name, image and labels
+	} `json:"Actor"`
+}
+
+// StreamContainerEvents calls onEvent for each new container event until
+// ctx ends or Podman closes the stream
+func (c *Client) StreamContainerEvents(ctx context.Context, onEvent func(ContainerEvent)) error {
+	// No timeout: the stream stays open for as long as the caller needs it
+	filters := url.QueryEscape(`{"type":["container"]}`)
+	resp, err := c.request(ctx, http.MethodGet, "/v4.0.0/libpod/events?stream=true&filters="+filters, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return newAPIError(resp)
+	}
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var event ContainerEvent
+		if err := decoder.Decode(&event); err != nil {
+			if err == io.EOF || ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		onEvent(event)
+	}
+}
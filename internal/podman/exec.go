@@ -0,0 +1,183 @@
+package podman
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrStdinNotSupported is returned by ExecRun with stdin when the
+// connection to Podman can't be half-closed to signal end of input
+var ErrStdinNotSupported = errors.New("exec stdin is not supported over this Podman connection")
+
+// ExecInspect is the state of an exec instance
+type ExecInspect struct {
+	Running  bool `json:"Running"`
+	ExitCode int  `json:"ExitCode"`
+}
+
+// ExecRun runs cmd in a container without a TTY and waits for it to exit.
+// stdin, when not nil, is copied to the command and then closed; stdout
+// and stderr receive its output separately. Returns the exit code.
+func (c *Client) ExecRun(ctx context.Context, containerID string, cmd []string, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	execID, err := c.createRunExec(ctx, containerID, cmd, stdin != nil)
+	if err != nil {
+		return -1, err
+	}
+
+	conn, err := c.Dial(ctx)
+	if err != nil {
+		return -1, err
+	}
+	defer conn.Close()
+
+	// Unblock reads and writes on the hijacked connection when ctx ends
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	var halfCloser interface{ CloseWrite() error }
+	if stdin != nil {
+		var ok bool
+		if halfCloser, ok = conn.(interface{ CloseWrite() error }); !ok {
+			return -1, ErrStdinNotSupported
+		}
+	}
+
+	startReq := `{"Detach":false,"Tty":false}`
+	httpReq := fmt.Sprintf("POST /v4.0.0/libpod/exec/%s/start HTTP/1.1\r\n"+
+		"Host: localhost\r\n"+
+		"Content-Type: application/json\r\n"+
+		"Content-Length: %d\r\n"+
+		"Connection: Upgrade\r\n"+
+		"Upgrade: tcp\r\n"+
+		"\r\n"+
+		"%s", execID, len(startReq), startReq)
+	if _, err := io.WriteString(conn, httpReq); err != nil {
+		return -1, err
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		return -1, err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols && resp.StatusCode != http.StatusOK {
+		return -1, newAPIError(resp)
+	}
+
+	stdinErr := make(chan error, 1)
+	if stdin != nil {
+		go func() {
+			_, err := io.Copy(conn, stdin)
+			if closeErr := halfCloser.CloseWrite(); err == nil {
+				err = closeErr
+			}
+			stdinErr <- err
+		}()
+	} else {
+		stdinErr <- nil
+	}
+
+	if err := DemuxStream(reader, stdout, stderr); err != nil {
+		if ctx.Err() != nil {
+			return -1, ctx.Err()
+		}
+		return -1, err
+	}
+	if err := <-stdinErr; err != nil {
+		return -1, fmt.Errorf("writing exec stdin: %w", err)
+	}
+
+	// The exit code is recorded shortly after the output stream ends
+	for attempt := 0; ; attempt++ {
+		inspect, err := c.InspectExec(ctx, execID)
+		if err != nil {
+			return -1, err
+		}
+		if !inspect.Running || attempt == 20 {
+			return inspect.ExitCode, nil
+		}
+		select {
+		case <-ctx.Done():
+			return -1, ctx.Err()
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+// createRunExec creates a non-TTY exec instance for ExecRun
+func (c *Client) createRunExec(ctx context.Context, containerID string, cmd []string, attachStdin bool) (string, error) {
+	ctx, cancel := withTimeout(ctx, c.Timeout())
+	defer cancel()
+
+	data, err := json.Marshal(ExecConfig{
+		AttachStdin:  attachStdin,
+		AttachStdout: true,
+		AttachStderr: true,
+		Cmd:          cmd,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := c.request(ctx, http.MethodPost, fmt.Sprintf("/v4.0.0/libpod/containers/%s/exec", containerID), strings.NewReader(string(data)))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return "", newAPIError(resp)
+	}
+
+	var result ExecCreateResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	return result.ID, nil
+}
+
+// InspectExec returns the state of an exec instance
+func (c *Client) InspectExec(ctx context.Context, execID string) (*ExecInspect, error) {
+	var result ExecInspect
+	if err := c.get(ctx, fmt.Sprintf("/v4.0.0/libpod/exec/%s/json", execID), &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// DemuxStream splits a multiplexed non-TTY exec or attach stream into
+// stdout and stderr. Frames are [1 byte type][3 bytes padding][4 bytes size BE][payload].
+func DemuxStream(r io.Reader, stdout, stderr io.Writer) error {
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		var dst io.Writer
+		switch header[0] {
+		case 0, 1:
+			dst = stdout
+		case 2:
+			dst = stderr
+		default:
+			return fmt.Errorf("invalid exec stream frame type %d", header[0])
+		}
+
+		size := int64(binary.BigEndian.Uint32(header[4:8]))
+		if _, err := io.CopyN(dst, r, size); err != nil {
+			return err
+		}
+	}
+}
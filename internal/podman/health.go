@@ -0,0 +1,169 @@
+package podman
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// healthPingTimeout limits a single health check ping
+const healthPingTimeout = 5 * time.Second
+
+// ConnectionState describes the current Podman connection health
+type ConnectionState struct {
+	Connected  bool      `json:"connected"`
+	Socket     string    `json:"socket"`
+	LastCheck  time.Time `json:"lastCheck"`
+	LastError  string    `json:"lastError,omitempty"`
+	Reconnects int       `json:"reconnects"`
+}
+
+// ConnectionState returns a snapshot of the connection health
+func (c *Client) ConnectionState() ConnectionState {
+	c.stateMu.RLock()
+	defer c.stateMu.RUnlock()
+	return c.state
+}
+
+// StartHealthMonitor pings Podman periodically and re-dials the socket
+// when the connection is lost. Stops when ctx is cancelled.
+func (c *Client) StartHealthMonitor(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.checkHealth(ctx)
+			}
+		}
+	}()
+}
+
+// checkHealth pings Podman and reconnects if the ping fails
+func (c *Client) checkHealth(ctx context.Context) {
+	pingCtx, cancel := context.WithTimeout(ctx, healthPingTimeout)
+	defer cancel()
+
+	err := c.Ping(pingCtx)
+	if err == nil {
+		c.markConnected()
+		return
+	}
+
+	wasConnected := c.ConnectionState().Connected
+	c.markDisconnected(err)
+	if wasConnected {
+		log.Printf("Podman connection lost: %v", err)
+	}
+
+	if err := c.reconnect(); err != nil {
+		return
+	}
+
+	pingCtx, cancel = context.WithTimeout(ctx, healthPingTimeout)
+	defer cancel()
+	if err := c.Ping(pingCtx); err != nil {
+		c.markDisconnected(err)
+		return
+	}
+
+	c.markConnected()
+	log.Printf("Podman connection restored (%s)", c.GetSocketPath())
+}
+
+// reconnect prepares the next requests to dial the socket again. The
+// dialer is kept if the socket is the same, only dropping the connections
+// it holds; otherwise, as after auto-detection finds another socket, it is
+// replaced and the old one closed. Counted as a reconnect once a ping
+// succeeds (see markConnected).
+func (c *Client) reconnect() error {
+	path := c.GetSocketPath()
+	if c.autoDetect {
+		detected, err := detectSocket()
+		if err != nil {
+			c.markDisconnected(err)
+			return err
+		}
+		path = detected
+	}
+
+	if path == c.GetSocketPath() {
+		c.mu.RLock()
+		c.dialer.reset()
+		c.mu.RUnlock()
+	} else {
+		d, err := newDialer(path)
+		if err != nil {
+			c.markDisconnected(err)
+			return err
+		}
+
+		c.mu.Lock()
+		old := c.dialer
+		c.dialer = d
+		c.socketPath = path
+		c.mu.Unlock()
+		old.Close()
+	}
+
+	// Drop pooled connections to the old socket
+	if t, ok := c.httpClient.Transport.(*http.Transport); ok {
+		t.CloseIdleConnections()
+	}
+
+	c.stateMu.Lock()
+	c.state.Socket = path
+	c.redialed = true
+	c.stateMu.Unlock()
+
+	return nil
+}
+
+// markConnected records a successful health check, counting a reconnect
+// if the socket was dialed again since the last one
+func (c *Client) markConnected() {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	if c.redialed {
+		c.state.Reconnects++
+		c.redialed = false
+	}
+	c.state.Connected = true
+	c.state.LastCheck = time.Now()
+	c.state.LastError = ""
+}
+
+// markDisconnected records a failed connection attempt
+func (c *Client) markDisconnected(err error) {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	c.state.Connected = false
+	c.state.LastCheck = time.Now()
+	c.state.LastError = err.Error()
+}
+
+// isConnectionError reports whether err means the socket is unreachable
+func isConnectionError(err error) bool {
+	return isDialError(err) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE)
+}
+
+// isDialError reports whether err means the connection could not be
+// opened, so the request never reached Podman
+func isDialError(err error) bool {
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ENOENT) {
+		return true
+	}
+
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
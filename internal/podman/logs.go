@@ -0,0 +1,225 @@
+package podman
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Log drivers that keep no readable logs
+const (
+	LogDriverNone        = "none"
+	LogDriverPassthrough = "passthrough"
+	LogDriverJournald    = "journald"
+)
+
+// ContainerLogs is a size-limited container log snapshot
+type ContainerLogs struct {
+	Text      string // newest line first, ANSI codes stripped
+	Truncated bool   // older lines were dropped to fit the byte limit
+}
+
+// GetContainerLogsLimited returns container logs keeping at most maxBytes
+// of the newest output (maxBytes <= 0 means no limit). The stream is
+// trimmed while reading, so huge logs are never fully held in memory.
+// With timestamps each line starts with its RFC 3339 time in UTC.
+func (c *Client) GetContainerLogsLimited(ctx context.Context, id string, tail int, maxBytes int64, timestamps bool) (*ContainerLogs, error) {
+	ctx, cancel := withTimeout(ctx, c.Timeout())
+	defer cancel()
+
+	path := fmt.Sprintf("/v4.0.0/libpod/containers/%s/logs?stdout=true&stderr=true&tail=%d", id, tail)
+	if timestamps {
+		path += "&timestamps=true"
+	}
+	resp, err := c.request(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return nil, newAPIError(resp)
+	}
+
+	window := &logWindow{maxBytes: maxBytes}
+	if err := readLogStream(resp.Body, window); err != nil {
+		return nil, err
+	}
+
+	// Reverse lines order (newest first)
+	lines := window.lines
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+
+	return &ContainerLogs{
+		Text:      StripAnsiCodes(strings.Join(lines, "\n")),
+		Truncated: window.truncated,
+	}, nil
+}
+
+// FollowContainerLogs calls onLine with the last tail lines of a
+// container's logs and then with each new line, oldest first and with ANSI
+// codes stripped, until ctx ends or the container stops. Lines longer than
+// maxLineBytes are cut to their end.
+func (c *Client) FollowContainerLogs(ctx context.Context, id string, tail int, maxLineBytes int64, onLine func(string)) error {
+	// No timeout: the stream stays open for as long as the caller needs it
+	path := fmt.Sprintf("/v4.0.0/libpod/containers/%s/logs?follow=true&stdout=true&stderr=true&tail=%d", id, tail)
+	resp, err := c.request(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return newAPIError(resp)
+	}
+
+	window := &logWindow{
+		maxBytes: maxLineBytes,
+		onLine:   func(line string) { onLine(StripAnsiCodes(line)) },
+	}
+	if err := readLogStream(resp.Body, window); err != nil && ctx.Err() == nil {
+		return err
+	}
+	return nil
+}
+
+// logWindow keeps the newest lines within a byte budget. With onLine set
+// lines are passed on instead, and maxBytes only limits each line.
+type logWindow struct {
+	maxBytes  int64
+	lines     []string
+	size      int64
+	truncated bool
+	onLine    func(string)
+}
+
+// add appends a line, dropping the oldest lines when over budget
+func (w *logWindow) add(line string) {
+	if w.maxBytes > 0 && int64(len(line)) > w.maxBytes {
+		line = line[int64(len(line))-w.maxBytes:]
+		w.truncated = true
+	}
+	if w.onLine != nil {
+		w.onLine(line)
+		return
+	}
+
+	w.lines = append(w.lines, line)
+	w.size += int64(len(line)) + 1
+
+	for w.maxBytes > 0 && w.size > w.maxBytes && len(w.lines) > 1 {
+		w.size -= int64(len(w.lines[0])) + 1
+		w.lines[0] = "" // release memory held by the backing array
+		w.lines = w.lines[1:]
+		w.truncated = true
+	}
+}
+
+// readLogStream reads Podman log output into window.
+// Non-TTY containers use a multiplexed stream with frames of
+// [1 byte type][3 bytes padding][4 bytes size BE][payload];
+// TTY containers return plain text.
+func readLogStream(r io.Reader, window *logWindow) error {
+	br := bufio.NewReader(r)
+
+	first, err := br.Peek(1)
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// Not a valid frame header - plain text
+	if first[0] > 2 {
+		return readPlainLog(br, window)
+	}
+
+	header := make([]byte, 8)
+	for {
+		n, err := io.ReadFull(br, header)
+		if err == io.EOF {
+			return nil
+		}
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			// Trailing bytes without a full header
+			addText(window, string(header[:n]))
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		// Not a frame header - treat the rest as plain text
+		if header[0] > 2 {
+			return readPlainLog(io.MultiReader(strings.NewReader(string(header)), br), window)
+		}
+
+		size := int64(binary.BigEndian.Uint32(header[4:8]))
+
+		// Skip the part of an oversized frame that can't fit anyway
+		if window.maxBytes > 0 && size > window.maxBytes {
+			if _, err := io.CopyN(io.Discard, br, size-window.maxBytes); err != nil {
+				return nil
+			}
+			size = window.maxBytes
+			window.truncated = true
+		}
+
+		payload := make([]byte, size)
+		n, err = io.ReadFull(br, payload)
+		if text := strings.TrimRight(string(payload[:n]), "\n\r"); text != "" {
+			window.add(text)
+		}
+		if err != nil {
+			return nil
+		}
+	}
+}
+
+// readPlainLog reads newline-separated text into window.
+// Overlong lines are trimmed while reading.
+func readPlainLog(r io.Reader, window *logWindow) error {
+	br := bufio.NewReader(r)
+	var line []byte
+
+	for {
+		chunk, err := br.ReadSlice('\n')
+		line = append(line, chunk...)
+		if window.maxBytes > 0 && int64(len(line)) > window.maxBytes {
+			line = append(line[:0], line[int64(len(line))-window.maxBytes:]...)
+			window.truncated = true
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+
+		if text := strings.TrimRight(string(line), "\n\r"); text != "" {
+			window.add(text)
+		}
+		line = line[:0]
+
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// addText adds each non-empty line of text to window
+func addText(window *logWindow, text string) {
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		if line != "" {
+			window.add(line)
+		}
+	}
+}
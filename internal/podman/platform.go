@@ -0,0 +1,92 @@
+package podman
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Platform selects the image variant pulled from a multi-arch manifest.
+// The zero value pulls the host's platform.
+type Platform struct {
+	OS      string
+	Arch    string
+	Variant string
+}
+
+// platformVariants lists the known os/arch pairs and the variants valid
+// for each (nil when the architecture has none)
+var platformVariants = map[string][]string{
+	"linux/amd64":    {"v1", "v2", "v3", "v4"},
+	"linux/arm64":    {"v8"},
+	"linux/arm":      {"v5", "v6", "v7"},
+	"linux/386":      nil,
+	"linux/ppc64le":  nil,
+	"linux/s390x":    nil,
+	"linux/riscv64":  nil,
+	"linux/mips64le": nil,
+	"linux/loong64":  nil,
+	"windows/amd64":  nil,
+	"windows/arm64":  nil,
+}
+
+// ParsePlatform parses an os/arch[/variant] platform such as linux/arm64
+// or linux/arm/v7. An empty string returns the zero Platform.
+func ParsePlatform(s string) (Platform, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return Platform{}, nil
+	}
+
+	parts := strings.Split(s, "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return Platform{}, fmt.Errorf("invalid platform %q, expected os/arch[/variant]", s)
+	}
+	p := Platform{OS: parts[0], Arch: parts[1]}
+	if len(parts) == 3 {
+		p.Variant = parts[2]
+	}
+
+	variants, ok := platformVariants[p.OS+"/"+p.Arch]
+	if !ok {
+		return Platform{}, fmt.Errorf("unsupported platform %s/%s", p.OS, p.Arch)
+	}
+	if p.Variant != "" {
+		known := false
+		for _, v := range variants {
+			known = known || v == p.Variant
+		}
+		if !known {
+			return Platform{}, fmt.Errorf("unsupported variant %s for %s/%s", p.Variant, p.OS, p.Arch)
+		}
+	}
+	return p, nil
+}
+
+// IsZero reports whether p selects the host's platform
+func (p Platform) IsZero() bool {
+	return p.OS == "" && p.Arch == ""
+}
+
+// String returns the platform as os/arch[/variant]
+func (p Platform) String() string {
+	if p.IsZero() {
+		return ""
+	}
+	if p.Variant != "" {
+		return p.OS + "/" + p.Arch + "/" + p.Variant
+	}
+	return p.OS + "/" + p.Arch
+}
+
+// setQuery adds the libpod pull parameters selecting p
+func (p Platform) setQuery(query url.Values) {
+	if p.IsZero() {
+		return
+	}
+	query.Set("OS", p.OS)
+	query.Set("arch", p.Arch)
+	if p.Variant != "" {
+		query.Set("variant", p.Variant)
+	}
+}
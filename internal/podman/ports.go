@@ -0,0 +1,88 @@
+package podman
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PortBinding is a published port, normalized from either the
+// container list format or the inspect NetworkSettings.Ports map
+type PortBinding struct {
+	HostIP        string `json:"hostIp"` // empty or 0.0.0.0 = all interfaces
+	HostPort      int    `json:"hostPort"`
+	ContainerPort int    `json:"containerPort"`
+	Protocol      string `json:"protocol"` // tcp, udp or sctp
+}
+
+// InspectHostPort is a host binding in inspect NetworkSettings.Ports
+type InspectHostPort struct {
+	HostIP   string `json:"HostIp"`
+	HostPort string `json:"HostPort"`
+}
+
+// Bindings expands a list-format port mapping (which may cover
+// a range of consecutive ports) into individual bindings
+func (p Port) Bindings() []PortBinding {
+	if p.HostPort == 0 {
+		return nil
+	}
+
+	count := max(p.Range, 1)
+	bindings := make([]PortBinding, 0, count)
+	for i := 0; i < count; i++ {
+		bindings = append(bindings, PortBinding{
+			HostIP:        p.HostIP,
+			HostPort:      p.HostPort + i,
+			ContainerPort: p.ContainerPort + i,
+			Protocol:      normalizeProtocol(p.Protocol),
+		})
+	}
+	return bindings
+}
+
+// PortBindings returns published ports from inspect data, sorted by
+// host port. Exposed ports without a host binding are skipped.
+func (c *ContainerInspect) PortBindings() []PortBinding {
+	bindings := []PortBinding{}
+	for key, hostPorts := range c.NetworkSettings.Ports {
+		// Keys look like "8080/tcp"
+		portStr, proto, _ := strings.Cut(key, "/")
+		containerPort, err := strconv.Atoi(portStr)
+		if err != nil {
+			continue
+		}
+
+		for _, hp := range hostPorts {
+			hostPort, err := strconv.Atoi(hp.HostPort)
+			if err != nil || hostPort == 0 {
+				continue
+			}
+			bindings = append(bindings, PortBinding{
+				HostIP:        hp.HostIP,
+				HostPort:      hostPort,
+				ContainerPort: containerPort,
+				Protocol:      normalizeProtocol(proto),
+			})
+		}
+	}
+
+	sort.Slice(bindings, func(i, j int) bool {
+		if bindings[i].HostPort != bindings[j].HostPort {
+			return bindings[i].HostPort < bindings[j].HostPort
+		}
+		if bindings[i].Protocol != bindings[j].Protocol {
+			return bindings[i].Protocol < bindings[j].Protocol
+		}
+		return bindings[i].HostIP < bindings[j].HostIP
+	})
+	return bindings
+}
+
+// normalizeProtocol lowercases protocol, defaulting to tcp
+func normalizeProtocol(proto string) string {
+	if proto == "" {
+		return "tcp"
+	}
+	return strings.ToLower(proto)
+}
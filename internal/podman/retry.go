@@ -0,0 +1,77 @@
+package podman
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// RetryPolicy controls retries of idempotent requests
+type RetryPolicy struct {
+	MaxRetries int           // 0 disables retries
+	BaseDelay  time.Duration // delay before the first retry
+	MaxDelay   time.Duration // cap for exponential backoff
+}
+
+// DefaultRetryPolicy smooths over a short Podman API restart
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 3,
+	BaseDelay:  200 * time.Millisecond,
+	MaxDelay:   2 * time.Second,
+}
+
+// SetRetryPolicy sets the retry policy for idempotent GET requests
+func (c *Client) SetRetryPolicy(p RetryPolicy) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.retry = p
+}
+
+// RetryPolicy returns the retry policy for idempotent GET requests
+func (c *Client) RetryPolicy() RetryPolicy {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.retry
+}
+
+// withRetry runs fn, retrying transient errors with exponential backoff.
+// Only use for idempotent requests - mutating POST/DELETE must not be retried.
+func (c *Client) withRetry(ctx context.Context, fn func() error) error {
+	policy := c.RetryPolicy()
+	delay := policy.BaseDelay
+
+	err := fn()
+	for attempt := 0; attempt < policy.MaxRetries && err != nil; attempt++ {
+		if ctx.Err() != nil || !isRetryable(err) {
+			return err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+
+		delay *= 2
+		if policy.MaxDelay > 0 && delay > policy.MaxDelay {
+			delay = policy.MaxDelay
+		}
+
+		err = fn()
+	}
+
+	return err
+}
+
+// isRetryable reports whether err is a transient failure:
+// connection refused/reset or a 5xx response from Podman
+func isRetryable(err error) bool {
+	if isConnectionError(err) {
+		return true
+	}
+
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode >= 500
+}
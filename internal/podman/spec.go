@@ -0,0 +1,79 @@
+package podman
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// HealthConfig is a container healthcheck, in the same format in inspect
+// output and create specs
+type HealthConfig struct {
+	Test        []string `json:"Test,omitempty"`        // e.g. ["CMD-SHELL", "curl -f localhost"]
+	Interval    int64    `json:"Interval,omitempty"`    // nanoseconds
+	Timeout     int64    `json:"Timeout,omitempty"`     // nanoseconds
+	StartPeriod int64    `json:"StartPeriod,omitempty"` // nanoseconds
+	Retries     int      `json:"Retries,omitempty"`
+}
+
+// Device is a host device added to a container
+type Device struct {
+	Path string `json:"path"` // "/dev/host[:/dev/container[:permissions]]"
+}
+
+// Namespace is a container namespace mode, e.g. {"nsmode": "host"}
+type Namespace struct {
+	NSMode string `json:"nsmode"`
+	Value  string `json:"value,omitempty"`
+}
+
+// NetworkOptions are a container's settings on one network
+type NetworkOptions struct {
+	Aliases   []string `json:"aliases,omitempty"`
+	StaticIPs []string `json:"static_ips,omitempty"`
+	StaticMAC string   `json:"static_mac,omitempty"`
+}
+
+// ResourceLimits are a container's cgroup limits; zero values are unset
+type ResourceLimits struct {
+	Memory *MemoryLimits `json:"memory,omitempty"`
+	CPU    *CPULimits    `json:"cpu,omitempty"`
+	Pids   *PidsLimits   `json:"pids,omitempty"`
+}
+
+// MemoryLimits limit a container's memory in bytes (swap -1 = unlimited)
+type MemoryLimits struct {
+	Limit int64 `json:"limit,omitempty"`
+	Swap  int64 `json:"swap,omitempty"`
+}
+
+// CPULimits limit a container's CPU time and CPUs
+type CPULimits struct {
+	Shares uint64 `json:"shares,omitempty"`
+	Quota  int64  `json:"quota,omitempty"`  // microseconds per period
+	Period uint64 `json:"period,omitempty"` // microseconds
+	Cpus   string `json:"cpus,omitempty"`   // e.g. "0-3"
+}
+
+// PidsLimits limit the number of processes in a container
+type PidsLimits struct {
+	Limit int64 `json:"limit"`
+}
+
+// StringList is a list of strings that is a single space-separated
+// string in older Podman versions (inspect Config.Entrypoint before 5.0)
+type StringList []string
+
+// UnmarshalJSON accepts a list or a string
+func (l *StringList) UnmarshalJSON(data []byte) error {
+	var list []string
+	if err := json.Unmarshal(data, &list); err == nil {
+		*l = list
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	*l = strings.Fields(s)
+	return nil
+}
@@ -0,0 +1,39 @@
+package podman
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// SystemdRestartPolicies lists restart policies accepted for generated units
+var SystemdRestartPolicies = []string{"no", "on-success", "on-failure", "on-abnormal", "on-watchdog", "on-abort", "always"}
+
+// SystemdOptions configures systemd unit generation
+type SystemdOptions struct {
+	New           bool   `json:"new"`           // create a fresh container on start (portable unit)
+	RestartPolicy string `json:"restartPolicy"` // empty = Podman default (on-failure)
+	StopTimeout   int    `json:"stopTimeout"`   // seconds, 0 = Podman default
+}
+
+// GenerateSystemd returns systemd unit files for a container or pod,
+// keyed by unit name (a pod yields one unit per container plus the pod)
+func (c *Client) GenerateSystemd(ctx context.Context, name string, opts SystemdOptions) (map[string]string, error) {
+	query := url.Values{}
+	query.Set("useName", "true")
+	query.Set("new", strconv.FormatBool(opts.New))
+	if opts.RestartPolicy != "" {
+		query.Set("restartPolicy", opts.RestartPolicy)
+	}
+	if opts.StopTimeout > 0 {
+		query.Set("stopTimeout", strconv.Itoa(opts.StopTimeout))
+	}
+
+	var units map[string]string
+	path := fmt.Sprintf("/v4.0.0/libpod/generate/%s/systemd?%s", url.PathEscape(name), query.Encode())
+	if err := c.get(ctx, path, &units); err != nil {
+		return nil, err
+	}
+	return units, nil
+}
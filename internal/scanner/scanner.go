@@ -0,0 +1,233 @@
+// Package scanner runs an external vulnerability scanner (trivy or grype)
+// against container images and parses its JSON report.
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotInstalled is returned when no supported scanner binary is found
+var ErrNotInstalled = errors.New("scanner not installed (install trivy or grype, or set PODMANVIEW_IMAGE_SCANNER)")
+
+// Kind identifies the scanner output format
+type Kind string
+
+const (
+	KindTrivy Kind = "trivy"
+	KindGrype Kind = "grype"
+)
+
+// Severity levels in report order
+var severities = []string{"CRITICAL", "HIGH", "MEDIUM", "LOW", "UNKNOWN"}
+
+// Vulnerability is a single finding
+type Vulnerability struct {
+	ID               string `json:"id"`
+	Package          string `json:"package"`
+	InstalledVersion string `json:"installedVersion"`
+	FixedVersion     string `json:"fixedVersion,omitempty"`
+	Severity         string `json:"severity"`
+	Title            string `json:"title,omitempty"`
+}
+
+// Result is a parsed scan report
+type Result struct {
+	Scanner         Kind            `json:"scanner"`
+	Image           string          `json:"image"`
+	Summary         map[string]int  `json:"summary"` // count per severity
+	Total           int             `json:"total"`
+	Vulnerabilities []Vulnerability `json:"vulnerabilities"`
+}
+
+// Scanner runs a configured scanner command
+type Scanner struct {
+	path string
+	kind Kind
+}
+
+// New resolves the scanner command. An empty command auto-detects
+// trivy, then grype, in PATH. Returns ErrNotInstalled if none is found.
+func New(command string) (*Scanner, error) {
+	candidates := []string{string(KindTrivy), string(KindGrype)}
+	if command != "" {
+		candidates = []string{command}
+	}
+
+	for _, candidate := range candidates {
+		path, err := exec.LookPath(candidate)
+		if err != nil {
+			continue
+		}
+
+		kind, err := detectKind(path)
+		if err != nil {
+			return nil, err
+		}
+		return &Scanner{path: path, kind: kind}, nil
+	}
+
+	return nil, ErrNotInstalled
+}
+
+// detectKind infers the output format from the binary name
+func detectKind(path string) (Kind, error) {
+	name := strings.ToLower(filepath.Base(path))
+	switch {
+	case strings.Contains(name, string(KindTrivy)):
+		return KindTrivy, nil
+	case strings.Contains(name, string(KindGrype)):
+		return KindGrype, nil
+	default:
+		return "", fmt.Errorf("unsupported scanner %q (expected trivy or grype)", name)
+	}
+}
+
+// Kind returns the scanner type
+func (s *Scanner) Kind() Kind {
+	return s.kind
+}
+
+// Scan scans image (name or ID from local Podman storage).
+// Cancel ctx to stop a long-running scan.
+func (s *Scanner) Scan(ctx context.Context, image string) (*Result, error) {
+	var args []string
+	switch s.kind {
+	case KindTrivy:
+		args = []string{"image", "--format", "json", "--quiet", "--image-src", "podman", image}
+	case KindGrype:
+		args = []string{"podman:" + image, "-o", "json", "-q"}
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, s.path, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("scan timed out: %w", ctx.Err())
+		}
+		msg := strings.TrimSpace(stderr.String())
+		if len(msg) > 500 {
+			msg = msg[len(msg)-500:]
+		}
+		return nil, fmt.Errorf("%s failed: %v: %s", s.kind, err, msg)
+	}
+
+	var vulns []Vulnerability
+	var err error
+	switch s.kind {
+	case KindTrivy:
+		vulns, err = parseTrivy(stdout.Bytes())
+	case KindGrype:
+		vulns, err = parseGrype(stdout.Bytes())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s output: %w", s.kind, err)
+	}
+
+	return newResult(s.kind, image, vulns), nil
+}
+
+// newResult builds a result with per-severity counts
+func newResult(kind Kind, image string, vulns []Vulnerability) *Result {
+	summary := make(map[string]int, len(severities))
+	for _, sev := range severities {
+		summary[sev] = 0
+	}
+	for i := range vulns {
+		sev := strings.ToUpper(vulns[i].Severity)
+		if _, ok := summary[sev]; !ok {
+			sev = "UNKNOWN"
+		}
+		vulns[i].Severity = sev
+		summary[sev]++
+	}
+
+	if vulns == nil {
+		vulns = []Vulnerability{}
+	}
+
+	return &Result{
+		Scanner:         kind,
+		Image:           image,
+		Summary:         summary,
+		Total:           len(vulns),
+		Vulnerabilities: vulns,
+	}
+}
+
+// parseTrivy parses `trivy image --format json` output
+func parseTrivy(data []byte) ([]Vulnerability, error) {
+	var report struct {
+		Results []struct {
+			Vulnerabilities []struct {
+				VulnerabilityID  string `json:"VulnerabilityID"`
+				PkgName          string `json:"PkgName"`
+				InstalledVersion string `json:"InstalledVersion"`
+				FixedVersion     string `json:"FixedVersion"`
+				Severity         string `json:"Severity"`
+				Title            string `json:"Title"`
+			} `json:"Vulnerabilities"`
+		} `json:"Results"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, err
+	}
+
+	var vulns []Vulnerability
+	for _, res := range report.Results {
+		for _, v := range res.Vulnerabilities {
+			vulns = append(vulns, Vulnerability{
+				ID:               v.VulnerabilityID,
+				Package:          v.PkgName,
+				InstalledVersion: v.InstalledVersion,
+				FixedVersion:     v.FixedVersion,
+				Severity:         v.Severity,
+				Title:            v.Title,
+			})
+		}
+	}
+	return vulns, nil
+}
+
+// parseGrype parses `grype -o json` output
+func parseGrype(data []byte) ([]Vulnerability, error) {
+	var report struct {
+		Matches []struct {
+			Vulnerability struct {
+				ID       string `json:"id"`
+				Severity string `json:"severity"`
+				Fix      struct {
+					Versions []string `json:"versions"`
+				} `json:"fix"`
+			} `json:"vulnerability"`
+			Artifact struct {
+				Name    string `json:"name"`
+				Version string `json:"version"`
+			} `json:"artifact"`
+		} `json:"matches"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, err
+	}
+
+	var vulns []Vulnerability
+	for _, m := range report.Matches {
+		vulns = append(vulns, Vulnerability{
+			ID:               m.Vulnerability.ID,
+			Package:          m.Artifact.Name,
+			InstalledVersion: m.Artifact.Version,
+			FixedVersion:     strings.Join(m.Vulnerability.Fix.Versions, ", "),
+			Severity:         m.Vulnerability.Severity,
+		})
+	}
+	return vulns, nil
+}
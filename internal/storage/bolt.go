@@ -3,6 +3,7 @@ package storage
 import (
 	"encoding/json"
 	"fmt"
+	"log"
 	"strconv"
 	"time"
 
@@ -46,6 +47,13 @@ func NewBoltStorage(path string) (*BoltStorage, error) {
 		if _, err := tx.CreateBucketIfNotExists([]byte(historyBucket)); err != nil {
 			return fmt.Errorf("failed to create history bucket: %w", err)
 		}
+		dropped, err := dropSharedHistory(tx)
+		if err != nil {
+			return err
+		}
+		if dropped > 0 {
+			log.Printf("Dropped %d command history entries from before per-user history", dropped)
+		}
 		return nil
 	})
 	if err != nil {
@@ -400,19 +408,40 @@ func (s *BoltStorage) DeleteAll(pluginName string) error {
 
 // Command History Methods
 
-// SaveCommandHistory saves a command to history
-func (s *BoltStorage) SaveCommandHistory(command string, timestamp time.Time) error {
+// userHistoryBucket returns the history bucket of username, nested in the
+// history bucket. Without create it returns nil if the user has no history.
+func userHistoryBucket(tx *bbolt.Tx, username string, create bool) (*bbolt.Bucket, error) {
+	root := tx.Bucket([]byte(historyBucket))
+	if root == nil {
+		return nil, fmt.Errorf("history bucket not found")
+	}
+	if username == "" {
+		return nil, fmt.Errorf("username is required")
+	}
+
+	if create {
+		bucket, err := root.CreateBucketIfNotExists([]byte(username))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create history bucket for %s: %w", username, err)
+		}
+		return bucket, nil
+	}
+	return root.Bucket([]byte(username)), nil
+}
+
+// SaveCommandHistory saves a command to username's history
+func (s *BoltStorage) SaveCommandHistory(username, command string, timestamp time.Time) error {
 	// Check if this is a duplicate of the last command
-	lastCmd, err := s.GetLastCommand()
+	lastCmd, err := s.GetLastCommand(username)
 	if err == nil && lastCmd == command {
 		// Skip duplicate consecutive command
 		return nil
 	}
 
 	return s.db.Update(func(tx *bbolt.Tx) error {
-		bucket := tx.Bucket([]byte(historyBucket))
-		if bucket == nil {
-			return fmt.Errorf("history bucket not found")
+		bucket, err := userHistoryBucket(tx, username, true)
+		if err != nil {
+			return err
 		}
 
 		entry := CommandHistoryEntry{
@@ -431,14 +460,14 @@ func (s *BoltStorage) SaveCommandHistory(command string, timestamp time.Time) er
 	})
 }
 
-// GetCommandHistory returns the last N commands from history
-func (s *BoltStorage) GetCommandHistory(limit int) ([]CommandHistoryEntry, error) {
+// GetCommandHistory returns the last N commands from username's history
+func (s *BoltStorage) GetCommandHistory(username string, limit int) ([]CommandHistoryEntry, error) {
 	var entries []CommandHistoryEntry
 
 	err := s.db.View(func(tx *bbolt.Tx) error {
-		bucket := tx.Bucket([]byte(historyBucket))
-		if bucket == nil {
-			return fmt.Errorf("history bucket not found")
+		bucket, err := userHistoryBucket(tx, username, false)
+		if err != nil || bucket == nil {
+			return err
 		}
 
 		// Collect all entries first
@@ -466,14 +495,14 @@ func (s *BoltStorage) GetCommandHistory(limit int) ([]CommandHistoryEntry, error
 	return entries, err
 }
 
-// GetLastCommand returns the most recent command from history
-func (s *BoltStorage) GetLastCommand() (string, error) {
+// GetLastCommand returns the most recent command from username's history
+func (s *BoltStorage) GetLastCommand(username string) (string, error) {
 	var lastCommand string
 
 	err := s.db.View(func(tx *bbolt.Tx) error {
-		bucket := tx.Bucket([]byte(historyBucket))
-		if bucket == nil {
-			return fmt.Errorf("history bucket not found")
+		bucket, err := userHistoryBucket(tx, username, false)
+		if err != nil || bucket == nil {
+			return err
 		}
 
 		// Get the last entry (bucket is sorted by key)
@@ -497,12 +526,12 @@ func (s *BoltStorage) GetLastCommand() (string, error) {
 	return lastCommand, err
 }
 
-// TrimCommandHistory keeps only the last maxCommands in history
-func (s *BoltStorage) TrimCommandHistory(maxCommands int) error {
+// TrimCommandHistory keeps only the last maxCommands in username's history
+func (s *BoltStorage) TrimCommandHistory(username string, maxCommands int) error {
 	return s.db.Update(func(tx *bbolt.Tx) error {
-		bucket := tx.Bucket([]byte(historyBucket))
-		if bucket == nil {
-			return fmt.Errorf("history bucket not found")
+		bucket, err := userHistoryBucket(tx, username, false)
+		if err != nil || bucket == nil {
+			return err
 		}
 
 		// Count total entries
@@ -531,6 +560,64 @@ func (s *BoltStorage) TrimCommandHistory(maxCommands int) error {
 	})
 }
 
+// DeleteCommandHistory removes the entry at index (0 = oldest) from username's history
+func (s *BoltStorage) DeleteCommandHistory(username string, index int) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		bucket, err := userHistoryBucket(tx, username, false)
+		if err != nil {
+			return err
+		}
+		if bucket == nil {
+			return ErrNotFound
+		}
+
+		i := 0
+		cursor := bucket.Cursor()
+		for k, _ := cursor.First(); k != nil; k, _ = cursor.Next() {
+			if i == index {
+				return bucket.Delete(k)
+			}
+			i++
+		}
+
+		return ErrNotFound
+	})
+}
+
+// ClearCommandHistory removes all commands from username's history
+func (s *BoltStorage) ClearCommandHistory(username string) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		bucket, err := userHistoryBucket(tx, username, false)
+		if err != nil || bucket == nil {
+			return err
+		}
+		return tx.Bucket([]byte(historyBucket)).DeleteBucket([]byte(username))
+	})
+}
+
+// dropSharedHistory removes entries from before history was kept per user.
+// They can't be attributed to a user, so they are dropped rather than
+// shown to everyone. Returns the number of entries removed.
+func dropSharedHistory(tx *bbolt.Tx) (int, error) {
+	root := tx.Bucket([]byte(historyBucket))
+
+	// Plain keys are legacy entries; nested buckets (nil values) are users
+	var keys [][]byte
+	cursor := root.Cursor()
+	for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
+		if v != nil {
+			keys = append(keys, append([]byte(nil), k...))
+		}
+	}
+
+	for _, k := range keys {
+		if err := root.Delete(k); err != nil {
+			return 0, fmt.Errorf("failed to delete shared history entry: %w", err)
+		}
+	}
+	return len(keys), nil
+}
+
 // Close closes the storage
 func (s *BoltStorage) Close() error {
 	return s.db.Close()
@@ -94,22 +94,30 @@ type Storage interface {
 	DeleteAll(pluginName string) error
 
 	// Command History Methods
+	// History is kept per user; username must not be empty
 
-	// SaveCommandHistory saves a command to history
+	// SaveCommandHistory saves a command to username's history
 	// Automatically prevents duplicate consecutive commands
-	SaveCommandHistory(command string, timestamp time.Time) error
+	SaveCommandHistory(username, command string, timestamp time.Time) error
 
-	// GetCommandHistory returns the last N commands from history
+	// GetCommandHistory returns the last N commands from username's history
 	// Returns up to limit commands, ordered from oldest to newest
-	GetCommandHistory(limit int) ([]CommandHistoryEntry, error)
+	GetCommandHistory(username string, limit int) ([]CommandHistoryEntry, error)
 
-	// GetLastCommand returns the most recent command from history
+	// GetLastCommand returns the most recent command from username's history
 	// Returns empty string if no history exists
-	GetLastCommand() (string, error)
+	GetLastCommand(username string) (string, error)
 
-	// TrimCommandHistory keeps only the last maxCommands in history
+	// TrimCommandHistory keeps only the last maxCommands in username's history
 	// Older commands are automatically removed
-	TrimCommandHistory(maxCommands int) error
+	TrimCommandHistory(username string, maxCommands int) error
+
+	// DeleteCommandHistory removes a single command by index
+	// Index 0 is the oldest command; returns ErrNotFound if out of range
+	DeleteCommandHistory(username string, index int) error
+
+	// ClearCommandHistory removes all commands from username's history
+	ClearCommandHistory(username string) error
 
 	// Lifecycle Methods
 
@@ -0,0 +1,249 @@
+package termfilter
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Keys the Guard interprets (readline emacs bindings)
+const (
+	keyCtrlA     = 0x01 // beginning of line
+	keyCtrlB     = 0x02 // back one character
+	keyCtrlC     = 0x03 // interrupt, discards the line
+	keyCtrlD     = 0x04 // delete character (EOF on an empty line)
+	keyCtrlE     = 0x05 // end of line
+	keyCtrlF     = 0x06 // forward one character
+	keyBackspace = 0x08
+	keyCtrlK     = 0x0b // kill to end of line
+	keyEnter     = 0x0d
+	keyNewline   = 0x0a
+	keyCtrlU     = 0x15 // kill to beginning of line
+	keyCtrlW     = 0x17 // kill previous word
+	keyEscape    = 0x1b
+	keyDelete    = 0x7f
+)
+
+// Blocked describes a submitted line the filter rejected
+type Blocked struct {
+	Line string
+	Err  error
+}
+
+// Guard applies a Filter to the keystrokes of one terminal session.
+// It follows line editing (cursor movement, deletion, bracketed paste) and
+// checks the line when Enter is pressed. Lines it can't follow are blocked.
+// A line continued with a trailing backslash is checked together with the
+// lines that follow it; a line ending inside a quote is blocked.
+type Guard struct {
+	filter *Filter
+
+	pending   string // earlier lines of a command continued with a backslash
+	line      []rune
+	cursor    int
+	untracked bool   // line was edited in a way that can't be followed
+	paste     bool   // inside a bracketed paste
+	esc       []byte // pending escape sequence
+	partial   []byte // pending incomplete UTF-8 sequence
+}
+
+// NewGuard creates a Guard for a terminal session
+func (f *Filter) NewGuard() *Guard {
+	return &Guard{filter: f}
+}
+
+// Input processes keystrokes and returns the bytes to forward to the PTY,
+// along with any lines that were blocked. A blocked Enter is replaced
+// with Ctrl-C so the shell discards the line instead of running it.
+func (g *Guard) Input(data []byte) ([]byte, []Blocked) {
+	out := make([]byte, 0, len(data))
+	var blocked []Blocked
+
+	for _, b := range data {
+		if g.esc != nil {
+			g.esc = append(g.esc, b)
+			if g.escapeDone() {
+				g.handleEscape(string(g.esc[1:]))
+				g.esc = nil
+			}
+			out = append(out, b)
+			continue
+		}
+
+		if len(g.partial) > 0 || b >= utf8.RuneSelf {
+			g.partial = append(g.partial, b)
+			if utf8.FullRune(g.partial) {
+				r, _ := utf8.DecodeRune(g.partial)
+				g.insert(r)
+				g.partial = g.partial[:0]
+			}
+			out = append(out, b)
+			continue
+		}
+
+		if (b == keyEnter || b == keyNewline) && !g.paste {
+			if err := g.submit(); err != nil {
+				blocked = append(blocked, Blocked{Line: g.pending + string(g.line), Err: err})
+				out = append(out, keyCtrlC)
+				g.reset()
+			} else {
+				out = append(out, b)
+				g.newLine()
+			}
+			continue
+		}
+
+		g.handleByte(b)
+		out = append(out, b)
+	}
+
+	return out, blocked
+}
+
+// submit checks the current line. A line ending in a backslash is kept
+// in pending and checked once the command is complete.
+func (g *Guard) submit() error {
+	if g.untracked {
+		return ErrUntracked
+	}
+
+	text := g.pending + string(g.line)
+	_, end, err := splitCommands(text)
+	switch {
+	case err != nil:
+		return err
+	case end == endQuoted:
+		return ErrUnterminated
+	case end == endContinued:
+		g.pending = text + "\n"
+		return nil
+	}
+
+	if err := g.filter.Check(text); err != nil {
+		return err
+	}
+	g.pending = ""
+	return nil
+}
+
+// newLine starts a new empty line, keeping a pending continuation
+func (g *Guard) newLine() {
+	g.line = g.line[:0]
+	g.cursor = 0
+	g.untracked = false
+}
+
+// reset discards the line and any pending continuation, as the shell
+// does on Ctrl-C
+func (g *Guard) reset() {
+	g.newLine()
+	g.pending = ""
+}
+
+// handleByte applies a single-byte key to the line
+func (g *Guard) handleByte(b byte) {
+	switch {
+	case b == keyEscape:
+		g.esc = []byte{b}
+	case g.paste && (b == keyEnter || b == keyNewline):
+		g.insert('\n')
+	case g.paste && b == '\t':
+		g.insert('\t')
+	case b == keyCtrlC:
+		g.reset()
+	case b == keyBackspace || b == keyDelete:
+		if g.cursor > 0 {
+			g.line = append(g.line[:g.cursor-1], g.line[g.cursor:]...)
+			g.cursor--
+		}
+	case b == keyCtrlD:
+		if g.cursor < len(g.line) {
+			g.line = append(g.line[:g.cursor], g.line[g.cursor+1:]...)
+		}
+	case b == keyCtrlA:
+		g.cursor = 0
+	case b == keyCtrlE:
+		g.cursor = len(g.line)
+	case b == keyCtrlB:
+		if g.cursor > 0 {
+			g.cursor--
+		}
+	case b == keyCtrlF:
+		if g.cursor < len(g.line) {
+			g.cursor++
+		}
+	case b == keyCtrlK:
+		g.line = g.line[:g.cursor]
+	case b == keyCtrlU:
+		g.line = append(g.line[:0], g.line[g.cursor:]...)
+		g.cursor = 0
+	case b == keyCtrlW:
+		start := g.cursor
+		for start > 0 && unicode.IsSpace(g.line[start-1]) {
+			start--
+		}
+		for start > 0 && !unicode.IsSpace(g.line[start-1]) {
+			start--
+		}
+		g.line = append(g.line[:start], g.line[g.cursor:]...)
+		g.cursor = start
+	case b < 0x20:
+		// Tab completion, history search, yank, ...
+		g.untracked = true
+	default:
+		g.insert(rune(b))
+	}
+}
+
+// insert adds r at the cursor
+func (g *Guard) insert(r rune) {
+	g.line = append(g.line, 0)
+	copy(g.line[g.cursor+1:], g.line[g.cursor:])
+	g.line[g.cursor] = r
+	g.cursor++
+}
+
+// escapeDone reports whether the pending escape sequence is complete:
+// CSI (ESC [ params final), SS3 (ESC O x) or a two-byte meta key
+func (g *Guard) escapeDone() bool {
+	if len(g.esc) < 2 {
+		return false
+	}
+	switch g.esc[1] {
+	case '[':
+		last := g.esc[len(g.esc)-1]
+		return len(g.esc) > 2 && last >= 0x40 && last <= 0x7e
+	case 'O':
+		return len(g.esc) == 3
+	default:
+		return true
+	}
+}
+
+// handleEscape applies a complete escape sequence (without the ESC)
+func (g *Guard) handleEscape(seq string) {
+	switch seq {
+	case "[200~":
+		g.paste = true
+	case "[201~":
+		g.paste = false
+	case "[C", "OC":
+		if g.cursor < len(g.line) {
+			g.cursor++
+		}
+	case "[D", "OD":
+		if g.cursor > 0 {
+			g.cursor--
+		}
+	case "[H", "OH", "[1~", "[7~":
+		g.cursor = 0
+	case "[F", "OF", "[4~", "[8~":
+		g.cursor = len(g.line)
+	case "[3~":
+		if g.cursor < len(g.line) {
+			g.line = append(g.line[:g.cursor], g.line[g.cursor+1:]...)
+		}
+	default:
+		// History (up/down), word movement, meta keys, ...
+		g.untracked = true
+	}
+}
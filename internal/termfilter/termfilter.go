@@ -0,0 +1,533 @@
+// Package termfilter restricts the command lines that can be submitted in
+// the host terminal. The PTY only receives raw keystrokes, so a Guard
+// reconstructs the line being edited and checks it when Enter is pressed.
+package termfilter
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// ErrUntracked is returned for lines edited in a way the Guard can't follow
+// (history recall, tab completion, reverse search)
+var ErrUntracked = errors.New("line was edited with history or completion and can't be checked, type it out in full")
+
+// ErrSubstitution is returned for lines with command or process substitution,
+// which could hide a command from the filter
+var ErrSubstitution = errors.New("command substitution is not allowed")
+
+// ErrIndirect is returned for commands the filter can't see: a command
+// name built from variables, quotes or globs, an alias, function or trap,
+// or one run by eval, xargs or a shell reading -c or standard input
+var ErrIndirect = errors.New("commands must be typed literally, not through variables, quotes, globs, aliases, functions, traps, eval, xargs or a shell")
+
+// ErrUnterminated is returned for lines that end inside a quote. The shell
+// would keep reading the quoted string on the next line, where the filter
+// can no longer tell code from data.
+var ErrUnterminated = errors.New("unterminated quote, close it on the same line")
+
+// ErrAssignment is returned for variables set on their own, which outlive
+// the line, and for variables that make the shell run code or change what
+// a command name means (PROMPT_COMMAND, PS1, BASH_ENV, IFS, PATH, ...)
+var ErrAssignment = errors.New("shell variables can only be set for the command that follows (FOO=bar cmd), and never the prompt, IFS, PATH or startup variables")
+
+// shellVariables are variables whose value the shell runs as code or uses
+// to find and split commands
+var shellVariables = map[string]bool{
+	"BASH_ENV":        true,
+	"ENV":             true,
+	"IFS":             true,
+	"LD_LIBRARY_PATH": true,
+	"LD_PRELOAD":      true,
+	"PATH":            true,
+	"PROMPT_COMMAND":  true,
+	"PS0":             true,
+	"PS1":             true,
+	"PS2":             true,
+	"PS3":             true,
+	"PS4":             true,
+	"SHELLOPTS":       true,
+}
+
+// wrapper describes a command that runs its arguments as another command
+type wrapper struct {
+	values   string   // short options that take a separate value (sudo -u root)
+	long     []string // long options that take a separate value (sudo --user root)
+	indirect string   // short options that run a shell or a string (sudo -s, env -S)
+	operands int      // operands before the command (timeout's duration)
+}
+
+// wrappers are commands that run their arguments as another command
+var wrappers = map[string]wrapper{
+	"builtin": {},
+	"command": {},
+	"doas":    {values: "Cu", indirect: "s"},
+	"env":     {values: "Cu", long: []string{"chdir", "unset"}, indirect: "S"},
+	"exec":    {values: "a"},
+	"nice":    {values: "n", long: []string{"adjustment"}},
+	"nohup":   {},
+	"setsid":  {},
+	"sudo": {
+		values:   "CDghpRrTtUu",
+		long:     []string{"chdir", "chroot", "close-from", "command-timeout", "group", "host", "other-user", "prompt", "role", "type", "user"},
+		indirect: "is",
+	},
+	"time":    {values: "fo", long: []string{"format", "output"}},
+	"timeout": {values: "ks", long: []string{"kill-after", "signal"}, operands: 1},
+	"xargs": {
+		values: "adEILnPs",
+		long:   []string{"arg-file", "delimiter", "max-args", "max-chars", "max-procs", "process-slot-var"},
+	},
+}
+
+// indirectLong are long wrapper options that run a shell or a string
+var indirectLong = map[string]bool{
+	"--login":        true,
+	"--shell":        true,
+	"--split-string": true,
+}
+
+// evaluators are commands that run a string as shell code, now or on an
+// event (trap, bind -x, complete -C), give another name to a command, or
+// set variables such as PROMPT_COMMAND with attributes
+var evaluators = map[string]bool{
+	".":        true,
+	"alias":    true,
+	"bind":     true,
+	"complete": true,
+	"declare":  true,
+	"enable":   true,
+	"eval":     true,
+	"export":   true,
+	"function": true,
+	"local":    true,
+	"readonly": true,
+	"source":   true,
+	"su":       true,
+	"trap":     true,
+	"typeset":  true,
+}
+
+// shells run a string as shell code with -c, or commands read from
+// standard input when no script is given
+var shells = map[string]bool{
+	"ash":  true,
+	"bash": true,
+	"dash": true,
+	"ksh":  true,
+	"sh":   true,
+	"zsh":  true,
+}
+
+// Filter checks command lines against allowed and denied command prefixes.
+// A prefix matches whole words: "podman" matches "podman ps" but not "podmanview",
+// "rm -rf" matches "rm -rf /tmp" but not "rm -r".
+type Filter struct {
+	allow [][]string
+	deny  [][]string
+}
+
+// New creates a filter from allowed and denied command prefixes.
+// An empty allow list allows everything not denied.
+// Returns nil (no filtering) if both lists are empty.
+func New(allow, deny []string) *Filter {
+	f := &Filter{
+		allow: parsePrefixes(allow),
+		deny:  parsePrefixes(deny),
+	}
+	if len(f.allow) == 0 && len(f.deny) == 0 {
+		return nil
+	}
+	return f
+}
+
+// Active reports whether the filter restricts anything (false for nil)
+func (f *Filter) Active() bool {
+	return f != nil
+}
+
+// Allowed returns the allowed command prefixes
+func (f *Filter) Allowed() []string {
+	if f == nil {
+		return nil
+	}
+	return joinPrefixes(f.allow)
+}
+
+// Denied returns the denied command prefixes
+func (f *Filter) Denied() []string {
+	if f == nil {
+		return nil
+	}
+	return joinPrefixes(f.deny)
+}
+
+// Check returns an error if line may not be run.
+// Every command in a list or pipeline (;, &&, ||, |, &, newlines) is checked.
+func (f *Filter) Check(line string) error {
+	if f == nil {
+		return nil
+	}
+
+	commands, end, err := splitCommands(line)
+	if err != nil {
+		return err
+	}
+	if end == endQuoted {
+		return ErrUnterminated
+	}
+
+	for _, command := range commands {
+		words, err := commandWords(command)
+		if err != nil {
+			return err
+		}
+		if len(words) == 0 {
+			continue
+		}
+
+		for _, prefix := range f.deny {
+			if hasPrefix(words, prefix) {
+				return fmt.Errorf("%q is denied", strings.Join(prefix, " "))
+			}
+		}
+
+		if len(f.allow) == 0 {
+			continue
+		}
+		allowed := false
+		for _, prefix := range f.allow {
+			if hasPrefix(words, prefix) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("%q is not an allowed command", strings.Join(words, " "))
+		}
+	}
+
+	return nil
+}
+
+// parsePrefixes splits each prefix into words, skipping empty ones
+func parsePrefixes(prefixes []string) [][]string {
+	var result [][]string
+	for _, p := range prefixes {
+		if words := strings.Fields(p); len(words) > 0 {
+			result = append(result, words)
+		}
+	}
+	return result
+}
+
+// joinPrefixes is the inverse of parsePrefixes
+func joinPrefixes(prefixes [][]string) []string {
+	result := make([]string, len(prefixes))
+	for i, p := range prefixes {
+		result[i] = strings.Join(p, " ")
+	}
+	return result
+}
+
+// hasPrefix reports whether words start with all words of prefix
+func hasPrefix(words, prefix []string) bool {
+	if len(words) < len(prefix) {
+		return false
+	}
+	for i, p := range prefix {
+		if words[i] != p {
+			return false
+		}
+	}
+	return true
+}
+
+// lineEnd is the state a line ends in
+type lineEnd int
+
+const (
+	endComplete  lineEnd = iota
+	endQuoted            // inside an unterminated quote
+	endContinued         // after a backslash, continued on the next line
+)
+
+// splitCommands splits a line into simple commands on shell control
+// operators outside quotes, joining backslash-newline continuations.
+// Fails on command/process substitution and function definitions.
+func splitCommands(line string) ([]string, lineEnd, error) {
+	var commands []string
+	var current strings.Builder
+	var quote rune // ', " or $ for ANSI-C $'...'
+	escaped := false
+
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		prev, next := rune(0), rune(0)
+		if i > 0 {
+			prev = runes[i-1]
+		}
+		if i+1 < len(runes) {
+			next = runes[i+1]
+		}
+
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && next == '\n' && quote != '\'':
+			// Line continuation: the shell drops both
+			i++
+			continue
+		case r == '\\' && quote != '\'':
+			escaped = true
+		case quote == '\'' || quote == '$':
+			if r == '\'' {
+				quote = 0
+			}
+		case r == '`', r == '$' && next == '(':
+			return nil, endComplete, ErrSubstitution
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			}
+		case r == '$' && next == '\'':
+			quote = '$'
+			current.WriteRune(r)
+			r = next
+			i++
+		case r == '\'' || r == '"':
+			quote = r
+		case (r == '<' || r == '>') && next == '(':
+			return nil, endComplete, ErrSubstitution
+		case r == '(' && isFunctionParens(runes[i+1:]):
+			return nil, endComplete, ErrIndirect
+		case r == '&' && (prev == '<' || prev == '>' || next == '>'), r == '|' && prev == '>':
+			// Redirection (2>&1, &>file, >|file), not a control operator
+		case r == '{' && isBoundary(prev) && (next == 0 || next == ' ' || next == '\t' || next == '\n'),
+			r == '}' && isBoundary(prev) && isBoundary(next):
+			// Group braces are reserved words; elsewhere braces are
+			// literal or brace expansion ({r,}m), part of the word
+			commands = append(commands, current.String())
+			current.Reset()
+			continue
+		case r == ';' || r == '&' || r == '|' || r == '\n' || r == '(' || r == ')':
+			commands = append(commands, current.String())
+			current.Reset()
+			continue
+		}
+
+		current.WriteRune(r)
+	}
+
+	end := endComplete
+	switch {
+	case quote != 0:
+		end = endQuoted
+	case escaped:
+		end = endContinued
+	}
+	return append(commands, current.String()), end, nil
+}
+
+// isBoundary reports whether r ends a word: whitespace, an operator or
+// the start or end of the line (0)
+func isBoundary(r rune) bool {
+	return r == 0 || strings.ContainsRune(" \t\n;&|()", r)
+}
+
+// isFunctionParens reports whether the text after an opening parenthesis
+// closes it right away, as in a function definition (name() { ...; })
+func isFunctionParens(rest []rune) bool {
+	for _, r := range rest {
+		if r == ')' {
+			return true
+		}
+		if r != ' ' && r != '\t' {
+			return false
+		}
+	}
+	return false
+}
+
+// commandWords returns the words of a simple command with quotes removed,
+// leading variable assignments, redirections and wrappers (sudo, env, ...)
+// skipped, and the command name reduced to its base name (/bin/rm -> rm).
+// Fails with ErrIndirect if the command name isn't typed literally, and
+// with ErrAssignment for assignments without a command or to shellVariables.
+func commandWords(command string) ([]string, error) {
+	unquote := strings.NewReplacer(`'`, "", `"`, "", `\`, "")
+
+	raw := strings.Fields(command)
+	words := make([]string, len(raw))
+	for i, w := range raw {
+		words[i] = unquote.Replace(w)
+	}
+
+	xargs := false    // the command's arguments come from xargs input
+	assigned := false // variables set ahead of the command
+	for len(words) > 0 {
+		w := words[0]
+		switch {
+		case w == "!":
+			words, raw = words[1:], raw[1:]
+		case isAssignment(w):
+			name, _, _ := strings.Cut(w, "=")
+			if shellVariables[strings.TrimSuffix(name, "+")] {
+				return nil, ErrAssignment
+			}
+			assigned = true
+			words, raw = words[1:], raw[1:]
+		case isRedirection(w):
+			n := redirectionWords(w)
+			words, raw = words[min(n, len(words)):], raw[min(n, len(raw)):]
+		case strings.ContainsAny(raw[0], "$'\"\\"),
+			strings.ContainsAny(raw[0], "*?[{~") && raw[0] != "[" && raw[0] != "[[":
+			// The name the shell runs is only known after expansion,
+			// of variables and quotes or of globs, braces and ~ (r?, {r,}m)
+			return nil, ErrIndirect
+		case hasWrapper(w):
+			name := path.Base(w)
+			if name == "xargs" {
+				xargs = true
+			}
+			rest, err := skipWrapper(wrappers[name], words[1:])
+			if err != nil {
+				return nil, err
+			}
+			// rest is a suffix of words, keep raw aligned with it
+			raw = raw[len(raw)-len(rest):]
+			words = rest
+			if len(words) == 0 && xargs && name != "xargs" {
+				// xargs env, xargs sudo: the command comes from the input
+				return nil, ErrIndirect
+			}
+		default:
+			words[0] = path.Base(w)
+			if evaluators[words[0]] || shells[words[0]] && shellReadsCode(words[1:]) {
+				return nil, ErrIndirect
+			}
+			return words, nil
+		}
+	}
+
+	if assigned {
+		// Without a command the variables stay set in the shell
+		return nil, ErrAssignment
+	}
+	return nil, nil
+}
+
+// hasWrapper reports whether w names a wrapper command
+func hasWrapper(w string) bool {
+	_, ok := wrappers[path.Base(w)]
+	return ok
+}
+
+// skipWrapper skips the options and leading operands of a wrapper and
+// returns the words of the command it runs
+func skipWrapper(spec wrapper, words []string) ([]string, error) {
+	for len(words) > 0 && strings.HasPrefix(words[0], "-") {
+		opt := words[0]
+		words = words[1:]
+
+		if opt == "--" {
+			break
+		}
+		if strings.HasPrefix(opt, "--") {
+			name, _, attached := strings.Cut(opt, "=")
+			if indirectLong[name] {
+				return nil, ErrIndirect
+			}
+			if !attached && slices.Contains(spec.long, name[2:]) && len(words) > 0 {
+				words = words[1:]
+			}
+			continue
+		}
+
+		for i, c := range opt[1:] {
+			if strings.ContainsRune(spec.indirect, c) {
+				return nil, ErrIndirect
+			}
+			if strings.ContainsRune(spec.values, c) {
+				// The value is the rest of the word or the next word
+				if i == len(opt)-2 && len(words) > 0 {
+					words = words[1:]
+				}
+				break
+			}
+		}
+	}
+
+	if len(words) < spec.operands {
+		return nil, nil
+	}
+	return words[spec.operands:], nil
+}
+
+// shellReadsCode reports whether shell arguments run code the filter can't
+// see: -c (alone or combined, -ec), -s, or no script operand at all, in
+// which case the shell reads commands from standard input
+func shellReadsCode(args []string) bool {
+	options := true
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		switch {
+		case isRedirection(a):
+			i += redirectionWords(a) - 1
+		case options && (a == "--" || a == "-"):
+			options = false
+		case options && (a == "--version" || a == "--help"):
+			return false
+		case options && strings.HasPrefix(a, "--"):
+			if a == "--rcfile" || a == "--init-file" {
+				i++
+			}
+		case options && (strings.HasPrefix(a, "-") || strings.HasPrefix(a, "+")):
+			if strings.ContainsAny(a[1:], "cs") {
+				return true
+			}
+			if strings.HasSuffix(a, "o") || strings.HasSuffix(a, "O") {
+				// -o pipefail: the option name is the next word
+				i++
+			}
+		default:
+			// Script file
+			return false
+		}
+	}
+	return true
+}
+
+// isRedirection reports whether w is a redirection (>file, 2>&1, <<<word)
+func isRedirection(w string) bool {
+	rest := strings.TrimLeft(w, "0123456789")
+	return strings.HasPrefix(rest, "<") || strings.HasPrefix(rest, ">") || strings.HasPrefix(rest, "&>")
+}
+
+// redirectionWords returns the number of words a redirection takes:
+// 1 if the target is attached (>file), 2 if it is the next word (> file)
+func redirectionWords(w string) int {
+	if strings.Trim(strings.TrimLeft(w, "0123456789"), "<>&|") == "" {
+		return 2
+	}
+	return 1
+}
+
+// isAssignment reports whether w is a variable assignment (FOO=bar, FOO+=bar)
+func isAssignment(w string) bool {
+	name, _, ok := strings.Cut(w, "=")
+	name = strings.TrimSuffix(name, "+")
+	if !ok || name == "" {
+		return false
+	}
+	for i, r := range name {
+		if r != '_' && (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (i == 0 || r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
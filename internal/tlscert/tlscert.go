@@ -0,0 +1,126 @@
+// Package tlscert generates self-signed TLS certificates for first-run HTTPS.
+package tlscert
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// validity of generated certificates (browsers reject longer leaf lifetimes)
+const validity = 825 * 24 * time.Hour
+
+// EnsureSelfSigned creates a self-signed certificate and key for hosts
+// (DNS names or IPs) unless both files already exist. A certificate that
+// older versions generated as a CA is replaced.
+// Returns true if a new certificate was generated.
+func EnsureSelfSigned(certFile, keyFile string, hosts []string) (bool, error) {
+	if fileExists(certFile) && fileExists(keyFile) && !isGeneratedCA(certFile) {
+		return false, nil
+	}
+
+	certPEM, keyPEM, err := Generate(hosts)
+	if err != nil {
+		return false, err
+	}
+
+	// Key first with owner-only permissions
+	if err := os.WriteFile(keyFile, keyPEM, 0600); err != nil {
+		return false, fmt.Errorf("failed to write TLS key: %w", err)
+	}
+	if err := os.WriteFile(certFile, certPEM, 0644); err != nil {
+		return false, fmt.Errorf("failed to write TLS certificate: %w", err)
+	}
+
+	return true, nil
+}
+
+// Generate returns a PEM-encoded self-signed certificate and ECDSA P-256 key
+func Generate(hosts []string) (certPEM, keyPEM []byte, err error) {
+	if len(hosts) == 0 {
+		return nil, nil, errors.New("at least one host is required")
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// A server certificate only, not a CA: users trust it to silence the
+	// browser warning, and its key lives on this host
+	now := time.Now()
+	template := x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"PodmanView"}, CommonName: hosts[0]},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(validity),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  false,
+	}
+
+	for _, host := range hosts {
+		// Zones (fe80::1%eth0) can't be part of a certificate
+		host, _, _ = strings.Cut(host, "%")
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else if host != "" {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return certPEM, keyPEM, nil
+}
+
+// isGeneratedCA reports whether certFile holds a certificate generated by
+// an older version, which was a CA
+func isGeneratedCA(certFile string) bool {
+	data, err := os.ReadFile(certFile)
+	if err != nil {
+		return false
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return false
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return false
+	}
+	return cert.IsCA && cert.Subject.String() == cert.Issuer.String() &&
+		len(cert.Subject.Organization) == 1 && cert.Subject.Organization[0] == "PodmanView"
+}
+
+// fileExists reports whether path exists
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -3,12 +3,15 @@ package updater
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -348,9 +351,72 @@ func (u *Updater) installUpdate(extractDir string) error {
 	return nil
 }
 
-// RestartService restarts the podmanview systemd service
+// RestartService restarts the systemd service PodmanView runs as
 func RestartService() error {
-	return exec.Command("systemctl", "restart", "podmanview").Run()
+	unit, user := ServiceUnit()
+	if unit == "" {
+		return errors.New("not running as a systemd service")
+	}
+	args := []string{"restart", unit}
+	if user {
+		args = append([]string{"--user"}, args...)
+	}
+	return exec.Command("systemctl", args...).Run()
+}
+
+// UnderSystemd reports whether PodmanView runs as a systemd service that
+// RestartService can restart
+func UnderSystemd() bool {
+	unit, _ := ServiceUnit()
+	return os.Getenv("INVOCATION_ID") != "" && unit != ""
+}
+
+// ServiceUnit returns the systemd service PodmanView runs as, read from
+// /proc/self/cgroup, and whether it's a user service. Empty when it was
+// started some other way, e.g. from a shell or by another service.
+func ServiceUnit() (unit string, user bool) {
+	data, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return "", false
+	}
+	unit, user = ParseServiceUnit(string(data))
+	if unit == "" {
+		return "", false
+	}
+
+	// Restarting the unit only restarts PodmanView if it's the main process
+	args := []string{"show", "--property=MainPID", "--value", unit}
+	if user {
+		args = append([]string{"--user"}, args...)
+	}
+	out, err := exec.Command("systemctl", args...).Output()
+	if err != nil || strings.TrimSpace(string(out)) != strconv.Itoa(os.Getpid()) {
+		return "", false
+	}
+	return unit, user
+}
+
+// ParseServiceUnit returns the service in the systemd cgroup path of a
+// /proc/<pid>/cgroup file (the cgroup v2 "0::" line, or "name=systemd"
+// on cgroup v1) and whether it runs under a user manager (user@.service)
+func ParseServiceUnit(cgroup string) (unit string, user bool) {
+	for _, line := range strings.Split(cgroup, "\n") {
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 || !(parts[0] == "0" && parts[1] == "") && parts[1] != "name=systemd" {
+			continue
+		}
+		for _, segment := range strings.Split(parts[2], "/") {
+			switch {
+			case strings.HasPrefix(segment, "user@") && strings.HasSuffix(segment, ".service"):
+				// The user manager, its services are below it
+				user = true
+			case strings.HasSuffix(segment, ".service"):
+				unit = segment
+			}
+		}
+		return unit, user && unit != ""
+	}
+	return "", false
 }
 
 // GetCurrentVersion returns the current version
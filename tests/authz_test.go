@@ -0,0 +1,165 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"podmanview/internal/api"
+	"podmanview/internal/auth"
+	"podmanview/internal/plugins"
+	"podmanview/internal/plugins/demo"
+	"podmanview/internal/plugins/temperature"
+)
+
+func TestRouteRequiredRoles(t *testing.T) {
+	cfg := newTestConfig(t)
+
+	server := api.NewServer(nil, cfg, "test", "test")
+	token := sessionToken(t, cfg, testReader)
+
+	// Routes that require admin - a read-only user must get 403
+	// before the handler runs (handlers would panic on nil client)
+	adminRoutes := []struct {
+		method string
+		path   string
+	}{
+		{http.MethodPost, "/api/containers"},
+		{http.MethodPost, "/api/containers/abc/start"},
+		{http.MethodPost, "/api/containers/abc/stop"},
+		{http.MethodPost, "/api/containers/abc/restart"},
+		{http.MethodPost, "/api/containers/run"},
+		{http.MethodPost, "/api/containers/abc/recreate"},
+		{http.MethodPost, "/api/containers/abc/redeploy"},
+		{http.MethodDelete, "/api/containers/abc"},
+		{http.MethodPost, "/api/containers/abc/systemd"},
+		{http.MethodPost, "/api/containers/abc/autostart"},
+		{http.MethodGet, "/api/templates"},
+		{http.MethodPost, "/api/templates"},
+		{http.MethodDelete, "/api/templates/nginx"},
+		{http.MethodGet, "/api/containers/abc/terminal"},
+		{http.MethodPost, "/api/containers/abc/exec/download"},
+		{http.MethodPost, "/api/containers/abc/exec/upload"},
+		{http.MethodGet, "/api/terminal"},
+		{http.MethodGet, "/api/terminal/history"},
+		{http.MethodDelete, "/api/terminal/history"},
+		{http.MethodDelete, "/api/terminal/history/0"},
+		{http.MethodGet, "/api/images/search?q=nginx"},
+		{http.MethodPost, "/api/images/pull"},
+		{http.MethodPost, "/api/images/prune"},
+		{http.MethodDelete, "/api/images/abc"},
+		{http.MethodPost, "/api/images/abc/scan"},
+		{http.MethodPost, "/api/volumes/prune"},
+		{http.MethodPost, "/api/system/reboot"},
+		{http.MethodPost, "/api/system/shutdown"},
+		{http.MethodPost, "/api/system/restart"},
+		{http.MethodGet, "/api/system/logs"},
+		{http.MethodGet, "/api/system/diagnostics"},
+		{http.MethodGet, "/api/system/runtime"},
+		{http.MethodPost, "/api/system/update"},
+		{http.MethodPost, "/api/auth/rotate-secret"},
+		{http.MethodPatch, "/api/system/config"},
+		{http.MethodPost, "/api/system/config/reload"},
+		{http.MethodGet, "/api/system/export-settings"},
+		{http.MethodPost, "/api/system/import-settings"},
+		{http.MethodPost, "/api/system/mqtt/config"},
+		{http.MethodPost, "/api/system/mqtt/test"},
+		{http.MethodPost, "/api/system/mqtt/publish"},
+		{http.MethodGet, "/api/pods/app/terminal"},
+		{http.MethodGet, "/api/files/browse"},
+		{http.MethodGet, "/api/files/download"},
+		{http.MethodGet, "/api/files/stream"},
+		{http.MethodGet, "/api/files/thumbnail"},
+		{http.MethodGet, "/api/files/tail"},
+		{http.MethodGet, "/api/files/watch"},
+		{http.MethodPost, "/api/files/upload"},
+		{http.MethodDelete, "/api/files"},
+		{http.MethodPost, "/api/files/mkdir"},
+		{http.MethodPost, "/api/files/create"},
+		{http.MethodPost, "/api/files/rename"},
+		{http.MethodPost, "/api/files/batch"},
+		{http.MethodGet, "/api/files/bookmarks"},
+		{http.MethodPost, "/api/files/bookmarks"},
+		{http.MethodDelete, "/api/files/bookmarks"},
+		{http.MethodGet, "/api/files/read"},
+		{http.MethodPost, "/api/files/write"},
+		{http.MethodPost, "/api/plugins/demo/toggle"},
+		{http.MethodPost, "/api/plugins/temperature/mqtt/rediscover"},
+		{http.MethodGet, "/api/plugins/demo/logs"},
+	}
+
+	for _, tt := range adminRoutes {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.AddCookie(&http.Cookie{Name: auth.CookieName, Value: token})
+			rec := httptest.NewRecorder()
+
+			server.Router().ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusForbidden {
+				t.Errorf("%s %s as readonly = %d; want %d", tt.method, tt.path, rec.Code, http.StatusForbidden)
+			}
+		})
+	}
+
+	// Read-only users can still query their capabilities
+	req := httptest.NewRequest(http.MethodGet, "/api/auth/capabilities", nil)
+	req.AddCookie(&http.Cookie{Name: auth.CookieName, Value: token})
+	rec := httptest.NewRecorder()
+	server.Router().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("GET /api/auth/capabilities as readonly = %d; want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestPluginRouteRoles(t *testing.T) {
+	cfg := newTestConfig(t)
+
+	pluginList := []plugins.Plugin{demo.New(), temperature.New()}
+	server := api.NewServerWithPlugins(nil, cfg, "test", "test", pluginList, nil, nil)
+	token := sessionToken(t, cfg, testReader)
+
+	// The plugins aren't initialized, so routes that pass the policy
+	// stop at the plugin-enabled check with 503
+	tests := []struct {
+		method string
+		path   string
+		want   int
+	}{
+		{http.MethodPost, "/api/plugins/demo/counter", http.StatusForbidden},
+		{http.MethodPost, "/api/plugins/temperature/settings", http.StatusForbidden},
+		{http.MethodPost, "/api/plugins/temperature/mqtt", http.StatusForbidden},
+		{http.MethodGet, "/api/plugins/demo/info", http.StatusServiceUnavailable},
+		{http.MethodGet, "/api/plugins/temperature/settings", http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.AddCookie(&http.Cookie{Name: auth.CookieName, Value: token})
+			rec := httptest.NewRecorder()
+
+			server.Router().ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("%s %s as readonly = %d; want %d", tt.method, tt.path, rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyRoles(t *testing.T) {
+	for _, action := range auth.AllActions() {
+		if !auth.Allowed(auth.RoleAdmin, action) {
+			t.Errorf("Allowed(admin, %s) = false; want true", action)
+		}
+		want := action == auth.ActionView
+		if got := auth.Allowed(auth.RoleReadOnly, action); got != want {
+			t.Errorf("Allowed(readonly, %s) = %v; want %v", action, got, want)
+		}
+	}
+
+	if auth.Allowed(auth.RoleAdmin, auth.Action("unknown")) {
+		t.Error("Allowed() should deny unknown actions")
+	}
+}
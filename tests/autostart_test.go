@@ -0,0 +1,160 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"podmanview/internal/api"
+	"podmanview/internal/podman"
+)
+
+// fakeSystemctl puts a systemctl on PATH that logs its arguments to the
+// returned file and prints state for is-enabled
+func fakeSystemctl(t *testing.T, state string) string {
+	t.Helper()
+	dir := t.TempDir()
+	log := filepath.Join(dir, "calls")
+	script := "#!/bin/sh\necho \"$@\" >> " + log + "\n" +
+		"if [ \"$1\" = is-enabled ] || [ \"$2\" = is-enabled ]; then echo " + state + "; fi\n"
+	if err := os.WriteFile(filepath.Join(dir, "systemctl"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+	return log
+}
+
+func TestContainerAutostart(t *testing.T) {
+	inspects := map[string]string{
+		"web":     `{"Id": "web1", "Name": "web", "Config": {}, "HostConfig": {}}`,
+		"quadlet": `{"Id": "q1", "Name": "quadlet", "Config": {"Labels": {"PODMAN_SYSTEMD_UNIT": "quadlet.service"}}, "HostConfig": {}}`,
+		"pinned":  `{"Id": "p1", "Name": "pinned", "Pod": "pod1", "Config": {}, "HostConfig": {}}`,
+	}
+	fake := &podmanMux{}
+	fake.HandleFunc("GET /containers/{name}/json", func(w http.ResponseWriter, r *http.Request) {
+		inspect, ok := inspects[r.PathValue("name")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(inspect))
+	})
+	_, _, request := newTestAPI(t, servePodman(t, fake), newTestConfig(t), testAdmin)
+	do := func(method, id, body string) (*httptest.ResponseRecorder, api.AutostartResponse) {
+		rec := request(method, "/api/containers/"+id+"/autostart", body)
+		var resp api.AutostartResponse
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		return rec, resp
+	}
+	calls := func(log string) string {
+		data, _ := os.ReadFile(log)
+		os.Remove(log)
+		return strings.TrimSpace(strings.ReplaceAll(string(data), "--user ", ""))
+	}
+
+	// The unit podman generate systemd names, installed but disabled
+	log := fakeSystemctl(t, "disabled")
+	rec, resp := do(http.MethodGet, "web", "")
+	if rec.Code != http.StatusOK || resp.Enabled || resp.Unit != "container-web.service" || resp.State != "disabled" {
+		t.Errorf("get = %d %s", rec.Code, rec.Body.String())
+	}
+	calls(log)
+	if rec, _ := do(http.MethodPost, "web", `{"enabled": true}`); rec.Code != http.StatusOK {
+		t.Errorf("enable = %d %s", rec.Code, rec.Body.String())
+	}
+	if got := calls(log); !strings.Contains(got, "enable container-web.service") {
+		t.Errorf("systemctl calls = %q; want enable", got)
+	}
+
+	// The unit that started the container is used
+	log = fakeSystemctl(t, "enabled")
+	if rec, resp := do(http.MethodGet, "quadlet", ""); rec.Code != http.StatusOK || !resp.Enabled || resp.Unit != "quadlet.service" {
+		t.Errorf("get quadlet = %d %s", rec.Code, rec.Body.String())
+	}
+	calls(log)
+	if rec, _ := do(http.MethodPost, "quadlet", `{"enabled": false}`); rec.Code != http.StatusOK {
+		t.Errorf("disable = %d %s", rec.Code, rec.Body.String())
+	}
+	if got := calls(log); !strings.Contains(got, "disable quadlet.service") {
+		t.Errorf("systemctl calls = %q; want disable", got)
+	}
+
+	// Static units start only when pulled in by another unit
+	fakeSystemctl(t, "static")
+	if rec, resp := do(http.MethodGet, "quadlet", ""); rec.Code != http.StatusOK || resp.Enabled {
+		t.Errorf("get static unit = %d %s; want not enabled", rec.Code, rec.Body.String())
+	}
+	if rec, _ := do(http.MethodPost, "quadlet", `{"enabled": true}`); rec.Code != http.StatusConflict {
+		t.Errorf("enable static unit = %d; want 409", rec.Code)
+	}
+
+	// Quadlet units can't be toggled with systemctl
+	fakeSystemctl(t, "generated")
+	if rec, _ := do(http.MethodPost, "quadlet", `{"enabled": false}`); rec.Code != http.StatusConflict {
+		t.Errorf("disable generated unit = %d; want 409", rec.Code)
+	}
+	if rec, _ := do(http.MethodPost, "pinned", `{"enabled": true}`); rec.Code != http.StatusConflict {
+		t.Errorf("enable container in pod = %d; want 409", rec.Code)
+	}
+	if rec, _ := do(http.MethodGet, "missing", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("get missing = %d; want 404", rec.Code)
+	}
+
+	t.Setenv("PATH", t.TempDir())
+	if rec, _ := do(http.MethodGet, "web", ""); rec.Code != http.StatusNotImplemented {
+		t.Errorf("without systemctl = %d; want 501", rec.Code)
+	}
+}
+
+func TestRemoteEngineHostState(t *testing.T) {
+	// Nothing listens there: the request must be refused before the
+	// engine is contacted
+	client, err := podman.NewClientWithSocket("tcp://127.0.0.1:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := newTestConfig(t)
+	cfg.SetNoAuth(true)
+	request := requester(api.NewServer(client, cfg, "test", "test"), "")
+	log := fakeSystemctl(t, "enabled")
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{http.MethodPost, "/api/containers/web/systemd", `{"enable": true}`},
+		{http.MethodGet, "/api/containers/web/autostart", ""},
+		{http.MethodPost, "/api/containers/web/autostart", `{"enabled": true}`},
+		{http.MethodGet, "/api/system/logs?container=web", ""},
+		{http.MethodGet, "/api/system/logs?unit=container-web.service", ""},
+		{http.MethodPost, "/api/images/nginx/scan", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			rec := request(tt.method, tt.path, tt.body)
+			if rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), "remote_engine") {
+				t.Errorf("%s %s with a remote engine = %d %s; want 409 remote_engine", tt.method, tt.path, rec.Code, rec.Body.String())
+			}
+		})
+	}
+
+	if _, err := os.Stat(log); err == nil {
+		t.Error("systemctl was run for a remote engine")
+	}
+
+	// Bind mount sources are on the engine's host, not checked here
+	body := `{"image": "nginx", "volumes": "/srv/remote-only:/data, data:/x"}`
+	rec := request(http.MethodPost, "/api/containers?dry_run=true", body)
+	var dryRun api.CreateDryRunResponse
+	json.Unmarshal(rec.Body.Bytes(), &dryRun)
+	errs := strings.Join(dryRun.Errors, "; ")
+	if rec.Code != http.StatusOK || strings.Contains(errs, "/srv/remote-only") || !strings.Contains(errs, "data must be an absolute path") {
+		t.Errorf("remote dry run = %d, errors %q; want only the relative source reported", rec.Code, errs)
+	}
+}
@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"podmanview/internal/plugins/backup"
+)
+
+func TestVolumeBackup(t *testing.T) {
+	volume := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(volume, "data", "nested"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(volume, "data", "nested", "db.sqlite"), []byte("rows"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("data/nested/db.sqlite", filepath.Join(volume, "current")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Destination inside the volume must not be archived into itself
+	dest := filepath.Join(volume, "backups")
+	if err := os.Mkdir(dest, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2026, 10, 16, 3, 0, 0, 0, time.Local)
+	file, err := backup.CreateBackup(context.Background(), volume, dest, "app-data", now)
+	if err != nil {
+		t.Fatalf("CreateBackup() failed: %v", err)
+	}
+	if file.Name != "app-data-20261016-030000.tar.gz" {
+		t.Errorf("backup name = %q", file.Name)
+	}
+
+	f, err := os.Open(filepath.Join(dest, file.Name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+
+	var names []string
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("reading archive: %v", err)
+		}
+		names = append(names, header.Name)
+		if header.Name == "data/nested/db.sqlite" {
+			content, _ := io.ReadAll(tr)
+			if string(content) != "rows" {
+				t.Errorf("db.sqlite content = %q", content)
+			}
+		}
+		if header.Name == "current" && header.Linkname != "data/nested/db.sqlite" {
+			t.Errorf("symlink target = %q", header.Linkname)
+		}
+	}
+	want := []string{"current", "data/", "data/nested/", "data/nested/db.sqlite"}
+	sort.Strings(names)
+	if len(names) != len(want) {
+		t.Fatalf("archive entries = %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Errorf("archive entries = %v, want %v", names, want)
+			break
+		}
+	}
+
+	// Retention keeps the newest backups of each volume only
+	for i := 1; i <= 3; i++ {
+		for _, v := range []string{"app-data", "app"} {
+			name := backup.FileName(v, now.Add(time.Duration(i)*time.Hour))
+			if err := os.WriteFile(filepath.Join(dest, name), nil, 0600); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	removed, err := backup.PruneBackups(dest, "app-data", 2)
+	if err != nil {
+		t.Fatalf("PruneBackups() failed: %v", err)
+	}
+	if len(removed) != 2 || removed[0] != "app-data-20261016-040000.tar.gz" || removed[1] != file.Name {
+		t.Errorf("PruneBackups() removed %v; want the two oldest app-data backups", removed)
+	}
+
+	backups, err := backup.ListBackups(dest)
+	if err != nil {
+		t.Fatalf("ListBackups() failed: %v", err)
+	}
+	count := map[string]int{}
+	for _, b := range backups {
+		count[b.Volume]++
+	}
+	if count["app-data"] != 2 || count["app"] != 3 {
+		t.Errorf("backups left per volume = %v; want app-data:2 app:3", count)
+	}
+}
@@ -0,0 +1,76 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"podmanview/internal/api"
+	"podmanview/internal/auth"
+)
+
+func TestBatch(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cfg := newTestConfig(t)
+	server := api.NewServer(nil, cfg, "test", "test")
+
+	do := func(user *auth.User, body string) *httptest.ResponseRecorder {
+		var token string
+		if user != nil {
+			token = sessionToken(t, cfg, user)
+		}
+		return requester(server, token)(http.MethodPost, "/api/batch", body)
+	}
+
+	admin := &auth.User{Username: "admin", Role: auth.RoleAdmin}
+	viewer := &auth.User{Username: "viewer", Role: auth.RoleReadOnly}
+	calls := `[{"method":"GET","path":"/api/auth/me"},{"path":"/api/files/browse?path=/"},{"path":"/api/files/read?path=/missing.txt"}]`
+
+	statuses := func(rec *httptest.ResponseRecorder) []int {
+		t.Helper()
+		if rec.Code != http.StatusOK {
+			t.Fatalf("batch = %d (%s)", rec.Code, rec.Body.String())
+		}
+		var resps []api.SubResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resps); err != nil {
+			t.Fatalf("invalid batch response %q: %v", rec.Body.String(), err)
+		}
+		var got []int
+		for _, resp := range resps {
+			got = append(got, resp.Status)
+		}
+		if len(resps) > 0 && !strings.Contains(string(resps[0].Body), `"username":"`) {
+			t.Errorf("me body = %s", resps[0].Body)
+		}
+		return got
+	}
+
+	// Sub-requests keep their order and are authorized as the caller
+	if got := statuses(do(admin, calls)); len(got) != 3 || got[0] != 200 || got[1] != 200 || got[2] != 404 {
+		t.Errorf("admin statuses = %v; want [200 200 404]", got)
+	}
+	if got := statuses(do(viewer, calls)); len(got) != 3 || got[0] != 200 || got[1] != 403 || got[2] != 403 {
+		t.Errorf("viewer statuses = %v; want [200 403 403]", got)
+	}
+
+	errorCases := []struct {
+		name string
+		user *auth.User
+		body string
+		want int
+	}{
+		{"unauthenticated", nil, calls, http.StatusUnauthorized},
+		{"empty", admin, `[]`, http.StatusBadRequest},
+		{"non-GET", admin, `[{"method":"POST","path":"/api/system/reboot"}]`, http.StatusBadRequest},
+		{"non-API path", admin, `[{"path":"/static/js/app.js"}]`, http.StatusBadRequest},
+		{"nested batch", admin, `[{"path":"/api/batch"}]`, http.StatusBadRequest},
+		{"too many", admin, "[" + strings.Repeat(`{"path":"/api/auth/me"},`, 20) + `{"path":"/api/auth/me"}]`, http.StatusBadRequest},
+	}
+	for _, tt := range errorCases {
+		if rec := do(tt.user, tt.body); rec.Code != tt.want {
+			t.Errorf("%s = %d (%s); want %d", tt.name, rec.Code, rec.Body.String(), tt.want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"podmanview/internal/api"
+	"podmanview/internal/config"
+)
+
+func TestRequestBodyLimit(t *testing.T) {
+	envPath := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(envPath, []byte("PODMANVIEW_JSON_MAX_BYTES=65536\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(envPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if cfg.JSONMaxBytes() != 65536 {
+		t.Fatalf("JSONMaxBytes() = %d; want 65536", cfg.JSONMaxBytes())
+	}
+	server := api.NewServer(nil, cfg, "test", "test")
+
+	gzipped := func(s string) []byte {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		gz.Write([]byte(s))
+		gz.Close()
+		return buf.Bytes()
+	}
+	// Valid JSON just over the limit; compresses to a few hundred bytes
+	huge := `{"username":"` + strings.Repeat("a", 70*1024) + `"}`
+
+	tests := []struct {
+		name     string
+		body     []byte
+		encoding string
+		wantCode int
+		wantErr  string
+	}{
+		{"plain", []byte("{}"), "", http.StatusBadRequest, "missing_field"},
+		{"gzip", gzipped("{}"), "gzip", http.StatusBadRequest, "missing_field"},
+		{"too large", []byte(huge), "", http.StatusRequestEntityTooLarge, "body_too_large"},
+		{"too large after decoding", gzipped(huge), "gzip", http.StatusRequestEntityTooLarge, "body_too_large"},
+		{"bad gzip", []byte("{}"), "gzip", http.StatusBadRequest, "invalid_encoding"},
+		{"unknown encoding", []byte("{}"), "br", http.StatusUnsupportedMediaType, "unsupported_encoding"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/auth/login", bytes.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.encoding != "" {
+				req.Header.Set("Content-Encoding", tt.encoding)
+			}
+			rec := httptest.NewRecorder()
+
+			server.Router().ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d; want %d (%s)", rec.Code, tt.wantCode, rec.Body.String())
+			}
+			var resp api.ErrorResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decoding %q: %v", rec.Body.String(), err)
+			}
+			if resp.Error.Code != tt.wantErr {
+				t.Errorf("error code = %q; want %q", resp.Error.Code, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"podmanview/internal/api"
+	"podmanview/internal/auth"
+	"podmanview/internal/storage"
+)
+
+func TestFileBookmarks(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.MkdirAll(filepath.Join(home, "app", "config"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := storage.NewBoltStorage(filepath.Join(t.TempDir(), "podmanview.db"))
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer store.Close()
+
+	cfg := newTestConfig(t)
+	server := api.NewServerWithPlugins(nil, cfg, "test", "test", nil, nil, store)
+
+	do := func(username, method, target, body string) *httptest.ResponseRecorder {
+		token := sessionToken(t, cfg, &auth.User{Username: username, Role: auth.RoleAdmin})
+		return requester(server, token)(method, target, body)
+	}
+	list := func(username string) []api.Bookmark {
+		rec := do(username, http.MethodGet, "/api/files/bookmarks", "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("list = %d (%s)", rec.Code, rec.Body.String())
+		}
+		var resp api.BookmarksResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp.Bookmarks
+	}
+
+	if got := list("alice"); len(got) != 0 {
+		t.Fatalf("initial bookmarks = %v", got)
+	}
+
+	// Paths are normalized before saving
+	if rec := do("alice", http.MethodPost, "/api/files/bookmarks", `{"path":"/app/./config/"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("add = %d (%s)", rec.Code, rec.Body.String())
+	}
+	got := list("alice")
+	if len(got) != 1 || got[0].Path != "/app/config" || got[0].Name != "config" || !got[0].IsDir {
+		t.Fatalf("bookmarks = %+v", got)
+	}
+
+	// Bookmarks are per user
+	if got := list("bob"); len(got) != 0 {
+		t.Errorf("bob's bookmarks = %v", got)
+	}
+
+	errorCases := []struct {
+		method, target, body string
+		want                 int
+	}{
+		{http.MethodPost, "/api/files/bookmarks", `{"path":"/app/config"}`, http.StatusConflict},
+		{http.MethodPost, "/api/files/bookmarks", `{"path":"/missing"}`, http.StatusNotFound},
+		{http.MethodPost, "/api/files/bookmarks", `{"path":"/../../etc"}`, http.StatusNotFound},
+		{http.MethodPost, "/api/files/bookmarks", `{}`, http.StatusBadRequest},
+		{http.MethodDelete, "/api/files/bookmarks?path=/missing", "", http.StatusNotFound},
+	}
+	for _, tt := range errorCases {
+		if rec := do("alice", tt.method, tt.target, tt.body); rec.Code != tt.want {
+			t.Errorf("%s %s %s = %d; want %d", tt.method, tt.target, tt.body, rec.Code, tt.want)
+		}
+	}
+
+	if rec := do("alice", http.MethodDelete, "/api/files/bookmarks?path=/app/config", ""); rec.Code != http.StatusOK {
+		t.Fatalf("delete = %d (%s)", rec.Code, rec.Body.String())
+	}
+	if got := list("alice"); len(got) != 0 {
+		t.Errorf("bookmarks after delete = %v", got)
+	}
+}
+
+func TestFileBookmarksWithoutStorage(t *testing.T) {
+	_, _, request := newTestAPI(t, nil, newTestConfig(t), testAdmin)
+	if rec := request(http.MethodGet, "/api/files/bookmarks", ""); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("list without storage = %d; want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+}
@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"podmanview/internal/api"
+	"podmanview/internal/auth"
+	"podmanview/internal/config"
+)
+
+func TestResponseCompression(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	text := strings.Repeat("compressible text\n", 1000)
+	if err := os.WriteFile(filepath.Join(home, "notes.txt"), []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// load returns a server with the settings and an admin token for it
+	load := func(env string) (*api.Server, string) {
+		path := filepath.Join(t.TempDir(), ".env")
+		if err := os.WriteFile(path, []byte(env), 0600); err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := config.Load(path)
+		if err != nil {
+			t.Fatalf("Load(%q) failed: %v", env, err)
+		}
+		server, token, _ := newTestAPI(t, nil, cfg, testAdmin)
+		return server, token
+	}
+	// encoding returns the Content-Encoding of a gzip-accepting request
+	encoding := func(server *api.Server, token, target string, header ...string) string {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		req.AddCookie(&http.Cookie{Name: auth.CookieName, Value: token})
+		rec := httptest.NewRecorder()
+		server.Router().ServeHTTP(rec, req)
+		if rec.Code >= 400 {
+			t.Fatalf("%s = %d %s", target, rec.Code, rec.Body.String())
+		}
+		return rec.Header().Get("Content-Encoding")
+	}
+
+	server, token := load("")
+	tests := []struct {
+		name   string
+		target string
+		header []string
+		want   string
+	}{
+		{"JSON", "/api/files/browse?path=/", nil, "gzip"},
+		{"stream", "/api/files/stream?path=/notes.txt", nil, ""},
+		{"stream range", "/api/files/stream?path=/notes.txt", []string{"Range", "bytes=100-199"}, ""},
+		{"download", "/api/files/download?path=/notes.txt", nil, ""},
+	}
+	for _, tt := range tests {
+		if got := encoding(server, token, tt.target, tt.header...); got != tt.want {
+			t.Errorf("%s: Content-Encoding = %q; want %q", tt.name, got, tt.want)
+		}
+	}
+
+	server, token = load("PODMANVIEW_COMPRESS_LEVEL=0\n")
+	if got := encoding(server, token, "/api/files/browse?path=/"); got != "" {
+		t.Errorf("level 0: Content-Encoding = %q; want none", got)
+	}
+	server, token = load("PODMANVIEW_COMPRESS_TYPES=text/*\n")
+	if got := encoding(server, token, "/api/files/browse?path=/"); got != "" {
+		t.Errorf("text only: JSON Content-Encoding = %q; want none", got)
+	}
+
+	for _, env := range []string{
+		"PODMANVIEW_COMPRESS_LEVEL=10",
+		"PODMANVIEW_COMPRESS_LEVEL=-1",
+		"PODMANVIEW_COMPRESS_TYPES=*/*",
+		"PODMANVIEW_COMPRESS_TYPES=text",
+		"PODMANVIEW_COMPRESS_TYPES=application/*+json",
+	} {
+		path := filepath.Join(t.TempDir(), ".env")
+		if err := os.WriteFile(path, []byte(env+"\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := config.Load(path); err == nil {
+			t.Errorf("Load(%q) succeeded; want error", env)
+		}
+	}
+}
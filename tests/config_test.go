@@ -0,0 +1,436 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"podmanview/internal/api"
+	"podmanview/internal/config"
+	"podmanview/internal/plugins"
+	"podmanview/internal/plugins/demo"
+	"podmanview/internal/plugins/temperature"
+	"podmanview/internal/storage"
+	"podmanview/internal/updater"
+)
+
+func TestSetMQTTBrokerValidation(t *testing.T) {
+	cfg, err := config.Load(filepath.Join(t.TempDir(), ".env"))
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	tests := []struct {
+		broker  string
+		wantErr bool
+	}{
+		{"", false},
+		{"tcp://localhost:1883", false},
+		{"ssl://broker.example.com:8883", false},
+		{"localhost:1883", true},
+		{"http://localhost:1883", true},
+		{"tcp://localhost", true},
+		{"tcp://localhost:99999", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.broker, func(t *testing.T) {
+			err := cfg.SetMQTTBroker(tt.broker)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SetMQTTBroker(%q) error = %v; wantErr %v", tt.broker, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSetAddrValidation(t *testing.T) {
+	cfg, err := config.Load(filepath.Join(t.TempDir(), ".env"))
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	tests := []struct {
+		addr    string
+		wantErr bool
+	}{
+		{":8080", false},
+		{"127.0.0.1:3000", false},
+		{"unix:/run/podmanview/podmanview.sock", false},
+		{"unix:podmanview.sock", true},
+		{"unix:", true},
+		{"localhost:99999", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			err := cfg.SetAddr(tt.addr)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SetAddr(%q) error = %v; wantErr %v", tt.addr, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDataDir(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".env")
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if cfg.DataDir() != "" {
+		t.Errorf("DataDir() = %q; want the working directory by default", cfg.DataDir())
+	}
+
+	dataDir := filepath.Join(dir, "data")
+	if err := os.WriteFile(path, []byte(config.EnvDataDir+"="+dataDir+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err = config.Load(path); err != nil || cfg.DataDir() != dataDir {
+		t.Errorf("Load() DataDir() = %q, %v; want %q", cfg.DataDir(), err, dataDir)
+	}
+
+	// Relative directories would depend on the working directory again
+	if err := os.WriteFile(path, []byte(config.EnvDataDir+"=data\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := config.Load(path); err == nil {
+		t.Error("Load() accepted a relative data directory")
+	}
+}
+
+func TestSetMQTTPrefixValidation(t *testing.T) {
+	cfg, err := config.Load(filepath.Join(t.TempDir(), ".env"))
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	if err := cfg.SetMQTTPrefix("home/podman"); err != nil {
+		t.Errorf("SetMQTTPrefix(valid) failed: %v", err)
+	}
+	if err := cfg.SetMQTTPrefix("/podman"); err == nil {
+		t.Error("SetMQTTPrefix should reject leading slash")
+	}
+	if got := cfg.MQTTPrefix(); got != "home/podman" {
+		t.Errorf("MQTTPrefix() = %q; want %q (invalid value must not be stored)", got, "home/podman")
+	}
+}
+
+func TestReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	secret := cfg.JWTSecret()
+
+	// Edit the file behind the running config
+	appendEnv := func(line string) {
+		t.Helper()
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if _, err := f.WriteString(line + "\n"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	appendEnv("PODMANVIEW_PODMAN_RETRIES=7")
+	appendEnv("PODMANVIEW_ADDR=:8080")
+
+	changed, err := cfg.Reload()
+	if err != nil {
+		t.Fatalf("Reload() failed: %v", err)
+	}
+	want := []string{config.EnvAddr, config.EnvPodmanRetries}
+	if !reflect.DeepEqual(changed, want) {
+		t.Errorf("Reload() changed = %v; want %v", changed, want)
+	}
+	if cfg.PodmanRetries() != 7 || cfg.Addr() != ":8080" || cfg.JWTSecret() != secret {
+		t.Errorf("after Reload() retries=%d addr=%q secret kept=%v", cfg.PodmanRetries(), cfg.Addr(), cfg.JWTSecret() == secret)
+	}
+
+	// Invalid file keeps the running configuration
+	appendEnv("PODMANVIEW_TLS_CERT=/etc/ssl/cert.pem")
+	if _, err := cfg.Reload(); err == nil {
+		t.Fatal("Reload() with cert but no key succeeded; want error")
+	}
+	if cfg.TLSCert() != "" || cfg.PodmanRetries() != 7 {
+		t.Errorf("after failed Reload() cert=%q retries=%d; want previous values", cfg.TLSCert(), cfg.PodmanRetries())
+	}
+}
+
+func TestGeneralConfigAPI(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	cfg.SetNoAuth(true)
+	server := api.NewServer(nil, cfg, "test", "test")
+
+	patch := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		server.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodPatch, "/api/system/config", strings.NewReader(body)))
+		return rec
+	}
+
+	// Secret is never returned
+	rec := httptest.NewRecorder()
+	server.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/system/config", nil))
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), cfg.JWTSecret()) {
+		t.Fatalf("GET status = %d body = %q; want 200 without secret", rec.Code, rec.Body.String())
+	}
+
+	rec = patch(`{"addr":":8080","podmanRetries":5}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PATCH status = %d; want 200 (%s)", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		RestartRequired []string `json:"restartRequired"`
+		Warning         string   `json:"warning"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(resp.RestartRequired, []string{config.EnvAddr}) || resp.Warning == "" {
+		t.Errorf("PATCH restartRequired = %v warning = %q; want addr with warning", resp.RestartRequired, resp.Warning)
+	}
+
+	// Saved to the file
+	reloaded, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if reloaded.Addr() != ":8080" || reloaded.PodmanRetries() != 5 {
+		t.Errorf("saved addr=%q retries=%d; want :8080 and 5", reloaded.Addr(), reloaded.PodmanRetries())
+	}
+
+	// Invalid values are rejected without partial changes
+	if rec := patch(`{"podmanRetries":3,"podmanTimeout":0}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("PATCH invalid status = %d; want 400", rec.Code)
+	}
+	if cfg.PodmanRetries() != 5 {
+		t.Errorf("retries after invalid PATCH = %d; want 5", cfg.PodmanRetries())
+	}
+}
+
+func TestRestart(t *testing.T) {
+	t.Setenv("INVOCATION_ID", "") // not under systemd
+	cfg, err := config.Load(filepath.Join(t.TempDir(), ".env"))
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	cfg.SetNoAuth(true)
+	server := api.NewServer(nil, cfg, "test", "test")
+
+	rec := httptest.NewRecorder()
+	server.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/system/restart", nil))
+	if rec.Code != http.StatusAccepted || !strings.Contains(rec.Body.String(), `"mode":"exec"`) {
+		t.Fatalf("restart = %d %s; want 202 with exec mode", rec.Code, rec.Body.String())
+	}
+
+	// main receives the request after the response went out
+	select {
+	case <-server.RestartRequests():
+	case <-time.After(5 * time.Second):
+		t.Fatal("no restart request received")
+	}
+}
+
+func TestParseServiceUnit(t *testing.T) {
+	tests := []struct {
+		name   string
+		cgroup string
+		unit   string
+		user   bool
+	}{
+		{"system service", "0::/system.slice/podmanview.service\n", "podmanview.service", false},
+		{"renamed service", "0::/system.slice/podman-view.service\n", "podman-view.service", false},
+		{"user service", "0::/user.slice/user-1000.slice/user@1000.service/app.slice/podmanview.service\n", "podmanview.service", true},
+		{"delegated subgroup", "0::/system.slice/podmanview.service/main\n", "podmanview.service", false},
+		{"shell", "0::/user.slice/user-1000.slice/session-3.scope\n", "", false},
+		{"user manager scope", "0::/user.slice/user-1000.slice/user@1000.service/init.scope\n", "", false},
+		{"cgroup v1", "12:memory:/system.slice/podmanview.service\n1:name=systemd:/system.slice/podmanview.service\n", "podmanview.service", false},
+		{"empty", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unit, user := updater.ParseServiceUnit(tt.cgroup)
+			if unit != tt.unit || user != tt.user {
+				t.Errorf("ParseServiceUnit() = %q, %v; want %q, %v", unit, user, tt.unit, tt.user)
+			}
+		})
+	}
+}
+
+func TestSettingsBundle(t *testing.T) {
+	// newInstance returns a no-auth server with its own .env and database
+	newInstance := func() (*config.Config, storage.Storage, *api.Server) {
+		cfg, err := config.Load(filepath.Join(t.TempDir(), ".env"))
+		if err != nil {
+			t.Fatalf("Load() failed: %v", err)
+		}
+		cfg.SetNoAuth(true)
+		store, err := storage.NewBoltStorage(filepath.Join(t.TempDir(), "podmanview.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { store.Close() })
+		return cfg, store, api.NewServerWithPlugins(nil, cfg, "test", "test", nil, nil, store)
+	}
+	do := func(server *api.Server, method, target, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		server.Router().ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return rec
+	}
+
+	source, sourceStore, sourceServer := newInstance()
+	source.SetPodmanRetries(7)
+	source.SetMQTTPassword("hunter2")
+	sourceStore.SetPluginConfig("backup", &storage.PluginConfig{Enabled: true, Name: "Volume Backups"})
+	sourceStore.SetJSON("file_bookmarks", "alice", []api.Bookmark{{Path: "/projects", Name: "projects", IsDir: true}})
+	if rec := do(sourceServer, http.MethodPost, "/api/templates", `{"name": "web", "config": {"image": "nginx"}}`); rec.Code != http.StatusCreated {
+		t.Fatalf("save template = %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec := do(sourceServer, http.MethodGet, "/api/system/export-settings", "")
+	var bundle api.SettingsBundle
+	if err := json.Unmarshal(rec.Body.Bytes(), &bundle); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("export = %d %s", rec.Code, rec.Body.String())
+	}
+	if _, ok := bundle.Settings[config.EnvJWTSecret]; ok || strings.Contains(rec.Body.String(), source.JWTSecret()) {
+		t.Error("export contains the JWT secret")
+	}
+	if strings.Contains(rec.Body.String(), "hunter2") || !reflect.DeepEqual(bundle.Redacted, []string{config.EnvMQTTPassword}) {
+		t.Errorf("export redacted = %v; want the MQTT password left out", bundle.Redacted)
+	}
+	if len(bundle.Templates) != 1 || len(bundle.Bookmarks["alice"]) != 1 || !bundle.Plugins["backup"].Enabled {
+		t.Errorf("export = %+v", bundle)
+	}
+	if rec := do(sourceServer, http.MethodGet, "/api/system/export-settings?secrets=true", ""); !strings.Contains(rec.Body.String(), "hunter2") {
+		t.Error("export with secrets = no MQTT password")
+	}
+
+	target, targetStore, targetServer := newInstance()
+	secret := target.JWTSecret()
+
+	// Invalid bundles change nothing
+	for _, body := range []string{
+		strings.Replace(rec.Body.String(), `"version":1`, `"version":2`, 1),
+		`{"version": 1, "settings": {"PODMANVIEW_NOPE": "1"}}`,
+		`{"version": 1, "settings": {"PODMANVIEW_JWT_SECRET": "stolen"}}`,
+		`{"version": 1, "templates": [{"name": "no image", "config": {}}]}`,
+		`{"version": 1, "settings": {"PODMANVIEW_PODMAN_RETRIES": "11"}}`,
+	} {
+		if rec := do(targetServer, http.MethodPost, "/api/system/import-settings", body); rec.Code != http.StatusBadRequest {
+			t.Errorf("import %.60s = %d; want 400", body, rec.Code)
+		}
+	}
+	if target.PodmanRetries() == 7 || target.JWTSecret() != secret {
+		t.Fatal("invalid import changed settings")
+	}
+
+	body, _ := json.Marshal(bundle)
+	rec = do(targetServer, http.MethodPost, "/api/system/import-settings", string(body))
+	var result api.ImportResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("import = %d %s", rec.Code, rec.Body.String())
+	}
+	if target.PodmanRetries() != 7 || target.JWTSecret() != secret || target.MQTTPassword() != "" {
+		t.Errorf("imported retries=%d; want 7 with own JWT secret and no MQTT password", target.PodmanRetries())
+	}
+	if result.Templates != 1 || result.Bookmarks != 1 || result.Plugins != 1 {
+		t.Errorf("result = %+v", result)
+	}
+	if reloaded, err := config.Load(target.FilePath()); err != nil || reloaded.PodmanRetries() != 7 {
+		t.Errorf("imported settings not saved: %v", err)
+	}
+	if plugin, err := targetStore.GetPluginConfig("backup"); err != nil || !plugin.Enabled {
+		t.Errorf("backup plugin = %+v, %v; want enabled", plugin, err)
+	}
+	var bookmarks []api.Bookmark
+	if err := targetStore.GetJSON("file_bookmarks", "alice", &bookmarks); err != nil || len(bookmarks) != 1 {
+		t.Errorf("bookmarks = %+v, %v", bookmarks, err)
+	}
+	if rec := do(targetServer, http.MethodGet, "/api/templates", ""); !strings.Contains(rec.Body.String(), `"name":"web"`) {
+		t.Errorf("templates = %s", rec.Body.String())
+	}
+}
+
+func TestSettingsBundlePluginSettings(t *testing.T) {
+	newInstance := func() (storage.Storage, *api.Server) {
+		cfg, err := config.Load(filepath.Join(t.TempDir(), ".env"))
+		if err != nil {
+			t.Fatalf("Load() failed: %v", err)
+		}
+		cfg.SetNoAuth(true)
+		store, err := storage.NewBoltStorage(filepath.Join(t.TempDir(), "podmanview.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { store.Close() })
+		pluginList := []plugins.Plugin{temperature.New(), demo.New()}
+		return store, api.NewServerWithPlugins(nil, cfg, "test", "test", pluginList, nil, store)
+	}
+	do := func(server *api.Server, method, target, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		server.Router().ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return rec
+	}
+
+	sourceStore, sourceServer := newInstance()
+	sourceStore.SetString("temperature", "unit", temperature.UnitFahrenheit)
+	sourceStore.SetInt("temperature", "precision", 2)
+	sourceStore.SetInt("demo", "counter", 5)
+
+	rec := do(sourceServer, http.MethodGet, "/api/system/export-settings", "")
+	var bundle api.SettingsBundle
+	if err := json.Unmarshal(rec.Body.Bytes(), &bundle); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("export = %d %s", rec.Code, rec.Body.String())
+	}
+	want := map[string]map[string]string{"temperature": {"unit": temperature.UnitFahrenheit, "precision": "2"}}
+	if !reflect.DeepEqual(bundle.PluginSettings, want) {
+		t.Errorf("exported plugin settings = %v; want %v (no demo data)", bundle.PluginSettings, want)
+	}
+
+	targetStore, targetServer := newInstance()
+	if rec := do(targetServer, http.MethodPost, "/api/system/import-settings",
+		`{"version": 1, "plugin_settings": {"temperature": {"history": "[]"}}}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("import of an unknown plugin setting = %d; want 400", rec.Code)
+	}
+
+	body, _ := json.Marshal(bundle)
+	rec = do(targetServer, http.MethodPost, "/api/system/import-settings", string(body))
+	var result api.ImportResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil || rec.Code != http.StatusOK || result.PluginSettings != 1 {
+		t.Fatalf("import = %d %s", rec.Code, rec.Body.String())
+	}
+
+	// The plugin picks the imported settings up when it loads
+	plugin := temperature.New()
+	if err := plugin.Init(context.Background(), &plugins.PluginDependencies{Storage: targetStore}); err != nil {
+		t.Fatalf("Init() failed: %v", err)
+	}
+	for _, route := range plugin.Routes() {
+		if route.Method == http.MethodGet && route.Path == "/api/plugins/temperature/settings" {
+			rec := httptest.NewRecorder()
+			route.Handler(rec, httptest.NewRequest(http.MethodGet, route.Path, nil))
+			var settings temperature.PluginSettings
+			json.Unmarshal(rec.Body.Bytes(), &settings)
+			if settings.Unit != temperature.UnitFahrenheit || settings.Precision == nil || *settings.Precision != 2 {
+				t.Errorf("imported settings = %s; want Fahrenheit with precision 2", rec.Body.String())
+			}
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"podmanview/internal/api"
+	"podmanview/internal/auth"
+)
+
+func TestCORS(t *testing.T) {
+	cfg := newTestConfig(t)
+
+	do := func(method, target, origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		if method == http.MethodOptions {
+			req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		}
+		rec := httptest.NewRecorder()
+		api.NewServer(nil, cfg, "test", "test").Router().ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Disabled by default
+	if got := do(http.MethodGet, "/api/auth/me", "https://dash.example.com").Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Access-Control-Allow-Origin without config = %q", got)
+	}
+
+	if err := cfg.SetCORSOrigins([]string{"https://dash.example.com", "http://localhost:5173"}); err != nil {
+		t.Fatalf("SetCORSOrigins() failed: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		origin     string
+		wantStatus int
+		wantOrigin string
+	}{
+		{"preflight", http.MethodOptions, "/api/containers/abc/start", "https://dash.example.com", http.StatusNoContent, "https://dash.example.com"},
+		{"request", http.MethodGet, "/api/auth/me", "http://localhost:5173", http.StatusUnauthorized, "http://localhost:5173"},
+		{"other origin", http.MethodGet, "/api/auth/me", "https://evil.example.com", http.StatusUnauthorized, ""},
+		{"other origin preflight", http.MethodOptions, "/api/auth/me", "https://evil.example.com", http.StatusMethodNotAllowed, ""},
+		{"not an API route", http.MethodGet, "/", "https://dash.example.com", http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := do(tt.method, tt.target, tt.origin)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d; want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q; want %q", got, tt.wantOrigin)
+			}
+			if tt.wantOrigin != "" && rec.Header().Get("Access-Control-Allow-Credentials") != "true" {
+				t.Error("Access-Control-Allow-Credentials not set")
+			}
+		})
+	}
+
+	invalid := []string{"*", "dash.example.com", "https://dash.example.com/", "ftp://dash.example.com"}
+	for _, origin := range invalid {
+		if err := cfg.SetCORSOrigins([]string{origin}); err == nil {
+			t.Errorf("SetCORSOrigins(%q) succeeded; want error", origin)
+		}
+	}
+}
+
+func TestCORSCredentials(t *testing.T) {
+	cfg := newTestConfig(t)
+	const origin = "https://dash.example.net"
+	if err := cfg.SetCORSOrigins([]string{origin}); err != nil {
+		t.Fatalf("SetCORSOrigins() failed: %v", err)
+	}
+	server := api.NewServer(nil, cfg, "test", "test")
+	defer auth.SetCrossSiteCookies(false)
+
+	// The cookie is SameSite=None so the other site's requests carry it,
+	// which browsers only accept over HTTPS
+	sessionCookie := func(target string) *http.Cookie {
+		rec := httptest.NewRecorder()
+		auth.SetAuthCookie(rec, httptest.NewRequest(http.MethodPost, target, nil), "token", 3600)
+		return rec.Result().Cookies()[0]
+	}
+	if c := sessionCookie("https://podman.example.com/api/auth/login"); c.SameSite != http.SameSiteNoneMode || !c.Secure {
+		t.Errorf("HTTPS cookie SameSite = %v, Secure = %v; want None and Secure", c.SameSite, c.Secure)
+	}
+	if c := sessionCookie("http://podman.example.com/api/auth/login"); c.SameSite != http.SameSiteStrictMode {
+		t.Errorf("plain HTTP cookie SameSite = %v; want Strict", c.SameSite)
+	}
+
+	token := sessionToken(t, cfg, testAdmin)
+	do := func(method, target, from string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "https://podman.example.com"+target, nil)
+		req.Header = header
+		req.Header.Set("Origin", from)
+		req.Header.Set("Sec-Fetch-Site", "cross-site")
+		req.AddCookie(&http.Cookie{Name: auth.CookieName, Value: token})
+		rec := httptest.NewRecorder()
+		server.Router().ServeHTTP(rec, req)
+		return rec
+	}
+
+	preflight := do(http.MethodOptions, "/api/auth/logout", origin, http.Header{
+		"Access-Control-Request-Method":  {http.MethodPost},
+		"Access-Control-Request-Headers": {"content-type"},
+	})
+	if preflight.Code != http.StatusNoContent || preflight.Header().Get("Access-Control-Allow-Credentials") != "true" ||
+		!strings.Contains(preflight.Header().Get("Access-Control-Allow-Methods"), http.MethodPost) {
+		t.Fatalf("preflight = %d %v", preflight.Code, preflight.Header())
+	}
+
+	// The follow-up request is authenticated by the cookie
+	rec := do(http.MethodGet, "/api/auth/me", origin, http.Header{})
+	if rec.Code != http.StatusOK || rec.Header().Get("Access-Control-Allow-Origin") != origin || !strings.Contains(rec.Body.String(), `"admin"`) {
+		t.Errorf("credentialed GET = %d %s %v", rec.Code, rec.Body.String(), rec.Header())
+	}
+	rec = do(http.MethodPost, "/api/auth/logout", origin, http.Header{"Content-Type": {"application/json"}})
+	if rec.Code != http.StatusOK || rec.Header().Get("Access-Control-Allow-Origin") != origin {
+		t.Errorf("credentialed POST = %d %s", rec.Code, rec.Body.String())
+	}
+
+	// Other sites get the cookie sent too, but can't change anything
+	rec = do(http.MethodPost, "/api/auth/logout", "https://evil.example.org", http.Header{})
+	if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "cross_site_request") {
+		t.Errorf("POST from another site = %d %s; want 403 cross_site_request", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,128 @@
+package tests
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"podmanview/internal/api"
+	"podmanview/internal/config"
+	"podmanview/internal/podman"
+	"podmanview/internal/storage"
+)
+
+func TestDiagnostics(t *testing.T) {
+	client := servePodman(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"host": {"hostname": "pi"}, "version": {"Version": "5.2.1"}}`))
+	}))
+	cfg := newTestConfig(t)
+	store, err := storage.NewBoltStorage(filepath.Join(t.TempDir(), "podmanview.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	token := sessionToken(t, cfg, testAdmin)
+	diagnostics := func(server *api.Server) (string, map[string]api.DiagnosticCheck) {
+		rec := requester(server, token)(http.MethodGet, "/api/system/diagnostics", "")
+		var resp api.DiagnosticsResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusOK {
+			t.Fatalf("diagnostics = %d %s", rec.Code, rec.Body.String())
+		}
+		checks := make(map[string]api.DiagnosticCheck)
+		for _, check := range resp.Checks {
+			checks[check.Name] = check
+		}
+		if len(checks) != 7 || resp.Checks[0].Name != "podman" {
+			t.Errorf("checks = %+v", resp.Checks)
+		}
+		return resp.Status, checks
+	}
+
+	_, checks := diagnostics(api.NewServerWithPlugins(client, cfg, "test", "test", nil, nil, store))
+	want := map[string]string{"podman": "Podman 5.2.1", "storage": "writable", "mqtt": "Not configured", "web_assets": "embedded"}
+	for name, detail := range want {
+		if check := checks[name]; check.Status != api.DiagnosticPass || !strings.Contains(check.Detail, detail) {
+			t.Errorf("%s = %+v; want pass with %q", name, check, detail)
+		}
+	}
+	if _, err := store.Get("diagnostics", "probe"); err == nil {
+		t.Error("storage probe left behind")
+	}
+
+	// No database and an unreachable Podman: its socket is left behind
+	// with nothing listening
+	socket := filepath.Join(t.TempDir(), "podman.sock")
+	listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: socket, Net: "unix"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener.SetUnlinkOnClose(false)
+	listener.Close()
+	unreachable, err := podman.NewClientWithSocket(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, checks := diagnostics(api.NewServer(unreachable, cfg, "test", "test"))
+	if status != api.DiagnosticFail {
+		t.Errorf("status = %q; want fail", status)
+	}
+	for _, name := range []string{"podman", "storage"} {
+		if checks[name].Status != api.DiagnosticFail {
+			t.Errorf("%s = %+v; want fail", name, checks[name])
+		}
+	}
+}
+
+func TestRuntimeStats(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	token := sessionToken(t, cfg, testAdmin)
+	get := func(server *api.Server, target string) *httptest.ResponseRecorder {
+		return requester(server, token)(http.MethodGet, target, "")
+	}
+
+	server := api.NewServer(nil, cfg, "test", "test")
+	rec := get(server, "/api/system/runtime")
+	var stats api.RuntimeStats
+	if err := json.Unmarshal(rec.Body.Bytes(), &stats); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("runtime = %d %s", rec.Code, rec.Body.String())
+	}
+	if stats.Goroutines == 0 || stats.HeapAlloc == 0 || stats.GoVersion == "" {
+		t.Errorf("runtime = %+v", stats)
+	}
+
+	// Profiles are served only when enabled
+	if rec := get(server, "/debug/pprof/goroutine?debug=1"); strings.Contains(rec.Body.String(), "goroutine profile") {
+		t.Error("pprof served without PODMANVIEW_PPROF")
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("PODMANVIEW_PPROF=true\n")
+	f.Close()
+	cfg, err = config.Load(path)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	server = api.NewServer(nil, cfg, "test", "test")
+	if rec := get(server, "/debug/pprof/goroutine?debug=1"); !strings.Contains(rec.Body.String(), "goroutine profile") {
+		t.Errorf("pprof = %d; want goroutine profile", rec.Code)
+	}
+
+	// Still behind auth
+	rec = httptest.NewRecorder()
+	server.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/pprof/heap", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("pprof without auth = %d; want 401", rec.Code)
+	}
+}
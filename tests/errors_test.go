@@ -0,0 +1,64 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"podmanview/internal/api"
+	"podmanview/internal/auth"
+)
+
+func TestErrorEnvelope(t *testing.T) {
+	cfg := newTestConfig(t)
+
+	server := api.NewServer(nil, cfg, "test", "test")
+	token := sessionToken(t, cfg, testReader)
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		token    string
+		wantCode int
+		wantErr  string
+	}{
+		{"no cookie", http.MethodGet, "/api/containers", "", "", http.StatusUnauthorized, "unauthorized"},
+		{"readonly", http.MethodPost, "/api/images/pull", "", token, http.StatusForbidden, "forbidden"},
+		{"bad login body", http.MethodPost, "/api/auth/login", "{", "", http.StatusBadRequest, "invalid_body"},
+		{"empty login", http.MethodPost, "/api/auth/login", "{}", "", http.StatusBadRequest, "missing_field"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.token != "" {
+				req.AddCookie(&http.Cookie{Name: auth.CookieName, Value: tt.token})
+			}
+			rec := httptest.NewRecorder()
+
+			server.Router().ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("%s %s = %d; want %d", tt.method, tt.path, rec.Code, tt.wantCode)
+			}
+			if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+				t.Errorf("Content-Type = %q; want application/json", ct)
+			}
+
+			var resp api.ErrorResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decoding %q: %v", rec.Body.String(), err)
+			}
+			if resp.Error.Code != tt.wantErr {
+				t.Errorf("error code = %q; want %q", resp.Error.Code, tt.wantErr)
+			}
+			if resp.Error.Message == "" {
+				t.Error("error message is empty")
+			}
+		})
+	}
+}
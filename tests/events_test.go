@@ -0,0 +1,118 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"podmanview/internal/api"
+	"podmanview/internal/config"
+	"podmanview/internal/events"
+	"podmanview/internal/plugins"
+)
+
+func TestEventStoreFilterByMeta(t *testing.T) {
+	store := events.NewStore(10)
+	store.AddWithMeta(events.EventContainerStart, "alice", "10.0.0.1", true, "abc", events.Meta{"container": "abc"})
+	store.AddWithMeta(events.EventContainerStop, "bob", "10.0.0.2", true, "def", events.Meta{"container": "def"})
+	store.AddWithMeta(events.EventContainerStop, "alice", "10.0.0.1", false, "abc", events.Meta{"container": "abc"})
+	store.Add(events.EventLogin, "alice", "10.0.0.1", true, "")
+
+	failed := false
+	tests := []struct {
+		name   string
+		filter events.Filter
+		want   []int64
+	}{
+		{"by meta", events.Filter{Meta: events.Meta{"container": "abc"}}, []int64{3, 1}},
+		{"by type", events.Filter{Type: events.EventContainerStop}, []int64{3, 2}},
+		{"by user and meta", events.Filter{Username: "bob", Meta: events.Meta{"container": "abc"}}, nil},
+		{"by success", events.Filter{Success: &failed}, []int64{3}},
+		{"since", events.Filter{Username: "alice", SinceID: 2}, []int64{4, 3}},
+		{"limit", events.Filter{Username: "alice", Limit: 1}, []int64{4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := store.Filter(tt.filter)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Filter() returned %d events; want %d", len(got), len(tt.want))
+			}
+			for i, e := range got {
+				if e.ID != tt.want[i] {
+					t.Errorf("Filter()[%d].ID = %d; want %d", i, e.ID, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLoginLockoutEvent(t *testing.T) {
+	cfg, err := config.Load(filepath.Join(t.TempDir(), ".env"))
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	store := events.NewStore(100)
+	lockouts := make(chan events.Event, 10)
+	store.Subscribe(func(e events.Event) {
+		if e.Type == events.EventLoginLockout {
+			lockouts <- e
+		}
+	})
+	registry := plugins.NewRegistry()
+	registry.SetDependencies(&plugins.PluginDependencies{EventStore: store})
+	server := api.NewServerWithPlugins(nil, cfg, "test", "test", nil, registry, nil)
+
+	// Attempts count before the credentials are checked, so empty ones do
+	codes := make([]int, 0, 8)
+	for range 8 {
+		req := httptest.NewRequest(http.MethodPost, "/api/auth/login", strings.NewReader(`{"username": "root"}`))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		server.Router().ServeHTTP(rec, req)
+		codes = append(codes, rec.Code)
+	}
+	if codes[4] != http.StatusBadRequest || codes[5] != http.StatusTooManyRequests {
+		t.Errorf("codes = %v; want 400 until the 6th attempt", codes)
+	}
+
+	select {
+	case e := <-lockouts:
+		if e.IP != "192.0.2.1" || e.Meta["attempts"] != "6" || e.Success {
+			t.Errorf("lockout = %+v; want IP 192.0.2.1 after 6 attempts", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no lockout event")
+	}
+	// Once per lockout, not for every blocked attempt
+	select {
+	case e := <-lockouts:
+		t.Errorf("second lockout event %+v", e)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestHostResolverCacheLimit(t *testing.T) {
+	resolver := events.NewHostResolver(3)
+
+	// Unparseable addresses fail without a DNS query and are cached as
+	// unresolved, like IPs without a PTR record
+	for _, ip := range []string{"host-a", "host-b", "host-c"} {
+		resolver.Lookup(ip)
+	}
+	resolver.Cached("host-a") // most recently used, so host-b goes next
+	resolver.Lookup("host-d")
+	resolver.Lookup("host-e")
+
+	if n := resolver.Len(); n != 3 {
+		t.Errorf("Len() = %d; want 3", n)
+	}
+	for ip, want := range map[string]bool{"host-a": true, "host-b": false, "host-c": false, "host-d": true, "host-e": true} {
+		if _, ok := resolver.Cached(ip); ok != want {
+			t.Errorf("Cached(%q) = %v; want %v", ip, ok, want)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"podmanview/internal/auth"
+	"podmanview/internal/podman"
+)
+
+// fakeExecPodman serves the exec endpoints of the Podman API over a unix
+// socket, running cat and sh -c 'cat > "$1"' against an in-memory filesystem
+type fakeExecPodman struct {
+	podmanMux
+	mu    sync.Mutex
+	files map[string]string
+	execs map[string]podman.ExecConfig
+	exits map[string]int
+}
+
+func newFakeExecPodman(files map[string]string) *fakeExecPodman {
+	f := &fakeExecPodman{files: files, execs: map[string]podman.ExecConfig{}, exits: map[string]int{}}
+	f.HandleFunc("POST /containers/{name}/exec", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("name") == "stopped" {
+			http.Error(w, "container state improper", http.StatusConflict)
+			return
+		}
+		var cfg podman.ExecConfig
+		json.NewDecoder(r.Body).Decode(&cfg)
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		id := fmt.Sprintf("exec%d", len(f.execs))
+		f.execs[id] = cfg
+		json.NewEncoder(w).Encode(podman.ExecCreateResponse{ID: id})
+	})
+	f.HandleFunc("POST /exec/{id}/start", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		id := r.PathValue("id")
+		io.Copy(io.Discard, r.Body) // the start options precede the stream
+		f.start(w, id, f.execs[id])
+	})
+	f.HandleFunc("GET /exec/{id}/json", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		json.NewEncoder(w).Encode(podman.ExecInspect{ExitCode: f.exits[r.PathValue("id")]})
+	})
+	return f
+}
+
+// start hijacks the connection and runs the command like Podman would
+func (f *fakeExecPodman) start(w http.ResponseWriter, id string, cfg podman.ExecConfig) {
+	conn, rw, err := w.(http.Hijacker).Hijack()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	rw.WriteString("HTTP/1.1 101 UPGRADED\r\nConnection: Upgrade\r\nUpgrade: tcp\r\n\r\n")
+	rw.Flush()
+
+	var stdin []byte
+	if cfg.AttachStdin {
+		stdin, _ = io.ReadAll(rw)
+	}
+
+	frame := func(stream byte, data string) {
+		header := make([]byte, 8)
+		header[0] = stream
+		binary.BigEndian.PutUint32(header[4:], uint32(len(data)))
+		conn.Write(append(header, data...))
+	}
+
+	switch {
+	case cfg.Cmd[0] == "cat":
+		content, ok := f.files[cfg.Cmd[1]]
+		if !ok {
+			frame(2, "cat: can't open '"+cfg.Cmd[1]+"': No such file or directory\n")
+			f.exits[id] = 1
+			return
+		}
+		frame(1, content)
+	case cfg.Cmd[0] == "sh":
+		f.files[cfg.Cmd[4]] = string(stdin)
+	}
+}
+
+func TestContainerExecFiles(t *testing.T) {
+	fake := newFakeExecPodman(map[string]string{"/etc/app.conf": "key=value\n"})
+	server, token, _ := newTestAPI(t, servePodman(t, fake), newTestConfig(t), testAdmin)
+
+	do := func(target, contentType string, body io.Reader) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, target, body)
+		req.Header.Set("Content-Type", contentType)
+		req.AddCookie(&http.Cookie{Name: auth.CookieName, Value: token})
+		rec := httptest.NewRecorder()
+		server.Router().ServeHTTP(rec, req)
+		return rec
+	}
+	download := func(container, path string) *httptest.ResponseRecorder {
+		return do("/api/containers/"+container+"/exec/download", "application/json",
+			strings.NewReader(`{"path":"`+path+`"}`))
+	}
+	upload := func(path, name, content string) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		mw := multipart.NewWriter(&buf)
+		mw.WriteField("path", path)
+		part, _ := mw.CreateFormFile("file", name)
+		part.Write([]byte(content))
+		mw.Close()
+		return do("/api/containers/web/exec/upload", mw.FormDataContentType(), &buf)
+	}
+
+	rec := download("web", "/etc/../etc/app.conf")
+	if rec.Code != http.StatusOK || rec.Body.String() != "key=value\n" {
+		t.Fatalf("download = %d %q", rec.Code, rec.Body.String())
+	}
+	if cd := rec.Header().Get("Content-Disposition"); cd != `attachment; filename="app.conf"` {
+		t.Errorf("Content-Disposition = %q", cd)
+	}
+
+	// Uploading to a directory keeps the file name
+	if rec := upload("/tmp/", "data.bin", "\x00binary\xff"); rec.Code != http.StatusOK {
+		t.Fatalf("upload = %d (%s)", rec.Code, rec.Body.String())
+	}
+	if got := fake.files["/tmp/data.bin"]; got != "\x00binary\xff" {
+		t.Errorf("uploaded content = %q", got)
+	}
+
+	errorCases := []struct {
+		name string
+		rec  *httptest.ResponseRecorder
+		want int
+	}{
+		{"missing file", download("web", "/missing"), http.StatusNotFound},
+		{"relative path", download("web", "etc/app.conf"), http.StatusBadRequest},
+		{"stopped container", download("stopped", "/etc/app.conf"), http.StatusConflict},
+		{"upload without path", upload("", "a.txt", "x"), http.StatusBadRequest},
+	}
+	for _, tt := range errorCases {
+		if tt.rec.Code != tt.want {
+			t.Errorf("%s = %d (%s); want %d", tt.name, tt.rec.Code, tt.rec.Body.String(), tt.want)
+		}
+	}
+}
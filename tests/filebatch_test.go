@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"podmanview/internal/api"
+)
+
+func TestFileBatch(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	setup := func() {
+		for _, dir := range []string{"src/sub", "dest"} {
+			if err := os.MkdirAll(filepath.Join(home, dir), 0755); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, name := range []string{"src/a.txt", "src/b.txt", "src/sub/c.txt", "dest/b.txt"} {
+			if err := os.WriteFile(filepath.Join(home, name), []byte("content\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	_, _, request := newTestAPI(t, nil, newTestConfig(t), testAdmin)
+
+	batch := func(body string) (int, api.BatchResponse) {
+		rec := request(http.MethodPost, "/api/files/batch", body)
+		var resp api.BatchResponse
+		if rec.Code == http.StatusOK {
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return rec.Code, resp
+	}
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(home, name))
+		return err == nil
+	}
+	codes := func(resp api.BatchResponse) []string {
+		var out []string
+		for _, r := range resp.Results {
+			if r.Success {
+				out = append(out, "ok")
+			} else {
+				out = append(out, r.Error.Code)
+			}
+		}
+		return out
+	}
+
+	// Best effort: the conflicting and missing paths fail, the rest move
+	setup()
+	code, resp := batch(`{"action":"move","dest_dir":"/dest","paths":["/src/a.txt","/src/b.txt","/missing","/src/sub"]}`)
+	if code != http.StatusOK {
+		t.Fatalf("move = %d", code)
+	}
+	if got := strings.Join(codes(resp), ","); got != "ok,file_exists,file_not_found,ok" {
+		t.Errorf("move results = %s", got)
+	}
+	if resp.Succeeded != 2 || resp.Failed != 2 || resp.Results[0].NewPath != "/dest/a.txt" {
+		t.Errorf("move response = %+v", resp)
+	}
+	if !exists("dest/a.txt") || !exists("dest/sub/c.txt") || !exists("src/b.txt") || exists("src/a.txt") {
+		t.Error("move left unexpected files")
+	}
+
+	// Stop on error: paths after the first failure are skipped
+	os.RemoveAll(filepath.Join(home, "src"))
+	os.RemoveAll(filepath.Join(home, "dest"))
+	setup()
+	_, resp = batch(`{"action":"delete","stop_on_error":true,"paths":["/src/a.txt","/missing","/src/b.txt"]}`)
+	if got := strings.Join(codes(resp), ","); got != "ok,file_not_found,skipped" {
+		t.Errorf("delete results = %s", got)
+	}
+	if exists("src/a.txt") || !exists("src/b.txt") {
+		t.Error("stop_on_error deleted unexpected files")
+	}
+
+	// Moving a directory into itself and touching the base directory fail
+	_, resp = batch(`{"action":"move","dest_dir":"/src/sub","paths":["/src","/"]}`)
+	if got := strings.Join(codes(resp), ","); got != "invalid_path,base_directory" {
+		t.Errorf("invalid move results = %s", got)
+	}
+
+	badRequests := []struct {
+		body string
+		want int
+	}{
+		{`{"action":"delete","paths":[]}`, http.StatusBadRequest},
+		{`{"action":"copy","paths":["/src"]}`, http.StatusBadRequest},
+		{`{"action":"move","paths":["/src"]}`, http.StatusBadRequest},
+		{`{"action":"move","dest_dir":"/nowhere","paths":["/src"]}`, http.StatusNotFound},
+		{`{"action":"move","dest_dir":"/src/b.txt","paths":["/src"]}`, http.StatusBadRequest},
+	}
+	for _, tt := range badRequests {
+		if code, _ := batch(tt.body); code != tt.want {
+			t.Errorf("%s = %d; want %d", tt.body, code, tt.want)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"podmanview/internal/api"
+)
+
+func TestReadFileLanguage(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	files := map[string]string{
+		"main.go":              "go",
+		"compose.YML":          "yaml",
+		"web.container":        "ini",
+		"Containerfile":        "dockerfile",
+		"Dockerfile.dev":       "dockerfile",
+		"notes.txt":            "",
+		"README":               "",
+		"scripts/backup.sh":    "shell",
+		"config/settings.toml": "toml",
+	}
+	for name := range files {
+		path := filepath.Join(home, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("content\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, _, request := newTestAPI(t, nil, newTestConfig(t), testAdmin)
+
+	for name, want := range files {
+		rec := request(http.MethodGet, "/api/files/read?path="+url.QueryEscape("/"+name), "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("read %s = %d (%s)", name, rec.Code, rec.Body.String())
+		}
+		var resp struct {
+			Language string `json:"language"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decoding %s: %v", name, err)
+		}
+		if resp.Language != want {
+			t.Errorf("%s language = %q; want %q", name, resp.Language, want)
+		}
+	}
+}
+
+func TestBrowseDiskUsage(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	_, _, request := newTestAPI(t, nil, newTestConfig(t), testAdmin)
+
+	rec := request(http.MethodGet, "/api/files/browse?path=/", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("browse = %d (%s)", rec.Code, rec.Body.String())
+	}
+	var resp api.BrowseResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.DiskTotal == 0 || resp.DiskFree > resp.DiskTotal {
+		t.Errorf("disk free/total = %d/%d", resp.DiskFree, resp.DiskTotal)
+	}
+}
+
+func TestBrowsePagination(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	// Sizes differ per file so stat results can't be mixed up
+	for i := 0; i < 60; i++ {
+		name := filepath.Join(home, fmt.Sprintf("file%02d", i))
+		if err := os.WriteFile(name, []byte(strings.Repeat("x", i)), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, _, request := newTestAPI(t, nil, newTestConfig(t), testAdmin)
+
+	tests := []struct {
+		offset, limit int
+		first, count  int
+		hasMore       bool
+	}{
+		{0, 25, 0, 25, true},
+		{25, 25, 25, 25, true},
+		{50, 25, 50, 10, false},
+		{100, 25, 60, 0, false},
+	}
+	for _, tt := range tests {
+		rec := request(http.MethodGet, fmt.Sprintf("/api/files/browse?path=/&offset=%d&limit=%d", tt.offset, tt.limit), "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("browse offset=%d = %d (%s)", tt.offset, rec.Code, rec.Body.String())
+		}
+		var resp api.BrowseResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.TotalCount != 60 || resp.HasMore != tt.hasMore || len(resp.Items) != tt.count {
+			t.Fatalf("offset=%d: total=%d has_more=%v items=%d", tt.offset, resp.TotalCount, resp.HasMore, len(resp.Items))
+		}
+		for i, item := range resp.Items {
+			n := tt.first + i
+			if want := fmt.Sprintf("file%02d", n); item.Name != want || item.Size != int64(n) {
+				t.Errorf("offset=%d item %d = %s (%d bytes); want %s (%d bytes)", tt.offset, i, item.Name, item.Size, want, n)
+			}
+		}
+	}
+}
+
+func TestRenameDirectoryPaths(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.MkdirAll(filepath.Join(home, "old"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, "old", "a.txt"), []byte("content\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, request := newTestAPI(t, nil, newTestConfig(t), testAdmin)
+
+	do := func(method, target, body string) int {
+		return request(method, target, body).Code
+	}
+
+	// Validate (and cache) the child path before renaming its parent
+	if code := do(http.MethodGet, "/api/files/read?path=/old/a.txt", ""); code != http.StatusOK {
+		t.Fatalf("read before rename = %d", code)
+	}
+	if code := do(http.MethodPost, "/api/files/rename", `{"old_path":"/old","new_name":"new"}`); code != http.StatusOK {
+		t.Fatalf("rename = %d", code)
+	}
+	if code := do(http.MethodGet, "/api/files/read?path=/old/a.txt", ""); code != http.StatusNotFound {
+		t.Errorf("read old path = %d; want %d", code, http.StatusNotFound)
+	}
+	if code := do(http.MethodGet, "/api/files/read?path=/new/a.txt", ""); code != http.StatusOK {
+		t.Errorf("read new path = %d; want %d", code, http.StatusOK)
+	}
+}
+
+func TestStreamContentTypeSniffing(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	pngData := append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 100)...)
+	files := map[string][]byte{
+		"image.bin": pngData,
+		"image":     pngData,
+		"notes":     []byte("plain text\n"),
+		"page.html": []byte("\x89PNG\r\n\x1a\n"), // known extension wins
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(home, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, _, request := newTestAPI(t, nil, newTestConfig(t), testAdmin)
+
+	tests := []struct {
+		endpoint, name, want string
+	}{
+		{"stream", "image.bin", "image/png"},
+		{"stream", "image", "image/png"},
+		{"stream", "notes", "text/plain; charset=utf-8"},
+		{"stream", "page.html", "text/html"},
+		{"download", "image", "image/png"},
+	}
+	for _, tt := range tests {
+		rec := request(http.MethodGet, "/api/files/"+tt.endpoint+"?path=/"+tt.name, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s %s = %d", tt.endpoint, tt.name, rec.Code)
+		}
+		if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, tt.want) {
+			t.Errorf("%s %s Content-Type = %q; want %q", tt.endpoint, tt.name, ct, tt.want)
+		}
+		// Sniffing must not consume the start of the file
+		if !bytes.Equal(rec.Body.Bytes(), files[tt.name]) {
+			t.Errorf("%s %s body differs from the file", tt.endpoint, tt.name)
+		}
+	}
+}
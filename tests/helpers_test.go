@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"podmanview/internal/api"
+	"podmanview/internal/auth"
+	"podmanview/internal/config"
+	"podmanview/internal/podman"
+)
+
+// Users the request tests sign session tokens for
+var (
+	testAdmin  = &auth.User{Username: "admin", Role: auth.RoleAdmin}
+	testReader = &auth.User{Username: "reader", Role: auth.RoleReadOnly}
+)
+
+// libpodPrefix is put in front of every libpod API path by the client
+const libpodPrefix = "/v4.0.0/libpod"
+
+// podmanMux is a small routable fake Podman. Patterns are registered
+// without the libpod prefix, e.g. "GET /containers/{name}/json"; requests
+// nothing matches get a 404.
+type podmanMux struct {
+	http.ServeMux
+}
+
+func (m *podmanMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	http.StripPrefix(libpodPrefix, &m.ServeMux).ServeHTTP(w, r)
+}
+
+// servePodman serves handler as the Podman API on a unix socket until the
+// test ends and returns a client for it
+func servePodman(t *testing.T, handler http.Handler) *podman.Client {
+	t.Helper()
+	socket := filepath.Join(t.TempDir(), "podman.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{Handler: handler}
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Close() })
+
+	client, err := podman.NewClientWithSocket(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+// newTestConfig loads a config with defaults from an empty directory
+func newTestConfig(t *testing.T) *config.Config {
+	t.Helper()
+	cfg, err := config.Load(filepath.Join(t.TempDir(), ".env"))
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	return cfg
+}
+
+// sessionToken signs a session token for user with cfg's secret
+func sessionToken(t *testing.T, cfg *config.Config, user *auth.User) string {
+	t.Helper()
+	token, err := auth.NewJWTManager(cfg.JWTSecret(), cfg.JWTExpiration()).GenerateToken(user)
+	if err != nil {
+		t.Fatalf("GenerateToken() failed: %v", err)
+	}
+	return token
+}
+
+// requestFunc sends a request through an API server and returns the
+// recorded response. A non-empty body is sent as JSON.
+type requestFunc func(method, target, body string) *httptest.ResponseRecorder
+
+// requester returns a requestFunc for server sending token as the session
+// cookie, or no cookie when token is empty
+func requester(server *api.Server, token string) requestFunc {
+	router := server.Router()
+	return func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if token != "" {
+			req.AddCookie(&http.Cookie{Name: auth.CookieName, Value: token})
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+}
+
+// newTestAPI creates an API server on client and cfg and returns it with a
+// session token for user and a requestFunc sending that token
+func newTestAPI(t *testing.T, client *podman.Client, cfg *config.Config, user *auth.User) (*api.Server, string, requestFunc) {
+	t.Helper()
+	server := api.NewServer(client, cfg, "test", "test")
+	token := sessionToken(t, cfg, user)
+	return server, token, requester(server, token)
+}
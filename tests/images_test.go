@@ -0,0 +1,265 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"podmanview/internal/api"
+	"podmanview/internal/podman"
+)
+
+func TestImageList(t *testing.T) {
+	var filters map[string][]string
+	fake := &podmanMux{}
+	fake.HandleFunc("GET /images/json", func(w http.ResponseWriter, r *http.Request) {
+		filters = nil
+		if v := r.URL.Query().Get("filters"); v != "" {
+			json.Unmarshal([]byte(v), &filters)
+		}
+		w.Write([]byte(`[
+			{"Id": "old", "RepoTags": ["nginx:1.24"], "Created": 100},
+			{"Id": "new", "RepoTags": ["nginx:1.25"], "Created": 300},
+			{"Id": "mid", "RepoTags": ["redis:7"], "Created": 200}
+		]`))
+	})
+	fake.HandleFunc("GET /containers/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"Id": "a", "ImageID": "new"}, {"Id": "b", "ImageID": "new"}, {"Id": "c", "ImageID": "mid"}]`))
+	})
+	_, _, request := newTestAPI(t, servePodman(t, fake), newTestConfig(t), testReader)
+
+	list := func(query string) *httptest.ResponseRecorder {
+		return request(http.MethodGet, "/api/images"+query, "")
+	}
+
+	// Without pagination: every image, newest first, with container counts
+	rec := list("")
+	var all []api.ImageWithUsage
+	if err := json.Unmarshal(rec.Body.Bytes(), &all); err != nil {
+		t.Fatalf("list = %d %s", rec.Code, rec.Body.String())
+	}
+	if len(all) != 3 || all[0].ID != "new" || all[0].Containers != 2 || !all[0].InUse || all[2].InUse {
+		t.Errorf("images = %+v", all)
+	}
+
+	// Filters reach Podman; a page is wrapped with the total
+	rec = list("?dangling=false&reference=nginx*&reference=redis*&offset=1&limit=1")
+	want := map[string][]string{"dangling": {"false"}, "reference": {"nginx*", "redis*"}}
+	if !reflect.DeepEqual(filters, want) {
+		t.Errorf("filters = %v; want %v", filters, want)
+	}
+	var page api.ImageListResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatalf("page = %d %s", rec.Code, rec.Body.String())
+	}
+	if page.TotalCount != 3 || !page.HasMore || len(page.Images) != 1 || page.Images[0].ID != "mid" || page.Images[0].Containers != 1 {
+		t.Errorf("page = %+v", page)
+	}
+
+	if rec := list("?offset=10"); !strings.Contains(rec.Body.String(), `"images":[]`) {
+		t.Errorf("page past the end = %s", rec.Body.String())
+	}
+	if rec := list("?dangling=maybe"); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid dangling = %d; want 400", rec.Code)
+	}
+}
+
+func TestImagePrune(t *testing.T) {
+	var gotQuery string
+	fake := &podmanMux{}
+	fake.HandleFunc("POST /images/prune", func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.RawQuery
+		w.Write([]byte(`[{"Id": "sha-a", "Size": 1000}, {"Id": "sha-b", "Size": 24}]`))
+	})
+	_, _, request := newTestAPI(t, servePodman(t, fake), newTestConfig(t), testAdmin)
+
+	prune := func(body string) *httptest.ResponseRecorder {
+		gotQuery = ""
+		return request(http.MethodPost, "/api/images/prune", body)
+	}
+
+	// Dangling only by default
+	rec := prune(`{}`)
+	if rec.Code != http.StatusOK || gotQuery != "all=false" {
+		t.Fatalf("prune = %d (%s), query %q", rec.Code, rec.Body.String(), gotQuery)
+	}
+	var resp api.ImagePruneResponse
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	if !reflect.DeepEqual(resp.Removed, []string{"sha-a", "sha-b"}) || resp.ReclaimedBytes != 1024 {
+		t.Errorf("prune response = %+v", resp)
+	}
+
+	// Unused images older than a week with a label
+	rec = prune(`{"all": true, "until": "168h", "labels": ["env=dev"]}`)
+	want := "all=true&filters=" + url.QueryEscape(`{"label":["env=dev"],"until":["168h0m0s"]}`)
+	if rec.Code != http.StatusOK || gotQuery != want {
+		t.Errorf("prune query = %q; want %q", gotQuery, want)
+	}
+
+	for _, body := range []string{`{"until": "a week"}`, `{"until": "-1h"}`, `{"labels": ["=dev"]}`} {
+		if rec := prune(body); rec.Code != http.StatusBadRequest || gotQuery != "" {
+			t.Errorf("prune %s = %d; want 400 without calling Podman", body, rec.Code)
+		}
+	}
+}
+
+func TestImagePinnedReference(t *testing.T) {
+	images := map[string]string{
+		// Tagged in two repositories, two digests for the first
+		"pulled": `{"Id": "pulled", "RepoTags": ["docker.io/library/nginx:1.27", "docker.io/library/nginx:latest", "registry.lan:5000/nginx:1.27"],
+			"RepoDigests": ["docker.io/library/nginx@sha256:aaa", "docker.io/library/nginx@sha256:bbb", "registry.lan:5000/nginx@sha256:ccc"]}`,
+		"built":    `{"Id": "built", "RepoTags": ["localhost/app:dev"], "RepoDigests": []}`,
+		"bydigest": `{"Id": "bydigest", "RepoTags": [], "RepoDigests": ["quay.io/team/tool@sha256:ddd"]}`,
+		"dangling": `{"Id": "dangling", "RepoTags": null, "RepoDigests": null}`,
+	}
+	fake := &podmanMux{}
+	fake.HandleFunc("GET /images/{id}/json", func(w http.ResponseWriter, r *http.Request) {
+		if image, ok := images[r.PathValue("id")]; ok {
+			w.Write([]byte(image))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"cause": "image not known", "message": "no such image", "response": 404}`))
+	})
+	_, _, request := newTestAPI(t, servePodman(t, fake), newTestConfig(t), testReader)
+	get := func(id string) *httptest.ResponseRecorder {
+		return request(http.MethodGet, "/api/images/"+id+"/pinned-reference", "")
+	}
+
+	tests := map[string][]api.PinnedReference{
+		"pulled": {
+			{Tag: "docker.io/library/nginx:1.27", Reference: "docker.io/library/nginx@sha256:aaa", Pinned: true},
+			{Tag: "docker.io/library/nginx:latest", Reference: "docker.io/library/nginx@sha256:aaa", Pinned: true},
+			{Tag: "registry.lan:5000/nginx:1.27", Reference: "registry.lan:5000/nginx@sha256:ccc", Pinned: true},
+		},
+		"built":    {{Tag: "localhost/app:dev", Reference: "built"}},
+		"bydigest": {{Reference: "quay.io/team/tool@sha256:ddd", Pinned: true}},
+		"dangling": {{Reference: "dangling"}},
+	}
+	for id, want := range tests {
+		rec := get(id)
+		var resp api.PinnedReferenceResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusOK {
+			t.Fatalf("%s = %d %s", id, rec.Code, rec.Body.String())
+		}
+		if resp.ID != id || !reflect.DeepEqual(resp.References, want) {
+			t.Errorf("%s = %+v; want %+v", id, resp.References, want)
+		}
+	}
+
+	if rec := get("missing"); rec.Code != http.StatusNotFound {
+		t.Errorf("missing image = %d; want 404", rec.Code)
+	}
+}
+
+func TestImageSearch(t *testing.T) {
+	var gotQuery url.Values
+	fake := &podmanMux{}
+	fake.HandleFunc("GET /images/search", func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.Query()
+		if strings.HasPrefix(gotQuery.Get("term"), "ghcr.io/") {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"cause": "StatusCode: 404", "message": "couldn't search registry \"ghcr.io\"", "response": 500}`))
+			return
+		}
+		w.Write([]byte(`[
+			{"Index": "docker.io", "Name": "docker.io/library/nginx", "Description": "Official build of Nginx.", "Stars": 20000, "Official": "[OK]", "Automated": ""},
+			{"Index": "docker.io", "Name": "docker.io/bitnami/nginx", "Description": "Bitnami nginx", "Stars": 200, "Official": "", "Automated": ""}
+		]`))
+	})
+	_, _, request := newTestAPI(t, servePodman(t, fake), newTestConfig(t), testAdmin)
+	search := func(query string) *httptest.ResponseRecorder {
+		gotQuery = nil
+		return request(http.MethodGet, "/api/images/search?"+query, "")
+	}
+
+	rec := search("q=nginx")
+	var resp api.ImageSearchResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("search = %d %s", rec.Code, rec.Body.String())
+	}
+	if gotQuery.Get("term") != "nginx" || gotQuery.Get("limit") != "25" {
+		t.Errorf("query = %v", gotQuery)
+	}
+	want := podman.ImageSearchResult{Index: "docker.io", Name: "docker.io/library/nginx", Description: "Official build of Nginx.", Stars: 20000, Official: true}
+	if len(resp.Results) != 2 || resp.Results[0] != want || resp.Results[1].Official {
+		t.Errorf("results = %+v", resp.Results)
+	}
+
+	if search("q=nginx&registry=registry.lan:5000"); gotQuery.Get("term") != "registry.lan:5000/nginx" {
+		t.Errorf("term = %q; want the registry prefixed", gotQuery.Get("term"))
+	}
+
+	// Registries without a search API
+	if rec := search("q=app&registry=ghcr.io"); rec.Code != http.StatusBadGateway || !strings.Contains(rec.Body.String(), "search_unavailable") {
+		t.Errorf("ghcr.io = %d %s; want 502 search_unavailable", rec.Code, rec.Body.String())
+	}
+
+	for _, query := range []string{"", "q=nginx:latest", "q=nginx&registry=https://quay.io", "q=nginx&registry=quay.io:99999"} {
+		if rec := search(query); rec.Code != http.StatusBadRequest || gotQuery != nil {
+			t.Errorf("search %q = %d; want 400 without calling Podman", query, rec.Code)
+		}
+	}
+}
+
+func TestParsePlatform(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"", "", false},
+		{"linux/arm64", "linux/arm64", false},
+		{"Linux/AMD64", "linux/amd64", false},
+		{"linux/arm/v7", "linux/arm/v7", false},
+		{"linux/arm64/v8", "linux/arm64/v8", false},
+		{"linux/arm/v8", "", true},
+		{"linux/sparc", "", true},
+		{"darwin/arm64", "", true},
+		{"arm64", "", true},
+		{"linux/arm/v7/extra", "", true},
+	}
+	for _, tt := range tests {
+		p, err := podman.ParsePlatform(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParsePlatform(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if err == nil && p.String() != tt.want {
+			t.Errorf("ParsePlatform(%q) = %q, want %q", tt.input, p.String(), tt.want)
+		}
+	}
+}
+
+func TestImagePullPlatform(t *testing.T) {
+	var gotQuery url.Values
+	fake := &podmanMux{}
+	fake.HandleFunc("POST /images/pull", func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.Query()
+		w.Write([]byte(`{"stream": "Writing manifest to image destination\n"}` + "\n" + `{"images": ["sha"], "id": "sha"}` + "\n"))
+	})
+	_, _, request := newTestAPI(t, servePodman(t, fake), newTestConfig(t), testAdmin)
+	pull := func(body string) *httptest.ResponseRecorder {
+		gotQuery = nil
+		return request(http.MethodPost, "/api/images/pull", body)
+	}
+
+	// Host platform by default
+	if rec := pull(`{"reference": "alpine"}`); rec.Code != http.StatusOK || gotQuery.Get("reference") != "alpine" || gotQuery.Has("arch") {
+		t.Errorf("pull = %d, query %v", rec.Code, gotQuery)
+	}
+
+	rec := pull(`{"reference": "alpine", "platform": "linux/arm/v7"}`)
+	want := url.Values{"reference": {"alpine"}, "OS": {"linux"}, "arch": {"arm"}, "variant": {"v7"}}
+	if rec.Code != http.StatusOK || !reflect.DeepEqual(gotQuery, want) {
+		t.Errorf("pull = %d, query %v; want %v", rec.Code, gotQuery, want)
+	}
+
+	if rec := pull(`{"reference": "alpine", "platform": "linux/m68k"}`); rec.Code != http.StatusBadRequest || gotQuery != nil {
+		t.Errorf("unknown platform = %d; want 400 without calling Podman", rec.Code)
+	}
+}
@@ -0,0 +1,145 @@
+package tests
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"podmanview/internal/auth"
+	"podmanview/internal/plugins/imageupdates"
+)
+
+func TestParseImageReference(t *testing.T) {
+	tests := []struct {
+		image   string
+		want    string
+		wantErr bool
+	}{
+		{"docker.io/library/nginx:latest", "docker.io/library/nginx:latest", false},
+		{"nginx", "docker.io/library/nginx:latest", false},
+		{"nginx:1.27", "docker.io/library/nginx:1.27", false},
+		{"grafana/grafana:11.0.0", "docker.io/grafana/grafana:11.0.0", false},
+		{"index.docker.io/grafana/grafana", "docker.io/grafana/grafana:latest", false},
+		{"ghcr.io/owner/app:v2", "ghcr.io/owner/app:v2", false},
+		{"registry.lan:5000/team/app", "registry.lan:5000/team/app:latest", false},
+		{"localhost:5000/app:dev", "localhost:5000/app:dev", false},
+		{"localhost/built-here:latest", "", true},
+		{"nginx@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef", "", true},
+		{"0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef", "", true},
+		{"", "", true},
+	}
+
+	for _, tt := range tests {
+		ref, err := imageupdates.ParseReference(tt.image)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseReference(%q) error = %v, wantErr %v", tt.image, err, tt.wantErr)
+			continue
+		}
+		if err == nil && ref.String() != tt.want {
+			t.Errorf("ParseReference(%q) = %q, want %q", tt.image, ref.String(), tt.want)
+		}
+	}
+}
+
+func TestRegistryDigest(t *testing.T) {
+	const (
+		digest = "sha256:4c0fdaa8b6341bfdeca5f18f7837462c80cff90527ee35ef185571e1c327beac"
+		token  = "pull-token"
+	)
+	login := base64.StdEncoding.EncodeToString([]byte("bob:secret"))
+
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			// Token endpoint requires the login from the auth file
+			if r.Header.Get("Authorization") != "Basic "+login || r.URL.Query().Get("scope") != "repository:team/app:pull" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"token": token})
+		case r.Header.Get("Authorization") != "Bearer "+token:
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="test",scope="repository:team/app:pull"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/v2/team/app/manifests/1.0":
+			if !strings.Contains(r.Header.Get("Accept"), "manifest.list.v2+json") {
+				t.Errorf("Accept = %q; want manifest lists accepted", r.Header.Get("Accept"))
+			}
+			w.Header().Set("Docker-Content-Digest", digest)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "https://")
+	authFile := filepath.Join(t.TempDir(), "auth.json")
+	content := `{"auths": {"https://` + host + `/v1/": {"auth": "` + login + `"}}}`
+	if err := os.WriteFile(authFile, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	creds := imageupdates.LoadCredentials(filepath.Join(t.TempDir(), "missing.json"), authFile)
+	client := imageupdates.NewRegistryClient(server.Client(), creds)
+
+	ref, err := imageupdates.ParseReference(host + "/team/app:1.0")
+	if err != nil {
+		t.Fatalf("ParseReference() failed: %v", err)
+	}
+	got, err := client.Digest(context.Background(), ref)
+	if err != nil {
+		t.Fatalf("Digest() failed: %v", err)
+	}
+	if got != digest {
+		t.Errorf("Digest() = %q, want %q", got, digest)
+	}
+
+	// Unknown tag
+	ref.Tag = "2.0"
+	if _, err := client.Digest(context.Background(), ref); err == nil {
+		t.Error("Digest() of a missing tag succeeded; want error")
+	}
+
+	// No login
+	anonymous := imageupdates.NewRegistryClient(server.Client(), nil)
+	ref.Tag = "1.0"
+	if _, err := anonymous.Digest(context.Background(), ref); err == nil {
+		t.Error("Digest() without login succeeded; want error")
+	}
+}
+
+// TestImageUpdatesReadOnly checks that read-only users can see the status
+// but can't start a check or change the settings
+func TestImageUpdatesReadOnly(t *testing.T) {
+	p := imageupdates.New()
+	user := &auth.User{Username: "viewer", Role: auth.RoleReadOnly}
+
+	want := map[string]int{
+		"GET /api/plugins/image-updates/status":    http.StatusOK,
+		"POST /api/plugins/image-updates/check":    http.StatusForbidden,
+		"POST /api/plugins/image-updates/settings": http.StatusForbidden,
+	}
+	for _, route := range p.Routes() {
+		code, ok := want[route.Method+" "+route.Path]
+		if !ok {
+			continue
+		}
+		req := httptest.NewRequest(route.Method, route.Path, strings.NewReader(`{"schedule": "0 4 * * *"}`))
+		req = req.WithContext(auth.SetUserContext(req.Context(), user))
+		rec := httptest.NewRecorder()
+		route.Handler(rec, req)
+		if rec.Code != code {
+			t.Errorf("%s %s as read-only = %d; want %d", route.Method, route.Path, rec.Code, code)
+		}
+		delete(want, route.Method+" "+route.Path)
+	}
+	if len(want) > 0 {
+		t.Errorf("routes not found: %v", want)
+	}
+}
@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"podmanview/internal/auth"
+	"podmanview/internal/config"
+)
+
+func TestJWTSecretRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	oldSecret := cfg.JWTSecret()
+
+	manager := auth.NewJWTManager(oldSecret, time.Hour)
+	user := &auth.User{Username: "alice", UID: "1000", Role: auth.RoleAdmin}
+	oldToken, err := manager.GenerateToken(user)
+	if err != nil {
+		t.Fatalf("GenerateToken() failed: %v", err)
+	}
+
+	if err := cfg.RotateJWTSecret(); err != nil {
+		t.Fatalf("RotateJWTSecret() failed: %v", err)
+	}
+	previous, until := cfg.JWTPreviousSecret()
+	if cfg.JWTSecret() == oldSecret || previous != oldSecret {
+		t.Fatalf("after rotation secret changed=%v previous kept=%v", cfg.JWTSecret() != oldSecret, previous == oldSecret)
+	}
+
+	// Rotation survives a restart
+	reloaded, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if p, u := reloaded.JWTPreviousSecret(); p != oldSecret || !u.Equal(until) {
+		t.Errorf("saved previous secret = %v until %v; want it kept until %v", p == oldSecret, u, until)
+	}
+
+	// Old tokens are accepted during the grace period, new ones are signed with the new secret
+	manager.SetSecrets(cfg.JWTSecret(), previous, until)
+	if _, err := manager.ValidateToken(oldToken); err != nil {
+		t.Errorf("ValidateToken(old) during grace = %v; want nil", err)
+	}
+	newToken, err := manager.GenerateToken(user)
+	if err != nil {
+		t.Fatalf("GenerateToken() failed: %v", err)
+	}
+	if _, err := auth.NewJWTManager(oldSecret, time.Hour).ValidateToken(newToken); err == nil {
+		t.Error("new token validates with the old secret; want it signed with the new one")
+	}
+
+	// Grace period over
+	manager.SetSecrets(cfg.JWTSecret(), previous, time.Now().Add(-time.Second))
+	if _, err := manager.ValidateToken(oldToken); err != auth.ErrInvalidToken {
+		t.Errorf("ValidateToken(old) after grace = %v; want %v", err, auth.ErrInvalidToken)
+	}
+	if _, err := manager.ValidateToken(newToken); err != nil {
+		t.Errorf("ValidateToken(new) = %v; want nil", err)
+	}
+}
@@ -0,0 +1,232 @@
+package tests
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+
+	"podmanview/internal/api"
+	"podmanview/internal/auth"
+	"podmanview/internal/config"
+)
+
+// ldapEntry is a user of the fake directory
+type ldapEntry struct {
+	dn       string
+	password string
+	uid      string
+	memberOf []string
+}
+
+// serveLDAP runs a minimal LDAP server answering simple binds and
+// user searches from entries; it returns the ldap:// URL
+func serveLDAP(t *testing.T, serviceDN, servicePassword string, entries []ldapEntry) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	result := func(id int64, op ber.Tag, code int) *ber.Packet {
+		msg := ber.NewSequence("message")
+		msg.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, ""))
+		res := ber.Encode(ber.ClassApplication, ber.TypeConstructed, op, nil, "")
+		res.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, code, ""))
+		res.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+		res.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+		msg.AppendChild(res)
+		return msg
+	}
+
+	serve := func(conn net.Conn) {
+		defer conn.Close()
+		for {
+			packet, err := ber.ReadPacket(conn)
+			if err != nil || len(packet.Children) < 2 {
+				return
+			}
+			id, _ := packet.Children[0].Value.(int64)
+			op := packet.Children[1]
+			switch op.Tag {
+			case ldap.ApplicationBindRequest:
+				dn, password := op.Children[1].Data.String(), op.Children[2].Data.String()
+				code := ldap.LDAPResultInvalidCredentials
+				if dn == "" && password == "" && serviceDN == "" {
+					code = ldap.LDAPResultSuccess
+				}
+				if dn == serviceDN && password == servicePassword && password != "" {
+					code = ldap.LDAPResultSuccess
+				}
+				for _, e := range entries {
+					if dn == e.dn && password == e.password {
+						code = ldap.LDAPResultSuccess
+					}
+				}
+				conn.Write(result(id, ldap.ApplicationBindResponse, code).Bytes())
+			case ldap.ApplicationSearchRequest:
+				filter, _ := ldap.DecompileFilter(op.Children[6])
+				for _, e := range entries {
+					if filter != "(uid="+e.uid+")" {
+						continue
+					}
+					msg := ber.NewSequence("message")
+					msg.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, ""))
+					entry := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "")
+					entry.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, e.dn, ""))
+					attrs := ber.NewSequence("attributes")
+					attr := ber.NewSequence("memberOf")
+					attr.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "memberOf", ""))
+					values := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "")
+					for _, group := range e.memberOf {
+						values.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, group, ""))
+					}
+					attr.AppendChild(values)
+					attrs.AppendChild(attr)
+					entry.AppendChild(attrs)
+					msg.AppendChild(entry)
+					conn.Write(msg.Bytes())
+				}
+				conn.Write(result(id, ldap.ApplicationSearchResultDone, ldap.LDAPResultSuccess).Bytes())
+			default: // unbind
+				return
+			}
+		}
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serve(conn)
+		}
+	}()
+	return "ldap://" + listener.Addr().String()
+}
+
+func TestLDAPAuthenticate(t *testing.T) {
+	entries := []ldapEntry{
+		{dn: "uid=alice,ou=people,dc=example,dc=com", password: "secret", uid: "alice",
+			memberOf: []string{"cn=users,ou=groups,dc=example,dc=com", "cn=Podman-Admins,ou=groups,dc=example,dc=com"}},
+		{dn: "uid=bob,ou=people,dc=example,dc=com", password: "hunter2", uid: "bob",
+			memberOf: []string{"cn=users,ou=groups,dc=example,dc=com"}},
+	}
+	url := serveLDAP(t, "cn=svc,dc=example,dc=com", "svcpass", entries)
+	ldapAuth := auth.NewLDAPAuth(auth.LDAPConfig{
+		URL:          url,
+		BindDN:       "cn=svc,dc=example,dc=com",
+		BindPassword: "svcpass",
+		BaseDN:       "dc=example,dc=com",
+		UserFilter:   "(uid=%s)",
+		AdminGroups:  []string{"podman-admins"},
+	})
+
+	user, err := ldapAuth.Authenticate("alice", "secret")
+	if err != nil || user.Role != auth.RoleAdmin || user.Username != "alice" {
+		t.Errorf("alice = %+v, %v; want admin", user, err)
+	}
+	user, err = ldapAuth.Authenticate("bob", "hunter2")
+	if err != nil || user.Role != auth.RoleReadOnly {
+		t.Errorf("bob = %+v, %v; want readonly", user, err)
+	}
+
+	// Wrong and empty passwords, unknown users and filter injection fail
+	for _, login := range [][2]string{{"bob", "wrong"}, {"bob", ""}, {"carol", "secret"}, {"*", "secret"}} {
+		if user, err := ldapAuth.Authenticate(login[0], login[1]); err == nil {
+			t.Errorf("Authenticate(%q, %q) = %+v; want error", login[0], login[1], user)
+		}
+	}
+
+	// Admin groups may also be given as DNs
+	ldapAuth = auth.NewLDAPAuth(auth.LDAPConfig{
+		URL:          url,
+		BindDN:       "cn=svc,dc=example,dc=com",
+		BindPassword: "svcpass",
+		BaseDN:       "dc=example,dc=com",
+		UserFilter:   "(uid=%s)",
+		AdminGroups:  []string{"CN=users, OU=groups, DC=example, DC=com"},
+	})
+	if user, err := ldapAuth.Authenticate("bob", "hunter2"); err != nil || user.Role != auth.RoleAdmin {
+		t.Errorf("bob with admin group DN = %+v, %v; want admin", user, err)
+	}
+}
+
+func TestLDAPLogin(t *testing.T) {
+	url := serveLDAP(t, "", "", []ldapEntry{
+		{dn: "uid=alice,ou=people,dc=example,dc=com", password: "secret", uid: "alice",
+			memberOf: []string{"cn=admins,ou=groups,dc=example,dc=com"}},
+	})
+
+	path := filepath.Join(t.TempDir(), ".env")
+	env := strings.Join([]string{
+		config.EnvAuthBackend + "=ldap",
+		config.EnvLDAPURL + "=" + url,
+		config.EnvLDAPBaseDN + "=dc=example,dc=com",
+		config.EnvLDAPAdminGroups + "=cn=admins,ou=groups,dc=example,dc=com;ops",
+	}, "\n")
+	if err := os.WriteFile(path, []byte(env+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if groups := cfg.LDAPSettings().AdminGroups; len(groups) != 2 {
+		t.Errorf("admin groups = %q; want a DN and a CN", groups)
+	}
+	server := api.NewServer(nil, cfg, "test", "test")
+
+	login := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/auth/login", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		server.Router().ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := login(`{"username": "alice", "password": "secret"}`)
+	var resp api.LoginResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("login = %d %s", rec.Code, rec.Body.String())
+	}
+	if resp.User.Role != auth.RoleAdmin {
+		t.Errorf("role = %q; want admin", resp.User.Role)
+	}
+	if rec := login(`{"username": "alice", "password": "wrong"}`); rec.Code != http.StatusUnauthorized {
+		t.Errorf("wrong password = %d; want 401", rec.Code)
+	}
+}
+
+func TestLDAPConfigValidation(t *testing.T) {
+	tests := []struct {
+		env     string
+		wantErr bool
+	}{
+		{"PODMANVIEW_AUTH_BACKEND=kerberos", true},
+		{"PODMANVIEW_AUTH_BACKEND=ldap", true}, // no URL or base DN
+		{"PODMANVIEW_AUTH_BACKEND=ldap\nPODMANVIEW_LDAP_URL=ldap://dc.lan\nPODMANVIEW_LDAP_BASE_DN=dc=lan", false},
+		{"PODMANVIEW_AUTH_BACKEND=ldap\nPODMANVIEW_LDAP_URL=http://dc.lan\nPODMANVIEW_LDAP_BASE_DN=dc=lan", true},
+		{"PODMANVIEW_AUTH_BACKEND=ldap\nPODMANVIEW_LDAP_URL=ldaps://dc.lan\nPODMANVIEW_LDAP_STARTTLS=true\nPODMANVIEW_LDAP_BASE_DN=dc=lan", true},
+		{"PODMANVIEW_AUTH_BACKEND=ldap\nPODMANVIEW_LDAP_URL=ldap://dc.lan\nPODMANVIEW_LDAP_BASE_DN=dc=lan\nPODMANVIEW_LDAP_USER_FILTER=(uid=alice)", true},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), ".env")
+		if err := os.WriteFile(path, []byte(tt.env+"\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		_, err := config.Load(path)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Load(%q) error = %v; wantErr %v", tt.env, err, tt.wantErr)
+		}
+	}
+}
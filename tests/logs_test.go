@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"podmanview/internal/api"
+)
+
+func TestLogTimestamps(t *testing.T) {
+	// Podman prefixes UTC timestamps when asked to
+	fake := &podmanMux{}
+	fake.HandleFunc("GET /containers/{name}/logs", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("name") != "web" {
+			http.Error(w, `{"message":"no such container"}`, http.StatusNotFound)
+			return
+		}
+		if r.URL.Query().Get("timestamps") == "true" {
+			w.Write([]byte("2024-03-01T10:00:00.5Z starting\n2024-03-01T10:00:01Z ready\n"))
+		} else {
+			w.Write([]byte("starting\nready\n"))
+		}
+	})
+	cfg := newTestConfig(t)
+	settings := cfg.GeneralSettings()
+	settings.LogTimezone = "Europe/Berlin"
+	if err := cfg.SetGeneralSettings(settings); err != nil {
+		t.Fatalf("SetGeneralSettings() failed: %v", err)
+	}
+	_, _, request := newTestAPI(t, servePodman(t, fake), cfg, testReader)
+
+	logs := func(query string) (int, api.LogsResponse) {
+		rec := request(http.MethodGet, "/api/containers/web/logs"+query, "")
+		var resp api.LogsResponse
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		return rec.Code, resp
+	}
+
+	// Newest line first, converted to the configured zone
+	code, resp := logs("?timestamps=true")
+	if code != http.StatusOK {
+		t.Fatalf("logs = %d", code)
+	}
+	want := []string{"2024-03-01T11:00:01.000+01:00 ready", "2024-03-01T11:00:00.500+01:00 starting"}
+	if strings.Join(resp.Lines, "|") != strings.Join(want, "|") {
+		t.Errorf("lines = %q; want %q", resp.Lines, want)
+	}
+	if resp.Timezone != "Europe/Berlin" || resp.TimezoneOffset == nil {
+		t.Errorf("timezone = %q offset = %v", resp.Timezone, resp.TimezoneOffset)
+	}
+
+	// The query overrides the configured zone; structured entries carry the time
+	code, resp = logs("?format=structured&tz=UTC")
+	if code != http.StatusOK || len(resp.Entries) != 2 {
+		t.Fatalf("structured logs = %d %+v", code, resp)
+	}
+	if entry := resp.Entries[1]; entry.Line != "starting" || entry.Time == nil ||
+		!entry.Time.Equal(time.Date(2024, 3, 1, 10, 0, 0, 5e8, time.UTC)) {
+		t.Errorf("entry = %+v", entry)
+	}
+	if resp.Lines[0] != "2024-03-01T10:00:01.000Z ready" {
+		t.Errorf("UTC line = %q", resp.Lines[0])
+	}
+
+	// Without timestamps nothing changes
+	if _, resp := logs(""); strings.Join(resp.Lines, "|") != "ready|starting" || resp.Timezone != "" {
+		t.Errorf("plain logs = %+v", resp)
+	}
+
+	if code, _ := logs("?timestamps=true&tz=Mars/Olympus"); code != http.StatusBadRequest {
+		t.Errorf("unknown tz = %d; want 400", code)
+	}
+	settings.LogTimezone = "Mars/Olympus"
+	if err := cfg.SetGeneralSettings(settings); err == nil {
+		t.Error("SetGeneralSettings() accepted an unknown log time zone")
+	}
+}
@@ -1,11 +1,17 @@
 package tests
 
 import (
+	"encoding/json"
 	"log"
 	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"testing"
 
+	"podmanview/internal/config"
 	"podmanview/internal/mqtt"
 	"podmanview/internal/storage"
 )
@@ -411,3 +417,238 @@ func BenchmarkDiscoveryConfigGeneration(b *testing.B) {
 		_ = discoveryMgr.PublishDiscoveryConfig(config)
 	}
 }
+
+// TestDiscoveryRemoval tests that retained discovery configs of gone
+// sensors, and all of them on RemoveDiscoveryConfigs, are cleared
+func TestDiscoveryRemoval(t *testing.T) {
+	var mu sync.Mutex
+	retained := make(map[string]bool)
+	var refuse atomic.Bool
+	broker, _ := fakeBroker(t, &refuse, func(topic string, payload []byte, isRetained bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		if isRetained {
+			retained[topic] = len(payload) > 0
+		}
+	})
+	retainedTopics := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		var topics []string
+		for topic, set := range retained {
+			if set {
+				topics = append(topics, topic)
+			}
+		}
+		sort.Strings(topics)
+		return topics
+	}
+
+	store, err := storage.NewBoltStorage(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create test storage: %v", err)
+	}
+	defer store.Close()
+
+	client, err := mqtt.New(mqtt.Config{Broker: broker, ClientID: "test", Prefix: "podmanview", DeviceID: "podmanview"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Connect(); err != nil {
+		t.Fatalf("Connect() failed: %v", err)
+	}
+	defer client.Disconnect()
+
+	sensor := func(id string) *mqtt.SensorConfig {
+		return &mqtt.SensorConfig{SensorID: id, Name: id, SensorType: mqtt.SensorTypeTemperature, Unit: "°C", StateTopic: "sensor/" + id + "/state"}
+	}
+	discoveryMgr := mqtt.NewDiscoveryManager(client, nil, store, "test_plugin")
+	discoveryMgr.PublishMultipleDiscoveryConfigs([]*mqtt.SensorConfig{sensor("cpu"), sensor("nvme0")})
+	want := []string{"homeassistant/sensor/podmanview/cpu/config", "homeassistant/sensor/podmanview/nvme0/config"}
+	if got := retainedTopics(); !reflect.DeepEqual(got, want) {
+		t.Errorf("retained = %v; want %v", got, want)
+	}
+
+	// An unplugged sensor is removed from Home Assistant
+	discoveryMgr.PublishMultipleDiscoveryConfigs([]*mqtt.SensorConfig{sensor("cpu")})
+	if got := retainedTopics(); !reflect.DeepEqual(got, want[:1]) {
+		t.Errorf("retained after unplug = %v; want %v", got, want[:1])
+	}
+
+	// Published topics are remembered across restarts
+	restarted := mqtt.NewDiscoveryManager(client, nil, store, "test_plugin")
+	if err := restarted.RemoveDiscoveryConfigs(); err != nil {
+		t.Fatalf("RemoveDiscoveryConfigs() failed: %v", err)
+	}
+	if got := retainedTopics(); len(got) != 0 {
+		t.Errorf("retained after removal = %v; want none", got)
+	}
+	if !restarted.ShouldRepublishDiscovery(1) {
+		t.Error("discovery should be republished after removal")
+	}
+}
+
+// TestDiscoveryComponents tests that text, enum, binary and switch sensors
+// are discovered as the right Home Assistant component and that their
+// states are published as plain strings and bools
+func TestDiscoveryComponents(t *testing.T) {
+	var mu sync.Mutex
+	messages := make(map[string]string)
+	var refuse atomic.Bool
+	broker, _ := fakeBroker(t, &refuse, func(topic string, payload []byte, _ bool) {
+		mu.Lock()
+		messages[topic] = string(payload)
+		mu.Unlock()
+	})
+	message := func(topic string) string {
+		mu.Lock()
+		defer mu.Unlock()
+		return messages[topic]
+	}
+
+	client, err := mqtt.New(mqtt.Config{Broker: broker, ClientID: "test", Prefix: "pv", DeviceID: "podmanview"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Connect(); err != nil {
+		t.Fatalf("Connect() failed: %v", err)
+	}
+	defer client.Disconnect()
+	discoveryMgr := mqtt.NewDiscoveryManager(client, nil, nil, "test_plugin")
+
+	configs := []*mqtt.SensorConfig{
+		{SensorID: "web_state", Name: "web State", SensorType: mqtt.SensorTypeEnum, Options: []string{"running", "stopped"}, StateTopic: "sensor/web_state/state"},
+		{SensorID: "web_image", Name: "web Image", SensorType: mqtt.SensorTypeText, StateTopic: "sensor/web_image/state"},
+		{SensorID: "web_update", Name: "web Update", SensorType: mqtt.SensorTypeBinary, StateTopic: "sensor/web_update/state"},
+		{SensorID: "web", Name: "web", Component: mqtt.ComponentSwitch, StateTopic: "sensor/web/state", CommandTopic: "container/web/set"},
+	}
+	for _, cfg := range configs {
+		if err := discoveryMgr.PublishDiscoveryConfig(cfg); err != nil {
+			t.Fatalf("PublishDiscoveryConfig(%s) failed: %v", cfg.SensorID, err)
+		}
+	}
+
+	discovered := func(topic string) map[string]interface{} {
+		var config map[string]interface{}
+		if err := json.Unmarshal([]byte(message(topic)), &config); err != nil {
+			t.Fatalf("%s: %v", topic, err)
+		}
+		return config
+	}
+	enum := discovered("homeassistant/sensor/podmanview/web_state/config")
+	if enum["device_class"] != "enum" || !reflect.DeepEqual(enum["options"], []interface{}{"running", "stopped"}) || enum["unit_of_measurement"] != nil {
+		t.Errorf("enum config = %v", enum)
+	}
+	if text := discovered("homeassistant/sensor/podmanview/web_image/config"); text["unit_of_measurement"] != nil || text["device_class"] != nil {
+		t.Errorf("text config = %v", text)
+	}
+	if binary := discovered("homeassistant/binary_sensor/podmanview/web_update/config"); binary["payload_on"] != "true" {
+		t.Errorf("binary config = %v", binary)
+	}
+	if sw := discovered("homeassistant/switch/podmanview/web/config"); sw["command_topic"] != "pv/container/web/set" || sw["state_topic"] != "pv/sensor/web/state" || sw["payload_on"] != "true" {
+		t.Errorf("switch config = %v", sw)
+	}
+
+	// Incomplete configs are rejected
+	for _, cfg := range []*mqtt.SensorConfig{
+		{SensorID: "no_command", Component: mqtt.ComponentSwitch, StateTopic: "sensor/x/state"},
+		{SensorID: "no_options", SensorType: mqtt.SensorTypeEnum, StateTopic: "sensor/x/state"},
+		{SensorID: "unknown", Component: "light", StateTopic: "sensor/x/state"},
+	} {
+		if err := discoveryMgr.PublishDiscoveryConfig(cfg); err == nil {
+			t.Errorf("PublishDiscoveryConfig(%s) succeeded; want error", cfg.SensorID)
+		}
+	}
+
+	publisher := mqtt.NewPublisher(client, nil)
+	publisher.PublishSensorState(&mqtt.SensorData{ID: "web_state", Value: "running"})
+	publisher.PublishSensorState(&mqtt.SensorData{ID: "web_update", Value: true})
+	publisher.PublishSensorState(&mqtt.SensorData{ID: "cpu", Value: 42.5})
+	// Messages arrive in order, so the acknowledged marker comes last
+	if err := client.PublishRaw("marker", []byte("x"), false); err != nil {
+		t.Fatal(err)
+	}
+	for topic, want := range map[string]string{
+		"pv/sensor/web_state/state":  "running",
+		"pv/sensor/web_update/state": "true",
+		"pv/sensor/cpu/state":        "42.5",
+	} {
+		if got := message(topic); got != want {
+			t.Errorf("%s = %q; want %q", topic, got, want)
+		}
+	}
+}
+
+// TestDiscoveryDevice tests that each host is discovered as its own Home
+// Assistant device, named after the host unless configured
+func TestDiscoveryDevice(t *testing.T) {
+	var mu sync.Mutex
+	messages := make(map[string]string)
+	var refuse atomic.Bool
+	broker, _ := fakeBroker(t, &refuse, func(topic string, payload []byte, _ bool) {
+		mu.Lock()
+		messages[topic] = string(payload)
+		mu.Unlock()
+	})
+	discovered := func(topic string) map[string]interface{} {
+		mu.Lock()
+		defer mu.Unlock()
+		var got map[string]interface{}
+		if err := json.Unmarshal([]byte(messages[topic]), &got); err != nil {
+			t.Fatalf("%s: %v", topic, err)
+		}
+		return got
+	}
+	sensor := &mqtt.SensorConfig{SensorID: "cpu", Name: "CPU", SensorType: mqtt.SensorTypeTemperature, Unit: "°C", StateTopic: "sensor/cpu/state"}
+
+	tests := []struct {
+		name       string
+		cfg        mqtt.Config
+		topic      string
+		uniqueID   string
+		identifier string
+		deviceName string
+	}{
+		{"host name", mqtt.Config{Hostname: "Rack.Pi"}, "homeassistant/sensor/rack_pi/cpu/config", "podmanview_rack_pi_cpu", "podmanview_rack_pi", "PodmanView Rack.Pi"},
+		{"configured", mqtt.Config{Hostname: "rack.pi", DeviceID: "garage", DeviceName: "Garage Pi"}, "homeassistant/sensor/garage/cpu/config", "podmanview_garage_cpu", "podmanview_garage", "Garage Pi"},
+	}
+	for _, tt := range tests {
+		tt.cfg.Broker, tt.cfg.ClientID = broker, "test"
+		client, err := mqtt.New(tt.cfg, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := client.Connect(); err != nil {
+			t.Fatalf("Connect() failed: %v", err)
+		}
+		discoveryMgr := mqtt.NewDiscoveryManager(client, nil, nil, "test_plugin")
+		device := discoveryMgr.Device("Temperature Monitor")
+		if !reflect.DeepEqual(device.Identifiers, []string{tt.identifier}) || device.Name != tt.deviceName || device.Model != "Temperature Monitor" {
+			t.Errorf("%s: Device() = %+v", tt.name, device)
+		}
+
+		// Sensors without device info belong to the host's device
+		if err := discoveryMgr.PublishDiscoveryConfig(sensor); err != nil {
+			t.Fatalf("%s: PublishDiscoveryConfig() failed: %v", tt.name, err)
+		}
+		client.Disconnect()
+		got := discovered(tt.topic)
+		dev, _ := got["device"].(map[string]interface{})
+		if got["unique_id"] != tt.uniqueID || dev == nil || dev["name"] != tt.deviceName || !reflect.DeepEqual(dev["identifiers"], []interface{}{tt.identifier}) {
+			t.Errorf("%s: config = %v", tt.name, got)
+		}
+	}
+
+	for _, env := range []string{
+		"PODMANVIEW_MQTT_DEVICE_ID=rack/pi",
+		"PODMANVIEW_MQTT_DEVICE_ID=rack pi",
+	} {
+		path := filepath.Join(t.TempDir(), ".env")
+		if err := os.WriteFile(path, []byte(env+"\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := config.Load(path); err == nil {
+			t.Errorf("Load(%q) succeeded; want error", env)
+		}
+	}
+}
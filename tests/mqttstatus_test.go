@@ -0,0 +1,322 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"podmanview/internal/api"
+	"podmanview/internal/config"
+	"podmanview/internal/events"
+	"podmanview/internal/mqtt"
+	"podmanview/internal/plugins"
+)
+
+// fakeBroker answers MQTT CONNECT, PUBLISH and PINGREQ packets. While
+// refuse is set connections are rejected as not authorized. Accepted
+// connections are sent on conns so a test can drop them. Published
+// messages are passed to onPublish, if set.
+func fakeBroker(t *testing.T, refuse *atomic.Bool, onPublish func(topic string, payload []byte, retained bool)) (string, chan net.Conn) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	conns := make(chan net.Conn, 10)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				for {
+					header := make([]byte, 1)
+					if _, err := io.ReadFull(conn, header); err != nil {
+						return
+					}
+					// Remaining length is a varint
+					length, shift := 0, 0
+					for {
+						b := make([]byte, 1)
+						if _, err := io.ReadFull(conn, b); err != nil {
+							return
+						}
+						length |= int(b[0]&0x7f) << shift
+						shift += 7
+						if b[0]&0x80 == 0 {
+							break
+						}
+					}
+					body := make([]byte, length)
+					if _, err := io.ReadFull(conn, body); err != nil {
+						return
+					}
+
+					switch header[0] >> 4 {
+					case 1: // CONNECT
+						if refuse.Load() {
+							conn.Write([]byte{0x20, 0x02, 0x00, 0x05})
+							return
+						}
+						conn.Write([]byte{0x20, 0x02, 0x00, 0x00})
+						conns <- conn
+					case 3: // PUBLISH
+						topicLen := int(body[0])<<8 | int(body[1])
+						topic, payload := string(body[2:2+topicLen]), body[2+topicLen:]
+						var packetID []byte
+						if qos := header[0] >> 1 & 3; qos > 0 {
+							packetID, payload = payload[:2], payload[2:]
+						}
+						if onPublish != nil {
+							onPublish(topic, payload, header[0]&1 == 1)
+						}
+						if packetID != nil {
+							conn.Write([]byte{0x40, 0x02, packetID[0], packetID[1]}) // PUBACK
+						}
+					case 12: // PINGREQ
+						conn.Write([]byte{0xd0, 0x00})
+					case 14: // DISCONNECT
+						return
+					}
+				}
+			}()
+		}
+	}()
+	return "tcp://" + listener.Addr().String(), conns
+}
+
+func TestMQTTConnectionState(t *testing.T) {
+	var refuse atomic.Bool
+	broker, conns := fakeBroker(t, &refuse, nil)
+
+	cfg, err := config.Load(filepath.Join(t.TempDir(), ".env"))
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if err := cfg.SetNoAuth(true); err != nil {
+		t.Fatal(err)
+	}
+
+	store := events.NewStore(100)
+	stateEvents := make(chan events.Event, 10)
+	store.Subscribe(func(e events.Event) {
+		if e.Type == events.EventMQTTConnected || e.Type == events.EventMQTTDisconnected {
+			stateEvents <- e
+		}
+	})
+	client, err := mqtt.New(mqtt.Config{Broker: broker, ClientID: "test", MaxReconnectInterval: time.Second}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mqtt.RecordConnectionEvents(store, client)
+	registry := plugins.NewRegistry()
+	deps := &plugins.PluginDependencies{EventStore: store}
+	deps.SetMQTT(client, nil, nil)
+	registry.SetDependencies(deps)
+	server := api.NewServerWithPlugins(nil, cfg, "test", "test", nil, registry, nil)
+
+	status := func() api.MQTTStatusResponse {
+		rec := httptest.NewRecorder()
+		server.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/system/mqtt/status", nil))
+		var resp api.MQTTStatusResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusOK {
+			t.Fatalf("status = %d %s", rec.Code, rec.Body.String())
+		}
+		return resp
+	}
+	nextEvent := func(want events.EventType) events.Event {
+		t.Helper()
+		select {
+		case e := <-stateEvents:
+			if e.Type != want {
+				t.Fatalf("event = %s %q; want %s", e.Type, e.Details, want)
+			}
+			return e
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no %s event", want)
+		}
+		return events.Event{}
+	}
+
+	if s := status(); !s.Configured || s.Connected || s.KeepAliveSeconds != 30 || s.ReconnectMaxSeconds != 10 {
+		t.Errorf("status before connect = %+v", s)
+	}
+
+	if err := client.Connect(); err != nil {
+		t.Fatalf("Connect() failed: %v", err)
+	}
+	if e := nextEvent(events.EventMQTTConnected); !e.Success || e.Username != "system" || e.Meta["broker"] != broker {
+		t.Errorf("connected event = %+v", e)
+	}
+	if s := status(); !s.Connected || s.Broker != broker || s.Since == nil || s.LastError != "" {
+		t.Errorf("status connected = %+v", s)
+	}
+
+	// A lost connection is retried until the broker accepts it again
+	refuse.Store(true)
+	(<-conns).Close()
+	if e := nextEvent(events.EventMQTTDisconnected); e.Success || e.Meta["error"] == "" {
+		t.Errorf("lost event = %+v; want failed with error", e)
+	}
+	var s api.MQTTStatusResponse
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+		if s = status(); s.ReconnectAttempts >= 2 {
+			break
+		}
+	}
+	if s.Connected || s.ReconnectAttempts < 2 || s.LastError == "" {
+		t.Errorf("status while down = %+v; want retrying with last error", s)
+	}
+
+	refuse.Store(false)
+	nextEvent(events.EventMQTTConnected)
+	if s := status(); !s.Connected || s.ReconnectAttempts != 0 || s.LastError != "" {
+		t.Errorf("status reconnected = %+v", s)
+	}
+
+	client.Disconnect()
+	if e := nextEvent(events.EventMQTTDisconnected); !e.Success {
+		t.Errorf("disconnect event = %+v; want success", e)
+	}
+
+	for _, env := range []string{
+		"PODMANVIEW_MQTT_KEEPALIVE=2",
+		"PODMANVIEW_MQTT_RECONNECT_MAX=7200",
+	} {
+		path := filepath.Join(t.TempDir(), ".env")
+		if err := os.WriteFile(path, []byte(env+"\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := config.Load(path); err == nil {
+			t.Errorf("Load(%q) succeeded; want error", env)
+		}
+	}
+}
+
+func TestMQTTPublish(t *testing.T) {
+	type message struct {
+		payload  string
+		retained bool
+	}
+	received := make(chan message, 10)
+	var refuse atomic.Bool
+	broker, _ := fakeBroker(t, &refuse, func(topic string, payload []byte, retained bool) {
+		if topic == "homeassistant/sensor/test/config" {
+			received <- message{string(payload), retained}
+		}
+	})
+
+	cfg, err := config.Load(filepath.Join(t.TempDir(), ".env"))
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if err := cfg.SetNoAuth(true); err != nil {
+		t.Fatal(err)
+	}
+	store := events.NewStore(100)
+	client, err := mqtt.New(mqtt.Config{Broker: broker, ClientID: "test", Prefix: "podmanview"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry := plugins.NewRegistry()
+	deps := &plugins.PluginDependencies{EventStore: store}
+	deps.SetMQTT(client, nil, nil)
+	registry.SetDependencies(deps)
+	server := api.NewServerWithPlugins(nil, cfg, "test", "test", nil, registry, nil)
+
+	publish := func(body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/system/mqtt/publish", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		server.Router().ServeHTTP(rec, req)
+		return rec.Code
+	}
+	body := `{"topic": "homeassistant/sensor/test/config", "payload": "{\"name\": \"test\"}", "qos": 1, "retained": true}`
+
+	if code := publish(body); code != http.StatusConflict {
+		t.Errorf("publish while disconnected = %d; want 409", code)
+	}
+
+	if err := client.Connect(); err != nil {
+		t.Fatalf("Connect() failed: %v", err)
+	}
+	defer client.Disconnect()
+	if code := publish(body); code != http.StatusOK {
+		t.Fatalf("publish = %d; want 200", code)
+	}
+	select {
+	case m := <-received:
+		if m.payload != `{"name": "test"}` || !m.retained {
+			t.Errorf("broker received %+v; want retained payload", m)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("message did not reach the broker")
+	}
+	if list := store.Filter(events.Filter{Type: events.EventMQTTPublish}); len(list) != 1 || !list[0].Success || list[0].Meta["topic"] != "homeassistant/sensor/test/config" {
+		t.Errorf("publish events = %+v", list)
+	}
+
+	for _, bad := range []string{
+		`{"topic": "", "payload": "x"}`,
+		`{"topic": "podmanview/#", "payload": "x"}`,
+		`{"topic": "podmanview/test", "payload": "x", "qos": 3}`,
+	} {
+		if code := publish(bad); code != http.StatusBadRequest {
+			t.Errorf("publish %s = %d; want 400", bad, code)
+		}
+	}
+}
+
+func TestMQTTEnableAtRuntime(t *testing.T) {
+	var refuse atomic.Bool
+	broker, _ := fakeBroker(t, &refuse, nil)
+
+	cfg, err := config.Load(filepath.Join(t.TempDir(), ".env"))
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if err := cfg.SetNoAuth(true); err != nil {
+		t.Fatal(err)
+	}
+	registry := plugins.NewRegistry()
+	registry.SetDependencies(&plugins.PluginDependencies{EventStore: events.NewStore(100)})
+	server := api.NewServerWithPlugins(nil, cfg, "test", "test", nil, registry, nil)
+
+	// A plugin initialized while MQTT was off
+	pluginDeps := registry.PluginDeps("demo")
+	if pluginDeps.MQTTClient() != nil {
+		t.Fatal("plugin has an MQTT client before MQTT is configured")
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/system/mqtt/config", strings.NewReader(`{"broker": "`+broker+`", "clientId": "test"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	server.Router().ServeHTTP(rec, req)
+	var resp struct {
+		RestartRequired bool   `json:"restart_required"`
+		Warning         string `json:"warning"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusOK || resp.RestartRequired || resp.Warning != "" {
+		t.Fatalf("update = %d %s", rec.Code, rec.Body.String())
+	}
+
+	client := pluginDeps.MQTTClient()
+	if client == nil || pluginDeps.MQTTPublisher() == nil || pluginDeps.MQTTDiscovery() == nil {
+		t.Fatal("plugin does not see the MQTT services created at runtime")
+	}
+	defer client.Disconnect()
+	if !client.IsConnected() {
+		t.Error("MQTT client created at runtime is not connected")
+	}
+}
@@ -0,0 +1,134 @@
+package tests
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"podmanview/internal/netutil"
+)
+
+func ipNet(cidr string) *net.IPNet {
+	ip, n, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+	n.IP = ip
+	return n
+}
+
+func TestFilterAddrs(t *testing.T) {
+	tests := []struct {
+		name  string
+		addrs []net.Addr
+		want  []string
+	}{
+		{"ipv4", []net.Addr{ipNet("192.168.1.10/24")}, []string{"192.168.1.10"}},
+		{"ip addr type", []net.Addr{&net.IPAddr{IP: net.ParseIP("10.0.0.5")}}, []string{"10.0.0.5"}},
+		{"loopback skipped", []net.Addr{ipNet("127.0.0.1/8"), ipNet("::1/128")}, nil},
+		{"ipv4 link-local skipped", []net.Addr{ipNet("169.254.10.1/16")}, nil},
+		{"global ipv6 after ipv4", []net.Addr{ipNet("2001:db8::1/64"), ipNet("10.1.2.3/8")}, []string{"10.1.2.3", "2001:db8::1"}},
+		{"link-local dropped when global exists", []net.Addr{ipNet("fe80::1/64"), ipNet("2001:db8::1/64")}, []string{"2001:db8::1"}},
+		{"link-local dropped when ipv4 exists", []net.Addr{ipNet("fe80::1/64"), ipNet("10.1.2.3/8"), ipNet("172.16.0.1/12")}, []string{"10.1.2.3", "172.16.0.1"}},
+		{"link-local only", []net.Addr{&net.IPAddr{IP: net.ParseIP("fe80::1"), Zone: "eth0"}}, []string{"fe80::1%eth0"}},
+		{"empty", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := netutil.FilterAddrs(tt.addrs); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FilterAddrs() = %v; want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAccessURLs(t *testing.T) {
+	got := netutil.AccessURLs("http", []string{"192.168.1.10", "2001:db8::1", "fe80::1%eth0"}, "8080")
+	want := []string{"http://192.168.1.10:8080", "http://[2001:db8::1]:8080", "http://[fe80::1%25eth0]:8080"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AccessURLs() = %v; want %v", got, want)
+	}
+
+	if got := netutil.AccessURLs("https", []string{"10.0.0.5"}, "443"); got[0] != "https://10.0.0.5:443" {
+		t.Errorf("AccessURLs(https) = %v; want https://10.0.0.5:443", got)
+	}
+}
+
+func TestPortFromAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		want string
+	}{
+		{":80", "80"},
+		{"0.0.0.0:8080", "8080"},
+		{"[::]:443", "443"},
+		{"9000", "9000"},
+	}
+
+	for _, tt := range tests {
+		if got := netutil.PortFromAddr(tt.addr); got != tt.want {
+			t.Errorf("PortFromAddr(%q) = %q; want %q", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestIPv6Only(t *testing.T) {
+	got := netutil.IPv6Only([]string{"10.0.0.1", "2001:db8::1", "fe80::1%eth0"})
+	want := []string{"2001:db8::1", "fe80::1%eth0"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("IPv6Only() = %v; want %v", got, want)
+	}
+}
+
+func TestListenUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "podmanview.sock")
+	addr := netutil.UnixPrefix + path
+
+	listener, err := netutil.Listen(addr, 0660)
+	if err != nil {
+		t.Fatalf("Listen() failed: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("socket not created: %v", err)
+	}
+	if info.Mode()&os.ModeSocket == 0 || info.Mode().Perm() != 0660 {
+		t.Errorf("socket mode = %v; want socket with 0660", info.Mode())
+	}
+
+	// A live socket is not taken over
+	if _, err := netutil.Listen(addr, 0660); err == nil {
+		t.Error("Listen() on a socket in use succeeded; want error")
+	}
+
+	// Closing removes the socket file
+	listener.Close()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("socket file after Close() = %v; want removed", err)
+	}
+
+	// A stale socket from an unclean shutdown is replaced
+	stale, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.SetUnlinkOnClose(false)
+	stale.Close()
+	listener, err = netutil.Listen(addr, 0600)
+	if err != nil {
+		t.Fatalf("Listen() over stale socket failed: %v", err)
+	}
+	listener.Close()
+
+	// Regular files are never removed
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := netutil.Listen(netutil.UnixPrefix+file, 0660); err == nil {
+		t.Error("Listen() over a regular file succeeded; want error")
+	}
+}
@@ -0,0 +1,231 @@
+package tests
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"podmanview/internal/api"
+	"podmanview/internal/auth"
+	"podmanview/internal/config"
+)
+
+// oidcProvider is a fake OpenID Connect provider: discovery, keys and a
+// token endpoint that returns an ID token with the claims and nonce set
+type oidcProvider struct {
+	*httptest.Server
+	key    *rsa.PrivateKey
+	nonce  string
+	claims jwt.MapClaims
+}
+
+func newOIDCProvider(t *testing.T) *oidcProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &oidcProvider{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"issuer":                                p.URL,
+			"authorization_endpoint":                p.URL + "/authorize",
+			"token_endpoint":                        p.URL + "/token",
+			"jwks_uri":                              p.URL + "/keys",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA", "alg": "RS256", "use": "sig", "kid": "test",
+			"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("code") != "good-code" || r.FormValue("code_verifier") == "" {
+			http.Error(w, `{"error": "invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		claims := jwt.MapClaims{
+			"iss":   p.URL,
+			"aud":   "podmanview",
+			"sub":   "1234",
+			"nonce": p.nonce,
+			"iat":   time.Now().Unix(),
+			"exp":   time.Now().Add(time.Minute).Unix(),
+		}
+		for k, v := range p.claims {
+			claims[k] = v
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "test"
+		idToken, _ := token.SignedString(key)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"access_token": "access", "token_type": "Bearer", "expires_in": 60, "id_token": idToken,
+		})
+	})
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+	return p
+}
+
+func TestOIDCLogin(t *testing.T) {
+	provider := newOIDCProvider(t)
+
+	path := filepath.Join(t.TempDir(), ".env")
+	env := strings.Join([]string{
+		config.EnvOIDCIssuer + "=" + provider.URL,
+		config.EnvOIDCClientID + "=podmanview",
+		config.EnvOIDCClientSecret + "=secret",
+		config.EnvOIDCRedirectURL + "=https://pi.lan/api/auth/oidc/callback",
+		config.EnvOIDCRoleClaim + "=realm_access.roles",
+		config.EnvOIDCAdminValues + "=podman-admins",
+		config.EnvJWTExpiration + "=7200",
+	}, "\n")
+	if err := os.WriteFile(path, []byte(env+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	server := api.NewServer(nil, cfg, "test", "test")
+	jwtManager := auth.NewJWTManager(cfg.JWTSecret(), cfg.JWTExpiration())
+
+	get := func(target string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		rec := httptest.NewRecorder()
+		server.Router().ServeHTTP(rec, req)
+		return rec
+	}
+	cookie := func(rec *httptest.ResponseRecorder, name string) *http.Cookie {
+		for _, c := range rec.Result().Cookies() {
+			if c.Name == name && c.Value != "" {
+				return c
+			}
+		}
+		return nil
+	}
+	// start follows the login redirect and returns the state cookie and state
+	start := func() (*http.Cookie, string) {
+		rec := get("/api/auth/oidc/login")
+		location, err := url.Parse(rec.Header().Get("Location"))
+		if rec.Code != http.StatusFound || err != nil || !strings.HasPrefix(location.String(), provider.URL+"/authorize") {
+			t.Fatalf("login = %d %q", rec.Code, rec.Header().Get("Location"))
+		}
+		query := location.Query()
+		if query.Get("code_challenge_method") != "S256" || query.Get("redirect_uri") != "https://pi.lan/api/auth/oidc/callback" {
+			t.Errorf("authorize query = %v", query)
+		}
+		provider.nonce = query.Get("nonce")
+		return cookie(rec, auth.OIDCStateCookie), query.Get("state")
+	}
+
+	if rec := get("/api/auth/oidc"); !strings.Contains(rec.Body.String(), `"enabled":true`) {
+		t.Errorf("status = %s; want enabled", rec.Body.String())
+	}
+
+	// Admin by a nested role claim
+	provider.claims = jwt.MapClaims{
+		"preferred_username": "alice",
+		"realm_access":       map[string]any{"roles": []string{"users", "podman-admins"}},
+	}
+	state, stateValue := start()
+	rec := get("/api/auth/oidc/callback?code=good-code&state="+stateValue, state)
+	session := cookie(rec, auth.CookieName)
+	if rec.Code != http.StatusFound || rec.Header().Get("Location") != "/" || session == nil {
+		t.Fatalf("callback = %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+	claims, err := jwtManager.ValidateToken(session.Value)
+	if err != nil || claims.Username != "oidc:alice" || claims.Role != auth.RoleAdmin {
+		t.Errorf("session = %+v, %v; want admin oidc:alice", claims, err)
+	}
+	// The session lasts PODMANVIEW_JWT_EXPIRATION
+	if valid := time.Until(claims.ExpiresAt.Time); session.MaxAge != 7200 || valid < time.Hour || valid > 2*time.Hour {
+		t.Errorf("session MaxAge = %d, token valid for %v; want 2h", session.MaxAge, valid)
+	}
+
+	// Everyone else is read-only, named after the email without a username
+	provider.claims = jwt.MapClaims{"email": "bob@example.com"}
+	state, stateValue = start()
+	rec = get("/api/auth/oidc/callback?code=good-code&state="+stateValue, state)
+	if session := cookie(rec, auth.CookieName); session == nil {
+		t.Fatalf("callback = %d %q", rec.Code, rec.Header().Get("Location"))
+	} else if claims, err := jwtManager.ValidateToken(session.Value); err != nil || claims.Username != "oidc:bob@example.com" || claims.Role != auth.RoleReadOnly {
+		t.Errorf("session = %+v, %v; want read-only oidc:bob@example.com", claims, err)
+	}
+
+	// Wrong state, missing state cookie, bad code and a replayed nonce fail
+	state, stateValue = start()
+	failures := map[string]*httptest.ResponseRecorder{
+		"wrong state": get("/api/auth/oidc/callback?code=good-code&state=forged", state),
+		"no cookie":   get("/api/auth/oidc/callback?code=good-code&state=" + stateValue),
+		"bad code":    get("/api/auth/oidc/callback?code=bad-code&state="+stateValue, state),
+	}
+	provider.nonce = "replayed"
+	failures["wrong nonce"] = get("/api/auth/oidc/callback?code=good-code&state="+stateValue, state)
+	for name, rec := range failures {
+		if rec.Header().Get("Location") != "/?login_error=sso" || cookie(rec, auth.CookieName) != nil {
+			t.Errorf("%s: callback = %d %q; want redirect with login_error", name, rec.Code, rec.Header().Get("Location"))
+		}
+	}
+}
+
+func TestOIDCDisabled(t *testing.T) {
+	cfg, err := config.Load(filepath.Join(t.TempDir(), ".env"))
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	server := api.NewServer(nil, cfg, "test", "test")
+	for target, want := range map[string]int{
+		"/api/auth/oidc":          http.StatusOK,
+		"/api/auth/oidc/login":    http.StatusNotFound,
+		"/api/auth/oidc/callback": http.StatusNotFound,
+	} {
+		rec := httptest.NewRecorder()
+		server.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != want {
+			t.Errorf("%s = %d; want %d", target, rec.Code, want)
+		}
+	}
+}
+
+func TestOIDCConfigValidation(t *testing.T) {
+	tests := []struct {
+		env     string
+		wantErr bool
+	}{
+		{"PODMANVIEW_OIDC_ISSUER=https://auth.lan\nPODMANVIEW_OIDC_CLIENT_ID=pv\nPODMANVIEW_OIDC_REDIRECT_URL=https://pi.lan/api/auth/oidc/callback", false},
+		{"PODMANVIEW_OIDC_ISSUER=auth.lan\nPODMANVIEW_OIDC_CLIENT_ID=pv\nPODMANVIEW_OIDC_REDIRECT_URL=https://pi.lan/api/auth/oidc/callback", true},
+		{"PODMANVIEW_OIDC_ISSUER=https://auth.lan\nPODMANVIEW_OIDC_REDIRECT_URL=https://pi.lan/api/auth/oidc/callback", true},
+		{"PODMANVIEW_OIDC_ISSUER=https://auth.lan\nPODMANVIEW_OIDC_CLIENT_ID=pv\nPODMANVIEW_OIDC_REDIRECT_URL=/api/auth/oidc/callback", true},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), ".env")
+		if err := os.WriteFile(path, []byte(tt.env+"\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		_, err := config.Load(path)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Load(%q) error = %v; wantErr %v", tt.env, err, tt.wantErr)
+		}
+	}
+}
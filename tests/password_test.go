@@ -0,0 +1,68 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"podmanview/internal/api"
+	"podmanview/internal/auth"
+	"podmanview/internal/config"
+)
+
+func TestChangePassword(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	viewer := sessionToken(t, cfg, &auth.User{Username: "viewer", Role: auth.RoleReadOnly})
+	admin := sessionToken(t, cfg, testAdmin)
+
+	change := func(server *api.Server, token, body string) *httptest.ResponseRecorder {
+		return requester(server, token)(http.MethodPost, "/api/auth/change-password", body)
+	}
+
+	server := api.NewServer(nil, cfg, "test", "test")
+	tests := []struct {
+		name  string
+		token string
+		body  string
+		want  int
+	}{
+		{"other user as viewer", viewer, `{"username": "admin", "currentPassword": "old", "newPassword": "long enough"}`, http.StatusForbidden},
+		{"missing current", viewer, `{"newPassword": "long enough"}`, http.StatusBadRequest},
+		{"too short", viewer, `{"currentPassword": "old", "newPassword": "short"}`, http.StatusBadRequest},
+		// PAM is unavailable in tests, so the current password never verifies
+		{"wrong current", viewer, `{"currentPassword": "wrong", "newPassword": "long enough"}`, http.StatusForbidden},
+		{"other user as admin", admin, `{"username": "viewer", "currentPassword": "wrong", "newPassword": "long enough"}`, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		rec := change(server, tt.token, tt.body)
+		if rec.Code != tt.want {
+			t.Errorf("%s = %d %s; want %d", tt.name, rec.Code, rec.Body.String(), tt.want)
+		}
+		if strings.Contains(rec.Body.String(), "long enough") {
+			t.Errorf("%s: response contains the password: %s", tt.name, rec.Body.String())
+		}
+	}
+
+	// Directory accounts are changed in the directory
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("PODMANVIEW_AUTH_BACKEND=ldap\nPODMANVIEW_LDAP_URL=ldap://127.0.0.1:1\nPODMANVIEW_LDAP_BASE_DN=dc=lan\n")
+	f.Close()
+	cfg, err = config.Load(path)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	server = api.NewServer(nil, cfg, "test", "test")
+	if rec := change(server, viewer, `{"currentPassword": "old", "newPassword": "long enough"}`); rec.Code != http.StatusNotImplemented {
+		t.Errorf("LDAP backend = %d; want 501", rec.Code)
+	}
+}
@@ -0,0 +1,403 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"podmanview/internal/api"
+	"podmanview/internal/config"
+	"podmanview/internal/events"
+	"podmanview/internal/plugins"
+	"podmanview/internal/plugins/backup"
+	"podmanview/internal/plugins/demo"
+	"podmanview/internal/plugins/imageupdates"
+	"podmanview/internal/plugins/temperature"
+)
+
+func TestBasePluginHTMLCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.html")
+	if err := os.WriteFile(path, []byte("<p>one</p>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p := plugins.NewBasePlugin("test", "Test plugin", "1.0.0", path)
+	v1, err := p.HTMLVersion()
+	if err != nil {
+		t.Fatalf("HTMLVersion() error = %v", err)
+	}
+
+	// Same file, same version
+	if v, _ := p.HTMLVersion(); v != v1 {
+		t.Errorf("HTMLVersion() = %q; want unchanged %q", v, v1)
+	}
+
+	// Rewritten file is picked up
+	if err := os.WriteFile(path, []byte("<p>two!</p>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, future, future); err != nil {
+		t.Fatal(err)
+	}
+	if html, _ := p.GetHTML(); html != "<p>two!</p>" {
+		t.Errorf("GetHTML() = %q; want updated content", html)
+	}
+	if v, _ := p.HTMLVersion(); v == v1 {
+		t.Errorf("HTMLVersion() = %q; want changed after rewrite", v)
+	}
+}
+
+func TestBasePluginEmbeddedHTML(t *testing.T) {
+	fsys := fstest.MapFS{"index.html": {Data: []byte("<p>embedded</p>")}}
+	p := plugins.NewBasePluginFS("test", "Test plugin", "1.0.0", fsys, "index.html")
+
+	html, err := p.GetHTML()
+	if err != nil || html != "<p>embedded</p>" {
+		t.Errorf("GetHTML() = %q, %v; want embedded content", html, err)
+	}
+	if v, _ := p.HTMLVersion(); v == "" {
+		t.Error("HTMLVersion() is empty")
+	}
+}
+
+// TestBasePluginDevDirHTML checks that bundled plugins find their pages in
+// the source checkout in dev mode, including those whose name differs from
+// their package directory
+func TestBasePluginDevDirHTML(t *testing.T) {
+	root, err := filepath.Abs("..")
+	if err != nil {
+		t.Fatal(err)
+	}
+	envPath := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(envPath, []byte(config.EnvDevDir+"="+root+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(envPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	for _, p := range []interface {
+		plugins.Plugin
+		SetDependencies(*plugins.PluginDependencies)
+	}{demo.New(), imageupdates.New(), temperature.New(), backup.New()} {
+		p.SetDependencies(&plugins.PluginDependencies{Config: cfg})
+		if html, err := p.GetHTML(); err != nil || html == "" {
+			t.Errorf("%s: GetHTML() = %d bytes, %v; want the page from %s", p.Name(), len(html), err, root)
+		}
+	}
+}
+
+func TestPluginHTMLNotModified(t *testing.T) {
+	cfg, err := config.Load(filepath.Join(t.TempDir(), ".env"))
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	cfg.SetNoAuth(true)
+
+	plugin := &htmlPlugin{html: `<section id="page-plugin-test" class="content-page hidden"></section>`}
+	server := api.NewServerWithPlugins(nil, cfg, "test", "test", []plugins.Plugin{plugin}, nil, nil)
+
+	rec := httptest.NewRecorder()
+	server.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/plugins/test/html", nil))
+	etag := rec.Header().Get("ETag")
+	if rec.Code != http.StatusOK || etag == "" {
+		t.Fatalf("GET html = %d, ETag %q; want 200 with ETag", rec.Code, etag)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/plugins/test/html", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	server.Router().ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Errorf("GET html with If-None-Match = %d (%d bytes); want 304 without body", rec.Code, rec.Body.Len())
+	}
+
+	// Changed HTML invalidates the ETag
+	plugin.html = `<section id="page-plugin-test" class="content-page hidden">v2</section>`
+	rec = httptest.NewRecorder()
+	server.Router().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("GET html after change = %d; want 200", rec.Code)
+	}
+}
+
+// manifestPlugin is a plugin with its own manifest
+type manifestPlugin struct {
+	htmlPlugin
+	manifest plugins.Manifest
+}
+
+func (p *manifestPlugin) Manifest() plugins.Manifest { return p.manifest }
+
+func TestPluginManifest(t *testing.T) {
+	registry := plugins.NewRegistry()
+	for _, p := range []plugins.Plugin{demo.New(), temperature.New(), imageupdates.New(), backup.New()} {
+		if err := registry.Register(p); err != nil {
+			t.Errorf("Register(%s) error = %v", p.Name(), err)
+		}
+		if m := plugins.ManifestOf(p); m.Icon == "" || m.Category == plugins.CategoryOther || len(m.Capabilities) == 0 {
+			t.Errorf("%s manifest = %+v; want icon, category and capabilities", p.Name(), m)
+		}
+	}
+
+	valid := plugins.Manifest{Name: "test", Description: "Test plugin", Version: "1.2.3-rc.1", Icon: "gauge",
+		Category: plugins.CategoryMonitoring, Capabilities: []string{plugins.CapabilityPodman}}
+	if err := plugins.NewRegistry().Register(&manifestPlugin{manifest: valid}); err != nil {
+		t.Errorf("Register(valid) error = %v", err)
+	}
+	invalid := map[string]func(m *plugins.Manifest){
+		"other name":         func(m *plugins.Manifest) { m.Name = "other" },
+		"no description":     func(m *plugins.Manifest) { m.Description = "" },
+		"not semver":         func(m *plugins.Manifest) { m.Version = "v1" },
+		"bad icon":           func(m *plugins.Manifest) { m.Icon = "<svg>" },
+		"unknown category":   func(m *plugins.Manifest) { m.Category = "games" },
+		"unknown capability": func(m *plugins.Manifest) { m.Capabilities = []string{"root"} },
+		"duplicate":          func(m *plugins.Manifest) { m.Capabilities = []string{"mqtt", "mqtt"} },
+	}
+	for name, change := range invalid {
+		m := valid
+		change(&m)
+		if err := plugins.NewRegistry().Register(&manifestPlugin{manifest: m}); err == nil {
+			t.Errorf("Register(%s) succeeded; want error", name)
+		}
+	}
+
+	// Plugins without a manifest are listed in the default category
+	cfg, err := config.Load(filepath.Join(t.TempDir(), ".env"))
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	cfg.SetNoAuth(true)
+	server := api.NewServerWithPlugins(nil, cfg, "test", "test", []plugins.Plugin{demo.New(), &htmlPlugin{}}, nil, nil)
+	rec := httptest.NewRecorder()
+	server.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/plugins", nil))
+	var list []struct {
+		Name         string   `json:"name"`
+		Icon         string   `json:"icon"`
+		Category     string   `json:"category"`
+		Capabilities []string `json:"capabilities"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil || len(list) != 2 {
+		t.Fatalf("list = %d %s", rec.Code, rec.Body.String())
+	}
+	if list[0].Icon != "code" || list[0].Category != plugins.CategoryDevelopment || len(list[0].Capabilities) != 1 {
+		t.Errorf("demo = %+v", list[0])
+	}
+	if list[1].Category != plugins.CategoryOther || list[1].Capabilities == nil {
+		t.Errorf("test = %+v; want category other and no capabilities", list[1])
+	}
+}
+
+func TestPluginLogs(t *testing.T) {
+	store := plugins.NewLogStore(3)
+	for i := 1; i <= 5; i++ {
+		store.Add("demo", time.Unix(int64(i), 0), fmt.Sprintf("line %d\n", i))
+	}
+	messages := func(lines []plugins.LogLine) string {
+		var out []string
+		for _, l := range lines {
+			out = append(out, l.Message)
+		}
+		return strings.Join(out, ",")
+	}
+	if lines, truncated := store.Lines("demo", 0); messages(lines) != "line 3,line 4,line 5" || !truncated {
+		t.Errorf("Lines(all) = %q, %v; want the newest 3, truncated", messages(lines), truncated)
+	}
+	if lines, _ := store.Lines("demo", 2); messages(lines) != "line 4,line 5" {
+		t.Errorf("Lines(2) = %q; want the newest 2", messages(lines))
+	}
+	if lines, truncated := store.Lines("other", 10); len(lines) != 0 || truncated {
+		t.Errorf("Lines(other) = %v, %v; want none", lines, truncated)
+	}
+
+	// Plugins log through the shared logger and into their own buffer
+	var out bytes.Buffer
+	registry := plugins.NewRegistry()
+	plugin := demo.New()
+	if err := registry.Register(plugin); err != nil {
+		t.Fatal(err)
+	}
+	registry.SetDependencies(&plugins.PluginDependencies{Logger: log.New(&out, "", 0)})
+	registry.PluginDeps("demo").Logger.Printf("[demo] sensor %s failed", "cpu")
+	if out.String() != "[demo] sensor cpu failed\n" {
+		t.Errorf("shared log = %q", out.String())
+	}
+
+	cfg, err := config.Load(filepath.Join(t.TempDir(), ".env"))
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	cfg.SetNoAuth(true)
+	server := api.NewServerWithPlugins(nil, cfg, "test", "test", []plugins.Plugin{plugin}, registry, nil)
+	get := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		server.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+	rec := get("/api/plugins/demo/logs?lines=10")
+	var resp api.PluginLogsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("logs = %d %s", rec.Code, rec.Body.String())
+	}
+	if messages(resp.Lines) != "[demo] sensor cpu failed" || resp.Truncated {
+		t.Errorf("logs = %+v", resp)
+	}
+	if rec := get("/api/plugins/demo/logs?lines=0"); rec.Code != http.StatusBadRequest {
+		t.Errorf("lines=0 = %d; want 400", rec.Code)
+	}
+	if rec := get("/api/plugins/missing/logs"); rec.Code != http.StatusNotFound {
+		t.Errorf("missing plugin = %d; want 404", rec.Code)
+	}
+}
+
+func TestEmbeddedWebAssets(t *testing.T) {
+	cfg, err := config.Load(filepath.Join(t.TempDir(), ".env"))
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	cfg.SetNoAuth(true)
+	server := api.NewServer(nil, cfg, "v1.2.3", "42")
+
+	// Tests run from tests/, so these only work if assets are embedded
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/", "v1.2.3"},
+		{"/static/css/style.css", ".content-page"},
+		{"/static/manifest.json", "PodmanView"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			server.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), tt.want) {
+				t.Errorf("GET %s = %d; want 200 containing %q", tt.path, rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestStopWithTimeout(t *testing.T) {
+	// A plugin that honors its context stops normally
+	if err := plugins.StopWithTimeout(&htmlPlugin{}, time.Second); err != nil {
+		t.Errorf("StopWithTimeout() = %v; want nil", err)
+	}
+
+	// A plugin that ignores its context is abandoned after the timeout
+	hang := &hangingPlugin{release: make(chan struct{})}
+	defer close(hang.release)
+
+	start := time.Now()
+	err := plugins.StopWithTimeout(hang, 50*time.Millisecond)
+	if !errors.Is(err, plugins.ErrStopTimeout) {
+		t.Errorf("StopWithTimeout(hanging) = %v; want %v", err, plugins.ErrStopTimeout)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("StopWithTimeout(hanging) took %v; want about 50ms", elapsed)
+	}
+}
+
+// hangingPlugin blocks in Stop until released, ignoring its context
+type hangingPlugin struct {
+	htmlPlugin
+	release chan struct{}
+}
+
+func (p *hangingPlugin) Stop(context.Context) error {
+	<-p.release
+	return nil
+}
+
+func TestEventEmitter(t *testing.T) {
+	store := events.NewStore(10)
+	deps := &plugins.PluginDependencies{EventStore: store}
+
+	deps.Events("uptime").Emit(events.EventType("uptime_down"), "alice", "10.0.0.5", false, "example.com unreachable", events.Meta{"plugin": "spoofed", "url": "https://example.com"})
+	deps.Events("uptime").System(events.EventPluginError, false, "timeout", nil)
+
+	got := store.GetAll() // newest first
+	if len(got) != 2 {
+		t.Fatalf("GetAll() returned %d events; want 2", len(got))
+	}
+	if got[1].Details != "uptime: example.com unreachable" || got[1].Meta["plugin"] != "uptime" || got[1].Meta["url"] != "https://example.com" {
+		t.Errorf("Emit() event = %+v; want prefixed details and plugin meta", got[1])
+	}
+	if got[0].Username != "system" || got[0].Details != "uptime: timeout" {
+		t.Errorf("System() event = %+v; want system user and prefixed details", got[0])
+	}
+
+	// Without an event store events are discarded
+	var noDeps *plugins.PluginDependencies
+	noDeps.Events("uptime").System(events.EventPluginError, false, "ignored", nil)
+}
+
+func TestPluginHTTPClient(t *testing.T) {
+	client, err := plugins.NewHTTPClient(3*time.Second, "http://proxy.lan:3128")
+	if err != nil {
+		t.Fatalf("NewHTTPClient() failed: %v", err)
+	}
+	if client.Timeout != 3*time.Second {
+		t.Errorf("Timeout = %v; want 3s", client.Timeout)
+	}
+
+	transport := client.Transport.(*http.Transport)
+	proxy, err := transport.Proxy(httptest.NewRequest(http.MethodGet, "https://example.com/", nil))
+	if err != nil || proxy == nil || proxy.Host != "proxy.lan:3128" {
+		t.Errorf("Proxy() = %v, %v; want proxy.lan:3128", proxy, err)
+	}
+}
+
+func TestCronSchedule(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone data not available: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		spec  string
+		after time.Time
+		want  time.Time
+	}{
+		{"daily", "0 3 * * *", time.Date(2026, 5, 10, 12, 0, 0, 0, berlin), time.Date(2026, 5, 11, 3, 0, 0, 0, berlin)},
+		{"descriptor", "@hourly", time.Date(2026, 5, 10, 12, 30, 0, 0, berlin), time.Date(2026, 5, 10, 13, 0, 0, 0, berlin)},
+		// 02:30 doesn't exist on the spring-forward day, the next run is a day later
+		{"dst gap", "30 2 * * *", time.Date(2026, 3, 29, 0, 0, 0, 0, berlin), time.Date(2026, 3, 30, 2, 30, 0, 0, berlin)},
+		// 02:30 happens twice on the fall-back day but runs once
+		{"dst repeat", "30 2 * * *", time.Date(2026, 10, 25, 2, 31, 0, 0, berlin), time.Date(2026, 10, 26, 2, 30, 0, 0, berlin)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := plugins.ParseCron("CRON_TZ=Europe/Berlin " + tt.spec)
+			if err != nil {
+				t.Fatalf("ParseCron(%q) failed: %v", tt.spec, err)
+			}
+			if got := schedule.Next(tt.after); !got.Equal(tt.want) {
+				t.Errorf("Next(%v) = %v; want %v", tt.after, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := plugins.ParseCron("61 * * * *"); err == nil {
+		t.Error("ParseCron(invalid) succeeded; want error")
+	}
+	if err := plugins.RunCron(context.Background(), "not a spec", nil, nil, "test", nil); err == nil {
+		t.Error("RunCron(invalid) succeeded; want error")
+	}
+}
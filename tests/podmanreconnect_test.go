@@ -0,0 +1,68 @@
+package tests
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"podmanview/internal/podman"
+)
+
+// TestPodmanResetNotReplayed checks that a request whose connection is
+// reset is replayed only when it is a GET: a POST may already have run
+func TestPodmanResetNotReplayed(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "podman.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	// Every connection reads the start of a request and closes with the
+	// rest unread, which resets it
+	var mu sync.Mutex
+	requests := map[string]int{}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			buf := make([]byte, 16)
+			n, _ := conn.Read(buf)
+			mu.Lock()
+			requests[strings.Fields(string(buf[:n]))[0]]++
+			mu.Unlock()
+			conn.Close()
+		}
+	}()
+
+	client, err := podman.NewClientWithSocket(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetRetryPolicy(podman.RetryPolicy{})
+
+	if err := client.StartContainer(context.Background(), "app"); err == nil {
+		t.Fatal("StartContainer() succeeded on a reset connection")
+	}
+	if _, err := client.ListContainers(context.Background()); err == nil {
+		t.Fatal("ListContainers() succeeded on a reset connection")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if requests["POST"] != 1 {
+		t.Errorf("POST sent %d times; want 1", requests["POST"])
+	}
+	if requests["GET"] != 2 {
+		t.Errorf("GET sent %d times; want 2 (replayed once)", requests["GET"])
+	}
+	// Re-dialing without a successful ping isn't a reconnect
+	if state := client.ConnectionState(); state.Connected || state.Reconnects != 0 {
+		t.Errorf("state = %+v; want disconnected with no reconnects", state)
+	}
+}
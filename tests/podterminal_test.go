@@ -0,0 +1,147 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"podmanview/internal/api"
+	"podmanview/internal/auth"
+	"podmanview/internal/podman"
+)
+
+// fakePodPodman serves a pod with an infra, a running and a stopped
+// container; exec sessions echo their input
+type fakePodPodman struct {
+	podmanMux
+	mu    sync.Mutex
+	execs map[string]string // exec ID -> container ID
+}
+
+func newFakePodPodman() *fakePodPodman {
+	f := &fakePodPodman{execs: map[string]string{}}
+	f.HandleFunc("GET /pods/{name}/json", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("name") != "app" {
+			http.Error(w, `{"message": "no such pod"}`, http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, `{"Id": "pod1", "Name": "app", "InfraContainerID": "aaaaaaaaaaaa1111", "Containers": [
+			{"Id": "aaaaaaaaaaaa1111", "Name": "app-infra", "State": "running"},
+			{"Id": "bbbbbbbbbbbb2222", "Name": "app-db", "State": "exited"},
+			{"Id": "cccccccccccc3333", "Name": "app-web", "State": "running"}]}`)
+	})
+	f.HandleFunc("POST /containers/{id}/exec", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		id := fmt.Sprintf("exec%d", len(f.execs))
+		f.execs[id] = r.PathValue("id")
+		f.mu.Unlock()
+		json.NewEncoder(w).Encode(podman.ExecCreateResponse{ID: id})
+	})
+	f.HandleFunc("POST /exec/{id}/start", func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 101 UPGRADED\r\nConnection: Upgrade\r\nUpgrade: tcp\r\n\r\n")
+		rw.Flush()
+		io.Copy(conn, rw)
+	})
+	return f
+}
+
+func TestPodTerminal(t *testing.T) {
+	fake := newFakePodPodman()
+	apiServer, token, _ := newTestAPI(t, servePodman(t, fake), newTestConfig(t), testAdmin)
+	server := httptest.NewServer(apiServer.Router())
+	defer server.Close()
+	cookie := &http.Cookie{Name: auth.CookieName, Value: token}
+	get := func(path string) *http.Response {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		req.AddCookie(cookie)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	// Without an upgrade the pod's containers are listed
+	resp := get("/api/pods/app/terminal")
+	var list api.PodTerminalResponse
+	json.NewDecoder(resp.Body).Decode(&list)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || list.Pod != "app" || len(list.Containers) != 3 || !list.Containers[0].Infra || list.Default != "app-web" {
+		t.Fatalf("list = %d %+v", resp.StatusCode, list)
+	}
+	if resp := get("/api/pods/missing/terminal"); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("missing pod = %d; want 404", resp.StatusCode)
+	}
+
+	dial := func(query string) (*websocket.Conn, int) {
+		resp := get("/api/auth/ws-token")
+		var wsToken struct {
+			Token string `json:"token"`
+		}
+		json.NewDecoder(resp.Body).Decode(&wsToken)
+		resp.Body.Close()
+
+		wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/pods/app/terminal?ws_token=" + url.QueryEscape(wsToken.Token) + query
+		ws, wsResp, err := websocket.DefaultDialer.Dial(wsURL, http.Header{"Cookie": {cookie.String()}})
+		if err != nil {
+			if wsResp == nil {
+				t.Fatalf("Dial() failed: %v", err)
+			}
+			return nil, wsResp.StatusCode
+		}
+		return ws, http.StatusSwitchingProtocols
+	}
+
+	tests := []struct {
+		query     string
+		container string
+	}{
+		{"", "cccccccccccc3333"},
+		{"&container=app-infra", "aaaaaaaaaaaa1111"},
+		{"&container=cccccccccccc", "cccccccccccc3333"},
+	}
+	for _, tt := range tests {
+		ws, code := dial(tt.query)
+		if ws == nil {
+			t.Fatalf("dial %q = %d", tt.query, code)
+		}
+		ws.WriteJSON(api.ExecMessage{Type: "stdin", Data: "echo hi\n"})
+		ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+		_, data, err := ws.ReadMessage()
+		ws.Close()
+		if err != nil || string(data) != "echo hi\n" {
+			t.Errorf("dial %q: read %q, %v", tt.query, data, err)
+		}
+
+		fake.mu.Lock()
+		container := fake.execs[fmt.Sprintf("exec%d", len(fake.execs)-1)]
+		fake.mu.Unlock()
+		if container != tt.container {
+			t.Errorf("dial %q: exec in %s; want %s", tt.query, container, tt.container)
+		}
+	}
+
+	for query, want := range map[string]int{
+		"&container=app-db":  http.StatusConflict,
+		"&container=missing": http.StatusNotFound,
+	} {
+		if _, code := dial(query); code != want {
+			t.Errorf("dial %q = %d; want %d", query, code, want)
+		}
+	}
+}
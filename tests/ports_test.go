@@ -0,0 +1,63 @@
+package tests
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"podmanview/internal/podman"
+)
+
+func TestListPortBindings(t *testing.T) {
+	var ports []podman.Port
+	data := `[{"host_ip":"","container_port":80,"host_port":8080,"range":1,"protocol":"tcp"},
+		{"host_ip":"127.0.0.1","container_port":53,"host_port":5353,"range":2,"protocol":"udp"},
+		{"host_ip":"","container_port":9000,"host_port":0,"range":1,"protocol":"tcp"}]`
+	if err := json.Unmarshal([]byte(data), &ports); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		port podman.Port
+		want []podman.PortBinding
+	}{
+		{"single", ports[0], []podman.PortBinding{{HostPort: 8080, ContainerPort: 80, Protocol: "tcp"}}},
+		{"range", ports[1], []podman.PortBinding{
+			{HostIP: "127.0.0.1", HostPort: 5353, ContainerPort: 53, Protocol: "udp"},
+			{HostIP: "127.0.0.1", HostPort: 5354, ContainerPort: 54, Protocol: "udp"},
+		}},
+		{"not published", ports[2], nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.port.Bindings(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Bindings() = %+v; want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInspectPortBindings(t *testing.T) {
+	var info podman.ContainerInspect
+	data := `{"NetworkSettings":{"Ports":{
+		"443/tcp":[{"HostIp":"","HostPort":"8443"}],
+		"80/tcp":[{"HostIp":"0.0.0.0","HostPort":"8080"},{"HostIp":"::","HostPort":"8080"}],
+		"9090/tcp":null,
+		"53/UDP":[{"HostIp":"192.168.1.5","HostPort":"53"}]}}}`
+	if err := json.Unmarshal([]byte(data), &info); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	want := []podman.PortBinding{
+		{HostIP: "192.168.1.5", HostPort: 53, ContainerPort: 53, Protocol: "udp"},
+		{HostIP: "0.0.0.0", HostPort: 8080, ContainerPort: 80, Protocol: "tcp"},
+		{HostIP: "::", HostPort: 8080, ContainerPort: 80, Protocol: "tcp"},
+		{HostIP: "", HostPort: 8443, ContainerPort: 443, Protocol: "tcp"},
+	}
+
+	if got := info.PortBindings(); !reflect.DeepEqual(got, want) {
+		t.Errorf("PortBindings() = %+v; want %+v", got, want)
+	}
+}
@@ -0,0 +1,285 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"podmanview/internal/api"
+	"podmanview/internal/auth"
+	"podmanview/internal/podman"
+)
+
+// fakeRecreatePodman serves a running container "web" and records the
+// create and remove calls made while recreating it, and a container
+// "sidecar" sharing its network namespace
+type fakeRecreatePodman struct {
+	podmanMux
+	mu      sync.Mutex
+	calls   []string
+	created []podman.ContainerCreateConfig
+	onStop  func() // called when the container is stopped
+}
+
+const webInspect = `{
+	"Id": "web1", "Name": "web", "Image": "sha-old", "ImageName": "docker.io/library/nginx:1.25",
+	"State": {"Running": true},
+	"Config": {
+		"Env": ["PATH=/usr/bin", "APP=shop", "OLD=1"],
+		"Cmd": ["nginx", "-g", "daemon off;"],
+		"Entrypoint": "/docker-entrypoint.sh",
+		"User": "101",
+		"WorkingDir": "/srv",
+		"Healthcheck": {"Test": ["CMD-SHELL", "curl -f localhost"], "Interval": 30000000000},
+		"Labels": {"maintainer": "nginx", "team": "web"}
+	},
+	"Mounts": [
+		{"Type": "volume", "Name": "webdata", "Source": "/var/lib/containers/storage/volumes/webdata/_data", "Destination": "/data", "RW": true},
+		{"Type": "bind", "Source": "/etc/web", "Destination": "/etc/nginx/conf.d", "RW": false}
+	],
+	"NetworkSettings": {
+		"Ports": {"80/tcp": [{"HostIp": "", "HostPort": "8080"}]},
+		"Networks": {"shop": {"Aliases": ["web1", "www"]}}
+	},
+	"HostConfig": {
+		"RestartPolicy": {"Name": "always"},
+		"NetworkMode": "bridge",
+		"CapAdd": ["CAP_NET_ADMIN"],
+		"Devices": [{"PathOnHost": "/dev/fuse", "PathInContainer": "/dev/fuse", "CgroupPermissions": "rwm"}],
+		"Memory": 536870912, "MemorySwap": 1073741824, "CpuQuota": 150000, "CpuPeriod": 100000, "PidsLimit": 2048
+	}
+}`
+
+const oldImageInspect = `{
+	"Id": "sha-old",
+	"Config": {
+		"Env": ["PATH=/usr/bin"], "Cmd": ["nginx", "-g", "daemon off;"], "Entrypoint": ["/docker-entrypoint.sh"],
+		"WorkingDir": "/", "Labels": {"maintainer": "nginx"}
+	}
+}`
+
+const boxInspect = `{
+	"Id": "box1", "Name": "box", "Image": "sha-old", "ImageName": "docker.io/library/nginx:1.25",
+	"State": {"Running": false},
+	"Config": {"Hostname": "box.local", "Env": ["PATH=/usr/bin"], "Cmd": ["nginx", "-g", "daemon off;"], "Entrypoint": "/docker-entrypoint.sh", "WorkingDir": "/"},
+	"Mounts": [{"Type": "tmpfs", "Source": "tmpfs", "Destination": "/run", "RW": true}],
+	"NetworkSettings": {
+		"Networks": {"shop": {"Aliases": ["box1"], "IPAddress": "10.89.0.5", "MacAddress": "02:42:0a:59:00:05"}}
+	},
+	"HostConfig": {
+		"NetworkMode": "bridge",
+		"Privileged": true,
+		"SecurityOpt": ["label=disable", "seccomp=unconfined", "no-new-privileges"],
+		"Tmpfs": {"/run": "rw,size=64m", "/tmp": ""},
+		"Dns": ["1.1.1.1"], "DnsSearch": ["lan"],
+		"ExtraHosts": ["db:10.0.0.2"]
+	}
+}`
+
+func newFakeRecreatePodman() *fakeRecreatePodman {
+	f := &fakeRecreatePodman{}
+	inspects := map[string]string{
+		"web":        webInspect,
+		"box":        boxInspect,
+		"imagemount": `{"Id": "img1", "Name": "imagemount", "Image": "sha-old", "Mounts": [{"Type": "image", "Destination": "/opt"}], "HostConfig": {"NetworkMode": "bridge"}}`,
+		"sidecar":    `{"Id": "side1", "Name": "sidecar", "Image": "sha-old", "HostConfig": {"NetworkMode": "container:web1"}}`,
+	}
+	f.HandleFunc("GET /containers/{name}/json", func(w http.ResponseWriter, r *http.Request) {
+		inspect, ok := inspects[r.PathValue("name")]
+		if !ok {
+			http.Error(w, `{"message": "no such container"}`, http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(inspect))
+	})
+	f.HandleFunc("GET /images/sha-old/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(oldImageInspect))
+	})
+	f.HandleFunc("/images/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"Id": "sha-new"}`))
+	})
+	f.HandleFunc("POST /containers/create", func(w http.ResponseWriter, r *http.Request) {
+		var cfg podman.ContainerCreateConfig
+		json.NewDecoder(r.Body).Decode(&cfg)
+		f.mu.Lock()
+		f.created = append(f.created, cfg)
+		f.calls = append(f.calls, "create "+cfg.Image)
+		f.mu.Unlock()
+		if cfg.Image == "nginx:broken" {
+			http.Error(w, `{"message":"broken image"}`, http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"Id": "web2"}`))
+	})
+	f.HandleFunc("DELETE /containers/{id}", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		f.calls = append(f.calls, "remove "+r.PathValue("id"))
+		f.mu.Unlock()
+	})
+	// start and stop
+	f.HandleFunc("POST /containers/{id}/{action}", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.calls = append(f.calls, r.PathValue("id")+"/"+r.PathValue("action"))
+		if r.PathValue("action") == "stop" && f.onStop != nil {
+			f.onStop()
+		}
+	})
+	return f
+}
+
+func TestRecreateContainer(t *testing.T) {
+	fake := newFakeRecreatePodman()
+	server, token, request := newTestAPI(t, servePodman(t, fake), newTestConfig(t), testAdmin)
+
+	recreateCtx := func(ctx context.Context, body string) *httptest.ResponseRecorder {
+		fake.mu.Lock()
+		fake.calls, fake.created = nil, nil
+		fake.mu.Unlock()
+		req := httptest.NewRequestWithContext(ctx, http.MethodPost, "/api/containers/web/recreate", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(&http.Cookie{Name: auth.CookieName, Value: token})
+		rec := httptest.NewRecorder()
+		server.Router().ServeHTTP(rec, req)
+		return rec
+	}
+	recreate := func(body string) *httptest.ResponseRecorder {
+		return recreateCtx(context.Background(), body)
+	}
+
+	// Changes are merged into the container's own settings; the image's
+	// command, entrypoint, variables and labels are not pinned
+	rec := recreate(`{"env": {"DEBUG": "1", "OLD": null}, "image": "nginx:1.26", "restart_policy": "on-failure"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("recreate = %d (%s)", rec.Code, rec.Body.String())
+	}
+	wantCalls := []string{"web1/stop", "remove web1", "create nginx:1.26", "web2/start"}
+	if !reflect.DeepEqual(fake.calls, wantCalls) {
+		t.Errorf("calls = %v; want %v", fake.calls, wantCalls)
+	}
+	want := podman.ContainerCreateConfig{
+		Name:          "web",
+		Image:         "nginx:1.26",
+		Env:           map[string]string{"APP": "shop", "DEBUG": "1"},
+		Labels:        map[string]string{"team": "web"},
+		PortMappings:  []podman.PortMapping{{ContainerPort: 80, HostPort: 8080, Protocol: "tcp"}},
+		Mounts:        []podman.Mount{{Type: "bind", Source: "/etc/web", Destination: "/etc/nginx/conf.d", Options: []string{"ro"}}},
+		Volumes:       []podman.NamedVolume{{Name: "webdata", Dest: "/data"}},
+		RestartPolicy: "on-failure",
+		User:          "101",
+		WorkDir:       "/srv",
+		HealthConfig:  &podman.HealthConfig{Test: []string{"CMD-SHELL", "curl -f localhost"}, Interval: 30000000000},
+		CapAdd:        []string{"CAP_NET_ADMIN"},
+		Devices:       []podman.Device{{Path: "/dev/fuse:/dev/fuse:rwm"}},
+		ResourceLimits: &podman.ResourceLimits{
+			Memory: &podman.MemoryLimits{Limit: 536870912, Swap: 1073741824},
+			CPU:    &podman.CPULimits{Quota: 150000, Period: 100000},
+			Pids:   &podman.PidsLimits{Limit: 2048},
+		},
+		NetNS:    &podman.Namespace{NSMode: "bridge"},
+		Networks: map[string]podman.NetworkOptions{"shop": {Aliases: []string{"www"}}},
+	}
+	if len(fake.created) != 1 || !reflect.DeepEqual(fake.created[0], want) {
+		t.Errorf("created = %+v; want %+v", fake.created, want)
+	}
+
+	// A failed create restores the original container
+	rec = recreate(`{"image": "nginx:broken"}`)
+	if rec.Code != http.StatusUnprocessableEntity || !strings.Contains(rec.Body.String(), "recreate_failed") {
+		t.Fatalf("broken recreate = %d (%s)", rec.Code, rec.Body.String())
+	}
+	if len(fake.created) != 2 {
+		t.Fatalf("created = %+v; want the new and the restored container", fake.created)
+	}
+	if restored := fake.created[1]; restored.Image != "docker.io/library/nginx:1.25" ||
+		restored.Env["OLD"] != "1" || restored.RestartPolicy != "always" {
+		t.Errorf("restored = %+v", restored)
+	}
+	if last := fake.calls[len(fake.calls)-1]; last != "web2/start" {
+		t.Errorf("last call = %q; want the restored container started", last)
+	}
+
+	if rec := recreate(`{"restart_policy": "sometimes"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid restart policy = %d; want 400", rec.Code)
+	}
+
+	// Another container's network namespace can't be reproduced
+	rec = request(http.MethodPost, "/api/containers/sidecar/recreate", `{}`)
+	if rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), "unsupported_config") {
+		t.Errorf("recreate with a shared network namespace = %d (%s); want 409", rec.Code, rec.Body.String())
+	}
+	if len(fake.calls) != 0 {
+		t.Errorf("calls = %v; want the container left alone", fake.calls)
+	}
+
+	// A client that goes away after the container was stopped doesn't
+	// leave it removed
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fake.mu.Lock()
+	fake.onStop = cancel
+	fake.mu.Unlock()
+	recreateCtx(ctx, `{"image": "nginx:1.26"}`)
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if !reflect.DeepEqual(fake.calls, wantCalls) {
+		t.Errorf("calls after the client went away = %v; want %v", fake.calls, wantCalls)
+	}
+}
+
+func TestRecreatePrivilegedContainer(t *testing.T) {
+	fake := newFakeRecreatePodman()
+	cfg := newTestConfig(t)
+	cfg.SetNoAuth(true)
+	request := requester(api.NewServer(servePodman(t, fake), cfg, "test", "test"), "")
+
+	recreate := func(name string) *httptest.ResponseRecorder {
+		fake.mu.Lock()
+		fake.calls, fake.created = nil, nil
+		fake.mu.Unlock()
+		return request(http.MethodPost, "/api/containers/"+name+"/recreate", `{}`)
+	}
+
+	rec := recreate("box")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("recreate = %d (%s)", rec.Code, rec.Body.String())
+	}
+	want := podman.ContainerCreateConfig{
+		Name:  "box",
+		Image: "docker.io/library/nginx:1.25",
+		Mounts: []podman.Mount{
+			{Type: "tmpfs", Source: "tmpfs", Destination: "/run", Options: []string{"rw", "size=64m"}},
+			{Type: "tmpfs", Source: "tmpfs", Destination: "/tmp"},
+		},
+		NetNS: &podman.Namespace{NSMode: "bridge"},
+		Networks: map[string]podman.NetworkOptions{
+			"shop": {StaticIPs: []string{"10.89.0.5"}, StaticMAC: "02:42:0a:59:00:05"},
+		},
+		Privileged:         true,
+		SelinuxOpts:        []string{"disable"},
+		SeccompProfilePath: "unconfined",
+		NoNewPrivileges:    true,
+		Hostname:           "box.local",
+		DNSServers:         []string{"1.1.1.1"},
+		DNSSearch:          []string{"lan"},
+		HostAdd:            []string{"db:10.0.0.2"},
+	}
+	if len(fake.created) != 1 || !reflect.DeepEqual(fake.created[0], want) {
+		t.Errorf("created = %+v; want %+v", fake.created, want)
+	}
+
+	// Mounts it doesn't know are refused before the container is touched
+	rec = recreate("imagemount")
+	if rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), "unsupported_config") {
+		t.Errorf("recreate with an image mount = %d (%s); want 409", rec.Code, rec.Body.String())
+	}
+	if len(fake.calls) != 0 {
+		t.Errorf("calls = %v; want the container left alone", fake.calls)
+	}
+}
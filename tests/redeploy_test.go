@@ -0,0 +1,107 @@
+package tests
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"podmanview/internal/api"
+	"podmanview/internal/podman"
+)
+
+// fakeRedeployPodman serves container "web" running nginx:1.25. Pulling
+// moves the tag to latestImage.
+type fakeRedeployPodman struct {
+	podmanMux
+	mu          sync.Mutex
+	latestImage string
+	tagImage    string
+	created     []podman.ContainerCreateConfig
+}
+
+func newFakeRedeployPodman() *fakeRedeployPodman {
+	f := &fakeRedeployPodman{latestImage: "sha-old", tagImage: "sha-old"}
+	f.HandleFunc("GET /containers/web/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"Id": "web1", "Name": "web", "Image": "sha-old", "ImageName": "docker.io/library/nginx:1.25",
+			"State": {"Running": true}, "Config": {"Env": ["APP=shop"]}}`))
+	})
+	f.HandleFunc("POST /images/pull", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.tagImage = f.latestImage
+		w.Write([]byte(`{"stream": "Copying blob 123\n"}` + "\n" + `{"images": ["` + f.tagImage + `"], "id": "` + f.tagImage + `"}` + "\n"))
+	})
+	f.HandleFunc("GET /images/sha-old/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"Id": "sha-old"}`))
+	})
+	f.HandleFunc("GET /images/docker.io/library/nginx:1.25/json", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		w.Write([]byte(`{"Id": "` + f.tagImage + `"}`))
+	})
+	f.HandleFunc("POST /containers/create", func(w http.ResponseWriter, r *http.Request) {
+		var cfg podman.ContainerCreateConfig
+		json.NewDecoder(r.Body).Decode(&cfg)
+		f.mu.Lock()
+		f.created = append(f.created, cfg)
+		f.mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"Id": "web2"}`))
+	})
+	f.HandleFunc("POST /containers/{id}/stop", func(w http.ResponseWriter, r *http.Request) {})
+	f.HandleFunc("DELETE /containers/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	f.HandleFunc("POST /containers/{id}/start", func(w http.ResponseWriter, r *http.Request) {})
+	return f
+}
+
+func TestRedeployContainer(t *testing.T) {
+	fake := newFakeRedeployPodman()
+	_, _, request := newTestAPI(t, servePodman(t, fake), newTestConfig(t), testAdmin)
+	redeploy := func(target string) *httptest.ResponseRecorder {
+		return request(http.MethodPost, target, "")
+	}
+
+	// The tag still points to the running image
+	rec := redeploy("/api/containers/web/redeploy")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("redeploy = %d (%s)", rec.Code, rec.Body.String())
+	}
+	var result api.RedeployResult
+	json.NewDecoder(rec.Body).Decode(&result)
+	if result.Updated || len(fake.created) != 0 {
+		t.Errorf("up-to-date redeploy = %+v, created %d containers", result, len(fake.created))
+	}
+
+	// A new image is pulled and the container recreated on it
+	fake.latestImage = "sha-new"
+	rec = redeploy("/api/containers/web/redeploy?stream=true")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/x-ndjson" {
+		t.Fatalf("streamed redeploy = %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	type redeployMessage struct {
+		api.StreamMessage
+		Result *api.RedeployResult `json:"result"`
+	}
+	var messages []redeployMessage
+	scanner := bufio.NewScanner(rec.Body)
+	for scanner.Scan() {
+		var msg redeployMessage
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			t.Fatalf("invalid line %q: %v", scanner.Text(), err)
+		}
+		messages = append(messages, msg)
+	}
+	if len(messages) != 2 || messages[0].Type != "progress" || messages[0].Stream != "Copying blob 123" {
+		t.Fatalf("messages = %+v; want progress then result", messages)
+	}
+	last := messages[1]
+	if last.Type != "result" || !last.Result.Updated || last.Result.ID != "web2" || last.Result.NewImage != "sha-new" {
+		t.Errorf("result = %+v", last.Result)
+	}
+	if len(fake.created) != 1 || fake.created[0].Image != "docker.io/library/nginx:1.25" || fake.created[0].Env["APP"] != "shop" {
+		t.Errorf("created = %+v; want the same settings on the pulled tag", fake.created)
+	}
+}
@@ -0,0 +1,42 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"podmanview/internal/api"
+	"podmanview/internal/config"
+)
+
+func TestRequestIDHeader(t *testing.T) {
+	cfg, err := config.Load(filepath.Join(t.TempDir(), ".env"))
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	server := api.NewServer(nil, cfg, "test", "test")
+
+	get := func(reqID string) string {
+		req := httptest.NewRequest(http.MethodGet, "/api/containers", nil)
+		if reqID != "" {
+			req.Header.Set("X-Request-Id", reqID)
+		}
+		rec := httptest.NewRecorder()
+		server.Router().ServeHTTP(rec, req)
+		return rec.Header().Get("X-Request-ID")
+	}
+
+	first, second := get(""), get("")
+	if first == "" || second == "" {
+		t.Fatalf("X-Request-ID missing: %q, %q", first, second)
+	}
+	if first == second {
+		t.Errorf("two requests got the same ID %q", first)
+	}
+
+	// An ID set by a reverse proxy is kept for end-to-end correlation
+	if got := get("proxy-1234"); got != "proxy-1234" {
+		t.Errorf("X-Request-ID = %q; want the incoming proxy-1234", got)
+	}
+}
@@ -0,0 +1,195 @@
+package tests
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"podmanview/internal/api"
+	"podmanview/internal/podman"
+)
+
+// fakeRunPodman runs containers that exit with exitCode, or never exit
+// when hang is set, and records the calls made for them. With missing set
+// the image is only there once pulled.
+type fakeRunPodman struct {
+	podmanMux
+	mu       sync.Mutex
+	calls    []string
+	created  []podman.ContainerCreateConfig
+	exitCode string
+	hang     bool
+	missing  bool
+}
+
+func newFakeRunPodman() *fakeRunPodman {
+	f := &fakeRunPodman{}
+	f.HandleFunc("POST /images/pull", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.missing = false
+		f.calls = append(f.calls, "pull")
+		w.Write([]byte(`{"stream": "Copying blob 123\n"}` + "\n" + `{"images": ["sha-alpine"], "id": "sha-alpine"}` + "\n"))
+	})
+	f.HandleFunc("GET /images/", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if f.missing {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"cause": "image not known", "message": "image not known", "response": 404}`))
+			return
+		}
+		w.Write([]byte(`{"Id": "sha-alpine"}`))
+	})
+	f.HandleFunc("POST /containers/create", func(w http.ResponseWriter, r *http.Request) {
+		var cfg podman.ContainerCreateConfig
+		json.NewDecoder(r.Body).Decode(&cfg)
+		f.mu.Lock()
+		f.created = append(f.created, cfg)
+		f.calls = append(f.calls, "create")
+		f.mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"Id": "run1"}`))
+	})
+	f.HandleFunc("POST /containers/{id}/wait", func(w http.ResponseWriter, r *http.Request) {
+		if f.hang {
+			<-r.Context().Done()
+			return
+		}
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.calls = append(f.calls, "wait "+r.URL.Query().Get("condition"))
+		w.Write([]byte(f.exitCode))
+	})
+	f.HandleFunc("GET /containers/{id}/logs", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.calls = append(f.calls, "logs")
+		w.Write([]byte("Connecting to nas.lan\nwriting to stdout\n"))
+	})
+	f.HandleFunc("DELETE /containers/{id}", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.calls = append(f.calls, "remove "+r.URL.RawQuery)
+	})
+	f.HandleFunc("POST /containers/{id}/{action}", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.calls = append(f.calls, r.PathValue("action"))
+	})
+	return f
+}
+
+func TestRunContainer(t *testing.T) {
+	fake := newFakeRunPodman()
+	fake.exitCode = "0"
+	_, _, request := newTestAPI(t, servePodman(t, fake), newTestConfig(t), testAdmin)
+	run := func(body string) (*httptest.ResponseRecorder, api.RunContainerResponse) {
+		fake.calls, fake.created = nil, nil
+		rec := request(http.MethodPost, "/api/containers/run", body)
+		var resp api.RunContainerResponse
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		return rec, resp
+	}
+
+	// Output oldest first, exit code, and the container removed afterwards
+	rec, resp := run(`{"image": "alpine", "command": ["wget", "-qO-", "http://nas.lan"], "env": "A=1"}`)
+	if rec.Code != http.StatusOK || resp.Status != "exited" || resp.ExitCode == nil || *resp.ExitCode != 0 {
+		t.Fatalf("run = %d %s", rec.Code, rec.Body.String())
+	}
+	if !reflect.DeepEqual(resp.Output, []string{"Connecting to nas.lan", "writing to stdout"}) {
+		t.Errorf("output = %q", resp.Output)
+	}
+	wantCalls := []string{"create", "start", "wait exited", "logs", "remove force=true"}
+	if !reflect.DeepEqual(fake.calls, wantCalls) {
+		t.Errorf("calls = %v; want %v", fake.calls, wantCalls)
+	}
+	created := fake.created[0]
+	if !reflect.DeepEqual(created.Command, []string{"wget", "-qO-", "http://nas.lan"}) || created.Env["A"] != "1" || created.Remove {
+		t.Errorf("created = %+v", created)
+	}
+
+	fake.exitCode = "3"
+	if rec, resp := run(`{"image": "alpine", "command": ["false"]}`); rec.Code != http.StatusOK || resp.ExitCode == nil || *resp.ExitCode != 3 {
+		t.Errorf("failing run = %d %s; want exit code 3", rec.Code, rec.Body.String())
+	}
+
+	// Detached: created with auto-remove and not waited for
+	rec, resp = run(`{"image": "alpine", "command": ["sleep", "5"], "detach": true}`)
+	if rec.Code != http.StatusAccepted || resp.Status != "started" || !fake.created[0].Remove {
+		t.Errorf("detached run = %d %s, created %+v", rec.Code, rec.Body.String(), fake.created)
+	}
+	if !reflect.DeepEqual(fake.calls, []string{"create", "start"}) {
+		t.Errorf("detached calls = %v", fake.calls)
+	}
+
+	// Still running at the timeout: killed by the forced remove
+	fake.hang = true
+	rec, resp = run(`{"image": "alpine", "command": ["sleep", "inf"], "timeout": 1}`)
+	fake.hang = false
+	if rec.Code != http.StatusOK || resp.Status != "timeout" || resp.ExitCode != nil || len(resp.Output) != 2 {
+		t.Errorf("timed out run = %d %s", rec.Code, rec.Body.String())
+	}
+	if got := fake.calls[len(fake.calls)-1]; got != "remove force=true" {
+		t.Errorf("last call = %q; want the forced remove", got)
+	}
+
+	for _, body := range []string{`{"command": ["ls"]}`, `{"image": "alpine", "timeout": 601}`, `{"image": "alpine", "timeout": -1}`} {
+		if rec, _ := run(body); rec.Code != http.StatusBadRequest || len(fake.calls) != 0 {
+			t.Errorf("run %s = %d; want 400 without calling Podman", body, rec.Code)
+		}
+	}
+}
+
+func TestCreateAutoPullStream(t *testing.T) {
+	fake := newFakeRunPodman()
+	fake.missing = true
+	cfg := newTestConfig(t)
+	cfg.SetNoAuth(true)
+	request := requester(api.NewServer(servePodman(t, fake), cfg, "test", "test"), "")
+	create := func(target, body string) *httptest.ResponseRecorder {
+		return request(http.MethodPost, target, body)
+	}
+
+	// Without auto_pull a missing image is a plain 404, streaming or not
+	rec := create("/api/containers?stream=true", `{"image": "alpine"}`)
+	if rec.Code != http.StatusNotFound || !strings.Contains(rec.Body.String(), "image_not_found") || len(fake.calls) != 0 {
+		t.Fatalf("create without auto_pull = %d %s, calls %v", rec.Code, rec.Body.String(), fake.calls)
+	}
+
+	rec = create("/api/containers?stream=true", `{"image": "alpine", "auto_pull": true}`)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/x-ndjson" {
+		t.Fatalf("streamed create = %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	var messages []api.StreamMessage
+	scanner := bufio.NewScanner(rec.Body)
+	for scanner.Scan() {
+		var msg api.StreamMessage
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			t.Fatalf("invalid line %q: %v", scanner.Text(), err)
+		}
+		messages = append(messages, msg)
+	}
+	if len(messages) != 2 || messages[0].Type != "progress" || messages[0].Stream != "Copying blob 123" {
+		t.Fatalf("messages = %+v; want progress then result", messages)
+	}
+	result, _ := messages[1].Result.(map[string]any)
+	if messages[1].Type != "result" || result["id"] != "run1" || result["status"] != "created" {
+		t.Errorf("result = %+v", messages[1])
+	}
+	if !reflect.DeepEqual(fake.calls, []string{"pull", "create"}) {
+		t.Errorf("calls = %v; want the pull before the create", fake.calls)
+	}
+
+	// Present images aren't pulled, and without stream the response is unchanged
+	fake.calls = nil
+	rec = create("/api/containers", `{"image": "alpine", "auto_pull": true}`)
+	if rec.Code != http.StatusCreated || rec.Header().Get("Content-Type") != "application/json" || !reflect.DeepEqual(fake.calls, []string{"create"}) {
+		t.Errorf("create = %d %q, calls %v", rec.Code, rec.Header().Get("Content-Type"), fake.calls)
+	}
+}
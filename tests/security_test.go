@@ -0,0 +1,182 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"podmanview/internal/api"
+	"podmanview/internal/plugins"
+	"podmanview/internal/plugins/demo"
+	"podmanview/internal/storage"
+)
+
+func TestHTTPSRedirect(t *testing.T) {
+	tests := []struct {
+		port   string
+		target string
+		want   string
+	}{
+		{"443", "http://rv2.lan/api/containers?all=true", "https://rv2.lan/api/containers?all=true"},
+		{"8443", "http://rv2.lan:80/", "https://rv2.lan:8443/"},
+		{"8443", "http://[fd00::1]/login", "https://[fd00::1]:8443/login"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			api.NewHTTPSRedirect(tt.port).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			if rec.Code != http.StatusMovedPermanently {
+				t.Errorf("status = %d; want %d", rec.Code, http.StatusMovedPermanently)
+			}
+			if got := rec.Header().Get("Location"); got != tt.want {
+				t.Errorf("Location = %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	tests := []struct {
+		name           string
+		frameAncestors string
+		wantFrame      string
+		wantCSP        string
+	}{
+		{"default", "", "SAMEORIGIN", "frame-ancestors 'self'"},
+		{"deny", "'none'", "DENY", "frame-ancestors 'none'"},
+		{"embed", "'self' https://ha.local:8123", "", "frame-ancestors 'self' https://ha.local:8123"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newTestConfig(t)
+			if tt.frameAncestors != "" {
+				if err := cfg.SetFrameAncestors(tt.frameAncestors); err != nil {
+					t.Fatalf("SetFrameAncestors() failed: %v", err)
+				}
+			}
+
+			server := api.NewServer(nil, cfg, "test", "test")
+			rec := httptest.NewRecorder()
+			server.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/auth/me", nil))
+
+			if got := rec.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+				t.Errorf("X-Content-Type-Options = %q; want nosniff", got)
+			}
+			if got := rec.Header().Get("X-Frame-Options"); got != tt.wantFrame {
+				t.Errorf("X-Frame-Options = %q; want %q", got, tt.wantFrame)
+			}
+			if got := rec.Header().Get("Content-Security-Policy"); !strings.Contains(got, tt.wantCSP) {
+				t.Errorf("Content-Security-Policy = %q; want it to contain %q", got, tt.wantCSP)
+			}
+		})
+	}
+}
+
+func TestPluginSandboxHTML(t *testing.T) {
+	cfg := newTestConfig(t)
+	if err := cfg.SetPluginSandbox(true); err != nil {
+		t.Fatalf("SetPluginSandbox() failed: %v", err)
+	}
+	cfg.SetNoAuth(true)
+
+	plugin := &htmlPlugin{html: `<section id="page-plugin-test" class="content-page hidden"><script>alert(document.cookie)</script></section>`}
+	server := api.NewServerWithPlugins(nil, cfg, "test", "test", []plugins.Plugin{plugin}, nil, nil)
+
+	// Inline request gets an iframe instead of the plugin markup
+	rec := httptest.NewRecorder()
+	server.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/plugins/test/html", nil))
+	body := rec.Body.String()
+	if strings.Contains(body, "<script>") || !strings.Contains(body, `sandbox="allow-scripts allow-forms"`) {
+		t.Errorf("GET html = %q; want sandboxed iframe without plugin script", body)
+	}
+
+	// Frame document carries the plugin markup with a sandbox CSP
+	rec = httptest.NewRecorder()
+	server.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/plugins/test/html?frame=1", nil))
+	if !strings.Contains(rec.Body.String(), "<script>alert") {
+		t.Errorf("GET html?frame=1 missing plugin markup: %q", rec.Body.String())
+	}
+	if csp := rec.Header().Get("Content-Security-Policy"); !strings.HasPrefix(csp, "sandbox allow-scripts allow-forms;") {
+		t.Errorf("frame Content-Security-Policy = %q; want sandbox policy", csp)
+	}
+}
+
+// TestPluginSandboxBundled checks that a bundled plugin page works
+// sandboxed: the frame loads the bridge without a session, and the calls
+// the bridge relays for the plugin succeed with the parent's session
+func TestPluginSandboxBundled(t *testing.T) {
+	cfg := newTestConfig(t)
+	if err := cfg.SetPluginSandbox(true); err != nil {
+		t.Fatalf("SetPluginSandbox() failed: %v", err)
+	}
+
+	store, err := storage.NewBoltStorage(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create test storage: %v", err)
+	}
+	defer store.Close()
+	if err := store.SetPluginConfig("demo", &storage.PluginConfig{Enabled: true, Name: "Demo"}); err != nil {
+		t.Fatal(err)
+	}
+	plugin := demo.New()
+	if err := plugin.Init(context.Background(), &plugins.PluginDependencies{Storage: store, Config: cfg}); err != nil {
+		t.Fatalf("Init() failed: %v", err)
+	}
+	server := api.NewServerWithPlugins(nil, cfg, "test", "test", []plugins.Plugin{plugin}, nil, store)
+
+	token := sessionToken(t, cfg, testAdmin)
+	get := func(target string, session bool) *httptest.ResponseRecorder {
+		if session {
+			return requester(server, token)(http.MethodGet, target, "")
+		}
+		return requester(server, "")(http.MethodGet, target, "")
+	}
+
+	// The parent page gets a frame that tells it which plugin it holds
+	if rec := get("/api/plugins/demo/html", true); !strings.Contains(rec.Body.String(), `data-plugin="demo"`) {
+		t.Fatalf("GET html = %d %q; want frame for demo", rec.Code, rec.Body.String())
+	}
+
+	// The frame document loads the bridge, which its CSP allows
+	rec := get("/api/plugins/demo/html?frame=1", true)
+	if !strings.Contains(rec.Body.String(), `<script src="/static/js/plugin-frame.js?v=test" data-plugin="demo">`) {
+		t.Errorf("frame document without bridge: %q", rec.Body.String())
+	}
+	if csp := rec.Header().Get("Content-Security-Policy"); !strings.Contains(csp, "script-src 'self' 'unsafe-inline'") {
+		t.Errorf("frame Content-Security-Policy = %q; want scripts from self", csp)
+	}
+
+	// The opaque frame origin has no session cookie for the bridge script
+	if rec := get("/static/js/plugin-frame.js", false); rec.Code != http.StatusOK {
+		t.Errorf("GET plugin-frame.js without session = %d; want 200", rec.Code)
+	}
+
+	// The status call the bridge relays uses the parent's session
+	if rec := get("/api/plugins/demo/info", false); rec.Code != http.StatusUnauthorized {
+		t.Errorf("GET info from the frame origin = %d; want 401", rec.Code)
+	}
+	if rec := get("/api/plugins/demo/info", true); rec.Code != http.StatusOK {
+		t.Errorf("GET info relayed by the parent = %d %s; want 200", rec.Code, rec.Body.String())
+	}
+}
+
+// htmlPlugin is a minimal plugin that only serves HTML
+type htmlPlugin struct {
+	html string
+}
+
+func (p *htmlPlugin) Name() string                                            { return "test" }
+func (p *htmlPlugin) Description() string                                     { return "Test plugin" }
+func (p *htmlPlugin) Version() string                                         { return "1.0.0" }
+func (p *htmlPlugin) Init(context.Context, *plugins.PluginDependencies) error { return nil }
+func (p *htmlPlugin) Start(context.Context) error                             { return nil }
+func (p *htmlPlugin) Stop(context.Context) error                              { return nil }
+func (p *htmlPlugin) Routes() []plugins.Route                                 { return nil }
+func (p *htmlPlugin) IsEnabled() bool                                         { return true }
+func (p *htmlPlugin) GetHTML() (string, error)                                { return p.html, nil }
@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"podmanview/internal/api"
+)
+
+func TestContainersStatsCache(t *testing.T) {
+	var streams atomic.Int32
+	events := make(chan string, 4)
+	fake := &podmanMux{}
+	fake.HandleFunc("GET /containers/stats", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("stream") != "true" {
+			w.Write([]byte(`{"Error": null, "Stats": [{"ContainerID": "web1full", "Name": "web", "CPU": 1}]}`))
+			return
+		}
+		// Each stream reports once per stream opened so far
+		n := streams.Add(1)
+		if r.URL.Query().Get("interval") != "5" {
+			t.Errorf("stats interval = %q; want 5", r.URL.Query().Get("interval"))
+		}
+		fmt.Fprintf(w, `{"Error": null, "Stats": [{"ContainerID": "web1full", "Name": "web", "CPU": %d}, {"ContainerID": "db1full", "Name": "db", "CPU": 50}]}`, 10*n)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})
+	fake.HandleFunc("GET /events", func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.URL.Query().Get("filters"), "container") {
+			t.Errorf("events filters = %q", r.URL.Query().Get("filters"))
+		}
+		w.(http.Flusher).Flush()
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case event := <-events:
+				w.Write([]byte(event + "\n"))
+				w.(http.Flusher).Flush()
+			}
+		}
+	})
+	server, _, request := newTestAPI(t, servePodman(t, fake), newTestConfig(t), testReader)
+	stats := func() map[string]float64 {
+		rec := request(http.MethodGet, "/api/containers/stats", "")
+		var resp api.ContainersStatsResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusOK {
+			t.Fatalf("stats = %d %s", rec.Code, rec.Body.String())
+		}
+		cpu := make(map[string]float64)
+		for _, s := range resp.Stats {
+			cpu[s.Name] = s.CPU
+		}
+		return cpu
+	}
+	waitFor := func(what string, ok func(map[string]float64) bool) {
+		t.Helper()
+		var cpu map[string]float64
+		for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+			if cpu = stats(); ok(cpu) {
+				return
+			}
+		}
+		t.Fatalf("%s: stats = %v", what, cpu)
+	}
+
+	// Without the stream running Podman is asked directly
+	if cpu := stats(); len(cpu) != 1 || cpu["web"] != 1 {
+		t.Errorf("uncached stats = %v; want web only", cpu)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server.StartStatsCache(ctx)
+	waitFor("streamed", func(cpu map[string]float64) bool { return cpu["web"] == 10 && cpu["db"] == 50 })
+
+	// A stopped container is dropped right away
+	events <- `{"Type": "container", "Action": "died", "Actor": {"ID": "db1full"}}`
+	waitFor("after died", func(cpu map[string]float64) bool { _, ok := cpu["db"]; return !ok && cpu["web"] == 10 })
+
+	// A started container reopens the stream to watch it too
+	events <- `{"Type": "container", "Action": "start", "Actor": {"ID": "db1full"}}`
+	waitFor("after start", func(cpu map[string]float64) bool { return cpu["web"] == 20 && cpu["db"] == 50 })
+	if n := streams.Load(); n != 2 {
+		t.Errorf("stats streams = %d; want 2", n)
+	}
+}
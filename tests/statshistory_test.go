@@ -0,0 +1,116 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"podmanview/internal/api"
+	"podmanview/internal/podman"
+)
+
+func TestStatsHistoryRing(t *testing.T) {
+	history := api.NewStatsHistory(3, 2)
+	start := time.Unix(1000, 0)
+	sample := func(i int, ids ...string) {
+		stats := make([]podman.ContainerStats, len(ids))
+		for j, id := range ids {
+			stats[j] = podman.ContainerStats{ContainerID: id, CPU: float64(i)}
+		}
+		history.Add(start.Add(time.Duration(i)*time.Second), stats)
+	}
+	cpus := func(points []api.StatsPoint) []float64 {
+		out := []float64{}
+		for _, p := range points {
+			out = append(out, p.CPU)
+		}
+		return out
+	}
+
+	for i := 1; i <= 5; i++ {
+		sample(i, "a")
+	}
+	// Only the newest 3, oldest first
+	if got := cpus(history.Get("a", 0)); !reflect.DeepEqual(got, []float64{3, 4, 5}) {
+		t.Errorf("a = %v; want [3 4 5]", got)
+	}
+	if got := cpus(history.Get("a", 2)); !reflect.DeepEqual(got, []float64{4, 5}) {
+		t.Errorf("a newest 2 = %v; want [4 5]", got)
+	}
+
+	// A third container evicts the one sampled longest ago
+	sample(6, "b")
+	sample(7, "b", "c")
+	if got := history.Containers(); !reflect.DeepEqual(got, []string{"b", "c"}) {
+		t.Errorf("containers = %v; want [b c]", got)
+	}
+
+	history.Remove("b")
+	history.Prune(start.Add(8 * time.Second))
+	if got := history.Containers(); len(got) != 0 {
+		t.Errorf("containers after remove and prune = %v; want none", got)
+	}
+	if got := history.Get("missing", 10); got == nil || len(got) != 0 {
+		t.Errorf("missing = %v; want empty", got)
+	}
+}
+
+func TestStatsHistoryAPI(t *testing.T) {
+	fake := &podmanMux{}
+	fake.HandleFunc("GET /containers/stats", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"Error": null, "Stats": [{"ContainerID": "web1full", "Name": "web", "CPU": 12.5, "MemUsage": 1048576, "MemPerc": 2.5}]}`))
+	})
+	fake.HandleFunc("GET /containers/{name}/json", func(w http.ResponseWriter, r *http.Request) {
+		if name := r.PathValue("name"); name != "web" && name != "web1full" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"Id": "web1full", "Name": "web", "Config": {}, "HostConfig": {}}`))
+	})
+	fake.HandleFunc("DELETE /containers/web", func(w http.ResponseWriter, r *http.Request) {})
+	server, _, request := newTestAPI(t, servePodman(t, fake), newTestConfig(t), testAdmin)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server.StartStatsHistory(ctx)
+
+	history := func() api.StatsHistoryResponse {
+		rec := request(http.MethodGet, "/api/containers/web/stats/history?points=10", "")
+		var resp api.StatsHistoryResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusOK {
+			t.Fatalf("history = %d %s", rec.Code, rec.Body.String())
+		}
+		return resp
+	}
+
+	// The first sample is taken right after starting
+	var resp api.StatsHistoryResponse
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if resp = history(); len(resp.Points) > 0 {
+			break
+		}
+	}
+	if resp.ID != "web1full" || resp.Interval != 10 || len(resp.Points) != 1 ||
+		resp.Points[0].CPU != 12.5 || resp.Points[0].MemUsage != 1048576 {
+		t.Fatalf("history = %+v", resp)
+	}
+
+	// Removing the container drops its history
+	if rec := request(http.MethodDelete, "/api/containers/web", ""); rec.Code != http.StatusOK {
+		t.Fatalf("remove = %d %s", rec.Code, rec.Body.String())
+	}
+	if resp := history(); len(resp.Points) != 0 {
+		t.Errorf("history after remove = %+v; want none", resp.Points)
+	}
+
+	for _, points := range []string{"0", "361", "many"} {
+		if rec := request(http.MethodGet, "/api/containers/web/stats/history?points="+points, ""); rec.Code != http.StatusBadRequest {
+			t.Errorf("points=%s = %d; want 400", points, rec.Code)
+		}
+	}
+	if rec := request(http.MethodGet, "/api/containers/missing/stats/history", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("missing container = %d; want 404", rec.Code)
+	}
+}
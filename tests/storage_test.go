@@ -7,6 +7,8 @@ import (
 	"testing"
 	"time"
 
+	"go.etcd.io/bbolt"
+
 	"podmanview/internal/storage"
 )
 
@@ -178,29 +180,29 @@ func TestBoltStorage(t *testing.T) {
 	t.Run("CommandHistory", func(t *testing.T) {
 		// Save some commands
 		now := time.Now()
-		err := store.SaveCommandHistory("ls -la", now)
+		err := store.SaveCommandHistory("alice", "ls -la", now)
 		if err != nil {
 			t.Fatalf("Failed to save command: %v", err)
 		}
 
-		err = store.SaveCommandHistory("cd /tmp", now.Add(1*time.Second))
+		err = store.SaveCommandHistory("alice", "cd /tmp", now.Add(1*time.Second))
 		if err != nil {
 			t.Fatalf("Failed to save command: %v", err)
 		}
 
-		err = store.SaveCommandHistory("pwd", now.Add(2*time.Second))
+		err = store.SaveCommandHistory("alice", "pwd", now.Add(2*time.Second))
 		if err != nil {
 			t.Fatalf("Failed to save command: %v", err)
 		}
 
 		// Try to save duplicate (should be skipped)
-		err = store.SaveCommandHistory("pwd", now.Add(3*time.Second))
+		err = store.SaveCommandHistory("alice", "pwd", now.Add(3*time.Second))
 		if err != nil {
 			t.Fatalf("Failed to save duplicate command: %v", err)
 		}
 
 		// Get history
-		history, err := store.GetCommandHistory(10)
+		history, err := store.GetCommandHistory("alice", 10)
 		if err != nil {
 			t.Fatalf("Failed to get history: %v", err)
 		}
@@ -222,7 +224,7 @@ func TestBoltStorage(t *testing.T) {
 		}
 
 		// Get last command
-		lastCmd, err := store.GetLastCommand()
+		lastCmd, err := store.GetLastCommand("alice")
 		if err != nil {
 			t.Fatalf("Failed to get last command: %v", err)
 		}
@@ -231,7 +233,7 @@ func TestBoltStorage(t *testing.T) {
 		}
 
 		// Test history limit
-		history, err = store.GetCommandHistory(2)
+		history, err = store.GetCommandHistory("alice", 2)
 		if err != nil {
 			t.Fatalf("Failed to get limited history: %v", err)
 		}
@@ -242,25 +244,121 @@ func TestBoltStorage(t *testing.T) {
 		// Add more commands to test trim
 		for i := 0; i < 10; i++ {
 			cmd := fmt.Sprintf("echo %d", i)
-			err := store.SaveCommandHistory(cmd, now.Add(time.Duration(i+10)*time.Second))
+			err := store.SaveCommandHistory("alice", cmd, now.Add(time.Duration(i+10)*time.Second))
 			if err != nil {
 				t.Fatalf("Failed to save command %d: %v", i, err)
 			}
 		}
 
 		// Trim to last 5 commands
-		err = store.TrimCommandHistory(5)
+		err = store.TrimCommandHistory("alice", 5)
 		if err != nil {
 			t.Fatalf("Failed to trim history: %v", err)
 		}
 
 		// Check that only 5 commands remain
-		history, err = store.GetCommandHistory(100)
+		history, err = store.GetCommandHistory("alice", 100)
 		if err != nil {
 			t.Fatalf("Failed to get history after trim: %v", err)
 		}
 		if len(history) != 5 {
 			t.Errorf("Expected 5 commands after trim, got %d", len(history))
 		}
+
+		// Delete a single entry (index 0 = oldest)
+		if err := store.DeleteCommandHistory("alice", 0); err != nil {
+			t.Fatalf("Failed to delete history entry: %v", err)
+		}
+		history, _ = store.GetCommandHistory("alice", 100)
+		if len(history) != 4 || history[0].Command != "echo 6" {
+			t.Errorf("Expected 4 commands starting with 'echo 6', got %v", history)
+		}
+
+		if err := store.DeleteCommandHistory("alice", 4); err != storage.ErrNotFound {
+			t.Errorf("Expected ErrNotFound for out of range index, got %v", err)
+		}
+
+		// Clear all
+		if err := store.ClearCommandHistory("alice"); err != nil {
+			t.Fatalf("Failed to clear history: %v", err)
+		}
+		history, _ = store.GetCommandHistory("alice", 100)
+		if len(history) != 0 {
+			t.Errorf("Expected empty history after clear, got %d commands", len(history))
+		}
+
+		// History still works after clearing
+		if err := store.SaveCommandHistory("alice", "uptime", now.Add(time.Minute)); err != nil {
+			t.Fatalf("Failed to save command after clear: %v", err)
+		}
 	})
+
+	// Test history is kept per user
+	t.Run("CommandHistoryPerUser", func(t *testing.T) {
+		now := time.Now()
+		if err := store.SaveCommandHistory("bob", "podman ps", now); err != nil {
+			t.Fatalf("Failed to save command: %v", err)
+		}
+
+		history, err := store.GetCommandHistory("bob", 100)
+		if err != nil {
+			t.Fatalf("Failed to get history: %v", err)
+		}
+		if len(history) != 1 || history[0].Command != "podman ps" {
+			t.Errorf("Expected only bob's command, got %v", history)
+		}
+
+		// Unknown user has no history
+		history, err = store.GetCommandHistory("carol", 100)
+		if err != nil || len(history) != 0 {
+			t.Errorf("Expected empty history for new user, got %v (%v)", history, err)
+		}
+
+		// Clearing one user's history keeps the others
+		if err := store.ClearCommandHistory("bob"); err != nil {
+			t.Fatalf("Failed to clear history: %v", err)
+		}
+		if last, _ := store.GetLastCommand("alice"); last != "uptime" {
+			t.Errorf("Expected alice's history to remain, last command %q", last)
+		}
+
+		if err := store.SaveCommandHistory("", "ls", now); err == nil {
+			t.Error("Expected error saving history without username")
+		}
+	})
+}
+
+func TestBoltStorageDropsSharedHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "legacy.db")
+
+	// Shared history from before per-user history: entries directly in _history
+	db, err := bbolt.Open(path, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Update(func(tx *bbolt.Tx) error {
+		bucket, err := tx.CreateBucket([]byte("_history"))
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte("00000000000000000001"), []byte(`{"command":"secret","timestamp":"2024-01-01T00:00:00Z"}`))
+	})
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := storage.NewBoltStorage(path)
+	if err != nil {
+		t.Fatalf("Failed to open storage: %v", err)
+	}
+	defer store.Close()
+
+	if err := store.SaveCommandHistory("alice", "ls", time.Now()); err != nil {
+		t.Fatalf("Failed to save command: %v", err)
+	}
+	history, _ := store.GetCommandHistory("alice", 100)
+	if len(history) != 1 || history[0].Command != "ls" {
+		t.Errorf("Expected only alice's new command, got %v", history)
+	}
 }
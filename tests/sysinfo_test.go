@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"podmanview/internal/api"
+	"podmanview/internal/config"
+)
+
+func TestHostStatsProcPath(t *testing.T) {
+	// A host /proc mounted into the container, without access to the host's root
+	proc := t.TempDir()
+	files := map[string]string{
+		"stat":    "cpu  100 0 50 800 50 0 0 0 0 0\n",
+		"meminfo": "MemTotal:        8000000 kB\nMemFree:          100000 kB\nMemAvailable:    2000000 kB\nSwapTotal:       1000000 kB\nSwapFree:         250000 kB\n",
+		"uptime":  "86400.50 170000.00\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(proc, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stats := api.GetHostStats(proc)
+	if stats.MemTotal != 8000000*1024 || stats.MemFree != 2000000*1024 || stats.Uptime != 86400 {
+		t.Errorf("stats = %+v; want values from %s", stats, proc)
+	}
+	if stats.SwapTotal != 1000000*1024 || stats.SwapFree != 250000*1024 {
+		t.Errorf("swap = %d free of %d; want 250000 kB of 1000000 kB", stats.SwapFree, stats.SwapTotal)
+	}
+	if !reflect.DeepEqual(stats.Unavailable, []string{"disks"}) {
+		t.Errorf("unavailable = %v; want [disks]", stats.Unavailable)
+	}
+
+	// No swap reports zeros
+	os.WriteFile(filepath.Join(proc, "meminfo"), []byte("MemTotal: 8000000 kB\nSwapTotal: 0 kB\nSwapFree: 0 kB\n"), 0644)
+	if stats := api.GetHostStats(proc); stats.SwapTotal != 0 || stats.SwapFree != 0 || stats.MemTotal == 0 {
+		t.Errorf("without swap = %+v", stats)
+	}
+
+	// Nothing readable degrades to empty values instead of failing
+	stats = api.GetHostStats(filepath.Join(proc, "missing"))
+	if want := []string{"cpu", "memory", "uptime", "disks"}; !reflect.DeepEqual(stats.Unavailable, want) {
+		t.Errorf("unavailable = %v; want %v", stats.Unavailable, want)
+	}
+
+	// Relative paths would depend on the working directory
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte(config.EnvHostProc+"=host/proc\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := config.Load(path); err == nil {
+		t.Error("Load() accepted a relative host proc path")
+	}
+}
+
+func TestHostSampler(t *testing.T) {
+	proc := t.TempDir()
+	writeStat := func(user, idle int) {
+		t.Helper()
+		line := fmt.Sprintf("cpu  %d 0 0 %d 0 0 0 0 0 0\n", user, idle)
+		if err := os.WriteFile(filepath.Join(proc, "stat"), []byte(line), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeStat(100, 100)
+
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte(config.EnvHostProc+"="+proc+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	api.StartHostSampler(ctx, cfg)
+
+	// 150 of the next 200 ticks busy; the first delta is sampled shortly after start
+	writeStat(250, 150)
+	deadline := time.Now().Add(5 * time.Second)
+	for api.GetHostStats(proc).CPUUsage != 75 {
+		if time.Now().After(deadline) {
+			t.Fatalf("CPU usage = %v; want 75", api.GetHostStats(proc).CPUUsage)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	// Requests read the last sample instead of measuring between themselves
+	if usage := api.GetHostStats(proc).CPUUsage; usage != 75 {
+		t.Errorf("CPU usage on the next request = %v; want 75", usage)
+	}
+}
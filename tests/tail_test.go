@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"podmanview/internal/api"
+	"podmanview/internal/auth"
+)
+
+func TestFileTail(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	logPath := filepath.Join(home, "app.log")
+	var initial strings.Builder
+	for i := 1; i <= 10; i++ {
+		initial.WriteString("line " + string(rune('0'+i%10)) + "\n")
+	}
+	if err := os.WriteFile(logPath, []byte(initial.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := newTestConfig(t)
+	server := httptest.NewServer(api.NewServer(nil, cfg, "test", "test").Router())
+	defer server.Close()
+
+	token := sessionToken(t, cfg, testAdmin)
+	cookie := &http.Cookie{Name: auth.CookieName, Value: token}
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/api/auth/ws-token?renewable=true", nil)
+	req.AddCookie(cookie)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wsToken struct {
+		Token string `json:"token"`
+	}
+	json.NewDecoder(resp.Body).Decode(&wsToken)
+	resp.Body.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") +
+		"/api/files/tail?path=/app.log&lines=3&ws_token=" + url.QueryEscape(wsToken.Token)
+	ws, _, err := websocket.DefaultDialer.Dial(wsURL, http.Header{"Cookie": {cookie.String()}})
+	if err != nil {
+		t.Fatalf("Dial() failed: %v", err)
+	}
+	defer ws.Close()
+
+	read := func() api.TailMessage {
+		t.Helper()
+		ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+		var msg api.TailMessage
+		if err := ws.ReadJSON(&msg); err != nil {
+			t.Fatalf("ReadJSON() failed: %v", err)
+		}
+		return msg
+	}
+
+	if msg := read(); msg.Type != "ws_token" {
+		t.Fatalf("first message = %q; want ws_token", msg.Type)
+	}
+	if msg := read(); msg.Type != "data" || msg.Data != "line 8\nline 9\nline 0\n" {
+		t.Fatalf("initial lines = %+v", msg)
+	}
+
+	// Appended data is streamed
+	f, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("appended\n")
+	f.Close()
+	if msg := read(); msg.Type != "data" || msg.Data != "appended\n" {
+		t.Fatalf("appended = %+v", msg)
+	}
+
+	// Rotation replaces the file; the new one is read from the start
+	if err := os.Rename(logPath, logPath+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(logPath, []byte("fresh\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if msg := read(); msg.Type != "rotated" {
+		t.Fatalf("after rotation = %+v; want rotated", msg)
+	}
+	if msg := read(); msg.Type != "data" || msg.Data != "fresh\n" {
+		t.Fatalf("after rotation = %+v", msg)
+	}
+}
@@ -2,10 +2,25 @@ package tests
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"podmanview/internal/api"
+	"podmanview/internal/config"
+	"podmanview/internal/events"
+	"podmanview/internal/mqtt"
+	"podmanview/internal/plugins"
+	"podmanview/internal/plugins/demo"
 	"podmanview/internal/plugins/temperature"
+	"podmanview/internal/storage"
 )
 
 func TestGetFriendlyName(t *testing.T) {
@@ -193,3 +208,296 @@ func TestTemperaturePluginLifecycle(t *testing.T) {
 		t.Error("GetTemperatureData() should still return cached data after Stop()")
 	}
 }
+
+func TestTemperaturePublishMode(t *testing.T) {
+	var mu sync.Mutex
+	published := make(map[string]bool)
+	var refuse atomic.Bool
+	broker, _ := fakeBroker(t, &refuse, func(topic string, _ []byte, _ bool) {
+		mu.Lock()
+		published[topic] = true
+		mu.Unlock()
+	})
+
+	store, err := storage.NewBoltStorage(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create test storage: %v", err)
+	}
+	defer store.Close()
+	if err := store.SetBool("temperature", "mqttEnabled", true); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := mqtt.New(mqtt.Config{Broker: broker, ClientID: "test", Prefix: "podmanview"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Disconnect()
+	deps := &plugins.PluginDependencies{
+		Storage: store,
+	}
+	deps.SetMQTT(client, mqtt.NewPublisher(client, nil), mqtt.NewDiscoveryManager(client, nil, store, "global"))
+
+	ctx := context.Background()
+	plugin := temperature.New()
+	if err := plugin.Init(ctx, deps); err != nil {
+		t.Fatalf("Init() failed: %v", err)
+	}
+	settings := func(p *temperature.TemperaturePlugin, method, body string) (int, temperature.PluginSettings) {
+		for _, route := range p.Routes() {
+			if route.Method == method && route.Path == "/api/plugins/temperature/settings" {
+				rec := httptest.NewRecorder()
+				route.Handler(rec, httptest.NewRequest(method, route.Path, strings.NewReader(body)))
+				var s temperature.PluginSettings
+				json.Unmarshal(rec.Body.Bytes(), &s)
+				return rec.Code, s
+			}
+		}
+		t.Fatalf("no %s settings route", method)
+		return 0, temperature.PluginSettings{}
+	}
+
+	if _, s := settings(plugin, http.MethodGet, ""); s.PublishMode != temperature.PublishBoth {
+		t.Errorf("default publish mode = %q; want both", s.PublishMode)
+	}
+	if code, _ := settings(plugin, http.MethodPost, `{"updateInterval": 15, "publishMode": "all"}`); code != http.StatusBadRequest {
+		t.Errorf("unknown publish mode = %d; want 400", code)
+	}
+
+	for _, mode := range []string{temperature.PublishIndividual, temperature.PublishBoth, temperature.PublishAggregated} {
+		if code, _ := settings(plugin, http.MethodPost, `{"updateInterval": 15, "publishMode": "`+mode+`"}`); code != http.StatusOK {
+			t.Fatalf("set publish mode %s = %d", mode, code)
+		}
+		mu.Lock()
+		clear(published)
+		mu.Unlock()
+		if err := plugin.Start(ctx); err != nil {
+			t.Fatalf("Start() failed: %v", err)
+		}
+		// Messages arrive in order, so the acknowledged marker comes last
+		if err := client.PublishRaw("marker", []byte("x"), false); err != nil {
+			t.Fatal(err)
+		}
+		mu.Lock()
+		aggregated := published["podmanview/sensor/temperature/state"]
+		mu.Unlock()
+		if want := mode != temperature.PublishIndividual; aggregated != want {
+			t.Errorf("mode %s: aggregated published = %v; want %v", mode, aggregated, want)
+		}
+	}
+
+	// The mode is kept in storage
+	reloaded := temperature.New()
+	if err := reloaded.Init(ctx, deps); err != nil {
+		t.Fatalf("Init() failed: %v", err)
+	}
+	if _, s := settings(reloaded, http.MethodGet, ""); s.PublishMode != temperature.PublishAggregated {
+		t.Errorf("reloaded publish mode = %q; want aggregated", s.PublishMode)
+	}
+}
+
+func TestTemperatureRediscover(t *testing.T) {
+	var refuse atomic.Bool
+	broker, _ := fakeBroker(t, &refuse, nil)
+
+	store, err := storage.NewBoltStorage(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create test storage: %v", err)
+	}
+	defer store.Close()
+	if err := store.SetPluginConfig("temperature", &storage.PluginConfig{Enabled: true, Name: "Temperature"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetBool("temperature", "mqttEnabled", true); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := mqtt.New(mqtt.Config{Broker: broker, ClientID: "test", Prefix: "podmanview"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Disconnect()
+	eventStore := events.NewStore(100)
+	deps := &plugins.PluginDependencies{
+		Storage:    store,
+		EventStore: eventStore,
+	}
+	deps.SetMQTT(client, mqtt.NewPublisher(client, nil), mqtt.NewDiscoveryManager(client, nil, store, "global"))
+
+	ctx := context.Background()
+	plugin := temperature.New()
+	if err := plugin.Init(ctx, deps); err != nil {
+		t.Fatalf("Init() failed: %v", err)
+	}
+	if err := plugin.Start(ctx); err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+	published := func() bool {
+		ok, _ := store.GetBool("global", "discoveryPublished")
+		return ok
+	}
+	if !published() {
+		t.Fatal("discovery not published on start")
+	}
+
+	cfg, err := config.Load(filepath.Join(t.TempDir(), ".env"))
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if err := cfg.SetNoAuth(true); err != nil {
+		t.Fatal(err)
+	}
+	registry := plugins.NewRegistry()
+	registry.SetDependencies(deps)
+	server := api.NewServerWithPlugins(nil, cfg, "test", "test", []plugins.Plugin{plugin, demo.New()}, registry, store)
+	rediscover := func(name string) int {
+		rec := httptest.NewRecorder()
+		server.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/plugins/"+name+"/mqtt/rediscover", nil))
+		return rec.Code
+	}
+
+	if code := rediscover("temperature"); code != http.StatusOK {
+		t.Fatalf("rediscover = %d; want 200", code)
+	}
+	if published() {
+		t.Error("discovery still marked published after rediscover")
+	}
+	if list := eventStore.Filter(events.Filter{Type: events.EventMQTTRediscover}); len(list) != 1 || !list[0].Success {
+		t.Errorf("rediscover events = %+v", list)
+	}
+
+	// The next update republishes
+	if err := plugin.Start(ctx); err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+	if !published() {
+		t.Error("discovery not republished after rediscover")
+	}
+
+	if code := rediscover("missing"); code != http.StatusNotFound {
+		t.Errorf("rediscover unknown plugin = %d; want 404", code)
+	}
+	if code := rediscover("demo"); code != http.StatusBadRequest {
+		t.Errorf("rediscover demo = %d; want 400", code)
+	}
+	if err := store.SetBool("temperature", "mqttEnabled", false); err != nil {
+		t.Fatal(err)
+	}
+	disabled := temperature.New()
+	if err := disabled.Init(ctx, deps); err != nil {
+		t.Fatalf("Init() failed: %v", err)
+	}
+	if err := disabled.RediscoverMQTT(); !errors.Is(err, plugins.ErrNoDiscovery) {
+		t.Errorf("RediscoverMQTT() with MQTT off = %v; want ErrNoDiscovery", err)
+	}
+}
+
+func TestTemperatureUnits(t *testing.T) {
+	tests := []struct {
+		celsius   float64
+		unit      string
+		precision int
+		want      float64
+	}{
+		{53.0000001, temperature.UnitCelsius, 1, 53},
+		{47.25, temperature.UnitCelsius, 1, 47.3},
+		{47.25, temperature.UnitCelsius, 0, 47},
+		{100, temperature.UnitFahrenheit, 1, 212},
+		{36.6, temperature.UnitFahrenheit, 2, 97.88},
+	}
+	for _, tt := range tests {
+		if got := temperature.ConvertTemp(tt.celsius, tt.unit, tt.precision); got != tt.want {
+			t.Errorf("ConvertTemp(%v, %s, %d) = %v; want %v", tt.celsius, tt.unit, tt.precision, got, tt.want)
+		}
+	}
+
+	var mu sync.Mutex
+	var aggregated []byte
+	var refuse atomic.Bool
+	broker, _ := fakeBroker(t, &refuse, func(topic string, payload []byte, _ bool) {
+		if topic == "podmanview/sensor/temperature/state" {
+			mu.Lock()
+			aggregated = payload
+			mu.Unlock()
+		}
+	})
+
+	store, err := storage.NewBoltStorage(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create test storage: %v", err)
+	}
+	defer store.Close()
+	if err := store.SetBool("temperature", "mqttEnabled", true); err != nil {
+		t.Fatal(err)
+	}
+	client, err := mqtt.New(mqtt.Config{Broker: broker, ClientID: "test", Prefix: "podmanview"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Disconnect()
+	deps := &plugins.PluginDependencies{
+		Storage: store,
+	}
+	deps.SetMQTT(client, mqtt.NewPublisher(client, nil), mqtt.NewDiscoveryManager(client, nil, store, "global"))
+
+	ctx := context.Background()
+	plugin := temperature.New()
+	if err := plugin.Init(ctx, deps); err != nil {
+		t.Fatalf("Init() failed: %v", err)
+	}
+	settings := func(p *temperature.TemperaturePlugin, method, body string) (int, temperature.PluginSettings) {
+		for _, route := range p.Routes() {
+			if route.Method == method && route.Path == "/api/plugins/temperature/settings" {
+				rec := httptest.NewRecorder()
+				route.Handler(rec, httptest.NewRequest(method, route.Path, strings.NewReader(body)))
+				var s temperature.PluginSettings
+				json.Unmarshal(rec.Body.Bytes(), &s)
+				return rec.Code, s
+			}
+		}
+		t.Fatalf("no %s settings route", method)
+		return 0, temperature.PluginSettings{}
+	}
+
+	if _, s := settings(plugin, http.MethodGet, ""); s.Unit != temperature.UnitCelsius || s.Precision == nil || *s.Precision != temperature.DefaultPrecision {
+		t.Errorf("default settings = %+v; want C with 1 decimal", s)
+	}
+	for _, body := range []string{
+		`{"updateInterval": 15, "unit": "K"}`,
+		`{"updateInterval": 15, "precision": 4}`,
+		`{"updateInterval": 15, "precision": -1}`,
+	} {
+		if code, _ := settings(plugin, http.MethodPost, body); code != http.StatusBadRequest {
+			t.Errorf("settings %s = %d; want 400", body, code)
+		}
+	}
+	if code, _ := settings(plugin, http.MethodPost, `{"updateInterval": 15, "unit": "F", "precision": 0}`); code != http.StatusOK {
+		t.Fatalf("set unit = %d", code)
+	}
+	if plugin.Unit() != temperature.UnitFahrenheit {
+		t.Errorf("Unit() = %q; want F", plugin.Unit())
+	}
+
+	if err := plugin.Start(ctx); err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+	if err := client.PublishRaw("marker", []byte("x"), false); err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	var data temperature.TemperatureData
+	err = json.Unmarshal(aggregated, &data)
+	mu.Unlock()
+	if err != nil || data.Unit != "°F" {
+		t.Errorf("aggregated payload unit = %q (%v); want °F", data.Unit, err)
+	}
+
+	// Settings are kept in storage
+	reloaded := temperature.New()
+	if err := reloaded.Init(ctx, deps); err != nil {
+		t.Fatalf("Init() failed: %v", err)
+	}
+	if _, s := settings(reloaded, http.MethodGet, ""); s.Unit != temperature.UnitFahrenheit || s.Precision == nil || *s.Precision != 0 {
+		t.Errorf("reloaded settings = %+v; want F with 0 decimals", s)
+	}
+}
@@ -0,0 +1,83 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"podmanview/internal/api"
+	"podmanview/internal/storage"
+)
+
+func TestContainerTemplates(t *testing.T) {
+	cfg := newTestConfig(t)
+	store, err := storage.NewBoltStorage(filepath.Join(t.TempDir(), "podmanview.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	server := api.NewServerWithPlugins(nil, cfg, "test", "test", nil, nil, store)
+
+	token := sessionToken(t, cfg, testAdmin)
+	do := requester(server, token)
+	list := func() []api.ContainerTemplate {
+		rec := do(http.MethodGet, "/api/templates", "")
+		var resp api.TemplatesResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusOK {
+			t.Fatalf("list = %d %s", rec.Code, rec.Body.String())
+		}
+		return resp.Templates
+	}
+
+	if templates := list(); len(templates) != 0 {
+		t.Errorf("templates = %+v; want none", templates)
+	}
+
+	nginx := `{"name": "nginx", "config": {"image": "docker.io/library/nginx:latest",
+		"portmappings": [{"host_port": 8080, "container_port": 80, "protocol": "tcp"}],
+		"mounts": [{"Type": "bind", "Source": "/srv/www", "Destination": "/usr/share/nginx/html"}],
+		"env": {"TZ": "UTC"}}}`
+	if rec := do(http.MethodPost, "/api/templates", nginx); rec.Code != http.StatusCreated {
+		t.Fatalf("save = %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodPost, "/api/templates", `{"name": "Alpine", "config": {"image": "alpine"}}`); rec.Code != http.StatusCreated {
+		t.Fatalf("save = %d %s", rec.Code, rec.Body.String())
+	}
+	// Saving under an existing name replaces the template
+	if rec := do(http.MethodPost, "/api/templates", strings.Replace(nginx, "8080", "8081", 1)); rec.Code != http.StatusOK {
+		t.Errorf("replace = %d %s", rec.Code, rec.Body.String())
+	}
+
+	templates := list()
+	if len(templates) != 2 || templates[0].Name != "Alpine" || templates[1].Name != "nginx" {
+		t.Fatalf("templates = %+v; want Alpine and nginx", templates)
+	}
+	config := templates[1].Config
+	if len(config.PortMappings) != 1 || config.PortMappings[0].HostPort != 8081 || config.Mounts[0].Source != "/srv/www" ||
+		config.Env["TZ"] != "UTC" || templates[1].CreatedBy != "admin" {
+		t.Errorf("nginx = %+v", templates[1])
+	}
+
+	for _, body := range []string{
+		`{"config": {"image": "alpine"}}`,
+		`{"name": "no image", "config": {}}`,
+		`{"name": "a/b", "config": {"image": "alpine"}}`,
+		`{"name": "` + strings.Repeat("x", 65) + `", "config": {"image": "alpine"}}`,
+	} {
+		if rec := do(http.MethodPost, "/api/templates", body); rec.Code != http.StatusBadRequest {
+			t.Errorf("save %s = %d; want 400", body, rec.Code)
+		}
+	}
+
+	if rec := do(http.MethodDelete, "/api/templates/nginx", ""); rec.Code != http.StatusOK {
+		t.Errorf("delete = %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodDelete, "/api/templates/nginx", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("delete again = %d; want 404", rec.Code)
+	}
+	if templates := list(); len(templates) != 1 {
+		t.Errorf("templates = %+v; want only Alpine", templates)
+	}
+}
@@ -0,0 +1,234 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"podmanview/internal/termfilter"
+)
+
+func TestTerminalFilterCheck(t *testing.T) {
+	allow := termfilter.New([]string{"podman", "systemctl status", "ls"}, []string{"podman system reset"})
+	deny := termfilter.New(nil, []string{"rm -rf", "reboot"})
+
+	tests := []struct {
+		name    string
+		filter  *termfilter.Filter
+		line    string
+		allowed bool
+	}{
+		{"allow exact", allow, "podman ps -a", true},
+		{"allow multi-word prefix", allow, "systemctl status podmanview", true},
+		{"allow wrong subcommand", allow, "systemctl restart podmanview", false},
+		{"allow whole words only", allow, "podmanview", false},
+		{"allow empty line", allow, "   ", true},
+		{"allow pipeline", allow, "podman ps | ls", true},
+		{"allow chained command", allow, "podman ps; rm -rf /", false},
+		{"allow and-list", allow, "podman ps && reboot", false},
+		{"allow background", allow, "podman ps & reboot", false},
+		{"allow subshell", allow, "(reboot)", false},
+		{"allow substitution", allow, "podman rm $(whoami)", false},
+		{"allow backticks", allow, "podman rm `whoami`", false},
+		{"allow quoted operator", allow, "podman run alpine echo 'a; reboot'", true},
+		{"allow deny wins", allow, "podman system reset --force", false},
+		{"allow sudo", allow, "sudo podman ps", true},
+		{"allow env assignment", allow, "FOO=1 reboot", false},
+		{"allow env assignment for command", allow, "FOO=1 podman ps", true},
+		{"allow assignment alone", allow, "FOO=1", false},
+		{"allow prompt command", allow, "PROMPT_COMMAND=reboot", false},
+		{"allow prompt command with IFS", allow, "PROMPT_COMMAND='rm${IFS}-rf${IFS}/tmp/x'", false},
+		{"allow prompt command append", allow, "PROMPT_COMMAND+=reboot", false},
+		{"allow prompt substitution", allow, "PS1='$(reboot)'", false},
+		{"allow PS0", allow, "PS0='$(reboot)' podman ps", false},
+		{"allow PS2", allow, "PS2='$(reboot)' podman ps", false},
+		{"allow PS3", allow, "PS3='$(reboot)' podman ps", false},
+		{"allow PS4", allow, "PS4='$(reboot)' podman ps", false},
+		{"allow BASH_ENV", allow, "BASH_ENV=/tmp/x podman ps", false},
+		{"allow ENV", allow, "ENV=/tmp/x podman ps", false},
+		{"allow IFS", allow, "IFS=/ podman ps", false},
+		{"allow SHELLOPTS", allow, "SHELLOPTS=xtrace podman ps", false},
+		{"allow PATH", allow, "PATH=/tmp podman ps", false},
+		{"deny prefix", deny, "rm -rf /tmp/x", false},
+		{"deny other flags", deny, "rm -r /tmp/x", true},
+		{"deny sudo", deny, "sudo -E reboot", false},
+		{"deny path", deny, "/sbin/reboot now", false},
+		{"deny quoted", deny, "'reboot'", false},
+		{"deny partly quoted", deny, `"re"boot`, false},
+		{"deny escaped", deny, `\reboot`, false},
+		{"deny variable", deny, "X=reboot; $X", false},
+		{"deny braced variable", deny, "X=reboot; ${X} now", false},
+		{"deny sudo variable", deny, "sudo $X", false},
+		{"deny eval", deny, "eval reboot", false},
+		{"deny source", deny, ". ./script", false},
+		{"deny sh -c", deny, "sh -c reboot", false},
+		{"deny bash -c", deny, "/bin/bash -c 'reboot'", false},
+		{"deny combined flags", deny, "sudo bash -ec reboot", false},
+		{"deny shell script", deny, "bash script.sh -c", true},
+		{"deny quoted argument", deny, "podman run alpine echo '$HOME'", true},
+		{"deny variable argument", deny, "ls $HOME", true},
+		{"deny chained", deny, "echo hi;reboot", false},
+		{"deny other", deny, "podman ps", true},
+		{"deny unterminated quote", deny, `echo "`, false},
+		{"deny line continuation", deny, "re\\\nboot", false},
+		{"deny ansi-c quote", deny, `echo $'a\'' ; reboot ; echo "'"`, false},
+		{"deny pipe to sh", deny, "echo reboot | sh", false},
+		{"deny bash stdin", deny, "bash < script.sh", false},
+		{"deny bash -s", deny, "curl example.com | bash -s", false},
+		{"deny here-string", deny, "bash <<< reboot", false},
+		{"deny alias", deny, "alias x=reboot", false},
+		{"deny function", deny, "x() { ls; }", false},
+		{"deny function keyword", deny, "function x { ls; }", false},
+		{"deny xargs", deny, "echo now | xargs reboot", false},
+		{"deny xargs options", deny, "echo now | xargs -I {} reboot {}", false},
+		{"deny xargs wrapper", deny, "echo reboot | xargs env", false},
+		{"deny xargs alone", deny, "echo hi | xargs", true},
+		{"deny timeout", deny, "timeout -s KILL 5 reboot", false},
+		{"deny setsid", deny, "setsid reboot", false},
+		{"deny su -c", deny, "su -c reboot", false},
+		{"deny sudo user", deny, "sudo -u root reboot", false},
+		{"deny sudo shell", deny, "echo reboot | sudo -s", false},
+		{"deny env split", deny, "env -S reboot", false},
+		{"deny redirection first", deny, ">/dev/null reboot", false},
+		{"deny redirection", deny, "ls 2>&1 >/dev/null", true},
+		{"deny rm glob class", termfilter.New(nil, []string{"rm"}), "/bin/r[m] -rf /x", false},
+		{"deny rm glob", termfilter.New(nil, []string{"rm"}), "r? -rf /x", false},
+		{"deny rm star", termfilter.New(nil, []string{"rm"}), "/bin/r* -rf /x", false},
+		{"deny rm brace expansion", termfilter.New(nil, []string{"rm"}), "{r,}m -rf /x", false},
+		{"deny tilde", deny, "~/bin/reboot", false},
+		{"deny group braces", deny, "{ reboot; }", false},
+		{"deny test bracket", deny, "[ -f /x ] && ls", true},
+		{"deny brace argument", deny, "echo {a,b}", true},
+		{"deny trap", termfilter.New(nil, []string{"rm"}), "trap 'rm -rf /x' DEBUG", false},
+		{"deny bind", termfilter.New(nil, []string{"rm"}), `bind -x '"\C-t": rm -rf /x'`, false},
+		{"deny complete", deny, "complete -C reboot ls", false},
+		{"deny enable", deny, "enable -f ./x.so x", false},
+		{"deny declare", deny, "declare PROMPT_COMMAND=reboot", false},
+		{"deny typeset", deny, "typeset PROMPT_COMMAND=reboot", false},
+		{"deny export", deny, "export PROMPT_COMMAND=reboot", false},
+		{"deny readonly", deny, "readonly PROMPT_COMMAND=reboot", false},
+		{"deny local", deny, "local PROMPT_COMMAND=reboot", false},
+		{"no filter", nil, "rm -rf /", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.filter.Check(tt.line)
+			if (err == nil) != tt.allowed {
+				t.Errorf("Check(%q) = %v; want allowed=%v", tt.line, err, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestTerminalFilterNew(t *testing.T) {
+	if f := termfilter.New(nil, []string{" ", ""}); f.Active() {
+		t.Error("New() with empty prefixes is active; want nil filter")
+	}
+}
+
+func TestTerminalGuard(t *testing.T) {
+	filter := termfilter.New([]string{"podman", "ls"}, nil)
+
+	tests := []struct {
+		name    string
+		input   []string // keystroke chunks
+		out     string
+		blocked []string
+	}{
+		{"allowed", []string{"podman ps\r"}, "podman ps\r", nil},
+		{"blocked", []string{"reboot\r"}, "reboot\x03", []string{"reboot"}},
+		{"split across messages", []string{"pod", "man ps", "\r"}, "podman ps\r", nil},
+		{"backspace", []string{"lsx\x7f\r"}, "lsx\x7f\r", nil},
+		{"backspace into blocked", []string{"ls\x7f\x7fid\r"}, "ls\x7f\x7fid\x03", []string{"id"}},
+		{"cursor edit", []string{"s\x1b[Dl\r"}, "s\x1b[Dl\r", nil},
+		{"home and end", []string{"s\x01l\x05 -a\r"}, "s\x01l\x05 -a\r", nil},
+		{"ctrl-u clears", []string{"reboot\x15ls\r"}, "reboot\x15ls\r", nil},
+		{"ctrl-w deletes word", []string{"ls rm\x17\x17podman\r"}, "ls rm\x17\x17podman\r", nil},
+		{"ctrl-c resets", []string{"reboot\x03ls\r"}, "reboot\x03ls\r", nil},
+		{"history recall", []string{"\x1b[A\r"}, "\x1b[A\x03", []string{""}},
+		{"tab completion", []string{"pod\t ps\r"}, "pod\t ps\x03", []string{"pod ps"}},
+		{"multiple lines", []string{"ls\rreboot\rls\r"}, "ls\rreboot\x03ls\r", []string{"reboot"}},
+		{"bracketed paste", []string{"\x1b[200~ls\rreboot\x1b[201~\r"}, "\x1b[200~ls\rreboot\x1b[201~\x03", []string{"ls\nreboot"}},
+		{"utf-8", []string{"ls ", "\xc3", "\xa9\r"}, "ls \xc3\xa9\r", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			guard := filter.NewGuard()
+			var out []byte
+			var blocked []string
+			for _, chunk := range tt.input {
+				data, b := guard.Input([]byte(chunk))
+				out = append(out, data...)
+				for _, line := range b {
+					blocked = append(blocked, line.Line)
+				}
+			}
+
+			if string(out) != tt.out {
+				t.Errorf("output = %q; want %q", out, tt.out)
+			}
+			if len(blocked) != len(tt.blocked) {
+				t.Fatalf("blocked = %q; want %q", blocked, tt.blocked)
+			}
+			for i := range blocked {
+				if blocked[i] != tt.blocked[i] {
+					t.Errorf("blocked[%d] = %q; want %q", i, blocked[i], tt.blocked[i])
+				}
+			}
+		})
+	}
+}
+
+func TestTerminalGuardMultiLine(t *testing.T) {
+	filter := termfilter.New(nil, []string{"rm -rf"})
+
+	tests := []struct {
+		name    string
+		input   []string // keystroke chunks
+		out     string
+		blocked []string
+	}{
+		{"open quote", []string{"echo \"\r", "x \"; rm -rf /\r"}, "echo \"\x03x \"; rm -rf /\x03", []string{"echo \"", "x \"; rm -rf /"}},
+		{"continuation", []string{"r\\\r", "m -rf /\r"}, "r\\\rm -rf /\x03", []string{"r\\\nm -rf /"}},
+		{"continuation allowed", []string{"ls \\\r", "-la\r"}, "ls \\\r-la\r", nil},
+		{"ctrl-c drops continuation", []string{"r\\\r", "\x03", "m -rf /\r"}, "r\\\r\x03m -rf /\r", nil},
+		{"pipe to sh", []string{"echo rm -rf / | sh\r"}, "echo rm -rf / | sh\x03", []string{"echo rm -rf / | sh"}},
+		{"alias", []string{"alias x=rm\r", "x -rf /\r"}, "alias x=rm\x03x -rf /\r", []string{"alias x=rm"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			guard := filter.NewGuard()
+			var out []byte
+			var blocked []string
+			for _, chunk := range tt.input {
+				data, b := guard.Input([]byte(chunk))
+				out = append(out, data...)
+				for _, line := range b {
+					blocked = append(blocked, line.Line)
+				}
+			}
+
+			if string(out) != tt.out {
+				t.Errorf("output = %q; want %q", out, tt.out)
+			}
+			if len(blocked) != len(tt.blocked) {
+				t.Fatalf("blocked = %q; want %q", blocked, tt.blocked)
+			}
+			for i := range blocked {
+				if blocked[i] != tt.blocked[i] {
+					t.Errorf("blocked[%d] = %q; want %q", i, blocked[i], tt.blocked[i])
+				}
+			}
+		})
+	}
+}
+
+func TestTerminalGuardUntracked(t *testing.T) {
+	guard := termfilter.New(nil, []string{"reboot"}).NewGuard()
+	_, blocked := guard.Input([]byte("\x1b[A\r"))
+	if len(blocked) != 1 || !errors.Is(blocked[0].Err, termfilter.ErrUntracked) {
+		t.Errorf("blocked = %+v; want ErrUntracked", blocked)
+	}
+}
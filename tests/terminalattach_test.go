@@ -0,0 +1,161 @@
+package tests
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"podmanview/internal/api"
+	"podmanview/internal/auth"
+)
+
+// fakeAttachPodman serves a container with a TTY and open stdin ("tty"),
+// which echoes its input, one with neither ("plain"), which prints a
+// line on stdout, and a stopped one ("stopped")
+type fakeAttachPodman struct {
+	podmanMux
+	mu      sync.Mutex
+	queries map[string]string // container -> query of its last attach
+}
+
+func newFakeAttachPodman() *fakeAttachPodman {
+	f := &fakeAttachPodman{queries: map[string]string{}}
+	known := func(w http.ResponseWriter, id string) bool {
+		if id != "tty" && id != "plain" && id != "stopped" {
+			http.Error(w, `{"message": "no such container"}`, http.StatusNotFound)
+			return false
+		}
+		return true
+	}
+	f.HandleFunc("GET /containers/{id}/json", func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		if !known(w, id) {
+			return
+		}
+		fmt.Fprintf(w, `{"Id": %q, "State": {"Running": %t}, "Config": {"Tty": %t, "OpenStdin": %t}}`,
+			id, id != "stopped", id == "tty", id == "tty")
+	})
+	f.HandleFunc("POST /containers/{id}/attach", func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		if !known(w, id) {
+			return
+		}
+		f.mu.Lock()
+		f.queries[id] = r.URL.RawQuery
+		f.mu.Unlock()
+
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 101 UPGRADED\r\nConnection: Upgrade\r\nUpgrade: tcp\r\n\r\n")
+		if id == "tty" {
+			rw.Flush()
+			io.Copy(conn, rw)
+			return
+		}
+		frame := make([]byte, 8)
+		frame[0] = 1
+		binary.BigEndian.PutUint32(frame[4:], 6)
+		rw.Write(append(frame, "hello\n"...))
+		rw.Flush()
+		io.Copy(io.Discard, rw)
+	})
+	return f
+}
+
+func TestTerminalAttach(t *testing.T) {
+	fake := newFakeAttachPodman()
+	apiServer, token, _ := newTestAPI(t, servePodman(t, fake), newTestConfig(t), testAdmin)
+	server := httptest.NewServer(apiServer.Router())
+	defer server.Close()
+	cookie := &http.Cookie{Name: auth.CookieName, Value: token}
+
+	dial := func(container, query string) (*websocket.Conn, int) {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/api/auth/ws-token", nil)
+		req.AddCookie(cookie)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var wsToken struct {
+			Token string `json:"token"`
+		}
+		json.NewDecoder(resp.Body).Decode(&wsToken)
+		resp.Body.Close()
+
+		wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/containers/" + container + "/terminal?ws_token=" + url.QueryEscape(wsToken.Token) + query
+		ws, wsResp, err := websocket.DefaultDialer.Dial(wsURL, http.Header{"Cookie": {cookie.String()}})
+		if err != nil {
+			if wsResp == nil {
+				t.Fatalf("Dial() failed: %v", err)
+			}
+			return nil, wsResp.StatusCode
+		}
+		return ws, http.StatusSwitchingProtocols
+	}
+	// readUntil reads terminal output until it contains want
+	readUntil := func(ws *websocket.Conn, want string) string {
+		var out strings.Builder
+		ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+		for !strings.Contains(out.String(), want) {
+			_, data, err := ws.ReadMessage()
+			if err != nil {
+				t.Errorf("read %q: %v (got %q)", want, err, out.String())
+				break
+			}
+			out.Write(data)
+		}
+		return out.String()
+	}
+
+	// With a TTY and stdin, input reaches the main process
+	ws, code := dial("tty", "&mode=attach")
+	if ws == nil {
+		t.Fatalf("attach tty = %d", code)
+	}
+	ws.WriteJSON(api.ExecMessage{Type: "stdin", Data: "ping\r"})
+	readUntil(ws, "ping\r")
+	ws.Close()
+
+	// Without them the output is demultiplexed, with CRLF line endings,
+	// and stdin is not requested
+	ws, code = dial("plain", "&mode=attach")
+	if ws == nil {
+		t.Fatalf("attach plain = %d", code)
+	}
+	if out := readUntil(ws, "hello"); !strings.Contains(out, "output only") || !strings.Contains(out, "hello\r\n") {
+		t.Errorf("attach plain output = %q", out)
+	}
+	ws.Close()
+
+	fake.mu.Lock()
+	if !strings.Contains(fake.queries["tty"], "stdin=true") || !strings.Contains(fake.queries["plain"], "stdin=false") {
+		t.Errorf("attach queries = %v", fake.queries)
+	}
+	fake.mu.Unlock()
+
+	for _, tt := range []struct {
+		container, query string
+		want             int
+	}{
+		{"stopped", "&mode=attach", http.StatusConflict},
+		{"missing", "&mode=attach", http.StatusNotFound},
+		{"tty", "&mode=bogus", http.StatusBadRequest},
+	} {
+		if _, code := dial(tt.container, tt.query); code != tt.want {
+			t.Errorf("dial %s%s = %d; want %d", tt.container, tt.query, code, tt.want)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"podmanview/internal/auth"
+)
+
+func TestFileThumbnail(t *testing.T) {
+	home := t.TempDir()
+	cacheDir := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CACHE_HOME", cacheDir)
+
+	// Opaque photo and a transparent icon
+	photo := image.NewRGBA(image.Rect(0, 0, 800, 400))
+	for y := 0; y < 400; y++ {
+		for x := 0; x < 800; x++ {
+			photo.Set(x, y, color.RGBA{uint8(x), uint8(y), 128, 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, photo, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, "photo.jpg"), buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	if err := png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 100, 300))); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, "icon.png"), buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, "notes.txt"), []byte("not an image"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	server, token, _ := newTestAPI(t, nil, newTestConfig(t), testAdmin)
+
+	get := func(query, etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/files/thumbnail?"+query, nil)
+		req.AddCookie(&http.Cookie{Name: auth.CookieName, Value: token})
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		rec := httptest.NewRecorder()
+		server.Router().ServeHTTP(rec, req)
+		return rec
+	}
+
+	tests := []struct {
+		query       string
+		contentType string
+		width       int
+		height      int
+	}{
+		{"path=/photo.jpg", "image/jpeg", 256, 128},
+		{"path=/photo.jpg&size=64", "image/jpeg", 64, 32},
+		{"path=/icon.png&size=150", "image/png", 50, 150},
+	}
+	for _, tt := range tests {
+		rec := get(tt.query, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s = %d (%s)", tt.query, rec.Code, rec.Body.String())
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != tt.contentType {
+			t.Errorf("%s Content-Type = %q; want %q", tt.query, ct, tt.contentType)
+		}
+		img, _, err := image.DecodeConfig(rec.Body)
+		if err != nil {
+			t.Fatalf("%s: decoding thumbnail: %v", tt.query, err)
+		}
+		if img.Width != tt.width || img.Height != tt.height {
+			t.Errorf("%s = %dx%d; want %dx%d", tt.query, img.Width, img.Height, tt.width, tt.height)
+		}
+	}
+
+	// Rendered thumbnails are cached on disk
+	entries, err := os.ReadDir(filepath.Join(cacheDir, "podmanview", "thumbnails"))
+	if err != nil || len(entries) != len(tests) {
+		t.Errorf("cached thumbnails = %d (%v); want %d", len(entries), err, len(tests))
+	}
+
+	// Unchanged files are revalidated by ETag
+	etag := get("path=/photo.jpg", "").Header().Get("ETag")
+	if rec := get("path=/photo.jpg", etag); rec.Code != http.StatusNotModified {
+		t.Errorf("If-None-Match = %d; want %d", rec.Code, http.StatusNotModified)
+	}
+
+	errorCases := []struct {
+		query string
+		want  int
+	}{
+		{"path=/notes.txt", http.StatusUnsupportedMediaType},
+		{"path=/missing.jpg", http.StatusNotFound},
+		{"path=/photo.jpg&size=5000", http.StatusBadRequest},
+		{"path=/", http.StatusBadRequest},
+	}
+	for _, tt := range errorCases {
+		if rec := get(tt.query, ""); rec.Code != tt.want {
+			t.Errorf("%s = %d; want %d", tt.query, rec.Code, tt.want)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"podmanview/internal/tlscert"
+)
+
+func TestEnsureSelfSigned(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "podmanview.crt")
+	keyFile := filepath.Join(dir, "podmanview.key")
+
+	created, err := tlscert.EnsureSelfSigned(certFile, keyFile, []string{"rv2", "192.168.1.10", "fe80::1%eth0"})
+	if err != nil || !created {
+		t.Fatalf("EnsureSelfSigned() = %v, %v; want true, nil", created, err)
+	}
+
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("LoadX509KeyPair() failed: %v", err)
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		t.Fatalf("ParseCertificate() failed: %v", err)
+	}
+	if err := cert.VerifyHostname("rv2"); err != nil {
+		t.Errorf("certificate not valid for hostname: %v", err)
+	}
+	if err := cert.VerifyHostname("192.168.1.10"); err != nil {
+		t.Errorf("certificate not valid for IP: %v", err)
+	}
+	if err := cert.VerifyHostname("fe80::1"); err != nil {
+		t.Errorf("certificate not valid for link-local IP: %v", err)
+	}
+
+	// Trusting the certificate must not trust a CA
+	if cert.IsCA || cert.KeyUsage&x509.KeyUsageCertSign != 0 {
+		t.Errorf("certificate is a CA (IsCA %v, KeyUsage %b)", cert.IsCA, cert.KeyUsage)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	if _, err := cert.Verify(x509.VerifyOptions{DNSName: "rv2", Roots: roots}); err != nil {
+		t.Errorf("trusted certificate does not verify: %v", err)
+	}
+
+	info, err := os.Stat(keyFile)
+	if err != nil {
+		t.Fatalf("Stat(key) failed: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("key file mode = %v; want 0600", info.Mode().Perm())
+	}
+
+	// Existing files are kept
+	created, err = tlscert.EnsureSelfSigned(certFile, keyFile, []string{"other"})
+	if err != nil || created {
+		t.Errorf("EnsureSelfSigned() on existing files = %v, %v; want false, nil", created, err)
+	}
+}
+
+func TestEnsureSelfSignedReplacesCA(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "podmanview.crt")
+	keyFile := filepath.Join(dir, "podmanview.key")
+
+	// A certificate as older versions generated it
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{Organization: []string{"PodmanView"}, CommonName: "rv2"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644)
+	os.WriteFile(keyFile, []byte("old key"), 0600)
+
+	created, err := tlscert.EnsureSelfSigned(certFile, keyFile, []string{"rv2"})
+	if err != nil || !created {
+		t.Fatalf("EnsureSelfSigned() over a generated CA = %v, %v; want true, nil", created, err)
+	}
+	if _, err := tls.LoadX509KeyPair(certFile, keyFile); err != nil {
+		t.Errorf("replaced certificate does not load: %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"podmanview/internal/api"
+)
+
+func TestDanglingVolumes(t *testing.T) {
+	// "data" is mounted by container a; "cache" gets used before it can be removed
+	var removed []string
+	fake := &podmanMux{}
+	fake.HandleFunc("GET /volumes/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"Name": "data"}, {"Name": "cache"}, {"Name": "orphan"}]`))
+	})
+	fake.HandleFunc("GET /containers/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"Id": "a"}, {"Id": "gone"}]`))
+	})
+	fake.HandleFunc("GET /containers/{id}/json", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") != "a" {
+			http.Error(w, `{"message":"no such container"}`, http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"Id": "a", "Mounts": [{"Type": "volume", "Name": "data", "Destination": "/data"}, {"Type": "bind", "Source": "/cache"}]}`))
+	})
+	fake.HandleFunc("GET /system/df", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"Volumes": [{"VolumeName": "data", "Size": 10}, {"VolumeName": "orphan", "Size": 2048}]}`))
+	})
+	fake.HandleFunc("DELETE /volumes/{name}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("name") == "cache" {
+			http.Error(w, `{"message":"volume is being used"}`, http.StatusConflict)
+			return
+		}
+		removed = append(removed, r.PathValue("name"))
+		w.WriteHeader(http.StatusNoContent)
+	})
+	_, _, request := newTestAPI(t, servePodman(t, fake), newTestConfig(t), testAdmin)
+
+	rec := request(http.MethodGet, "/api/volumes/dangling", "")
+	var dangling api.DanglingVolumesResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &dangling); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("dangling = %d %s", rec.Code, rec.Body.String())
+	}
+	if len(dangling.Volumes) != 2 || dangling.Volumes[0].Name != "cache" || dangling.Volumes[0].Size != -1 ||
+		dangling.Volumes[1].Name != "orphan" || dangling.ReclaimableBytes != 2048 {
+		t.Errorf("dangling = %+v", dangling)
+	}
+
+	// Only dangling volumes are removed, even when named
+	rec = request(http.MethodPost, "/api/volumes/prune", `{"names": ["orphan", "data", "cache"]}`)
+	var pruned api.VolumePruneResponse
+	json.Unmarshal(rec.Body.Bytes(), &pruned)
+	if !reflect.DeepEqual(removed, []string{"orphan"}) {
+		t.Errorf("removed = %v; want only orphan", removed)
+	}
+	if !reflect.DeepEqual(pruned.Removed, []string{"orphan"}) || pruned.ReclaimedBytes != 2048 ||
+		!reflect.DeepEqual(pruned.Skipped, []string{"data"}) || len(pruned.Failed) != 1 || pruned.Failed[0].Name != "cache" {
+		t.Errorf("prune = %+v", pruned)
+	}
+}
@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"podmanview/internal/api"
+	"podmanview/internal/auth"
+)
+
+func TestFileWatch(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("directory watching uses inotify")
+	}
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := filepath.Join(home, "output")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := newTestConfig(t)
+	server := httptest.NewServer(api.NewServer(nil, cfg, "test", "test").Router())
+	defer server.Close()
+
+	token := sessionToken(t, cfg, testAdmin)
+	cookie := &http.Cookie{Name: auth.CookieName, Value: token}
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/api/auth/ws-token?renewable=true", nil)
+	req.AddCookie(cookie)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wsToken struct {
+		Token string `json:"token"`
+	}
+	json.NewDecoder(resp.Body).Decode(&wsToken)
+	resp.Body.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") +
+		"/api/files/watch?path=/output&ws_token=" + url.QueryEscape(wsToken.Token)
+	ws, _, err := websocket.DefaultDialer.Dial(wsURL, http.Header{"Cookie": {cookie.String()}})
+	if err != nil {
+		t.Fatalf("Dial() failed: %v", err)
+	}
+	defer ws.Close()
+
+	read := func() api.WatchMessage {
+		t.Helper()
+		ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+		var msg api.WatchMessage
+		if err := ws.ReadJSON(&msg); err != nil {
+			t.Fatalf("ReadJSON() failed: %v", err)
+		}
+		return msg
+	}
+
+	if msg := read(); msg.Type != "ws_token" {
+		t.Fatalf("first message = %q; want ws_token", msg.Type)
+	}
+
+	// A new file and its writes are reported as one create
+	f, err := os.Create(filepath.Join(dir, "result.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		f.WriteString("line\n")
+	}
+	f.Close()
+
+	msg := read()
+	if msg.Type != "changes" {
+		t.Fatalf("message = %+v; want changes", msg)
+	}
+	raw, _ := json.Marshal(msg.Changes)
+	if got := string(raw); got != `[{"op":"create","name":"result.txt"}]` {
+		t.Errorf("changes = %s", got)
+	}
+
+	// Removing the watched directory ends the stream
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		msg := read()
+		if msg.Type == "gone" {
+			break
+		}
+		if msg.Type != "changes" {
+			t.Fatalf("message = %+v; want gone", msg)
+		}
+	}
+}
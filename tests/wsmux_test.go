@@ -0,0 +1,131 @@
+package tests
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"podmanview/internal/api"
+	"podmanview/internal/auth"
+)
+
+func TestStreamMux(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	// Podman serving the logs of "web" and nothing else
+	fake := &podmanMux{}
+	fake.HandleFunc("GET /containers/{name}/logs", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("name") != "web" || r.URL.Query().Get("follow") != "true" {
+			http.Error(w, `{"message":"no such container"}`, http.StatusNotFound)
+			return
+		}
+		for _, line := range []string{"starting\n", "\x1b[32mready\x1b[0m\n"} {
+			header := make([]byte, 8)
+			header[0] = 1
+			binary.BigEndian.PutUint32(header[4:], uint32(len(line)))
+			w.Write(append(header, line...))
+		}
+	})
+	apiServer, token, _ := newTestAPI(t, servePodman(t, fake), newTestConfig(t), testAdmin)
+	server := httptest.NewServer(apiServer.Router())
+	defer server.Close()
+
+	cookie := &http.Cookie{Name: auth.CookieName, Value: token}
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/api/auth/ws-token?renewable=true", nil)
+	req.AddCookie(cookie)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wsToken struct {
+		Token string `json:"token"`
+	}
+	json.NewDecoder(resp.Body).Decode(&wsToken)
+	resp.Body.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws?ws_token=" + url.QueryEscape(wsToken.Token)
+	ws, _, err := websocket.DefaultDialer.Dial(wsURL, http.Header{"Cookie": {cookie.String()}})
+	if err != nil {
+		t.Fatalf("Dial() failed: %v", err)
+	}
+	defer ws.Close()
+
+	read := func() api.MuxMessage {
+		t.Helper()
+		ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+		var msg api.MuxMessage
+		if err := ws.ReadJSON(&msg); err != nil {
+			t.Fatalf("ReadJSON() failed: %v", err)
+		}
+		return msg
+	}
+	expect := func(typ, id string) api.MuxMessage {
+		t.Helper()
+		msg := read()
+		if msg.Type != typ || msg.ID != id {
+			raw, _ := json.Marshal(msg)
+			t.Fatalf("message = %s; want %s for %q", raw, typ, id)
+		}
+		return msg
+	}
+
+	if msg := read(); msg.Type != "ws_token" {
+		t.Fatalf("first message = %q; want ws_token", msg.Type)
+	}
+
+	// Events added after subscribing are delivered
+	ws.WriteJSON(api.MuxRequest{Type: "subscribe", ID: "ev", Channel: api.ChannelEvents})
+	expect("subscribed", "ev")
+
+	mkdir, _ := http.NewRequest(http.MethodPost, server.URL+"/api/files/mkdir", strings.NewReader(`{"path":"/","name":"new"}`))
+	mkdir.Header.Set("Content-Type", "application/json")
+	mkdir.AddCookie(cookie)
+	if resp, err := http.DefaultClient.Do(mkdir); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("mkdir failed: %v %v", err, resp)
+	}
+	if msg := expect("data", "ev"); !strings.Contains(mustJSON(t, msg.Data), `"username":"admin"`) {
+		t.Errorf("events data = %s", mustJSON(t, msg.Data))
+	}
+
+	ws.WriteJSON(api.MuxRequest{Type: "subscribe", ID: "ev", Channel: api.ChannelEvents})
+	if msg := expect("error", "ev"); msg.Error.Code != "subscription_exists" {
+		t.Errorf("duplicate subscription error = %+v", msg.Error)
+	}
+	ws.WriteJSON(api.MuxRequest{Type: "subscribe", ID: "x", Channel: "nope"})
+	if msg := expect("error", "x"); msg.Error.Code != "invalid_channel" {
+		t.Errorf("unknown channel error = %+v", msg.Error)
+	}
+	ws.WriteJSON(api.MuxRequest{Type: "unsubscribe", ID: "ev"})
+
+	// Logs are sent without ANSI codes, then the channel ends with the stream
+	ws.WriteJSON(api.MuxRequest{Type: "subscribe", ID: "logs", Channel: api.ChannelContainerLogs, Container: "web"})
+	expect("subscribed", "logs")
+	if got := mustJSON(t, expect("data", "logs").Data); got != `{"lines":["starting","ready"]}` {
+		t.Errorf("logs data = %s", got)
+	}
+	expect("end", "logs")
+
+	ws.WriteJSON(api.MuxRequest{Type: "subscribe", ID: "missing", Channel: api.ChannelContainerLogs, Container: "db"})
+	expect("subscribed", "missing")
+	if msg := expect("error", "missing"); msg.Error.Code != "container_not_found" {
+		t.Errorf("missing container error = %+v", msg.Error)
+	}
+	expect("end", "missing")
+}
+
+func mustJSON(t *testing.T, v interface{}) string {
+	t.Helper()
+	raw, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(raw)
+}
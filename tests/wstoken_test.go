@@ -0,0 +1,90 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"podmanview/internal/auth"
+)
+
+func TestWSTokenOneTimeUse(t *testing.T) {
+	store := auth.NewWSTokenStore()
+
+	token, err := store.Generate("alice")
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	username, ok := store.Validate(token)
+	if !ok || username != "alice" {
+		t.Fatalf("Expected valid token for alice, got %q, %v", username, ok)
+	}
+
+	// Second use must fail
+	if _, ok := store.Validate(token); ok {
+		t.Error("Expected token to be consumed after first use")
+	}
+}
+
+func TestWSTokenRenewable(t *testing.T) {
+	store := auth.NewWSTokenStore()
+
+	session := auth.Session{ID: "s1", ExpiresAt: time.Now().Add(time.Hour)}
+	token, err := store.GenerateRenewable("bob", session)
+	if err != nil {
+		t.Fatalf("Failed to generate renewable token: %v", err)
+	}
+
+	// Renewable tokens must not open terminals
+	if _, ok := store.Validate(token); ok {
+		t.Fatal("Expected renewable token to be rejected by Validate")
+	}
+
+	token, _ = store.GenerateRenewable("bob", session)
+	username, next, ok := store.ValidateAndRenew(token)
+	if !ok || username != "bob" {
+		t.Fatalf("Expected valid renewable token for bob, got %q, %v", username, ok)
+	}
+	if next == "" || next == token {
+		t.Fatalf("Expected a fresh token, got %q", next)
+	}
+
+	// Old token is consumed, fresh one works for the next reconnect
+	if _, _, ok := store.ValidateAndRenew(token); ok {
+		t.Error("Expected old token to be consumed")
+	}
+	if username, _, ok := store.ValidateAndRenew(next); !ok || username != "bob" {
+		t.Errorf("Expected renewed token to be valid for bob, got %q, %v", username, ok)
+	}
+}
+
+func TestWSTokenRenewableSession(t *testing.T) {
+	store := auth.NewWSTokenStore()
+
+	// One-time terminal tokens can't be upgraded to renewable ones
+	token, _ := store.Generate("alice")
+	if _, _, ok := store.ValidateAndRenew(token); ok {
+		t.Error("Expected one-time token to be rejected by ValidateAndRenew")
+	}
+
+	// Renewal stops once the login session has expired
+	token, _ = store.GenerateRenewable("alice", auth.Session{ID: "old", ExpiresAt: time.Now().Add(-time.Second)})
+	if _, _, ok := store.ValidateAndRenew(token); ok {
+		t.Error("Expected token of an expired session to be rejected")
+	}
+
+	// Logout revokes the session's tokens, including renewed ones
+	token, _ = store.GenerateRenewable("alice", auth.Session{ID: "s1", ExpiresAt: time.Now().Add(time.Hour)})
+	other, _ := store.GenerateRenewable("alice", auth.Session{ID: "s2", ExpiresAt: time.Now().Add(time.Hour)})
+	_, next, ok := store.ValidateAndRenew(token)
+	if !ok {
+		t.Fatal("Expected renewable token to be valid")
+	}
+	store.RevokeSession("s1")
+	if _, _, ok := store.ValidateAndRenew(next); ok {
+		t.Error("Expected renewed token to be revoked with its session")
+	}
+	if _, _, ok := store.ValidateAndRenew(other); !ok {
+		t.Error("Expected token of another session to stay valid")
+	}
+}
@@ -10,6 +10,7 @@ const App = {
     hostTerminalSocket: null,
     hostTerminalFitAddon: null,
     autoRefreshIntervals: {},
+    containerStatsInterval: null,
     autoRefreshDelay: 5000, // 5 seconds
     xtermLoaded: false,
     xtermLoading: false,
@@ -35,6 +36,13 @@ const App = {
     fileManagerLimit: 500,
     fileManagerTotalCount: 0,
     fileManagerHasMore: false,
+    fileManagerBookmarks: [], // the user's saved locations
+    fileWatchSocket: null, // live refresh of the shown directory
+    fileWatchPath: null,
+    fileWatchReload: null,
+    fileManagerSelected: new Set(), // paths checked for batch operations
+    fileManagerDiskFree: 0, // bytes free on the current directory's filesystem, 0 if unknown
+    fileManagerDiskTotal: 0,
 
     // File Manager lazy loading state
     fileManagerCoreLoaded: false,
@@ -270,6 +278,59 @@ const App = {
         return response;
     },
 
+    // Build an Error from a failed API response. The API answers with
+    // {"error": {"code", "message"}}; code is kept on the Error so callers
+    // can handle specific cases.
+    async apiError(response, fallback) {
+        let code = '';
+        let message = '';
+        const text = await response.text().catch(() => '');
+        try {
+            const data = JSON.parse(text);
+            if (data && data.error && typeof data.error === 'object') {
+                code = data.error.code || '';
+                message = data.error.message || '';
+            } else if (data) {
+                message = data.error || data.message || '';
+            }
+        } catch (e) {
+            message = text.trim();
+        }
+        const error = new Error(message || fallback);
+        error.code = code;
+        error.status = response.status;
+        return error;
+    },
+
+    // Read a response requested with ?stream=true: newline-delimited
+    // {"type": "progress", "stream"} lines, then a "result" or an "error".
+    // Progress goes to onProgress; returns the result or throws the error.
+    async readStream(response, onProgress) {
+        const reader = response.body.getReader();
+        const decoder = new TextDecoder();
+        let buffer = '';
+        for (;;) {
+            const { done, value } = await reader.read();
+            buffer += decoder.decode(value, { stream: !done });
+            const lines = buffer.split('\n');
+            buffer = lines.pop();
+            for (const line of lines) {
+                if (!line.trim()) continue;
+                const message = JSON.parse(line);
+                if (message.type === 'progress') {
+                    onProgress(message.stream);
+                } else if (message.type === 'error') {
+                    const error = new Error(message.error.message);
+                    error.code = message.error.code;
+                    throw error;
+                } else if (message.type === 'result') {
+                    return message.result;
+                }
+            }
+            if (done) throw new Error('Connection closed before the result');
+        }
+    },
+
     // Initialize application
     async init() {
         this.bindEvents();
@@ -287,6 +348,13 @@ const App = {
         // Logout button
         document.getElementById('logout-btn').addEventListener('click', () => this.logout());
 
+        // Change password
+        document.getElementById('change-password-btn').addEventListener('click', () => this.showModal('modal-password'));
+        document.getElementById('password-form').addEventListener('submit', (e) => {
+            e.preventDefault();
+            this.changePassword();
+        });
+
         // Navigation
         document.querySelectorAll('.nav-item').forEach(item => {
             item.addEventListener('click', (e) => {
@@ -304,7 +372,13 @@ const App = {
         // Containers page
         document.getElementById('refresh-containers').addEventListener('click', () => this.loadContainers());
         document.getElementById('auto-refresh-containers').addEventListener('change', (e) => this.setAutoRefresh('containers', e.target.checked));
-        document.getElementById('create-container-btn').addEventListener('click', () => this.showModal('modal-create-container'));
+        document.getElementById('create-container-btn').addEventListener('click', () => {
+            this.showModal('modal-create-container');
+            this.loadTemplates();
+        });
+        document.getElementById('container-template').addEventListener('change', (e) => this.applyTemplate(e.target.value));
+        document.getElementById('template-save-btn').addEventListener('click', () => this.saveTemplate());
+        document.getElementById('template-delete-btn').addEventListener('click', () => this.deleteTemplate());
         document.getElementById('create-container-form').addEventListener('submit', (e) => {
             e.preventDefault();
             this.createContainer();
@@ -318,6 +392,18 @@ const App = {
             e.preventDefault();
             this.pullImage();
         });
+        document.getElementById('image-search-btn').addEventListener('click', () => this.searchImages());
+        ['image-search-term', 'image-search-registry'].forEach(id => {
+            document.getElementById(id).addEventListener('keydown', (e) => {
+                if (e.key === 'Enter') {
+                    e.preventDefault();
+                    this.searchImages();
+                }
+            });
+        });
+
+        // Terminal page
+        document.getElementById('clear-terminal-history').addEventListener('click', () => this.confirmAction('Clear History', 'Remove all saved terminal commands?', () => this.clearTerminalHistory()));
 
         // Close dropdowns on click outside
         document.addEventListener('click', (e) => {
@@ -412,20 +498,50 @@ const App = {
                 body: JSON.stringify({ username, password, remember })
             });
 
-            const data = await response.json();
-
-            if (data.success) {
-                this.user = data.user;
-                errorEl.textContent = '';
-                this.showApp();
-            } else {
-                errorEl.textContent = data.message || 'Login failed';
+            if (!response.ok) {
+                const error = await this.apiError(response, 'Login failed');
+                errorEl.textContent = error.message;
+                return;
             }
+
+            const data = await response.json();
+            this.user = data.user;
+            errorEl.textContent = '';
+            this.showApp();
         } catch (error) {
             errorEl.textContent = 'Connection error';
         }
     },
 
+    // Change the current user's password
+    async changePassword() {
+        const currentPassword = document.getElementById('current-password').value;
+        const newPassword = document.getElementById('new-password').value;
+        if (newPassword !== document.getElementById('confirm-password').value) {
+            this.showToast('New passwords do not match', 'error');
+            return;
+        }
+
+        const btn = document.querySelector('#password-form button[type="submit"]');
+        btn.disabled = true;
+        try {
+            const response = await this.authFetch('/api/auth/change-password', {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ currentPassword, newPassword })
+            });
+            if (!response.ok) throw await this.apiError(response, 'Failed to change password');
+
+            this.showToast('Password changed', 'success');
+            this.closeModal('modal-password');
+            document.getElementById('password-form').reset();
+        } catch (error) {
+            if (error.message !== 'Session expired') this.showToast(error.message, 'error');
+        } finally {
+            btn.disabled = false;
+        }
+    },
+
     // Logout
     async logout() {
         try {
@@ -444,6 +560,7 @@ const App = {
         document.getElementById('username').value = '';
         document.getElementById('password').value = '';
         document.getElementById('login-error').textContent = '';
+        this.checkSSO();
         // Stop events check
         this.stopEventsCheck();
         this.eventsLastId = 0;
@@ -451,6 +568,22 @@ const App = {
         this.stopUpdateCheck();
     },
 
+    // Offer single sign-on when configured and show why the last one failed
+    async checkSSO() {
+        const params = new URLSearchParams(window.location.search);
+        if (params.get('login_error') === 'sso') {
+            document.getElementById('login-error').textContent = 'Single sign-on failed';
+            history.replaceState(null, '', window.location.pathname);
+        }
+        try {
+            const response = await fetch('/api/auth/oidc');
+            const data = await response.json();
+            document.getElementById('sso-login').classList.toggle('hidden', !data.enabled);
+        } catch {
+            // Keep the button hidden
+        }
+    },
+
     // Show main app
     showApp() {
         document.getElementById('login-page').classList.add('hidden');
@@ -495,6 +628,12 @@ const App = {
         if (this.currentPage === 'terminal') {
             this.cleanupHostTerminal();
         }
+        if (this.currentPage === 'files') {
+            this.stopFileWatch();
+        }
+        if (this.currentPage === 'containers') {
+            this.stopContainerStats();
+        }
         // Stop auto-refresh on previous page
         this.stopAutoRefresh(this.currentPage);
 
@@ -538,6 +677,7 @@ const App = {
             case 'containers':
                 this.loadContainers();
                 this.restoreAutoRefresh('containers');
+                this.startContainerStats();
                 break;
             case 'images':
                 this.loadImages();
@@ -568,10 +708,10 @@ const App = {
         this.hostTerminalFitAddon = null;
     },
 
-    // Get WebSocket CSRF token
-    async getWSToken() {
+    // Get WebSocket CSRF token; renewable tokens are for read-only streams
+    async getWSToken(renewable = false) {
         try {
-            const response = await this.authFetch('/api/auth/ws-token');
+            const response = await this.authFetch('/api/auth/ws-token' + (renewable ? '?renewable=true' : ''));
             if (!response.ok) {
                 throw new Error('Failed to get WebSocket token');
             }
@@ -680,18 +820,31 @@ const App = {
         const eventLabels = {
             'login': 'Login',
             'login_failed': 'Login Failed',
+            'login_lockout': 'Login Lockout',
             'logout': 'Logout',
+            'password_change': 'Password Change',
             'terminal_host': 'Host Terminal',
             'terminal_container': 'Container Terminal',
+            'terminal_blocked': 'Terminal Command Blocked',
+            'terminal_history': 'Terminal History Cleared',
             'container_start': 'Container Start',
             'container_stop': 'Container Stop',
             'container_restart': 'Container Restart',
             'container_remove': 'Container Remove',
             'container_create': 'Container Create',
+            'container_run': 'Container Run',
+            'container_systemd': 'Install Systemd Unit',
             'image_pull': 'Image Pull',
             'image_remove': 'Image Remove',
+            'image_scan': 'Image Scan',
+            'image_update': 'Image Update Available',
+            'volume_backup': 'Volume Backup',
             'system_reboot': 'System Reboot',
-            'system_shutdown': 'System Shutdown'
+            'system_shutdown': 'System Shutdown',
+            'mqtt_connected': 'MQTT Connected',
+            'mqtt_disconnected': 'MQTT Disconnected',
+            'mqtt_publish': 'MQTT Publish',
+            'mqtt_rediscover': 'MQTT Rediscover'
         };
 
         list.innerHTML = events.map(event => {
@@ -900,6 +1053,19 @@ const App = {
         });
     },
 
+    // Clear saved host terminal command history
+    async clearTerminalHistory() {
+        try {
+            const response = await this.authFetch('/api/terminal/history', { method: 'DELETE' });
+            if (!response.ok) throw new Error('Failed to clear history');
+            this.commandHistory = [];
+            this.historyIndex = -1;
+            this.showToast('Command history cleared', 'success');
+        } catch (error) {
+            if (error.message !== 'Session expired') this.showToast(error.message, 'error');
+        }
+    },
+
     // Initialize host terminal
     async initHostTerminal() {
         const container = document.getElementById('host-terminal-container');
@@ -1155,20 +1321,39 @@ const App = {
                 document.getElementById('info-hostname').textContent = data.system.host.hostname || '-';
                 document.getElementById('info-kernel').textContent = data.system.host.kernel || '-';
                 document.getElementById('info-arch').textContent = data.system.host.arch || '-';
+                const ipv6 = data.system.host.ipv6 || [];
+                document.getElementById('info-ipv6').textContent = ipv6.join(', ');
+                document.getElementById('info-ipv6-item').classList.toggle('hidden', ipv6.length === 0);
             }
             if (data.system && data.system.version) {
                 document.getElementById('info-podman').textContent = data.system.version.Version || '-';
             }
             // Update host stats (CPU, memory, uptime, disk, temperatures)
             if (data.hostStats) {
-                document.getElementById('info-cpu').textContent = data.hostStats.cpuUsage.toFixed(1) + '%';
-                document.getElementById('info-uptime').textContent = this.formatUptime(data.hostStats.uptime);
+                // Stats PodmanView could not read (e.g. containerized without the host's /proc)
+                const unavailable = data.hostStats.unavailable || [];
+                document.getElementById('info-cpu').textContent = unavailable.includes('cpu') ? 'n/a' : data.hostStats.cpuUsage.toFixed(1) + '%';
+                document.getElementById('info-uptime').textContent = unavailable.includes('uptime') ? 'n/a' : this.formatUptime(data.hostStats.uptime);
 
                 // Update memory (using MemAvailable for accurate "free" memory)
                 if (data.hostStats.memTotal) {
                     const memFree = this.formatBytes(data.hostStats.memFree);
                     const memTotal = this.formatBytes(data.hostStats.memTotal);
                     document.getElementById('info-memory').textContent = `${memFree} free / ${memTotal} total`;
+
+                    // Swap in use means memory pressure, more so the fuller it gets
+                    const swapEl = document.getElementById('info-swap');
+                    const swapTotal = data.hostStats.swapTotal || 0;
+                    if (swapTotal === 0) {
+                        swapEl.textContent = 'None';
+                        swapEl.classList.remove('warning', 'danger');
+                    } else {
+                        const swapUsed = swapTotal - (data.hostStats.swapFree || 0);
+                        const percent = swapUsed / swapTotal * 100;
+                        swapEl.textContent = `${this.formatBytes(swapUsed)} used / ${this.formatBytes(swapTotal)} (${percent.toFixed(0)}%)`;
+                        swapEl.classList.toggle('danger', percent >= 50);
+                        swapEl.classList.toggle('warning', percent >= 10 && percent < 50);
+                    }
                 }
 
                 // Update disks
@@ -1210,6 +1395,8 @@ const App = {
                 } else {
                     tempsSection.style.display = '';
 
+                    this.tempUnit = data.hostStats.tempUnit || 'C';
+
                     // Update CPU temperatures
                     if (hasCpuTemps) {
                         tempsCpu.innerHTML = data.hostStats.temperatures.map(t => this.renderTempItem(t)).join('');
@@ -1281,6 +1468,8 @@ const App = {
                         ? `${c.CPU.toFixed(1)}% / ${this.formatBytes(c.MemUsage)}`
                         : '-';
                     statsCell.textContent = statsDisplay;
+
+                    existingRow.querySelector('.container-meta').innerHTML = this.getContainerMeta(c);
                 } else {
                     // Add new row
                     const tr = document.createElement('tr');
@@ -1314,6 +1503,43 @@ const App = {
         }
     },
 
+    // Keep CPU and memory of running containers live between list refreshes
+    startContainerStats() {
+        this.stopContainerStats();
+        this.containerStatsInterval = setInterval(() => {
+            if (this.user && this.currentPage === 'containers' && !document.hidden) {
+                this.refreshContainerStats();
+            }
+        }, this.autoRefreshDelay);
+    },
+
+    stopContainerStats() {
+        if (this.containerStatsInterval) {
+            clearInterval(this.containerStatsInterval);
+            this.containerStatsInterval = null;
+        }
+    },
+
+    // Update the stats cells from the server's stats cache
+    async refreshContainerStats() {
+        try {
+            const response = await this.authFetch('/api/containers/stats');
+            if (!response.ok) return;
+            const data = await response.json();
+
+            const byId = new Map(data.stats.map(s => [s.ContainerID, s]));
+            document.querySelectorAll('#containers-list tr[data-id]').forEach(row => {
+                const stat = byId.get(row.dataset.id);
+                const status = row.querySelector('.status');
+                if (stat && status && status.textContent === 'running') {
+                    row.querySelector('.stats-cell').textContent = `${stat.CPU.toFixed(1)}% / ${this.formatBytes(stat.MemUsage)}`;
+                }
+            });
+        } catch (error) {
+            // Stats are refreshed again shortly; the list reports errors
+        }
+    },
+
     // Get container row HTML content (without tr wrapper)
     getContainerRowContent(c) {
         const statsDisplay = c.State === 'running'
@@ -1323,13 +1549,27 @@ const App = {
         return `
             <td class="truncate">${this.getContainerName(c)}</td>
             <td class="truncate">${c.Image}</td>
-            <td><span class="status ${c.State}">${c.State}</span></td>
+            <td>
+                <span class="status ${c.State}">${c.State}</span>
+                <div class="container-meta">${this.getContainerMeta(c)}</div>
+            </td>
             <td class="stats-cell">${statsDisplay}</td>
             <td class="actions">
                 ${this.getContainerActions(c)}
             </td>`;
     },
 
+    // Get uptime and restart count line for the status cell
+    getContainerMeta(c) {
+        const parts = [];
+        if (c.Uptime) parts.push(`up ${c.Uptime}`);
+        if (c.RestartCount > 0) {
+            const times = c.RestartCount === 1 ? 'time' : 'times';
+            parts.push(`<span class="restart-count">restarted ${c.RestartCount} ${times}</span>`);
+        }
+        return parts.join(' · ');
+    },
+
     // Get container name from Names array
     getContainerName(container) {
         if (container.Names && container.Names.length > 0) {
@@ -1345,10 +1585,15 @@ const App = {
         const id = container.Id || container.ID;
 
         let menuItems = `<button class="dropdown-item" onclick="App.viewLogs('${id}')">Logs</button>`;
+        menuItems += `<button class="dropdown-item" onclick="App.viewStatsHistory('${id}')">Resource Usage</button>`;
+        if (container.State === 'running') {
+            menuItems += `<button class="dropdown-item" onclick="App.openService('${id}')">Open Service</button>`;
+        }
 
         if (isAdmin) {
             if (container.State === 'running') {
                 menuItems += `<button class="dropdown-item" onclick="App.openTerminal('${id}')">Terminal</button>`;
+                menuItems += `<button class="dropdown-item" onclick="App.openTerminal('${id}', 'attach')">Attach</button>`;
                 menuItems += `<div class="dropdown-divider"></div>`;
                 menuItems += `<button class="dropdown-item" onclick="App.stopContainer('${id}')">Stop</button>`;
                 menuItems += `<button class="dropdown-item" onclick="App.restartContainer('${id}')">Restart</button>`;
@@ -1356,6 +1601,7 @@ const App = {
                 menuItems += `<div class="dropdown-divider"></div>`;
                 menuItems += `<button class="dropdown-item" onclick="App.startContainer('${id}')">Start</button>`;
             }
+            menuItems += `<button class="dropdown-item" onclick="App.toggleAutostart('${id}')">Start on Boot...</button>`;
             menuItems += `<div class="dropdown-divider"></div>`;
             menuItems += `<button class="dropdown-item btn-danger" onclick="App.removeContainer('${id}')">Remove</button>`;
         }
@@ -1408,6 +1654,23 @@ const App = {
         }
     },
 
+    // Open the first published port that answers HTTP(S)
+    async openService(id) {
+        try {
+            const response = await this.authFetch(`/api/containers/${id}/links`);
+            if (!response.ok) throw new Error('Failed to resolve links');
+            const data = await response.json();
+            const link = data.links.find(l => l.verified) || data.links[0];
+            if (!link) {
+                this.showToast('No published TCP ports', 'info');
+                return;
+            }
+            window.open(link.url, '_blank', 'noopener');
+        } catch (error) {
+            if (error.message !== 'Session expired') this.showToast(error.message, 'error');
+        }
+    },
+
     // Container actions
     async startContainer(id) {
         this.showToast('Starting container...', 'info');
@@ -1445,6 +1708,36 @@ const App = {
         }
     },
 
+    // Turn starting on boot on or off via the container's systemd unit
+    async toggleAutostart(id) {
+        try {
+            const response = await this.authFetch(`/api/containers/${id}/autostart`);
+            if (!response.ok) throw await this.apiError(response, 'Failed to read start on boot state');
+            const state = await response.json();
+
+            const message = state.enabled
+                ? `The container starts on boot (${state.unit}). Stop starting it on boot?`
+                : 'Start the container on boot? A systemd unit is installed if it has none.';
+            this.confirmAction('Start on Boot', message, async () => {
+                try {
+                    const response = await this.authFetch(`/api/containers/${id}/autostart`, {
+                        method: 'POST',
+                        headers: { 'Content-Type': 'application/json' },
+                        body: JSON.stringify({ enabled: !state.enabled })
+                    });
+                    if (!response.ok) throw await this.apiError(response, 'Failed to change start on boot');
+                    const result = await response.json();
+                    this.showToast(result.enabled ? 'Container starts on boot' : 'Container no longer starts on boot', 'success');
+                    if (result.warning) this.showToast(result.warning, 'info');
+                } catch (error) {
+                    if (error.message !== 'Session expired') this.showToast(error.message, 'error');
+                }
+            });
+        } catch (error) {
+            if (error.message !== 'Session expired') this.showToast(error.message, 'error');
+        }
+    },
+
     removeContainer(id) {
         this.confirmAction('Remove Container', 'Are you sure you want to remove this container?', async () => {
             this.showToast('Removing container...', 'info');
@@ -1459,6 +1752,45 @@ const App = {
         });
     },
 
+    // Show CPU and memory graphs of the last 20 minutes
+    async viewStatsHistory(id) {
+        const content = document.getElementById('stats-history-content');
+        content.innerHTML = '<div class="log-loading">Loading...</div>';
+        this.showModal('modal-stats-history');
+
+        try {
+            const response = await this.authFetch(`/api/containers/${id}/stats/history?points=120`);
+            if (!response.ok) throw await this.apiError(response, 'Failed to load resource usage');
+            const data = await response.json();
+
+            if (data.points.length < 2) {
+                content.innerHTML = `<div class="log-empty">Not enough samples yet, usage is recorded every ${data.interval} seconds while the container runs</div>`;
+                return;
+            }
+            const last = data.points[data.points.length - 1];
+            const minutes = Math.round((last.t - data.points[0].t) / 60);
+            content.innerHTML =
+                this.statsGraph(`CPU (last ${minutes} min)`, `${last.cpu.toFixed(1)}%`, data.points.map(p => p.cpu)) +
+                this.statsGraph(`Memory (last ${minutes} min)`, this.formatBytes(last.mem), data.points.map(p => p.mem));
+        } catch (error) {
+            if (error.message !== 'Session expired') {
+                content.innerHTML = `<div class="log-empty">${this.escapeHtml(error.message)}</div>`;
+            }
+        }
+    },
+
+    // Render a series as an SVG line scaled to its maximum
+    statsGraph(title, current, values) {
+        const max = Math.max(...values) || 1;
+        const step = 100 / (values.length - 1);
+        const points = values.map((v, i) => `${(i * step).toFixed(2)},${(40 - (v / max) * 38).toFixed(2)}`).join(' ');
+        return `
+            <div class="stats-graph">
+                <div class="stats-graph-header"><span>${title}</span><span>${current}</span></div>
+                <svg viewBox="0 0 100 40" preserveAspectRatio="none"><polyline points="${points}"/></svg>
+            </div>`;
+    },
+
     async viewLogs(id) {
         this.logsContainerId = id;
         this.stopAutoLogs();
@@ -1567,10 +1899,10 @@ const App = {
                     // Update only InUse status (the only thing that can change for images)
                     const statusCell = existingRow.querySelector('.badge');
                     if (statusCell) {
-                        const isInUse = statusCell.classList.contains('in-use');
-                        if (isInUse !== img.InUse) {
+                        const label = this.imageUsageLabel(img);
+                        if (statusCell.textContent !== label) {
                             statusCell.className = img.InUse ? 'badge in-use' : 'badge unused';
-                            statusCell.textContent = img.InUse ? 'In Use' : 'Unused';
+                            statusCell.textContent = label;
                         }
                     }
                 } else {
@@ -1606,6 +1938,12 @@ const App = {
         }
     },
 
+    // Image usage badge text, with the number of containers using it
+    imageUsageLabel(img) {
+        if (!img.InUse) return 'Unused';
+        return img.Containers > 1 ? `In Use (${img.Containers})` : 'In Use';
+    },
+
     // Get image row HTML content (without tr wrapper)
     getImageRowContent(img) {
         const [repo, tag] = this.parseImageTag(img);
@@ -1613,9 +1951,7 @@ const App = {
         const shortId = imgId.substring(0, 12);
         const displayRepo = repo === '<none>' ? `<span class="text-muted">&lt;none&gt;</span>` : repo;
         const displayTag = tag === '<none>' ? `<span class="text-muted">&lt;none&gt;</span>` : tag;
-        const usageStatus = img.InUse
-            ? '<span class="badge in-use">In Use</span>'
-            : '<span class="badge unused">Unused</span>';
+        const usageStatus = `<span class="badge ${img.InUse ? 'in-use' : 'unused'}">${this.imageUsageLabel(img)}</span>`;
 
         return `
             <td class="truncate">${displayRepo}</td>
@@ -1625,14 +1961,15 @@ const App = {
             <td>${this.formatDate(img.Created)}</td>
             <td>${usageStatus}</td>
             <td class="actions">
-                ${this.user && this.user.role === 'admin'
-                    ? `<div class="dropdown">
-                        <button class="btn btn-small" onclick="App.toggleDropdown(this)">...</button>
-                        <div class="dropdown-menu">
-                            <button class="dropdown-item btn-danger" onclick="App.removeImage('${imgId}')">Remove</button>
-                        </div>
-                    </div>`
-                    : ''}
+                <div class="dropdown">
+                    <button class="btn btn-small" onclick="App.toggleDropdown(this)">...</button>
+                    <div class="dropdown-menu">
+                        <button class="dropdown-item" onclick="App.copyPinnedReference('${imgId}')">Copy Pinned Reference</button>
+                        ${this.user && this.user.role === 'admin'
+                            ? `<button class="dropdown-item btn-danger" onclick="App.removeImage('${imgId}')">Remove</button>`
+                            : ''}
+                    </div>
+                </div>
             </td>`;
     },
 
@@ -1646,9 +1983,51 @@ const App = {
         return ['<none>', '<none>'];
     },
 
+    // Search registries from the pull dialog; picking a result fills the reference
+    async searchImages() {
+        const term = document.getElementById('image-search-term').value.trim();
+        const registry = document.getElementById('image-search-registry').value.trim();
+        const container = document.getElementById('image-search-results');
+        if (!term) return;
+
+        container.classList.remove('hidden');
+        container.innerHTML = '<div class="image-search-message">Searching...</div>';
+
+        try {
+            const params = new URLSearchParams({ q: term });
+            if (registry) params.set('registry', registry);
+            const response = await this.authFetch(`/api/images/search?${params}`);
+            if (!response.ok) throw await this.apiError(response, 'Search failed');
+
+            const data = await response.json();
+            if (data.results.length === 0) {
+                container.innerHTML = '<div class="image-search-message">No images found</div>';
+                return;
+            }
+            container.innerHTML = data.results.map(result => `
+                <button type="button" class="image-search-result" data-name="${this.escapeHtml(result.name)}">
+                    ${this.escapeHtml(result.name)}
+                    ${result.official ? '<span class="badge in-use">Official</span>' : ''}
+                    <span class="text-muted">&#9733; ${result.stars}</span>
+                    ${result.description ? `<span class="description">${this.escapeHtml(result.description)}</span>` : ''}
+                </button>`).join('');
+            container.querySelectorAll('.image-search-result').forEach(btn => {
+                btn.addEventListener('click', () => {
+                    document.getElementById('image-reference').value = btn.dataset.name;
+                    container.classList.add('hidden');
+                });
+            });
+        } catch (error) {
+            if (error.message !== 'Session expired') {
+                container.innerHTML = `<div class="image-search-message">${this.escapeHtml(error.message)}</div>`;
+            }
+        }
+    },
+
     // Pull image
     async pullImage() {
         const reference = document.getElementById('image-reference').value;
+        const platform = document.getElementById('image-platform').value;
         if (!reference) return;
 
         const btn = document.querySelector('#pull-form button[type="submit"]');
@@ -1660,30 +2039,66 @@ const App = {
             const response = await this.authFetch('/api/images/pull', {
                 method: 'POST',
                 headers: { 'Content-Type': 'application/json' },
-                body: JSON.stringify({ reference })
+                body: JSON.stringify({ reference, platform })
             });
 
-            if (!response.ok) throw new Error('Failed to pull image');
+            if (!response.ok) throw await this.apiError(response, 'Failed to pull image');
 
             this.showToast('Image pulled successfully', 'success');
             this.closeModal('modal-pull');
             this.loadImages();
             document.getElementById('image-reference').value = '';
         } catch (error) {
-            if (error.message !== 'Session expired') this.showToast('Failed to pull image', 'error');
+            if (error.message !== 'Session expired') this.showToast(error.message, 'error');
         } finally {
             btn.disabled = false;
             btn.textContent = 'Pull';
         }
     },
 
+    // Copy the image's repo@sha256 reference, or its ID for locally built images
+    async copyPinnedReference(id) {
+        try {
+            const response = await this.authFetch(`/api/images/${id}/pinned-reference`);
+            if (!response.ok) throw await this.apiError(response, 'Failed to get pinned reference');
+
+            const data = await response.json();
+            const ref = data.references.find(r => r.pinned) || data.references[0];
+            await this.copyText(ref.reference);
+            this.showToast(ref.pinned ? `Copied ${ref.reference}` : 'No repo digest (locally built), copied image ID', 'success');
+        } catch (error) {
+            if (error.message !== 'Session expired') this.showToast(error.message, 'error');
+        }
+    },
+
+    // Copy text to the clipboard; the Clipboard API needs HTTPS or localhost
+    async copyText(text) {
+        if (navigator.clipboard && window.isSecureContext) {
+            await navigator.clipboard.writeText(text);
+            return;
+        }
+        const textarea = document.createElement('textarea');
+        textarea.value = text;
+        textarea.style.position = 'fixed';
+        textarea.style.opacity = '0';
+        document.body.appendChild(textarea);
+        textarea.select();
+        const copied = document.execCommand('copy');
+        textarea.remove();
+        if (!copied) throw new Error('Failed to copy to clipboard');
+    },
+
     // Remove image
     removeImage(id) {
         this.confirmAction('Remove Image', 'Are you sure you want to remove this image?', async () => {
             this.showToast('Removing image...', 'info');
             try {
                 const response = await this.authFetch(`/api/images/${id}?force=true`, { method: 'DELETE' });
-                if (!response.ok) throw new Error('Failed to remove image');
+                if (!response.ok) {
+                    const error = await this.apiError(response, 'Failed to remove image');
+                    // Already gone, e.g. removed from another session
+                    if (error.code !== 'image_not_found') throw error;
+                }
                 this.showToast('Image removed', 'success');
                 this.loadImages();
             } catch (error) {
@@ -1738,37 +2153,137 @@ const App = {
         }
     },
 
-    // Create container
-    async createContainer() {
-        const form = document.getElementById('create-container-form');
-        const btn = form.querySelector('button[type="submit"]');
-        btn.disabled = true;
-        btn.textContent = 'Creating...';
-        this.showToast('Creating container...', 'info');
-
-        const data = {
+    // Read the create container form as a request body
+    createContainerData() {
+        return {
             image: document.getElementById('container-image').value,
             name: document.getElementById('container-name').value,
             ports: document.getElementById('container-ports').value,
             volumes: document.getElementById('container-volumes').value,
             env: document.getElementById('container-env').value,
             command: document.getElementById('container-command').value,
-            start: document.getElementById('container-start').checked
+            start: document.getElementById('container-start').checked,
+            remove: document.getElementById('container-remove').checked,
+            auto_pull: document.getElementById('container-auto-pull').checked
         };
+    },
+
+    // Load container templates into the create form, keeping the selection
+    async loadTemplates(selected) {
+        const select = document.getElementById('container-template');
+        try {
+            const response = await this.authFetch('/api/templates');
+            if (!response.ok) throw await this.apiError(response, 'Failed to load templates');
+            const data = await response.json();
+            this.containerTemplates = data.templates || [];
+        } catch (error) {
+            this.containerTemplates = [];
+        }
+
+        select.innerHTML = '<option value="">None</option>' + this.containerTemplates
+            .map(t => `<option value="${this.escapeHtml(t.name)}">${this.escapeHtml(t.name)} (${this.escapeHtml(t.config.image)})</option>`)
+            .join('');
+        select.value = selected || '';
+        document.getElementById('template-delete-btn').disabled = !select.value;
+    },
+
+    // Fill the create form from a template
+    applyTemplate(name) {
+        document.getElementById('template-delete-btn').disabled = !name;
+        const template = (this.containerTemplates || []).find(t => t.name === name);
+        if (!template) return;
+
+        const config = template.config;
+        document.getElementById('container-image').value = config.image || '';
+        document.getElementById('container-name').value = config.name || '';
+        document.getElementById('container-ports').value = (config.portmappings || [])
+            .map(p => `${p.host_port}:${p.container_port}`).join(', ');
+        document.getElementById('container-volumes').value = (config.mounts || [])
+            .map(m => `${m.Source}:${m.Destination}`).join(', ');
+        document.getElementById('container-env').value = Object.entries(config.env || {})
+            .map(([key, value]) => `${key}=${value}`).join(', ');
+        document.getElementById('container-command').value = (config.command || []).join(' ');
+        document.getElementById('container-remove').checked = !!config.remove;
+    },
+
+    // Save the create form as a template; the dry run resolves it into a config
+    async saveTemplate() {
+        const data = this.createContainerData();
+        if (!data.image) {
+            this.showToast('Enter an image first', 'error');
+            return;
+        }
+        const name = prompt('Template name:', document.getElementById('container-template').value);
+        if (!name) return;
 
         try {
-            const response = await this.authFetch('/api/containers', {
+            const dryRun = await this.authFetch('/api/containers?dry_run=true', {
                 method: 'POST',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify(data)
             });
+            if (!dryRun.ok) throw await this.apiError(dryRun, 'Failed to read the form');
+            const { config } = await dryRun.json();
 
-            const result = await response.json();
+            const response = await this.authFetch('/api/templates', {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ name, config })
+            });
+            if (!response.ok) throw await this.apiError(response, 'Failed to save template');
+
+            const template = await response.json();
+            this.showToast(`Template "${template.name}" saved`, 'success');
+            this.loadTemplates(template.name);
+        } catch (error) {
+            if (error.message !== 'Session expired') this.showToast(error.message, 'error');
+        }
+    },
+
+    // Delete the selected template
+    async deleteTemplate() {
+        const name = document.getElementById('container-template').value;
+        if (!name || !confirm(`Delete template "${name}"?`)) return;
+
+        try {
+            const response = await this.authFetch(`/api/templates/${encodeURIComponent(name)}`, { method: 'DELETE' });
+            if (!response.ok) throw await this.apiError(response, 'Failed to delete template');
+            this.showToast('Template deleted', 'success');
+            this.loadTemplates();
+        } catch (error) {
+            if (error.message !== 'Session expired') this.showToast(error.message, 'error');
+        }
+    },
+
+    // Create container
+    async createContainer() {
+        const form = document.getElementById('create-container-form');
+        const btn = form.querySelector('button[type="submit"]');
+        btn.disabled = true;
+        btn.textContent = 'Creating...';
+        this.showToast('Creating container...', 'info');
+
+        const data = this.createContainerData();
+        const progress = document.getElementById('create-container-progress');
+
+        try {
+            const response = await this.authFetch('/api/containers?stream=true', {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify(data)
+            });
 
             if (!response.ok) {
-                throw new Error(result.error || 'Failed to create container');
+                throw await this.apiError(response, 'Failed to create container');
             }
 
+            // Output of pulling a missing image arrives ahead of the result
+            const result = await this.readStream(response, (line) => {
+                btn.textContent = 'Pulling...';
+                progress.textContent = line;
+                progress.classList.remove('hidden');
+            });
+
             this.showToast(`Container ${result.status}`, 'success');
             this.closeModal('modal-create-container');
             form.reset();
@@ -1778,11 +2293,14 @@ const App = {
         } finally {
             btn.disabled = false;
             btn.textContent = 'Create';
+            progress.classList.add('hidden');
+            progress.textContent = '';
         }
     },
 
     // Open terminal
-    async openTerminal(containerId) {
+    // mode 'attach' connects to the container's main process instead of a new shell
+    async openTerminal(containerId, mode = 'exec') {
         this.showModal('modal-terminal');
         const container = document.getElementById('terminal-container');
         container.innerHTML = '<p style="color: var(--text-secondary); padding: 20px;">Loading terminal...</p>';
@@ -1828,7 +2346,7 @@ const App = {
             this.terminalFitAddon.fit();
         }
 
-        this.terminal.writeln('Connecting to container...');
+        this.terminal.writeln(mode === 'attach' ? 'Attaching to container...' : 'Connecting to container...');
 
         // Get CSRF token for WebSocket
         const wsToken = await this.getWSToken();
@@ -1839,7 +2357,7 @@ const App = {
 
         // Connect WebSocket with CSRF token
         const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
-        const wsUrl = `${protocol}//${window.location.host}/api/containers/${containerId}/terminal?ws_token=${encodeURIComponent(wsToken)}`;
+        const wsUrl = `${protocol}//${window.location.host}/api/containers/${containerId}/terminal?ws_token=${encodeURIComponent(wsToken)}${mode === 'attach' ? '&mode=attach' : ''}`;
 
         try {
             this.terminalSocket = new WebSocket(wsUrl);
@@ -1862,18 +2380,85 @@ const App = {
                 console.error('WebSocket error:', error);
             };
 
-            // Setup terminal input handler with localStorage history support
-            this.setupTerminalInputHandler(
-                this.terminal,
-                this.terminalSocket,
-                (cmd) => this.addToHistoryLocal(cmd)
-            );
+            if (mode === 'attach') {
+                // The main process is not a shell, so input is passed through without history
+                const socket = this.terminalSocket;
+                this.terminal.onData(data => {
+                    if (socket.readyState === WebSocket.OPEN) {
+                        socket.send(JSON.stringify({ type: 'stdin', data: data }));
+                    }
+                });
+            } else {
+                // Setup terminal input handler with localStorage history support
+                this.setupTerminalInputHandler(
+                    this.terminal,
+                    this.terminalSocket,
+                    (cmd) => this.addToHistoryLocal(cmd)
+                );
+            }
 
         } catch (error) {
             this.terminal.writeln('\r\n\x1b[31mFailed to connect: ' + error.message + '\x1b[0m');
         }
     },
 
+    // Download a single file from the terminal's container
+    async downloadContainerFile() {
+        const containerId = this.currentContainerId;
+        if (!containerId) return;
+        const path = prompt('Path of the file in the container:');
+        if (!path) return;
+
+        try {
+            const response = await this.authFetch(`/api/containers/${containerId}/exec/download`, {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ path })
+            });
+            if (!response.ok) {
+                throw await this.apiError(response, 'Download failed');
+            }
+
+            const blob = await response.blob();
+            const link = document.createElement('a');
+            link.href = URL.createObjectURL(blob);
+            link.download = path.split('/').pop() || 'download';
+            link.click();
+            URL.revokeObjectURL(link.href);
+        } catch (error) {
+            this.showToast(error.message, 'error');
+        }
+    },
+
+    // Upload the chosen file into the terminal's container
+    async uploadContainerFile(input) {
+        const file = input.files[0];
+        input.value = '';
+        const containerId = this.currentContainerId;
+        if (!file || !containerId) return;
+
+        const path = prompt('Destination in the container (end with / to keep the file name):', '/tmp/');
+        if (!path) return;
+
+        const formData = new FormData();
+        formData.append('path', path);
+        formData.append('file', file);
+
+        try {
+            const response = await this.authFetch(`/api/containers/${containerId}/exec/upload`, {
+                method: 'POST',
+                body: formData
+            });
+            if (!response.ok) {
+                throw await this.apiError(response, 'Upload failed');
+            }
+            const result = await response.json();
+            this.showToast(`Uploaded to ${result.path}`, 'success');
+        } catch (error) {
+            this.showToast(error.message, 'error');
+        }
+    },
+
     // Close terminal
     closeTerminal() {
         if (this.terminalSocket) {
@@ -1901,6 +2486,9 @@ const App = {
             this.stopAutoLogs();
             this.logsContainerId = null;
         }
+        if (id === 'modal-file-tail') {
+            this.stopFileTail();
+        }
     },
 
     // Toast notifications
@@ -1960,11 +2548,19 @@ const App = {
         return `
             <div class="temp-item">
                 <span class="temp-label">${t.label}</span>
-                <span class="temp-value ${tempClass}">${t.temp.toFixed(1)}°C</span>
+                <span class="temp-value ${tempClass}">${this.formatTemp(t.temp)}</span>
             </div>
         `;
     },
 
+    // formatTemp shows a °C reading in the unit set in the temperature plugin
+    formatTemp(celsius) {
+        if (this.tempUnit === 'F') {
+            return `${(celsius * 9 / 5 + 32).toFixed(1)}°F`;
+        }
+        return `${celsius.toFixed(1)}°C`;
+    },
+
     renderStorageDevice(device) {
         const sensorsHtml = device.sensors.map(t => this.renderTempItem(t)).join('');
         return `
@@ -2113,8 +2709,7 @@ const App = {
         try {
             const response = await this.authFetch('/api/system/update', { method: 'POST' });
             if (!response.ok) {
-                const data = await response.json();
-                throw new Error(data.error || 'Failed to start update');
+                throw await this.apiError(response, 'Failed to start update');
             }
 
             // Start polling for progress
@@ -2237,9 +2832,26 @@ const App = {
             this.showNewFileDialog();
         };
 
+        document.getElementById('fm-select-all').onchange = (e) => {
+            document.querySelectorAll('#fm-file-list .fm-select').forEach(cb => {
+                cb.checked = e.target.checked;
+                this.toggleFileSelection(cb.dataset.path, cb.checked);
+            });
+        };
+
+        document.getElementById('fm-delete-selected-btn').onclick = () => {
+            this.deleteSelectedFiles();
+        };
+
+        document.getElementById('fm-move-selected-btn').onclick = () => {
+            this.moveSelectedFiles();
+        };
+
         // Setup drag & drop
         this.setupFileManagerDragDrop();
 
+        this.loadBookmarks();
+
         // Load root directory
         await this.loadFiles('/');
     },
@@ -2255,22 +2867,100 @@ const App = {
             }
 
             const data = await response.json();
+            const samePath = data.path === this.fileManagerCurrentPath;
             this.fileManagerCurrentPath = data.path;
             this.fileManagerParent = data.parent;
             this.fileManagerFiles = data.items || [];
             this.fileManagerOffset = data.offset || 0;
             this.fileManagerTotalCount = data.total_count || 0;
             this.fileManagerHasMore = data.has_more || false;
+            this.fileManagerDiskFree = data.disk_free || 0;
+            this.fileManagerDiskTotal = data.disk_total || 0;
+
+            // A live refresh keeps the selection of entries that still exist
+            if (samePath) {
+                const present = new Set(this.fileManagerFiles.map(f => f.path));
+                this.fileManagerSelected.forEach(p => {
+                    if (!present.has(p)) this.fileManagerSelected.delete(p);
+                });
+            } else {
+                this.fileManagerSelected.clear();
+            }
 
             this.renderBreadcrumb(data.path);
+            this.renderDiskFree();
             this.renderFiles(data.items || []);
             this.renderPagination(data);
+            this.updateFileSelection();
+            this.watchDirectory(data.path);
         } catch (error) {
             console.error('Failed to load files:', error);
             this.showToast('Failed to load files', 'error');
         }
     },
 
+    // Watch the shown directory and refresh the listing when it changes
+    async watchDirectory(path) {
+        if (this.fileWatchPath === path) return;
+        this.stopFileWatch();
+        this.fileWatchPath = path;
+
+        const wsToken = await this.getWSToken(true);
+        if (!wsToken || this.fileWatchPath !== path) return;
+
+        const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
+        const wsUrl = `${protocol}//${window.location.host}/api/files/watch?path=${encodeURIComponent(path)}&ws_token=${encodeURIComponent(wsToken)}`;
+        const socket = new WebSocket(wsUrl);
+        this.fileWatchSocket = socket;
+
+        socket.onmessage = (event) => {
+            const msg = JSON.parse(event.data);
+            if (msg.type === 'changes') {
+                // Coalesce bursts into one reload
+                clearTimeout(this.fileWatchReload);
+                this.fileWatchReload = setTimeout(() => {
+                    this.loadFiles(this.fileManagerCurrentPath, this.fileManagerOffset);
+                }, 300);
+            } else if (msg.type === 'gone') {
+                this.showToast('Directory was removed', 'info');
+                this.loadFiles(this.fileManagerParent);
+            }
+        };
+        socket.onclose = () => {
+            // Watch again on the next load (limit reached, server restart, ...)
+            if (this.fileWatchSocket === socket) {
+                this.fileWatchSocket = null;
+                this.fileWatchPath = null;
+            }
+        };
+    },
+
+    // Stop watching the shown directory
+    stopFileWatch() {
+        clearTimeout(this.fileWatchReload);
+        this.fileWatchPath = null;
+        if (this.fileWatchSocket) {
+            const socket = this.fileWatchSocket;
+            this.fileWatchSocket = null;
+            socket.close();
+        }
+    },
+
+    // Show free space of the current directory's filesystem
+    renderDiskFree() {
+        const el = document.getElementById('fm-disk-free');
+        if (!el) return;
+
+        const total = this.fileManagerDiskTotal;
+        if (!total) {
+            el.textContent = '';
+            return;
+        }
+        const free = this.fileManagerDiskFree;
+        el.textContent = `${this.formatBytes(free)} free of ${this.formatBytes(total)}`;
+        el.classList.toggle('low', free < total * 0.05);
+    },
+
     // Render pagination controls
     renderPagination(data) {
         const container = document.getElementById('fm-pagination');
@@ -2358,7 +3048,7 @@ const App = {
         if (!files || files.length === 0) {
             tbody.innerHTML = `
                 <tr class="fm-empty">
-                    <td colspan="4">No files or directories</td>
+                    <td colspan="5">No files or directories</td>
                 </tr>
             `;
             return;
@@ -2378,6 +3068,9 @@ const App = {
 
             return `
                 <tr class="fm-row ${file.is_dir ? 'fm-dir' : 'fm-file'}" data-path="${file.path}" data-name="${file.name}" data-is-dir="${file.is_dir}">
+                    <td class="fm-col-select">
+                        <input type="checkbox" class="fm-select" data-path="${this.escapeHtml(file.path)}" ${this.fileManagerSelected.has(file.path) ? 'checked' : ''}>
+                    </td>
                     <td class="fm-col-name">
                         <div class="fm-name-cell">
                             ${icon}
@@ -2393,6 +3086,15 @@ const App = {
             `;
         }).join('');
 
+        // Unreadable or unsupported images keep the plain icon
+        tbody.querySelectorAll('img.fm-thumb').forEach(img => {
+            img.onerror = () => { img.outerHTML = this.getImageIcon(); };
+        });
+
+        tbody.querySelectorAll('.fm-select').forEach(cb => {
+            cb.onchange = () => this.toggleFileSelection(cb.dataset.path, cb.checked);
+        });
+
         // Add click handlers for navigation and file opening
         tbody.querySelectorAll('.fm-row').forEach(row => {
             const isDir = row.dataset.isDir === 'true';
@@ -2407,6 +3109,15 @@ const App = {
         });
     },
 
+    // Generic image icon, also shown when a thumbnail fails to load
+    getImageIcon() {
+        return `<svg class="fm-icon fm-icon-image" viewBox="0 0 24 24" fill="currentColor">
+                <rect x="3" y="3" width="18" height="18" rx="2"/>
+                <circle cx="8.5" cy="8.5" r="1.5"/>
+                <path d="M21 15l-5-5L5 21"/>
+            </svg>`;
+    },
+
     // Get icon for file type
     getFileIcon(file) {
         if (file.is_dir) {
@@ -2417,13 +3128,15 @@ const App = {
 
         const ext = file.name.split('.').pop().toLowerCase();
 
+        // Images the server can thumbnail; fall back to the icon on error
+        if (['jpg', 'jpeg', 'png', 'gif'].includes(ext)) {
+            const src = `/api/files/thumbnail?path=${encodeURIComponent(file.path)}&size=64`;
+            return `<img class="fm-icon fm-thumb" src="${src}" alt="" loading="lazy">`;
+        }
+
         // Image files
-        if (['jpg', 'jpeg', 'png', 'gif', 'svg', 'webp'].includes(ext)) {
-            return `<svg class="fm-icon fm-icon-image" viewBox="0 0 24 24" fill="currentColor">
-                <rect x="3" y="3" width="18" height="18" rx="2"/>
-                <circle cx="8.5" cy="8.5" r="1.5"/>
-                <path d="M21 15l-5-5L5 21"/>
-            </svg>`;
+        if (['svg', 'webp'].includes(ext)) {
+            return this.getImageIcon();
         }
 
         // Code files
@@ -2468,6 +3181,7 @@ const App = {
 
         if (!isDir) {
             menuItems += `<button class="dropdown-item" onclick="App.downloadFile('${path}', '${name}')">Download</button>`;
+            menuItems += `<button class="dropdown-item" onclick="App.followFile('${path}', '${name}')">Follow</button>`;
         }
 
         menuItems += `<button class="dropdown-item" onclick="App.showRenameDialog('${path}', '${name}')">Rename</button>`;
@@ -2489,8 +3203,273 @@ const App = {
             </div>`;
     },
 
+    // Follow a file like tail -F: the last lines, then appended data
+    async followFile(path, name) {
+        this.stopFileTail();
+        document.getElementById('file-tail-name').textContent = name;
+        document.getElementById('file-tail-content').textContent = '';
+        const status = document.getElementById('file-tail-status');
+        status.textContent = 'Connecting...';
+        this.showModal('modal-file-tail');
+
+        const wsToken = await this.getWSToken(true);
+        if (!wsToken) {
+            status.textContent = 'Failed to get connection token';
+            return;
+        }
+
+        const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
+        const wsUrl = `${protocol}//${window.location.host}/api/files/tail?path=${encodeURIComponent(path)}&lines=200&ws_token=${encodeURIComponent(wsToken)}`;
+        const socket = new WebSocket(wsUrl);
+        this.fileTailSocket = socket;
+
+        socket.onopen = () => { status.textContent = 'Following'; };
+        socket.onmessage = (event) => {
+            const msg = JSON.parse(event.data);
+            if (msg.type === 'data') {
+                this.appendFileTail(msg.data);
+            } else if (msg.type === 'rotated') {
+                this.appendFileTail('\n--- file truncated or rotated ---\n');
+            } else if (msg.type === 'error') {
+                status.textContent = msg.data;
+            }
+        };
+        socket.onclose = () => {
+            if (this.fileTailSocket === socket) {
+                status.textContent = 'Disconnected';
+                this.fileTailSocket = null;
+            }
+        };
+    },
+
+    // Append followed data, keeping the view pinned to the end if it was
+    appendFileTail(data) {
+        const content = document.getElementById('file-tail-content');
+        const atBottom = content.scrollHeight - content.scrollTop <= content.clientHeight + 50;
+        content.append(data);
+        // Keep the last ~1 MB so a busy log can't grow the page forever
+        const maxChars = 1024 * 1024;
+        if (content.textContent.length > maxChars) {
+            content.textContent = content.textContent.slice(-maxChars);
+        }
+        if (atBottom) {
+            content.scrollTop = content.scrollHeight;
+        }
+    },
+
+    clearFileTail() {
+        document.getElementById('file-tail-content').textContent = '';
+    },
+
+    stopFileTail() {
+        if (this.fileTailSocket) {
+            const socket = this.fileTailSocket;
+            this.fileTailSocket = null;
+            socket.close();
+        }
+    },
+
+    // Load bookmarks; the menu stays hidden when the server has no storage
+    async loadBookmarks() {
+        try {
+            const response = await this.authFetch('/api/files/bookmarks');
+            if (!response.ok) {
+                throw await this.apiError(response, 'Failed to load bookmarks');
+            }
+            const data = await response.json();
+            this.fileManagerBookmarks = data.bookmarks || [];
+            document.getElementById('fm-bookmarks').classList.remove('hidden');
+        } catch (error) {
+            if (error.code !== 'storage_unavailable') {
+                console.error('Failed to load bookmarks:', error);
+            }
+        }
+    },
+
+    // Open the bookmarks menu
+    toggleBookmarks(btn) {
+        this.renderBookmarksMenu();
+        this.toggleDropdown(btn);
+    },
+
+    // Render the bookmarks menu for the current directory
+    renderBookmarksMenu() {
+        const menu = document.getElementById('fm-bookmarks-menu');
+        menu.innerHTML = '';
+
+        const addItem = (label, onclick, className = 'dropdown-item') => {
+            const item = document.createElement('button');
+            item.className = className;
+            item.textContent = label;
+            item.onclick = (e) => {
+                e.stopPropagation();
+                document.getElementById('fm-bookmarks').classList.remove('open');
+                onclick();
+            };
+            return item;
+        };
+
+        const current = this.fileManagerCurrentPath;
+        if (this.fileManagerBookmarks.some(b => b.path === current)) {
+            menu.appendChild(addItem('Remove bookmark for this folder', () => this.removeBookmark(current)));
+        } else {
+            menu.appendChild(addItem('Bookmark this folder', () => this.addBookmark(current)));
+        }
+        const divider = document.createElement('div');
+        divider.className = 'dropdown-divider';
+        menu.appendChild(divider);
+
+        if (this.fileManagerBookmarks.length === 0) {
+            const empty = document.createElement('div');
+            empty.className = 'fm-bookmarks-empty';
+            empty.textContent = 'No bookmarks yet';
+            menu.appendChild(empty);
+            return;
+        }
+
+        this.fileManagerBookmarks.forEach(bookmark => {
+            const row = document.createElement('div');
+            row.className = 'fm-bookmark-item';
+            const open = addItem(bookmark.name, () => {
+                if (bookmark.is_dir) {
+                    this.loadFiles(bookmark.path);
+                } else {
+                    this.openFileEditor(bookmark.path, bookmark.name);
+                }
+            });
+            open.title = bookmark.path;
+            row.appendChild(open);
+            const remove = addItem('\u00d7', () => this.removeBookmark(bookmark.path), 'dropdown-item fm-bookmark-remove');
+            remove.title = 'Remove bookmark';
+            row.appendChild(remove);
+            menu.appendChild(row);
+        });
+    },
+
+    // Bookmark a file or directory
+    async addBookmark(path) {
+        try {
+            const response = await this.authFetch('/api/files/bookmarks', {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ path })
+            });
+            if (!response.ok) {
+                throw await this.apiError(response, 'Failed to add bookmark');
+            }
+            const bookmark = await response.json();
+            this.fileManagerBookmarks.push(bookmark);
+            this.showToast(`Bookmarked ${bookmark.name}`, 'success');
+        } catch (error) {
+            this.showToast(error.message, 'error');
+        }
+    },
+
+    // Remove a bookmark
+    async removeBookmark(path) {
+        try {
+            const response = await this.authFetch(`/api/files/bookmarks?path=${encodeURIComponent(path)}`, {
+                method: 'DELETE'
+            });
+            if (!response.ok) {
+                throw await this.apiError(response, 'Failed to remove bookmark');
+            }
+            this.fileManagerBookmarks = this.fileManagerBookmarks.filter(b => b.path !== path);
+            this.showToast('Bookmark removed', 'success');
+        } catch (error) {
+            this.showToast(error.message, 'error');
+        }
+    },
+
+    // Add or remove a path from the batch selection
+    toggleFileSelection(path, selected) {
+        if (selected) {
+            this.fileManagerSelected.add(path);
+        } else {
+            this.fileManagerSelected.delete(path);
+        }
+        this.updateFileSelection();
+    },
+
+    // Show the batch actions while files are selected
+    updateFileSelection() {
+        const count = this.fileManagerSelected.size;
+        document.getElementById('fm-selection').classList.toggle('hidden', count === 0);
+        document.getElementById('fm-selection-count').textContent = `${count} selected`;
+
+        const selectAll = document.getElementById('fm-select-all');
+        const boxes = document.querySelectorAll('#fm-file-list .fm-select');
+        selectAll.checked = boxes.length > 0 && count === boxes.length;
+        selectAll.indeterminate = count > 0 && count < boxes.length;
+    },
+
+    // Delete all selected files and directories
+    async deleteSelectedFiles() {
+        const count = this.fileManagerSelected.size;
+        if (!confirm(`Are you sure you want to delete ${count} selected item(s)? Directories are deleted with all contents.`)) {
+            return;
+        }
+        await this.batchFiles({ action: 'delete' }, 'Deleted');
+    },
+
+    // Move all selected files and directories to another directory
+    async moveSelectedFiles() {
+        const destDir = prompt('Move selected items to directory:', this.fileManagerCurrentPath);
+        if (!destDir || destDir === this.fileManagerCurrentPath) {
+            return;
+        }
+        await this.batchFiles({ action: 'move', dest_dir: destDir }, 'Moved');
+    },
+
+    // Run a batch operation on the selected paths and report per-path failures
+    async batchFiles(request, doneVerb) {
+        try {
+            const response = await this.authFetch('/api/files/batch', {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ ...request, paths: [...this.fileManagerSelected] })
+            });
+            if (!response.ok) {
+                throw await this.apiError(response, 'Batch operation failed');
+            }
+
+            const result = await response.json();
+            if (result.failed === 0) {
+                this.showToast(`${doneVerb} ${result.succeeded} item(s)`, 'success');
+            } else {
+                const failures = result.results.filter(r => !r.success);
+                const first = failures[0];
+                this.showToast(`${doneVerb} ${result.succeeded} item(s), ${result.failed} failed: ${first.path}: ${first.error.message}`, 'error');
+                console.warn('Batch failures:', failures);
+            }
+        } catch (error) {
+            console.error('Batch error:', error);
+            this.showToast(error.message, 'error');
+        }
+
+        await this.loadFiles(this.fileManagerCurrentPath, this.fileManagerOffset);
+    },
+
     // Upload files
     async uploadFiles(files) {
+        // Warn before an upload that would fill the disk
+        if (this.fileManagerDiskTotal) {
+            let size = 0;
+            for (let i = 0; i < files.length; i++) {
+                size += files[i].size;
+            }
+            const free = this.fileManagerDiskFree;
+            let warning = null;
+            if (size > free) {
+                warning = `The upload (${this.formatBytes(size)}) is larger than the free space (${this.formatBytes(free)}) and will likely fail.`;
+            } else if (free - size < this.fileManagerDiskTotal * 0.05) {
+                warning = `The upload (${this.formatBytes(size)}) will leave only ${this.formatBytes(free - size)} free on the disk.`;
+            }
+            if (warning && !confirm(`${warning} Upload anyway?`)) {
+                return;
+            }
+        }
+
         const formData = new FormData();
         formData.append('path', this.fileManagerCurrentPath);
 
@@ -2577,8 +3556,7 @@ const App = {
             });
 
             if (!response.ok) {
-                const error = await response.text();
-                throw new Error(error || 'Failed to create folder');
+                throw await this.apiError(response, 'Failed to create folder');
             }
 
             this.showToast('Folder created', 'success');
@@ -2612,8 +3590,7 @@ const App = {
             });
 
             if (!response.ok) {
-                const error = await response.text();
-                throw new Error(error || 'Failed to create file');
+                throw await this.apiError(response, 'Failed to create file');
             }
 
             this.showToast('File created', 'success');
@@ -2647,8 +3624,7 @@ const App = {
             });
 
             if (!response.ok) {
-                const error = await response.text();
-                throw new Error(error || 'Failed to rename');
+                throw await this.apiError(response, 'Failed to rename');
             }
 
             this.showToast('Renamed successfully', 'success');
@@ -2724,8 +3700,7 @@ const App = {
                 // Cache miss - fetch from server
                 const response = await this.authFetch(`/api/files/read?path=${encodeURIComponent(path)}`);
                 if (!response.ok) {
-                    const error = await response.text();
-                    throw new Error(error || 'Failed to load file');
+                    throw await this.apiError(response, 'Failed to load file');
                 }
 
                 fileData = await response.json();
@@ -2755,7 +3730,7 @@ const App = {
             }
 
             // NOW we can detect file type and load appropriate viewer
-            const fileType = FileTypeDetector.detect(fileData.name, fileData.mimeType);
+            const fileType = FileTypeDetector.detect(fileData.name, fileData.mimeType, fileData.language);
             const viewerLoaded = await this.loadViewerForFileType(fileType);
 
             if (!viewerLoaded) {
@@ -2777,7 +3752,8 @@ const App = {
             document.getElementById('file-viewer-title').textContent =
                 this.currentFileViewer.canEdit() ? 'Edit File' : 'View File';
             document.getElementById('file-viewer-name').textContent = fileData.name;
-            document.getElementById('file-viewer-type').textContent = FileTypeDetector.getTypeName(viewerFileType.category);
+            document.getElementById('file-viewer-type').textContent = FileTypeDetector.getTypeName(viewerFileType.category) +
+                (fileData.language ? ` (${fileData.language})` : '');
             document.getElementById('file-viewer-size').textContent = this.formatFileSize(fileData.size);
 
             // Render viewer content
@@ -2897,8 +3873,7 @@ const App = {
             });
 
             if (!response.ok) {
-                const error = await response.text();
-                throw new Error(error || 'Failed to save file');
+                throw await this.apiError(response, 'Failed to save file');
             }
 
             const result = await response.json();
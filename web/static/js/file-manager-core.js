@@ -100,9 +100,11 @@ class FileTypeDetector {
         'application/octet-stream': 'binary'
     };
 
-    static detect(filename, mimeType = null) {
+    static detect(filename, mimeType = null, language = null) {
         const extension = this.getExtension(filename);
         let category = this.detectByExtension(extension);
+        // The server knows files like Dockerfile or *.container by name
+        if (!category && language) category = language === 'markdown' ? 'markdown' : 'code';
         if (!category && mimeType) category = this.detectByMimeType(mimeType);
         if (!category) category = 'binary';
         return {
@@ -316,7 +318,7 @@ class FileViewerFactory {
     };
 
     static createViewer(fileData) {
-        const fileType = FileTypeDetector.detect(fileData.name, fileData.mimeType);
+        const fileType = FileTypeDetector.detect(fileData.name, fileData.mimeType, fileData.language);
         if (!fileType.isViewable) {
             return this.createDownloadViewer(fileData, fileType);
         }
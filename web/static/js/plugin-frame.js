@@ -0,0 +1,81 @@
+// Bridge for plugin pages in sandboxed iframes (PODMANVIEW_PLUGIN_SANDBOX).
+// The frame has an opaque origin: no session cookie, no storage and no
+// access to the app. API calls are relayed by the parent page, which adds
+// the session and allows only the plugin's own /api/plugins/<name>/ routes.
+(function() {
+    'use strict';
+
+    const plugin = document.currentScript.dataset.plugin;
+    const pending = new Map();
+    let nextId = 0;
+
+    // Storage throws in an opaque origin; plugins get one for the page's lifetime
+    const memory = new Map();
+    const storage = {
+        getItem: key => memory.has(key) ? memory.get(key) : null,
+        setItem: (key, value) => memory.set(key, String(value)),
+        removeItem: key => memory.delete(key),
+        clear: () => memory.clear()
+    };
+    try {
+        Object.defineProperty(window, 'localStorage', { value: storage });
+    } catch (e) {
+        console.warn('[PluginFrame] localStorage is unavailable:', e);
+    }
+
+    window.addEventListener('message', event => {
+        const data = event.data;
+        if (event.source !== window.parent || !data || data.type !== 'plugin-fetch-result') return;
+
+        const request = pending.get(data.id);
+        if (!request) return;
+        pending.delete(data.id);
+
+        if (data.error) {
+            request.reject(new TypeError(data.error));
+            return;
+        }
+        // Null body statuses can't carry one
+        const body = [204, 205, 304].includes(data.status) ? null : data.body;
+        request.resolve(new Response(body, { status: data.status, headers: data.headers }));
+    });
+
+    window.fetch = function(input, init = {}) {
+        const url = typeof input === 'string' ? input : input.url;
+        if (init.body != null && typeof init.body !== 'string') {
+            return Promise.reject(new TypeError('Only string request bodies are supported in sandboxed plugins'));
+        }
+
+        const headers = {};
+        new Headers(init.headers || {}).forEach((value, name) => {
+            // The parent authenticates with its session
+            if (name !== 'authorization') headers[name] = value;
+        });
+
+        return new Promise((resolve, reject) => {
+            const id = ++nextId;
+            pending.set(id, { resolve, reject });
+            window.parent.postMessage({
+                type: 'plugin-fetch',
+                id,
+                url,
+                method: init.method || 'GET',
+                headers,
+                body: init.body == null ? null : init.body
+            }, '*');
+        });
+    };
+
+    // The subset of App that plugin pages use
+    window.App = {
+        navigateTo: page => window.parent.postMessage({ type: 'plugin-navigate', page }, '*')
+    };
+
+    // The page is shown as soon as the frame loads
+    document.addEventListener('DOMContentLoaded', () => {
+        document.querySelectorAll('.content-page').forEach(page => {
+            page.classList.remove('hidden');
+            page.dispatchEvent(new CustomEvent('plugin-page-shown', { detail: { name: plugin } }));
+        });
+    });
+})();
@@ -44,6 +44,35 @@ const PluginsManager = {
         }
     },
 
+    // Icons by manifest icon name (stroke paths on a 24x24 grid)
+    pluginIcons: {
+        archive: '<rect x="2" y="3" width="20" height="5" rx="1"/><path d="M4 8v11a2 2 0 0 0 2 2h12a2 2 0 0 0 2-2V8"/><line x1="10" y1="12" x2="14" y2="12"/>',
+        code: '<polyline points="16 18 22 12 16 6"/><polyline points="8 6 2 12 8 18"/>',
+        refresh: '<polyline points="23 4 23 10 17 10"/><polyline points="1 20 1 14 7 14"/><path d="M3.51 9a9 9 0 0 1 14.85-3.36L23 10M1 14l4.64 4.36A9 9 0 0 0 20.49 15"/>',
+        thermometer: '<path d="M14 14.76V3.5a2.5 2.5 0 0 0-5 0v11.26a4.5 4.5 0 1 0 5 0z"/>',
+        plugin: '<path d="M12 2L2 7l10 5 10-5-10-5z"/><path d="M2 17l10 5 10-5"/><path d="M2 12l10 5 10-5"/>'
+    },
+
+    // Name and description cells from the plugin's manifest
+    renderPluginInfo(plugin) {
+        const icon = this.pluginIcons[plugin.icon] || this.pluginIcons.plugin;
+        const capabilities = (plugin.capabilities || []).join(', ');
+        return `
+            <td>
+                <div class="plugin-name">
+                    <svg class="plugin-icon" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2">${icon}</svg>
+                    <div>
+                        <strong>${plugin.name}</strong>
+                        <div class="plugin-category">${plugin.category || 'other'}</div>
+                    </div>
+                </div>
+            </td>
+            <td>
+                ${plugin.description || '-'}
+                ${capabilities ? `<div class="plugin-capabilities">Requires: ${capabilities}</div>` : ''}
+            </td>`;
+    },
+
     renderPlugins() {
         const tbody = document.getElementById('plugins-list');
         if (!tbody) return;
@@ -67,10 +96,7 @@ const PluginsManager = {
 
             // Update row content
             row.innerHTML = `
-                <td>
-                    <strong>${plugin.name}</strong>
-                </td>
-                <td>${plugin.description || '-'}</td>
+                ${this.renderPluginInfo(plugin)}
                 <td>
                     <span class="badge">${plugin.version || '-'}</span>
                 </td>
@@ -91,6 +117,7 @@ const PluginsManager = {
                             Settings
                         </button>
                     ` : ''}
+                    <button class="btn btn-sm" onclick="PluginsManager.showPluginLogs('${plugin.name}')">Logs</button>
                 </td>
             `;
 
@@ -195,10 +222,7 @@ const PluginsManager = {
         if (!row) return;
 
         row.innerHTML = `
-            <td>
-                <strong>${plugin.name}</strong>
-            </td>
-            <td>${plugin.description || '-'}</td>
+            ${this.renderPluginInfo(plugin)}
             <td>
                 <span class="badge">${plugin.version || '-'}</span>
             </td>
@@ -219,6 +243,7 @@ const PluginsManager = {
                         Settings
                     </button>
                 ` : ''}
+                <button class="btn btn-sm" onclick="PluginsManager.showPluginLogs('${plugin.name}')">Logs</button>
             </td>
         `;
 
@@ -256,13 +281,11 @@ const PluginsManager = {
                 existingPage.remove();
             }
 
-            // Add cache-busting timestamp
-            const timestamp = new Date().getTime();
-            const response = await fetch(`/api/plugins/${name}/html?v=${timestamp}`, {
+            // Server sends an ETag; the browser revalidates its cached copy
+            const response = await fetch(`/api/plugins/${name}/html`, {
+                cache: 'no-cache',
                 headers: {
-                    'Authorization': 'Bearer ' + localStorage.getItem('token'),
-                    'Cache-Control': 'no-cache, no-store, must-revalidate',
-                    'Pragma': 'no-cache'
+                    'Authorization': 'Bearer ' + localStorage.getItem('token')
                 }
             });
 
@@ -340,6 +363,33 @@ const PluginsManager = {
         }
     },
 
+    // Show the plugin's recent log lines, newest at the bottom
+    async showPluginLogs(name) {
+        const output = document.getElementById('plugin-logs-output');
+        document.getElementById('plugin-logs-title').textContent = `Logs: ${name}`;
+        output.textContent = 'Loading...';
+        App.showModal('modal-plugin-logs');
+
+        try {
+            const response = await App.authFetch(`/api/plugins/${name}/logs?lines=500`);
+            if (!response.ok) throw await App.apiError(response, 'Failed to load plugin logs');
+            const data = await response.json();
+
+            if (data.lines.length === 0) {
+                output.textContent = 'No log output since the server started';
+                return;
+            }
+            const lines = data.lines.map(l => `${new Date(l.time).toLocaleString()}  ${l.message}`);
+            if (data.truncated) lines.unshift('(older lines dropped)');
+            output.textContent = lines.join('\n');
+            output.scrollTop = output.scrollHeight;
+        } catch (error) {
+            if (error.message !== 'Session expired') {
+                output.textContent = error.message;
+            }
+        }
+    },
+
     showRestartWarning() {
         const warning = document.createElement('div');
         warning.className = 'plugin-restart-warning';
@@ -358,9 +408,50 @@ const PluginsManager = {
         } else {
             alert(message);
         }
+    },
+
+    // Relay requests of sandboxed plugin frames (see plugin-frame.js).
+    // The plugin is taken from the frame element, not the message, and may
+    // only call its own API routes.
+    async handleFrameMessage(event) {
+        const frame = Array.from(document.querySelectorAll('iframe.plugin-frame'))
+            .find(f => f.contentWindow === event.source);
+        const data = event.data;
+        if (!frame || !data) return;
+        const name = frame.dataset.plugin;
+
+        if (data.type === 'plugin-navigate') {
+            if (typeof data.page === 'string') App.navigateTo(data.page);
+            return;
+        }
+        if (data.type !== 'plugin-fetch') return;
+
+        const reply = result => event.source.postMessage({ type: 'plugin-fetch-result', id: data.id, ...result }, '*');
+        const url = new URL(String(data.url), window.location.origin);
+        if (url.origin !== window.location.origin || !url.pathname.startsWith(`/api/plugins/${encodeURIComponent(name)}/`)) {
+            reply({ error: `Plugin ${name} may only call /api/plugins/${name}/` });
+            return;
+        }
+
+        try {
+            const response = await App.authFetch(url.pathname + url.search, {
+                method: String(data.method || 'GET'),
+                headers: data.headers || {},
+                body: typeof data.body === 'string' ? data.body : undefined
+            });
+            reply({
+                status: response.status,
+                headers: { 'Content-Type': response.headers.get('Content-Type') || '' },
+                body: await response.text()
+            });
+        } catch (error) {
+            reply({ error: error.message });
+        }
     }
 };
 
+window.addEventListener('message', event => PluginsManager.handleFrameMessage(event));
+
 document.addEventListener('DOMContentLoaded', function() {
     const pluginsPage = document.getElementById('page-plugins');
     if (!pluginsPage) return;
@@ -23,6 +23,8 @@ class TextViewer extends BaseFileViewer {
         this.textarea.className = 'file-viewer-textarea';
         this.textarea.value = this.fileData.content;
         this.textarea.spellcheck = false;
+        // Language from the server, for highlighting
+        this.textarea.dataset.language = this.fileData.language || 'plaintext';
 
         // Store bound handlers for proper cleanup
         this.boundInputHandler = () => {
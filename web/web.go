@@ -0,0 +1,10 @@
+// Package web embeds the frontend assets (static files and page templates)
+// so the binary can run from any working directory.
+package web
+
+import "embed"
+
+// FS holds the static/ and templates/ directories
+//
+//go:embed static templates
+var FS embed.FS